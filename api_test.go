@@ -5,9 +5,29 @@
 package feather_test
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/feather-lang/feather"
 )
@@ -29,6 +49,125 @@ func TestInterpreterLifecycle(t *testing.T) {
 	}
 }
 
+func TestClose(t *testing.T) {
+	interp := feather.New()
+
+	type File struct{ name string }
+	var events []string
+	feather.RegisterType[*File](interp, "File", feather.TypeDef[*File]{
+		New:     func() *File { return &File{} },
+		Destroy: func(*File) { events = append(events, "destroy") },
+	})
+	interp.OnClose(func() { events = append(events, "first") })
+	interp.OnClose(func() { events = append(events, "second") })
+	interp.MustEval("File new; File new; [File new] destroy")
+	if len(events) != 1 {
+		t.Fatalf("events before Close = %q", events)
+	}
+
+	interp.Close()
+	want := []string{"destroy", "destroy", "destroy", "second", "first"}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %q; want %q", events, want)
+	}
+}
+
+// debugBuild is set when the tests are built with the featherdebug tag.
+var debugBuild bool
+
+func TestUseAfterClose(t *testing.T) {
+	if debugBuild {
+		t.Skip("featherdebug builds panic instead of returning errors")
+	}
+	interp := feather.New()
+	n := interp.Int(42)
+	list := interp.String("a b c")
+	interp.Close()
+	interp.Close()
+
+	if _, err := interp.Eval("set x 1"); !errors.Is(err, feather.ErrInterpClosed) {
+		t.Errorf("Eval after Close: err = %v; want ErrInterpClosed", err)
+	}
+	if _, err := interp.Call("llength", list); !errors.Is(err, feather.ErrInterpClosed) {
+		t.Errorf("Call after Close: err = %v; want ErrInterpClosed", err)
+	}
+	if _, err := interp.EvalTimeout("set x 1", time.Second); !errors.Is(err, feather.ErrInterpClosed) {
+		t.Errorf("EvalTimeout after Close: err = %v; want ErrInterpClosed", err)
+	}
+	if _, err := n.Int(); !errors.Is(err, feather.ErrInterpClosed) {
+		t.Errorf("Int after Close: err = %v; want ErrInterpClosed", err)
+	}
+	if _, err := list.List(); !errors.Is(err, feather.ErrInterpClosed) {
+		t.Errorf("List after Close: err = %v; want ErrInterpClosed", err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	type Conn struct{}
+	destroyed := 0
+	feather.RegisterType[*Conn](interp, "Conn", feather.TypeDef[*Conn]{
+		New:     func() *Conn { return &Conn{} },
+		Destroy: func(*Conn) { destroyed++ },
+	})
+	interp.Register("greet", func(name string) string { return "hello " + name })
+	interp.RegisterEnsemble("geo", map[string]any{"add": func(a, b int) int { return a + b }})
+	inits := 0
+	interp.RegisterPackage("lib", "1.0", func(*feather.Interp) error {
+		inits++
+		return nil
+	})
+	commands := interp.CommandNames("")
+
+	interp.MustEval(`
+		proc double {x} { expr {$x * 2} }
+		set counter 1
+		namespace eval tenant { variable secret 42 }
+		rename puts say
+		rename greet {}
+		trace add variable counter write {apply {args {}}}
+		after 1000 {set late 1}
+		set c [Conn new]
+		package require lib
+		nproc opts {-v} { set v }
+	`)
+	interp.SetVar("fromGo", 1)
+	interp.Reset()
+
+	if got := interp.CommandNames(""); !slices.Equal(got, commands) {
+		t.Errorf("commands after Reset = %v; want %v", got, commands)
+	}
+	if got := interp.MustEval("list [lsort [info globals]] [namespace exists tenant] [after info]").String(); got != "{env tcl_patchLevel tcl_platform tcl_version} 0 {}" {
+		t.Errorf("globals, tenant namespace and after events = %q; want {env tcl_patchLevel tcl_platform tcl_version} 0 {}", got)
+	}
+	if destroyed != 1 || len(interp.ForeignInstances()) != 0 {
+		t.Errorf("destroyed %d objects, %d left; want 1, 0", destroyed, len(interp.ForeignInstances()))
+	}
+	if got := interp.MustEval("greet you; geo add 1 2").String(); got != "3" {
+		t.Errorf("geo add 1 2 = %q; want 3", got)
+	}
+	if got := interp.MustEval("nproc opts {-w} { set w }; opts -w").String(); got != "1" {
+		t.Errorf("nproc after Reset = %q; want 1", got)
+	}
+	if got := interp.MustEval("set counter 2; Conn new").String(); got != "conn1" {
+		t.Errorf("first object after Reset = %q; want conn1", got)
+	}
+	interp.MustEval("package require lib")
+	if inits != 2 {
+		t.Errorf("package init ran %d times; want 2", inits)
+	}
+
+	if err := interp.Unregister("greet"); err != nil {
+		t.Fatal(err)
+	}
+	interp.Reset()
+	if got := interp.CommandNames("greet"); len(got) != 0 {
+		t.Errorf("Reset restored the unregistered command greet")
+	}
+}
+
 // =============================================================================
 // Constructing Values - Primitives
 // =============================================================================
@@ -86,6 +225,51 @@ func TestConstructPrimitives(t *testing.T) {
 			t.Errorf("Bool() = %v, %v; want false, nil", b, err)
 		}
 	})
+
+	t.Run("ByteArray", func(t *testing.T) {
+		data := []byte{0x00, 0xff, 0x80, 'a'}
+		b := interp.ByteArray(data)
+		if b.Type() != "bytearray" {
+			t.Errorf("expected type 'bytearray', got %q", b.Type())
+		}
+		if got := b.String(); got != "\x00\u00ff\u0080a" {
+			t.Errorf("String() = %q; want one character per byte", got)
+		}
+
+		result, err := interp.Eval(`binary format H* 00ff8061`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if got := result.ByteArray(); !bytes.Equal(got, data) {
+			t.Errorf("ByteArray() = %v; want %v", got, data)
+		}
+	})
+
+	t.Run("BigInt", func(t *testing.T) {
+		v, _ := new(big.Int).SetString("18446744073709551616", 10)
+		n := interp.BigInt(v)
+		if n.Type() != "bignum" {
+			t.Errorf("expected type 'bignum', got %q", n.Type())
+		}
+		if _, err := n.Int(); err == nil {
+			t.Error("Int() should fail for values beyond int64")
+		}
+		if small := interp.BigInt(big.NewInt(42)); small.Type() != "int" {
+			t.Errorf("expected small value to be 'int', got %q", small.Type())
+		}
+
+		result, err := interp.Eval("expr {2**64 * 2}")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		got, err := result.BigInt()
+		if err != nil {
+			t.Fatalf("BigInt() failed: %v", err)
+		}
+		if want := new(big.Int).Lsh(v, 1); got.Cmp(want) != 0 {
+			t.Errorf("BigInt() = %v; want %v", got, want)
+		}
+	})
 }
 
 // =============================================================================
@@ -183,6 +367,122 @@ func TestConstructDicts(t *testing.T) {
 	})
 }
 
+// =============================================================================
+// Constructing Values - Go Values
+// =============================================================================
+
+type celsius float64
+
+func (c celsius) FeatherValue(i *feather.Interp) *feather.Obj {
+	return i.String(fmt.Sprintf("%.1fC", float64(c)))
+}
+
+func (c *celsius) FeatherUnmarshal(obj *feather.Obj) error {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(obj.String(), "C"), 64)
+	*c = celsius(f)
+	return err
+}
+
+func TestConstructGoValues(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	type Address struct {
+		City string `feather:"city"`
+		Zip  string `feather:"zip"`
+	}
+	type User struct {
+		Name    string            `feather:"name"`
+		Tags    []string          `feather:"tags"`
+		Home    *Address          `feather:"home"`
+		Meta    map[string]any    `feather:"meta"`
+		Labels  map[string]string `feather:"labels"`
+		Created time.Time         `feather:"created"`
+		Temp    celsius           `feather:"temp"`
+		secret  string
+	}
+
+	user := User{
+		Name:    "alice",
+		Tags:    []string{"admin", "dev ops"},
+		Home:    &Address{City: "Berlin", Zip: "10115"},
+		Meta:    map[string]any{"b": []int{1, 2}, "a": true},
+		Labels:  map[string]string{"team": "core"},
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Temp:    21.5,
+		secret:  "hidden",
+	}
+	want := "name alice tags {admin {dev ops}} home {city Berlin zip 10115} " +
+		"meta {a 1 b {1 2}} labels {team core} created 2024-01-02T03:04:05Z temp 21.5C"
+
+	t.Run("Value deep-converts structs", func(t *testing.T) {
+		obj := interp.Value(user)
+		if obj.String() != want {
+			t.Errorf("Value = %q; want %q", obj.String(), want)
+		}
+		if obj.Type() != "dict" {
+			t.Errorf("expected type 'dict', got %q", obj.Type())
+		}
+	})
+
+	t.Run("Value nil pointer", func(t *testing.T) {
+		var a *Address
+		if s := interp.Value(a).String(); s != "" {
+			t.Errorf("Value(nil) = %q; want empty", s)
+		}
+	})
+
+	t.Run("OK converts through the interpreter", func(t *testing.T) {
+		interp.RegisterCommand("get-user", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.OK(user)
+		})
+		result, err := interp.Eval("dict get [get-user] home city")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "Berlin" {
+			t.Errorf("home city = %q; want 'Berlin'", result.String())
+		}
+		result, err = interp.Eval("lindex [dict get [get-user] tags] 1")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "dev ops" {
+			t.Errorf("tags[1] = %q; want 'dev ops'", result.String())
+		}
+	})
+
+	t.Run("Value unsigned integers past int64", func(t *testing.T) {
+		obj := interp.Value(map[string]uint64{"max": math.MaxUint64, "small": 7})
+		if obj.String() != "max 18446744073709551615 small 7" {
+			t.Errorf("Value = %q", obj.String())
+		}
+		interp.Register("umax", func() uint64 { return math.MaxUint64 })
+		result, err := interp.Eval("list [umax] [expr {[umax] > 0}]")
+		if err != nil || result.String() != "18446744073709551615 1" {
+			t.Errorf("umax: %v, %v; want 18446744073709551615 1", result, err)
+		}
+	})
+
+	t.Run("Value byte slices", func(t *testing.T) {
+		data := []byte{'h', 'i', 0, 0xff}
+		obj := interp.Value(struct {
+			Data []byte `feather:"data"`
+		}{data})
+		got, err := obj.Dict()
+		if err != nil {
+			t.Fatalf("Dict failed: %v", err)
+		}
+		if v := got.Items["data"]; v.Type() != "bytearray" || !bytes.Equal(v.ByteArray(), data) {
+			t.Errorf("data = %s %q; want a bytearray of %q", v.Type(), v.String(), data)
+		}
+		data[0] = 'H'
+		if v := got.Items["data"]; v.ByteArray()[0] != 'h' {
+			t.Errorf("the bytearray shares memory with the slice")
+		}
+	})
+}
+
 // =============================================================================
 // Reading Values Back
 // =============================================================================
@@ -269,6 +569,120 @@ func TestReadingValues(t *testing.T) {
 			t.Errorf("dict[a] = %q; want '1'", d.Items["a"].String())
 		}
 	})
+
+	t.Run("IntSlice and FloatSlice round trip", func(t *testing.T) {
+		ints := interp.IntSlice([]int64{3, 1, 2})
+		if ints.String() != "3 1 2" {
+			t.Errorf("IntSlice String() = %q; want '3 1 2'", ints.String())
+		}
+		got, err := feather.AsIntSlice(ints)
+		if err != nil || !slices.Equal(got, []int64{3, 1, 2}) {
+			t.Errorf("AsIntSlice = %v, %v; want [3 1 2]", got, err)
+		}
+
+		result, err := interp.Call("lsort", "-real", interp.FloatSlice([]float64{1.5, 2, 0.5}))
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		floats, err := feather.AsFloatSlice(result)
+		if err != nil || !slices.Equal(floats, []float64{0.5, 1.5, 2}) {
+			t.Errorf("AsFloatSlice = %v, %v; want [0.5 1.5 2]", floats, err)
+		}
+	})
+
+	t.Run("AsIntSlice from script list", func(t *testing.T) {
+		got, err := feather.AsIntSlice(interp.MustEval("list 1 -2 3"))
+		if err != nil || !slices.Equal(got, []int64{1, -2, 3}) {
+			t.Errorf("AsIntSlice = %v, %v; want [1 -2 3]", got, err)
+		}
+		_, err = feather.AsIntSlice(interp.String("1 two 3"))
+		if err == nil || err.Error() != `list element 1: expected integer but got "two"` {
+			t.Errorf("AsIntSlice error = %v", err)
+		}
+		if _, err := feather.AsFloatSlice(interp.String("1 {2")); err == nil {
+			t.Error("AsFloatSlice should fail for a malformed list")
+		}
+	})
+
+	t.Run("AsStringSlice and AsStringMap", func(t *testing.T) {
+		got, err := feather.AsStringSlice(interp.MustEval("list a {b c} 3"))
+		if err != nil || !slices.Equal(got, []string{"a", "b c", "3"}) {
+			t.Errorf("AsStringSlice = %q, %v", got, err)
+		}
+		m, err := feather.AsStringMap(interp.MustEval("dict create a 1 b {x y}"))
+		if err != nil || len(m) != 2 || m["a"] != "1" || m["b"] != "x y" {
+			t.Errorf("AsStringMap = %v, %v", m, err)
+		}
+		if _, err := feather.AsStringMap(interp.String("a b c")); err == nil {
+			t.Error("AsStringMap should fail for an odd-length list")
+		}
+	})
+
+	t.Run("Into", func(t *testing.T) {
+		type Listener struct {
+			Port int    `feather:"port"`
+			TLS  bool   `feather:"tls"`
+			Addr string `feather:"-"`
+		}
+		type Server struct {
+			Host      string            `feather:"host"`
+			Listeners []Listener        `feather:"listeners"`
+			Limits    map[string]uint16 `feather:"limits"`
+			Backup    *Server           `feather:"backup"`
+			Started   time.Time         `feather:"started"`
+			Temp      celsius           `feather:"temp"`
+			Raw       *feather.Obj      `feather:"raw"`
+			Weights   [2]float64        `feather:"weights"`
+		}
+		s := Server{Host: "unchanged"}
+		err := feather.Into(interp.MustEval(`dict create listeners {{port 80 tls no} {port 443 tls yes addr x}} `+
+			`limits {conns 100} backup {host b.example.com} started 2024-01-02T03:04:05Z temp 21.5C `+
+			`raw {1 2 3} weights {0.5 1} extra ignored`), &s)
+		if err != nil {
+			t.Fatalf("Into: %v", err)
+		}
+		want := Server{
+			Host:      "unchanged",
+			Listeners: []Listener{{Port: 80}, {Port: 443, TLS: true}},
+			Limits:    map[string]uint16{"conns": 100},
+			Backup:    &Server{Host: "b.example.com"},
+			Started:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Temp:      21.5,
+			Weights:   [2]float64{0.5, 1},
+		}
+		raw := s.Raw
+		s.Raw = nil
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("Into = %+v; want %+v", s, want)
+		}
+		if raw == nil || raw.String() != "1 2 3" {
+			t.Errorf("Raw = %v; want 1 2 3", raw)
+		}
+
+		var round Server
+		want.Backup = nil
+		err = feather.Into(interp.Value(want), &round)
+		round.Raw = nil
+		if err != nil || !reflect.DeepEqual(round, want) {
+			t.Errorf("round trip = %+v, %v", round, err)
+		}
+
+		errs := map[string]string{
+			"listeners {{port x}}": `field "listeners": element 0: field "port": expected integer but got "x"`,
+			"limits {conns -1}":    `field "limits": value for key "conns": expected unsigned integer but got -1`,
+			"limits {conns 70000}": `field "limits": value for key "conns": integer value 70000 out of range`,
+			"weights {1 2 3}":      `field "weights": expected 2 elements but got 3`,
+		}
+		for script, want := range errs {
+			var s Server
+			if err := feather.Into(interp.String(script), &s); err == nil || err.Error() != want {
+				t.Errorf("Into(%s) error = %v; want %q", script, err, want)
+			}
+		}
+		if err := feather.Into(interp.String("1"), s); err == nil {
+			t.Error("Into should fail for a non-pointer")
+		}
+	})
 }
 
 // =============================================================================
@@ -337,6 +751,59 @@ func TestVariables(t *testing.T) {
 			t.Error("GetVars failed")
 		}
 	})
+
+	t.Run("GetVarObj and SetVarObj", func(t *testing.T) {
+		interp.MustEval("namespace eval cfg { variable port 8080 }")
+		port, ok := interp.GetVarObj("::cfg::port")
+		if !ok || port.String() != "8080" {
+			t.Errorf("GetVarObj(::cfg::port) = %v, %v; want 8080, true", port, ok)
+		}
+		if _, ok := interp.GetVarObj("missing"); ok {
+			t.Error("GetVarObj(missing) reported true")
+		}
+
+		if err := interp.SetVarObj("cfg::hosts", interp.List(interp.String("a"), interp.String("b"))); err != nil {
+			t.Fatalf("SetVarObj: %v", err)
+		}
+		hosts, _ := interp.GetVarObj("::cfg::hosts")
+		if hosts.Type() != "list" {
+			t.Errorf("type = %q; want list", hosts.Type())
+		}
+		if got := interp.MustEval("namespace eval cfg { llength $hosts }").String(); got != "2" {
+			t.Errorf("llength = %q; want 2", got)
+		}
+
+		interp.MustEval("trace add variable traced write {apply {args {error denied}}}")
+		if err := interp.SetVarObj("traced", interp.Int(1)); err == nil || err.Error() != `can't set "traced": denied` {
+			t.Errorf("SetVarObj(traced) error = %v", err)
+		}
+	})
+
+	t.Run("VarAtLevel", func(t *testing.T) {
+		var seen []string
+		interp.RegisterCommand("peek", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			level := len(i.Frames()) - 2
+			v, ok := i.VarAtLevel(level, "x")
+			seen = append(seen, fmt.Sprint(v, ok))
+			if err := i.SetVarAtLevel(level, "x", i.Int(7)); err != nil {
+				return feather.Error(err.Error())
+			}
+			return feather.OK("")
+		})
+		interp.MustEval("proc outer {} { set x 1; inner; return $x }; proc inner {} { set x 2; peek }")
+		if got := interp.MustEval("outer").String(); got != "7" {
+			t.Errorf("outer = %q; want 7", got)
+		}
+		if got := strings.Join(seen, ","); got != "1 true" {
+			t.Errorf("VarAtLevel = %q; want %q", got, "1 true")
+		}
+		if _, ok := interp.VarAtLevel(5, "x"); ok {
+			t.Error("VarAtLevel(5) reported true")
+		}
+		if err := interp.SetVarAtLevel(-1, "x", interp.Int(1)); err == nil || err.Error() != `bad level "-1"` {
+			t.Errorf("SetVarAtLevel(-1) error = %v", err)
+		}
+	})
 }
 
 // =============================================================================
@@ -455,6 +922,295 @@ func TestCommandsRaw(t *testing.T) {
 			t.Errorf("age = %q; want '30'", d.Items["age"].String())
 		}
 	})
+
+	t.Run("GetIndexFromObj", func(t *testing.T) {
+		table := []string{"get", "getall", "incr", "reset"}
+		for _, tc := range []struct {
+			word string
+			want int
+			err  string
+		}{
+			{"get", 0, ""},
+			{"geta", 1, ""},
+			{"i", 2, ""},
+			{"reset", 3, ""},
+			{"ge", -1, `ambiguous subcommand "ge": must be get, getall, incr, or reset`},
+			{"bogus", -1, `bad subcommand "bogus": must be get, getall, incr, or reset`},
+			{"", -1, `bad subcommand "": must be get, getall, incr, or reset`},
+		} {
+			obj := interp.String(tc.word)
+			got, err := interp.GetIndexFromObj(obj, table, "subcommand")
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("GetIndexFromObj(%q) error = %v; want %q", tc.word, err, tc.err)
+				}
+				continue
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("GetIndexFromObj(%q) = %d, %v; want %d", tc.word, got, err, tc.want)
+			}
+			if obj.Type() != "index" {
+				t.Errorf("GetIndexFromObj(%q) left type %q; want 'index'", tc.word, obj.Type())
+			}
+			// A second lookup is served from the cached index
+			if again, _ := interp.GetIndexFromObj(obj, table, "subcommand"); again != tc.want {
+				t.Errorf("cached GetIndexFromObj(%q) = %d; want %d", tc.word, again, tc.want)
+			}
+			if obj.String() != tc.word {
+				t.Errorf("String() = %q; want %q", obj.String(), tc.word)
+			}
+		}
+
+		// The same object looked up in another table is resolved afresh
+		obj := interp.String("reset")
+		interp.GetIndexFromObj(obj, table, "subcommand")
+		if got, err := interp.GetIndexFromObj(obj, []string{"clear", "reset"}, "option"); err != nil || got != 1 {
+			t.Errorf("GetIndexFromObj in second table = %d, %v; want 1", got, err)
+		}
+	})
+
+	t.Run("Args", func(t *testing.T) {
+		interp.RegisterCommand("fetch", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			a, err := feather.Args(cmd, args).
+				Flag("-force").
+				Option("-timeout", feather.Double, 30).
+				Require("url", feather.String).
+				Optional("retries", feather.Int, 1).
+				Rest("headers", feather.String).
+				Parse()
+			if err != nil {
+				return feather.Error(err.Error())
+			}
+			return feather.OK(fmt.Sprintf("%s %d %g %v %v %v", a.String("url"), a.Int("retries"), a.Double("-timeout"), a.Bool("-force"), a.Has("retries"), a.Rest("headers")))
+		})
+		for _, tc := range []struct{ script, want string }{
+			{"fetch /a", "/a 1 30 false false []"},
+			{"fetch -f -time 2.5 /a 3 x y", "/a 3 2.5 true true [x y]"},
+			{"fetch -- -a", "-a 1 30 false false []"},
+			{"fetch -5", "-5 1 30 false false []"},
+			{"fetch -timeout", "-timeout 1 30 false false []"},
+		} {
+			if got, err := interp.Eval(tc.script); err != nil || got.String() != tc.want {
+				t.Errorf("%s = %v, %v; want %q", tc.script, got, err, tc.want)
+			}
+		}
+		for _, tc := range []struct{ script, want string }{
+			{"fetch", `wrong # args: should be "fetch ?-force? ?-timeout timeout? url ?retries? ?headers ...?"`},
+			{"fetch -x /a", `bad option "-x": must be -force or -timeout`},
+			{"fetch /a many", `expected integer but got "many"`},
+			{"fetch -timeout 1", `wrong # args: should be "fetch ?-force? ?-timeout timeout? url ?retries? ?headers ...?"`},
+		} {
+			if _, err := interp.Eval(tc.script); err == nil || err.Error() != tc.want {
+				t.Errorf("%s: error = %v; want %q", tc.script, err, tc.want)
+			}
+		}
+	})
+
+	t.Run("ParseOptions", func(t *testing.T) {
+		args := []*feather.Obj{interp.String("-v"), interp.String("-port"), interp.String("9000"), interp.String("file")}
+		opts, rest, err := interp.ParseOptions(args, []feather.OptionSpec{
+			{Name: "-port", Type: feather.Int, Default: 8080},
+			{Name: "-verbose", Flag: true},
+			{Name: "-tags", Type: feather.List},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.Int("-port") != 9000 || !opts.Bool("-verbose") || opts.Has("-tags") || len(rest) != 1 || rest[0].String() != "file" {
+			t.Errorf("ParseOptions = %v %v %v, rest %v", opts.Int("-port"), opts.Bool("-verbose"), opts.Has("-tags"), rest)
+		}
+	})
+
+	t.Run("EvalAtLevel", func(t *testing.T) {
+		interp.RegisterCommand("repeat", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			n, err := args[0].Int()
+			if err != nil {
+				return feather.Error(err.Error())
+			}
+			level := i.CallerFrame().Level
+			for range n {
+				r := i.EvalAtLevel(level, args[1].String())
+				if r.Code() == feather.ResultBreak {
+					break
+				}
+				if r.Code() != feather.ResultOK && r.Code() != feather.ResultContinue {
+					return r
+				}
+			}
+			return feather.OK("")
+		})
+		got, err := interp.Eval(`
+			proc count {} {
+				set n 0
+				repeat 10 {
+					incr n
+					if {$n == 2} continue
+					if {$n == 4} break
+				}
+				return $n
+			}
+			list [count] [catch {repeat 1 {error boom}} msg] $msg
+		`)
+		if err != nil || got.String() != "4 1 boom" {
+			t.Errorf("repeat = %v, %v; want 4 1 boom", got, err)
+		}
+
+		interp.RegisterCommand("frame-pair", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			level := i.CallerFrame().Level
+			v, err := i.EvalInFrame(level, "set v")
+			if err != nil {
+				return feather.Error(err.Error())
+			}
+			_, err = i.EvalInFrame(level+1, "set v")
+			return feather.OK(i.List(v, i.String(err.Error())))
+		})
+		got, err = interp.Eval(`proc f {} { set v local; frame-pair }; f`)
+		if err != nil || got.String() != `local {bad level "2"}` {
+			t.Errorf("frame-pair = %v, %v; want local {bad level \"2\"}", got, err)
+		}
+	})
+
+	t.Run("ControlFlowResults", func(t *testing.T) {
+		interp.RegisterCommand("stop", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.Break()
+		})
+		interp.RegisterCommand("skip", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.Continue()
+		})
+		interp.RegisterCommand("leave", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			n, _ := args[0].Int()
+			return feather.Return(args[1], feather.ResultOK, int(n))
+		})
+		interp.RegisterCommand("custom", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.Return([]int{1, 2}, 5, 0)
+		})
+		got, err := interp.Eval(`
+			set seen {}
+			foreach x {1 2 3 4} {
+				if {$x == 2} skip
+				if {$x == 4} stop
+				lappend seen $x
+			}
+			proc inner {} { leave 2 early; return late }
+			proc outer {} { inner; return late }
+			set code [catch custom msg]
+			list $seen [outer] $code $msg
+		`)
+		if err != nil || got.String() != "{1 3} early 5 {1 2}" {
+			t.Errorf("control flow = %v, %v; want {{1 3} early 5 {1 2}}", got, err)
+		}
+		if r := feather.Break(); r.Code() != feather.ResultBreak {
+			t.Errorf("Break().Code() = %d", r.Code())
+		}
+	})
+
+	t.Run("Throw", func(t *testing.T) {
+		interp.RegisterCommand("fetch", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.Throw([]string{"HTTP", "404"}, "not found: "+args[0].String())
+		})
+		got, err := interp.Eval(`try { fetch /x } trap {HTTP 404} {msg} { set msg }`)
+		if err != nil || got.String() != "not found: /x" {
+			t.Errorf("trap = %v, %v; want not found: /x", got, err)
+		}
+		_, err = interp.Eval(`fetch /y`)
+		var evalErr *feather.EvalError
+		if !errors.As(err, &evalErr) || evalErr.ErrorCode != "HTTP 404" {
+			t.Fatalf("fetch error = %#v; want ErrorCode HTTP 404", err)
+		}
+		if !strings.Contains(evalErr.ErrorInfo, `while executing`) {
+			t.Errorf("ErrorInfo = %q; want the command that failed", evalErr.ErrorInfo)
+		}
+	})
+
+	t.Run("LinkVar", func(t *testing.T) {
+		interp.RegisterCommand("shared", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			if err := i.LinkVar(args[0].String(), 0, "shared_"+args[0].String()); err != nil {
+				return feather.Error(err.Error())
+			}
+			return feather.OK(i.CallerFrame().Command)
+		})
+		got, err := interp.Eval(`
+			proc bump {} {
+				set caller [shared hits]
+				incr hits
+				return $caller
+			}
+			bump; bump
+			list [bump] $shared_hits
+		`)
+		if err != nil || got.String() != "::bump 3" {
+			t.Errorf("shared = %v, %v; want {::bump 3}", got, err)
+		}
+	})
+}
+
+func TestOnUnknown(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	var missing []string
+	interp.OnUnknown(func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+		missing = append(missing, cmd.String())
+		if cmd.String() != "hello" {
+			return feather.Errorf("invalid command name \"%s\"", cmd.String())
+		}
+		// Autoload the command, then run it
+		i.MustEval(`proc hello {name} { return "hello $name" }`)
+		result, err := i.EvalObj(i.List(append([]*feather.Obj{cmd}, args...)...))
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK(result)
+	})
+
+	if got := interp.MustEval(`list [hello world] [hello again]`).String(); got != "{hello world} {hello again}" {
+		t.Errorf("autoloaded = %q", got)
+	}
+	if !slices.Equal(missing, []string{"hello"}) {
+		t.Errorf("handler called for %v; want hello once", missing)
+	}
+	if _, err := interp.Eval(`nosuch`); err == nil || err.Error() != `invalid command name "nosuch"` {
+		t.Errorf("nosuch = %v", err)
+	}
+
+	t.Run("unknown proc takes precedence", func(t *testing.T) {
+		result := interp.MustEval(`
+			proc unknown {cmd args} {
+				set matches [info commands $cmd*]
+				if {[llength $matches] != 1} {
+					error "invalid command name \"$cmd\""
+				}
+				uplevel 1 [list [lindex $matches 0] {*}$args]
+			}
+			set r [hell there]
+			rename unknown {}
+			set r
+		`)
+		if result.String() != "hello there" {
+			t.Errorf("abbreviation = %q; want hello there", result.String())
+		}
+	})
+
+	t.Run("unknown command from Go", func(t *testing.T) {
+		interp.Register("unknown", func(cmd string, args ...string) string {
+			return "go handled " + cmd
+		})
+		defer interp.UnregisterCommand("unknown")
+		if got := interp.MustEval(`frobnicate 1 2`).String(); got != "go handled frobnicate" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		interp.OnUnknown(nil)
+		missing = nil
+		if _, err := interp.Eval(`other`); err == nil || err.Error() != `invalid command name "other"` {
+			t.Errorf("other = %v", err)
+		}
+		if missing != nil {
+			t.Errorf("removed handler called for %v", missing)
+		}
+	})
 }
 
 // =============================================================================
@@ -558,10 +1314,321 @@ func TestCommandsTyped(t *testing.T) {
 			t.Error("sideeffect was not called")
 		}
 	})
-}
 
-// =============================================================================
-// Call - Direct Command Invocation
+	t.Run("Register numeric slices", func(t *testing.T) {
+		interp.Register("scale", func(xs []float64, factor int) []float64 {
+			out := make([]float64, len(xs))
+			for j, x := range xs {
+				out[j] = x * float64(factor)
+			}
+			return out
+		})
+		interp.Register("sum", func(xs []int) int {
+			total := 0
+			for _, x := range xs {
+				total += x
+			}
+			return total
+		})
+
+		result, err := interp.Eval("scale {1.5 2} 2")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "3.0 4.0" {
+			t.Errorf("scale = %q; want '3.0 4.0'", result.String())
+		}
+
+		result, err = interp.Eval("sum {1 2 3}")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "6" {
+			t.Errorf("sum = %q; want '6'", result.String())
+		}
+
+		_, err = interp.Eval("sum {1 x 3}")
+		if err == nil {
+			t.Error("expected error for non-integer element")
+		}
+	})
+
+	t.Run("Register maps", func(t *testing.T) {
+		interp.Register("keys-of", func(m map[string]any) []string {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return keys
+		})
+		interp.Register("upper-values", func(m map[string]string) map[string]string {
+			for k, v := range m {
+				m[k] = strings.ToUpper(v)
+			}
+			return m
+		})
+
+		result, err := interp.Eval("keys-of {b 1 a 2}")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "a b" {
+			t.Errorf("keys-of = %q; want 'a b'", result.String())
+		}
+
+		result, err = interp.Eval("upper-values {y hello x world}")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "x WORLD y HELLO" {
+			t.Errorf("upper-values = %q; want 'x WORLD y HELLO'", result.String())
+		}
+	})
+
+	t.Run("Register structs", func(t *testing.T) {
+		type Point struct {
+			X, Y int
+		}
+		type Shape struct {
+			Name   string  `feather:"name"`
+			Points []Point `feather:"points"`
+			Scale  float64 `feather:"scale"`
+			Secret string  `feather:"-"`
+		}
+
+		interp.Register("shift", func(p Point, dx int) Point {
+			return Point{X: p.X + dx, Y: p.Y}
+		})
+		interp.Register("make-shape", func(name string) *Shape {
+			return &Shape{Name: name, Points: []Point{{1, 2}, {3, 4}}, Scale: 1, Secret: "hidden"}
+		})
+		interp.Register("shape-size", func(s *Shape) int {
+			return len(s.Points)
+		})
+
+		result, err := interp.Eval("shift {X 1 Y 2} 10")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "X 11 Y 2" {
+			t.Errorf("shift = %q; want 'X 11 Y 2'", result.String())
+		}
+
+		result, err = interp.Eval("make-shape tri")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		want := "name tri points {{X 1 Y 2} {X 3 Y 4}} scale 1.0"
+		if result.String() != want {
+			t.Errorf("make-shape = %q; want %q", result.String(), want)
+		}
+
+		result, err = interp.Eval("shape-size [make-shape tri]")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "2" {
+			t.Errorf("shape-size = %q; want '2'", result.String())
+		}
+
+		_, err = interp.Eval("shift {X one} 1")
+		if err == nil || !strings.Contains(err.Error(), `field "X"`) {
+			t.Errorf("expected field error, got %v", err)
+		}
+	})
+
+	t.Run("Register argument ranges", func(t *testing.T) {
+		interp.Register("i8", func(v int8) int8 { return v })
+		interp.Register("u8", func(v uint8) uint8 { return v })
+		interp.Register("u64", func(v uint64) uint64 { return v })
+		interp.Register("f32", func(v float32) float32 { return v })
+		interp.Register("small", func(p struct{ X int8 }) int8 { return p.X })
+		interp.Register("bytes", func(b []byte) int { return len(b) })
+
+		tests := []struct {
+			script, want, err string
+		}{
+			{script: "i8 -128", want: "-128"},
+			{script: "i8 300", err: "argument 1: integer value 300 out of range"},
+			{script: "i8 -129", err: "argument 1: integer value -129 out of range"},
+			{script: "u8 255", want: "255"},
+			{script: "u8 256", err: "argument 1: integer value 256 out of range"},
+			{script: "u8 -1", err: "argument 1: expected unsigned integer but got -1"},
+			{script: "u64 18446744073709551615", want: "18446744073709551615"},
+			{script: "u64 18446744073709551616", err: "argument 1: integer value 18446744073709551616 out of range"},
+			{script: "u64 [u64 18446744073709551615]", want: "18446744073709551615"},
+			{script: "f32 1e39", err: "argument 1: floating-point value 1e+39 out of range"},
+			{script: "small {X 500}", err: `argument 1: field "X": integer value 500 out of range`},
+			{script: "bytes abc", want: "3"},
+			{script: "bytes [binary format H4 ff00]", want: "2"},
+		}
+		interp.Register("obj-type", func(o *feather.Obj) string { return o.Type() })
+		interp.Register("opt-point", func(p *struct{ X int }) bool { return p == nil })
+		interp.Register("year", func(t time.Time) int { return t.Year() })
+		tests = append(tests, []struct{ script, want, err string }{
+			{script: "obj-type [list a b]", want: "list"},
+			{script: "opt-point {}", want: "1"},
+			{script: "opt-point {X 1}", want: "0"},
+			{script: "year 2024-03-01T10:00:00Z", want: "2024"},
+		}...)
+		for _, tt := range tests {
+			result, err := interp.Eval(tt.script)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("%s: error = %v; want %q", tt.script, err, tt.err)
+				}
+				continue
+			}
+			if err != nil || result.String() != tt.want {
+				t.Errorf("%s = %v, %v; want %q", tt.script, result, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("RegisterEnsemble", func(t *testing.T) {
+		n := 0
+		interp.RegisterEnsemble("counter", map[string]any{
+			"incr": func(by int) int { n += by; return n },
+			"get":  func() int { return n },
+		})
+
+		result, err := interp.Eval("counter incr 5; counter g")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "5" {
+			t.Errorf("counter get = %q; want '5'", result.String())
+		}
+
+		result, err = interp.Eval("namespace ensemble exists counter")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "1" {
+			t.Errorf("ensemble exists = %q; want '1'", result.String())
+		}
+
+		_, err = interp.Eval("counter reset")
+		want := `unknown or ambiguous subcommand "reset": must be get, or incr`
+		if err == nil || err.Error() != want {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	})
+
+	t.Run("RegisterNamespace", func(t *testing.T) {
+		headers := map[string]string{}
+		interp.RegisterNamespace("http", map[string]any{
+			"get": func(url string) string { return "GET " + url },
+			"raw": func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+				return feather.OK(len(args))
+			},
+			"header": map[string]any{
+				"set": func(name, value string) { headers[name] = value },
+				"get": func(name string) string { return headers[name] },
+			},
+		})
+
+		result, err := interp.Eval("http header set Accept text/plain; list [http g /] [http r a b c] [http h g Accept] [::http::get /x]")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if got := result.String(); got != "{GET /} 3 text/plain {GET /x}" {
+			t.Errorf("result = %q; want {{GET /} 3 text/plain {GET /x}}", got)
+		}
+
+		_, err = interp.Eval("http header del Accept")
+		want := `unknown or ambiguous subcommand "del": must be get, or set`
+		if err == nil || err.Error() != want {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	})
+
+	t.Run("RegisterMathFunc", func(t *testing.T) {
+		interp.RegisterMathFunc("percentile", func(data []float64, p float64) float64 {
+			sorted := slices.Clone(data)
+			slices.Sort(sorted)
+			return sorted[int(p*float64(len(sorted)-1))]
+		})
+		interp.RegisterMathFunc("total", func(xs ...int) int {
+			sum := 0
+			for _, x := range xs {
+				sum += x
+			}
+			return sum
+		})
+
+		result, err := interp.Eval("set data {9 1 5 3 7}; expr {percentile($data, 0.5) * 2}")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "10.0" {
+			t.Errorf("percentile = %q; want '10.0'", result.String())
+		}
+
+		result, err = interp.Eval("expr {total() + total(1, 2, 3)}")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "6" {
+			t.Errorf("total = %q; want '6'", result.String())
+		}
+	})
+
+	t.Run("Func", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		interp.MustEval(`proc add {a b} { expr {$a + $b} }`)
+		interp.MustEval(`proc join3 {sep args} { join $args $sep }`)
+		interp.MustEval(`proc pair {k v} { dict create $k $v }`)
+
+		add, err := feather.Func[func(int, int) (int, error)](interp, "add")
+		if err != nil {
+			t.Fatalf("Func failed: %v", err)
+		}
+		if sum, err := add(2, 3); err != nil || sum != 5 {
+			t.Errorf("add(2, 3) = %d, %v; want 5", sum, err)
+		}
+
+		join3, err := feather.Func[func(string, ...string) string](interp, "join3")
+		if err != nil {
+			t.Fatalf("Func failed: %v", err)
+		}
+		if got := join3("-", "a b", "c"); got != "a b-c" {
+			t.Errorf("join3 = %q; want 'a b-c'", got)
+		}
+
+		pair, err := feather.Func[func(string, []int) (map[string][]int, error)](interp, "pair")
+		if err != nil {
+			t.Fatalf("Func failed: %v", err)
+		}
+		m, err := pair("xs", []int{1, 2})
+		if err != nil || len(m["xs"]) != 2 || m["xs"][1] != 2 {
+			t.Errorf("pair = %v, %v; want map[xs:[1 2]]", m, err)
+		}
+
+		// Redefining the proc changes what the function calls
+		interp.MustEval(`proc add {a b} { error "no adding" }`)
+		sum, err := add(2, 3)
+		var evalErr *feather.EvalError
+		if !errors.As(err, &evalErr) || evalErr.Message != "no adding" || sum != 0 {
+			t.Errorf("add = %d, %v; want 0 and 'no adding'", sum, err)
+		}
+
+		if _, err := feather.Func[func() error](interp, "nosuch"); err == nil {
+			t.Error("expected error for unknown command")
+		}
+		if _, err := feather.Func[int](interp, "add"); err == nil {
+			t.Error("expected error for a non-function type")
+		}
+		if _, err := feather.Func[func() (int, int)](interp, "add"); err == nil {
+			t.Error("expected error for two results")
+		}
+	})
+}
+
+// =============================================================================
+// Call - Direct Command Invocation
 // =============================================================================
 
 func TestCallAPI(t *testing.T) {
@@ -637,6 +1704,151 @@ func TestCallAPI(t *testing.T) {
 			t.Errorf("first item = %q; want 'test value'", items[0].String())
 		}
 	})
+
+	t.Run("Call with Go values", func(t *testing.T) {
+		result, err := interp.Call("list", []string{"a b", "c"}, 42, map[string]int{"k": 1}, true)
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if result.String() != "{{a b} c} 42 {k 1} 1" {
+			t.Errorf("Call = %q; want '{{a b} c} 42 {k 1} 1'", result.String())
+		}
+	})
+
+	t.Run("Call with Expand", func(t *testing.T) {
+		interp.Eval("set expanded {}")
+		result, err := interp.Call("lappend", "expanded", feather.Expand([]string{"a b", "c"}), "d")
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if result.String() != "{a b} c d" {
+			t.Errorf("lappend = %q; want '{a b} c d'", result.String())
+		}
+		list, _ := interp.Eval("list x y")
+		result, err = interp.Call("llength", feather.Expand(list))
+		if err == nil {
+			t.Errorf("llength x y = %q; want wrong # args error", result.String())
+		}
+	})
+
+	t.Run("Call error", func(t *testing.T) {
+		interp.Eval("proc failing {} { throw {MY ERR} oops }")
+		_, err := interp.Call("failing")
+		var evalErr *feather.EvalError
+		if !errors.As(err, &evalErr) {
+			t.Fatalf("Call error = %v; want *EvalError", err)
+		}
+		if evalErr.Message != "oops" {
+			t.Errorf("Message = %q; want 'oops'", evalErr.Message)
+		}
+		if evalErr.ErrorCode != "MY ERR" {
+			t.Errorf("ErrorCode = %q; want 'MY ERR'", evalErr.ErrorCode)
+		}
+		if !strings.Contains(evalErr.ErrorInfo, "failing") {
+			t.Errorf("ErrorInfo = %q; want it to mention failing", evalErr.ErrorInfo)
+		}
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		lambda := interp.List(interp.String("x y"), interp.String("expr {$x * $y}"))
+		result, err := interp.Apply(lambda, 6, 7)
+		if err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+		if result.String() != "42" {
+			t.Errorf("Apply = %q; want '42'", result.String())
+		}
+		if _, err := interp.Apply(lambda, 6); err == nil {
+			t.Error("expected error applying with too few arguments")
+		}
+	})
+
+	t.Run("Coroutine and Resume", func(t *testing.T) {
+		first, err := interp.Coroutine("acc", "set total 0; while 1 { incr total [yield $total] }")
+		if err != nil {
+			t.Fatalf("Coroutine failed: %v", err)
+		}
+		if first.String() != "0" {
+			t.Errorf("first yield = %q; want '0'", first.String())
+		}
+		interp.Resume("acc", 5)
+		result, err := interp.Resume("acc", 7)
+		if err != nil {
+			t.Fatalf("Resume failed: %v", err)
+		}
+		if result.String() != "12" {
+			t.Errorf("Resume = %q; want '12'", result.String())
+		}
+
+		result, err = interp.Coroutine("once", "yield a; return done")
+		if err != nil || result.String() != "a" {
+			t.Fatalf("Coroutine = %v, %v; want 'a'", result, err)
+		}
+		result, err = interp.Resume("once", nil)
+		if err != nil || result.String() != "done" {
+			t.Errorf("Resume = %v, %v; want 'done'", result, err)
+		}
+		if _, err := interp.Resume("once", nil); err == nil {
+			t.Error("expected error resuming a finished coroutine")
+		}
+	})
+
+	t.Run("Coroutine scratch arena", func(t *testing.T) {
+		// A suspended coroutine keeps its objects in an arena of its own, so
+		// the interpreter's is emptied after each eval as usual
+		before := interp.Stats()
+		if _, err := interp.Coroutine("gen", "while 1 { yield [string repeat x 100] }"); err != nil {
+			t.Fatalf("Coroutine failed: %v", err)
+		}
+		for range 100 {
+			interp.Resume("gen", nil)
+			if n := interp.ScratchSize(); n != 0 {
+				t.Fatalf("ScratchSize = %d with the coroutine suspended; want 0", n)
+			}
+		}
+		interp.Eval("rename gen {}")
+		// and its own is emptied when it is deleted
+		after := interp.Stats()
+		if after.ScratchObjects != before.ScratchObjects ||
+			after.CBytesAllocated-after.CBytesFreed != before.CBytesAllocated-before.CBytesFreed {
+			t.Errorf("Stats after the coroutine was deleted = %+v; before = %+v", after, before)
+		}
+	})
+
+	t.Run("Classes and objects", func(t *testing.T) {
+		_, err := interp.Eval(`
+			oo::class create Shape {
+				variable name
+				constructor {n} { set name $n }
+				method describe {} { return "$name with [my sides] sides" }
+			}
+			oo::class create Square {
+				superclass Shape
+				constructor {} { next square }
+				method sides {} { return 4 }
+			}
+		`)
+		if err != nil {
+			t.Fatalf("class definition failed: %v", err)
+		}
+		obj, err := interp.Call("Square", "new")
+		if err != nil {
+			t.Fatalf("Square new failed: %v", err)
+		}
+		result, err := interp.Call(obj.String(), "describe")
+		if err != nil {
+			t.Fatalf("describe failed: %v", err)
+		}
+		if result.String() != "square with 4 sides" {
+			t.Errorf("describe = %q; want 'square with 4 sides'", result.String())
+		}
+		if _, err := interp.Call(obj.String(), "destroy"); err != nil {
+			t.Fatalf("destroy failed: %v", err)
+		}
+		if _, err := interp.Call(obj.String(), "describe"); err == nil {
+			t.Error("expected error calling a destroyed object")
+		}
+	})
 }
 
 // =============================================================================
@@ -710,6 +1922,40 @@ func TestDictManipulation(t *testing.T) {
 	})
 }
 
+func TestBuilders(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("ListBuilder", func(t *testing.T) {
+		b := interp.NewListBuilder()
+		b.Grow(3)
+		b.Append(1, "two words").Append(interp.List(interp.Int(3)))
+		first := b.Obj()
+		b.Append(4)
+		second := b.Obj()
+		if first.String() != "1 {two words} 3" || first.Type() != "list" {
+			t.Errorf("first = %q (%s)", first.String(), first.Type())
+		}
+		if second.String() != "1 {two words} 3 4" || b.Len() != 4 {
+			t.Errorf("second = %q, Len = %d", second.String(), b.Len())
+		}
+	})
+
+	t.Run("DictBuilder", func(t *testing.T) {
+		b := interp.NewDictBuilder()
+		b.Set("name", "alice").Set("roles", []string{"admin", "dev"}).Set("name", "bob")
+		first := b.Obj()
+		b.Set("age", 30)
+		if first.String() != "name bob roles {admin dev}" || first.Type() != "dict" {
+			t.Errorf("first = %q (%s)", first.String(), first.Type())
+		}
+		if got := b.Obj().String(); got != "name bob roles {admin dev} age 30" || b.Len() != 3 {
+			t.Errorf("second = %q, Len = %d", got, b.Len())
+		}
+	})
+
+}
+
 // =============================================================================
 // Foreign Types
 // =============================================================================
@@ -781,72 +2027,2818 @@ func TestForeignTypes(t *testing.T) {
 			t.Errorf("Type() = %q; want 'Counter'", result.Type())
 		}
 	})
-}
 
-// =============================================================================
-// Error Handling
-// =============================================================================
+	t.Run("Object without a command", func(t *testing.T) {
+		counter := &Counter{value: 5}
+		obj, _ := interp.NewForeignHandleNamed("Counter", "<Counter:go>", counter)
+		interp.RegisterCommand("handle", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.OK(obj)
+		})
+		interp.MustEval("set h [handle]")
 
-func TestErrorHandling(t *testing.T) {
-	interp := feather.New()
-	defer interp.Close()
+		result, err := interp.Eval("proc bump {} { uplevel 1 {$h incr} }; bump; $h get")
+		if err != nil {
+			t.Fatalf("method call failed: %v", err)
+		}
+		if result.String() != "6" {
+			t.Errorf("get = %q; want '6'", result.String())
+		}
 
-	t.Run("Eval syntax error", func(t *testing.T) {
-		_, err := interp.Eval("set x {")
-		if err == nil {
-			t.Fatal("expected syntax error")
+		_, err = interp.Eval("$h reset")
+		want := `unknown method "reset": must be destroy, get, incr, set`
+		if err == nil || err.Error() != want {
+			t.Errorf("error = %v; want %q", err, want)
 		}
 	})
 
-	t.Run("Eval command error", func(t *testing.T) {
-		_, err := interp.Eval("nonexistent_command")
-		if err == nil {
-			t.Fatal("expected command not found error")
+	t.Run("Object whose command was renamed away", func(t *testing.T) {
+		interp.MustEval("set r [Counter new]; rename $r {}")
+
+		result, err := interp.Eval("$r incr; $r incr")
+		if err != nil {
+			t.Fatalf("method call failed: %v", err)
+		}
+		if result.String() != "2" {
+			t.Errorf("incr = %q; want '2'", result.String())
 		}
-	})
 
-	t.Run("Int conversion error", func(t *testing.T) {
-		obj := interp.String("not a number")
-		_, err := obj.Int()
-		if err == nil {
-			t.Fatal("expected conversion error")
+		_, err = interp.Eval("$r")
+		if err == nil || !strings.HasSuffix(err.Error(), ` method ?arg ...?"`) {
+			t.Errorf("error = %v; want wrong # args", err)
+		}
+
+		if _, err := interp.Eval("$r destroy"); err != nil {
+			t.Fatalf("destroy failed: %v", err)
+		}
+		if _, err := interp.Eval("$r get"); err == nil {
+			t.Error("expected error after destroy")
 		}
 	})
 }
 
-// =============================================================================
-// Parse
-// =============================================================================
+type Shape struct {
+	Name string
+	ID   int
+}
 
-func TestParseAPI(t *testing.T) {
+type Circle struct {
+	Shape
+	Radius float64
+}
+
+func TestForeignTypeOptions(t *testing.T) {
 	interp := feather.New()
 	defer interp.Close()
 
-	t.Run("Complete input", func(t *testing.T) {
-		pr := interp.Parse("set x 1")
-		if pr.Status != feather.ParseOK {
-			t.Errorf("Status = %v; want ParseOK", pr.Status)
-		}
+	err := feather.RegisterType[*Shape](interp, "Shape", feather.TypeDef[*Shape]{
+		New: func() *Shape { return &Shape{Name: "shape"} },
+		Methods: map[string]any{
+			"describe": func(s *Shape) string { return "a " + s.Name },
+			"Rename":   feather.Method{Func: func(s *Shape, name string) { s.Name = name }, Name: "rename"},
+			"name":     feather.Method{Field: "Name"},
+			"id":       feather.Method{Field: "ID", ReadOnly: true},
+			"tag": feather.Method{Func: func(s *Shape, sep string, parts []string) string {
+				return s.Name + sep + strings.Join(parts, sep)
+			}, Variadic: true},
+			"sum": func(s *Shape, xs ...int) int {
+				total := 0
+				for _, x := range xs {
+					total += x
+				}
+				return total
+			},
+		},
 	})
+	if err != nil {
+		t.Fatalf("RegisterType Shape failed: %v", err)
+	}
 
-	t.Run("Incomplete input - open brace", func(t *testing.T) {
-		pr := interp.Parse("set x {")
-		if pr.Status != feather.ParseIncomplete {
-			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
-		}
-	})
+	nextID := 0
+	err = feather.RegisterType[*Circle](interp, "Circle", feather.TypeDef[*Circle]{
+		New: func() *Circle {
+			nextID++
+			return &Circle{Shape: Shape{Name: "circle", ID: nextID}, Radius: 1}
+		},
+		Methods: map[string]any{
+			"describe": func(c *Circle) string { return fmt.Sprintf("a circle of radius %g", c.Radius) },
+			"radius":   feather.Method{Field: "Radius"},
+		},
+		Statics: map[string]any{
+			"unit":  func() float64 { return 1 },
+			"count": func() int { return nextID },
+		},
+		Embed:  []string{"Shape"},
+		String: func(c *Circle) string { return fmt.Sprintf("circle#%d", c.ID) },
+	})
+	if err != nil {
+		t.Fatalf("RegisterType Circle failed: %v", err)
+	}
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"set s [Shape new]; $s rename square; $s describe", "a square"},
+		{"$s name", "square"},
+		{"$s name box; $s describe", "a box"},
+		{"$s id", "0"},
+		{"$s tag - a b c", "box-a-b-c"},
+		{"$s tag :", "box:"},
+		{"$s sum", "0"},
+		{"$s sum 1 2 3", "6"},
+		{"set c [Circle new]", "circle#1"},
+		{"$c describe", "a circle of radius 1"},
+		{"$c radius 2.5; $c describe", "a circle of radius 2.5"},
+		{"$c rename wheel; $c name", "wheel"},
+		{"$c sum 4 5", "9"},
+		{"$c id", "1"},
+		{"Circle unit", "1.0"},
+		{"Circle new; Circle count", "2"},
+		{"circle#2 id", "2"},
+	}
+	for _, tt := range tests {
+		result, err := interp.Eval(tt.script)
+		if err != nil {
+			t.Errorf("%s: %v", tt.script, err)
+		} else if result.String() != tt.want {
+			t.Errorf("%s = %q, want %q", tt.script, result.String(), tt.want)
+		}
+	}
+
+	errs := []struct {
+		script string
+		want   string
+	}{
+		{"$s id 3", "wrong # args: expected 0, got 1"},
+		{"$s sum 1 x", "argument 2: "},
+		{"$s tag", "wrong # args: expected at least 1, got 0"},
+		{"$s Rename x", "unknown method \"Rename\""},
+		{"Circle area", "unknown subcommand \"area\": must be new, count, unit"},
+		{"Shape unit", "unknown subcommand \"unit\": must be new"},
+	}
+	for _, tt := range errs {
+		_, err := interp.Eval(tt.script)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.script, err, tt.want)
+		}
+	}
+
+	t.Run("Invalid definitions", func(t *testing.T) {
+		type Plain struct{}
+		defs := map[string]feather.TypeDef[*Plain]{
+			"unknown embed": {New: func() *Plain { return &Plain{} }, Embed: []string{"Nope"}},
+			"missing embed": {New: func() *Plain { return &Plain{} }, Embed: []string{"Shape"}},
+			"bad receiver":  {New: func() *Plain { return &Plain{} }, Methods: map[string]any{"m": func(s *Shape) {}}},
+			"not a func":    {New: func() *Plain { return &Plain{} }, Methods: map[string]any{"m": 42}},
+			"no field":      {New: func() *Plain { return &Plain{} }, Methods: map[string]any{"m": feather.Method{Field: "X"}}},
+			"static new":    {New: func() *Plain { return &Plain{} }, Statics: map[string]any{"new": func() {}}},
+		}
+		for name, def := range defs {
+			if err := feather.RegisterType[*Plain](interp, "Plain", def); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+
+	t.Run("Duplicate string representation", func(t *testing.T) {
+		err := feather.RegisterType[*Shape](interp, "Fixed", feather.TypeDef[*Shape]{
+			New:    func() *Shape { return &Shape{} },
+			String: func(*Shape) string { return "fixed" },
+		})
+		if err != nil {
+			t.Fatalf("RegisterType Fixed failed: %v", err)
+		}
+		if result, err := interp.Eval("Fixed new"); err != nil || result.String() != "fixed" {
+			t.Fatalf("Fixed new = %v, %v; want fixed", result, err)
+		}
+		if _, err := interp.Eval("Fixed new"); err == nil || !strings.Contains(err.Error(), "already in use") {
+			t.Errorf("second Fixed new: error = %v, want name in use", err)
+		}
+	})
+}
+
+func TestForeignLifecycle(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	type Conn struct{ closed bool }
+	var destroyed []*Conn
+	def := feather.TypeDef[*Conn]{
+		New:     func() *Conn { return &Conn{} },
+		Methods: map[string]any{"closed": func(c *Conn) bool { return c.closed }},
+		Destroy: func(c *Conn) {
+			c.closed = true
+			destroyed = append(destroyed, c)
+		},
+	}
+	if err := feather.RegisterType[*Conn](interp, "Conn", def); err != nil {
+		t.Fatalf("RegisterType Conn failed: %v", err)
+	}
+	def.AutoDestroy = true
+	if err := feather.RegisterType[*Conn](interp, "Temp", def); err != nil {
+		t.Fatalf("RegisterType Temp failed: %v", err)
+	}
+
+	names := func() []string {
+		var names []string
+		for _, inst := range interp.ForeignInstances() {
+			names = append(names, inst.Type+":"+inst.Name)
+		}
+		return names
+	}
+
+	t.Run("Handle scope", func(t *testing.T) {
+		interp.MustEval("set keep [Conn new]")
+		interp.WithHandleScope(func() {
+			interp.MustEval("set a [Conn new]; set b [Conn new]")
+			interp.WithHandleScope(func() {
+				interp.MustEval("set c [Conn new]; $a destroy")
+			})
+			if got := names(); !slices.Equal(got, []string{"Conn:conn1", "Conn:conn3"}) {
+				t.Errorf("after inner scope: instances = %v", got)
+			}
+		})
+		if got := names(); !slices.Equal(got, []string{"Conn:conn1"}) {
+			t.Errorf("after outer scope: instances = %v", got)
+		}
+		if len(destroyed) != 3 {
+			t.Errorf("destroyed %d objects, want 3", len(destroyed))
+		}
+		if _, err := interp.Eval("$b closed"); err == nil {
+			t.Error("expected $b to be destroyed")
+		}
+		if got := interp.MustEval("$keep closed").String(); got != "0" {
+			t.Errorf("$keep closed = %q, want 0", got)
+		}
+	})
+
+	t.Run("Finalizer", func(t *testing.T) {
+		destroyed = nil
+		interp.MustEval("set t1 [Temp new]; set t2 [Temp new]; set held [list $t2]; unset t1 t2")
+		for n := 0; n < 100 && len(destroyed) == 0; n++ {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+			interp.MustEval("set x 1")
+		}
+		if len(destroyed) != 1 {
+			t.Fatalf("destroyed %d objects, want 1", len(destroyed))
+		}
+		if got := names(); !slices.Equal(got, []string{"Conn:conn1", "Temp:temp2"}) {
+			t.Errorf("instances = %v", got)
+		}
+		if got := interp.MustEval("[lindex $held 0] closed").String(); got != "0" {
+			t.Errorf("held object closed = %q, want 0", got)
+		}
+	})
+}
+
+// =============================================================================
+// Error Handling
+// =============================================================================
+
+func TestErrorHandling(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("Eval syntax error", func(t *testing.T) {
+		_, err := interp.Eval("set x {")
+		if err == nil {
+			t.Fatal("expected syntax error")
+		}
+	})
+
+	t.Run("Eval command error", func(t *testing.T) {
+		_, err := interp.Eval("nonexistent_command")
+		if err == nil {
+			t.Fatal("expected command not found error")
+		}
+	})
+
+	t.Run("Int conversion error", func(t *testing.T) {
+		obj := interp.String("not a number")
+		_, err := obj.Int()
+		if err == nil {
+			t.Fatal("expected conversion error")
+		}
+	})
+
+	t.Run("MustEval panics on error", func(t *testing.T) {
+		if got := interp.MustEval("expr {6 * 7}").String(); got != "42" {
+			t.Errorf("MustEval = %q; want '42'", got)
+		}
+		defer func() {
+			if recover() == nil {
+				t.Error("expected MustEval to panic")
+			}
+		}()
+		interp.MustEval("error boom")
+	})
+
+	t.Run("Report captures and replays", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		interp.RegisterCommand("greet", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.OK("hello " + args[0].String())
+		})
+		interp.SetReportEvents(2)
+		interp.Eval("set x 1")
+		script := "greet {big world}; expr {1 / 0}"
+		if _, err := interp.Eval(script); err == nil {
+			t.Fatal("expected divide by zero")
+		}
+
+		var buf bytes.Buffer
+		if err := interp.Report(script).Write(&buf); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		r, err := feather.ReadReport(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("ReadReport: %v", err)
+		}
+		if r.Script != script {
+			t.Errorf("Script = %q; want %q", r.Script, script)
+		}
+		want := []feather.ReportEvent{
+			{Kind: "command", Text: "greet {big world}", Code: "ok", Result: "hello big world"},
+			{Kind: "eval", Text: script, Code: "error", Result: "divide by zero"},
+		}
+		if !slices.Equal(r.Events, want) {
+			t.Errorf("Events = %+v; want %+v", r.Events, want)
+		}
+		if !slices.Contains(r.Commands, "::greet") || !slices.Contains(r.Commands, "::set") {
+			t.Errorf("Commands = %v; want ::greet and ::set", r.Commands)
+		}
+		if r.Config["recursionlimit"] != "1000" {
+			t.Errorf("Config = %v", r.Config)
+		}
+
+		fresh := feather.New()
+		defer fresh.Close()
+		if missing := r.MissingCommands(fresh); !slices.Equal(missing, []string{"::greet"}) {
+			t.Errorf("MissingCommands = %v; want [::greet]", missing)
+		}
+		if _, err := r.Replay(fresh); err == nil {
+			t.Error("expected replay to fail like the original")
+		}
+	})
+}
+
+func TestRecoverPanics(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Register("boom", func() { var m map[string]int; m["x"] = 1 })
+	interp.RegisterCommand("raw", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+		panic("raw " + args[0].String())
+	})
+
+	_, err := interp.Eval("proc f {} {boom}; f")
+	var e *feather.EvalError
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %v; want an EvalError", err)
+	}
+	if want := `command "boom" panicked: assignment to entry in nil map`; e.Message != want {
+		t.Errorf("Message = %q; want %q", e.Message, want)
+	}
+	if e.ErrorCode != "GO PANIC" {
+		t.Errorf("ErrorCode = %q; want GO PANIC", e.ErrorCode)
+	}
+	if !strings.Contains(e.ErrorInfo, "goroutine ") || !strings.Contains(e.ErrorInfo, "\n    while executing\n\"boom\"") {
+		t.Errorf("ErrorInfo = %q; want the Go stack and the script trace", e.ErrorInfo)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"catch", "list [catch {raw x} m o] $m [dict get $o -errorcode]", `1 {command "raw" panicked: raw x} {GO PANIC}`},
+		{"try trap", "try {raw y} trap {GO PANIC} m {set m}", `command "raw" panicked: raw y`},
+		{"frames unwound", "proc g {} {raw z}; catch g; info level", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("SetRecoverPanics false", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		interp.SetRecoverPanics(false)
+		interp.RegisterCommand("raw", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			panic("raw")
+		})
+		defer func() {
+			if r := recover(); r != "raw" {
+				t.Errorf("recovered %v; want the panic to propagate", r)
+			}
+		}()
+		interp.Eval("raw")
+	})
+}
+
+func TestSetLogger(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	var buf bytes.Buffer
+	interp.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	type Counter struct{ n int }
+	feather.RegisterType[*Counter](interp, "Counter", feather.TypeDef[*Counter]{
+		New: func() *Counter { return &Counter{} },
+	})
+	interp.Register("greet", func(name string) string { return "hello " + name })
+	interp.SetRecursionLimit(20)
+	interp.Eval(`
+		set c [Counter new]; $c destroy
+		trace add variable x write {apply {args {}}}
+		set x 1
+		proc deep {} {deep}
+		catch deep
+	`)
+	interp.Eval("nosuch a b")
+
+	log := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="command registered" name=greet`,
+		`level=DEBUG msg="foreign object created" type=Counter name=counter1`,
+		`level=DEBUG msg="foreign object destroyed" type=Counter name=counter1`,
+		`level=DEBUG msg="trace fired" kind=variable command="apply {args {}} x {} write"`,
+		`level=WARN msg="recursion limit reached" limit=20 command=::deep`,
+		`level=DEBUG msg="unknown command" name=nosuch args="[a b]" handled=false`,
+		`level=ERROR msg="script error" error="invalid command name \"nosuch\"" errorcode=NONE`,
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log is missing %s:\n%s", want, log)
+		}
+	}
+
+	buf.Reset()
+	interp.SetLogger(nil)
+	interp.Eval("nosuch")
+	if buf.Len() != 0 {
+		t.Errorf("logged after SetLogger(nil): %s", buf.String())
+	}
+}
+
+func TestStackLimit(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetRecursionLimit(100000)
+	// A smaller limit than the default keeps the scripts short
+	interp.SetStackLimit(1 << 20)
+
+	const n = 100000
+	parens := "expr {" + strings.Repeat("(", n) + "1" + strings.Repeat(")", n) + "}"
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"expr parentheses", parens},
+		{"expr unary", "expr {" + strings.Repeat("-", n) + "1}"},
+		{"command substitution", "set x " + strings.Repeat("[set x ", n/20) + "1" + strings.Repeat("]", n/20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interp.Eval(tt.script)
+			var e *feather.EvalError
+			if !errors.As(err, &e) || e.Message != "out of stack space (infinite loop?)" {
+				t.Fatalf("err = %v; want out of stack space", err)
+			}
+			result, err := interp.Eval("list [catch {" + tt.script + "} m o] [dict get $o -errorcode] [info level] [expr {(1 + 2) * 3}]")
+			if err != nil || result.String() != "1 {TCL LIMIT STACK} 0 9" {
+				t.Errorf("caught: %v, %v", result, err)
+			}
+		})
+	}
+
+	t.Run("in a coroutine", func(t *testing.T) {
+		result, err := interp.Eval("coroutine co apply {{} {yield [catch {" + parens + "} m]; return $m}}")
+		if err != nil || result.String() != "1" {
+			t.Fatalf("coroutine: %v, %v", result, err)
+		}
+		if result, err := interp.Eval("co"); err != nil || result.String() != "out of stack space (infinite loop?)" {
+			t.Errorf("coroutine: %v, %v", result, err)
+		}
+	})
+
+	t.Run("SetStackLimit", func(t *testing.T) {
+		interp.SetStackLimit(0)
+		script := "expr {" + strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200) + "}"
+		if _, err := interp.Eval(script); err != nil {
+			t.Fatalf("default limit: %v", err)
+		}
+		interp.SetStackLimit(16 << 10)
+		if _, err := interp.Eval(script); err == nil || err.Error() != "out of stack space (infinite loop?)" {
+			t.Errorf("16KB limit: err = %v", err)
+		}
+		other := feather.New()
+		defer other.Close()
+		if _, err := other.Eval(script); err != nil {
+			t.Errorf("limit of another interpreter: %v", err)
+		}
+		interp.SetStackLimit(-1)
+		if _, err := interp.Eval(script); err != nil {
+			t.Errorf("default limit restored: %v", err)
+		}
+	})
+}
+
+func TestTailcallConstantSpace(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	// Tail calls must fit within limits that ordinary recursion exceeds
+	interp.SetRecursionLimit(20)
+	interp.SetStackLimit(1 << 18)
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"proc", `proc count {n} { if {$n == 0} { return done }; tailcall count [expr {$n - 1}] }; count 5000`, "done"},
+		{"lambda", `set f {{n} { if {$n == 0} { return done }; tailcall apply $::f [expr {$n - 1}] }}; apply $f 5000`, "done"},
+		{"namespace", `namespace eval ns { proc a {n} { if {$n == 0} { return [namespace current] }; tailcall b $n }; proc b {n} { tailcall a [expr {$n - 1}] } }; ns::a 2500`, "::ns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil || result.String() != tt.want {
+				t.Fatalf("got %v, %v; want %s", result, err, tt.want)
+			}
+		})
+	}
+	if _, err := interp.Eval(`proc deep {n} { if {$n == 0} { return }; deep [expr {$n - 1}] }; deep 5000`); err == nil {
+		t.Errorf("recursion without tailcall: want an error")
+	}
+	if _, err := interp.Eval(`proc p {} { uplevel 1 {tailcall set x} }; proc q {} { p; return q }; q`); err == nil || err.Error() != "tailcall can only be called from a proc or lambda" {
+		t.Errorf("tailcall in uplevel: err = %v", err)
+	}
+}
+
+func TestDeepRecursion(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	// Recursion is limited by frames, not by the C stack
+	interp.SetRecursionLimit(3000)
+	interp.SetStackLimit(1 << 18)
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"proc", `proc sum {n} { if {$n == 0} { return 0 }; expr {$n + [sum [expr {$n - 1}]]} }; sum 2500`},
+		{"lambda", `set f {{n} { if {$n == 0} { return 0 }; expr {$n + [apply $::f [expr {$n - 1}]]} }}; apply $f 2500`},
+		{"coroutine", `coroutine co apply {{} { proc down {n} { if {$n == 0} { yield; return 0 }; expr {$n + [down [expr {$n - 1}]]} }; down 2500 }}; co`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil || result.String() != "3126250" {
+				t.Fatalf("got %v, %v; want 3126250", result, err)
+			}
+		})
+	}
+
+	_, err := interp.Eval(`proc deep {} { deep }; deep`)
+	var e *feather.EvalError
+	if !errors.As(err, &e) || !strings.HasPrefix(e.Message, "too many nested evaluations") {
+		t.Fatalf("err = %v; want the recursion limit", err)
+	}
+	if result, err := interp.Eval(`list [info level] [sum 10]`); err != nil || result.String() != "0 55" {
+		t.Errorf("after the limit: %v, %v", result, err)
+	}
+}
+
+func TestEvalTimeout(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	for _, script := range []string{
+		"while 1 {}",
+		"proc spin {} {while 1 {incr n}}; spin",
+		"while 1 {catch {while 1 {}}}",
+		"catch {while 1 {}}; set after ok",
+	} {
+		t.Run(script, func(t *testing.T) {
+			start := time.Now()
+			_, err := interp.EvalTimeout(script, 20*time.Millisecond)
+			if !errors.Is(err, feather.ErrTimeout) {
+				t.Fatalf("err = %v; want ErrTimeout", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("stopped after %v", elapsed)
+			}
+			var e *feather.EvalError
+			if !errors.As(err, &e) || e.Message != "time limit exceeded" || e.ErrorCode != "TCL LIMIT TIME" {
+				t.Errorf("err = %#v", err)
+			}
+			// The frames have unwound and the interpreter carries on
+			if got := interp.MustEval("list [info level] [expr {6 * 7}]").String(); got != "0 42" {
+				t.Errorf("after timeout: %q; want %q", got, "0 42")
+			}
+		})
+	}
+
+	t.Run("finishes in time", func(t *testing.T) {
+		result, err := interp.EvalTimeout("set x 0; for {set k 0} {$k < 100} {incr k} {incr x $k}; set x", time.Second)
+		if err != nil || result.String() != "4950" {
+			t.Errorf("EvalTimeout = %v, %v; want 4950", result, err)
+		}
+		if _, err := interp.Eval("after 30; set y 1"); err != nil {
+			t.Errorf("Eval after deadline: %v", err)
+		}
+	})
+
+	t.Run("script error", func(t *testing.T) {
+		_, err := interp.EvalTimeout("error boom", time.Second)
+		if err == nil || errors.Is(err, feather.ErrTimeout) || err.Error() != "boom" {
+			t.Errorf("err = %v; want boom", err)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		interp.RegisterCommand("limited", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			ms, _ := args[0].Int()
+			if _, err := i.EvalTimeout(args[1].String(), time.Duration(ms)*time.Millisecond); err != nil {
+				return feather.Error(err.Error())
+			}
+			return feather.OK("done")
+		})
+		result, err := interp.EvalTimeout("catch {limited 10 {while 1 {}}} msg; set msg", time.Second)
+		if err != nil || result.String() != "time limit exceeded" {
+			t.Errorf("inner deadline: %v, %v", result, err)
+		}
+		// The outer deadline comes first and stops both scripts
+		_, err = interp.EvalTimeout("catch {limited 1000 {while 1 {}}}; set x 1", 10*time.Millisecond)
+		if !errors.Is(err, feather.ErrTimeout) {
+			t.Errorf("outer deadline: %v", err)
+		}
+	})
+
+	t.Run("interpreters on other goroutines", func(t *testing.T) {
+		// Each interpreter counts its own limits, so deadlines set and
+		// cleared on other goroutines leave this one's in force
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				other := feather.New()
+				defer other.Close()
+				for range 200 {
+					other.EvalTimeout("set x 1", time.Second)
+				}
+			}()
+		}
+		_, err := interp.EvalTimeout("while 1 {}", 50*time.Millisecond)
+		wg.Wait()
+		if !errors.Is(err, feather.ErrTimeout) {
+			t.Errorf("err = %v; want ErrTimeout", err)
+		}
+	})
+}
+
+func TestCommandIntrospection(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Register("greet", func(name string) string { return "hi " + name })
+	interp.Eval("namespace eval ::util {proc pad {s {width 10}} {}}")
+
+	t.Run("CommandInfo", func(t *testing.T) {
+		info, ok := interp.CommandInfo("greet")
+		if !ok || info.Kind != "go" || info.Name != "::greet" || info.Namespace != "::" {
+			t.Errorf("CommandInfo(greet) = %+v, %v", info, ok)
+		}
+		if info, _ := interp.CommandInfo("set"); info.Kind != "builtin" {
+			t.Errorf("set Kind = %q; want builtin", info.Kind)
+		}
+		info, ok = interp.CommandInfo("util::pad")
+		want := []feather.ProcArg{{Name: "s"}, {Name: "width", Default: "10", HasDefault: true}}
+		if !ok || info.Kind != "proc" || info.Namespace != "::util" || !slices.Equal(info.Params, want) {
+			t.Errorf("CommandInfo(util::pad) = %+v, %v", info, ok)
+		}
+		if _, ok := interp.CommandInfo("nosuch"); ok {
+			t.Error("CommandInfo(nosuch) found a command")
+		}
+	})
+
+	t.Run("CommandNames", func(t *testing.T) {
+		if got := interp.CommandNames("gr*"); !slices.Equal(got, []string{"greet"}) {
+			t.Errorf("CommandNames(gr*) = %q", got)
+		}
+		if got := interp.CommandNames(""); !slices.Contains(got, "set") || !slices.Contains(got, "greet") {
+			t.Errorf("CommandNames() = %q", got)
+		}
+	})
+
+	t.Run("Unregister", func(t *testing.T) {
+		var deleted []string
+		interp.TraceCommand("greet", "delete", func(info feather.CommandTraceInfo) {
+			deleted = append(deleted, info.OldName)
+		})
+		if err := interp.Unregister("greet"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := interp.Eval("greet x"); err == nil {
+			t.Error("greet still runs")
+		}
+		if !slices.Equal(deleted, []string{"::greet"}) {
+			t.Errorf("delete traces = %q", deleted)
+		}
+		err := interp.Unregister("greet")
+		if want := `command "greet" doesn't exist`; err == nil || err.Error() != want {
+			t.Errorf("Unregister(missing) = %v; want %q", err, want)
+		}
+	})
+}
+
+func TestHiddenCommands(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Register("secret", func(s string) string { return "secret " + s })
+	interp.Eval("proc double {x} {expr {$x * 2}}")
+
+	for _, name := range []string{"secret", "double", "string"} {
+		if err := interp.Hide(name); err != nil {
+			t.Fatalf("Hide(%s): %v", name, err)
+		}
+		if _, err := interp.Eval(name + " 1"); err == nil || !strings.Contains(err.Error(), "invalid command name") {
+			t.Errorf("%s visible after Hide: %v", name, err)
+		}
+	}
+	if got := interp.HiddenCommands(); !slices.Equal(got, []string{"double", "secret", "string"}) {
+		t.Errorf("HiddenCommands() = %q", got)
+	}
+	if got := interp.CommandNames("secret"); len(got) != 0 {
+		t.Errorf("info commands lists hidden command: %q", got)
+	}
+
+	tests := []struct {
+		name string
+		args []any
+		want string
+	}{
+		{"secret", []any{"x"}, "secret x"},
+		{"double", []any{21}, "42"},
+		{"string", []any{"toupper", "abc"}, "ABC"},
+	}
+	for _, tt := range tests {
+		got, err := interp.InvokeHidden(tt.name, tt.args...)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("InvokeHidden(%s) = %v, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := interp.InvokeHidden("string", "bogus"); err == nil {
+		t.Error("expected error from hidden builtin")
+	}
+
+	if err := interp.Expose("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := interp.Eval("secret y"); err != nil || v.String() != "secret y" {
+		t.Errorf("after Expose: %v, %v", v, err)
+	}
+	if err := interp.Hide("nosuch"); err == nil {
+		t.Error("Hide of missing command succeeded")
+	}
+	if err := interp.Hide("::tcl::mathfunc::abs"); err == nil {
+		t.Error("Hide of namespaced command succeeded")
+	}
+}
+
+func TestInterpCommand(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Register("secret", func(s string) string { return "secret " + s })
+
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{`interp alias {} up {} string toupper`, "up"},
+		{`up abc`, "ABC"},
+		{`interp alias {} up`, "string toupper"},
+		{`interp alias {} nosuch`, ""},
+		{`interp alias {} miss {} nosuchcmd; catch miss msg; set msg`, `invalid command name "nosuchcmd"`},
+		{`interp aliases {}`, "miss up"},
+		{`interp alias {} up {}; interp aliases`, "miss"},
+		{`catch {interp alias {} up {}} msg; set msg`, `alias "up" not found`},
+		{`interp hide {} secret`, ""},
+		{`catch {secret x} msg; set msg`, `invalid command name "secret"`},
+		{`interp hidden {}`, "secret"},
+		{`interp invokehidden {} -global -- secret x`, "secret x"},
+		{`interp expose {} secret shown; shown y`, "secret y"},
+		{`interp hide {} shown hush; interp hidden`, "hush"},
+		{`catch {interp hide {} up x::y} msg; set msg`, "cannot use namespace qualifiers in hidden command token (rename)"},
+		{`interp exists {}`, "1"},
+		{`interp exists child`, "0"},
+		{`catch {interp hide child set} msg; set msg`, `could not find interpreter "child"`},
+		{`catch {interp alias {} x child y} msg; set msg`, `could not find interpreter "child"`},
+		{`proc caller {} { uplevel 1 {namespace current} }; interp hide {} caller; interp invokehidden {} -namespace app caller`, "::app"},
+		{`interp invokehidden {} -namespace app -global caller`, "::"},
+		{`catch {interp invokehidden {} -namespace} msg; set msg`, `wrong # args: should be "interp invokehidden path ?-namespace ns? ?-global? ?--? cmd ?arg ..?"`},
+		{`catch {interp invokehidden {} -x hush} msg; set msg`, `bad option "-x": must be -global, -namespace, or --`},
+		{`catch {interp create} msg; set msg`, `bad option "create": must be alias, aliases, exists, expose, hide, hidden, or invokehidden`},
+	}
+	for _, tt := range tests {
+		got, err := interp.Eval(tt.script)
+		if err != nil {
+			t.Errorf("%s: %v", tt.script, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s = %q; want %q", tt.script, got.String(), tt.want)
+		}
+	}
+}
+
+func TestAlias(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Alias("first", "lindex", interp.List(interp.String("a"), interp.String("b"), interp.String("c")))
+	interp.Alias("upper", "string", interp.String("toupper"))
+
+	if v, err := interp.Eval("first 1"); err != nil || v.String() != "b" {
+		t.Errorf("first 1 = %v, %v", v, err)
+	}
+	if v, err := interp.Eval("upper {hello world}"); err != nil || v.String() != "HELLO WORLD" {
+		t.Errorf("upper = %v, %v", v, err)
+	}
+	info, ok := interp.CommandInfo("upper")
+	if !ok || info.Kind != "alias" || info.Target != "::string" {
+		t.Errorf("CommandInfo(upper) = %+v", info)
+	}
+
+	// The target is resolved on each call
+	interp.Eval("proc greet {who} {return hi-$who}")
+	interp.Alias("hello", "greet", interp.String("there"))
+	interp.Eval("proc greet {who} {return hello-$who}")
+	if v, _ := interp.Eval("hello"); v.String() != "hello-there" {
+		t.Errorf("hello = %q", v.String())
+	}
+	if _, err := interp.Eval("first x"); err == nil {
+		t.Error("expected error from target")
+	}
+
+	interp.Alias("missing", "nosuchcmd")
+	_, err := interp.Eval("missing")
+	if want := `invalid command name "nosuchcmd"`; err == nil || err.Error() != want {
+		t.Errorf("missing = %v; want %q", err, want)
+	}
+}
+
+// =============================================================================
+// Parse
+// =============================================================================
+
+func TestParseAPI(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("Complete input", func(t *testing.T) {
+		pr := interp.Parse("set x 1")
+		if pr.Status != feather.ParseOK {
+			t.Errorf("Status = %v; want ParseOK", pr.Status)
+		}
+	})
+
+	t.Run("Incomplete input - open brace", func(t *testing.T) {
+		pr := interp.Parse("set x {")
+		if pr.Status != feather.ParseIncomplete {
+			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
+		}
+	})
+
+	t.Run("Incomplete input - open bracket", func(t *testing.T) {
+		pr := interp.Parse("set x [expr")
+		if pr.Status != feather.ParseIncomplete {
+			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
+		}
+	})
+
+	t.Run("Incomplete input - open quote", func(t *testing.T) {
+		pr := interp.Parse(`set x "hello`)
+		if pr.Status != feather.ParseIncomplete {
+			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
+		}
+	})
+
+	t.Run("Incomplete later command", func(t *testing.T) {
+		pr := interp.Parse("set x 1\nset y {")
+		if pr.Status != feather.ParseIncomplete {
+			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
+		}
+	})
+
+	t.Run("Does not evaluate", func(t *testing.T) {
+		interp.Eval("set ran 0")
+		pr := interp.Parse("set z $undefined [set ran 1]")
+		if pr.Status != feather.ParseOK {
+			t.Errorf("Status = %v (%s); want ParseOK", pr.Status, pr.Message)
+		}
+		if v := interp.Var("ran").String(); v != "0" {
+			t.Errorf("ran = %q; want '0'", v)
+		}
+	})
+}
+
+func TestListQuoting(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	quoted := map[string]string{
+		"":       "{}",
+		"abc":    "abc",
+		"a b":    "{a b}",
+		"a$b":    "{a$b}",
+		"a{b":    `a\{b`,
+		"a}b":    `a\}b`,
+		"a{b}c":  "a{b}c",
+		"{a}":    "{{a}}",
+		`a\`:     `a\\`,
+		"a\\\nb": `a\\\nb`,
+		`a]b`:    `a\]b`,
+		`a"b`:    `a\"b`,
+		`"a`:     `{"a}`,
+		"#a":     "{#a}",
+	}
+	for s, want := range quoted {
+		got := feather.QuoteListElement(s)
+		if got != want {
+			t.Errorf("QuoteListElement(%q) = %q; want %q", s, got, want)
+		}
+		// The element must read back unchanged, both as a list and as a word
+		// of a script
+		if elems, err := feather.SplitList(got); err != nil || len(elems) != 1 || elems[0] != s {
+			t.Errorf("SplitList(%q) = %q, %v; want [%q]", got, elems, err, s)
+		}
+		if v, err := interp.Eval("set v " + got); err != nil || v.String() != s {
+			t.Errorf("set v %s = %v, %v; want %q", got, v, err, s)
+		}
+	}
+
+	elems := []string{"#a", "#b", "", "x y", "a{", `z\`}
+	joined := feather.JoinList(elems)
+	if want := `{#a} #b {} {x y} a\{ z\\`; joined != want {
+		t.Errorf("JoinList = %q; want %q", joined, want)
+	}
+	list, err := interp.Eval("list " + joined)
+	if err != nil || list.String() != joined {
+		t.Errorf("list %s = %v, %v; want the same string", joined, list, err)
+	}
+
+	got, err := feather.SplitList(" a {b c}\t\"d\\te\" f\\ g\n")
+	if want := []string{"a", "b c", "d\te", "f g"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitList = %q, %v; want %q", got, err, want)
+	}
+	for s, msg := range map[string]string{
+		"a {b":    "unmatched open brace in list",
+		`a "b`:    "unmatched open quote in list",
+		"{a}b c":  `list element in braces followed by "b" instead of space`,
+		`"a"bc d`: `list element in quotes followed by "bc" instead of space`,
+	} {
+		if _, err := feather.SplitList(s); err == nil || err.Error() != msg {
+			t.Errorf("SplitList(%q) error = %v; want %q", s, err, msg)
+		}
+	}
+}
+
+func TestSubst(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Eval("set name world; set ran 0; set {a(k)} elem; set k k")
+
+	tests := []struct {
+		in    string
+		flags feather.SubstFlags
+		want  string
+	}{
+		{`hello $name\t[set ran 1]`, feather.SubstAll, "hello world\t1"},
+		{`hello ${name}!`, feather.SubstVariables, "hello world!"},
+		{`$name\n[set ran 2]`, feather.SubstVariables, `world\n[set ran 2]`},
+		{`$name\x41[set ran 3]`, feather.SubstVariables | feather.SubstBackslashes, "worldA[set ran 3]"},
+		{`$name [string length abc]`, feather.SubstCommands, "$name 3"},
+		{`$a($k) $a([set ran 4])`, feather.SubstVariables, ""},
+		{`a[break]b`, feather.SubstAll, "a"},
+		{`$name`, 0, "$name"},
+	}
+	for _, tt := range tests {
+		got, err := interp.Subst(tt.in, tt.flags)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Subst(%q, %d) = %q; want an error", tt.in, tt.flags, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Subst(%q, %d) = %v, %v; want %q", tt.in, tt.flags, got, err, tt.want)
+		}
+	}
+	if v := interp.Var("ran").String(); v != "1" {
+		t.Errorf("ran = %q; want 1, as only SubstAll runs commands", v)
+	}
+
+	if got, err := interp.Subst(`$a($k)`, feather.SubstVariables); err != nil || got.String() != "elem" {
+		t.Errorf("Subst of an indexed name = %v, %v; want elem", got, err)
+	}
+	_, err := interp.Subst("$nosuch", feather.SubstVariables)
+	if err == nil || err.Error() != `can't read "nosuch": no such variable` {
+		t.Errorf("Subst of a missing variable: err = %v", err)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	t.Run("Feed", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		repl := feather.NewREPL(interp)
+		var history []string
+		repl.History = func(command string) { history = append(history, command) }
+
+		if repl.Prompt() != "% " {
+			t.Errorf("Prompt() = %q; want '%% '", repl.Prompt())
+		}
+		res, err := repl.Feed("proc double {x} {")
+		if err != nil || !res.Incomplete {
+			t.Fatalf("Feed = %+v, %v; want incomplete", res, err)
+		}
+		if repl.Prompt() != "> " {
+			t.Errorf("Prompt() = %q; want '> '", repl.Prompt())
+		}
+		repl.Feed("  expr {$x * 2}")
+		if res, err = repl.Feed("}"); err != nil || res.Incomplete {
+			t.Fatalf("Feed = %+v, %v; want complete", res, err)
+		}
+		res, err = repl.Feed("double 21")
+		if err != nil || res.Value.String() != "42" {
+			t.Errorf("Feed = %+v, %v; want 42", res, err)
+		}
+		if _, err = repl.Feed("nosuchcommand"); err == nil {
+			t.Error("expected an error")
+		}
+		want := []string{"proc double {x} {\n  expr {$x * 2}\n}", "double 21", "nosuchcommand"}
+		if !slices.Equal(history, want) {
+			t.Errorf("history = %q; want %q", history, want)
+		}
+	})
+
+	t.Run("Discard", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		repl := feather.NewREPL(interp)
+		repl.Feed("set x {")
+		if repl.Pending() != "set x {" {
+			t.Errorf("Pending() = %q", repl.Pending())
+		}
+		repl.Discard()
+		if res, err := repl.Feed("set x 1"); err != nil || res.Value.String() != "1" {
+			t.Errorf("Feed = %+v, %v; want 1", res, err)
+		}
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		repl := feather.NewREPL(interp)
+		in := strings.NewReader("set x {a\nb}\nset y \"\"\nbad\n")
+		var out bytes.Buffer
+		if err := repl.Run(in, &out); err != nil {
+			t.Fatal(err)
+		}
+		want := "% > a\nb\n% % error: invalid command name \"bad\"\n% "
+		if out.String() != want {
+			t.Errorf("output = %q; want %q", out.String(), want)
+		}
+	})
+}
+
+func TestComplete(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.Eval("namespace eval ::geo {proc area {} {}; namespace eval shapes {}}; set total 1")
+
+	texts := func(cs []feather.Completion) []string {
+		var out []string
+		for _, c := range cs {
+			out = append(out, c.Kind+":"+c.Text)
+		}
+		return out
+	}
+	tests := []struct {
+		script string
+		want   []string
+		start  int
+	}{
+		{"llen", []string{"command:llength"}, 0},
+		{"puts a; llen", []string{"command:llength"}, 8},
+		{"set x [string tou", []string{"subcommand:toupper"}, 14},
+		{"if {1} {dict getd", []string{"subcommand:getdef"}, 13},
+		{"puts $tot", []string{"variable:$total"}, 5},
+		{"geo", []string{"namespace:geo::"}, 0},
+		{"geo::", []string{"command:geo::area", "namespace:geo::shapes::"}, 0},
+		{"::geo::a", []string{"command:::geo::area"}, 0},
+	}
+	for _, tt := range tests {
+		got := interp.Complete(tt.script, len(tt.script))
+		if !slices.Equal(texts(got), tt.want) {
+			t.Errorf("Complete(%q) = %q; want %q", tt.script, texts(got), tt.want)
+			continue
+		}
+		if got[0].Start != tt.start {
+			t.Errorf("Complete(%q) start = %d; want %d", tt.script, got[0].Start, tt.start)
+		}
+	}
+
+	t.Run("Does not evaluate", func(t *testing.T) {
+		interp.Complete("set ran [set ran 1]; pu", 23)
+		if _, err := interp.Eval("set ran"); err == nil {
+			t.Error("completion ran the script")
+		}
+	})
+}
+
+// =============================================================================
+// Event Loop
+// =============================================================================
+
+func TestEventLoop(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("DoOneEvent", func(t *testing.T) {
+		if interp.DoOneEvent() {
+			t.Fatal("DoOneEvent processed an event on an empty queue")
+		}
+		interp.Eval("after idle {set idled 1}")
+		if !interp.DoOneEvent() {
+			t.Fatal("DoOneEvent did not run the idle callback")
+		}
+		if v := interp.Var("idled").String(); v != "1" {
+			t.Errorf("idled = %q; want '1'", v)
+		}
+	})
+
+	t.Run("Post from another goroutine", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		go func() {
+			interp.Post(func() {
+				interp.SetVar("posted", "yes")
+				cancel()
+			})
+		}()
+		if err := interp.RunEventLoop(ctx); err != context.Canceled {
+			t.Fatalf("RunEventLoop = %v; want context.Canceled", err)
+		}
+		if v := interp.Var("posted").String(); v != "yes" {
+			t.Errorf("posted = %q; want 'yes'", v)
+		}
+	})
+
+	t.Run("vwait woken by Post", func(t *testing.T) {
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			interp.Post(func() { interp.Eval("set ready done") })
+		}()
+		result, err := interp.Eval("vwait ready; set ready")
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "done" {
+			t.Errorf("ready = %q; want 'done'", result.String())
+		}
+	})
+
+	t.Run("RunEventLoop runs timers", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		interp.RegisterCommand("stop", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			cancel()
+			return feather.OK("")
+		})
+		interp.Eval("set ticks 0; after 1 {incr ticks}; after 2 {incr ticks; stop}")
+		interp.RunEventLoop(ctx)
+		if v := interp.Var("ticks").String(); v != "2" {
+			t.Errorf("ticks = %q; want '2'", v)
+		}
+	})
+
+	t.Run("Send and EvalAsync", func(t *testing.T) {
+		server := feather.New()
+		defer server.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error)
+		go func() { served <- server.Serve(ctx) }()
+
+		var wg sync.WaitGroup
+		for n := 0; n < 10; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				server.Send(func(i *feather.Interp) { i.Eval("incr hits") })
+			}()
+		}
+		wg.Wait()
+		res := <-server.EvalAsync("set hits")
+		if res.Err != nil || res.Value != "10" {
+			t.Errorf("EvalAsync = %+v; want hits 10", res)
+		}
+		res = <-server.EvalAsync("error boom")
+		if res.Err == nil || res.Err.Error() != "boom" {
+			t.Errorf("EvalAsync error = %v; want boom", res.Err)
+		}
+
+		cancel()
+		if err := <-served; err != context.Canceled {
+			t.Errorf("Serve = %v; want context.Canceled", err)
+		}
+		if err := server.Send(func(*feather.Interp) {}); err != context.Canceled {
+			t.Errorf("Send after Serve stopped = %v; want context.Canceled", err)
+		}
+	})
+}
+
+func TestAsync(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	release := make(chan struct{})
+	interp.RegisterCommand("slow", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+		val := args[0].String()
+		return feather.Async(func(ctx context.Context) (any, error) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if val == "fail" {
+				return nil, errors.New("slow failed")
+			}
+			return []string{val, "done"}, nil
+		})
+	})
+
+	t.Run("await", func(t *testing.T) {
+		f := interp.MustEval("set f [slow a]").String()
+		if f != "future#0" {
+			t.Errorf("future = %q; want future#0", f)
+		}
+		if got := interp.MustEval("future status $f").String(); got != "pending" {
+			t.Errorf("status = %q; want pending", got)
+		}
+		// Events keep running while await waits
+		interp.MustEval("after 0 {set ticked 1}")
+		go func() { release <- struct{}{} }()
+		if got := interp.MustEval("await $f").String(); got != "a done" {
+			t.Errorf("await = %q; want 'a done'", got)
+		}
+		if interp.Var("ticked").String() != "1" {
+			t.Error("after event did not run during await")
+		}
+		if _, err := interp.Eval("await $f"); err == nil {
+			t.Error("expected error awaiting a future twice")
+		}
+
+		go func() { release <- struct{}{} }()
+		_, err := interp.Eval("await [slow fail]")
+		if err == nil || err.Error() != "slow failed" {
+			t.Errorf("await = %v; want 'slow failed'", err)
+		}
+	})
+
+	t.Run("then", func(t *testing.T) {
+		interp.MustEval(`
+			set results {}
+			future then [slow b] {lappend results}
+		`)
+		go func() { release <- struct{}{} }()
+		interp.MustEval("vwait results")
+		if got := interp.Var("results").String(); got != "ok {b done}" {
+			t.Errorf("results = %q; want 'ok {b done}'", got)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		interp.MustEval("set f [slow c]; future cancel $f")
+		if got := interp.MustEval("future status $f").String(); got != "error" {
+			t.Errorf("status = %q; want error", got)
+		}
+		if _, err := interp.Eval("await $f"); err == nil || err.Error() != "future cancelled" {
+			t.Errorf("await = %v; want 'future cancelled'", err)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		interp.MustEval("slow d")
+		interp.Reset()
+		if got := interp.MustEval("future names").String(); got != "" {
+			t.Errorf("future names = %q; want none after Reset", got)
+		}
+	})
+}
+
+// =============================================================================
+// Traces
+// =============================================================================
+
+func TestNprocCleanup(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	before := interp.MustEval(`llength [info commands ::tcl::trace::*]`).String()
+
+	// Each nproc follows its name with a command trace while it exists
+	interp.MustEval(`
+		for {set k 0} {$k < 100} {incr k} {
+			nproc p$k [list -o$k] { return }
+			nproc p$k [list -o$k -x] { return }
+			rename p$k q$k
+			rename q$k ""
+		}
+	`)
+	if got := interp.MustEval(`llength [info commands ::tcl::trace::*]`).String(); got != before {
+		t.Errorf("trace commands after deleting the nprocs = %s; want %s", got, before)
+	}
+}
+
+func TestTraces(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("TraceVar", func(t *testing.T) {
+		var seen []string
+		untrace, err := interp.TraceVar("x", "write unset", func(info feather.VarTraceInfo) error {
+			seen = append(seen, info.Name+" "+info.Op)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("TraceVar failed: %v", err)
+		}
+		interp.Eval("set x 1; set x; unset x")
+		if got := strings.Join(seen, ","); got != "x write,x unset" {
+			t.Errorf("traces = %q; want 'x write,x unset'", got)
+		}
+
+		untrace()
+		interp.Eval("set x 2")
+		if len(seen) != 2 {
+			t.Errorf("trace fired after removal: %v", seen)
+		}
+	})
+
+	t.Run("TraceVar with interpreters on other goroutines", func(t *testing.T) {
+		// Each interpreter counts its own traces, including those left
+		// when it is closed
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					other := feather.New()
+					other.Eval("trace add variable v write {apply {args {}}}; set v 1")
+					other.Eval("trace remove variable v write {apply {args {}}}")
+					other.Eval("trace add variable w write {apply {args {}}}")
+					other.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		seen := 0
+		untrace, err := interp.TraceVar("y", "write", func(feather.VarTraceInfo) error {
+			seen++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("TraceVar failed: %v", err)
+		}
+		defer untrace()
+		interp.Eval("set y 1")
+		if seen != 1 {
+			t.Errorf("trace fired %d times; want 1", seen)
+		}
+	})
+
+	t.Run("TraceVar error", func(t *testing.T) {
+		untrace, err := interp.TraceVar("locked", "write", func(feather.VarTraceInfo) error {
+			return errors.New("read-only")
+		})
+		if err != nil {
+			t.Fatalf("TraceVar failed: %v", err)
+		}
+		defer untrace()
+		_, err = interp.Eval("set locked 1")
+		if err == nil || err.Error() != `can't set "locked": read-only` {
+			t.Errorf("expected trace error, got %v", err)
+		}
+	})
+
+	t.Run("TraceCommand", func(t *testing.T) {
+		interp.Eval("proc old {} {}")
+		var got feather.CommandTraceInfo
+		if _, err := interp.TraceCommand("old", "rename", func(info feather.CommandTraceInfo) {
+			got = info
+		}); err != nil {
+			t.Fatalf("TraceCommand failed: %v", err)
+		}
+		interp.Eval("rename old new")
+		want := feather.CommandTraceInfo{OldName: "::old", NewName: "::new", Op: "rename"}
+		if got != want {
+			t.Errorf("trace = %+v; want %+v", got, want)
+		}
+	})
+
+	t.Run("TraceExecution", func(t *testing.T) {
+		interp.Eval("proc double {n} { expr {$n * 2} }")
+		var seen []string
+		if _, err := interp.TraceExecution("double", "enter leave", func(info feather.ExecTraceInfo) error {
+			if info.Op == "leave" {
+				seen = append(seen, fmt.Sprintf("leave %s %d %s", info.Command, info.Code, info.Result))
+			} else {
+				seen = append(seen, "enter "+info.Command.String())
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("TraceExecution failed: %v", err)
+		}
+		interp.Eval("double 21")
+		want := "enter double 21|leave double 21 0 42"
+		if got := strings.Join(seen, "|"); got != want {
+			t.Errorf("traces = %q; want %q", got, want)
+		}
+	})
+
+	t.Run("invalid ops", func(t *testing.T) {
+		_, err := interp.TraceVar("x", "bogus", func(feather.VarTraceInfo) error { return nil })
+		if err == nil {
+			t.Error("expected error for invalid trace operation")
+		}
+	})
+}
+
+func TestBindVar(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	var cfg struct {
+		Port  int
+		Host  string
+		Debug bool
+		Ratio float64
+		Small uint8
+	}
+	cfg.Port, cfg.Host = 80, "localhost"
+	var hits atomic.Int64
+
+	for name, ptr := range map[string]any{"port": &cfg.Port, "host": &cfg.Host, "debug": &cfg.Debug, "cfg::ratio": &cfg.Ratio, "small": &cfg.Small} {
+		if _, err := interp.BindVar(name, ptr); err != nil {
+			t.Fatalf("BindVar %s: %v", name, err)
+		}
+	}
+	unbindHits, err := interp.BindVarReadOnly("hits", &hits)
+	if err != nil {
+		t.Fatalf("BindVarReadOnly: %v", err)
+	}
+
+	interp.MustEval("set port 8080; set host example.com; set debug yes; set cfg::ratio 0.5")
+	if cfg.Port != 8080 || cfg.Host != "example.com" || !cfg.Debug || cfg.Ratio != 0.5 {
+		t.Errorf("cfg = %+v after writes", cfg)
+	}
+	if got := interp.MustEval("proc p {} { global port; incr port }; p").String(); got != "8081" || cfg.Port != 8081 {
+		t.Errorf("incr in proc = %q, cfg.Port = %d; want 8081", got, cfg.Port)
+	}
+
+	cfg.Host = "changed"
+	hits.Store(3)
+	if got := interp.MustEval(`list $host [namespace eval cfg {set ratio}] $hits`).String(); got != "changed 0.5 3" {
+		t.Errorf("reads = %q, want %q", got, "changed 0.5 3")
+	}
+
+	errs := map[string]string{
+		"set port abc":  `can't set "port": expected integer but got "abc"`,
+		"set small 300": `can't set "small": integer value 300 out of range`,
+		"set hits 4":    `can't set "hits": linked variable is read-only`,
+	}
+	for script, want := range errs {
+		if _, err := interp.Eval(script); err == nil || err.Error() != want {
+			t.Errorf("%s: error = %v, want %q", script, err, want)
+		}
+	}
+	if got := interp.MustEval("list $port $small $hits").String(); got != "8081 0 3" {
+		t.Errorf("after failed writes = %q, want %q", got, "8081 0 3")
+	}
+
+	unbindHits()
+	hits.Store(10)
+	if got := interp.MustEval("set hits").String(); got != "3" {
+		t.Errorf("hits after unbind = %q, want 3", got)
+	}
+	interp.MustEval("set hits 5")
+	if hits.Load() != 10 {
+		t.Errorf("hits = %d after writing unbound variable, want 10", hits.Load())
+	}
+
+	interp.MustEval("unset port; set port 1")
+	if cfg.Port != 8081 {
+		t.Errorf("cfg.Port = %d after unset, want 8081", cfg.Port)
+	}
+
+	if _, err := interp.BindVar("bad", cfg); err == nil {
+		t.Error("expected error binding a non-pointer")
+	}
+}
+
+func TestEvalHook(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	var seen []string
+	interp.SetEvalHook(func(e feather.EvalEvent) {
+		args := make([]string, len(e.Args))
+		for n, a := range e.Args {
+			args[n] = a.String()
+		}
+		seen = append(seen, fmt.Sprintf("%d:%d %s %s", e.Level, e.Line, e.Name, strings.Join(args, " ")))
+		if e.Name == "set" {
+			// Commands run by the hook are not reported
+			interp.Eval("llength {a b}")
+		}
+	})
+	_, err := interp.Eval("proc sq {n} {\n  expr {$n * $n}\n}\nset x [sq 3]")
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	want := []string{
+		"0:1 proc sq n \n  expr {$n * $n}\n",
+		"0:4 sq 3",
+		"1:2 expr $n * $n", // proc bodies report lines of the script defining them
+		"0:4 set x 9",
+	}
+	if !slices.Equal(seen, want) {
+		t.Errorf("events = %q; want %q", seen, want)
+	}
+
+	interp.SetEvalHook(nil)
+	seen = nil
+	interp.Eval("set y 1")
+	if len(seen) != 0 {
+		t.Errorf("hook called after removal: %q", seen)
+	}
+
+	// Hooks installed and removed on other goroutines leave this
+	// interpreter's alone
+	count := 0
+	interp.SetEvalHook(func(feather.EvalEvent) { count++ })
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			other := feather.New()
+			defer other.Close()
+			for range 200 {
+				other.SetEvalHook(func(feather.EvalEvent) {})
+				other.Eval("set x 1")
+				other.SetEvalHook(nil)
+			}
+		}()
+	}
+	wg.Wait()
+	interp.Eval("set a 1; set b 2")
+	if count != 2 {
+		t.Errorf("hook saw %d commands; want 2", count)
+	}
+}
+
+func TestDebugger(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	interp.MustEval("# add sums its arguments\nproc add {a b} {\n  set sum [expr {$a + $b}]\n  return $sum\n}")
+	script := "set x 1\nset y [add $x 2]\nset z [expr {$x + $y}]"
+
+	var stops []string
+	interp.SetBreakpoint("", 3)
+	interp.SetDebugger(func(e feather.EvalEvent) feather.DebugAction {
+		stops = append(stops, fmt.Sprintf("%d:%d %s", e.Level, e.Line, e.Name))
+		switch e.Name {
+		case "set":
+			if e.Level == 1 {
+				return feather.DebugStepOver
+			}
+		case "return":
+			frames := interp.Frames()
+			if len(frames) != 2 || frames[1].Command != "::add" {
+				t.Errorf("Frames = %+v; want global and ::add", frames)
+			}
+			sum, ok := interp.VarAtLevel(e.Level, "sum")
+			if !ok || sum.String() != "3" {
+				t.Errorf("VarAtLevel(sum) = %v, %v; want 3", sum, ok)
+			}
+			if err := interp.SetVarAtLevel(0, "x", interp.Int(10)); err != nil {
+				t.Errorf("SetVarAtLevel failed: %v", err)
+			}
+			double, err := interp.EvalInFrame(1, "expr {$sum * 2}")
+			if err != nil || double.String() != "6" {
+				t.Errorf("EvalInFrame = %v, %v; want 6", double, err)
+			}
+			return feather.DebugStepOut
+		}
+		return feather.DebugStepInto
+	})
+
+	if _, err := interp.Eval(script); err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	want := []string{
+		"1:3 expr",   // breakpoint on line 3, in the proc body
+		"1:3 set",    // step into
+		"1:4 return", // step over
+		"0:2 set",    // step out
+		"0:3 expr",   // step into the command substitution on line 3
+		"0:3 set",    // step into
+	}
+	if !slices.Equal(stops, want) {
+		t.Errorf("stops = %q; want %q", stops, want)
+	}
+	if z := interp.Var("z").String(); z != "13" {
+		t.Errorf("z = %q; want 13 after SetVarAtLevel", z)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	interp.MustEval("proc sq {n} { expr {$n * $n} }")
+	interp.StartProfile()
+	interp.MustEval("foreach n {1 2 3} { sq $n }")
+	report := interp.StopProfile()
+	interp.MustEval("sq 4")
+
+	calls := make(map[string]string)
+	for _, e := range report.Entries {
+		calls[e.Name] = fmt.Sprintf("%s %d", e.Kind, e.Calls)
+		if e.Exclusive > e.Inclusive {
+			t.Errorf("%s: exclusive %v > inclusive %v", e.Name, e.Exclusive, e.Inclusive)
+		}
+	}
+	want := map[string]string{
+		"::foreach": "builtin 1",
+		"::sq":      "proc 3",
+		"::expr":    "builtin 3",
+	}
+	if !maps.Equal(calls, want) {
+		t.Errorf("calls = %v; want %v", calls, want)
+	}
+	if !slices.IsSortedFunc(report.Entries, func(a, b feather.ProfileEntry) int {
+		return cmp.Compare(b.Exclusive, a.Exclusive)
+	}) {
+		t.Errorf("entries not sorted by exclusive time: %+v", report.Entries)
+	}
+	if again := interp.StopProfile(); len(again.Entries) != len(report.Entries) {
+		t.Errorf("StopProfile after stopping = %+v; want the last report", again)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetSourceLoader(func(path string) (string, error) {
+		return "proc classify {n} {\n" +
+			"    if {$n < 0} {\n" +
+			"        return negative\n" +
+			"    }\n" +
+			"    return positive\n" +
+			"}\n", nil
+	})
+
+	interp.EnableCoverage()
+	interp.MustEval("source lib.tcl\nclassify 1\nclassify 2")
+	report := interp.CoverageReport()
+	interp.DisableCoverage()
+	interp.MustEval("classify -1")
+
+	want := []feather.FileCoverage{
+		{File: "", Lines: map[int]int{1: 1, 2: 1, 3: 1}},
+		{File: "lib.tcl", Lines: map[int]int{1: 1, 2: 2, 5: 2}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("CoverageReport = %v; want %v", report, want)
+	}
+	if again := interp.CoverageReport(); !reflect.DeepEqual(again, want) {
+		t.Errorf("CoverageReport after DisableCoverage = %v; want %v", again, want)
+	}
+
+	result, err := interp.Eval("coverage on; classify -1; coverage off; coverage report")
+	if err != nil {
+		t.Fatalf("coverage command: %v", err)
+	}
+	if got := result.String(); got != "{} {1 2} lib.tcl {2 1 3 1}" {
+		t.Errorf("coverage report = %q", got)
+	}
+	if _, err := interp.Eval("coverage bogus"); err == nil || err.Error() != `bad option "bogus": must be off, on, or report` {
+		t.Errorf("coverage bogus: %v", err)
+	}
+}
+
+// =============================================================================
+// Scripts and Packages
+// =============================================================================
+
+func TestSource(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	scripts := map[string]string{
+		"app/main.tcl":     "set order [list [info script]]\nsource lib/util.tcl\nlappend order [info script]",
+		"app/lib/util.tcl": "lappend order [info script]\nreturn done\nlappend order unreachable",
+	}
+	var loaded []string
+	interp.SetSourceLoader(func(path string) (string, error) {
+		loaded = append(loaded, path)
+		script, ok := scripts[path]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return script, nil
+	})
+
+	t.Run("relative to the sourcing script", func(t *testing.T) {
+		if _, err := interp.SourceFile("app/main.tcl"); err != nil {
+			t.Fatalf("SourceFile failed: %v", err)
+		}
+		want := []string{"app/main.tcl", "app/lib/util.tcl"}
+		if !slices.Equal(loaded, want) {
+			t.Errorf("loaded = %q; want %q", loaded, want)
+		}
+		if got := interp.Var("order").String(); got != "app/main.tcl app/lib/util.tcl app/main.tcl" {
+			t.Errorf("order = %q", got)
+		}
+		if got := interp.MustEval("info script").String(); got != "" {
+			t.Errorf("info script after source = %q; want empty", got)
+		}
+	})
+
+	t.Run("return ends the script", func(t *testing.T) {
+		if got := interp.MustEval("source app/lib/util.tcl").String(); got != "done" {
+			t.Errorf("source = %q; want done", got)
+		}
+	})
+
+	t.Run("loader errors", func(t *testing.T) {
+		_, err := interp.Eval("source missing.tcl")
+		if err == nil || err.Error() != `couldn't read file "missing.tcl": not found` {
+			t.Errorf("source missing.tcl = %v", err)
+		}
+	})
+
+	t.Run("default loader reads files", func(t *testing.T) {
+		interp.SetSourceLoader(nil)
+		dir := t.TempDir()
+		path := filepath.Join(dir, "x.tcl")
+		if err := os.WriteFile(path, []byte("expr {6 * 7}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := interp.SourceFile(path); err != nil || got.String() != "42" {
+			t.Errorf("SourceFile = %v, %v; want 42", got, err)
+		}
+		_, err := interp.SourceFile(filepath.Join(dir, "missing.tcl"))
+		if err == nil || !strings.HasSuffix(err.Error(), ": no such file or directory") {
+			t.Errorf("SourceFile(missing) = %v", err)
+		}
+	})
+}
+
+func TestPackages(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("RegisterPackage runs init on first require", func(t *testing.T) {
+		inits := 0
+		interp.RegisterPackage("geo", "1.2", func(i *feather.Interp) error {
+			inits++
+			i.Register("geo::double", func(n int) int { return 2 * n })
+			return nil
+		})
+		if inits != 0 {
+			t.Fatal("init ran before the package was required")
+		}
+		if got := interp.MustEval("package require geo 1.0").String(); got != "1.2" {
+			t.Errorf("package require geo = %q; want 1.2", got)
+		}
+		interp.MustEval("package require geo")
+		if inits != 1 {
+			t.Errorf("init ran %d times; want 1", inits)
+		}
+		if got := interp.MustEval("geo::double 21").String(); got != "42" {
+			t.Errorf("geo::double 21 = %q; want 42", got)
+		}
+	})
+
+	t.Run("init errors", func(t *testing.T) {
+		interp.RegisterPackage("broken", "1.0", func(i *feather.Interp) error {
+			return errors.New("no database")
+		})
+		if _, err := interp.Eval("package require broken"); err == nil || err.Error() != "no database" {
+			t.Errorf("package require broken = %v; want no database", err)
+		}
+		if got := interp.MustEval("package provide broken").String(); got != "" {
+			t.Errorf("broken provided %q after failing", got)
+		}
+	})
+
+	t.Run("bad version panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("RegisterPackage with a bad version did not panic")
+			}
+		}()
+		interp.RegisterPackage("bad", "one", func(*feather.Interp) error { return nil })
+	})
+
+	t.Run("auto_path is searched through the source loader", func(t *testing.T) {
+		scripts := map[string]string{
+			"lib/text/pkgIndex.tcl": "package ifneeded text 1.0 [list source $dir/text.tcl]",
+			"lib/text/text.tcl":     "source upper.tcl\npackage provide text 1.0",
+			"lib/text/upper.tcl":    "namespace eval text { proc upper {s} { string toupper $s } }",
+			"lib/util.tcl":          "package provide util 0.1",
+		}
+		interp.SetSourceLoader(func(path string) (string, error) {
+			script, ok := scripts[path]
+			if !ok {
+				return "", errors.New("not found")
+			}
+			return script, nil
+		})
+		defer interp.SetSourceLoader(nil)
+
+		interp.MustEval("set auto_path lib")
+		if got := interp.MustEval("package require text; text::upper abc").String(); got != "ABC" {
+			t.Errorf("text::upper abc = %q; want ABC", got)
+		}
+		if got := interp.MustEval("package require util").String(); got != "0.1" {
+			t.Errorf("package require util = %q; want 0.1", got)
+		}
+		if _, err := interp.Eval("package require nosuch"); err == nil || err.Error() != "can't find package nosuch" {
+			t.Errorf("package require nosuch = %v", err)
+		}
+	})
+}
+
+func TestBundle(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	lib := fstest.MapFS{
+		"lib/greet/pkgIndex.tcl": {Data: []byte("package ifneeded greet 1.0 [list source $dir/greet.tcl]")},
+		"lib/greet/greet.tcl":    {Data: []byte("package provide greet 1.0\nsource helpers.tcl")},
+		"lib/greet/helpers.tcl": {Data: []byte(
+			"namespace eval greet { proc hello {} { return hello } }\nset ::helpers [info script]")},
+		"other/skip.tcl": {Data: []byte("error unreachable")},
+	}
+	interp.SetSourceLoader(func(path string) (string, error) {
+		if path == "disk.tcl" {
+			return "set ::from disk", nil
+		}
+		return "", errors.New("not found")
+	})
+	feather.Bundle(lib, "lib").Install(interp)
+	feather.Bundle(lib, "lib").Install(interp)
+
+	if got := interp.MustEval("set auto_path").String(); got != "lib" {
+		t.Errorf("auto_path = %q; want lib once", got)
+	}
+	if got := interp.MustEval("package require greet; greet::hello").String(); got != "hello" {
+		t.Errorf("greet::hello = %q; want hello", got)
+	}
+	if got := interp.Var("helpers").String(); got != "lib/greet/helpers.tcl" {
+		t.Errorf("info script in helpers = %q", got)
+	}
+	if got := interp.MustEval("source disk.tcl").String(); got != "disk" {
+		t.Errorf("source disk.tcl = %q; want the earlier loader's script", got)
+	}
+	if _, err := interp.Eval("source other/skip.tcl"); err == nil {
+		t.Error("source read a file outside the bundle's root")
+	}
+}
+
+func TestRunTestFile(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	var out bytes.Buffer
+	interp.SetStdout(&out)
+	interp.SetSourceLoader(func(path string) (string, error) {
+		return `package require tcltest
+namespace import ::tcltest::*
+testConstraint bigMemory 0
+test add-1.1 {adds} -body {expr {1 + 2}} -result 3
+test add-1.2 {wrong} -body {expr {1 + 2}} -result 4
+test add-1.3 {big} -constraints bigMemory -body {expr 1} -result 1
+test add-1.4 {unix or big} -constraints {unix || bigMemory} -body {expr 1} -result 1
+test add-1.5 {error} -body {error oops} -returnCodes error -match glob -result oo*
+test add-1.6 {setup} -setup {set x 5} -body {incr x} -cleanup {unset x} -result 6
+test add-1.7 {output} -body {puts hi} -output "hi\n"
+test add-1.8 {old form} {expr {2 * 3}} 6
+test add-1.9 {unexpected error} -body {error boom} -result boom
+cleanupTests
+`, nil
+	})
+
+	report, err := feather.RunTestFile(interp, "add.test")
+	if err != nil {
+		t.Fatalf("RunTestFile: %v", err)
+	}
+	var got []string
+	for _, r := range report.Tests {
+		got = append(got, r.Name+" "+r.Status.String()+" "+r.SkippedBy)
+	}
+	want := []string{
+		"add-1.1 passed ", "add-1.2 failed ", "add-1.3 skipped bigMemory", "add-1.4 passed ",
+		"add-1.5 passed ", "add-1.6 passed ", "add-1.7 passed ", "add-1.8 passed ", "add-1.9 failed ",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %q; want %q", got, want)
+	}
+	if n := report.Count(feather.TestFailed); n != 2 {
+		t.Errorf("Count(TestFailed) = %d; want 2", n)
+	}
+	if r := report.Tests[1].Report; !strings.Contains(r, "==== add-1.2 wrong FAILED\n") ||
+		!strings.Contains(r, "---- Result was:\n3\n---- Result should have been (exact matching):\n4\n") {
+		t.Errorf("report of add-1.2 = %q", r)
+	}
+	if r := report.Tests[8].Report; !strings.Contains(r, "---- Test generated error; Return code was: 1\n---- Return code should have been one of: 0 2\n") {
+		t.Errorf("report of add-1.9 = %q", r)
+	}
+	if !strings.Contains(out.String(), report.Tests[1].Report) {
+		t.Error("failure report not printed to stdout")
+	}
+	summary := "add.test:\tTotal\t9\tPassed\t6\tSkipped\t1\tFailed\t2\n" +
+		"Number of tests skipped for each constraint:\n\t1\tbigMemory\n"
+	if !strings.HasSuffix(out.String(), summary) {
+		t.Errorf("stdout = %q; want it to end with %q", out.String(), summary)
+	}
+	if _, err := interp.Eval("test x-1.1 {bad} -body {} -bogus 1"); err == nil || !strings.HasPrefix(err.Error(), `bad option "-bogus"`) {
+		t.Errorf("test with a bad option: %v", err)
+	}
+}
+
+// =============================================================================
+// Hooks
+// =============================================================================
+
+func TestHooks(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	hooks := interp.Hooks()
+	hooks.Define("on-request", feather.HookSpec{Params: []string{"req"}})
+	hooks.Define("on-save", feather.HookSpec{Params: []string{"name", "size"}, Errors: feather.CollectErrors})
+
+	interp.MustEval(`
+		hook add on-request {req} { return "second $req" }
+		hook add -priority -5 on-request {req} { return "first $req" }
+		namespace eval audit { variable seen {} }
+		namespace eval audit { hook add on-request {args} { variable seen; lappend seen $args; return third } }
+	`)
+	results, err := hooks.Run("on-request", "/index")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.String())
+	}
+	if strings.Join(got, ", ") != "first /index, second /index, third" {
+		t.Errorf("results = %v; want the handlers in priority order", got)
+	}
+	if seen := interp.MustEval("set audit::seen").String(); seen != "/index" {
+		t.Errorf("audit::seen = %q; want /index", seen)
+	}
+	if ids := hooks.Handlers("on-request"); len(ids) != 3 || ids[0] != "hook2" {
+		t.Errorf("Handlers = %v; want hook2 first", ids)
+	}
+
+	if _, err := interp.Eval("hook add on-request {a b} {}"); err == nil ||
+		err.Error() != `handler for hook "on-request" must take 1 argument: req` {
+		t.Errorf("hook add with wrong params: %v", err)
+	}
+	if _, err := interp.Eval("hook add nosuch {} {}"); err == nil {
+		t.Error("expected error adding to an unknown hook")
+	}
+	if _, err := hooks.Run("on-request"); err == nil {
+		t.Error("expected error running with too few arguments")
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		interp.MustEval(`
+			hook add on-request {req} { error "rejected $req" }
+			hook add on-save {name size} { error "no space for $name" }
+			hook add on-save {name size} { return $size }
+			hook add on-save {name size} { throw {DISK FULL} "disk full" }
+		`)
+		results, err := hooks.Run("on-request", "/admin")
+		var evalErr *feather.EvalError
+		if !errors.As(err, &evalErr) || evalErr.Message != "rejected /admin" || len(results) != 3 {
+			t.Errorf("Run = %d results, %v; want 3 and the first error", len(results), err)
+		}
+
+		results, err = hooks.Run("on-save", "a.txt", 10)
+		if len(results) != 1 || results[0].String() != "10" {
+			t.Errorf("results = %v; want the one that succeeded", results)
+		}
+		if err == nil || err.Error() != "no space for a.txt\ndisk full" {
+			t.Errorf("Run error = %v; want both errors", err)
+		}
+		if !errors.As(err, &evalErr) || evalErr.Message != "no space for a.txt" {
+			t.Errorf("joined errors are not *EvalError: %v", err)
+		}
+	})
+
+	t.Run("Script", func(t *testing.T) {
+		if got := interp.MustEval("hook list").String(); got != "on-request on-save" {
+			t.Errorf("hook list = %q", got)
+		}
+		interp.MustEval("hook remove hook4")
+		if got := interp.MustEval("hook list on-request").String(); got != "hook2 hook1 hook3" {
+			t.Errorf("hook list on-request = %q; want hook2 hook1 hook3", got)
+		}
+		if got := interp.MustEval("hook run on-request /x").String(); got != "{first /x} {second /x} third" {
+			t.Errorf("hook run = %q", got)
+		}
+		if _, err := interp.Eval("hook remove hook4"); err == nil {
+			t.Error("expected error removing a removed handler")
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		interp.Reset()
+		if ids := hooks.Handlers("on-request"); len(ids) != 0 {
+			t.Errorf("Handlers after Reset = %v; want none", ids)
+		}
+		if got := interp.MustEval("hook list").String(); got != "on-request on-save" {
+			t.Errorf("hook list after Reset = %q; want the hooks kept", got)
+		}
+	})
+}
+
+// =============================================================================
+// Processes
+// =============================================================================
+
+func TestExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run")
+	}
+	interp := feather.New()
+	defer interp.Close()
+	dir := t.TempDir()
+	interp.SetVar("dir", dir)
+	var stderr bytes.Buffer
+	interp.SetStderr(&stderr)
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"output", `exec echo hello world`, "hello world"},
+		{"keepnewline", `exec -keepnewline echo hi`, "hi\n"},
+		{"pipeline", `exec echo hello | tr a-z A-Z`, "HELLO"},
+		{"input", `exec cat << "from tcl"`, "from tcl"},
+		{"stderr to stdout", `exec sh -c "echo out; echo err >&2" 2>@1`, "out\nerr"},
+		{"pipe stderr", `exec sh -c "echo err >&2" |& cat`, "err"},
+		{"ignorestderr", `exec -ignorestderr sh -c "echo out; echo err >&2"`, "out"},
+		{"files", `exec echo a > $dir/f; exec echo b >> $dir/f; exec cat < $dir/f`, "a\nb"},
+		{"exit status", `list [catch {exec sh -c "echo out; exit 3"} msg opts] $msg [lreplace [dict get $opts -errorcode] 1 1]`,
+			"1 {out\nchild process exited abnormally} {CHILDSTATUS 3}"},
+		{"stderr is an error", `list [catch {exec sh -c "echo oops >&2"} msg] $msg`, "1 oops"},
+		{"not found", `catch {exec no-such-program-here} msg opts; list $msg [dict get $opts -errorcode]`,
+			`{couldn't execute "no-such-program-here": no such file or directory} {POSIX ENOENT {no such file or directory}}`},
+		{"syntax", `catch {exec echo a |} msg; set msg`, "illegal use of | or |& in command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
 
-	t.Run("Incomplete input - open bracket", func(t *testing.T) {
-		pr := interp.Parse("set x [expr")
-		if pr.Status != feather.ParseIncomplete {
-			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
+	if stderr.String() != "err\n" {
+		t.Errorf("stderr = %q; want what -ignorestderr ignored", stderr.String())
+	}
+
+	t.Run("background", func(t *testing.T) {
+		result := interp.MustEval(`exec echo later > $dir/bg &`)
+		if _, err := result.Int(); err != nil {
+			t.Errorf("exec & = %q; want a process id", result.String())
 		}
 	})
 
-	t.Run("Incomplete input - open quote", func(t *testing.T) {
-		pr := interp.Parse(`set x "hello`)
-		if pr.Status != feather.ParseIncomplete {
-			t.Errorf("Status = %v; want ParseIncomplete", pr.Status)
+	t.Run("SetExecPolicy", func(t *testing.T) {
+		var seen []string
+		interp.SetExecPolicy(func(cmd *exec.Cmd) error {
+			seen = append(seen, filepath.Base(cmd.Path))
+			if filepath.Base(cmd.Path) == "rm" {
+				return errors.New("rm is not allowed")
+			}
+			cmd.Env = []string{"GREETING=hi"}
+			return nil
+		})
+		defer interp.SetExecPolicy(nil)
+
+		if got := interp.MustEval(`exec sh -c {echo $GREETING}`).String(); got != "hi" {
+			t.Errorf("exec with the policy's environment = %q; want hi", got)
+		}
+		_, err := interp.Eval(`exec echo x | rm -rf $dir`)
+		var evalErr *feather.EvalError
+		if !errors.As(err, &evalErr) || evalErr.Message != `couldn't execute "rm": rm is not allowed` {
+			t.Fatalf("denied exec = %v; want rm is not allowed", err)
+		}
+		if evalErr.ErrorCode != "TCL OPERATION EXEC DENIED" {
+			t.Errorf("ErrorCode = %q", evalErr.ErrorCode)
+		}
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("the pipeline ran despite the policy: %v", err)
+		}
+		if !slices.Equal(seen, []string{"sh", "echo", "rm"}) {
+			t.Errorf("policy saw %v; want sh, echo, rm", seen)
+		}
+	})
+}
+
+// =============================================================================
+// Files
+// =============================================================================
+
+func TestFile(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	dir := t.TempDir()
+	interp.SetVar("dir", dir)
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"join", `file join a /b c/ d`, "/b/c/d"},
+		{"dirname", `list [file dirname a/b/c] [file dirname a] [file dirname /a] [file dirname a//b/]`, "a/b . / a"},
+		{"tail", `list [file tail a/b/c] [file tail a/b/] [file tail /]`, "c b {}"},
+		{"extension", `list [file extension a.b.c] [file extension a.b/c] [file extension .tclshrc]`, ".c {} .tclshrc"},
+		{"mkdir", `file mkdir $dir/a/b/c; file exists $dir/a/b`, "1"},
+		{"copy and rename", `
+			exec echo hello > $dir/a/f
+			file copy $dir/a/f $dir/a/b
+			file rename $dir/a/f $dir/g
+			list [file exists $dir/a/f] [file exists $dir/a/b/f] [exec cat $dir/g]`, "0 1 hello"},
+		{"copy directory", `file copy $dir/a $dir/c; file exists $dir/c/b/f`, "1"},
+		{"glob", `lmap f [file glob $dir/*/b] {file tail [file dirname $f]}`, "a c"},
+		{"stat", `file stat $dir/g st; list [dict get $st type] [dict get $st size] [dict get [file stat $dir/a] type]`, "file 6 directory"},
+		{"exists", `catch {file copy $dir/g $dir/a/b/f} msg opts; list [file tail $msg] [dict get $opts -errorcode]`,
+			`{f": file already exists} {POSIX EEXIST {file already exists}}`},
+		{"delete", `file delete $dir/nosuch $dir/g; file exists $dir/g`, "0"},
+		{"not empty", `catch {file delete $dir/c} msg; string match {error deleting *: directory not empty} $msg`, "1"},
+		{"delete force", `file delete -force $dir/c; file exists $dir/c`, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFilesystem(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	interp.SetFilesystem(feather.ReadOnlyFilesystem(fstest.MapFS{
+		"lib/util.tcl":  {Data: []byte("proc util {} {}")},
+		"lib/main.tcl":  {Data: []byte("source util.tcl")},
+		"data/rows.txt": {Data: []byte("1 2 3")},
+	}))
+	tests := []struct {
+		name, script, want string
+	}{
+		{"exists", `list [file exists /lib/util.tcl] [file exists lib/nosuch.tcl]`, "1 0"},
+		{"stat", `dict get [file stat data/rows.txt] size`, "5"},
+		{"glob", `lsort [file glob lib/*.tcl]`, "lib/main.tcl lib/util.tcl"},
+		{"read-only", `catch {file mkdir tmp} msg opts; list $msg [dict get $opts -errorcode]`,
+			`{can't create directory "tmp": read-only file system} {POSIX EROFS {read-only file system}}`},
+		{"outside", `file exists ../etc/passwd`, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("denied", func(t *testing.T) {
+		interp.SetFilesystem(feather.DeniedFilesystem())
+		defer interp.SetFilesystem(nil)
+		_, err := interp.Eval(`file stat lib/util.tcl`)
+		if err == nil || err.Error() != `could not read "lib/util.tcl": permission denied` {
+			t.Errorf("file stat = %v; want permission denied", err)
+		}
+		if got := interp.MustEval(`file join lib util.tcl`).String(); got != "lib/util.tcl" {
+			t.Errorf("file join = %q; name subcommands should still work", got)
+		}
+	})
+}
+
+func TestGlob(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetFilesystem(feather.ReadOnlyFilesystem(fstest.MapFS{
+		"src/main.go":      {},
+		"src/main_test.go": {},
+		"src/lib/util.go":  {},
+		"src/.hidden.go":   {},
+		"doc/readme.md":    {},
+	}))
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"pattern", `glob src/*.go`, "src/main.go src/main_test.go"},
+		{"several patterns", `glob src/*_test.go doc/*`, "src/main_test.go doc/readme.md"},
+		{"braces", `glob {{src,doc}/*.{go,md}}`, "src/main.go src/main_test.go doc/readme.md"},
+		{"nested", `glob */*/*`, "src/lib/util.go"},
+		{"hidden", `glob src/.*`, "src/.hidden.go"},
+		{"directory", `glob -directory src -tails *.go`, "main.go main_test.go"},
+		{"types", `list [glob -types d src/*] [glob -types f src/*]`, "src/lib {src/main.go src/main_test.go}"},
+		{"trailing slash", `glob */`, "doc/ src/"},
+		{"nocomplain", `glob -nocomplain *.txt`, ""},
+		{"no match", `catch {glob *.txt} msg opts; list $msg [dict get $opts -errorcode]`,
+			`{no files matched glob pattern "*.txt"} {TCL OPERATION GLOB NOMATCH}`},
+		{"unbalanced", `catch {glob "src/\{a"} msg; set msg`, "unmatched open-brace in file name"},
+		{"bad type", `catch {glob -types q *} msg; set msg`, `bad argument to "-types": q`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// Environment
+// =============================================================================
+
+func TestEnv(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetEnvPolicy(feather.MapEnvironment(map[string]string{"HOME": "/sandbox", "LANG": "C"}))
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"read", `dict get $env HOME`, "/sandbox"},
+		{"set", `dict set env APP_MODE test; dict get $env APP_MODE`, "test"},
+		{"unset", `dict unset env LANG; dict exists $env LANG`, "0"},
+		{"global", `proc home {} { global env; dict get $env HOME }; home`, "/sandbox"},
+		{"not a dict", `list [catch {set env {a b c}} msg] $msg [dict get $env HOME]`,
+			`1 {can't set "env": missing value to go with key} /sandbox`},
+		{"platform", `list [dict exists $tcl_platform os] [dict get $tcl_platform engine] $tcl_version`, "1 Feather 8.6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("exec", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("no sh to run")
+		}
+		if got := interp.MustEval(`exec sh -c {echo $APP_MODE}`).String(); got != "test" {
+			t.Errorf("exec saw APP_MODE = %q; want test", got)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		interp.SetEnvPolicy(feather.FilterEnvironment(feather.MapEnvironment(map[string]string{
+			"HOME":   "/home/me",
+			"SECRET": "hunter2",
+		}), func(key string) bool { return key != "SECRET" }))
+		if got := interp.MustEval(`dict keys $env`).String(); got != "HOME" {
+			t.Errorf("env keys = %q; want HOME only", got)
+		}
+		if _, err := interp.Eval(`dict set env SECRET x`); err == nil {
+			t.Error("expected error setting a filtered variable")
+		}
+	})
+
+	t.Run("os", func(t *testing.T) {
+		interp.SetEnvPolicy(nil)
+		t.Setenv("FEATHER_TEST_ENV", "from go")
+		if got := interp.MustEval(`dict get $env FEATHER_TEST_ENV`).String(); got != "from go" {
+			t.Errorf("env FEATHER_TEST_ENV = %q", got)
+		}
+		interp.MustEval(`dict set env FEATHER_TEST_ENV "from tcl"`)
+		if got := os.Getenv("FEATHER_TEST_ENV"); got != "from tcl" {
+			t.Errorf("os.Getenv = %q; want the value the script set", got)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		interp.MustEval(`unset env`)
+		interp.Reset()
+		t.Setenv("FEATHER_TEST_ENV", "after reset")
+		if got := interp.MustEval(`dict get $env FEATHER_TEST_ENV`).String(); got != "after reset" {
+			t.Errorf("env after Reset = %q; want it linked again", got)
+		}
+	})
+}
+
+func TestSetArgs(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetArgs("deploy.tcl", []string{"-n", "two words"})
+	if got := interp.MustEval(`list $argv0 $argc [lindex $argv 1]`).String(); got != "deploy.tcl 2 {two words}" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	type Counter struct{ value int }
+	feather.RegisterType[*Counter](interp, "Counter", feather.TypeDef[*Counter]{
+		New: func() *Counter { return &Counter{} },
+	})
+
+	before := interp.Stats()
+	for range 50 {
+		interp.MustEval(`set x [list a b c]; proc p {} {string length abc}; p; rename p {}`)
+	}
+	after := interp.Stats()
+	if after.PermanentObjects != before.PermanentObjects {
+		t.Errorf("PermanentObjects grew from %d to %d", before.PermanentObjects, after.PermanentObjects)
+	}
+	if after.ScratchObjects != 0 {
+		t.Errorf("ScratchObjects = %d between evals; want 0", after.ScratchObjects)
+	}
+	if after.CBytesAllocated <= before.CBytesAllocated || after.CBytesFreed != after.CBytesAllocated {
+		t.Errorf("CBytesAllocated = %d, CBytesFreed = %d; want more lent and all of it given back", after.CBytesAllocated, after.CBytesFreed)
+	}
+
+	interp.MustEval(`set c [Counter new]`)
+	if n := interp.Stats().ForeignInstances; n != 1 {
+		t.Errorf("ForeignInstances = %d after Counter new; want 1", n)
+	}
+	interp.MustEval(`$c destroy`)
+	if n := interp.Stats().ForeignInstances; n != 0 {
+		t.Errorf("ForeignInstances = %d after destroy; want 0", n)
+	}
+}
+
+func TestStringPinsReleased(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	// pinned reports the bytes lent to the C core and not yet given back
+	pinned := func() uint64 {
+		s := interp.Stats()
+		return s.CBytesAllocated - s.CBytesFreed
+	}
+	var during uint64
+	interp.Register("pinned", func() { during = pinned() })
+
+	for n := range 20 {
+		interp.MustEval(`set s [string repeat x 100]; expr {[string length $s] + 1}; pinned`)
+		if during == 0 {
+			t.Fatalf("eval %d: no bytes pinned while it ran", n)
 		}
+		if after := pinned(); after != 0 {
+			t.Fatalf("eval %d: %d bytes still pinned after it returned", n, after)
+		}
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.RegisterCommand("scratch", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+		return feather.OK(i.ScratchSize())
 	})
+
+	literals := interp.Stats().Literals
+	if literals == 0 {
+		t.Fatal("Stats().Literals = 0; want the common literals and builtin command names")
+	}
+	// Literal words take no room in the scratch arena
+	grown := func(words string) int {
+		n, err := interp.MustEval(`set n [scratch]; list ` + words + `; expr {[scratch] - $n}`).Int()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return int(n)
+	}
+	if lit, other := grown(`0 1 {} set 0 1 {} set`), grown(`7 8 x y 7 8 x y`); lit+8 > other {
+		t.Errorf("scratch grew by %d for literal words and %d for others; want 8 fewer", lit, other)
+	}
+
+	// Changing a value that started as a literal leaves the literal alone
+	interp.MustEval(`proc p {} {
+		set d {}; dict set d k v
+		set l {}; lappend l a; lset l 0 b
+		set n 1; lset n 0 2
+		list $d $l $n
+	}`)
+	for range 2 {
+		if got := interp.MustEval(`p`).String(); got != "{k v} b 2" {
+			t.Errorf("p = %q; want {k v} b 2", got)
+		}
+	}
+	if got := interp.MustEval(`list {} 1 [llength {}]`).String(); got != "{} 1 0" {
+		t.Errorf("literals after p: %q", got)
+	}
+	if n := interp.Stats().Literals; n != literals {
+		t.Errorf("Stats().Literals = %d after evals; want %d", n, literals)
+	}
+}
+
+func TestCompactPermanent(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.MustEval(`set x 1`)
+	if n := interp.ScratchSize(); n != 0 {
+		t.Errorf("ScratchSize = %d after eval; want 0", n)
+	}
+	before := interp.ObjectCount()
+
+	dropped := interp.NewForeignHandle("Conn", 1)
+	obj, kept := interp.NewForeignHandleNamed("Conn", "conn2", 2)
+	if n := interp.ObjectCount(); n != before+2 {
+		t.Fatalf("ObjectCount = %d; want %d", n, before+2)
+	}
+	interp.SetVarObj("conns", interp.List(obj))
+
+	if n := interp.CompactPermanent(); n != 1 {
+		t.Errorf("CompactPermanent released %d objects; want 1", n)
+	}
+	if interp.IsForeignHandle(dropped) {
+		t.Error("handle of the unreferenced object is still valid")
+	}
+	if !interp.IsForeignHandle(kept) {
+		t.Error("handle of the object in a variable was released")
+	}
+	if got := interp.MustEval(`lindex $conns 0`).String(); got != "conn2" {
+		t.Errorf("conns = %q", got)
+	}
+	if n := interp.CompactPermanent(); n != 0 {
+		t.Errorf("second CompactPermanent released %d objects; want 0", n)
+	}
+}
+
+func TestSetValueLimits(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetValueLimits(feather.ValueLimits{MaxListLength: 100, MaxDictSize: 10})
+
+	for _, script := range []string{
+		`lrepeat 101 a`,
+		`set l {}; for {set n 0} {$n < 200} {incr n} {lappend l $n}`,
+		`set d {}; for {set n 0} {$n < 20} {incr n} {dict set d $n x}`,
+		`llength [string repeat "a " 101]`,
+		`set s {}; for {set n 0} {$n < 20} {incr n} {append s "$n x "}; dict size $s`,
+	} {
+		_, err := interp.Eval(script)
+		if !errors.Is(err, feather.ErrValueTooLarge) {
+			t.Errorf("%s: err = %v; want ErrValueTooLarge", script, err)
+		}
+	}
+
+	if got := interp.MustEval(`llength [lrepeat 100 a]`).String(); got != "100" {
+		t.Errorf("list at the limit: llength = %s", got)
+	}
+	_, err := interp.Eval(`set x {1 2}; catch {set x [lrepeat 200 a]}; set x done`)
+	var e *feather.EvalError
+	if !errors.As(err, &e) || e.Message != "list size limit exceeded: 100 elements" || e.ErrorCode != "TCL LIMIT VALUE" {
+		t.Errorf("err = %#v; want the limit error, which catch cannot stop", err)
+	}
+	if got := interp.MustEval(`set x`).String(); got != "1 2" {
+		t.Errorf("x = %q; want the value before the limit was hit", got)
+	}
+
+	interp.SetValueLimits(feather.ValueLimits{})
+	if got := interp.MustEval(`llength [lrepeat 1000 a]`).String(); got != "1000" {
+		t.Errorf("without limits: llength = %s", got)
+	}
+}
+
+func TestDictOrder(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	d := interp.MustEval(`set d [dict create c 1 a 2 b 3]; dict unset d c; dict set d c 4; llength $d; dict set d a 5; set d`)
+	dict, err := d.Dict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(dict.Order, want) {
+		t.Errorf("Order = %q; want %q", dict.Order, want)
+	}
+	if got := d.String(); got != "a 5 b 3 c 4" {
+		t.Errorf("String = %q", got)
+	}
+
+	for range 10 {
+		got := interp.DictFrom(map[string]any{"z": 1, "m": 2, "a": 3, "q": 4}).String()
+		if got != "a 3 m 2 q 4 z 1" {
+			t.Fatalf("DictFrom = %q; want the keys sorted", got)
+		}
+	}
+}
+
+func TestStringIndexLongStrings(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	text := interp.MustEval(`set s [string repeat "aé中👋 " 50]`).String()
+	runes := []rune(text)
+	for _, n := range []int{0, 1, 31, 32, 33, 63, 64, 100, len(runes) - 1} {
+		got := interp.MustEval(fmt.Sprintf("string index $s %d", n)).String()
+		if want := string(runes[n]); got != want {
+			t.Errorf("string index $s %d = %q; want %q", n, got, want)
+		}
+		got = interp.MustEval(fmt.Sprintf("string range $s %d end", n)).String()
+		if want := string(runes[n:]); got != want {
+			t.Errorf("string range $s %d end = %q; want %q", n, got, want)
+		}
+		got = interp.MustEval(fmt.Sprintf("string range $s 0 %d", n)).String()
+		if want := string(runes[:n+1]); got != want {
+			t.Errorf("string range $s 0 %d = %q; want %q", n, got, want)
+		}
+	}
+
+	// The index follows the string when the variable changes
+	got := interp.MustEval(`string length $s; append s ü; list [string length $s] [string index $s end]`).String()
+	if want := fmt.Sprintf("%d ü", len(runes)+1); got != want {
+		t.Errorf("after append = %q; want %q", got, want)
+	}
+}
+
+func TestDictCopyOnWrite(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	// Changing a dict held by a variable leaves every other reference alone
+	tests := []struct{ script, want string }{
+		{`set d {a 1}; set e $d; dict set d b 2; list $e $d`, "{a 1} {a 1 b 2}"},
+		{`set d {a 1}; set e $d; dict unset d a; list $e $d`, "{a 1} {}"},
+		{`set d {a 1}; set e $d; dict incr d a; list $e $d`, "{a 1} {a 2}"},
+		{`set d {a x}; set e $d; dict append d a y; list $e $d`, "{a x} {a xy}"},
+		{`set d {a x}; set e $d; dict lappend d a y; list $e $d`, "{a x} {a {x y}}"},
+		{`set d {a {b 1}}; set e $d; dict set d a b 2; list $e $d`, "{a {b 1}} {a {b 2}}"},
+		{`set d {a 1}; set l [list $d]; dict set d b 2; list $l $d`, "{{a 1}} {a 1 b 2}"},
+		{`set d {a 1}; set e $d; dict with d {set a 2}; list $e $d`, "{a 1} {a 2}"},
+		{`set d {a 1}; set e $d; dict update d a v {set v 2}; list $e $d`, "{a 1} {a 2}"},
+		{`proc p {x} {dict set x b 2; return $x}; set d {a 1}; list [p $d] $d`, "{a 1 b 2} {a 1}"},
+	}
+	for _, tt := range tests {
+		if got := interp.MustEval(tt.script).String(); got != tt.want {
+			t.Errorf("%s = %q; want %q", tt.script, got, tt.want)
+		}
+	}
+
+	// A dict Go holds keeps its value
+	held := interp.MustEval(`set g [dict create a 1]`)
+	interp.MustEval(`dict set g b 2`)
+	if got := held.String(); got != "a 1" {
+		t.Errorf("held dict = %q after dict set; want a 1", got)
+	}
+}
+
+func TestCloneDuplicate(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	orig := interp.MustEval(`set l {a b c}`)
+	items, _ := orig.List()
+	clone := orig.Clone()
+	citems, _ := clone.List()
+	citems[0] = interp.String("z")
+	if got := orig.String(); got != "a b c" {
+		t.Errorf("original = %q after changing clone; want a b c", got)
+	}
+	if items[0].String() != "a" {
+		t.Errorf("original element = %q; want a", items[0].String())
+	}
+
+	d := interp.DictKV("k", "v")
+	cd, _ := d.Clone().Dict()
+	cd.Items["k"] = interp.String("w")
+	if got := d.String(); got != "k v" {
+		t.Errorf("original dict = %q after changing clone; want k v", got)
+	}
+
+	other := feather.New()
+	defer other.Close()
+	src := other.MustEval(`list {x y} {k {1 2}}`)
+	dup := interp.Duplicate(src)
+	other.Close()
+	if err := interp.SetVarObj("dup", dup); err != nil {
+		t.Fatal(err)
+	}
+	if got := interp.MustEval(`lindex $dup 1 1 0`).String(); got != "1" {
+		t.Errorf("lindex of duplicate = %q; want 1", got)
+	}
+}
+
+type unboundPoint struct{ X, Y int }
+
+func (p unboundPoint) FeatherValue(i *feather.Interp) *feather.Obj {
+	return i.List(i.Int(int64(p.X)), i.Int(int64(p.Y)))
+}
+
+func TestObjectsWithoutInterp(t *testing.T) {
+	list := feather.NewListOf(feather.NewString("a b"), feather.NewIntObj(1), feather.NewDoubleObj(2.5), feather.NewBoolObj(true))
+	if got := list.String(); got != "{a b} 1 2.5 1" {
+		t.Errorf("NewListOf = %q", got)
+	}
+	dict := feather.NewDictOf("name", "Alice", "point", unboundPoint{1, 2})
+	if got := dict.String(); got != "name Alice point {1 2}" {
+		t.Errorf("NewDictOf = %q", got)
+	}
+	if got := feather.NewValue(map[string]any{"p": unboundPoint{3, 4}}).String(); got != "p {3 4}" {
+		t.Errorf("NewValue = %q", got)
+	}
+	if _, err := feather.NewString("a b").List(); err == nil {
+		t.Error("List of a string without an interpreter succeeded")
+	}
+
+	// Each interpreter is given the values themselves or a duplicate of them
+	for n := range 2 {
+		interp := feather.New()
+		l, d := list, dict
+		if n > 0 {
+			l, d = interp.Duplicate(list), interp.Duplicate(dict)
+		}
+		interp.RegisterCommand("getlist", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.OK(l)
+		})
+		if err := interp.SetVarObj("d", d); err != nil {
+			t.Fatal(err)
+		}
+		if got := interp.MustEval(`list [lindex [getlist] 0 1] [dict get $d name] [llength [dict get $d point]]`).String(); got != "b Alice 2" {
+			t.Errorf("script result = %q; want b Alice 2", got)
+		}
+		items, err := interp.Duplicate(feather.NewString("x y")).List()
+		if err != nil || len(items) != 2 {
+			t.Errorf("List of duplicate = %v, %v", items, err)
+		}
+		interp.Close()
+	}
+}
+
+func TestFreezeThaw(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+	obj := interp.MustEval(`dict create n [expr {40 + 2}] pi 3.5 l [list a {b c} 0x10] s "x y" d [dict create k v]`)
+	v := obj.Freeze()
+	interp.MustEval(`set x 1`)
+
+	if v.Kind() != feather.ValueDict || v.Len() != 5 {
+		t.Fatalf("Freeze = %v with %d keys; want dict with 5", v.Kind(), v.Len())
+	}
+	if got := strings.Join(v.Keys(), " "); got != "n pi l s d" {
+		t.Errorf("Keys = %q", got)
+	}
+	if n, _ := v.Get("n"); n.Kind() != feather.ValueInt {
+		t.Errorf("n is %v; want int", n.Kind())
+	}
+	if l, _ := v.Get("l"); l.Kind() != feather.ValueList || l.Index(1).String() != "b c" || l.Index(2).String() != "0x10" {
+		t.Errorf("l = %v %q", l.Kind(), l.String())
+	}
+	if _, ok := v.Get("missing"); ok {
+		t.Error("Get of a missing key succeeded")
+	}
+	want := obj.String()
+
+	// Frozen values are read by several goroutines and thawed into
+	// another interpreter
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := v.String(); got != want {
+				t.Errorf("String in goroutine = %q; want %q", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+
+	other := feather.New()
+	defer other.Close()
+	thawed := other.Thaw(v)
+	if got := thawed.String(); got != want {
+		t.Errorf("Thaw = %q; want %q", got, want)
+	}
+	if err := other.SetVarObj("d", thawed); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.MustEval(`dict set d s z; list [dict get $d n] [lindex [dict get $d l] 2]`).String(); got != "42 0x10" {
+		t.Errorf("thawed dict = %q", got)
+	}
+	if got := v.String(); got != want {
+		t.Errorf("frozen value = %q after changing the thawed one; want %q", got, want)
+	}
+	if got := feather.NewListOf().Freeze().String(); got != "" {
+		t.Errorf("empty list = %q", got)
+	}
+	if got := (feather.Value{}).Kind(); got != feather.ValueString {
+		t.Errorf("zero Value kind = %v", got)
+	}
+}
+
+func TestBus(t *testing.T) {
+	bus := feather.NewBus()
+	replies := make(chan feather.Value, 4)
+	unsubscribe := bus.Subscribe("done", func(topic string, v feather.Value) { replies <- v })
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	var workers []*feather.Interp
+	for range 2 {
+		interp := feather.New()
+		interp.SetBus(bus)
+		interp.MustEval(`bus subscribe job {apply {{topic job} {
+			set n [dict get $job n]
+			bus publish done [list $n [expr {$n * 2}]]
+		}}}`)
+		workers = append(workers, interp)
+		go func() { served <- interp.Serve(ctx) }()
+	}
+
+	if n := bus.Publish("job", map[string]any{"n": 21}); n != 2 {
+		t.Errorf("Publish = %d; want 2 subscribers", n)
+	}
+	for range 2 {
+		v := <-replies
+		if n, ok := v.Index(1).Int(); v.Kind() != feather.ValueList || !ok || n != 42 {
+			t.Errorf("reply = %v %q; want list with 42", v.Kind(), v.String())
+		}
+	}
+
+	// Any of the workers may stop first, so all must have before closing
+	cancel()
+	for range workers {
+		<-served
+	}
+	for _, interp := range workers {
+		interp.Close()
+	}
+	if n := bus.Publish("job", 1); n != 0 {
+		t.Errorf("Publish after Close = %d; want 0", n)
+	}
+
+	// Reset ends the subscriptions of scripts
+	interp := feather.New()
+	defer interp.Close()
+	interp.SetBus(bus)
+	interp.MustEval(`bus subscribe job {set ::got}`)
+	interp.Reset()
+	if n := bus.Publish("job", 1); n != 0 {
+		t.Errorf("Publish after Reset = %d; want 0", n)
+	}
 }
@@ -0,0 +1,298 @@
+package feather
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgType is the type an argument declared with [Args] is converted to.
+type ArgType int
+
+const (
+	Any    ArgType = iota // the *Obj as passed, read with ArgValues.Obj
+	String                // a string, read with ArgValues.String
+	Int                   // an integer, read with ArgValues.Int
+	Double                // a floating-point number, read with ArgValues.Double
+	Bool                  // a TCL boolean, read with ArgValues.Bool
+	List                  // a list, read with ArgValues.List
+	Dict                  // a dict, read with ArgValues.Dict
+)
+
+// ArgSpec declares the arguments a command takes, for [ArgSpec.Parse] to
+// check and convert. Create one with [Args].
+type ArgSpec struct {
+	cmd     *Obj
+	args    []*Obj
+	params  []argParam
+	options []OptionSpec
+}
+
+// argParam is a positional argument.
+type argParam struct {
+	name     string
+	typ      ArgType
+	def      any
+	optional bool
+	rest     bool
+}
+
+// Args starts the declaration of the arguments of a command implemented
+// with [Interp.RegisterCommand], to be checked and converted by Parse:
+//
+//	interp.RegisterCommand("fetch", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+//	    a, err := feather.Args(cmd, args).
+//	        Flag("-force").
+//	        Option("-timeout", feather.Double, 30.0).
+//	        Require("url", feather.String).
+//	        Optional("retries", feather.Int, 1).
+//	        Parse()
+//	    if err != nil {
+//	        return feather.Error(err.Error())
+//	    }
+//	    body, err := fetch(a.String("url"), a.Int("retries"), a.Double("-timeout"), a.Bool("-force"))
+//	    if err != nil {
+//	        return feather.Error(err.Error())
+//	    }
+//	    return feather.OK(body)
+//	})
+//
+// Errors have the messages TCL commands give, such as
+//
+//	wrong # args: should be "fetch ?-force? ?-timeout timeout? url ?retries?"
+//	bad option "-x": must be -force or -timeout
+//	expected integer but got "many"
+func Args(cmd *Obj, args []*Obj) *ArgSpec {
+	return &ArgSpec{cmd: cmd, args: args}
+}
+
+// Require declares a positional argument that must be given.
+func (s *ArgSpec) Require(name string, typ ArgType) *ArgSpec {
+	s.params = append(s.params, argParam{name: name, typ: typ})
+	return s
+}
+
+// Optional declares a positional argument that may be left out, in which
+// case it has the value def. Optional arguments are filled from the left
+// with the arguments left once every required one has its value, as for
+// proc arguments with defaults.
+func (s *ArgSpec) Optional(name string, typ ArgType, def any) *ArgSpec {
+	s.params = append(s.params, argParam{name: name, typ: typ, def: def, optional: true})
+	return s
+}
+
+// Rest declares that the remaining positional arguments, any number of
+// them, are collected as name. It must be declared last. Their values are
+// read with [ArgValues.Rest].
+func (s *ArgSpec) Rest(name string, typ ArgType) *ArgSpec {
+	s.params = append(s.params, argParam{name: name, typ: typ, rest: true})
+	return s
+}
+
+// Flag declares an option, such as -force, that takes no value. Its value
+// is true when it is given.
+func (s *ArgSpec) Flag(name string) *ArgSpec {
+	s.options = append(s.options, OptionSpec{Name: name, Flag: true})
+	return s
+}
+
+// Option declares an option, such as -timeout 5, that takes a value, or
+// has the value def when it is not given.
+func (s *ArgSpec) Option(name string, typ ArgType, def any) *ArgSpec {
+	s.options = append(s.options, OptionSpec{Name: name, Type: typ, Default: def})
+	return s
+}
+
+// Parse checks the arguments against the declaration and converts them.
+//
+// Options come first, and may be abbreviated to unique prefixes; "--" ends
+// them. An argument that a required positional argument needs is never
+// taken for an option, so that a value such as -1 needs no "--".
+func (s *ArgSpec) Parse() (*ArgValues, error) {
+	required, optional, rest := 0, 0, false
+	for _, p := range s.params {
+		switch {
+		case p.rest:
+			rest = true
+		case p.optional:
+			optional++
+		default:
+			required++
+		}
+	}
+
+	v, args, err := s.interp().parseOptions(s.args, s.options, required)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) < required || (!rest && len(args) > required+optional) {
+		return nil, fmt.Errorf("wrong # args: should be \"%s\"", s.usage())
+	}
+	extra := len(args) - required // arguments for optional parameters
+	for _, p := range s.params {
+		switch {
+		case p.rest:
+			vals := make([]any, len(args))
+			for n, arg := range args {
+				val, err := convertArg(p.typ, arg)
+				if err != nil {
+					return nil, err
+				}
+				vals[n] = val
+			}
+			v.values[p.name], v.given[p.name] = vals, len(args) > 0
+			args = nil
+			continue
+		case p.optional && extra == 0:
+			if p.def != nil {
+				v.values[p.name] = defaultValue(s.interp(), p.typ, p.def)
+			}
+			continue
+		case p.optional:
+			extra--
+		}
+		val, err := convertArg(p.typ, args[0])
+		if err != nil {
+			return nil, err
+		}
+		v.values[p.name], v.given[p.name] = val, true
+		args = args[1:]
+	}
+	return v, nil
+}
+
+// usage describes the arguments for wrong # args messages, as in
+// "fetch ?-force? ?-timeout timeout? url ?retries?".
+func (s *ArgSpec) usage() string {
+	words := []string{s.cmd.String()}
+	for _, o := range s.options {
+		if o.Flag {
+			words = append(words, "?"+o.Name+"?")
+		} else {
+			words = append(words, "?"+o.Name+" "+strings.TrimLeft(o.Name, "-")+"?")
+		}
+	}
+	for _, p := range s.params {
+		switch {
+		case p.rest:
+			words = append(words, "?"+p.name+" ...?")
+		case p.optional:
+			words = append(words, "?"+p.name+"?")
+		default:
+			words = append(words, p.name)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// interp returns the interpreter the command belongs to, if known.
+func (s *ArgSpec) interp() *Interp {
+	if s.cmd == nil {
+		return nil
+	}
+	return s.cmd.interp
+}
+
+// defaultValue converts the default def to typ, so that it reads the same
+// as a value that was given.
+func defaultValue(i *Interp, typ ArgType, def any) any {
+	var obj *Obj
+	if i != nil {
+		obj = i.Value(def)
+	} else {
+		obj = &Obj{bytes: fmt.Sprint(def)}
+	}
+	if val, err := convertArg(typ, obj); err == nil {
+		return val
+	}
+	return def
+}
+
+// convertArg converts arg to the Go value for typ.
+func convertArg(typ ArgType, arg *Obj) (any, error) {
+	switch typ {
+	case String:
+		return arg.String(), nil
+	case Int:
+		return asInt(arg)
+	case Double:
+		return asDouble(arg)
+	case Bool:
+		return asBool(arg)
+	case List:
+		return arg.List()
+	case Dict:
+		return arg.Dict()
+	}
+	return arg, nil
+}
+
+// ArgValues holds the arguments [ArgSpec.Parse] converted, by the names
+// they were declared with; options are named with their leading dash. An
+// argument that was left out and has no default reads as the zero value.
+type ArgValues struct {
+	values map[string]any
+	given  map[string]bool
+}
+
+// Has reports whether the argument or option name was given, rather than
+// taking its default.
+func (v *ArgValues) Has(name string) bool {
+	return v.given[name]
+}
+
+// Get returns the value of name as Parse converted it: a string, int64,
+// float64, bool, []*Obj, *DictType or *Obj, depending on its ArgType, or
+// a []any of those for the arguments collected by Rest.
+func (v *ArgValues) Get(name string) any {
+	return v.values[name]
+}
+
+// Obj returns the value of an argument declared with type Any.
+func (v *ArgValues) Obj(name string) *Obj {
+	val, _ := v.values[name].(*Obj)
+	return val
+}
+
+// String returns the value of an argument declared with type String.
+func (v *ArgValues) String(name string) string {
+	val, _ := v.values[name].(string)
+	return val
+}
+
+// Int returns the value of an argument declared with type Int.
+func (v *ArgValues) Int(name string) int64 {
+	val, _ := v.values[name].(int64)
+	return val
+}
+
+// Double returns the value of an argument declared with type Double.
+func (v *ArgValues) Double(name string) float64 {
+	val, _ := v.values[name].(float64)
+	return val
+}
+
+// Bool returns the value of a flag or an argument declared with type Bool.
+func (v *ArgValues) Bool(name string) bool {
+	val, _ := v.values[name].(bool)
+	return val
+}
+
+// List returns the value of an argument declared with type List.
+func (v *ArgValues) List(name string) []*Obj {
+	val, _ := v.values[name].([]*Obj)
+	return val
+}
+
+// Dict returns the value of an argument declared with type Dict.
+func (v *ArgValues) Dict(name string) *DictType {
+	val, _ := v.values[name].(*DictType)
+	return val
+}
+
+// Rest returns the values of the arguments collected by [ArgSpec.Rest],
+// each converted as Get describes.
+func (v *ArgValues) Rest(name string) []any {
+	val, _ := v.values[name].([]any)
+	return val
+}
@@ -0,0 +1,296 @@
+package feather
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// AsIntSlice returns the elements of the list obj as integers.
+//
+// Values created with [Interp.IntSlice] are copied out directly; other lists
+// are converted in a single pass. It fails if an element is not an integer.
+//
+//	v, err := feather.AsIntSlice(interp.MustEval("list 1 2 3"))
+//	// v == []int64{1, 2, 3}
+func AsIntSlice(obj *Obj) ([]int64, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	switch t := obj.intrep.(type) {
+	case IntSliceType:
+		return slices.Clone([]int64(t)), nil
+	case FloatSliceType:
+		// Same truncation as Obj.Int on a double
+		out := make([]int64, len(t))
+		for j, v := range t {
+			out[j] = int64(v)
+		}
+		return out, nil
+	}
+	items, err := obj.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int64, len(items))
+	for j, item := range items {
+		v, err := asInt(item)
+		if err != nil {
+			return nil, fmt.Errorf("list element %d: %w", j, err)
+		}
+		out[j] = v
+	}
+	return out, nil
+}
+
+// AsFloatSlice returns the elements of the list obj as floating-point
+// numbers. Integer elements are converted.
+//
+// Values created with [Interp.FloatSlice] or [Interp.IntSlice] are copied
+// out directly; other lists are converted in a single pass. It fails if an
+// element is not a number.
+func AsFloatSlice(obj *Obj) ([]float64, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	switch t := obj.intrep.(type) {
+	case FloatSliceType:
+		return slices.Clone([]float64(t)), nil
+	case IntSliceType:
+		out := make([]float64, len(t))
+		for j, v := range t {
+			out[j] = float64(v)
+		}
+		return out, nil
+	}
+	items, err := obj.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, len(items))
+	for j, item := range items {
+		v, err := asDouble(item)
+		if err != nil {
+			return nil, fmt.Errorf("list element %d: %w", j, err)
+		}
+		out[j] = v
+	}
+	return out, nil
+}
+
+// AsStringSlice returns the elements of the list obj as strings.
+//
+//	v, err := feather.AsStringSlice(interp.MustEval("list a {b c}"))
+//	// v == []string{"a", "b c"}
+func AsStringSlice(obj *Obj) ([]string, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	items, err := obj.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(items))
+	for j, item := range items {
+		out[j] = item.String()
+	}
+	return out, nil
+}
+
+// AsStringMap returns the dict obj as a map of strings.
+//
+//	m, err := feather.AsStringMap(interp.MustEval("dict create a 1 b {x y}"))
+//	// m == map[string]string{"a": "1", "b": "x y"}
+func AsStringMap(obj *Obj) (map[string]string, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	d, err := obj.Dict()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(d.Items))
+	for key, item := range d.Items {
+		out[key] = item.String()
+	}
+	return out, nil
+}
+
+// Unmarshaler is implemented by Go types that control their own conversion
+// from a TCL value, the inverse of [Marshaler]. It is consulted by [Into],
+// including for values nested inside slices, maps and structs.
+type Unmarshaler interface {
+	FeatherUnmarshal(obj *Obj) error
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// Into stores the value obj in the Go value that out points to, the
+// inverse of [Interp.Value]. Lists fill slices and arrays, dicts fill maps
+// with string keys and structs, and conversion is deep:
+//
+//	type Server struct {
+//	    Host  string   `feather:"host"`
+//	    Ports []int    `feather:"ports"`
+//	}
+//	var s Server
+//	err := feather.Into(interp.MustEval("dict create host example.com ports {80 443}"), &s)
+//
+// Struct fields are matched to dict keys by their `feather:"name"` tag or
+// their name, as for [Interp.Value]; keys without a field are ignored and
+// fields without a key are left unchanged. Nil pointers are allocated, and
+// an empty value sets a pointer to nil, as [Interp.Value] converts nil
+// pointers to empty values. Integers out of range for their type are an
+// error, and a []byte receives the binary data of the value. A target of
+// type *Obj receives the value itself, time.Time is parsed as RFC 3339 and
+// other interfaces receive the string value.
+//
+// The arguments of functions registered with [Interp.Register] are
+// converted the same way.
+func Into(obj *Obj, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("Into: %T is not a non-nil pointer", out)
+	}
+	return intoValue(obj, v.Elem())
+}
+
+var objPtrType = reflect.TypeOf((*Obj)(nil))
+
+// intoValue converts obj to the type of v and stores it in v.
+func intoValue(obj *Obj, v reflect.Value) error {
+	if v.Type() == objPtrType {
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+	if v.Kind() != reflect.Pointer && v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).FeatherUnmarshal(obj)
+	}
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339Nano, obj.String())
+		if err != nil {
+			return fmt.Errorf("expected time but got %q", obj.String())
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(obj.String())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := asInt(obj)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("integer value %d out of range", n)
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := asUint(obj)
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(n) {
+			return fmt.Errorf("integer value %d out of range", n)
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := asDouble(obj)
+		if err != nil {
+			return err
+		}
+		if v.OverflowFloat(f) {
+			return fmt.Errorf("floating-point value %g out of range", f)
+		}
+		v.SetFloat(f)
+
+	case reflect.Bool:
+		b, err := asBool(obj)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Slice, reflect.Array:
+		// Binary data is taken as one bytearray value, as Value returns it
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(bytes.Clone(asByteArray(obj)))
+			return nil
+		}
+		items, err := obj.List()
+		if err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		} else if len(items) != v.Len() {
+			return fmt.Errorf("expected %d elements but got %d", v.Len(), len(items))
+		}
+		for j, item := range items {
+			if err := intoValue(item, v.Index(j)); err != nil {
+				return fmt.Errorf("element %d: %v", j, err)
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("map key must be string")
+		}
+		d, err := obj.Dict()
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(d.Order))
+		for _, key := range d.Order {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := intoValue(d.Items[key], elem); err != nil {
+				return fmt.Errorf("value for key %q: %v", key, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+
+	case reflect.Struct:
+		d, err := obj.Dict()
+		if err != nil {
+			return err
+		}
+		fields := structFields(v.Type())
+		for _, key := range d.Order {
+			idx, ok := fields.byName[key]
+			if !ok {
+				continue
+			}
+			if err := intoValue(d.Items[key], v.Field(idx)); err != nil {
+				return fmt.Errorf("field %q: %v", key, err)
+			}
+		}
+
+	case reflect.Pointer:
+		if obj.String() == "" {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return intoValue(obj, v.Elem())
+
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("cannot convert to interface %v", v.Type())
+		}
+		v.Set(reflect.ValueOf(obj.String()))
+
+	default:
+		return fmt.Errorf("unsupported type: %v", v.Type())
+	}
+	return nil
+}
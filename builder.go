@@ -0,0 +1,97 @@
+package feather
+
+// ListBuilder builds a list object an element at a time, without copying
+// the elements built so far each time one is added:
+//
+//	b := interp.NewListBuilder()
+//	for _, row := range rows {
+//	    b.Append(row.ID, row.Name)
+//	}
+//	list := b.Obj()
+//
+// A ListBuilder must not be copied after first use.
+type ListBuilder struct {
+	interp *Interp
+	items  []*Obj
+}
+
+// NewListBuilder returns a builder for a list, initially empty.
+func (i *Interp) NewListBuilder() *ListBuilder {
+	return &ListBuilder{interp: i}
+}
+
+// Grow makes room for n more elements, as when their number is known in
+// advance.
+func (b *ListBuilder) Grow(n int) {
+	if n > cap(b.items)-len(b.items) {
+		items := make([]*Obj, len(b.items), len(b.items)+n)
+		copy(items, b.items)
+		b.items = items
+	}
+}
+
+// Append adds vals to the end of the list, converted as for
+// [Interp.Value].
+func (b *ListBuilder) Append(vals ...any) *ListBuilder {
+	for _, v := range vals {
+		b.items = append(b.items, b.interp.Value(v))
+	}
+	return b
+}
+
+// Len returns the number of elements added so far.
+func (b *ListBuilder) Len() int {
+	return len(b.items)
+}
+
+// Obj returns the list built so far. The builder can still be used
+// afterwards; elements added later do not change the returned list.
+func (b *ListBuilder) Obj() *Obj {
+	obj := &Obj{intrep: ListType(b.items), interp: b.interp}
+	// Later appends must reallocate rather than write into obj's elements
+	b.items = b.items[:len(b.items):len(b.items)]
+	return obj
+}
+
+// DictBuilder builds a dict object a key at a time, without copying the
+// entries built so far each time one is added:
+//
+//	b := interp.NewDictBuilder()
+//	for _, u := range users {
+//	    b.Set(u.Name, u.Email)
+//	}
+//	dict := b.Obj()
+//
+// A DictBuilder must not be copied after first use.
+type DictBuilder struct {
+	interp *Interp
+	dict   *DictType
+}
+
+// NewDictBuilder returns a builder for a dict, initially empty.
+func (i *Interp) NewDictBuilder() *DictBuilder {
+	return &DictBuilder{interp: i, dict: &DictType{Items: make(map[string]*Obj)}}
+}
+
+// Set sets key to val, converted as for [Interp.Value]. A new key is added
+// at the end of the dict; an existing one keeps its position.
+func (b *DictBuilder) Set(key string, val any) *DictBuilder {
+	d := b.dict
+	d.unshare()
+	if _, ok := d.Items[key]; !ok {
+		d.Order = append(d.Order, key)
+	}
+	d.Items[key] = b.interp.Value(val)
+	return b
+}
+
+// Len returns the number of keys set so far.
+func (b *DictBuilder) Len() int {
+	return len(b.dict.Order)
+}
+
+// Obj returns the dict built so far. The builder can still be used
+// afterwards; keys set later do not change the returned dict.
+func (b *DictBuilder) Obj() *Obj {
+	return b.interp.Obj(b.dict.share())
+}
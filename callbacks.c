@@ -33,6 +33,10 @@ FeatherResult feather_host_string_regex_match(FeatherInterp interp, FeatherObj p
     return goStringRegexMatch(interp, pattern, string, nocase, result, matches, indices);
 }
 
+FeatherResult feather_host_string_get_index(FeatherInterp interp, FeatherObj obj, const char *const *table, const char *what, int flags, int *index) {
+    return goStringGetIndex(interp, obj, (char**)table, (char*)what, flags, index);
+}
+
 int feather_host_string_byte_at(FeatherInterp interp, FeatherObj str, size_t index) {
     return goStringByteAt(interp, str, index);
 }
@@ -41,6 +45,10 @@ size_t feather_host_string_byte_length(FeatherInterp interp, FeatherObj str) {
     return goStringByteLength(interp, str);
 }
 
+const char *feather_host_string_bytes(FeatherInterp interp, FeatherObj str, size_t *len) {
+    return goStringBytes(interp, str, len);
+}
+
 FeatherObj feather_host_string_slice(FeatherInterp interp, FeatherObj str, size_t start, size_t end) {
     return goStringSlice(interp, str, start, end);
 }
@@ -133,6 +141,39 @@ void feather_host_interp_set_script(FeatherInterp interp, FeatherObj path) {
     goInterpSetScript(interp, path);
 }
 
+void feather_host_interp_eval_hook(FeatherInterp interp, FeatherObj command, size_t line) {
+    goInterpEvalHook(interp, command, line);
+}
+
+void feather_host_interp_eval_done_hook(FeatherInterp interp, FeatherObj command, FeatherResult code) {
+    goInterpEvalDoneHook(interp, command, code);
+}
+
+FeatherResult feather_host_interp_limit_check(FeatherInterp interp) {
+    return goInterpLimitCheck(interp);
+}
+
+void feather_host_interp_trace_hook(FeatherInterp interp, const char *kind, FeatherObj command) {
+    goInterpTraceHook(interp, (char*)kind, command);
+}
+
+void feather_host_interp_set_source(FeatherInterp interp, FeatherObj obj, FeatherObj file, size_t line) {
+    goInterpSetSource(interp, obj, file, line);
+}
+
+size_t feather_host_interp_get_source(FeatherInterp interp, FeatherObj obj, FeatherObj *file) {
+    return goInterpGetSource(interp, obj, file);
+}
+
+FeatherInterpState *feather_host_interp_state(FeatherInterp interp) {
+    return goInterpState(interp);
+}
+
+FeatherResult feather_host_interp_eval_on_new_stack(FeatherInterp interp, FeatherObj script,
+                                                    FeatherEvalFlags flags) {
+    return goInterpEvalOnNewStack(interp, script, flags);
+}
+
 // ============================================================================
 // List Operations
 // ============================================================================
@@ -142,7 +183,9 @@ FeatherObj feather_host_list_create(FeatherInterp interp) {
 }
 
 int feather_host_list_is_nil(FeatherInterp interp, FeatherObj obj) {
-    return goListIsNil(interp, obj);
+    // Nil is the zero handle, which needs no call into Go to recognize
+    (void)interp;
+    return obj == 0;
 }
 
 FeatherObj feather_host_list_from(FeatherInterp interp, FeatherObj obj) {
@@ -272,6 +315,26 @@ FeatherResult feather_host_dbl_math(FeatherInterp interp, FeatherMathOp op, doub
     return goDoubleMath(interp, op, a, b, out);
 }
 
+// ============================================================================
+// Bignum Operations
+// ============================================================================
+
+FeatherResult feather_host_bignum_get(FeatherInterp interp, FeatherObj obj, FeatherObj *out) {
+    return goBignumGet(interp, obj, out);
+}
+
+FeatherResult feather_host_bignum_arith(FeatherInterp interp, FeatherBignumOp op, FeatherObj a, FeatherObj b, FeatherObj *out) {
+    return goBignumArith(interp, op, a, b, out);
+}
+
+int feather_host_bignum_compare(FeatherInterp interp, FeatherObj a, FeatherObj b) {
+    return goBignumCompare(interp, a, b);
+}
+
+FeatherObj feather_host_bignum_format(FeatherInterp interp, FeatherObj obj, int base, int uppercase) {
+    return goBignumFormat(interp, obj, base, uppercase);
+}
+
 // ============================================================================
 // Frame Operations
 // ============================================================================
@@ -324,6 +387,14 @@ size_t feather_host_frame_get_line(FeatherInterp interp, size_t level) {
     return goFrameGetLine(interp, level);
 }
 
+FeatherResult feather_host_frame_set_file(FeatherInterp interp, FeatherObj file) {
+    return goFrameSetFile(interp, file);
+}
+
+FeatherObj feather_host_frame_get_file(FeatherInterp interp, size_t level) {
+    return goFrameGetFile(interp, level);
+}
+
 FeatherResult feather_host_frame_set_lambda(FeatherInterp interp, FeatherObj lambda) {
     return goFrameSetLambda(interp, lambda);
 }
@@ -332,6 +403,14 @@ FeatherObj feather_host_frame_get_lambda(FeatherInterp interp, size_t level) {
     return goFrameGetLambda(interp, level);
 }
 
+FeatherResult feather_host_frame_set_word(FeatherInterp interp, FeatherObj word) {
+    return goFrameSetWord(interp, word);
+}
+
+FeatherObj feather_host_frame_get_word(FeatherInterp interp, size_t level) {
+    return goFrameGetWord(interp, level);
+}
+
 // ============================================================================
 // Variable Operations
 // ============================================================================
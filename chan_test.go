@@ -0,0 +1,316 @@
+package feather_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/feather-lang/feather"
+)
+
+// =============================================================================
+// Channels
+// =============================================================================
+
+// slowWriter blocks every write until release is closed.
+type slowWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestChannels(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	t.Run("puts to registered channel", func(t *testing.T) {
+		var buf bytes.Buffer
+		interp.RegisterChannel("out", nil, &buf)
+		if _, err := interp.Eval(`puts out hello; puts -nonewline out world; flush out`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if buf.String() != "hello\nworld" {
+			t.Errorf("output = %q; want %q", buf.String(), "hello\nworld")
+		}
+	})
+
+	t.Run("full buffering holds output until flush", func(t *testing.T) {
+		var buf bytes.Buffer
+		interp.RegisterChannel("full", nil, &buf)
+		if _, err := interp.Eval(`puts full a`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("output before flush = %q; want empty", buf.String())
+		}
+		if _, err := interp.Eval(`flush full`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if buf.String() != "a\n" {
+			t.Errorf("output after flush = %q; want %q", buf.String(), "a\n")
+		}
+	})
+
+	t.Run("line buffering flushes on newline", func(t *testing.T) {
+		var buf bytes.Buffer
+		interp.RegisterChannel("line", nil, &buf)
+		if _, err := interp.Eval(`fconfigure line -buffering line; puts -nonewline line a`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("output = %q; want empty", buf.String())
+		}
+		if _, err := interp.Eval(`puts line b`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if buf.String() != "ab\n" {
+			t.Errorf("output = %q; want %q", buf.String(), "ab\n")
+		}
+	})
+
+	t.Run("buffersize triggers flush", func(t *testing.T) {
+		var buf bytes.Buffer
+		interp.RegisterChannel("small", nil, &buf)
+		if _, err := interp.Eval(`chan configure small -buffersize 4; puts -nonewline small abcdef`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if buf.String() != "abcdef" {
+			t.Errorf("output = %q; want %q", buf.String(), "abcdef")
+		}
+	})
+
+	t.Run("configure query", func(t *testing.T) {
+		interp.RegisterChannel("q", nil, &bytes.Buffer{})
+		result, err := interp.Eval(`fconfigure q`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "-blocking 1 -buffering full -buffersize 4096" {
+			t.Errorf("fconfigure = %q", result.String())
+		}
+		result, err = interp.Eval(`fconfigure stdout -buffering`)
+		if err != nil || result.String() != "line" {
+			t.Errorf("stdout -buffering = %q; want line", result.String())
+		}
+	})
+
+	t.Run("configure errors", func(t *testing.T) {
+		tests := []struct{ script, want string }{
+			{`fconfigure nosuch`, `can not find channel named "nosuch"`},
+			{`fconfigure stdout -bogus`, `bad option "-bogus": should be one of -blocking, -buffering, or -buffersize`},
+			{`fconfigure stdout -buffering sometimes`, `bad value for -buffering: must be one of full, line, or none`},
+			{`puts stdin x`, `channel "stdin" wasn't opened for writing`},
+		}
+		for _, tt := range tests {
+			_, err := interp.Eval(tt.script)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("%s: err = %v; want %q", tt.script, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("non-blocking puts does not stall", func(t *testing.T) {
+		w := &slowWriter{release: make(chan struct{})}
+		interp.RegisterChannel("slow", nil, w)
+		var pending string
+		done := make(chan error, 1)
+		go func() {
+			result, err := interp.Eval(`fconfigure slow -blocking 0 -buffering line; puts slow one; puts slow two; chan pending output slow`)
+			if err == nil {
+				pending = result.String()
+			}
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			close(w.release)
+			t.Fatal("puts blocked on a slow non-blocking channel")
+		}
+		if pending != "8" {
+			t.Errorf("pending output = %q; want 8", pending)
+		}
+		close(w.release)
+		if _, err := interp.Eval(`fconfigure slow -blocking 1`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if w.String() != "one\ntwo\n" {
+			t.Errorf("output = %q; want %q", w.String(), "one\ntwo\n")
+		}
+	})
+
+	t.Run("slow writers cannot hold up flushing", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		interp.SetFlushTimeout(50 * time.Millisecond)
+		w := &slowWriter{release: make(chan struct{})}
+		defer close(w.release)
+		interp.RegisterChannel("stuck", nil, w)
+		if _, err := interp.Eval(`fconfigure stuck -blocking 0; puts stuck one; flush stuck`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+
+		_, err := interp.Eval(`fconfigure stuck -blocking 1`)
+		if want := `error flushing "stuck": feather: timed out writing queued output`; err == nil || err.Error() != want {
+			t.Errorf("fconfigure -blocking 1: err = %v; want %q", err, want)
+		}
+		result, err := interp.Eval(`list [fconfigure stuck -blocking] [chan pending output stuck]`)
+		if err != nil || result.String() != "0 4" {
+			t.Errorf("after the timeout: %v, %v; want 0 4", result, err)
+		}
+		if err := interp.FlushChannels(); !errors.Is(err, feather.ErrFlushTimeout) {
+			t.Errorf("FlushChannels: err = %v; want ErrFlushTimeout", err)
+		}
+		if err := interp.UnregisterChannel("stuck"); !errors.Is(err, feather.ErrFlushTimeout) {
+			t.Errorf("UnregisterChannel: err = %v; want ErrFlushTimeout", err)
+		}
+
+		interp.RegisterChannel("stuck", nil, w)
+		if _, err := interp.Eval(`fconfigure stuck -blocking 0; puts stuck two; flush stuck`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		start := time.Now()
+		interp.Close()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Close took %v with a stuck writer", elapsed)
+		}
+	})
+
+	t.Run("SetStdout and SetStderr", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		var out, errs bytes.Buffer
+		interp.SetStdout(&out)
+		interp.SetStderr(&errs)
+		if _, err := interp.Eval(`puts one; puts -nonewline stderr two; puts stdout three`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if out.String() != "one\nthree\n" {
+			t.Errorf("stdout = %q; want %q", out.String(), "one\nthree\n")
+		}
+		if errs.String() != "two" {
+			t.Errorf("stderr = %q; want %q", errs.String(), "two")
+		}
+	})
+
+	t.Run("Stdout orders Go output with puts", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		var out bytes.Buffer
+		interp.SetStdout(&out)
+		interp.RegisterCommand("hello", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			fmt.Fprint(i.Stdout(), "hello ")
+			return feather.OK("")
+		})
+		if _, err := interp.Eval(`puts -nonewline "<"; hello; puts ">"`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if out.String() != "<hello >\n" {
+			t.Errorf("stdout = %q; want %q", out.String(), "<hello >\n")
+		}
+	})
+
+	t.Run("CaptureOutput", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		var out bytes.Buffer
+		interp.SetStdout(&out)
+		if _, err := interp.Eval(`puts -nonewline before`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+
+		stdout, stderr, result, err := interp.CaptureOutput(`puts -nonewline a; puts stderr b; expr {6 * 7}`)
+		if err != nil {
+			t.Fatalf("CaptureOutput failed: %v", err)
+		}
+		if stdout != "a" || stderr != "b\n" || result.String() != "42" {
+			t.Errorf("CaptureOutput = %q, %q, %v; want %q, %q, 42", stdout, stderr, result, "a", "b\n")
+		}
+		if out.String() != "before" {
+			t.Errorf("previous stdout = %q; want %q", out.String(), "before")
+		}
+
+		stdout, _, result, err = interp.CaptureOutput(`puts partial; error oops`)
+		if err == nil || err.Error() != "oops" || result != nil {
+			t.Errorf("CaptureOutput error = %v, %v; want oops, nil", err, result)
+		}
+		if stdout != "partial\n" {
+			t.Errorf("stdout on error = %q; want %q", stdout, "partial\n")
+		}
+
+		if _, err := interp.Eval(`puts after`); err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if out.String() != "beforeafter\n" {
+			t.Errorf("stdout after capture = %q; want %q", out.String(), "beforeafter\n")
+		}
+	})
+
+	t.Run("gets and read from SetStdin", func(t *testing.T) {
+		interp := feather.New()
+		defer interp.Close()
+		interp.SetStdin(strings.NewReader("ab\r\ncd\nlast"))
+		result, err := interp.Eval(`list [gets stdin x] $x [gets stdin] [eof stdin] [gets stdin x] $x [eof stdin] [gets stdin x] $x`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if want := "2 ab cd 0 4 last 1 -1 {}"; result.String() != want {
+			t.Errorf("gets = %q; want %q", result.String(), want)
+		}
+
+		interp.SetStdin(strings.NewReader("héllo\nworld\n"))
+		result, err = interp.Eval(`list [read stdin 2] [string length [chan read stdin 4]] [eof stdin] [read -nonewline stdin] [eof stdin]`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if want := "hé 4 0 world 1"; result.String() != want {
+			t.Errorf("read = %q; want %q", result.String(), want)
+		}
+	})
+
+	t.Run("read errors", func(t *testing.T) {
+		tests := []struct{ script, want string }{
+			{`gets`, `wrong # args: should be "gets channelId ?varName?"`},
+			{`read -nonewline stdin 5`, `wrong # args: should be "read channelId ?numChars?" or "read ?-nonewline? channelId"`},
+			{`read stdin -1`, `expected non-negative integer but got "-1"`},
+			{`gets stdout`, `channel "stdout" wasn't opened for reading`},
+			{`eof nosuch`, `can not find channel named "nosuch"`},
+		}
+		for _, tt := range tests {
+			_, err := interp.Eval(tt.script)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("%s: err = %v; want %q", tt.script, err, tt.want)
+			}
+		}
+	})
+
+	t.Run("chan names", func(t *testing.T) {
+		result, err := interp.Eval(`chan names std*`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if !strings.Contains(result.String(), "stdout") {
+			t.Errorf("chan names = %q", result.String())
+		}
+	})
+}
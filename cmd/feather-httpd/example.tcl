@@ -35,5 +35,30 @@ route GET /notfound {
     response "This page intentionally returns 404"
 }
 
+# Serve the files under ./templates at /files, cached for an hour
+static -maxage 3600 /files ./templates
+
+# Send a file from a handler
+route GET /download {
+    header Content-Disposition "attachment; filename=index.html"
+    sendfile templates/index.html
+}
+
+# Middleware: tag every response, and log each request once it is handled
+middleware {
+    header X-Powered-By feather
+}
+middleware -after {
+    puts "[request method] [request path]"
+}
+
+# Lifecycle hooks
+on_start {
+    puts "Serving [llength [routes]] routes"
+}
+on_stop {
+    puts "Goodbye"
+}
+
 # Start the server
 listen 8080
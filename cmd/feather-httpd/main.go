@@ -2,12 +2,19 @@
 //
 // Usage:
 //
-//	feather-httpd [script.tcl]
+//	feather-httpd [script.tcl [arg ...]]
 //
-// If a script is provided, it is evaluated at startup. Then, a REPL is started
-// for interactive configuration. The server can be controlled via TCL commands:
+// If a script is provided, it is evaluated at startup, with its arguments
+// in argv. Then, a REPL is started for interactive configuration. The
+// server can be controlled via TCL commands:
 //
 //	route GET /path {script}   - register a route handler
+//	routes                     - list the registered routes as {METHOD /path} pairs
+//	middleware ?-after? script - run script before (or after) every route handler
+//	on_start script            - run script when the server starts listening
+//	on_stop script             - run script when the server stops
+//	static /assets ./public    - serve the files under ./public at /assets
+//	sendfile path              - send a file as the response (in handler context)
 //	listen 8080                - start the HTTP server on a port
 //	stop                       - stop the HTTP server
 //	response body              - set response body (in handler context)
@@ -22,8 +29,38 @@
 //	template render name data  - render template with data to response
 //	template errors            - get dict of templates with parse errors
 //
-// Templates are loaded from the "templates" directory and automatically
-// reloaded when files change. Supported extensions: .html, .tmpl
+// Middleware runs for every request, in the order it was added, including
+// requests no route matches. A middleware script that calls break ends the
+// request with the response set so far, skipping the route handler and any
+// remaining middleware that runs before it:
+//
+//	middleware {
+//	    if {[request header Authorization] eq ""} {
+//	        status 401
+//	        response "unauthorized"
+//	        break
+//	    }
+//	}
+//	middleware -after {puts "[request method] [request path]"}
+//
+// static serves the files of a directory, with a Content-Type from their
+// extension and Last-Modified headers for revalidation, and answers Range
+// and If-Modified-Since requests. Requests cannot reach files outside the
+// directory, through ".." or symbolic links. -maxage sets the max-age of a
+// Cache-Control header:
+//
+//	static -maxage 3600 /assets ./public
+//
+// on_start and on_stop add handlers to the start and stop hooks, which
+// scripts can also list and remove with the hook command.
+//
+// Templates are loaded from the "templates" directory, parsed with
+// html/template, and reloaded when their files change. The templates
+// command of the feathertemplate package is also available, to load
+// another directory or call procs from templates:
+//
+//	proc money {n} { format %.2f $n }
+//	templates func money money
 //
 // Example session:
 //
@@ -36,38 +73,41 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"html/template"
 	"io"
+	"maps"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/feather-lang/feather"
+	"github.com/feather-lang/feather/feathertemplate"
 )
 
-// TemplateInfo holds a parsed template and its file modification time.
-type TemplateInfo struct {
-	Template *template.Template
-	ModTime  int64
-	Error    error
-}
-
 // HTTPServer wraps an HTTP server with feather integration.
 type HTTPServer struct {
-	interp      *feather.Interp
-	mux         *http.ServeMux
-	server      *http.Server
-	mu          sync.RWMutex
-	routes      map[string]string // "METHOD /path" -> script
-	running     bool
-	templateDir string
-	templates   map[string]*TemplateInfo
-	templateMu  sync.RWMutex
+	interp    *feather.Interp
+	mux       *http.ServeMux
+	server    *http.Server
+	mu        sync.RWMutex
+	routes    map[string]string // "METHOD /path" -> script
+	before    []string          // middleware scripts run before the route handler
+	after     []string          // middleware scripts run after the route handler
+	statics   []staticMount     // directories served by static, longest prefix first
+	running   bool
+	templates *feathertemplate.Set
+}
+
+// staticMount is a directory served by static.
+type staticMount struct {
+	prefix string
+	root   *os.Root
+	maxAge int64 // seconds; 0 for no Cache-Control header
 }
 
 // RequestContext holds per-request state for handler scripts.
@@ -78,6 +118,7 @@ type RequestContext struct {
 	Headers      map[string]string
 	BodyWritten  bool
 	ResponseBody string
+	File         *os.File // file to send instead of ResponseBody (nil = none)
 }
 
 // Global request context (thread-local would be better, but this is a demo)
@@ -89,18 +130,18 @@ func main() {
 	defer i.Close()
 
 	srv := &HTTPServer{
-		interp:      i,
-		mux:         http.NewServeMux(),
-		routes:      make(map[string]string),
-		templateDir: "templates",
-		templates:   make(map[string]*TemplateInfo),
+		interp: i,
+		mux:    http.NewServeMux(),
+		routes: make(map[string]string),
 	}
 
 	// Register HTTP commands
 	srv.registerCommands()
 
-	// If a script file is provided, evaluate it
+	// If a script file is provided, evaluate it with its arguments in argv
+	i.SetArgs(os.Args[0], nil)
 	if len(os.Args) > 1 {
+		i.SetArgs(os.Args[1], os.Args[2:])
 		script, err := os.ReadFile(os.Args[1])
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error reading script: %v\n", err)
@@ -142,6 +183,10 @@ func main() {
 func (s *HTTPServer) registerCommands() {
 	// Register commands using the public API
 	s.interp.RegisterCommand("route", s.cmdRoute)
+	s.interp.RegisterCommand("routes", s.cmdRoutes)
+	s.interp.RegisterCommand("middleware", s.cmdMiddleware)
+	s.interp.RegisterCommand("on_start", s.cmdOnStart)
+	s.interp.RegisterCommand("on_stop", s.cmdOnStop)
 	s.interp.RegisterCommand("listen", s.cmdListen)
 	s.interp.RegisterCommand("stop", s.cmdStop)
 	s.interp.RegisterCommand("response", s.cmdResponse)
@@ -149,18 +194,34 @@ func (s *HTTPServer) registerCommands() {
 	s.interp.RegisterCommand("header", s.cmdHeader)
 	s.interp.RegisterCommand("request", s.cmdRequest)
 	s.interp.RegisterCommand("template", s.cmdTemplate)
+	s.interp.RegisterCommand("static", s.cmdStatic)
+	s.interp.RegisterCommand("sendfile", s.cmdSendfile)
+
+	// The "templates" directory is optional; templates load can load another.
+	s.templates = feathertemplate.Register(s.interp)
+	s.templates.Load("templates", feathertemplate.HTML)
+
+	hooks := s.interp.Hooks()
+	hooks.Define("start", feather.HookSpec{Errors: feather.CollectErrors})
+	hooks.Define("stop", feather.HookSpec{Errors: feather.CollectErrors})
+	s.interp.Eval("namespace eval ::httpd {}")
 }
 
 // cmdRoute registers a route handler.
 // Usage: route METHOD /path {script}
 func (s *HTTPServer) cmdRoute(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
-	if len(args) < 3 {
-		return feather.Error("wrong # args: should be \"route method path script\"")
+	a, err := feather.Args(cmd, args).
+		Require("method", feather.String).
+		Require("path", feather.String).
+		Require("script", feather.String).
+		Parse()
+	if err != nil {
+		return feather.Error(err.Error())
 	}
 
-	method := strings.ToUpper(args[0].String())
-	path := args[1].String()
-	script := args[2].String()
+	method := strings.ToUpper(a.String("method"))
+	path := a.String("path")
+	script := a.String("script")
 
 	key := method + " " + path
 	s.mu.Lock()
@@ -170,15 +231,89 @@ func (s *HTTPServer) cmdRoute(i *feather.Interp, cmd *feather.Obj, args []*feath
 	return feather.OK("")
 }
 
+// cmdRoutes lists the registered routes, sorted.
+// Usage: routes
+func (s *HTTPServer) cmdRoutes(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if _, err := feather.Args(cmd, args).Parse(); err != nil {
+		return feather.Error(err.Error())
+	}
+
+	s.mu.RLock()
+	keys := slices.Sorted(maps.Keys(s.routes))
+	s.mu.RUnlock()
+
+	routes := make([][]string, len(keys))
+	for n, key := range keys {
+		method, path, _ := strings.Cut(key, " ")
+		routes[n] = []string{method, path}
+	}
+	return feather.OK(routes)
+}
+
+// cmdMiddleware registers a script to run for every request.
+// Usage: middleware ?-after? script
+func (s *HTTPServer) cmdMiddleware(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	a, err := feather.Args(cmd, args).
+		Flag("-after").
+		Require("script", feather.String).
+		Parse()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+
+	s.mu.Lock()
+	if a.Bool("-after") {
+		s.after = append(s.after, a.String("script"))
+	} else {
+		s.before = append(s.before, a.String("script"))
+	}
+	s.mu.Unlock()
+
+	return feather.OK("")
+}
+
+// cmdOnStart adds a handler to the start hook.
+// Usage: on_start script
+func (s *HTTPServer) cmdOnStart(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	return s.addHook(i, cmd, args, "start")
+}
+
+// cmdOnStop adds a handler to the stop hook.
+// Usage: on_stop script
+func (s *HTTPServer) cmdOnStop(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	return s.addHook(i, cmd, args, "stop")
+}
+
+// addHook adds script as a handler of the hook name, returning its id.
+func (s *HTTPServer) addHook(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj, name string) feather.Result {
+	a, err := feather.Args(cmd, args).Require("script", feather.Any).Parse()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+
+	id, err := i.Call("hook", "add", name, "", a.Obj("script"))
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	return feather.OK(id)
+}
+
+// runHook runs the handlers of the hook name, reporting their errors.
+func (s *HTTPServer) runHook(name string) {
+	if _, err := s.interp.Hooks().Run(name); err != nil {
+		fmt.Fprintf(os.Stderr, "%s hook error: %v\n", name, err)
+	}
+}
+
 // cmdListen starts the HTTP server.
 // Usage: listen port
 func (s *HTTPServer) cmdListen(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
-	if len(args) < 1 {
-		return feather.Error("wrong # args: should be \"listen port\"")
+	a, err := feather.Args(cmd, args).Require("port", feather.Int).Parse()
+	if err != nil {
+		return feather.Error(err.Error())
 	}
 
-	port := args[0].String()
-	addr := ":" + port
+	addr := fmt.Sprintf(":%d", a.Int("port"))
 
 	s.mu.Lock()
 	if s.running {
@@ -205,6 +340,7 @@ func (s *HTTPServer) cmdListen(i *feather.Interp, cmd *feather.Obj, args []*feat
 	}()
 
 	fmt.Printf("Listening on %s\n", addr)
+	s.runHook("start")
 	return feather.OK("")
 }
 
@@ -224,6 +360,7 @@ func (s *HTTPServer) cmdStop(i *feather.Interp, cmd *feather.Obj, args []*feathe
 	}
 
 	fmt.Println("Server stopped")
+	s.runHook("stop")
 	return feather.OK("")
 }
 
@@ -238,11 +375,12 @@ func (s *HTTPServer) cmdResponse(i *feather.Interp, cmd *feather.Obj, args []*fe
 		return feather.Error("response: not in request context")
 	}
 
-	if len(args) < 1 {
-		return feather.Error("wrong # args: should be \"response body\"")
+	a, err := feather.Args(cmd, args).Require("body", feather.String).Parse()
+	if err != nil {
+		return feather.Error(err.Error())
 	}
 
-	ctx.ResponseBody = args[0].String()
+	ctx.ResponseBody = a.String("body")
 	return feather.OK("")
 }
 
@@ -257,16 +395,12 @@ func (s *HTTPServer) cmdStatus(i *feather.Interp, cmd *feather.Obj, args []*feat
 		return feather.Error("status: not in request context")
 	}
 
-	if len(args) < 1 {
-		return feather.Error("wrong # args: should be \"status code\"")
-	}
-
-	code, err := args[0].Int()
+	a, err := feather.Args(cmd, args).Require("code", feather.Int).Parse()
 	if err != nil {
-		return feather.Errorf("status: invalid code: %v", err)
+		return feather.Error(err.Error())
 	}
 
-	ctx.StatusCode = int(code)
+	ctx.StatusCode = int(a.Int("code"))
 	return feather.OK("")
 }
 
@@ -281,17 +415,118 @@ func (s *HTTPServer) cmdHeader(i *feather.Interp, cmd *feather.Obj, args []*feat
 		return feather.Error("header: not in request context")
 	}
 
-	if len(args) < 2 {
-		return feather.Error("wrong # args: should be \"header name value\"")
+	a, err := feather.Args(cmd, args).
+		Require("name", feather.String).
+		Require("value", feather.String).
+		Parse()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+
+	ctx.Headers[a.String("name")] = a.String("value")
+
+	return feather.OK("")
+}
+
+// cmdStatic serves the files of a directory under a path prefix.
+// Usage: static ?-maxage seconds? prefix dir
+func (s *HTTPServer) cmdStatic(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	a, err := feather.Args(cmd, args).
+		Option("-maxage", feather.Int, 0).
+		Require("prefix", feather.String).
+		Require("dir", feather.String).
+		Parse()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+
+	root, err := os.OpenRoot(a.String("dir"))
+	if err != nil {
+		return feather.Errorf("static: %v", err)
+	}
+	mount := staticMount{
+		prefix: "/" + strings.Trim(a.String("prefix"), "/"),
+		root:   root,
+		maxAge: a.Int("-maxage"),
+	}
+
+	s.mu.Lock()
+	s.statics = slices.DeleteFunc(s.statics, func(m staticMount) bool { return m.prefix == mount.prefix })
+	s.statics = append(s.statics, mount)
+	slices.SortStableFunc(s.statics, func(a, b staticMount) int { return len(b.prefix) - len(a.prefix) })
+	s.mu.Unlock()
+
+	return feather.OK("")
+}
+
+// cmdSendfile sends a file as the response body.
+// Usage: sendfile path
+func (s *HTTPServer) cmdSendfile(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	requestMu.Lock()
+	ctx := currentRequest
+	requestMu.Unlock()
+
+	if ctx == nil {
+		return feather.Error("sendfile: not in request context")
 	}
 
-	name := args[0].String()
-	value := args[1].String()
-	ctx.Headers[name] = value
+	a, err := feather.Args(cmd, args).Require("path", feather.String).Parse()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
 
+	f, err := os.Open(a.String("path"))
+	if err != nil {
+		return feather.Errorf("sendfile: %v", err)
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return feather.Errorf("sendfile: %s is not a file", a.String("path"))
+	}
+	if ctx.File != nil {
+		ctx.File.Close()
+	}
+	ctx.File = f
 	return feather.OK("")
 }
 
+// openStatic opens the file a static mount serves for urlPath. A directory
+// is served by its index.html. It returns nil if no mount serves the path
+// or the file does not exist.
+func (s *HTTPServer) openStatic(urlPath string) (*os.File, int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, m := range s.statics {
+		rest, ok := strings.CutPrefix(urlPath, m.prefix)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && m.prefix != "/") {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean("/"+rest), "/")
+		if name == "" {
+			name = "."
+		}
+		f, err := m.root.Open(name)
+		if err != nil {
+			return nil, 0
+		}
+		info, err := f.Stat()
+		if err == nil && info.IsDir() {
+			f.Close()
+			f, err = m.root.Open(path.Join(name, "index.html"))
+			if err != nil {
+				return nil, 0
+			}
+			info, err = f.Stat()
+		}
+		if err != nil || info.IsDir() {
+			f.Close()
+			return nil, 0
+		}
+		return f, m.maxAge
+	}
+	return nil, 0
+}
+
 // cmdRequest gets request information.
 // Usage: request method | path | header name | query name | body
 func (s *HTTPServer) cmdRequest(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
@@ -336,64 +571,9 @@ func (s *HTTPServer) cmdRequest(i *feather.Interp, cmd *feather.Obj, args []*fea
 	}
 }
 
-// refreshTemplates scans the template directory and reloads changed templates.
-func (s *HTTPServer) refreshTemplates() {
-	s.templateMu.Lock()
-	defer s.templateMu.Unlock()
-
-	// Track which templates still exist
-	seen := make(map[string]bool)
-
-	// Walk the template directory
-	filepath.Walk(s.templateDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
-		// Only process .html and .tmpl files
-		ext := filepath.Ext(path)
-		if ext != ".html" && ext != ".tmpl" {
-			return nil
-		}
-
-		// Use relative path as template name
-		relPath, err := filepath.Rel(s.templateDir, path)
-		if err != nil {
-			return nil
-		}
-		name := relPath
-
-		seen[name] = true
-		modTime := info.ModTime().UnixNano()
-
-		// Check if we need to reload
-		existing, ok := s.templates[name]
-		if ok && existing.ModTime == modTime {
-			return nil
-		}
-
-		// Parse the template
-		tmpl, parseErr := template.ParseFiles(path)
-		s.templates[name] = &TemplateInfo{
-			Template: tmpl,
-			ModTime:  modTime,
-			Error:    parseErr,
-		}
-		return nil
-	})
-
-	// Remove templates that no longer exist
-	for name := range s.templates {
-		if !seen[name] {
-			delete(s.templates, name)
-		}
-	}
-}
-
-// cmdTemplate handles template subcommands.
-// Usage: template list | template render name data | template errors
+// cmdTemplate handles template subcommands, rendering from the
+// "templates" directory with html/template.
+// Usage: template list | template show name | template render name data | template errors
 func (s *HTTPServer) cmdTemplate(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
 	if len(args) < 1 {
 		return feather.Error("wrong # args: should be \"template subcommand ?args?\"")
@@ -402,33 +582,29 @@ func (s *HTTPServer) cmdTemplate(i *feather.Interp, cmd *feather.Obj, args []*fe
 	subcmd := args[0].String()
 	switch subcmd {
 	case "list":
-		return s.cmdTemplateList(i)
+		return feather.OK(s.templates.Names())
+	case "show":
+		if len(args) < 2 {
+			return feather.Error("wrong # args: should be \"template show name\"")
+		}
+		src, err := s.templates.Source(args[1].String())
+		if err != nil {
+			return feather.Errorf("template show: %v", err)
+		}
+		return feather.OK(src)
+	case "errors":
+		errs := make(map[string]any)
+		for name, err := range s.templates.Errors() {
+			errs[name] = err.Error()
+		}
+		return feather.OK(i.DictFrom(errs))
 	case "render":
 		return s.cmdTemplateRender(i, args[1:])
-	case "errors":
-		return s.cmdTemplateErrors(i)
-	case "show":
-		return s.cmdTemplateShow(i, args[1:])
 	default:
 		return feather.Errorf("template: unknown subcommand %q", subcmd)
 	}
 }
 
-// cmdTemplateList lists available templates.
-func (s *HTTPServer) cmdTemplateList(i *feather.Interp) feather.Result {
-	s.refreshTemplates()
-
-	s.templateMu.RLock()
-	defer s.templateMu.RUnlock()
-
-	items := make([]*feather.Obj, 0, len(s.templates))
-	for name := range s.templates {
-		items = append(items, i.String(name))
-	}
-
-	return feather.OK(i.List(items...))
-}
-
 // cmdTemplateRender renders a template with data to the response.
 func (s *HTTPServer) cmdTemplateRender(i *feather.Interp, args []*feather.Obj) feather.Result {
 	requestMu.Lock()
@@ -443,145 +619,34 @@ func (s *HTTPServer) cmdTemplateRender(i *feather.Interp, args []*feather.Obj) f
 		return feather.Error("wrong # args: should be \"template render name data\"")
 	}
 
-	name := args[0].String()
-	dataObj := args[1]
-
-	s.refreshTemplates()
-
-	s.templateMu.RLock()
-	info, ok := s.templates[name]
-	s.templateMu.RUnlock()
-
-	if !ok {
-		return feather.Errorf("template render: template %q not found", name)
-	}
-
-	if info.Error != nil {
-		return feather.Errorf("template render: template %q has parse error: %v", name, info.Error)
-	}
-
-	// Convert TCL data to Go map
-	data := s.tclToGoData(dataObj)
-
-	// Render template to buffer
-	var buf strings.Builder
-	if err := info.Template.Execute(&buf, data); err != nil {
+	body, err := s.templates.Render(args[0].String(), args[1])
+	if err != nil {
 		return feather.Errorf("template render: %v", err)
 	}
 
-	ctx.ResponseBody = buf.String()
+	ctx.ResponseBody = body
 	return feather.OK("")
 }
 
-// cmdTemplateShow returns the source of a template.
-func (s *HTTPServer) cmdTemplateShow(i *feather.Interp, args []*feather.Obj) feather.Result {
-	if len(args) < 1 {
-		return feather.Error("wrong # args: should be \"template show name\"")
-	}
-
-	name := args[0].String()
-	path := filepath.Join(s.templateDir, name)
-
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return feather.Errorf("template show: %v", err)
-	}
-
-	return feather.OK(string(content))
-}
-
-// cmdTemplateErrors returns a dict of templates and their parsing errors.
-func (s *HTTPServer) cmdTemplateErrors(i *feather.Interp) feather.Result {
-	s.refreshTemplates()
-
-	s.templateMu.RLock()
-	defer s.templateMu.RUnlock()
-
-	errors := make(map[string]any)
-	for name, info := range s.templates {
-		if info.Error != nil {
-			errors[name] = info.Error.Error()
-		}
-	}
-	return feather.OK(i.DictFrom(errors))
-}
-
-// tclToGoData converts a TCL object to Go data suitable for template execution.
-func (s *HTTPServer) tclToGoData(obj *feather.Obj) any {
-	// Check native dict first (avoids infinite recursion from shimmering)
-	if obj.Type() == "dict" {
-		d, err := obj.Dict()
-		if err == nil {
-			result := make(map[string]any)
-			for _, key := range d.Order {
-				val := d.Items[key]
-				result[key] = s.tclToGoData(val)
-			}
-			return result
-		}
-	}
-
-	// Check native list (avoids infinite recursion from shimmering)
-	if obj.Type() == "list" {
-		listItems, err := obj.List()
-		if err == nil {
-			result := make([]any, len(listItems))
-			for idx, elem := range listItems {
-				result[idx] = s.tclToGoData(elem)
-			}
-			return result
-		}
-	}
-
-	// Default to string
-	return obj.String()
-}
-
-// tclList formats strings as a proper TCL list.
-func tclList(items []string) string {
-	var parts []string
-	for _, item := range items {
-		parts = append(parts, tclQuote(item))
-	}
-	return strings.Join(parts, " ")
-}
-
-// tclQuote quotes a string for use in a TCL list if necessary.
-func tclQuote(s string) string {
-	if s == "" {
-		return "{}"
-	}
-	needsQuote := false
-	for _, c := range s {
-		if c == ' ' || c == '\t' || c == '\n' || c == '{' || c == '}' || c == '"' || c == '\\' {
-			needsQuote = true
-			break
-		}
-	}
-	if !needsQuote {
-		return s
-	}
-	// Use braces for quoting
-	return "{" + s + "}"
-}
-
 // ServeHTTP implements http.Handler.
 func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Find matching route
 	key := r.Method + " " + r.URL.Path
 	s.mu.RLock()
 	script, ok := s.routes[key]
-	s.mu.RUnlock()
-
 	if !ok {
 		// Try without method (ANY)
-		key = "ANY " + r.URL.Path
-		s.mu.RLock()
-		script, ok = s.routes[key]
-		s.mu.RUnlock()
+		script, ok = s.routes["ANY "+r.URL.Path]
 	}
+	before, after := s.before, s.after
+	s.mu.RUnlock()
 
+	var static *os.File
+	var maxAge int64
 	if !ok {
+		static, maxAge = s.openStatic(r.URL.Path)
+	}
+	if !ok && static == nil && len(before) == 0 && len(after) == 0 {
 		http.NotFound(w, r)
 		return
 	}
@@ -602,10 +667,34 @@ func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		requestMu.Lock()
 		currentRequest = nil
 		requestMu.Unlock()
+		if ctx.File != nil {
+			ctx.File.Close()
+		}
+		if static != nil {
+			static.Close()
+		}
 	}()
 
-	// Execute the handler script
-	_, err := s.interp.Eval(script)
+	// Run the middleware and the handler script
+	stopped, err := s.runMiddleware(before)
+	if err == nil && !stopped {
+		switch {
+		case ok:
+			_, err = s.interp.Eval(script)
+		case static != nil:
+			ctx.File, static = static, nil
+			if maxAge > 0 {
+				ctx.Headers["Cache-Control"] = fmt.Sprintf("public, max-age=%d", maxAge)
+			}
+		default:
+			ctx.StatusCode = http.StatusNotFound
+			ctx.Headers["Content-Type"] = "text/plain; charset=utf-8"
+			ctx.ResponseBody = "404 page not found\n"
+		}
+	}
+	if err == nil && !stopped {
+		_, err = s.runMiddleware(after)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -615,57 +704,51 @@ func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	for name, value := range ctx.Headers {
 		w.Header().Set(name, value)
 	}
+	if ctx.File != nil && ctx.StatusCode == http.StatusOK {
+		// ServeContent sets the Content-Type from the extension, unless a
+		// handler set one, and answers conditional and range requests
+		info, err := ctx.File.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, info.Name(), info.ModTime(), ctx.File)
+		return
+	}
+	if ctx.File != nil {
+		w.WriteHeader(ctx.StatusCode)
+		io.Copy(w, ctx.File)
+		return
+	}
 	w.WriteHeader(ctx.StatusCode)
 	if ctx.ResponseBody != "" {
 		w.Write([]byte(ctx.ResponseBody))
 	}
 }
 
-func runREPL(i *feather.Interp) {
-	scanner := bufio.NewScanner(os.Stdin)
-	var inputBuffer string
-
-	for {
-		if inputBuffer == "" {
-			fmt.Print("% ")
-		} else {
-			fmt.Print("> ")
-		}
-
-		if !scanner.Scan() {
-			break
-		}
-
-		line := scanner.Text()
-		if inputBuffer != "" {
-			inputBuffer += "\n" + line
-		} else {
-			inputBuffer = line
-		}
-
-		// Check if input is complete
-		parseResult := i.Parse(inputBuffer)
-		if parseResult.Status == feather.ParseIncomplete {
-			continue
-		}
-
-		if parseResult.Status == feather.ParseError {
-			fmt.Fprintf(os.Stderr, "error: %s\n", parseResult.Message)
-			inputBuffer = ""
-			continue
-		}
-
-		// Evaluate the complete input
-		result, err := i.Eval(inputBuffer)
+// runMiddleware runs the middleware scripts in order. It reports whether
+// one of them called break to end the request.
+func (s *HTTPServer) runMiddleware(scripts []string) (bool, error) {
+	for _, script := range scripts {
+		code, err := s.interp.Call("catch", script, "::httpd::result")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
-		} else if result.String() != "" {
-			fmt.Println(result.String())
+			return false, err
+		}
+		n, _ := code.Int()
+		switch feather.FeatherResult(n) {
+		case feather.ResultError:
+			return false, errors.New(s.interp.Var("::httpd::result").String())
+		case feather.ResultBreak:
+			return true, nil
 		}
-		inputBuffer = ""
 	}
+	return false, nil
+}
 
-	if err := scanner.Err(); err != nil {
+func runREPL(i *feather.Interp) {
+	repl := feather.NewREPL(i)
+	repl.Errors = os.Stderr
+	if err := repl.Run(os.Stdin, os.Stdout); err != nil {
 		fmt.Fprintf(os.Stderr, "error reading input: %v\n", err)
 	}
 }
@@ -33,9 +33,16 @@ func (m memStats) String() string {
 		m.alloc/1024, m.totalAlloc/1024, m.sys/1024, m.numGC)
 }
 
+// counter is a foreign type the stress script creates and destroys.
+type counter struct{ n int }
+
 func main() {
 	interp := feather.New()
 	defer interp.Close()
+	feather.RegisterType[*counter](interp, "Counter", feather.TypeDef[*counter]{
+		New:     func() *counter { return &counter{} },
+		Methods: map[string]any{"incr": func(c *counter) int { c.n++; return c.n }},
+	})
 
 	const iterations = 10000
 	const reportInterval = 1000
@@ -43,6 +50,8 @@ func main() {
 	// Get baseline memory stats
 	startMem := getMemStats()
 	fmt.Println("Start:", startMem)
+	startStats := interp.Stats()
+	fmt.Printf("Start: %+v\n", startStats)
 
 	// Run stress test: repeatedly create objects, procs, variables
 	// This should not leak memory in the Go implementation
@@ -52,6 +61,9 @@ func main() {
 		proc tmp {} { return [expr {1 + 2}] }
 		tmp
 		rename tmp {}
+		set c [Counter new]
+		$c incr
+		$c destroy
 	`
 
 	for i := 0; i < iterations; i++ {
@@ -70,6 +82,37 @@ func main() {
 	// Get final memory stats
 	endMem := getMemStats()
 	fmt.Println("End:  ", endMem)
+	endStats := interp.Stats()
+	fmt.Printf("End:   %+v\n", endStats)
+
+	// The arenas, the bytes lent to C and the foreign objects must all be
+	// back where they started: anything left over is kept forever
+	var leaks []string
+	if endStats.PermanentObjects != startStats.PermanentObjects {
+		leaks = append(leaks, fmt.Sprintf("permanent objects: %d -> %d", startStats.PermanentObjects, endStats.PermanentObjects))
+	}
+	if endStats.ScratchObjects != 0 {
+		leaks = append(leaks, fmt.Sprintf("scratch objects after eval: %d", endStats.ScratchObjects))
+	}
+	if endStats.Builders != 0 {
+		leaks = append(leaks, fmt.Sprintf("string builders after eval: %d", endStats.Builders))
+	}
+	if endStats.CBytesAllocated != endStats.CBytesFreed {
+		leaks = append(leaks, fmt.Sprintf("bytes lent to C and not given back: %d", endStats.CBytesAllocated-endStats.CBytesFreed))
+	}
+	if endStats.ForeignInstances != startStats.ForeignInstances {
+		leaks = append(leaks, fmt.Sprintf("foreign instances: %d -> %d", startStats.ForeignInstances, endStats.ForeignInstances))
+	}
+	if n := interp.CompactPermanent(); n > 0 {
+		leaks = append(leaks, fmt.Sprintf("unreferenced permanent objects: %d", n))
+	}
+	if len(leaks) > 0 {
+		fmt.Fprintf(os.Stderr, "FAIL: Interpreter storage leak detected\n")
+		for _, leak := range leaks {
+			fmt.Fprintf(os.Stderr, "  %s\n", leak)
+		}
+		os.Exit(1)
+	}
 
 	// Check for memory leaks
 	// Calculate growth metrics
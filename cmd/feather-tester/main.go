@@ -46,9 +46,14 @@ func main() {
 		runBenchmarkMode()
 		return
 	}
+	if len(os.Args) > 1 && (os.Args[1] == "report" || os.Args[1] == "replay") {
+		runReportMode(os.Args[1], os.Args[2:])
+		return
+	}
 
 	i := feather.New()
 	defer i.Close()
+	i.SetArgs(os.Args[0], nil)
 
 	// Register test-specific commands
 	registerTestCommands(i)
@@ -99,18 +104,19 @@ func registerTestCommands(i *feather.Interp) {
 }
 
 func cmdSayHello(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
-	fmt.Println("hello")
+	fmt.Fprintln(i.Stdout(), "hello")
 	return feather.OK("")
 }
 
 func cmdEcho(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	out := i.Stdout()
 	for idx, arg := range args {
 		if idx > 0 {
-			fmt.Print(" ")
+			fmt.Fprint(out, " ")
 		}
-		fmt.Print(arg.String())
+		fmt.Fprint(out, arg.String())
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 	return feather.OK("")
 }
 
@@ -119,32 +125,11 @@ func cmdCount(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.
 }
 
 func cmdList(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
-	var parts []string
-	for _, arg := range args {
-		s := arg.String()
-		needsBraces := false
-		for _, c := range s {
-			if c == ' ' || c == '\t' || c == '\n' || c == '{' || c == '}' {
-				needsBraces = true
-				break
-			}
-		}
-		if needsBraces {
-			parts = append(parts, "{"+s+"}")
-		} else if len(s) == 0 {
-			parts = append(parts, "{}")
-		} else {
-			parts = append(parts, s)
-		}
-	}
-	result := ""
-	for idx, part := range parts {
-		if idx > 0 {
-			result += " "
-		}
-		result += part
+	elems := make([]string, len(args))
+	for idx, arg := range args {
+		elems[idx] = arg.String()
 	}
-	return feather.OK(result)
+	return feather.OK(feather.JoinList(elems))
 }
 
 func runREPL(i *feather.Interp) {
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/feather-lang/feather"
+)
+
+// reportEvents is how many trace events a report keeps.
+const reportEvents = 100
+
+// runReportMode handles the bug report subcommands:
+//
+//	feather-tester report out.zip < script.tcl  - run a script and save a report
+//	feather-tester replay report.zip            - run the script from a report
+func runReportMode(mode string, args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: feather-tester %s file.zip\n", mode)
+		os.Exit(2)
+	}
+
+	i := feather.New()
+	defer i.Close()
+	registerTestCommands(i)
+
+	if mode == "replay" {
+		replayReport(i, args[0])
+		return
+	}
+
+	script, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading script: %v\n", err)
+		os.Exit(1)
+	}
+	i.SetReportEvents(reportEvents)
+	_, evalErr := i.Eval(string(script))
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := i.Report(string(script)).Write(f); err != nil {
+		f.Close()
+		fmt.Fprintf(os.Stderr, "error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if evalErr != nil {
+		fmt.Fprintf(os.Stderr, "script failed: %v\n", evalErr)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", args[0])
+}
+
+func replayReport(i *feather.Interp, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	r, err := feather.ReadReport(f, stat.Size())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading report: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "report from feather %s, %s, %s\n", r.Version, r.GoVersion, r.Platform)
+	for _, name := range r.MissingCommands(i) {
+		fmt.Fprintf(os.Stderr, "warning: command %s is not available\n", name)
+	}
+
+	result, err := r.Replay(i)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if s := result.String(); s != "" {
+		fmt.Println(s)
+	}
+}
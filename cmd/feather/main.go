@@ -0,0 +1,192 @@
+// feather is the command line shell of the feather TCL interpreter.
+//
+// Usage:
+//
+//	feather                      start an interactive shell
+//	feather script.tcl [arg ...] evaluate a script file
+//	feather -c script [arg ...]  evaluate a script given on the command line
+//
+// If standard input is not a terminal and no script is given, the script
+// is read from standard input.
+//
+// Scripts see their name in argv0, their arguments as a list in argv and
+// the number of arguments in argc. tcl_interactive is 1 in the interactive
+// shell and 0 otherwise.
+//
+// Before the interactive shell starts, ~/.featherrc is evaluated if it
+// exists. The shell has line editing with history, which is kept in
+// ~/.feather_history, reverse history search with Ctrl-R, and Tab
+// completion of command and variable names.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/feather-lang/feather"
+	"github.com/feather-lang/feather/internal/lineedit"
+	"golang.org/x/term"
+)
+
+// exitFuncs run before the process exits, from the exit command or the end
+// of main.
+var exitFuncs []func()
+
+func main() {
+	script := flag.String("c", "", "evaluate `script` instead of a file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: feather [-c script | file] [arg ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+
+	i := feather.New()
+	i.RegisterCommand("exit", cmdExit)
+
+	argv0 := os.Args[0]
+	switch {
+	case isFlagSet("c"):
+		run(i, argv0, args, *script)
+	case len(args) > 0:
+		setArgs(i, args[0], args[1:], false)
+		if _, err := i.SourceFile(args[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			exit(i, 1)
+		}
+	case term.IsTerminal(int(os.Stdin.Fd())):
+		setArgs(i, argv0, nil, true)
+		sourceRC(i)
+		shell(i)
+	default:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "feather: %v\n", err)
+			os.Exit(1)
+		}
+		run(i, argv0, nil, string(data))
+	}
+	exit(i, 0)
+}
+
+// isFlagSet reports whether the flag name was given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// setArgs sets the variables describing the program and its arguments.
+func setArgs(i *feather.Interp, argv0 string, argv []string, interactive bool) {
+	i.SetArgs(argv0, argv)
+	i.SetVar("tcl_interactive", interactive)
+}
+
+// run evaluates script non-interactively, exiting with status 1 if it fails.
+func run(i *feather.Interp, argv0 string, argv []string, script string) {
+	setArgs(i, argv0, argv, false)
+	if _, err := i.Eval(script); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		exit(i, 1)
+	}
+}
+
+// sourceRC evaluates ~/.featherrc if it exists.
+func sourceRC(i *feather.Interp) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".featherrc"))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "feather: %v\n", err)
+		}
+		return
+	}
+	if _, err := i.Eval(string(data)); err != nil {
+		fmt.Fprintf(os.Stderr, "error in ~/.featherrc: %s\n", err.Error())
+	}
+}
+
+// shell runs the interactive shell until the end of input.
+func shell(i *feather.Interp) {
+	editor := lineedit.New()
+	editor.Complete = func(input string) []lineedit.Candidate {
+		var candidates []lineedit.Candidate
+		for _, c := range i.Complete(input, len(input)) {
+			candidates = append(candidates, lineedit.Candidate{Text: c.Text, Type: c.Kind, Help: c.Help, Name: c.Name})
+		}
+		return candidates
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		history := filepath.Join(home, ".feather_history")
+		editor.LoadHistory(history)
+		exitFuncs = append(exitFuncs, func() { editor.SaveHistory(history) })
+	}
+
+	repl := feather.NewREPL(i)
+	for {
+		editor.SetInputBuffer(repl.Pending())
+		line, err := editor.ReadLine(repl.Prompt())
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			repl.Discard()
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "feather: %v\n", err)
+			}
+			return
+		}
+		editor.AddHistory(line)
+
+		res, err := repl.Feed(line)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		} else if !res.Incomplete && res.Value.String() != "" {
+			fmt.Println(res.Value.String())
+		}
+	}
+}
+
+// exit runs the exit functions, writes out the output still buffered in
+// the channels of i, closes it and ends the process with status code.
+func exit(i *feather.Interp, code int) {
+	for _, f := range exitFuncs {
+		f()
+	}
+	if err := i.FlushChannels(); err != nil {
+		fmt.Fprintf(os.Stderr, "feather: %v\n", err)
+	}
+	i.Close()
+	os.Exit(code)
+}
+
+// cmdExit implements the exit command:
+//
+//	exit ?returnCode?
+func cmdExit(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if len(args) > 1 {
+		return feather.Error(`wrong # args: should be "exit ?returnCode?"`)
+	}
+	code := 0
+	if len(args) == 1 {
+		n, err := args[0].Int()
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		code = int(n)
+	}
+	exit(i, code)
+	return feather.OK("")
+}
@@ -0,0 +1,190 @@
+package feather
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Completion is a candidate returned by [Interp.Complete].
+type Completion struct {
+	// Text replaces the word being completed, script[Start:pos]. It is
+	// empty for an argument placeholder.
+	Text string
+
+	// Start is the byte offset in the script of the word being completed.
+	Start int
+
+	// Kind is "command", "namespace", "subcommand", "variable", "flag",
+	// "value" or "arg-placeholder".
+	Kind string
+
+	// Help is a short description, if one is known.
+	Help string
+
+	// Name is the name of the argument of an arg-placeholder.
+	Name string
+}
+
+// Complete returns the candidates for completing the word that ends at
+// byte offset pos of script, such as for Tab in an interactive shell.
+//
+// Where a command is expected, the candidates are the names of commands and
+// namespaces; a word such as "ns::" completes inside namespace ns. A word
+// starting with $ completes to variable names. After the name of a command
+// with a usage spec, such as string, dict or info, the candidates are its
+// subcommands, flags, flag values and argument placeholders, as reported by
+// "usage complete".
+//
+// Candidates are sorted by text. Completing does not run any of script.
+func (i *Interp) Complete(script string, pos int) []Completion {
+	pos = max(0, min(pos, len(script)))
+	script = script[:pos]
+	cmdStart := commandStart(script)
+	command := script[cmdStart:]
+	wordStart := cmdStart + strings.LastIndexAny(command, " \t") + 1
+	word := script[wordStart:]
+
+	var completions []Completion
+	if name, ok := strings.CutPrefix(word, "$"); ok {
+		completions = i.completeNames([]string{"info", "vars"}, nil, name, "$", "variable")
+	} else {
+		specs := i.usageCompletions(command)
+		if !strings.ContainsAny(strings.TrimLeft(command, " \t"), " \t") {
+			help := make(map[string]string)
+			for _, c := range specs {
+				help[c.Text] = c.Help
+			}
+			completions = i.completeNames([]string{"info", "commands"}, []string{"namespace", "children"}, word, "", "command")
+			for n := range completions {
+				completions[n].Help = help[completions[n].Text]
+			}
+		} else {
+			completions = specs
+		}
+	}
+	for n := range completions {
+		completions[n].Start = wordStart
+	}
+	slices.SortStableFunc(completions, func(a, b Completion) int {
+		return cmp.Compare(a.Text, b.Text)
+	})
+	return completions
+}
+
+// commandStart returns the offset of the innermost command that script
+// ends in: after the last command separator, open bracket or open brace
+// that is not closed. Braced words are treated as scripts, since a body is
+// the likeliest place to complete inside braces.
+func commandStart(script string) int {
+	var outer []int
+	start := 0
+	for n := 0; n < len(script); n++ {
+		switch script[n] {
+		case '\\':
+			n++
+		case '[', '{':
+			outer = append(outer, start)
+			start = n + 1
+		case ']', '}':
+			if len(outer) > 0 {
+				start = outer[len(outer)-1]
+				outer = outer[:len(outer)-1]
+			}
+		case ';', '\n':
+			start = n + 1
+		}
+	}
+	return start
+}
+
+// completeNames returns the names listed by the list command that start
+// with prefix, as candidates of kind with text sigil+name. If prefix is
+// qualified, the names are those in its namespace, as typed. If children
+// is not nil, the child namespaces listed by that command are included
+// too, with text name+"::".
+func (i *Interp) completeNames(list, children []string, prefix, sigil, kind string) []Completion {
+	qualifier, tail := "", prefix
+	if k := strings.LastIndex(prefix, "::"); k >= 0 {
+		qualifier, tail = prefix[:k+2], prefix[k+2:]
+	}
+	var completions []Completion
+	seen := make(map[string]bool)
+	add := func(kind, suffix string, cmd []string, args ...string) {
+		var callArgs []any
+		for _, arg := range append(cmd[1:], args...) {
+			callArgs = append(callArgs, arg)
+		}
+		names, err := i.Call(cmd[0], callArgs...)
+		if err != nil {
+			return
+		}
+		items, err := names.List()
+		if err != nil {
+			return
+		}
+		for _, item := range items {
+			name := item.String()
+			if qualifier != "" || suffix != "" {
+				name = name[strings.LastIndex(name, "::")+2:]
+			}
+			text := sigil + qualifier + name + suffix
+			if strings.HasPrefix(name, tail) && !seen[text] {
+				seen[text] = true
+				completions = append(completions, Completion{Text: text, Kind: kind})
+			}
+		}
+	}
+	if qualifier != "" {
+		ns := strings.TrimSuffix(qualifier, "::")
+		if ns == "" {
+			ns = "::"
+		}
+		add(kind, "", list, qualifier+"*")
+		if children != nil {
+			add("namespace", "::", children, ns)
+		}
+		return completions
+	}
+	add(kind, "", list)
+	if children != nil {
+		add("namespace", "::", children)
+		add("namespace", "::", children, "::")
+	}
+	return completions
+}
+
+// usageCompletions returns the candidates of "usage complete" for the end
+// of command.
+func (i *Interp) usageCompletions(command string) []Completion {
+	result, err := i.Call("usage", "complete", command, len(command))
+	if err != nil {
+		return nil
+	}
+	items, err := result.List()
+	if err != nil {
+		return nil
+	}
+	completions := make([]Completion, 0, len(items))
+	for _, item := range items {
+		d, err := item.Dict()
+		if err != nil {
+			continue
+		}
+		var c Completion
+		if v, ok := d.Items["text"]; ok {
+			c.Text = v.String()
+		}
+		if v, ok := d.Items["type"]; ok {
+			c.Kind = v.String()
+		}
+		if v, ok := d.Items["help"]; ok {
+			c.Help = v.String()
+		}
+		if v, ok := d.Items["name"]; ok {
+			c.Name = v.String()
+		}
+		completions = append(completions, c)
+	}
+	return completions
+}
@@ -1,9 +1,14 @@
 package feather
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 )
 
 // toTclString converts a Go value to a TCL string representation.
@@ -61,73 +66,9 @@ func toTclString(v any) string {
 	}
 }
 
-// quote returns a properly quoted TCL string.
-// It uses brace quoting when safe (balanced braces, no trailing backslash).
-// Otherwise it uses double-quote escaping.
+// quote returns s quoted as a single word of a script.
 func quote(s string) string {
-	if s == "" {
-		return "{}"
-	}
-
-	// Check if we need any quoting at all
-	needsQuote := false
-	for _, c := range s {
-		if c == ' ' || c == '\t' || c == '\n' || c == '{' || c == '}' || c == '"' || c == '\\' || c == '$' || c == '[' || c == ']' {
-			needsQuote = true
-			break
-		}
-	}
-	if !needsQuote {
-		return s
-	}
-
-	// Check if brace quoting is safe: braces must be balanced and no trailing backslash
-	if canBraceQuote(s) {
-		return "{" + s + "}"
-	}
-
-	// Fall back to double-quote escaping
-	return doubleQuote(s)
-}
-
-// canBraceQuote returns true if the string can be safely quoted with braces.
-// This requires balanced braces and no trailing backslash.
-func canBraceQuote(s string) bool {
-	if len(s) > 0 && s[len(s)-1] == '\\' {
-		return false
-	}
-
-	depth := 0
-	for _, c := range s {
-		switch c {
-		case '{':
-			depth++
-		case '}':
-			depth--
-			if depth < 0 {
-				return false
-			}
-		}
-	}
-	return depth == 0
-}
-
-// doubleQuote quotes a string using double-quote escaping.
-func doubleQuote(s string) string {
-	var buf strings.Builder
-	buf.Grow(len(s) + 10) // estimate with some headroom for escapes
-	buf.WriteByte('"')
-	for _, c := range s {
-		switch c {
-		case '"', '$', '[', ']', '\\':
-			buf.WriteByte('\\')
-			buf.WriteRune(c)
-		default:
-			buf.WriteRune(c)
-		}
-	}
-	buf.WriteByte('"')
-	return buf.String()
+	return QuoteListElement(s)
 }
 
 // wrapFunc wraps a Go function to be callable from TCL.
@@ -182,81 +123,168 @@ func wrapFunc(i *Interp, fn any) InternalCommandFunc {
 	}
 }
 
-// convertArgInternal converts a TCL value to a Go value of the specified type.
+// convertArgInternal converts a TCL value to a Go value of the specified
+// type, as Into does.
 func convertArgInternal(i *Interp, arg FeatherObj, targetType reflect.Type) (reflect.Value, error) {
-	switch targetType.Kind() {
-	case reflect.String:
-		return reflect.ValueOf(i.getString(arg)), nil
+	v := reflect.New(targetType).Elem()
+	if err := intoValue(i.getObject(arg), v); err != nil {
+		return reflect.Value{}, err
+	}
+	return v, nil
+}
+
+// structFieldSet describes how dict keys map onto the fields of a struct type.
+type structFieldSet struct {
+	names  []string       // dict key for each mapped field, in declaration order
+	index  []int          // field index for each entry in names
+	byName map[string]int // dict key -> field index
+}
 
-	case reflect.Int:
-		v, err := i.getInt(arg)
-		if err != nil {
-			return reflect.Value{}, err
+// structFields returns the dict key mapping for a struct type.
+//
+// Each exported field is keyed by its `feather:"name"` tag when present,
+// otherwise by its Go field name. Fields tagged `feather:"-"` are skipped.
+func structFields(t reflect.Type) *structFieldSet {
+	fs := &structFieldSet{byName: make(map[string]int, t.NumField())}
+	for j := 0; j < t.NumField(); j++ {
+		f := t.Field(j)
+		if !f.IsExported() {
+			continue
 		}
-		return reflect.ValueOf(int(v)), nil
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("feather"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fs.names = append(fs.names, name)
+		fs.index = append(fs.index, j)
+		fs.byName[name] = j
+	}
+	return fs
+}
+
+// Marshaler is implemented by Go types that control their own conversion
+// to a TCL value. It is consulted by [Interp.Value] and [OK], including for
+// values nested inside slices, maps and structs. [NewValue] passes a nil
+// interpreter, whose methods create objects that belong to none.
+//
+//	type Point struct{ X, Y int }
+//
+//	func (p Point) FeatherValue(i *feather.Interp) *feather.Obj {
+//	    return i.List(i.Int(int64(p.X)), i.Int(int64(p.Y)))
+//	}
+type Marshaler interface {
+	FeatherValue(i *Interp) *Obj
+}
+
+var (
+	marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+)
 
-	case reflect.Int64:
-		v, err := i.getInt(arg)
-		if err != nil {
-			return reflect.Value{}, err
+// reflectToObj converts a Go value to an object, recursing into slices,
+// maps, structs and pointers so nested values become nested lists and dicts.
+func (i *Interp) reflectToObj(v reflect.Value) *Obj {
+	if !v.IsValid() {
+		return i.String("")
+	}
+	if v.CanInterface() {
+		if obj, ok := v.Interface().(*Obj); ok {
+			if obj == nil {
+				return i.String("")
+			}
+			if obj.interp == nil {
+				obj.interp = i
+			} else if featherDebug {
+				i.checkOwner(obj)
+			}
+			return obj
+		}
+		if v.Type().Implements(marshalerType) {
+			if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+				return i.String("")
+			}
+			return v.Interface().(Marshaler).FeatherValue(i)
 		}
-		return reflect.ValueOf(v), nil
+		if v.CanAddr() && v.Addr().Type().Implements(marshalerType) {
+			return v.Addr().Interface().(Marshaler).FeatherValue(i)
+		}
+		if v.Type() == timeType {
+			return i.String(v.Interface().(time.Time).Format(time.RFC3339Nano))
+		}
+	}
 
-	case reflect.Float64:
-		v, err := i.getDouble(arg)
-		if err != nil {
-			return reflect.Value{}, err
+	switch v.Kind() {
+	case reflect.String:
+		return i.String(v.String())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return i.Int(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Values past the range of int64 become bignums
+		if n := v.Uint(); n > math.MaxInt64 {
+			return i.BigInt(new(big.Int).SetUint64(n))
 		}
-		return reflect.ValueOf(v), nil
+		return i.Int(int64(v.Uint()))
+
+	case reflect.Float32, reflect.Float64:
+		return i.Double(v.Float())
 
 	case reflect.Bool:
-		s := i.getString(arg)
-		switch strings.ToLower(s) {
-		case "1", "true", "yes", "on":
-			return reflect.ValueOf(true), nil
-		case "0", "false", "no", "off":
-			return reflect.ValueOf(false), nil
-		default:
-			return reflect.Value{}, fmt.Errorf("expected boolean but got %q", s)
+		return i.Bool(v.Bool())
+
+	case reflect.Slice, reflect.Array:
+		// Binary data becomes a bytearray, which keeps it as one value
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return i.ByteArray(bytes.Clone(v.Bytes()))
 		}
+		items := make([]*Obj, v.Len())
+		for j := range items {
+			items[j] = i.reflectToObj(v.Index(j))
+		}
+		return i.List(items...)
 
-	case reflect.Slice:
-		if targetType.Elem().Kind() == reflect.String {
-			// Special case: []string
-			items, err := i.getList(arg)
-			if err != nil {
-				return reflect.Value{}, err
-			}
-			slice := make([]string, len(items))
-			for j, item := range items {
-				slice[j] = i.getString(item)
-			}
-			return reflect.ValueOf(slice), nil
+	case reflect.Map:
+		// Sort keys so the resulting dict has a stable order
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for j, k := range keys {
+			names[j] = fmt.Sprintf("%v", k.Interface())
 		}
-		// Generic slice handling
-		items, err := i.getList(arg)
-		if err != nil {
-			return reflect.Value{}, err
+		order := make([]int, len(keys))
+		for j := range order {
+			order[j] = j
 		}
-		slice := reflect.MakeSlice(targetType, len(items), len(items))
-		for j, item := range items {
-			converted, err := convertArgInternal(i, item, targetType.Elem())
-			if err != nil {
-				return reflect.Value{}, fmt.Errorf("element %d: %v", j, err)
-			}
-			slice.Index(j).Set(converted)
+		sort.Slice(order, func(a, b int) bool { return names[order[a]] < names[order[b]] })
+		dict := &DictType{Items: make(map[string]*Obj, len(keys)), Order: make([]string, 0, len(keys))}
+		for _, j := range order {
+			dict.Order = append(dict.Order, names[j])
+			dict.Items[names[j]] = i.reflectToObj(v.MapIndex(keys[j]))
 		}
-		return slice, nil
+		return i.Obj(dict)
 
-	case reflect.Interface:
-		// For any/interface{}, return the string value
-		if targetType.NumMethod() == 0 {
-			return reflect.ValueOf(i.getString(arg)), nil
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		dict := &DictType{Items: make(map[string]*Obj, len(fields.names)), Order: fields.names}
+		for j, name := range fields.names {
+			dict.Items[name] = i.reflectToObj(v.Field(fields.index[j]))
 		}
-		return reflect.Value{}, fmt.Errorf("cannot convert to interface %v", targetType)
+		return i.Obj(dict)
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return i.String("")
+		}
+		return i.reflectToObj(v.Elem())
 
 	default:
-		return reflect.Value{}, fmt.Errorf("unsupported parameter type: %v", targetType)
+		return i.String(fmt.Sprintf("%v", v.Interface()))
 	}
 }
 
@@ -303,7 +331,7 @@ func convertResultInternal(i *Interp, result reflect.Value) FeatherResult {
 		i.SetResult(i.newIntObj(result.Int()))
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		i.SetResult(i.newIntObj(int64(result.Uint())))
+		i.SetResult(i.registerObj(i.reflectToObj(result)))
 
 	case reflect.Float32, reflect.Float64:
 		i.SetResult(i.newDoubleObj(result.Float()))
@@ -315,50 +343,16 @@ func convertResultInternal(i *Interp, result reflect.Value) FeatherResult {
 			i.SetResult(i.newIntObj(0))
 		}
 
-	case reflect.Slice:
-		// Convert slice to list
-		list := i.newListObj()
-		for j := 0; j < result.Len(); j++ {
-			elem := result.Index(j)
-			var elemHandle FeatherObj
-			switch elem.Kind() {
-			case reflect.String:
-				elemHandle = i.internString(elem.String())
-			case reflect.Int, reflect.Int64:
-				elemHandle = i.newIntObj(elem.Int())
-			default:
-				elemHandle = i.internString(fmt.Sprintf("%v", elem.Interface()))
-			}
-			list = i.listAppendObj(list, elemHandle)
-		}
-		i.SetResult(list)
-
-	case reflect.Map:
-		// Convert map to dict
-		dict := i.newDictObj()
-		iter := result.MapRange()
-		for iter.Next() {
-			key := fmt.Sprintf("%v", iter.Key().Interface())
-			val := iter.Value()
-			var valHandle FeatherObj
-			switch val.Kind() {
-			case reflect.String:
-				valHandle = i.internString(val.String())
-			case reflect.Int, reflect.Int64:
-				valHandle = i.newIntObj(val.Int())
-			default:
-				valHandle = i.internString(fmt.Sprintf("%v", val.Interface()))
-			}
-			dict = i.dictSetObj(dict, key, valHandle)
-		}
-		i.SetResult(dict)
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		// Convert slices to lists and maps and structs to dicts
+		i.SetResult(i.registerObj(i.reflectToObj(result)))
 
 	case reflect.Ptr, reflect.Interface:
 		if result.IsNil() {
 			i.SetResultString("")
 			return ResultOK
 		}
-		i.SetResultString(fmt.Sprintf("%v", result.Interface()))
+		i.SetResult(i.registerObj(i.reflectToObj(result)))
 
 	default:
 		i.SetResultString(fmt.Sprintf("%v", result.Interface()))
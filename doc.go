@@ -50,7 +50,23 @@
 //	}()
 //
 // For server applications, use a pool of interpreters or create one per request.
+// [Interp.Reset] clears what one request's scripts left behind before the
+// interpreter goes back to the pool.
 // [*Obj] values are also tied to their interpreter and must not be shared.
+// To hand a value to another goroutine or interpreter, freeze it with
+// [Obj.Freeze]: the [Value] it returns never changes, and [Interp.Thaw]
+// turns it back into an object of any interpreter.
+//
+// The exceptions are [Interp.Post], [Interp.Send] and [Interp.EvalAsync],
+// which other goroutines may call to queue work for the goroutine that owns
+// the interpreter. That goroutine runs [Interp.Serve] (or
+// [Interp.RunEventLoop], or a script blocked in vwait):
+//
+//	go interp.Serve(ctx)
+//
+//	// from any goroutine:
+//	interp.Send(func(i *feather.Interp) { i.Call("onReading", line) })
+//	res := <-interp.EvalAsync("llength $readings")
 //
 // # Supported TCL Commands
 //
@@ -62,7 +78,21 @@
 //
 // Procedures and evaluation:
 //
-//	proc, apply, eval, uplevel, upvar, catch, try, throw, error
+//	proc, apply, eval, uplevel, upvar, catch, try, throw, error,
+//	defer (feather extension: run a cleanup script when the proc exits),
+//	nproc (feather extension: proc with named -option parameters),
+//	options (feather extension: options parse $args {-port int 80 -v flag}),
+//	hook (feather extension: add handlers to hooks defined with Interp.Hooks)
+//
+// Coroutines and events:
+//
+//	coroutine, yield, yieldto, after, vwait, update,
+//	await, future (feather extension: results of commands returning Async),
+//	bus (feather extension: publish values to subscribers, see Bus)
+//
+// Objects:
+//
+//	oo::class, oo::object, oo::define (a subset of TclOO)
 //
 // Variables and namespaces:
 //
@@ -77,12 +107,33 @@
 //
 //	dict (with subcommands: create, get, set, exists, keys, values, etc.)
 //
+// As in TCL, dicts keep their keys in the order they were added: setting a
+// key that exists keeps its place, a key removed and added again goes at
+// the end, and the order survives conversion to a string or list and
+// back. Dicts made from Go maps have their keys sorted.
+//
 // Strings:
 //
 //	string (with subcommands: length, index, range, equal, compare,
 //	        match, map, tolower, toupper, trim, replace, first, last, etc.)
 //	format, scan, subst
 //
+// Binary data:
+//
+//	binary (format, scan, encode, decode), encoding (convertto, convertfrom,
+//	names, system)
+//
+// Processes:
+//
+//	exec (pipelines and redirections; see Interp.SetExecPolicy to restrict it)
+//
+// Files:
+//
+//	file (with subcommands: copy, delete, dirname, exists, extension, glob,
+//	      join, mkdir, rename, stat, tail; see Interp.SetFilesystem
+//	      to confine it)
+//	glob (with -directory, -nocomplain, -tails, -types and {a,b} patterns)
+//
 // Introspection:
 //
 //	info (with subcommands: exists, commands, procs, vars, body, args,
@@ -94,8 +145,19 @@
 //	sinh, cosh, tanh, floor, ceil, round, abs, pow, fmod, hypot,
 //	double, int, wide, isnan, isinf
 //
-// NOT implemented: file I/O, sockets, regex, clock, encoding, interp (safe interps),
-// and most Tk-related commands. Use [Interp.Register] to add these if needed.
+// NOT implemented: file I/O, sockets, regex, clock, child and safe
+// interpreters, and most Tk-related commands. Use [Interp.Register] to add
+// these if needed.
+// A json command is available from the featherjson package, a templates
+// command, rendering Go templates, from the feathertemplate package, and a
+// db command, for databases with database/sql drivers, from the featherdb
+// package.
+// The interp subcommands alias, aliases, exists, expose, hide, hidden and
+// invokehidden work on the one interpreter there is, whose path is {}, so
+// aliases cannot reach across interpreters as they do in TCL:
+//
+//	interp hide {} exec
+//	interp alias {} log {} puts stderr
 //
 // # Error Handling
 //
@@ -107,6 +169,10 @@
 //	    fmt.Println("Error:", err)
 //	}
 //
+// Its ErrorCode and ErrorInfo fields hold the -errorcode the error was
+// thrown with and its stack trace, as in ::errorInfo. [Interp.Call] and
+// [Interp.Apply] return errors the same way.
+//
 // To return errors from Go commands, use [Error] or [Errorf]:
 //
 //	interp.RegisterCommand("fail", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
@@ -131,9 +197,20 @@
 //	    puts "Error: $errmsg"
 //	}
 //
+// Commands that take part in control flow return [Break], [Continue] or
+// [Return] in place of [OK], and evaluate scripts in their caller's frame
+// with [Interp.EvalAtLevel], which keeps the code the script completed with.
+//
 // Note: feather does not currently provide stack traces or line numbers in errors.
 // The error message is the only diagnostic information available.
 //
+// To file a reproducible bug report, turn on event recording with
+// [Interp.SetReportEvents] and save [Interp.Report] after the failure. The
+// archive holds the script, interpreter settings, command names, version
+// information and recent events; [ReadReport] and [Report.Replay] run it
+// again elsewhere. feather-tester does the same with
+// "feather-tester report out.zip < script.tcl" and "feather-tester replay out.zip".
+//
 // # Working with Results
 //
 // [Interp.Eval] returns (*Obj, error). The result is the value of the last
@@ -159,6 +236,14 @@
 // The [Result] type is only used when implementing commands with [Interp.RegisterCommand].
 // Create results with [OK], [Error], or [Errorf].
 //
+// Go values passed to [OK] or [Interp.Value] are converted deeply: slices
+// become lists, and maps and structs become dicts, so nested data keeps its
+// shape. Types that need a different representation implement [Marshaler]:
+//
+//	func (p Point) FeatherValue(i *feather.Interp) *feather.Obj {
+//	    return i.List(i.Int(int64(p.X)), i.Int(int64(p.Y)))
+//	}
+//
 // # Memory and Lifetime
 //
 // [*Obj] values are managed by Go's garbage collector. You don't need to
@@ -166,12 +251,29 @@
 //
 //   - After [Interp.Close], all [*Obj] values from that interpreter become invalid
 //   - Don't store [*Obj] values beyond the interpreter's lifetime
-//   - Don't share [*Obj] values between interpreters
+//   - Don't share [*Obj] values between interpreters; pass a copy made by
+//     [Interp.Duplicate], or a [Value] made by [Obj.Freeze]
+//
+// Evaluating scripts in a closed interpreter, or converting its values with
+// methods such as [Obj.Int], returns [ErrInterpClosed]. Building with
+// -tags featherdebug turns both mistakes into panics with a clear message:
+// using an interpreter or its values after Close, and handing an [*Obj] to
+// an interpreter other than the one that created it.
+//
+// Close destroys the foreign objects scripts left alive, calling the Destroy
+// function of their types, so Go resources they own are released when the
+// interpreter is. Use [Interp.OnClose] for other resources tied to it.
 //
 // For long-lived applications, be aware that string representations are cached.
 // An object that shimmers between int and string keeps both representations
 // until garbage collected.
 //
+// [Interp.Stats] counts what the interpreter keeps outside Go's view: the
+// objects in its arenas, the bytes lent to the C core and the foreign
+// objects alive, so a long-running host can check that they stop growing.
+// [Interp.CompactPermanent] releases the permanent objects nothing refers
+// to any more.
+//
 // # The Obj Type System
 //
 // TCL values are represented by [*Obj]. Each Obj has two representations:
@@ -192,9 +294,20 @@
 //	list, err := obj.List()  // Get as []*Obj (parses strings automatically)
 //	dict, err := obj.Dict()  // Get as *DictType (parses strings automatically)
 //
+// Integers that overflow int64 in expr become bignums. obj.Int() fails
+// for them; use obj.BigInt() to get a *big.Int, and [Interp.BigInt] to
+// create one.
+//
 // The List() and Dict() methods automatically parse string objects when needed,
 // using the interpreter that created the object.
 //
+// For large numeric vectors, [Interp.IntSlice] and [Interp.FloatSlice] create
+// lists that keep their numbers unboxed, and [AsIntSlice] and [AsFloatSlice]
+// read any list back into a Go slice in one pass:
+//
+//	result, err := interp.Call("lsort", "-real", interp.FloatSlice(samples))
+//	sorted, err := feather.AsFloatSlice(result)
+//
 // # Custom Object Types
 //
 // Implement [ObjType] to create types that participate in shimmering.
@@ -340,12 +453,137 @@
 //	    return feather.OK(n * 2)
 //	})
 //
+// [Args] declares the arguments such a command takes and checks them with
+// the error messages TCL commands give:
+//
+//	a, err := feather.Args(cmd, args).Flag("-force").Require("value", feather.Int).Parse()
+//
+// Options are parsed as by [Interp.ParseOptions], which the options command
+// uses too, so commands written in Go and in TCL treat them alike.
+//
+// In the other direction, [Func] turns a proc into a typed Go function, so
+// that scripts can supply hooks for Go code:
+//
+//	interp.MustEval(`proc score {name} { string length $name }`)
+//	score, err := feather.Func[func(string) (int, error)](interp, "score")
+//
 // # Configuration
 //
 // Set the recursion limit to prevent stack overflow from deeply nested calls:
 //
 //	interp.SetRecursionLimit(500)  // Default is 1000
 //
+// Calls made with tailcall replace the proc that makes them, so recursion
+// written with tailcall runs to any depth. Other recursion runs as deep as
+// the recursion limit allows: procs nested deeply continue on new stacks.
+//
+// Expressions and substitutions nested deeply within a command fail with
+// "out of stack space" before they overflow the C stack;
+// [Interp.SetStackLimit] sets how much of it they may use.
+//
+// [Interp.EvalTimeout] stops a script that runs for too long, such as one
+// stuck in a loop, and leaves the interpreter ready for the next:
+//
+//	_, err := interp.EvalTimeout(script, time.Second)
+//	if errors.Is(err, feather.ErrTimeout) { ... }
+//
+// [Interp.SetValueLimits] caps the number of elements of the lists and
+// dicts scripts build, and stops a script that builds a larger one with
+// [ErrValueTooLarge], so that it cannot exhaust the host's memory.
+//
+// A panic in a command written in Go becomes an error of the command, with
+// the Go stack in ::errorInfo, unless [Interp.SetRecoverPanics] is given
+// false to let it crash the process.
+//
+// Scripts print to the process's standard output and error unless they are
+// redirected with [Interp.SetStdout] and [Interp.SetStderr], and gets and read
+// take standard input from the process unless [Interp.SetStdin] gives them
+// another reader. Commands written
+// in Go should print through [Interp.Stdout] so that their output goes to the
+// same place, in order with puts. [Interp.CaptureOutput] collects what one
+// script prints:
+//
+//	out, _, _, err := interp.CaptureOutput(`puts "hello"`)
+//
+// The source command reads scripts from disk, resolving relative paths
+// against the directory of the script that sources them. Give
+// [Interp.SetSourceLoader] a function to serve them from somewhere else,
+// such as an embed.FS, and use [Interp.SourceFile] to run one from Go:
+//
+//	interp.SetSourceLoader(func(path string) (string, error) {
+//	    data, err := scripts.ReadFile(path)
+//	    return string(data), err
+//	})
+//	_, err := interp.SourceFile("app/main.tcl")
+//
+// package require loads libraries when scripts ask for them. Packages of
+// TCL scripts are found through the source loader in the directories listed
+// in auto_path, and [Interp.RegisterPackage] offers a package written in Go
+// whose commands are only registered once a script requires it:
+//
+//	interp.RegisterPackage("geo", "1.0", func(i *feather.Interp) error {
+//	    i.Register("geo::distance", distance)
+//	    return nil
+//	})
+//
+// [Bundle] serves a tree of library scripts embedded in the program and
+// adds it to auto_path, so single-binary tools can carry their packages:
+//
+//	//go:embed lib
+//	var lib embed.FS
+//
+//	feather.Bundle(lib, "lib").Install(interp)
+//
+// Scripts find the environment in the global dict env, and changing it
+// changes the environment exec passes to programs. [Interp.SetEnvPolicy]
+// hides variables from scripts or gives them a synthetic environment, and
+// [Interp.SetArgs] sets argv0, argv and argc for hosts that run scripts
+// from the command line:
+//
+//	interp.SetEnvPolicy(feather.FilterEnvironment(feather.OSEnvironment(), func(key string) bool {
+//	    return !strings.HasPrefix(key, "AWS_")
+//	}))
+//
+// # Tracing and Debugging
+//
+// [Interp.TraceVar], [Interp.TraceCommand] and [Interp.TraceExecution] add
+// traces like the trace command does, with Go callbacks. [Interp.SetEvalHook]
+// reports every command before it runs, with its arguments, frame level and
+// line, for profilers and coverage tools.
+//
+// [Interp.SetLogger] sends structured events to a [log/slog.Logger]: commands
+// registered, calls to unknown commands, script errors with their stack,
+// traces firing, the recursion limit being hit and foreign objects being
+// created and destroyed.
+//
+// [Interp.StartProfile] and [Interp.StopProfile] time every command and
+// report call counts and inclusive and exclusive time per proc and builtin.
+// Scripts can do the same with profile on, profile off and profile report.
+//
+// [Interp.EnableCoverage] counts the commands run on each line of each
+// sourced file, and [Interp.CoverageReport] returns the counts, to find the
+// lines of a library that tests never reach. Scripts use coverage on,
+// coverage off and coverage report.
+//
+// package require tcltest provides test, testConstraint and cleanupTests,
+// so that test suites written for TCL's tcltest run under feather.
+// [RunTestFile] sources such a suite and returns the result of each test,
+// for a Go test to report:
+//
+//	report, err := feather.RunTestFile(interp, "tests/parse.test")
+//	fmt.Println(report.Count(feather.TestFailed), "tests failed")
+//
+// [Interp.SetDebugger] installs a callback that pauses execution at
+// breakpoints set with [Interp.SetBreakpoint]. While paused it can inspect
+// the stack with [Interp.Frames], read and write variables in any frame, and
+// then continue or step into, over or out of the current command:
+//
+//	interp.SetBreakpoint("", 12)
+//	interp.SetDebugger(func(e feather.EvalEvent) feather.DebugAction {
+//	    fmt.Println("paused at", e.Line, e.Name)
+//	    return feather.DebugStepOver
+//	})
+//
 // # Parsing Without Evaluation
 //
 // Use [Interp.Parse] to check if a script is syntactically complete without
@@ -361,6 +599,22 @@
 //	    // Syntax error, pr.Message has details
 //	}
 //
+// [REPL] builds an interactive loop on this, buffering lines until they
+// form a complete command:
+//
+//	repl := feather.NewREPL(interp)
+//	repl.Run(os.Stdin, os.Stdout)
+//
+// [Interp.Complete] returns the candidates for completing the word at a
+// cursor position, for Tab completion in shells and editors.
+//
+// To build scripts and lists as text, quote words with [QuoteListElement]
+// or join them with [JoinList], which quote exactly as the list command
+// does, and read lists back with [SplitList]. None of them need an
+// interpreter:
+//
+//	script := feather.JoinList([]string{"puts", userInput})
+//
 // # Internal Types (Do Not Use)
 //
 // The following types are internal implementation details for C interop.
@@ -1,10 +1,18 @@
 package feather
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"os/exec"
 	"reflect"
 	"runtime/cgo"
+	"slices"
 	"strings"
+	"time"
 )
 
 // Interp is a TCL interpreter instance.
@@ -17,21 +25,23 @@ import (
 //	result, err := interp.Eval("expr 2 + 2")
 type Interp struct {
 	handle          FeatherInterp
-	objects         map[FeatherObj]*Obj // permanent storage (foreign objects)
-	scratch         map[FeatherObj]*Obj // scratch arena (temporary objects, reset after eval)
-	scratchNextID   FeatherObj          // next ID for scratch arena (has high bit set)
-	globalNS        FeatherObj          // global namespace object (FeatherObj handle for "::")
+	core            *coreState        // the C core's state, in C memory
+	objects         handleTable[*Obj] // permanent storage (foreign objects)
+	scratch         handleTable[*Obj] // scratch arena (temporary objects, reset after eval)
+	literals        literalTable      // objects of strings interned often, shared by every eval
+	pins            stringPins        // string bytes lent to C, released with the scratch arena
+	globalNS        FeatherObj        // global namespace object (FeatherObj handle for "::")
 	namespaces      map[string]*Namespace
 	globalNamespace *Namespace
-	nextID          FeatherObj // next ID for permanent storage (no high bit)
-	result          *Obj       // current result (persistent, not handle)
-	returnOptions   *Obj       // options from the last return command (persistent)
+	result          *Obj // current result (persistent, not handle)
+	returnOptions   *Obj // options from the last return command (persistent)
 	frames          []*CallFrame
 	active          int  // currently active frame index
 	recursionLimit  int  // maximum call stack depth (0 means use default)
+	keepPanics      bool // panics in Go commands are not recovered
 	scriptPath      *Obj // current script file being executed (nil = none)
-	builders        map[FeatherObj]*strings.Builder
-	evalDepth       int  // tracks nested eval calls for scratch arena management
+	builders        handleTable[*strings.Builder]
+	evalDepth       int          // tracks nested eval calls for scratch arena management
 	savedLocals     []*Namespace // stack for saving frame.locals during namespace eval
 
 	// Commands holds registered Go command implementations.
@@ -42,6 +52,56 @@ type Interp struct {
 	ForeignRegistry *ForeignRegistry
 
 	unknownHandler InternalCommandFunc
+	hidden         map[string]*Command // commands hidden with Hide, by name
+
+	channels     map[string]*channel   // host-side I/O channels (stdin, stdout, ...)
+	flushTimeout time.Duration         // how long to wait for queued output (0 = default)
+	nprocSpecs   map[string]*nprocSpec // parsed nproc parameter lists, keyed by source
+	nprocTraces  map[string]func()     // untrack the nprocs defined, by qualified name
+	encoding     string                // system encoding used by encoding convertto/convertfrom
+
+	sourceLoader func(path string) (string, error) // reads scripts for source (nil = os.ReadFile)
+	execPolicy   func(cmd *exec.Cmd) error         // checks the programs exec runs (nil = allow all)
+	filesystem   FilesystemDriver                  // the files the file command works on (nil = the OS)
+	envPolicy    Environment                       // the environment env presents (nil = the OS)
+
+	reportEvents []ReportEvent // recent activity for Report, oldest first
+	reportLimit  int           // maximum number of reportEvents kept (0 = off)
+
+	coroutines       map[*coroutine]struct{} // live coroutines
+	currentCoroutine *coroutine              // running coroutine (nil = none)
+
+	events *eventQueue // after events and functions posted from Go
+	bus    *Bus        // where the bus command publishes (nil = none yet)
+	oo     *ooState    // oo::class and its objects
+
+	packages *packageState // packages provided and available to package require
+	hooks    *Hooks        // hooks defined from Go, with the handlers scripts added
+	tcltest  *tcltestState // constraints and results of the tcltest package (nil = not loaded)
+
+	baseline    map[string]map[string]*Command // commands installed from Go, by namespace, which Reset restores
+	baseVars    map[string]map[string]*Obj     // variables New leaves, by namespace, which Reset restores
+	hostChanges int                            // a Go API is changing commands through scripts
+	resetting   bool                           // Reset is running
+
+	onClose []func() // functions Close calls, registered with OnClose
+	closed  bool     // Close has been called
+
+	traceCount  int             // traces added with TraceVar, TraceCommand and TraceExecution
+	evalHook    func(EvalEvent) // called before each command (nil = none)
+	debug       *debugger       // breakpoints and stepping (nil = none)
+	profile     *profiler       // the running or last profile (nil = never profiled)
+	coverage    *coverage       // the lines run since EnableCoverage (nil = never enabled)
+	evalHooksOn bool            // the C core reports commands to reportEval
+	inEvalHook  bool            // evalHook or the debugger is running
+
+	logger *slog.Logger // where diagnostics go (nil = nowhere)
+
+	deadline time.Time // when EvalTimeout stops the script (zero = none)
+	timedOut bool      // the deadline passed and the script is unwinding
+
+	valueLimits   ValueLimits // caps on the size of values, set with SetValueLimits
+	valueTooLarge string      // the error of a value that ran into its limit, until the outermost eval returns
 }
 
 // -----------------------------------------------------------------------------
@@ -129,13 +189,12 @@ func (o objHandle) foreignValue() any {
 //	defer interp.Close()
 func New() *Interp {
 	interp := &Interp{
-		objects:       make(map[FeatherObj]*Obj),
-		scratch:       make(map[FeatherObj]*Obj),
-		scratchNextID: scratchHandleBit | 1, // Start scratch IDs with high bit set
-		namespaces:    make(map[string]*Namespace),
-		builders:      make(map[FeatherObj]*strings.Builder),
-		Commands:      make(map[string]InternalCommandFunc),
-		nextID:        1, // Permanent IDs start at 1 (no high bit)
+		scratch:    handleTable[*Obj]{tag: scratchHandleBit},
+		builders:   handleTable[*strings.Builder]{tag: builderHandleBit},
+		namespaces: make(map[string]*Namespace),
+		Commands:   make(map[string]InternalCommandFunc),
+		baseline:   make(map[string]map[string]*Command),
+		baseVars:   make(map[string]map[string]*Obj),
 	}
 	// Create the global namespace
 	globalNS := &Namespace{
@@ -158,19 +217,109 @@ func New() *Interp {
 	interp.active = 0
 	// Use cgo.Handle to allow C callbacks to find this interpreter
 	interp.handle = FeatherInterp(cgo.NewHandle(interp))
+	interp.core = newCoreState()
 	// Create the global namespace object (FeatherObj handle for "::")
 	interp.globalNS = interp.internStringPermanent("::")
 	// Initialize the C interpreter
 	callCInterpInit(interp.handle)
+	// Install host-provided commands (I/O channels and feather extensions)
+	interp.registerChannels()
+	interp.registerDefer()
+	interp.registerNproc()
+	interp.registerBinary()
+	interp.registerEncoding()
+	interp.registerUnicode()
+	interp.registerExec()
+	interp.registerFile()
+	interp.registerGlob()
+	interp.registerCoroutines()
+	interp.registerEvents()
+	interp.registerBus()
+	interp.registerOO()
+	interp.registerInterp()
+	interp.registerSource()
+	interp.registerPackages()
+	interp.registerTcltest()
+	interp.registerOptions()
+	interp.registerHooks()
+	interp.registerPlatform()
+	interp.registerEnv()
+	interp.RegisterCommand("profile", cmdProfile)
+	interp.RegisterCommand("coverage", cmdCoverage)
+	interp.recordBaseline()
+	interp.initLiterals()
+	interp.bindEnv()
 	return interp
 }
 
+// ErrInterpClosed is returned when a closed interpreter, or an *Obj that
+// belongs to one, is used, and fails work still queued when it is closed.
+var ErrInterpClosed = errors.New("feather: interpreter is closed")
+
 // Close releases resources associated with the interpreter.
 //
+// Close destroys the foreign objects still alive, calling the Destroy
+// function of their types, and then calls the functions registered with
+// [Interp.OnClose].
+//
 // After Close is called, the interpreter and all *Obj values created from it
-// become invalid. Always use defer to ensure Close is called.
+// become invalid: evaluating scripts and converting values with methods such
+// as [Obj.Int] and [Obj.List] return [ErrInterpClosed]. Built with the
+// featherdebug tag, they panic instead, as does [Obj.String]. Calling Close
+// again does nothing. Always use defer to ensure Close is called.
 func (i *Interp) Close() {
+	if i.closed {
+		return
+	}
+	for co := range i.coroutines {
+		i.killCoroutine(co)
+	}
+	i.cancelFutures()
+	i.leaveBus()
+	i.destroyAllForeign()
+	i.SetValueLimits(ValueLimits{})
+	for _, fn := range slices.Backward(i.onClose) {
+		fn()
+	}
+	i.onClose = nil
+	i.failPosted(ErrInterpClosed)
+	i.evalHook, i.debug, i.profile, i.coverage = nil, nil, nil, nil
+	i.updateEvalHooks()
+	// All channels share one timeout, so that slow writers cannot hold up
+	// Close for longer than it
+	deadline := time.Now().Add(i.getFlushTimeout())
+	for _, c := range i.channels {
+		c.close(time.Until(deadline))
+	}
+	i.resetScratch()
+	i.closed = true
 	cgo.Handle(i.handle).Delete()
+	freeCoreState(i.core)
+	i.core = nil
+}
+
+// checkOpen returns ErrInterpClosed once the interpreter is closed, or
+// panics in featherdebug builds.
+func (i *Interp) checkOpen() error {
+	if !i.closed {
+		return nil
+	}
+	if featherDebug {
+		panic("feather: interpreter used after Close")
+	}
+	return ErrInterpClosed
+}
+
+// OnClose registers fn to be called when the interpreter is closed, after
+// its foreign objects are destroyed, to tie the lifetime of Go resources
+// to the interpreter:
+//
+//	db, _ := sql.Open("sqlite", path)
+//	interp.OnClose(func() { db.Close() })
+//
+// Functions are called in the reverse of the order they were registered.
+func (i *Interp) OnClose(fn func()) {
+	i.onClose = append(i.onClose, fn)
 }
 
 // -----------------------------------------------------------------------------
@@ -204,6 +353,31 @@ func (i *Interp) Double(v float64) *Obj {
 	return &Obj{intrep: DoubleType(v), interp: i}
 }
 
+// BigInt creates an integer object of any size. Values that fit in an
+// int64 become plain integers; larger ones are stored as bignums.
+//
+//	n := interp.BigInt(new(big.Int).Lsh(big.NewInt(1), 64))
+//	n.Type()   // "bignum"
+//	n.String() // "18446744073709551616"
+func (i *Interp) BigInt(v *big.Int) *Obj {
+	if v.IsInt64() {
+		return i.Int(v.Int64())
+	}
+	return &Obj{intrep: BignumType{Value: new(big.Int).Set(v)}, interp: i}
+}
+
+// ByteArray creates a binary data object.
+//
+// The object's string form has one character per byte, so the data
+// survives being passed through string commands unchanged.
+//
+//	b := interp.ByteArray([]byte{0x00, 0xff})
+//	b.Type()        // "bytearray"
+//	b.ByteArray()   // []byte{0x00, 0xff}
+func (i *Interp) ByteArray(b []byte) *Obj {
+	return &Obj{intrep: ByteArrayType(b), interp: i}
+}
+
 // Bool creates a boolean object, stored as int 1 (true) or 0 (false).
 //
 // TCL has no native boolean type; booleans are represented as integers.
@@ -224,6 +398,11 @@ func (i *Interp) Bool(v bool) *Obj {
 //	list.Type()   // "list"
 //	list.String() // "a 1 1"
 func (i *Interp) List(items ...*Obj) *Obj {
+	if featherDebug {
+		for _, item := range items {
+			i.checkOwner(item)
+		}
+	}
 	return &Obj{intrep: ListType(items), interp: i}
 }
 
@@ -275,9 +454,34 @@ func (i *Interp) ListFrom(slice any) *Obj {
 	return i.List(items...)
 }
 
+// IntSlice creates a list of integers backed by a copy of v.
+//
+// Unlike [Interp.ListFrom], the numbers are not boxed into one *Obj each
+// until a script first treats the value as a list, and [AsIntSlice] reads
+// them back in a single copy. Use it to hand large numeric vectors to
+// scripts.
+//
+//	v := interp.IntSlice([]int64{1, 2, 3})
+//	v.Type()   // "intslice"
+//	v.String() // "1 2 3"
+func (i *Interp) IntSlice(v []int64) *Obj {
+	return &Obj{intrep: IntSliceType(slices.Clone(v)), interp: i}
+}
+
+// FloatSlice creates a list of floating-point numbers backed by a copy of v.
+// See [Interp.IntSlice]; [AsFloatSlice] reads the numbers back.
+//
+//	v := interp.FloatSlice([]float64{0.5, 2})
+//	v.Type()   // "floatslice"
+//	v.String() // "0.5 2.0"
+func (i *Interp) FloatSlice(v []float64) *Obj {
+	return &Obj{intrep: FloatSliceType(slices.Clone(v)), interp: i}
+}
+
 // Dict creates an empty dict object.
 //
-// For populated dicts, use [Interp.DictKV] or [Interp.DictFrom]:
+// For populated dicts, use [Interp.DictKV] or [Interp.DictFrom], or
+// [Interp.NewDictBuilder] to add keys one at a time:
 //
 //	dict := interp.DictKV("name", "Alice", "age", 30)
 func (i *Interp) Dict() *Obj {
@@ -301,6 +505,37 @@ func (i *Interp) Obj(intrep ObjType) *Obj {
 	return &Obj{intrep: intrep, interp: i}
 }
 
+// Duplicate returns a copy of obj that belongs to i, which Go code can
+// modify without changing obj, as with [Obj.Clone].
+//
+// Use it to pass a value created by another interpreter, or by none, to
+// i. Elements of lists and dicts that belong elsewhere are duplicated too,
+// so that i parses them when they are shimmered.
+//
+//	mine := interp.Duplicate(other.Var("config"))
+func (i *Interp) Duplicate(obj *Obj) *Obj {
+	if obj == nil {
+		return nil
+	}
+	c := obj.Clone()
+	c.interp = i
+	switch t := c.intrep.(type) {
+	case ListType:
+		for n, item := range t {
+			if item != nil && item.interp != i {
+				t[n] = i.Duplicate(item)
+			}
+		}
+	case *DictType:
+		for k, v := range t.Items {
+			if v != nil && v.interp != i {
+				t.Items[k] = i.Duplicate(v)
+			}
+		}
+	}
+	return c
+}
+
 // DictKV creates a dict object from alternating key-value pairs.
 //
 // Keys should be strings (non-strings are converted via fmt.Sprintf).
@@ -326,8 +561,8 @@ func (i *Interp) DictKV(kvs ...any) *Obj {
 
 // DictFrom creates a dict object from a Go map.
 //
-// Values are auto-converted based on their Go type.
-// Note: Go maps have undefined iteration order, so dict key order may vary.
+// Values are auto-converted based on their Go type. Go maps have no
+// order, so the keys are sorted, as for maps converted by [Interp.Value].
 //
 //	dict := interp.DictFrom(map[string]any{
 //	    "name": "Alice",
@@ -335,10 +570,9 @@ func (i *Interp) DictKV(kvs ...any) *Obj {
 //	})
 func (i *Interp) DictFrom(m map[string]any) *Obj {
 	items := make(map[string]*Obj, len(m))
-	order := make([]string, 0, len(m))
-	for k, v := range m {
-		order = append(order, k)
-		items[k] = i.anyToObj(v)
+	order := slices.Sorted(maps.Keys(m))
+	for _, k := range order {
+		items[k] = i.anyToObj(m[k])
 	}
 	return &Obj{intrep: &DictType{Items: items, Order: order}, interp: i}
 }
@@ -363,10 +597,36 @@ func (i *Interp) anyToObj(v any) *Obj {
 		}
 		return val
 	default:
-		return i.String(fmt.Sprintf("%v", v))
+		return i.Value(v)
 	}
 }
 
+// Value converts a Go value to a TCL value.
+//
+// Conversion is deep, so nested combinations work as expected:
+//   - string, integer, float and bool types become strings, ints and doubles;
+//     unsigned integers too large for an int64 become bignums
+//   - []byte becomes a bytearray; other slices and arrays become lists
+//   - maps become dicts, with keys sorted for a stable order
+//   - structs become dicts keyed by field name, or by a `feather:"name"`
+//     field tag (`feather:"-"` skips a field)
+//   - pointers and interfaces are followed; nil becomes the empty string
+//   - time.Time becomes an RFC 3339 timestamp
+//   - types implementing [Marshaler] convert themselves
+//   - [*Obj] values are returned unchanged
+//
+// Example:
+//
+//	type User struct {
+//	    Name  string   `feather:"name"`
+//	    Roles []string `feather:"roles"`
+//	}
+//	obj := interp.Value(User{Name: "alice", Roles: []string{"admin", "dev"}})
+//	obj.String() // "name alice roles {admin dev}"
+func (i *Interp) Value(v any) *Obj {
+	return i.reflectToObj(reflect.ValueOf(v))
+}
+
 // anyToHandle converts any Go value to an internal object handle.
 // Used internally for auto-conversion in SetVar, etc.
 func (i *Interp) anyToHandle(v any) FeatherObj {
@@ -388,11 +648,32 @@ func (i *Interp) anyToHandle(v any) FeatherObj {
 //	}
 //	fmt.Println(result.String()) // "20"
 func (i *Interp) Eval(script string) (*Obj, error) {
-	_, err := i.eval(script)
+	res, err := i.eval(script)
 	if err != nil {
+		i.recordEvent("eval", script, ResultError, err.Error())
+		if i.evalDepth == 0 && i.logging(slog.LevelError) {
+			attrs := []any{"error", err.Error()}
+			if e, ok := err.(*EvalError); ok {
+				attrs = append(attrs, "errorcode", e.ErrorCode, "errorinfo", e.ErrorInfo)
+			}
+			i.log(slog.LevelError, "script error", attrs...)
+		}
 		return nil, err
 	}
-	return i.objForHandle(i.ResultHandle()), nil
+	i.recordEvent("eval", script, ResultOK, res)
+	return dictShared(i.result), nil
+}
+
+// MustEval is like [Interp.Eval] but panics if the script returns an error.
+// It simplifies initialization scripts that are known to be valid.
+//
+//	interp.MustEval(`proc double {x} { expr {$x * 2} }`)
+func (i *Interp) MustEval(script string) *Obj {
+	result, err := i.Eval(script)
+	if err != nil {
+		panic(fmt.Sprintf("feather: MustEval(%q): %v", script, err))
+	}
+	return result
 }
 
 // EvalObj evaluates a TCL script contained in an object.
@@ -412,14 +693,13 @@ func (i *Interp) EvalObj(obj *Obj) (*Obj, error) {
 // directly to the command without TCL parsing. This means strings with special
 // characters (unbalanced braces, $, [, etc.) are passed safely without escaping.
 //
-// Arguments can be Go types or *Obj values:
-//   - *Obj: passed directly as-is
-//   - string: converted to TCL string
-//   - int, int64: converted to TCL integer
-//   - float64: converted to TCL double
-//   - bool: converted to 1 or 0
-//   - []string: converted to TCL list
-//   - Other types: converted via fmt.Sprintf
+// Arguments are converted as described for [Interp.Value], as the results
+// of functions registered with [Interp.Register] are: a [*Obj] is passed as
+// it is, a slice becomes a single list argument, and a map or struct a dict.
+// Wrap a value in [Expand] to pass its elements as separate arguments, as
+// {*} does in a script.
+//
+// A failing command returns an [*EvalError].
 //
 // Examples:
 //
@@ -427,20 +707,53 @@ func (i *Interp) EvalObj(obj *Obj) (*Obj, error) {
 //	result, err := interp.Call("llength", myList)
 //	result, err := interp.Call("myns::proc", arg1, arg2)
 //	result, err := interp.Call("usage", "complete", "hello { l", 9)  // unbalanced brace OK
+//	result, err := interp.Call("lappend", "names", feather.Expand([]string{"ann", "bob"}))
 func (i *Interp) Call(cmd string, args ...any) (*Obj, error) {
-	// Build the command string with proper quoting
-	parts := make([]string, len(args)+1)
-	parts[0] = quote(cmd)
-	for idx, arg := range args {
-		parts[idx+1] = toTclString(arg)
-	}
+	return i.callWords(cmd, args)
+}
 
-	script := strings.Join(parts, " ")
-	result, err := i.Eval(script)
-	if err != nil {
-		return nil, err
+// Apply calls the anonymous function lambda, a list of parameters, body
+// and optional namespace, with args, as apply does. Arguments are converted
+// as for [Interp.Call].
+//
+//	double := interp.List(interp.String("x"), interp.String("expr {$x * 2}"))
+//	result, err := interp.Apply(double, 21) // 42
+func (i *Interp) Apply(lambda *Obj, args ...any) (*Obj, error) {
+	return i.callWords("apply", append([]any{lambda}, args...))
+}
+
+// callWords evaluates the command cmd with args converted to its words,
+// expanding those wrapped in Expand.
+func (i *Interp) callWords(cmd string, args []any) (*Obj, error) {
+	words := make([]string, 1, len(args)+1)
+	words[0] = quote(cmd)
+	for _, arg := range args {
+		if e, ok := arg.(Expansion); ok {
+			items, err := i.Value(e.list).List()
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				words = append(words, quote(item.String()))
+			}
+			continue
+		}
+		words = append(words, quote(i.Value(arg).String()))
 	}
-	return result, nil
+	return i.Eval(strings.Join(words, " "))
+}
+
+// Expansion is a value whose elements [Interp.Call] passes as separate
+// arguments. Create one with [Expand].
+type Expansion struct {
+	list any
+}
+
+// Expand marks list, a Go slice or a TCL list, for [Interp.Call] and
+// [Interp.Apply] to pass its elements as separate arguments, as
+// {*}$list does in a script.
+func Expand(list any) Expansion {
+	return Expansion{list: list}
 }
 
 // -----------------------------------------------------------------------------
@@ -461,7 +774,7 @@ func (i *Interp) Var(name string) *Obj {
 	if h == 0 {
 		return i.String("")
 	}
-	return i.objForHandle(h)
+	return dictShared(i.objForHandle(h))
 }
 
 // SetVar sets a variable to a value.
@@ -478,6 +791,51 @@ func (i *Interp) SetVar(name string, val any) {
 	i.setVar(name, toTclString(val))
 }
 
+// GetVarObj returns the value of the variable name as the script would
+// read it with set: name may be qualified, such as "::ns::var", upvar and
+// global links are followed and read traces fire. It reports false if the
+// variable does not exist or a read trace fails. The interpreter's result
+// is left unchanged.
+//
+//	interp.Eval("namespace eval cfg { variable port 8080 }")
+//	port, ok := interp.GetVarObj("::cfg::port") // 8080, true
+func (i *Interp) GetVarObj(name string) (*Obj, bool) {
+	result := i.result
+	defer func() { i.result = result }()
+	val, err := i.getVarObj(name)
+	return val, err == nil
+}
+
+// SetVarObj sets the variable name to val as the script would with set,
+// keeping the type of val. Like [Interp.GetVarObj], it resolves qualified
+// names and links, and write traces fire; the error is that of a failing
+// trace, such as writing a variable bound with [Interp.BindVarReadOnly].
+func (i *Interp) SetVarObj(name string, val *Obj) error {
+	result := i.result
+	defer func() { i.result = result }()
+	return i.setVarObj(name, val)
+}
+
+// VarAtLevel is like [Interp.GetVarObj], but reads the variable in the
+// call frame at level, as upvar #level does: 0 is the global frame and
+// [Interp.Frames] lists the others. It reports false if there is no such
+// frame.
+func (i *Interp) VarAtLevel(level int, name string) (*Obj, bool) {
+	var val *Obj
+	var ok bool
+	i.atLevel(level, func() error {
+		val, ok = i.GetVarObj(name)
+		return nil
+	})
+	return val, ok
+}
+
+// SetVarAtLevel is like [Interp.SetVarObj], but sets the variable in the
+// call frame at level, as for [Interp.VarAtLevel].
+func (i *Interp) SetVarAtLevel(level int, name string, val *Obj) error {
+	return i.atLevel(level, func() error { return i.SetVarObj(name, val) })
+}
+
 // SetVars sets multiple variables at once from a map.
 //
 // This is a convenience method equivalent to calling [Interp.SetVar] for each entry.
@@ -541,13 +899,27 @@ type CommandFunc func(i *Interp, cmd *Obj, args []*Obj) Result
 //	    return feather.OK(a + b)
 //	})
 func (i *Interp) RegisterCommand(name string, fn CommandFunc) {
-	i.register(name, func(ii *Interp, cmd FeatherObj, args []FeatherObj) FeatherResult {
+	i.register(name, i.wrapCommand(fn))
+}
+
+// wrapCommand adapts a [CommandFunc] to the handle-based internal calling convention.
+func (i *Interp) wrapCommand(fn CommandFunc) InternalCommandFunc {
+	return func(ii *Interp, cmd FeatherObj, args []FeatherObj) FeatherResult {
 		objArgs := make([]*Obj, len(args))
 		for j, h := range args {
 			objArgs[j] = ii.objForHandle(h)
 		}
 		cmdObj := ii.objForHandle(cmd)
 		r := fn(i, cmdObj, objArgs)
+		if r.returnLevel > 0 {
+			ii.returnOptions = ii.List(ii.String("-code"), ii.Int(int64(r.returnCode)), ii.String("-level"), ii.Int(int64(r.returnLevel)))
+		}
+		if r.async != nil {
+			r.obj, r.hasObj = ii.String(ii.startFuture(r.async)), true
+		}
+		if r.value != nil {
+			r.obj, r.hasObj = ii.Value(r.value), true
+		}
 		if r.hasObj && r.obj != nil {
 			h := ii.handleForObj(r.obj)
 			if r.code == ResultError {
@@ -560,31 +932,138 @@ func (i *Interp) RegisterCommand(name string, fn CommandFunc) {
 		} else {
 			ii.SetResultString(r.val)
 		}
+		if r.errorCode != nil {
+			ii.throwError(ii.Value(r.errorCode), cmd, args)
+		}
 		return r.code
-	})
+	}
 }
 
 // UnregisterCommand removes a previously registered command.
 // This is used by destroy methods to make the command unavailable.
+// Unlike [Interp.Unregister], it fires no traces.
 func (i *Interp) UnregisterCommand(name string) {
 	delete(i.Commands, name)
 	if i.globalNamespace != nil {
-		delete(i.globalNamespace.commands, name)
+		i.deleteCommand(i.globalNamespace, name)
+	}
+}
+
+// Unregister deletes the command name, resolved from the current namespace,
+// as rename name {} does: delete traces on the command fire, and any
+// command, not only one registered from Go, can be deleted. Deleting a
+// command that does not exist is an error.
+//
+//	interp.Unregister("exec")
+func (i *Interp) Unregister(name string) error {
+	if _, cmd := i.resolveCommandName(name); cmd == nil {
+		return fmt.Errorf("command \"%s\" doesn't exist", name)
 	}
+	var err error
+	i.asHost(func() { _, err = i.Call("rename", name, "") })
+	return err
+}
+
+// CommandNames returns the names of the commands visible from the current
+// namespace that match the glob pattern, as info commands does, sorted. An
+// empty pattern matches every command.
+func (i *Interp) CommandNames(pattern string) []string {
+	args := []any{"commands"}
+	if pattern != "" {
+		args = append(args, pattern)
+	}
+	list, err := i.Call("info", args...)
+	if err != nil {
+		return nil
+	}
+	items, _ := list.List()
+	names := make([]string, len(items))
+	for n, item := range items {
+		names[n] = item.String()
+	}
+	slices.Sort(names)
+	return names
+}
+
+// CommandInfo describes a command, as returned by [Interp.CommandInfo].
+type CommandInfo struct {
+	Name      string    // fully qualified name
+	Namespace string    // fully qualified name of the namespace the command is in
+	Kind      string    // "proc", "builtin" (implemented in C), "go" or "alias"
+	Params    []ProcArg // parameters of a proc; nil for other kinds
+	Target    string    // fully qualified target of an alias
+}
+
+// ProcArg is a parameter of a proc.
+type ProcArg struct {
+	Name       string
+	Default    string // default value, if HasDefault is set
+	HasDefault bool
+}
+
+// CommandInfo looks up the command name, resolved from the current
+// namespace, and reports whether it exists.
+//
+//	if info, ok := interp.CommandInfo("greet"); ok && info.Kind == "proc" {
+//	    fmt.Println(len(info.Params), "parameters")
+//	}
+func (i *Interp) CommandInfo(name string) (CommandInfo, bool) {
+	qualified, cmd := i.resolveCommandName(name)
+	if cmd == nil {
+		return CommandInfo{}, false
+	}
+	info := CommandInfo{Name: qualified, Kind: "builtin"}
+	info.Namespace = qualified[:strings.LastIndex(qualified, "::")]
+	if info.Namespace == "" {
+		info.Namespace = "::"
+	}
+	switch {
+	case cmd.cmdType == CmdProc:
+		info.Kind = "proc"
+		params, _ := asList(cmd.proc.params)
+		info.Params = make([]ProcArg, len(params))
+		for n, param := range params {
+			parts, err := asList(param)
+			if err != nil || len(parts) == 0 {
+				info.Params[n].Name = param.String()
+				continue
+			}
+			info.Params[n].Name = parts[0].String()
+			if len(parts) > 1 {
+				info.Params[n].Default, info.Params[n].HasDefault = parts[1].String(), true
+			}
+		}
+	case cmd.alias != "":
+		info.Kind, info.Target = "alias", cmd.alias
+	case cmd.fn != nil:
+		info.Kind = "go"
+	}
+	return info, true
 }
 
 // Register adds a command with automatic argument conversion.
 //
 // The function's signature determines how arguments are converted:
 //   - string parameters receive the string representation
-//   - int/int64 parameters parse the argument as an integer
-//   - float64 parameters parse as a floating-point number
+//   - integer parameters parse the argument as an integer, and fail if it is
+//     out of range for the type; uint64 accepts values past int64
+//   - float parameters parse as a floating-point number
 //   - bool parameters use TCL boolean rules
 //   - []string parameters receive remaining args as a list
+//   - []byte parameters receive the binary data of the argument
+//   - []int, []float64 and other slices parse the argument as a list
+//   - map[string]string and map[string]any parameters parse the argument as a dict
+//   - struct and *struct parameters are filled from a dict, keyed by field
+//     name or by a `feather:"name"` field tag (`feather:"-"` skips a field)
 //   - Variadic parameters (...string, ...int) consume remaining arguments
 //
+// Each argument is converted as [Into] converts a value, so *Obj,
+// time.Time and [Unmarshaler] parameters work too, and an empty argument
+// gives a pointer parameter nil.
+//
 // Return types are also auto-converted:
 //   - string, int, int64, float64, bool become the command result
+//   - slices become lists; maps, structs and *struct become dicts
 //   - error causes the command to fail with the error message
 //   - (T, error) returns T on success or fails on error
 //
@@ -612,51 +1091,176 @@ func (i *Interp) Register(name string, fn any) {
 	i.register(name, wrapper)
 }
 
-// SetUnknownHandler sets a handler called when a command is not found.
+// RegisterEnsemble adds an ensemble command whose subcommands are Go
+// functions, converted as described for [Interp.Register].
 //
-// The handler receives the unknown command name and its arguments. It can:
-//   - Implement the command dynamically
-//   - Delegate to another system
-//   - Return an error for truly unknown commands
+// Each subcommand is registered as a command in the namespace of the same
+// name, so the ensemble behaves like one created with namespace ensemble
+// create: unique prefixes of subcommand names are accepted and namespace
+// ensemble configure can change it afterwards.
 //
-// Set to nil to restore default behavior (return "invalid command" error).
+//	interp.RegisterEnsemble("counter", map[string]any{
+//	    "incr": func(by int) int { n += by; return n },
+//	    "get":  func() int { return n },
+//	})
+//	interp.Eval("counter incr 5")
 //
-//	interp.SetUnknownHandler(func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
-//	    // Try to auto-load the command
-//	    if loaded := tryLoadCommand(cmd.String()); loaded {
-//	        return i.Call(cmd.String(), args...)
-//	    }
-//	    return feather.Errorf("unknown command: %s", cmd.String())
+// Subcommands may also be any of the values [Interp.RegisterNamespace]
+// accepts.
+func (i *Interp) RegisterEnsemble(name string, subcommands map[string]any) {
+	i.RegisterNamespace(name, subcommands)
+}
+
+// RegisterNamespace creates the namespace name holding a command for each
+// member, and makes it an ensemble command as [Interp.RegisterEnsemble]
+// does, so that http get runs the command ::http::get:
+//
+//	interp.RegisterNamespace("http", map[string]any{
+//	    "get":  httpGet,  // func(url string) (string, error)
+//	    "post": httpPost, // func(url, body string) (string, error)
+//	    "header": map[string]any{
+//	        "set": setHeader,
+//	        "get": getHeader,
+//	    },
 //	})
-func (i *Interp) SetUnknownHandler(fn CommandFunc) {
-	i.setUnknownHandler(func(ii *Interp, cmd FeatherObj, args []FeatherObj) FeatherResult {
-		objArgs := make([]*Obj, len(args))
-		for j, h := range args {
-			objArgs[j] = ii.objForHandle(h)
-		}
-		cmdObj := ii.objForHandle(cmd)
-		r := fn(i, cmdObj, objArgs)
-		if r.hasObj && r.obj != nil {
-			h := ii.handleForObj(r.obj)
-			if r.code == ResultError {
-				ii.SetError(h)
-			} else {
-				ii.SetResult(h)
-			}
-		} else if r.code == ResultError {
-			ii.SetErrorString(r.val)
-		} else {
-			ii.SetResultString(r.val)
+//	interp.Eval("http header set Accept text/plain")
+//
+// A member that is a [CommandFunc], or a function of that signature, gets
+// its arguments unconverted, as with [Interp.RegisterCommand]. A member that
+// is a map[string]any becomes a child namespace that is an ensemble in turn.
+// Other members are functions converted as described for [Interp.Register].
+// As for any ensemble, subcommands can be abbreviated to unique prefixes, and
+// an unknown subcommand is an error listing the ones there are.
+func (i *Interp) RegisterNamespace(name string, members map[string]any) {
+	ns := "::" + strings.TrimPrefix(name, "::")
+	namespace := i.ensureNamespace(ns)
+	subs := slices.Sorted(maps.Keys(members))
+	mapping := make([]string, 0, 2*len(subs))
+	for _, sub := range subs {
+		var fn InternalCommandFunc
+		switch m := members[sub].(type) {
+		case map[string]any:
+			i.RegisterNamespace(ns+"::"+sub, m)
+			mapping = append(mapping, quote(sub), quote(ns+"::"+sub))
+			continue
+		case CommandFunc:
+			fn = i.wrapCommand(m)
+		case func(*Interp, *Obj, []*Obj) Result:
+			fn = i.wrapCommand(m)
+		default:
+			fn = wrapFunc(i, m)
 		}
-		return r.code
+		i.setCommand(namespace, sub, &Command{cmdType: CmdBuiltin, fn: fn})
+		mapping = append(mapping, quote(sub), quote(ns+"::"+sub))
+	}
+	i.asHost(func() {
+		i.Call("namespace", "ensemble", "create", "-command", ns, "-map", strings.Join(mapping, " "))
 	})
 }
 
+// RegisterMathFunc adds a function that can be called inside expr, as in
+// expr {percentile($data, 0.95)}. Arguments and results are converted as
+// described for [Interp.Register], so a []float64 parameter receives a list
+// and a variadic parameter receives any number of arguments.
+//
+// The function is registered as the command tcl::mathfunc::name, like the
+// built-in math functions, and replaces any existing function of that name.
+//
+//	interp.RegisterMathFunc("avg", func(xs ...float64) (float64, error) {
+//	    if len(xs) == 0 {
+//	        return 0, errors.New("avg needs at least one argument")
+//	    }
+//	    sum := 0.0
+//	    for _, x := range xs {
+//	        sum += x
+//	    }
+//	    return sum / float64(len(xs)), nil
+//	})
+//	interp.Eval("expr {avg(1, 2, 6)}") // 3.0
+func (i *Interp) RegisterMathFunc(name string, fn any) {
+	i.setCommand(i.ensureNamespace("::tcl::mathfunc"), name, &Command{cmdType: CmdBuiltin, fn: wrapFunc(i, fn)})
+}
+
+// OnUnknown sets fn to handle the commands scripts call that do not exist.
+// fn receives the name of the command as the script gave it, and the
+// arguments. It can define the command and run it, delegate to another
+// system, or fail as the default does:
+//
+//	interp.OnUnknown(func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+//	    script, ok := plugins[cmd.String()]
+//	    if !ok {
+//	        return feather.Errorf("invalid command name \"%s\"", cmd.String())
+//	    }
+//	    if _, err := i.Eval(script); err != nil {
+//	        return feather.Error(err.Error())
+//	    }
+//	    result, err := i.EvalObj(i.List(append([]*feather.Obj{cmd}, args...)...))
+//	    if err != nil {
+//	        return feather.Error(err.Error())
+//	    }
+//	    return feather.OK(result)
+//	})
+//
+// As in TCL, scripts can handle missing commands themselves by defining a
+// command named unknown in the global namespace, usually a proc, which is
+// called with the command's words and takes precedence over fn:
+//
+//	proc unknown {cmd args} {
+//	    set matches [info commands $cmd*]
+//	    if {[llength $matches] != 1} {
+//	        error "invalid command name \"$cmd\""
+//	    }
+//	    uplevel 1 [list [lindex $matches 0] {*}$args]
+//	}
+//
+// A nil fn restores the default, the error invalid command name "cmd".
+func (i *Interp) OnUnknown(fn CommandFunc) {
+	if fn == nil {
+		i.setUnknownHandler(nil)
+		return
+	}
+	i.setUnknownHandler(i.wrapCommand(fn))
+}
+
+// SetUnknownHandler is the former name of [Interp.OnUnknown].
+//
+// Deprecated: Use [Interp.OnUnknown].
+func (i *Interp) SetUnknownHandler(fn CommandFunc) {
+	i.OnUnknown(fn)
+}
+
+// GetIndexFromObj looks up the string value of obj in table and returns the
+// position of the matching entry. As with TCL's Tcl_GetIndexFromObj, a unique
+// prefix of an entry matches too, and a full match always wins.
+//
+// The position is cached on obj, so a command that resolves its subcommand
+// this way does the string comparisons once per literal word instead of on
+// every call. The cache is keyed by the table's backing array: keep tables
+// in package-level variables and never modify them.
+//
+//	var counterSubcommands = []string{"get", "incr", "reset"}
+//
+//	interp.RegisterCommand("counter", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+//	    if len(args) < 1 {
+//	        return feather.Error(`wrong # args: should be "counter subcommand"`)
+//	    }
+//	    n, err := i.GetIndexFromObj(args[0], counterSubcommands, "subcommand")
+//	    if err != nil {
+//	        return feather.Error(err.Error()) // bad subcommand "x": must be get, incr, or reset
+//	    }
+//	    ...
+//	})
+func (i *Interp) GetIndexFromObj(obj *Obj, table []string, what string) (int, error) {
+	return getIndex(obj, tableKey(table), table, what, false)
+}
+
 // -----------------------------------------------------------------------------
 // Parsing
 // -----------------------------------------------------------------------------
 
-// Parse checks if a script is syntactically complete.
+// Parse checks if a script is syntactically complete. It checks every
+// command without running anything: variables are not read and command
+// substitutions are not evaluated.
 //
 // This is useful for implementing REPLs that need to detect incomplete input
 // (unclosed braces, brackets, or quotes).
@@ -665,6 +1269,8 @@ func (i *Interp) SetUnknownHandler(fn CommandFunc) {
 //	if pr.Status == feather.ParseIncomplete {
 //	    // Prompt for more input
 //	}
+//
+// [REPL] does this for interactive input.
 func (i *Interp) Parse(script string) ParseResult {
 	pr := i.ParseInternal(script)
 	return ParseResult{
@@ -681,15 +1287,11 @@ func (i *Interp) Parse(script string) ParseResult {
 // This is used internally by Obj.List() for shimmering.
 func (i *Interp) parseList(s string) ([]*Obj, error) {
 	strHandle := i.internString(s)
-	handles, err := i.getList(strHandle)
+	items, err := i.listItems(strHandle)
 	if err != nil {
 		return nil, err
 	}
-	result := make([]*Obj, len(handles))
-	for j, h := range handles {
-		result[j] = i.objForHandle(h)
-	}
-	return result, nil
+	return slices.Clone(items), nil
 }
 
 // parseDict parses a string into a dict.
@@ -702,7 +1304,7 @@ func (i *Interp) parseDict(s string) (*DictType, error) {
 	}
 	result := &DictType{
 		Items: make(map[string]*Obj, len(items)),
-		Order: order,
+		Order: slices.Clone(order),
 	}
 	for k, h := range items {
 		result.Items[k] = i.objForHandle(h)
@@ -716,22 +1318,36 @@ func (i *Interp) parseDict(s string) (*DictType, error) {
 
 // Result represents the result of a command execution.
 //
-// Create results using [OK], [Error], or [Errorf].
+// Create results using [OK], [Error], or [Errorf], or [Throw] for errors
+// with an error code, and for commands that take part in control flow,
+// [Break], [Continue] or [Return]. [Async] returns a result that completes
+// later.
 type Result struct {
 	code   FeatherResult
 	val    string // used when obj is nil
 	obj    *Obj   // used when non-nil (preserves type)
 	hasObj bool   // true if obj should be used
+	value  any    // Go value converted with Interp.Value when the result is set
+
+	async     func(context.Context) (any, error) // the work of a future, for Async
+	errorCode any                                // the -errorcode of an error, for Throw
+
+	// For Return with a level above 0, the code and level the return options
+	// carry to the procs the return passes through
+	returnCode  FeatherResult
+	returnLevel int
 }
 
 // OK returns a successful result with a value.
 //
-// The value is auto-converted to a TCL string representation.
+// The value is auto-converted to a TCL value as described in [Interp.Value]:
+// slices become lists and maps and structs become dicts, deeply.
 // Pass a [*Obj] directly to preserve its internal type (int, list, dict, etc.).
 //
 //	return feather.OK("success")
 //	return feather.OK(42)
 //	return feather.OK([]string{"a", "b"})
+//	return feather.OK(User{Name: "alice"}) // dict: Name alice
 //	return feather.OK(myObj)  // preserves *Obj type
 func OK(v any) Result {
 	if o, ok := v.(*Obj); ok {
@@ -752,8 +1368,11 @@ func OK(v any) Result {
 			return Result{code: ResultOK, val: "1"}
 		}
 		return Result{code: ResultOK, val: "0"}
+	case nil:
+		return Result{code: ResultOK}
 	default:
-		return Result{code: ResultOK, val: fmt.Sprintf("%v", v)}
+		// Converted by the interpreter once the result is set
+		return Result{code: ResultOK, value: v}
 	}
 }
 
@@ -780,6 +1399,53 @@ func Errorf(format string, args ...any) Result {
 	return Result{code: ResultError, val: fmt.Sprintf(format, args...)}
 }
 
+// Throw returns an error result with message and the -errorcode code, a
+// list converted as for [OK], as the throw command does. Scripts match the
+// code with try ... trap:
+//
+//	return feather.Throw([]string{"POSIX", "ENOENT", "no such file or directory"},
+//	    `couldn't open "x": no such file or directory`)
+func Throw(code any, message string) Result {
+	return Result{code: ResultError, val: message, errorCode: code}
+}
+
+// Break returns a result that ends the innermost loop, as the break
+// command does.
+func Break() Result {
+	return Result{code: ResultBreak}
+}
+
+// Continue returns a result that skips to the next iteration of the
+// innermost loop, as the continue command does.
+func Continue() Result {
+	return Result{code: ResultContinue}
+}
+
+// Return returns a result with value, converted as for [OK], as
+// return -code code -level level value does. With level 0 the command
+// completes with code itself, which may be one of the standard codes or
+// an application-defined one; with level 1 the proc that called the
+// command returns with code, and so on up the call stack.
+//
+//	return feather.Return("done", feather.ResultOK, 1)  // return done from the caller
+//	return feather.Return("", feather.ResultBreak, 1)   // break in the caller's caller
+//	return feather.Return(nil, 5, 0)                    // custom code 5
+func Return(value any, code FeatherResult, level int) Result {
+	r := OK(value)
+	if level <= 0 {
+		r.code = code
+		return r
+	}
+	r.code, r.returnCode, r.returnLevel = ResultReturn, code, level
+	return r
+}
+
+// Code returns the return code of the result, such as [ResultOK],
+// [ResultError] or [ResultBreak].
+func (r Result) Code() FeatherResult {
+	return r.code
+}
+
 // -----------------------------------------------------------------------------
 // Parse Status
 // -----------------------------------------------------------------------------
@@ -823,15 +1489,68 @@ type TypeDef[T any] struct {
 	// Methods maps method names to Go functions.
 	// Each function's first parameter must be the receiver type T.
 	// Additional parameters and return values are auto-converted.
+	// A value may also be a [Method], to give the method options.
 	Methods map[string]any
 
-	// String optionally provides a custom string representation.
-	// If nil, a default "<TypeName:address>" format is used.
+	// Statics maps names to functions called as "TypeName name args",
+	// without an object, such as alternative constructors. They take no
+	// receiver; otherwise they are like Methods. The name new is reserved.
+	Statics map[string]any
+
+	// Embed lists registered types whose methods this type inherits, as Go
+	// promotes the methods of embedded fields. T must point to a struct
+	// that embeds each of their Go types, either the type itself or, for a
+	// pointer type *S, S. Methods of T shadow inherited ones, and types
+	// earlier in Embed shadow later ones.
+	Embed []string
+
+	// String optionally names new objects. Its result is the object's string
+	// representation and the name of its command, so it must not be the
+	// name of an existing command. It is called once, when the object is
+	// created. If nil, names are the lowercase type name and a counter,
+	// such as "counter1".
 	String func(T) string
 
-	// Destroy is called when the object is garbage collected or explicitly destroyed.
+	// Destroy is called when the object is garbage collected or explicitly destroyed,
+	// and for objects still alive when the interpreter is reset or closed.
 	// Use for cleanup (closing files, connections, etc.).
 	Destroy func(T)
+
+	// AutoDestroy makes objects destroy themselves, as their destroy method
+	// does, once no TCL value refers to them: no variable, list or other
+	// value holds the object returned by new, and Go holds no *Obj for it.
+	// A copy of an object's name in a plain string does not keep it alive.
+	// Unreachable objects are found by the Go garbage collector and destroyed
+	// before the next top-level evaluation.
+	AutoDestroy bool
+}
+
+// Method is a method of a foreign type with options, for use as a value
+// of [TypeDef] Methods or Statics in place of a plain function:
+//
+//	Methods: map[string]any{
+//	    "Sum":   feather.Method{Func: func(c *Calc, xs []int) int { ... }, Variadic: true, Name: "sum"},
+//	    "count": feather.Method{Field: "Count", ReadOnly: true},
+//	}
+type Method struct {
+	// Func is the function implementing the method, as in TypeDef Methods.
+	Func any
+
+	// Field, instead of Func, names an exported field of the struct that T
+	// points to. "$obj name" returns the field and "$obj name value" sets
+	// it and returns the new value.
+	Field string
+
+	// ReadOnly makes a Field method only return the field.
+	ReadOnly bool
+
+	// Variadic makes the last parameter of Func, which must be a slice,
+	// take all the remaining arguments, each converted to its element
+	// type. Functions declared with ... are always variadic.
+	Variadic bool
+
+	// Name is the TCL name of the method, if it differs from its key.
+	Name string
 }
 
 // RegisterType registers a foreign type with the interpreter.
@@ -873,12 +1592,36 @@ func RegisterType[T any](i *Interp, typeName string, def TypeDef[T]) error {
 	info := &foreignTypeInfo{
 		name:         typeName,
 		newFunc:      reflect.ValueOf(def.New),
-		methods:      make(map[string]reflect.Value),
+		methods:      make(map[string]*foreignMethod),
+		statics:      make(map[string]*foreignMethod),
 		receiverType: reflect.TypeOf((*T)(nil)).Elem(),
 	}
 
-	for name, fn := range def.Methods {
-		info.methods[name] = reflect.ValueOf(fn)
+	for key, m := range def.Methods {
+		name, method, err := newForeignMethod(key, m, info.receiverType)
+		if err != nil {
+			return fmt.Errorf("RegisterType: %s: %v", typeName, err)
+		}
+		info.methods[name] = method
+	}
+	for key, m := range def.Statics {
+		name, method, err := newForeignMethod(key, m, nil)
+		if err != nil {
+			return fmt.Errorf("RegisterType: %s: %v", typeName, err)
+		}
+		if name == "new" {
+			return fmt.Errorf("RegisterType: %s: static method cannot be named new", typeName)
+		}
+		info.statics[name] = method
+	}
+	for _, baseName := range def.Embed {
+		base, ok := i.ForeignRegistry.types[baseName]
+		if !ok {
+			return fmt.Errorf("RegisterType: %s: unknown foreign type %q", typeName, baseName)
+		}
+		if err := info.inherit(base); err != nil {
+			return fmt.Errorf("RegisterType: %s: %v", typeName, err)
+		}
 	}
 
 	if def.String != nil {
@@ -887,6 +1630,7 @@ func RegisterType[T any](i *Interp, typeName string, def TypeDef[T]) error {
 	if def.Destroy != nil {
 		info.destroy = reflect.ValueOf(def.Destroy)
 	}
+	info.autoDestroy = def.AutoDestroy
 
 	i.ForeignRegistry.types[typeName] = info
 	i.ForeignRegistry.counters[typeName] = 1
@@ -2,6 +2,7 @@ package feather_test
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/feather-lang/feather"
@@ -218,6 +219,41 @@ func TestForeignType(t *testing.T) {
 	}
 }
 
+func TestObjectHandleReuse(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	type Box struct{ value int }
+
+	err := feather.RegisterType[*Box](interp, "Box", feather.TypeDef[*Box]{
+		New: func() *Box { return &Box{} },
+		Methods: map[string]any{
+			"get": func(b *Box) int { return b.value },
+			"set": func(b *Box, v int) { b.value = v },
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterType failed: %v", err)
+	}
+
+	// Values built in one eval must outlive the scratch objects of later ones
+	interp.MustEval(`set items {}; for {set i 0} {$i < 1000} {incr i} { lappend items $i }`)
+	for n := range 100 {
+		interp.MustEval(fmt.Sprintf("set b%d [Box new]; $b%d set %d", n, n, n))
+		if n%2 == 0 {
+			interp.MustEval(fmt.Sprintf("$b%d destroy", n))
+		}
+	}
+	for n := 1; n < 100; n += 2 {
+		if got := interp.MustEval(fmt.Sprintf("$b%d get", n)).String(); got != fmt.Sprint(n) {
+			t.Errorf("$b%d get = %q, want %d", n, got, n)
+		}
+	}
+	if got := interp.MustEval(`list [llength $items] [lindex $items 500]`).String(); got != "1000 500" {
+		t.Errorf("got %q, want %q", got, "1000 500")
+	}
+}
+
 func TestCall(t *testing.T) {
 	interp := feather.New()
 	defer interp.Close()
@@ -0,0 +1,412 @@
+// Package featherdb adds a db command to a feather interpreter, giving
+// scripts access to databases through database/sql and whichever drivers
+// the program imports.
+//
+//	import _ "modernc.org/sqlite"
+//
+//	interp := feather.New()
+//	featherdb.Register(interp)
+//
+//	interp.Eval(`set db [db open sqlite app.db]`)
+//	interp.Eval(`$db exec {CREATE TABLE users (name TEXT, age INTEGER)}`)
+//	interp.Eval(`$db exec {INSERT INTO users VALUES (?, ?)} [list alice 30]`)
+//	interp.Eval(`$db query {SELECT * FROM users WHERE age > ?} [list 18]`)
+//	// {name alice age 30}
+//
+// db open returns a handle, which is also a command with these
+// subcommands:
+//
+//	$db query sql ?params?   - run a query, returning its rows as a list of dicts
+//	$db exec sql ?params?    - run a statement, returning the number of rows affected
+//	$db prepare sql          - prepare a statement, returning its handle
+//	$db transaction script   - run script in a transaction
+//	$db close                - close the database and its statements
+//
+// Prepared statements are commands too:
+//
+//	$stmt query ?params?     - run the statement as a query
+//	$stmt exec ?params?      - run the statement
+//	$stmt close              - close the statement
+//
+// params is a list of the values of the ? placeholders of sql, or a dict,
+// made by dict create, of the values of named placeholders such as :name.
+// Values are bound as int64 when they are ints, float64 when doubles, as
+// []byte when byte arrays, and as strings otherwise. In the rows of query,
+// columns that are NULL are left out of the dicts, integers and floats
+// become ints and doubles, and other values strings.
+//
+// transaction commits when script completes, also through break,
+// continue or return, and rolls back when it fails. While it runs, the
+// queries and statements of the handle run in the transaction.
+package featherdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/feather-lang/feather"
+)
+
+// Handles holds the databases open in an interpreter.
+type Handles struct {
+	interp   *feather.Interp
+	handles  map[string]*handle
+	nextDB   int
+	nextStmt int
+}
+
+// handle is a database open in an interpreter.
+type handle struct {
+	name  string
+	db    *sql.DB
+	tx    *sql.Tx // the transaction in progress, or nil
+	stmts map[string]*sql.Stmt
+}
+
+// Register installs the db command in interp and returns its handles.
+// Databases still open when the interpreter is closed are closed with it.
+//
+// The command has these subcommands:
+//
+//	db open driver dsn   - open a database with sql.Open, returning its handle
+//	db names             - list the handles of the open databases
+func Register(interp *feather.Interp) *Handles {
+	h := &Handles{interp: interp, handles: make(map[string]*handle)}
+	interp.RegisterCommand("db", h.cmdDB)
+	interp.OnClose(h.closeAll)
+	interp.Eval("namespace eval ::featherdb {}")
+	return h
+}
+
+// Add makes db available to scripts and returns the name of its handle, for
+// databases the program opens itself. Closing the handle closes db.
+func (h *Handles) Add(db *sql.DB) string {
+	h.nextDB++
+	hd := &handle{name: fmt.Sprintf("db%d", h.nextDB), db: db, stmts: make(map[string]*sql.Stmt)}
+	h.handles[hd.name] = hd
+	h.interp.RegisterCommand(hd.name, func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+		return h.cmdHandle(i, hd, args)
+	})
+	return hd.name
+}
+
+// close closes hd, its statements and any transaction in progress.
+func (h *Handles) close(hd *handle) error {
+	for name, stmt := range hd.stmts {
+		stmt.Close()
+		h.interp.UnregisterCommand(name)
+	}
+	if hd.tx != nil {
+		hd.tx.Rollback()
+	}
+	delete(h.handles, hd.name)
+	h.interp.UnregisterCommand(hd.name)
+	return hd.db.Close()
+}
+
+// closeAll closes every open database, for Interp.Close.
+func (h *Handles) closeAll() {
+	for _, hd := range h.handles {
+		hd.db.Close()
+	}
+	clear(h.handles)
+}
+
+// cmdDB implements: db subcommand ?arg ...?
+func (h *Handles) cmdDB(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if len(args) < 1 {
+		return feather.Error(`wrong # args: should be "db subcommand ?arg ...?"`)
+	}
+	sub, err := i.GetIndexFromObj(args[0], []string{"names", "open"}, "subcommand")
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	if sub == 0 {
+		if len(args) != 1 {
+			return feather.Error(`wrong # args: should be "db names"`)
+		}
+		return feather.OK(slices.Sorted(maps.Keys(h.handles)))
+	}
+	if len(args) != 3 {
+		return feather.Error(`wrong # args: should be "db open driver dsn"`)
+	}
+	db, err := sql.Open(args[1].String(), args[2].String())
+	if err != nil {
+		return feather.Errorf("db open: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return feather.Errorf("db open: %v", err)
+	}
+	return feather.OK(h.Add(db))
+}
+
+// cmdHandle implements the command of the database handle hd.
+func (h *Handles) cmdHandle(i *feather.Interp, hd *handle, args []*feather.Obj) feather.Result {
+	if len(args) < 1 {
+		return feather.Errorf(`wrong # args: should be "%s subcommand ?arg ...?"`, hd.name)
+	}
+	subs := []string{"close", "exec", "prepare", "query", "transaction"}
+	sub, err := i.GetIndexFromObj(args[0], subs, "subcommand")
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	switch subs[sub] {
+	case "close":
+		if len(args) != 1 {
+			return feather.Errorf(`wrong # args: should be "%s close"`, hd.name)
+		}
+		if err := h.close(hd); err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK("")
+
+	case "exec", "query":
+		if len(args) < 2 || len(args) > 3 {
+			return feather.Errorf(`wrong # args: should be "%s %s sql ?params?"`, hd.name, subs[sub])
+		}
+		params, err := bindParams(args[2:])
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		q := hd.querier()
+		if subs[sub] == "exec" {
+			return execResult(q.ExecContext(context.Background(), args[1].String(), params...))
+		}
+		rows, err := q.QueryContext(context.Background(), args[1].String(), params...)
+		return queryResult(i, rows, err)
+
+	case "prepare":
+		if len(args) != 2 {
+			return feather.Errorf(`wrong # args: should be "%s prepare sql"`, hd.name)
+		}
+		stmt, err := hd.db.Prepare(args[1].String())
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		h.nextStmt++
+		name := fmt.Sprintf("stmt%d", h.nextStmt)
+		hd.stmts[name] = stmt
+		i.RegisterCommand(name, func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return cmdStmt(i, hd, name, args)
+		})
+		return feather.OK(name)
+	}
+
+	if len(args) != 2 {
+		return feather.Errorf(`wrong # args: should be "%s transaction script"`, hd.name)
+	}
+	return transaction(i, hd, args[1])
+}
+
+// querier is what queries run on: the database, or its transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// querier returns what the queries of hd run on.
+func (hd *handle) querier() querier {
+	if hd.tx != nil {
+		return hd.tx
+	}
+	return hd.db
+}
+
+// transaction runs script in a transaction of hd, committing unless the
+// script fails, and completes as script did.
+func transaction(i *feather.Interp, hd *handle, script *feather.Obj) feather.Result {
+	if hd.tx != nil {
+		return feather.Errorf("%s transaction: a transaction is already in progress", hd.name)
+	}
+	tx, err := hd.db.Begin()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	hd.tx = tx
+	code, err := i.Call("catch", script, "::featherdb::result", "::featherdb::options")
+	hd.tx = nil
+	if err != nil {
+		tx.Rollback()
+		return feather.Error(err.Error())
+	}
+	result, _ := i.Call("set", "::featherdb::result")
+	options, _ := i.Call("set", "::featherdb::options")
+	i.Call("unset", "::featherdb::result", "::featherdb::options")
+
+	n, _ := code.Int()
+	if feather.FeatherResult(n) == feather.ResultError {
+		if err := tx.Rollback(); err != nil {
+			return feather.Errorf("%s (rollback failed: %v)", result.String(), err)
+		}
+		return feather.Error(result)
+	}
+	if err := tx.Commit(); err != nil {
+		return feather.Error(err.Error())
+	}
+	// A return in script shows as a -level above 0, as catch unwraps it
+	if opts, err := options.Dict(); err == nil && opts.Items["-level"] != nil {
+		level, _ := opts.Items["-level"].Int()
+		if level > 0 {
+			retCode, _ := opts.Items["-code"].Int()
+			return feather.Return(result, feather.FeatherResult(retCode), int(level))
+		}
+	}
+	switch feather.FeatherResult(n) {
+	case feather.ResultOK:
+		return feather.OK(result)
+	case feather.ResultBreak:
+		return feather.Break()
+	case feather.ResultContinue:
+		return feather.Continue()
+	}
+	return feather.Return(result, feather.FeatherResult(n), 0)
+}
+
+// cmdStmt implements the command of the statement name of hd.
+func cmdStmt(i *feather.Interp, hd *handle, name string, args []*feather.Obj) feather.Result {
+	if len(args) < 1 {
+		return feather.Errorf(`wrong # args: should be "%s subcommand ?arg ...?"`, name)
+	}
+	subs := []string{"close", "exec", "query"}
+	sub, err := i.GetIndexFromObj(args[0], subs, "subcommand")
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	stmt := hd.stmts[name]
+	if subs[sub] == "close" {
+		if len(args) != 1 {
+			return feather.Errorf(`wrong # args: should be "%s close"`, name)
+		}
+		delete(hd.stmts, name)
+		i.UnregisterCommand(name)
+		if err := stmt.Close(); err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK("")
+	}
+
+	if len(args) > 2 {
+		return feather.Errorf(`wrong # args: should be "%s %s ?params?"`, name, subs[sub])
+	}
+	params, err := bindParams(args[1:])
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	if hd.tx != nil {
+		stmt = hd.tx.Stmt(stmt)
+	}
+	if subs[sub] == "exec" {
+		return execResult(stmt.Exec(params...))
+	}
+	rows, err := stmt.Query(params...)
+	return queryResult(i, rows, err)
+}
+
+// bindParams returns the arguments of a query for the optional params
+// argument of a subcommand.
+func bindParams(args []*feather.Obj) ([]any, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if d, ok := args[0].InternalRep().(*feather.DictType); ok {
+		params := make([]any, 0, len(d.Order))
+		for _, key := range d.Order {
+			name := strings.TrimLeft(key, ":@$")
+			params = append(params, sql.Named(name, bindValue(d.Items[key])))
+		}
+		return params, nil
+	}
+	items, err := args[0].List()
+	if err != nil {
+		return nil, err
+	}
+	params := make([]any, len(items))
+	for n, item := range items {
+		params[n] = bindValue(item)
+	}
+	return params, nil
+}
+
+// bindValue returns the value v is bound as.
+func bindValue(v *feather.Obj) any {
+	switch rep := v.InternalRep().(type) {
+	case feather.IntType:
+		return int64(rep)
+	case feather.DoubleType:
+		return float64(rep)
+	case feather.ByteArrayType:
+		return []byte(rep)
+	}
+	return v.String()
+}
+
+// execResult returns the number of rows affected by a statement.
+func execResult(res sql.Result, err error) feather.Result {
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return feather.OK(0)
+	}
+	return feather.OK(n)
+}
+
+// queryResult returns the rows of a query as a list of dicts.
+func queryResult(i *feather.Interp, rows *sql.Rows, err error) feather.Result {
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	values := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for n := range values {
+		dest[n] = &values[n]
+	}
+	result := i.NewListBuilder()
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return feather.Error(err.Error())
+		}
+		row := i.NewDictBuilder()
+		for n, col := range cols {
+			if values[n] != nil {
+				row.Set(col, columnValue(values[n]))
+			}
+		}
+		result.Append(row.Obj())
+	}
+	if err := rows.Err(); err != nil {
+		return feather.Error(err.Error())
+	}
+	return feather.OK(result.Obj())
+}
+
+// columnValue converts the value of a column, as returned by a driver, to
+// a value for Interp.Value.
+func columnValue(v any) any {
+	switch v := v.(type) {
+	case int64, float64, string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
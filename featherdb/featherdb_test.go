@@ -0,0 +1,376 @@
+package featherdb_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/feather-lang/feather"
+	"github.com/feather-lang/feather/featherdb"
+)
+
+// memDriver is a database/sql driver for an in-memory database that
+// understands just enough SQL for the tests:
+//
+//	CREATE TABLE t (a, b)
+//	INSERT INTO t VALUES (?, :name, NULL, 42, 'text')
+//	SELECT * FROM t ?WHERE col = value?
+//	DELETE FROM t
+//
+// Databases are named by their DSN and shared by its connections.
+type memDriver struct {
+	mu  sync.Mutex
+	dbs map[string]map[string]*memTable
+}
+
+type memTable struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func init() {
+	sql.Register("memdb", &memDriver{dbs: make(map[string]map[string]*memTable)})
+}
+
+func (d *memDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dbs[dsn] == nil {
+		d.dbs[dsn] = make(map[string]*memTable)
+	}
+	return &memConn{d: d, dsn: dsn}, nil
+}
+
+type memConn struct {
+	d        *memDriver
+	dsn      string
+	snapshot map[string]*memTable // the tables when the transaction began
+}
+
+func (c *memConn) Prepare(query string) (driver.Stmt, error) {
+	return &memStmt{c: c, words: strings.Fields(strings.NewReplacer("(", " ", ")", " ", ",", " ").Replace(query))}, nil
+}
+
+func (c *memConn) Close() error { return nil }
+
+func (c *memConn) Begin() (driver.Tx, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.snapshot = make(map[string]*memTable)
+	for name, t := range c.d.dbs[c.dsn] {
+		c.snapshot[name] = &memTable{cols: t.cols, rows: slices.Clone(t.rows)}
+	}
+	return c, nil
+}
+
+func (c *memConn) Commit() error {
+	c.snapshot = nil
+	return nil
+}
+
+func (c *memConn) Rollback() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.dbs[c.dsn] = c.snapshot
+	c.snapshot = nil
+	return nil
+}
+
+type memStmt struct {
+	c     *memConn
+	words []string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("use ExecContext")
+}
+
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("use QueryContext")
+}
+
+// value returns the value of the word w of a statement run with args,
+// taking the next positional argument from args for a ? placeholder.
+func value(w string, args []driver.NamedValue, next *int) (driver.Value, error) {
+	switch {
+	case w == "?":
+		if *next >= len(args) {
+			return nil, errors.New("too few arguments")
+		}
+		*next++
+		return args[*next-1].Value, nil
+	case strings.HasPrefix(w, ":"):
+		for _, arg := range args {
+			if arg.Name == w[1:] {
+				return arg.Value, nil
+			}
+		}
+		return nil, fmt.Errorf("no argument %s", w)
+	case w == "NULL":
+		return nil, nil
+	case strings.HasPrefix(w, "'"):
+		return strings.Trim(w, "'"), nil
+	}
+	return strconv.ParseInt(w, 10, 64)
+}
+
+func (s *memStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	db := s.c.d.dbs[s.c.dsn]
+	w := s.words
+	switch {
+	case len(w) >= 3 && w[0] == "CREATE":
+		db[w[2]] = &memTable{cols: w[3:]}
+		return driver.RowsAffected(0), nil
+	case len(w) >= 4 && w[0] == "INSERT":
+		t, ok := db[w[2]]
+		if !ok {
+			return nil, fmt.Errorf("no such table: %s", w[2])
+		}
+		var row []driver.Value
+		next := 0
+		for _, word := range w[4:] {
+			v, err := value(word, args, &next)
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, v)
+		}
+		t.rows = append(t.rows, row)
+		return driver.RowsAffected(1), nil
+	case len(w) == 3 && w[0] == "DELETE":
+		t, ok := db[w[2]]
+		if !ok {
+			return nil, fmt.Errorf("no such table: %s", w[2])
+		}
+		n := len(t.rows)
+		t.rows = nil
+		return driver.RowsAffected(n), nil
+	}
+	return nil, fmt.Errorf("syntax error: %s", strings.Join(w, " "))
+}
+
+func (s *memStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	w := s.words
+	if len(w) < 4 || w[0] != "SELECT" {
+		return nil, fmt.Errorf("syntax error: %s", strings.Join(w, " "))
+	}
+	t, ok := s.c.d.dbs[s.c.dsn][w[3]]
+	if !ok {
+		return nil, fmt.Errorf("no such table: %s", w[3])
+	}
+	rows := &memRows{cols: t.cols}
+	for _, row := range t.rows {
+		if len(w) == 8 && w[4] == "WHERE" {
+			next := 0
+			want, err := value(w[7], args, &next)
+			if err != nil {
+				return nil, err
+			}
+			col := slices.Index(t.cols, w[5])
+			if col < 0 || fmt.Sprint(row[col]) != fmt.Sprint(want) {
+				continue
+			}
+		}
+		rows.rows = append(rows.rows, row)
+	}
+	return rows, nil
+}
+
+type memRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *memRows) Columns() []string { return r.cols }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newInterp(t *testing.T) *feather.Interp {
+	t.Helper()
+	interp := feather.New()
+	t.Cleanup(interp.Close)
+	featherdb.Register(interp)
+	interp.SetVar("dsn", t.Name())
+	interp.MustEval(`
+		set db [db open memdb $dsn]
+		$db exec {CREATE TABLE users (name, age)}
+	`)
+	return interp
+}
+
+func TestQuery(t *testing.T) {
+	interp := newInterp(t)
+	interp.MustEval(`
+		$db exec {INSERT INTO users VALUES (?, ?)} [list alice [expr 30]]
+		$db exec {INSERT INTO users VALUES (:name, NULL)} [dict create name bob]
+		$db exec {INSERT INTO users VALUES ('carol', ?)} [list [expr 2.5]]
+	`)
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"rows as dicts", `$db query {SELECT * FROM users}`, "{name alice age 30} {name bob} {name carol age 2.5}"},
+		{"params", `$db query {SELECT * FROM users WHERE name = ?} [list bob]`, "{name bob}"},
+		{"named params", `$db query {SELECT * FROM users WHERE name = :who} [dict create who alice]`, "{name alice age 30}"},
+		{"int column", `dict get [lindex [$db query {SELECT * FROM users}] 0] age`, "30"},
+		{"exec counts rows", `$db exec {DELETE FROM users}`, "3"},
+		{"names", `db names`, "db1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := interp.Eval(`$db query {SELECT * FROM nosuch}`); err == nil || err.Error() != "no such table: nosuch" {
+			t.Errorf("query of a missing table = %v; want no such table", err)
+		}
+		if _, err := interp.Eval(`db open nosuchdriver x`); err == nil {
+			t.Error("expected error opening an unknown driver")
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		interp.MustEval(`$db close`)
+		if _, err := interp.Eval(`$db query {SELECT * FROM users}`); err == nil {
+			t.Error("expected error using a closed handle")
+		}
+		if got := interp.MustEval(`db names`).String(); got != "" {
+			t.Errorf("db names = %q; want none", got)
+		}
+	})
+}
+
+func TestPrepare(t *testing.T) {
+	interp := newInterp(t)
+	result, err := interp.Eval(`
+		set insert [$db prepare {INSERT INTO users VALUES (?, ?)}]
+		foreach {name age} {alice 30 bob 25} {
+			$insert exec [list $name $age]
+		}
+		$insert close
+		set select [$db prepare {SELECT * FROM users WHERE name = ?}]
+		$select query [list bob]
+	`)
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	if result.String() != "{name bob age 25}" {
+		t.Errorf("got %q; want bob's row", result.String())
+	}
+	if _, err := interp.Eval(`$insert exec {carol 20}`); err == nil {
+		t.Error("expected error using a closed statement")
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	interp := newInterp(t)
+
+	t.Run("commit", func(t *testing.T) {
+		result := interp.MustEval(`
+			$db transaction {
+				$db exec {INSERT INTO users VALUES ('alice', 30)}
+				$db exec {INSERT INTO users VALUES ('bob', 25)}
+			}
+			llength [$db query {SELECT * FROM users}]
+		`)
+		if result.String() != "2" {
+			t.Errorf("got %s rows; want 2", result.String())
+		}
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		_, err := interp.Eval(`
+			$db transaction {
+				$db exec {DELETE FROM users}
+				error "changed my mind"
+			}
+		`)
+		if err == nil || err.Error() != "changed my mind" {
+			t.Errorf("transaction = %v; want the error of the script", err)
+		}
+		if got := interp.MustEval(`llength [$db query {SELECT * FROM users}]`).String(); got != "2" {
+			t.Errorf("got %s rows after rollback; want 2", got)
+		}
+	})
+
+	t.Run("return and break commit", func(t *testing.T) {
+		result := interp.MustEval(`
+			proc add {db name} {
+				$db transaction {
+					$db exec {INSERT INTO users VALUES (?, 1)} [list $name]
+					return added
+				}
+				return "not reached"
+			}
+			foreach name {carol dave} {
+				$db transaction {
+					$db exec {INSERT INTO users VALUES (?, 1)} [list $name]
+					break
+				}
+			}
+			list [add $db erin] [llength [$db query {SELECT * FROM users}]]
+		`)
+		if result.String() != "added 4" {
+			t.Errorf("got %q; want 'added 4'", result.String())
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		_, err := interp.Eval(`$db transaction { $db transaction {} }`)
+		if err == nil {
+			t.Error("expected error nesting transactions")
+		}
+	})
+}
+
+func TestAdd(t *testing.T) {
+	interp := feather.New()
+	t.Cleanup(interp.Close)
+	handles := featherdb.Register(interp)
+
+	db, err := sql.Open("memdb", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := handles.Add(db)
+	if name != "db1" {
+		t.Errorf("Add = %q; want db1", name)
+	}
+	interp.SetVar("db", name)
+	interp.MustEval(`$db exec {CREATE TABLE t (a)}`)
+	interp.MustEval(`$db exec {INSERT INTO t VALUES (1)}`)
+	if got := interp.MustEval(`$db query {SELECT * FROM t}`).String(); got != "{a 1}" {
+		t.Errorf("query = %q; want {a 1}", got)
+	}
+}
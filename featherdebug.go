@@ -0,0 +1,8 @@
+//go:build featherdebug
+
+package feather
+
+// featherDebug makes misuse of interpreters and their values panic: using
+// an interpreter or *Obj after Close, and passing an *Obj to an interpreter
+// other than the one that created it.
+const featherDebug = true
@@ -0,0 +1,6 @@
+//go:build !featherdebug
+
+package feather
+
+// featherDebug is set in builds with the featherdebug tag.
+const featherDebug = false
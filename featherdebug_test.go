@@ -0,0 +1,41 @@
+//go:build featherdebug
+
+package feather_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/feather-lang/feather"
+)
+
+func init() { debugBuild = true }
+
+func TestDebugMisuse(t *testing.T) {
+	expectPanic := func(t *testing.T, want string, fn func()) {
+		t.Helper()
+		defer func() {
+			t.Helper()
+			if r, _ := recover().(string); !strings.Contains(r, want) {
+				t.Errorf("panic = %q; want one mentioning %q", r, want)
+			}
+		}()
+		fn()
+	}
+
+	t.Run("AfterClose", func(t *testing.T) {
+		interp := feather.New()
+		s := interp.String("hello")
+		interp.Close()
+		expectPanic(t, "after Close", func() { _ = s.String() })
+		expectPanic(t, "after Close", func() { interp.Eval("set x 1") })
+	})
+
+	t.Run("OtherInterp", func(t *testing.T) {
+		a, b := feather.New(), feather.New()
+		defer a.Close()
+		defer b.Close()
+		expectPanic(t, "used in interpreter", func() { b.SetVarObj("x", a.String("a b")) })
+		expectPanic(t, "used in interpreter", func() { b.List(a.Int(1)) })
+	})
+}
@@ -0,0 +1,300 @@
+// Package featherjson adds a json command to a feather interpreter.
+//
+// JSON objects become dicts, arrays become lists, numbers become ints or
+// doubles, and strings stay strings. Following the tcllib json package,
+// true, false and null are represented by the strings "true", "false" and
+// "null".
+//
+//	interp := feather.New()
+//	featherjson.Register(interp)
+//
+//	interp.Eval(`set doc [json parse {{"users": [{"name": "alice"}]}}]`)
+//	interp.Eval(`json get $doc users.0.name`)  // alice
+//	interp.Eval(`json format {a 1 b {x y}}`)   // {"a":1,"b":"x y"}
+//
+// The command has three subcommands:
+//
+//	json parse text        - parse JSON text into nested dicts and lists
+//	json format value      - serialize a value as JSON
+//	json get value ?path?  - look up a dot-separated path such as a.b.2.c
+package featherjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/feather-lang/feather"
+)
+
+// Register installs the json command in interp.
+func Register(interp *feather.Interp) {
+	interp.RegisterCommand("json", cmdJSON)
+}
+
+func cmdJSON(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if len(args) < 1 {
+		return feather.Error(`wrong # args: should be "json subcommand ?arg ...?"`)
+	}
+	sub, args := args[0].String(), args[1:]
+	switch sub {
+	case "parse":
+		if len(args) != 1 {
+			return feather.Error(`wrong # args: should be "json parse text"`)
+		}
+		v, err := Parse(i, args[0].String())
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK(v)
+
+	case "format":
+		if len(args) != 1 {
+			return feather.Error(`wrong # args: should be "json format value"`)
+		}
+		s, err := Format(args[0])
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK(s)
+
+	case "get":
+		if len(args) < 1 || len(args) > 2 {
+			return feather.Error(`wrong # args: should be "json get value ?path?"`)
+		}
+		path := ""
+		if len(args) == 2 {
+			path = args[1].String()
+		}
+		v, err := Get(args[0], path)
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK(v)
+
+	default:
+		return feather.Errorf(`unknown or ambiguous subcommand "%s": must be format, get, or parse`, sub)
+	}
+}
+
+// Parse decodes JSON text into nested dict and list objects.
+// Object keys keep the order in which they appear in the text.
+func Parse(i *feather.Interp, text string) (*feather.Obj, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	v, err := decodeValue(i, dec)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func parseError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("invalid JSON: unexpected end of input")
+	}
+	return fmt.Errorf("invalid JSON: %v", err)
+}
+
+// decodeValue reads one complete JSON value from dec.
+func decodeValue(i *feather.Interp, dec *json.Decoder) (*feather.Obj, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			dict := &feather.DictType{Items: make(map[string]*feather.Obj)}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				val, err := decodeValue(i, dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, exists := dict.Items[key]; !exists {
+					dict.Order = append(dict.Order, key)
+				}
+				dict.Items[key] = val
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return i.Obj(dict), nil
+		case '[':
+			var items []*feather.Obj
+			for dec.More() {
+				val, err := decodeValue(i, dec)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, val)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return i.List(items...), nil
+		}
+		return nil, fmt.Errorf("unexpected %q", t)
+	case string:
+		return i.String(t), nil
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return i.Int(n), nil
+		}
+		if f, err := t.Float64(); err == nil {
+			return i.Double(f), nil
+		}
+		return i.String(t.String()), nil
+	case bool:
+		if t {
+			return i.String("true"), nil
+		}
+		return i.String("false"), nil
+	case nil:
+		return i.String("null"), nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", tok)
+}
+
+// jsonNumber matches the JSON number grammar.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// Format serializes v as compact JSON.
+//
+// The JSON type is chosen from the value's internal representation: dicts
+// become objects, lists become arrays, and ints and doubles become numbers.
+// Plain strings become numbers when they are valid JSON numbers, the literals
+// true, false and null when they spell them, and JSON strings otherwise.
+func Format(v *feather.Obj) (string, error) {
+	var buf bytes.Buffer
+	if err := format(&buf, v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func format(buf *bytes.Buffer, v *feather.Obj) error {
+	switch rep := v.InternalRep().(type) {
+	case *feather.DictType:
+		buf.WriteByte('{')
+		for j, key := range rep.Order {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, key)
+			buf.WriteByte(':')
+			if err := format(buf, rep.Items[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case feather.ListType:
+		buf.WriteByte('[')
+		for j, item := range rep {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			if err := format(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case feather.IntType:
+		buf.WriteString(strconv.FormatInt(int64(rep), 10))
+		return nil
+	case feather.DoubleType:
+		f := float64(rep)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("cannot format %s as JSON", v.String())
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		return nil
+	}
+
+	s := v.String()
+	switch {
+	case s == "true" || s == "false" || s == "null":
+		buf.WriteString(s)
+	case jsonNumber.MatchString(s):
+		buf.WriteString(s)
+	default:
+		writeString(buf, s)
+	}
+	return nil
+}
+
+// writeString writes s as a JSON string literal.
+func writeString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode appends a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+// Get returns the value at a dot-separated path inside v, such as
+// "users.0.name". Numeric segments index into lists; other segments are
+// dict keys. An empty path returns v itself.
+func Get(v *feather.Obj, path string) (*feather.Obj, error) {
+	if path == "" {
+		return v, nil
+	}
+	cur := v
+	for _, seg := range strings.Split(path, ".") {
+		next, err := step(cur, seg)
+		if err != nil {
+			return nil, fmt.Errorf("%v in path %q", err, path)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// step descends one path segment into v.
+func step(v *feather.Obj, seg string) (*feather.Obj, error) {
+	if _, isList := v.InternalRep().(feather.ListType); isList {
+		return index(v, seg)
+	}
+	d, err := v.Dict()
+	if err != nil {
+		return index(v, seg)
+	}
+	item, ok := d.Items[seg]
+	if !ok {
+		return nil, fmt.Errorf("key %q not known", seg)
+	}
+	return item, nil
+}
+
+// index returns element seg of list v.
+func index(v *feather.Obj, seg string) (*feather.Obj, error) {
+	n, err := strconv.Atoi(seg)
+	if err != nil {
+		return nil, fmt.Errorf("expected list index but got %q", seg)
+	}
+	items, err := v.List()
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n >= len(items) {
+		return nil, fmt.Errorf("index %d out of range", n)
+	}
+	return items[n], nil
+}
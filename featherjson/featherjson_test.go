@@ -0,0 +1,135 @@
+package featherjson_test
+
+import (
+	"testing"
+
+	"github.com/feather-lang/feather"
+	"github.com/feather-lang/feather/featherjson"
+)
+
+func newInterp(t *testing.T) *feather.Interp {
+	t.Helper()
+	interp := feather.New()
+	t.Cleanup(interp.Close)
+	featherjson.Register(interp)
+	return interp
+}
+
+func TestParse(t *testing.T) {
+	interp := newInterp(t)
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"object keeps key order", `json parse {{"b": 1, "a": 2}}`, "b 1 a 2"},
+		{"nested", `json parse {{"user": {"name": "alice", "tags": ["x", "y z"]}}}`, "user {name alice tags {x {y z}}}"},
+		{"literals", `json parse {[true, false, null]}`, "true false null"},
+		{"numbers", `json parse {[1, -2.5, 1e3]}`, "1 -2.5 1000.0"},
+		{"escapes", `json parse {"a\"bé"}`, `a"bé`},
+		{"empty object", `json parse {{}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("result is a dict", func(t *testing.T) {
+		result, err := interp.Eval(`dict get [json parse {{"a": {"b": 3}}}] a b`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "3" {
+			t.Errorf("got %q; want '3'", result.String())
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, script := range []string{
+			`json parse {{"a": }}`,
+			`json parse {[1, 2}`,
+			`json parse {1 2}`,
+			`json parse {}`,
+		} {
+			if _, err := interp.Eval(script); err == nil {
+				t.Errorf("%s: expected error", script)
+			}
+		}
+	})
+}
+
+func TestFormat(t *testing.T) {
+	interp := newInterp(t)
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"dict", `json format [dict create a 1 b hello]`, `{"a":1,"b":"hello"}`},
+		{"list", `json format [list 1 two 3.5]`, `[1,"two",3.5]`},
+		{"nested", `json format [dict create xs [list 1 2] d [dict create k v]]`, `{"xs":[1,2],"d":{"k":"v"}}`},
+		{"literals", `json format [list true false null]`, `[true,false,null]`},
+		{"string escapes", `json format "a\"b<c>"`, `"a\"b<c>"`},
+		{"not a number", `json format 007`, `"007"`},
+		{"round trip", `json format [json parse {{"a":[1,{"b":null}],"c":"d e"}}]`, `{"a":[1,{"b":null}],"c":"d e"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	interp := newInterp(t)
+	if _, err := interp.Eval(`set doc [json parse {{"a": {"b": [10, 20, {"c": "deep"}]}}}]`); err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{"a.b.2.c", "deep"},
+		{"a.b.1", "20"},
+		{"a.b", "10 20 {c deep}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := interp.Eval(`json get $doc ` + tt.path)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			script, want string
+		}{
+			{`json get $doc a.x`, `key "x" not known in path "a.x"`},
+			{`json get $doc a.b.5`, `index 5 out of range in path "a.b.5"`},
+			{`json get $doc a.b.x`, `expected list index but got "x" in path "a.b.x"`},
+			{`json bogus`, `unknown or ambiguous subcommand "bogus": must be format, get, or parse`},
+		}
+		for _, tt := range tests {
+			_, err := interp.Eval(tt.script)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("%s: err = %v; want %q", tt.script, err, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,496 @@
+// Package feathertemplate adds a templates command to a feather
+// interpreter, rendering Go templates with data from scripts.
+//
+//	interp := feather.New()
+//	feathertemplate.Register(interp)
+//
+//	interp.Eval(`templates load ./reports`)
+//	interp.Eval(`templates render summary.txt [dict create title Sales rows [list a b c]]`)
+//
+// Templates are loaded from the files of a directory, named by their path
+// relative to it. Files ending in .html or .htm are parsed with
+// html/template, which escapes what it inserts, and other files with
+// text/template; -mode picks one package for all of them. Files are parsed
+// again when they change, and the change is reported to the callbacks
+// registered with templates onchange.
+//
+// Dicts become maps in the data of a template, lists become slices, and
+// numbers numbers, as [Data] describes. Procs can be called from templates
+// once they are registered with templates func:
+//
+//	proc money {n} { format %.2f $n }
+//	templates func money money
+//	# {{money .total}} in a template
+//
+// The command has these subcommands:
+//
+//	templates load ?-mode auto|html|text? dir - load the templates of dir
+//	templates render name data                 - render a template with data
+//	templates list                             - list the templates, sorted
+//	templates show name                        - return the source of a template
+//	templates errors                           - dict of templates that failed to parse
+//	templates func name command                - call command from templates as name
+//	templates onchange command                 - call command with the name and event of each change
+//	templates refresh                          - look for changes now
+//	templates watch ms                         - look for changes every ms milliseconds, 0 to stop
+package feathertemplate
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/feather-lang/feather"
+)
+
+// Mode selects the template package used to parse templates.
+type Mode int
+
+const (
+	Auto Mode = iota // html/template for .html and .htm files, text/template for the rest
+	HTML             // html/template for every file
+	Text             // text/template for every file
+)
+
+// Change events reported to the callbacks of [Set.OnChange].
+const (
+	Added   = "added"
+	Changed = "changed"
+	Removed = "removed"
+)
+
+// Template is a template of a [Set].
+type Template struct {
+	Name    string    // the path of its file, relative to the directory
+	ModTime time.Time // the modification time of the file when it was parsed
+	Err     error     // the error parsing it, if any
+
+	exec interface {
+		Execute(w io.Writer, data any) error
+	}
+}
+
+// Set holds the templates loaded from a directory. Its methods are safe to
+// call from several goroutines, but rendering a template that calls procs
+// uses the interpreter, and must happen on the goroutine that owns it.
+type Set struct {
+	interp *feather.Interp
+
+	mu        sync.Mutex
+	dir       string
+	mode      Mode
+	templates map[string]*Template
+	funcs     map[string]*feather.Obj // command prefixes, by template function
+	onChange  []func(name, event string)
+	watch     *time.Timer
+}
+
+// New returns an empty set of templates for interp.
+func New(interp *feather.Interp) *Set {
+	s := &Set{
+		interp:    interp,
+		templates: make(map[string]*Template),
+		funcs:     make(map[string]*feather.Obj),
+	}
+	interp.OnClose(func() { s.Watch(0) })
+	return s
+}
+
+// Register installs the templates command in interp, backed by a new set,
+// and returns the set.
+func Register(interp *feather.Interp) *Set {
+	s := New(interp)
+	interp.RegisterCommand("templates", s.cmdTemplates)
+	return s
+}
+
+// Load loads the templates of dir, replacing those loaded before.
+func (s *Set) Load(dir string, mode Mode) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	s.mu.Lock()
+	s.dir, s.mode = dir, mode
+	clear(s.templates)
+	s.mu.Unlock()
+	s.Refresh()
+	return nil
+}
+
+// Refresh parses the templates whose files were added or changed since
+// they were last parsed, forgets those whose files were removed, and calls
+// the OnChange callbacks for each.
+func (s *Set) Refresh() {
+	type change struct{ name, event string }
+	var changes []change
+
+	s.mu.Lock()
+	seen := make(map[string]bool)
+	if s.dir != "" {
+		filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			name, err := filepath.Rel(s.dir, path)
+			if err != nil {
+				return nil
+			}
+			name = filepath.ToSlash(name)
+			seen[name] = true
+
+			existing, ok := s.templates[name]
+			if ok && existing.ModTime.Equal(info.ModTime()) {
+				return nil
+			}
+			s.templates[name] = s.parse(name, path, info.ModTime())
+			if ok {
+				changes = append(changes, change{name, Changed})
+			} else {
+				changes = append(changes, change{name, Added})
+			}
+			return nil
+		})
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.templates)) {
+		if !seen[name] {
+			delete(s.templates, name)
+			changes = append(changes, change{name, Removed})
+		}
+	}
+	callbacks := slices.Clone(s.onChange)
+	s.mu.Unlock()
+
+	for _, c := range changes {
+		for _, fn := range callbacks {
+			fn(c.name, c.event)
+		}
+	}
+}
+
+// parse parses the file path as the template name.
+func (s *Set) parse(name, path string, modTime time.Time) *Template {
+	t := &Template{Name: name, ModTime: modTime}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Err = err
+		return t
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if s.mode == HTML || (s.mode == Auto && (ext == ".html" || ext == ".htm")) {
+		t.exec, t.Err = htmltemplate.New(name).Funcs(s.funcMap()).Parse(string(src))
+	} else {
+		t.exec, t.Err = texttemplate.New(name).Funcs(s.funcMap()).Parse(string(src))
+	}
+	if t.Err != nil {
+		t.exec = nil
+	}
+	return t
+}
+
+// funcMap returns the template functions, which call their commands.
+func (s *Set) funcMap() map[string]any {
+	m := make(map[string]any, len(s.funcs))
+	for name, cmd := range s.funcs {
+		m[name] = func(args ...any) (string, error) {
+			words, err := cmd.List()
+			if err != nil {
+				return "", err
+			}
+			callArgs := make([]any, 0, len(words)+len(args)-1)
+			for _, w := range words[1:] {
+				callArgs = append(callArgs, w)
+			}
+			callArgs = append(callArgs, args...)
+			result, err := s.interp.Call(words[0].String(), callArgs...)
+			if err != nil {
+				return "", err
+			}
+			return result.String(), nil
+		}
+	}
+	return m
+}
+
+// Func makes the command prefix cmd callable from templates as name, with
+// the arguments of the call appended. The templates are parsed again, as
+// functions must be known when a template is parsed.
+func (s *Set) Func(name string, cmd *feather.Obj) error {
+	if words, err := cmd.List(); err != nil || len(words) == 0 {
+		return fmt.Errorf("bad command prefix \"%s\"", cmd.String())
+	}
+	s.mu.Lock()
+	s.funcs[name] = cmd
+	for name, t := range s.templates {
+		s.templates[name] = s.parse(name, filepath.Join(s.dir, filepath.FromSlash(name)), t.ModTime)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// OnChange registers fn to be called with the name of each template
+// added, changed or removed, and the event: [Added], [Changed] or
+// [Removed].
+func (s *Set) OnChange(fn func(name, event string)) {
+	s.mu.Lock()
+	s.onChange = append(s.onChange, fn)
+	s.mu.Unlock()
+}
+
+// Watch looks for changes every interval, through the event loop of the
+// interpreter, so that OnChange callbacks run while it is serviced. An
+// interval of 0 stops watching.
+func (s *Set) Watch(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watch != nil {
+		s.watch.Stop()
+		s.watch = nil
+	}
+	if interval <= 0 {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(interval, func() {
+		s.interp.Post(func() {
+			s.mu.Lock()
+			current := s.watch == timer
+			s.mu.Unlock()
+			if !current {
+				return
+			}
+			s.Refresh()
+			s.mu.Lock()
+			if s.watch == timer {
+				timer.Reset(interval)
+			}
+			s.mu.Unlock()
+		})
+	})
+	s.watch = timer
+}
+
+// Lookup returns the template name, refreshing the set first.
+func (s *Set) Lookup(name string) (*Template, bool) {
+	s.Refresh()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.templates[name]
+	return t, ok
+}
+
+// Names returns the names of the templates, sorted, refreshing the set
+// first.
+func (s *Set) Names() []string {
+	s.Refresh()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Sorted(maps.Keys(s.templates))
+}
+
+// Errors returns the errors of the templates that failed to parse, by
+// name, refreshing the set first.
+func (s *Set) Errors() map[string]error {
+	s.Refresh()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := make(map[string]error)
+	for name, t := range s.templates {
+		if t.Err != nil {
+			errs[name] = t.Err
+		}
+	}
+	return errs
+}
+
+// Render renders the template name with data, converted as described in
+// the package documentation.
+func (s *Set) Render(name string, data *feather.Obj) (string, error) {
+	t, ok := s.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("template \"%s\" not found", name)
+	}
+	if t.Err != nil {
+		return "", fmt.Errorf("template \"%s\" has a parse error: %v", name, t.Err)
+	}
+	var buf strings.Builder
+	if err := t.exec.Execute(&buf, Data(data)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Source returns the source of the template name.
+func (s *Set) Source(name string) (string, error) {
+	if _, ok := s.Lookup(name); !ok {
+		return "", fmt.Errorf("template \"%s\" not found", name)
+	}
+	s.mu.Lock()
+	dir := s.dir
+	s.mu.Unlock()
+	src, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+// Data converts v to the data of a template: dicts become maps, lists
+// become slices, ints and strings spelling integers, such as 42 but not
+// 042, become int64, doubles float64, and other values strings. A value
+// becomes a map or slice only if it already is a dict or list, as made by
+// dict create or list; a string that could be parsed as a list stays a
+// string.
+func Data(v *feather.Obj) any {
+	switch rep := v.InternalRep().(type) {
+	case *feather.DictType:
+		m := make(map[string]any, len(rep.Order))
+		for _, key := range rep.Order {
+			m[key] = Data(rep.Items[key])
+		}
+		return m
+	case feather.ListType:
+		items := make([]any, len(rep))
+		for n, item := range rep {
+			items[n] = Data(item)
+		}
+		return items
+	case feather.IntType:
+		return int64(rep)
+	case feather.DoubleType:
+		return float64(rep)
+	}
+	str := v.String()
+	if n, err := strconv.ParseInt(str, 10, 64); err == nil && strconv.FormatInt(n, 10) == str {
+		return n
+	}
+	return str
+}
+
+// cmdTemplates implements: templates subcommand ?arg ...?
+func (s *Set) cmdTemplates(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if len(args) < 1 {
+		return feather.Error(`wrong # args: should be "templates subcommand ?arg ...?"`)
+	}
+	subs := []string{"errors", "func", "list", "load", "onchange", "refresh", "render", "show", "watch"}
+	sub, err := i.GetIndexFromObj(args[0], subs, "subcommand")
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	args = args[1:]
+	switch subs[sub] {
+	case "errors":
+		if len(args) != 0 {
+			return feather.Error(`wrong # args: should be "templates errors"`)
+		}
+		errs := s.Errors()
+		b := i.NewDictBuilder()
+		for _, name := range slices.Sorted(maps.Keys(errs)) {
+			b.Set(name, errs[name].Error())
+		}
+		return feather.OK(b.Obj())
+
+	case "func":
+		if len(args) != 2 {
+			return feather.Error(`wrong # args: should be "templates func name command"`)
+		}
+		if err := s.Func(args[0].String(), args[1]); err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK("")
+
+	case "list":
+		if len(args) != 0 {
+			return feather.Error(`wrong # args: should be "templates list"`)
+		}
+		return feather.OK(s.Names())
+
+	case "load":
+		a, err := feather.Args(i.String("templates load"), args).
+			Option("-mode", feather.String, "auto").
+			Require("dir", feather.String).
+			Parse()
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		modes := []string{"auto", "html", "text"}
+		mode, err := i.GetIndexFromObj(i.String(a.String("-mode")), modes, "mode")
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		if err := s.Load(a.String("dir"), Mode(mode)); err != nil {
+			return feather.Errorf("templates load: %v", err)
+		}
+		return feather.OK("")
+
+	case "onchange":
+		if len(args) != 1 {
+			return feather.Error(`wrong # args: should be "templates onchange command"`)
+		}
+		prefix, err := args[0].List()
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		s.OnChange(func(name, event string) {
+			words := append(slices.Clone(prefix), i.String(name), i.String(event))
+			if _, err := i.EvalObj(i.List(words...)); err != nil {
+				fmt.Fprintf(os.Stderr, "templates onchange: %v\n", err)
+			}
+		})
+		return feather.OK("")
+
+	case "refresh":
+		if len(args) != 0 {
+			return feather.Error(`wrong # args: should be "templates refresh"`)
+		}
+		s.Refresh()
+		return feather.OK("")
+
+	case "render":
+		if len(args) != 2 {
+			return feather.Error(`wrong # args: should be "templates render name data"`)
+		}
+		out, err := s.Render(args[0].String(), args[1])
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK(out)
+
+	case "show":
+		if len(args) != 1 {
+			return feather.Error(`wrong # args: should be "templates show name"`)
+		}
+		src, err := s.Source(args[0].String())
+		if err != nil {
+			return feather.Error(err.Error())
+		}
+		return feather.OK(src)
+	}
+
+	if len(args) != 1 {
+		return feather.Error(`wrong # args: should be "templates watch ms"`)
+	}
+	ms, err := args[0].Int()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	s.Watch(time.Duration(ms) * time.Millisecond)
+	return feather.OK("")
+}
@@ -0,0 +1,133 @@
+package feathertemplate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/feather-lang/feather"
+	"github.com/feather-lang/feather/feathertemplate"
+)
+
+func newInterp(t *testing.T, files map[string]string) (*feather.Interp, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		writeFile(t, filepath.Join(dir, name), src)
+	}
+	interp := feather.New()
+	t.Cleanup(interp.Close)
+	feathertemplate.Register(interp)
+	interp.SetVar("dir", dir)
+	return interp, dir
+}
+
+func writeFile(t *testing.T, path, src string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	interp, _ := newInterp(t, map[string]string{
+		"page.html":        `<h1>{{.title}}</h1>{{range .items}}<li>{{.}}</li>{{end}}`,
+		"report/sum.txt":   `{{.title}}: {{if gt .count 2}}many{{else}}few{{end}}`,
+		"broken.tmpl":      `{{.title`,
+		"report/money.txt": `total {{money .total}}`,
+	})
+	interp.MustEval(`templates load $dir`)
+
+	tests := []struct {
+		name, script, want string
+	}{
+		{"html escapes", `templates render page.html [dict create title <b> items [list a {b c}]]`,
+			"<h1>&lt;b&gt;</h1><li>a</li><li>b c</li>"},
+		{"text with numbers", `templates render report/sum.txt [dict create title <b> count 3]`, "<b>: many"},
+		{"list", `templates list`, "broken.tmpl page.html report/money.txt report/sum.txt"},
+		{"show", `templates show report/sum.txt`, `{{.title}}: {{if gt .count 2}}many{{else}}few{{end}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interp.Eval(tt.script)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if result.String() != tt.want {
+				t.Errorf("got %q; want %q", result.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		result := interp.MustEval(`dict keys [templates errors]`)
+		if result.String() != "broken.tmpl report/money.txt" {
+			t.Errorf("templates errors = %q; want broken.tmpl and report/money.txt", result.String())
+		}
+		if _, err := interp.Eval(`templates render nosuch {}`); err == nil {
+			t.Error("expected error rendering a missing template")
+		}
+	})
+
+	t.Run("func", func(t *testing.T) {
+		interp.MustEval(`proc money {n} { format %.2f $n }`)
+		interp.MustEval(`templates func money money`)
+		result, err := interp.Eval(`templates render report/money.txt [dict create total 12.5]`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "total 12.50" {
+			t.Errorf("got %q; want 'total 12.50'", result.String())
+		}
+	})
+
+	t.Run("text mode", func(t *testing.T) {
+		interp.MustEval(`templates load -mode text $dir`)
+		result := interp.MustEval(`templates render page.html [dict create title <b>]`)
+		if result.String() != "<h1><b></h1>" {
+			t.Errorf("got %q; want '<h1><b></h1>'", result.String())
+		}
+	})
+}
+
+func TestOnChange(t *testing.T) {
+	interp, dir := newInterp(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	interp.MustEval(`
+		set changes {}
+		templates onchange {lappend changes}
+		templates load $dir
+	`)
+	if got := interp.Var("changes").String(); got != "a.txt added b.txt added" {
+		t.Errorf("changes = %q; want both added", got)
+	}
+
+	interp.MustEval(`set changes {}`)
+	later := time.Now().Add(time.Second)
+	writeFile(t, filepath.Join(dir, "a.txt"), "A")
+	os.Chtimes(filepath.Join(dir, "a.txt"), later, later)
+	os.Remove(filepath.Join(dir, "b.txt"))
+	interp.MustEval(`templates refresh`)
+	if got := interp.Var("changes").String(); got != "a.txt changed b.txt removed" {
+		t.Errorf("changes = %q; want a.txt changed and b.txt removed", got)
+	}
+	if got := interp.MustEval(`templates render a.txt {}`).String(); got != "A" {
+		t.Errorf("render = %q; want the new source", got)
+	}
+
+	t.Run("watch", func(t *testing.T) {
+		interp.MustEval(`set changes {}; templates watch 10`)
+		writeFile(t, filepath.Join(dir, "c.txt"), "c")
+		result, err := interp.Eval(`vwait changes; set changes`)
+		if err != nil {
+			t.Fatalf("Eval failed: %v", err)
+		}
+		if result.String() != "c.txt added" {
+			t.Errorf("changes = %q; want c.txt added", result.String())
+		}
+		interp.MustEval(`templates watch 0`)
+	})
+}
@@ -0,0 +1,152 @@
+package feathertest
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/feather-lang/feather"
+)
+
+// RunFS runs every .tcl file in fsys as a subtest named after the file's
+// path without the extension. Files are typically embedded with go:embed:
+//
+//	//go:embed testdata/*.tcl
+//	var scripts embed.FS
+//
+//	func TestScripts(t *testing.T) {
+//	    feathertest.RunFS(t, scripts, func(f *feathertest.Fixture) {
+//	        mymodule.Register(f.Interp)
+//	    })
+//	}
+//
+// Each file runs in a fresh [Fixture], prepared by setup if it is not nil.
+// Comment lines at the top of a file state what the script should do:
+//
+//	# result: 42            - the script's result
+//	# error: wrong # args*  - the script fails with a matching message
+//	# stdout: hello         - one line of expected output; may repeat
+//	# skip: reason          - skip the file
+//
+// Without a result or error header the script only has to succeed. Output
+// is checked only when the file has stdout headers.
+func RunFS(t *testing.T, fsys fs.FS, setup func(f *Fixture)) {
+	t.Helper()
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".tcl" {
+			return err
+		}
+		script, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		t.Run(strings.TrimSuffix(name, ".tcl"), func(t *testing.T) {
+			runScript(t, string(script), setup)
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("reading test scripts: %v", err)
+	}
+}
+
+// scriptHeader holds the expectations read from a script's leading comments.
+type scriptHeader struct {
+	result, error, skip *string
+	stdout              []string
+}
+
+// parseHeader reads the expectations from the comment block at the start of script.
+func parseHeader(script string) scriptHeader {
+	var h scriptHeader
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line[1:]), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "result":
+			h.result = &value
+		case "error":
+			h.error = &value
+		case "skip":
+			h.skip = &value
+		case "stdout":
+			h.stdout = append(h.stdout, value)
+		}
+	}
+	return h
+}
+
+// runScript evaluates one script and checks it against its header.
+func runScript(t *testing.T, script string, setup func(f *Fixture)) {
+	h := parseHeader(script)
+	if h.skip != nil {
+		t.Skip(*h.skip)
+	}
+	f := New(t)
+	if setup != nil {
+		setup(f)
+	}
+
+	switch {
+	case h.error != nil:
+		f.AssertError(script, *h.error)
+	case h.result != nil:
+		f.AssertResult(script, *h.result)
+	default:
+		f.MustEval(script)
+	}
+
+	if h.stdout != nil {
+		want := strings.Join(h.stdout, "\n") + "\n"
+		if got := f.Stdout(); got != want {
+			t.Errorf("stdout = %q; want %q", got, want)
+		}
+	}
+}
+
+// RunTestFile runs the tcltest suite at path, as [feather.RunTestFile]
+// does, and reports each of its tests as a subtest named after it:
+//
+//	func TestParser(t *testing.T) {
+//	    feathertest.RunTestFile(t, "testdata/parse.test", func(f *feathertest.Fixture) {
+//	        parser.Register(f.Interp)
+//	    })
+//	}
+//
+// A failing test fails its subtest with the report tcltest printed, and a
+// test whose constraints are not satisfied is skipped. The suite runs in a
+// fresh [Fixture], prepared by setup if it is not nil.
+func RunTestFile(t *testing.T, path string, setup func(f *Fixture)) {
+	t.Helper()
+	f := New(t)
+	if setup != nil {
+		setup(f)
+	}
+	report, err := feather.RunTestFile(f.Interp, path)
+	if report != nil {
+		for _, r := range report.Tests {
+			t.Run(r.Name, func(t *testing.T) {
+				switch r.Status {
+				case feather.TestSkipped:
+					t.Skipf("constraint %s is not satisfied", r.SkippedBy)
+				case feather.TestFailed:
+					t.Error(strings.TrimSpace(r.Report))
+				}
+			})
+		}
+	}
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package feathertest provides helpers for Go unit tests of script-exposed APIs.
+//
+// The helpers report failures through [testing.TB], so tests read as a list
+// of scripts and expectations:
+//
+//	func TestGreet(t *testing.T) {
+//	    f := feathertest.New(t)
+//	    f.Register("greet", func(name string) string { return "Hello, " + name })
+//
+//	    f.AssertResult(`greet World`, "Hello, World")
+//	    f.AssertError(`greet`, "wrong # args*")
+//	}
+//
+// Error patterns use TCL glob syntax, as in string match.
+//
+// [RunFS] runs a directory of .tcl scripts, usually embedded with go:embed,
+// as subtests, checking each against expectations in its leading comments.
+package feathertest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/feather-lang/feather"
+)
+
+// MustEval evaluates script and fails the test immediately if it returns an error.
+func MustEval(t testing.TB, interp *feather.Interp, script string) *feather.Obj {
+	t.Helper()
+	result, err := interp.Eval(script)
+	if err != nil {
+		t.Fatalf("eval %q: %v", script, err)
+	}
+	return result
+}
+
+// AssertResult evaluates script and reports an error unless it succeeds
+// with a result whose string form equals want.
+func AssertResult(t testing.TB, interp *feather.Interp, script, want string) {
+	t.Helper()
+	result, err := interp.Eval(script)
+	if err != nil {
+		t.Errorf("eval %q: unexpected error: %v", script, err)
+		return
+	}
+	if got := result.String(); got != want {
+		t.Errorf("eval %q = %q; want %q", script, got, want)
+	}
+}
+
+// AssertError evaluates script and reports an error unless it fails with a
+// message matching the glob pattern wantMsgPattern.
+func AssertError(t testing.TB, interp *feather.Interp, script, wantMsgPattern string) {
+	t.Helper()
+	result, err := interp.Eval(script)
+	if err == nil {
+		t.Errorf("eval %q = %q; want error matching %q", script, result.String(), wantMsgPattern)
+		return
+	}
+	matched, merr := interp.Call("string", "match", wantMsgPattern, err.Error())
+	if merr != nil {
+		t.Errorf("bad pattern %q: %v", wantMsgPattern, merr)
+		return
+	}
+	if ok, _ := matched.Bool(); !ok {
+		t.Errorf("eval %q: error %q does not match %q", script, err.Error(), wantMsgPattern)
+	}
+}
+
+// Fixture is an interpreter set up for tests. It is closed automatically
+// when the test finishes, captures stdout and stderr, and provides these
+// commands in addition to the standard ones:
+//
+//	echo ?arg ...?          - returns its arguments as a list
+//	assert expr ?message?   - fails unless expr is true
+//	fail ?message?          - always fails
+type Fixture struct {
+	*feather.Interp
+	t      testing.TB
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+// New creates a [Fixture] bound to t.
+func New(t testing.TB) *Fixture {
+	t.Helper()
+	f := &Fixture{Interp: feather.New(), t: t}
+	t.Cleanup(f.Close)
+
+	f.RegisterChannel("stdout", nil, &f.stdout)
+	f.RegisterChannel("stderr", nil, &f.stderr)
+	f.RegisterCommand("echo", cmdEcho)
+	f.RegisterCommand("assert", cmdAssert)
+	f.RegisterCommand("fail", cmdFail)
+	return f
+}
+
+// MustEval evaluates script and fails the test immediately on error.
+func (f *Fixture) MustEval(script string) *feather.Obj {
+	f.t.Helper()
+	return MustEval(f.t, f.Interp, script)
+}
+
+// AssertResult checks that script succeeds with result want.
+func (f *Fixture) AssertResult(script, want string) {
+	f.t.Helper()
+	AssertResult(f.t, f.Interp, script, want)
+}
+
+// AssertError checks that script fails with a message matching wantMsgPattern.
+func (f *Fixture) AssertError(script, wantMsgPattern string) {
+	f.t.Helper()
+	AssertError(f.t, f.Interp, script, wantMsgPattern)
+}
+
+// Stdout returns everything written to stdout so far.
+func (f *Fixture) Stdout() string {
+	f.FlushChannels()
+	return f.stdout.String()
+}
+
+// Stderr returns everything written to stderr so far.
+func (f *Fixture) Stderr() string {
+	f.FlushChannels()
+	return f.stderr.String()
+}
+
+func cmdEcho(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	return feather.OK(i.List(args...))
+}
+
+func cmdAssert(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if len(args) < 1 || len(args) > 2 {
+		return feather.Error(`wrong # args: should be "assert expr ?message?"`)
+	}
+	result, err := i.Call("expr", args[0])
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	ok, err := result.Bool()
+	if err != nil {
+		return feather.Error(err.Error())
+	}
+	if ok {
+		return feather.OK("")
+	}
+	if len(args) == 2 {
+		return feather.Errorf("assertion failed: %s", args[1].String())
+	}
+	return feather.Errorf("assertion failed: %s", args[0].String())
+}
+
+func cmdFail(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+	if len(args) > 1 {
+		return feather.Error(`wrong # args: should be "fail ?message?"`)
+	}
+	if len(args) == 1 {
+		return feather.Error(args[0].String())
+	}
+	return feather.Error("failed")
+}
@@ -0,0 +1,87 @@
+package feathertest_test
+
+import (
+	"embed"
+	"testing"
+
+	"github.com/feather-lang/feather"
+	"github.com/feather-lang/feather/feathertest"
+)
+
+// recorder captures failures instead of failing the enclosing test.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper()                        {}
+func (r *recorder) Errorf(format string, a ...any) { r.failed = true }
+
+func TestAssertions(t *testing.T) {
+	interp := feather.New()
+	defer interp.Close()
+
+	feathertest.AssertResult(t, interp, "expr {2 + 3}", "5")
+	feathertest.AssertError(t, interp, "error boom", "boom")
+	feathertest.AssertError(t, interp, "expr {1 / 0}", "divide by zero*")
+	if got := feathertest.MustEval(t, interp, "string toupper abc").String(); got != "ABC" {
+		t.Errorf("MustEval = %q; want 'ABC'", got)
+	}
+
+	t.Run("failures are reported", func(t *testing.T) {
+		tests := []func(r *recorder){
+			func(r *recorder) { feathertest.AssertResult(r, interp, "expr {2 + 3}", "6") },
+			func(r *recorder) { feathertest.AssertResult(r, interp, "error boom", "boom") },
+			func(r *recorder) { feathertest.AssertError(r, interp, "set x 1", "*") },
+			func(r *recorder) { feathertest.AssertError(r, interp, "error boom", "bang*") },
+		}
+		for j, tt := range tests {
+			r := &recorder{TB: t}
+			tt(r)
+			if !r.failed {
+				t.Errorf("case %d: expected failure to be reported", j)
+			}
+		}
+	})
+}
+
+func TestFixture(t *testing.T) {
+	f := feathertest.New(t)
+
+	f.AssertResult(`echo a {b c}`, "a {b c}")
+	f.AssertResult(`assert {1 < 2}`, "")
+	f.AssertError(`assert {1 > 2}`, "assertion failed: 1 > 2")
+	f.AssertError(`assert {1 > 2} "ordering"`, "assertion failed: ordering")
+	f.AssertError(`fail "not yet"`, "not yet")
+
+	f.MustEval(`proc check {x} { assert {$x > 0} "x must be positive" }`)
+	f.AssertResult(`check 1`, "")
+	f.AssertError(`check -1`, "*must be positive")
+
+	f.MustEval(`puts hello; puts stderr oops`)
+	if f.Stdout() != "hello\n" {
+		t.Errorf("Stdout = %q; want %q", f.Stdout(), "hello\n")
+	}
+	if f.Stderr() != "oops\n" {
+		t.Errorf("Stderr = %q; want %q", f.Stderr(), "oops\n")
+	}
+}
+
+//go:embed testdata/*.tcl
+var scripts embed.FS
+
+func TestRunFS(t *testing.T) {
+	feathertest.RunFS(t, scripts, func(f *feathertest.Fixture) {
+		f.RegisterCommand("greet", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.OK("Hello, " + args[0].String())
+		})
+	})
+}
+
+func TestRunTestFile(t *testing.T) {
+	feathertest.RunTestFile(t, "testdata/sums.test", func(f *feathertest.Fixture) {
+		f.RegisterCommand("greet", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+			return feather.OK("Hello, " + args[0].String())
+		})
+	})
+}
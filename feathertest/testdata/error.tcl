@@ -0,0 +1,2 @@
+# error: expected integer*
+incr x notanumber
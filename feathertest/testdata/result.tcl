@@ -0,0 +1,7 @@
+# result: 6
+proc sum {args} {
+    set total 0
+    foreach n $args { incr total $n }
+    return $total
+}
+sum 1 2 3
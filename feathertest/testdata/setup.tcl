@@ -0,0 +1,2 @@
+# result: Hello, World
+greet World
@@ -0,0 +1,2 @@
+# skip: demonstrates skipping
+error "should not run"
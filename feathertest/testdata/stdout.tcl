@@ -0,0 +1,5 @@
+# A script that only prints.
+# stdout: hello
+# stdout: world
+puts hello
+puts world
@@ -0,0 +1,234 @@
+package feather
+
+import "slices"
+
+// ValueKind is the kind of a [Value].
+type ValueKind uint8
+
+const (
+	ValueString ValueKind = iota // a string
+	ValueInt                     // an integer
+	ValueDouble                  // a floating-point number
+	ValueList                    // a list of values
+	ValueDict                    // a dict of string keys and values
+)
+
+// String returns the name of the kind, as [Obj.Type] names types.
+func (k ValueKind) String() string {
+	switch k {
+	case ValueInt:
+		return "int"
+	case ValueDouble:
+		return "double"
+	case ValueList:
+		return "list"
+	case ValueDict:
+		return "dict"
+	}
+	return "string"
+}
+
+// Value is an immutable snapshot of an object, made by [Obj.Freeze]. It
+// belongs to no interpreter and nothing changes it, so it can be shared by
+// any number of goroutines and brought into any interpreter with
+// [Interp.Thaw].
+//
+// A Value is a tree of strings, integers, doubles, lists and dicts, shaped
+// like the internal representation of the object it was made from: a list
+// written as a string is frozen as a string, not parsed. Other types, such
+// as bignums, byte arrays and foreign objects, are frozen as their string.
+//
+// The zero Value is the empty string.
+type Value struct {
+	n *valueNode
+}
+
+type valueNode struct {
+	kind ValueKind
+	s    string // string form; for non-strings valid only if hasS
+	hasS bool
+	i    int64
+	f    float64
+
+	items []Value        // list elements, or dict values in key order
+	keys  []string       // dict keys in order
+	index map[string]int // position of each dict key in keys
+}
+
+// Freeze returns an immutable snapshot of the object's value, which is
+// safe to hand to other goroutines. Lists and dicts are frozen deeply.
+//
+//	v := result.Freeze()
+//	go func() { fmt.Println(v.Kind(), v.Len()) }()
+func (o *Obj) Freeze() Value {
+	if o == nil {
+		return Value{}
+	}
+	n := &valueNode{s: o.bytes, hasS: o.bytes != ""}
+	switch t := o.intrep.(type) {
+	case nil:
+		n.hasS = true
+	case IntType:
+		n.kind, n.i = ValueInt, int64(t)
+	case DoubleType:
+		n.kind, n.f = ValueDouble, float64(t)
+	case ListType:
+		n.kind = ValueList
+		n.items = make([]Value, len(t))
+		for k, item := range t {
+			n.items[k] = item.Freeze()
+		}
+	case IntSliceType:
+		n.kind = ValueList
+		n.items = make([]Value, len(t))
+		for k, v := range t {
+			n.items[k] = Value{&valueNode{kind: ValueInt, i: v}}
+		}
+	case FloatSliceType:
+		n.kind = ValueList
+		n.items = make([]Value, len(t))
+		for k, v := range t {
+			n.items[k] = Value{&valueNode{kind: ValueDouble, f: v}}
+		}
+	case *DictType:
+		n.kind = ValueDict
+		n.keys = slices.Clone(t.Order)
+		n.items = make([]Value, len(t.Order))
+		n.index = make(map[string]int, len(t.Order))
+		for k, key := range t.Order {
+			n.items[k] = t.Items[key].Freeze()
+			n.index[key] = k
+		}
+	default:
+		n.s, n.hasS = o.String(), true
+	}
+	return Value{n}
+}
+
+// Kind returns the kind of the value.
+func (v Value) Kind() ValueKind {
+	if v.n == nil {
+		return ValueString
+	}
+	return v.n.kind
+}
+
+// String returns the string form of the value. The string of a list or
+// dict that was not known when it was frozen is built on each call.
+func (v Value) String() string {
+	n := v.n
+	switch {
+	case n == nil:
+		return ""
+	case n.hasS:
+		return n.s
+	case n.kind == ValueInt:
+		return IntType(n.i).UpdateString()
+	case n.kind == ValueDouble:
+		return DoubleType(n.f).UpdateString()
+	}
+	var buf []byte
+	for k, item := range n.items {
+		if n.kind == ValueDict {
+			if len(buf) > 0 {
+				buf = append(buf, ' ')
+			}
+			buf = appendListElement(buf, n.keys[k], len(buf) == 0)
+		}
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendListElement(buf, item.String(), len(buf) == 0)
+	}
+	return string(buf)
+}
+
+// Int returns the value of an integer, and false for other kinds.
+func (v Value) Int() (int64, bool) {
+	if v.Kind() != ValueInt {
+		return 0, false
+	}
+	return v.n.i, true
+}
+
+// Double returns the value of a double, and false for other kinds.
+func (v Value) Double() (float64, bool) {
+	if v.Kind() != ValueDouble {
+		return 0, false
+	}
+	return v.n.f, true
+}
+
+// Len returns the number of elements of a list or keys of a dict, and 0
+// for other kinds.
+func (v Value) Len() int {
+	if v.n == nil {
+		return 0
+	}
+	return len(v.n.items)
+}
+
+// Index returns element k of a list, or the value of key k of a dict in
+// key order. It panics if k is out of range.
+func (v Value) Index(k int) Value {
+	return v.n.items[k]
+}
+
+// Keys returns the keys of a dict in order, and nil for other kinds.
+func (v Value) Keys() []string {
+	if v.Kind() != ValueDict {
+		return nil
+	}
+	return slices.Clone(v.n.keys)
+}
+
+// Get returns the value of key in a dict. It reports false if the key is
+// missing or v is not a dict.
+func (v Value) Get(key string) (Value, bool) {
+	if v.Kind() != ValueDict {
+		return Value{}, false
+	}
+	k, ok := v.n.index[key]
+	if !ok {
+		return Value{}, false
+	}
+	return v.n.items[k], true
+}
+
+// Thaw creates an object of i from a frozen value, with the same string
+// and the same shape of lists and dicts. Each call creates new objects, so
+// the result can be changed like any other object of i.
+//
+//	v := other.MustEval(`dict create a {1 2}`).Freeze()
+//	obj := interp.Thaw(v)
+func (i *Interp) Thaw(v Value) *Obj {
+	n := v.n
+	if n == nil {
+		return i.String("")
+	}
+	var o *Obj
+	switch n.kind {
+	case ValueInt:
+		o = i.Int(n.i)
+	case ValueDouble:
+		o = i.Double(n.f)
+	case ValueList:
+		items := make([]*Obj, len(n.items))
+		for k, item := range n.items {
+			items[k] = i.Thaw(item)
+		}
+		o = i.List(items...)
+	case ValueDict:
+		d := &DictType{Items: make(map[string]*Obj, len(n.keys)), Order: slices.Clone(n.keys)}
+		for k, key := range n.keys {
+			d.Items[key] = i.Thaw(n.items[k])
+		}
+		o = i.Obj(d)
+	default:
+		return i.String(n.s)
+	}
+	if n.hasS {
+		o.bytes = n.s
+	}
+	return o
+}
@@ -0,0 +1,75 @@
+package feather
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Func returns a Go function of type T that calls the command name, the
+// inverse of [Interp.Register]. It makes procs usable as typed hooks:
+//
+//	interp.MustEval(`proc add {a b} { expr {$a + $b} }`)
+//	add, err := feather.Func[func(int, int) (int, error)](interp, "add")
+//	sum, err := add(2, 3) // 5
+//
+// Arguments are converted as for [Interp.Call], and the last argument of a
+// variadic function is passed as separate arguments. The result is
+// converted to the first result of T as for [Into]. T may return nothing,
+// a value, an error, or a value and an error; errors from the command are
+// returned as [*EvalError]. A function whose type returns no error panics
+// if the command fails, as [Interp.MustEval] does.
+//
+// The command is looked up each time the function is called, so that
+// redefining it changes what the function calls. Func fails if T is not a
+// function type of that shape, or if there is no command name.
+func Func[T any](i *Interp, name string) (T, error) {
+	var fn T
+	fnType := reflect.TypeOf((*T)(nil)).Elem()
+	if fnType.Kind() != reflect.Func {
+		return fn, fmt.Errorf("Func: %v is not a function type", fnType)
+	}
+	numOut := fnType.NumOut()
+	hasErr := numOut > 0 && fnType.Out(numOut-1) == errorType
+	if hasErr {
+		numOut--
+	}
+	if numOut > 1 {
+		return fn, fmt.Errorf("Func: %v returns more than one value besides an error", fnType)
+	}
+	if i.resolveCommand(name) == nil {
+		return fn, fmt.Errorf("invalid command name \"%s\"", name)
+	}
+
+	impl := func(in []reflect.Value) []reflect.Value {
+		args := make([]any, 0, len(in))
+		for n, v := range in {
+			if fnType.IsVariadic() && n == len(in)-1 {
+				args = append(args, Expand(v.Interface()))
+				continue
+			}
+			args = append(args, v.Interface())
+		}
+
+		out := make([]reflect.Value, fnType.NumOut())
+		for n := range out {
+			out[n] = reflect.New(fnType.Out(n)).Elem()
+		}
+		result, err := i.Call(name, args...)
+		if err == nil && numOut == 1 {
+			err = intoValue(result, out[0])
+		}
+		if err != nil {
+			if !hasErr {
+				panic(err)
+			}
+			if numOut == 1 {
+				out[0].SetZero()
+			}
+			out[len(out)-1].Set(reflect.ValueOf(err))
+		}
+		return out
+	}
+	return reflect.MakeFunc(fnType, impl).Interface().(T), nil
+}
@@ -2,6 +2,9 @@ module github.com/feather-lang/feather
 
 go 1.25.5
 
-require golang.org/x/term v0.38.0
+require (
+	golang.org/x/term v0.38.0
+	golang.org/x/text v0.40.0
+)
 
 require golang.org/x/sys v0.39.0 // indirect
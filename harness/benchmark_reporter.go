@@ -57,6 +57,38 @@ func (r *BenchmarkReporter) reportBenchmark(result BenchmarkResult) {
 	fmt.Fprintf(r.output, "  Ops/sec:    %.2f\n\n", result.OpsPerSecond)
 }
 
+// ReportStats reports the statistics of the samples taken of each
+// benchmark of a suite.
+func (r *BenchmarkReporter) ReportStats(stats []BenchmarkStats) {
+	fmt.Fprintf(r.output, "--- Samples ---\n")
+	for _, s := range stats {
+		if s.Error != "" {
+			fmt.Fprintf(r.output, "FAIL: %s\n  Error: %s\n", s.Name, s.Error)
+			continue
+		}
+		fmt.Fprintf(r.output, "%s\n", s.Name)
+		fmt.Fprintf(r.output, "  Mean: %s/op ± %s  p95: %s  (%d samples)\n",
+			formatDuration(s.Mean), formatDuration(s.StdDev), formatDuration(s.P95), len(s.Samples))
+	}
+	fmt.Fprintln(r.output)
+}
+
+// ReportComparison reports how the mean time of each benchmark changed
+// from a baseline, marking the changes above threshold as regressions.
+// A negative threshold marks none.
+func (r *BenchmarkReporter) ReportComparison(comparisons []BenchmarkComparison, threshold float64) {
+	fmt.Fprintf(r.output, "=== Comparison ===\n\n")
+	for _, c := range comparisons {
+		mark := ""
+		if threshold >= 0 && c.Change > threshold {
+			mark = "  REGRESSION"
+		}
+		fmt.Fprintf(r.output, "%s/%s\n  %s/op -> %s/op  %+.1f%%%s\n",
+			c.Suite, c.Name, formatDuration(c.Old), formatDuration(c.New), c.Change*100, mark)
+	}
+	fmt.Fprintln(r.output)
+}
+
 // formatDuration formats a duration in a human-readable way.
 func formatDuration(d time.Duration) string {
 	// Choose appropriate unit
@@ -0,0 +1,166 @@
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BenchmarkStats summarizes the samples taken of one benchmark. Each sample
+// is the average time per iteration of one run of the benchmark's suite.
+type BenchmarkStats struct {
+	Suite   string          `json:"suite"`
+	Name    string          `json:"name"`
+	Samples []time.Duration `json:"samples_ns"`
+	Mean    time.Duration   `json:"mean_ns"`
+	StdDev  time.Duration   `json:"stddev_ns"`
+	P95     time.Duration   `json:"p95_ns"`
+	Error   string          `json:"error,omitempty"` // Error of the first failed run, if any
+}
+
+// RunSamples runs a suite count times, each in a new host process, and
+// returns the results of every run.
+func (r *BenchmarkRunner) RunSamples(suite *BenchmarkSuite, count int) [][]BenchmarkResult {
+	runs := make([][]BenchmarkResult, 0, count)
+	for range max(count, 1) {
+		runs = append(runs, r.RunSuite(suite))
+	}
+	return runs
+}
+
+// SummarizeSamples computes the statistics of each benchmark of a suite
+// from the results of the runs RunSamples returned.
+func SummarizeSamples(suite *BenchmarkSuite, runs [][]BenchmarkResult) []BenchmarkStats {
+	stats := make([]BenchmarkStats, len(suite.Benchmarks))
+	for i, b := range suite.Benchmarks {
+		s := BenchmarkStats{Suite: suite.Name, Name: b.Name}
+		for _, results := range runs {
+			if i >= len(results) {
+				continue
+			}
+			if !results[i].Success {
+				if s.Error == "" {
+					s.Error = results[i].Error
+				}
+				continue
+			}
+			s.Samples = append(s.Samples, results[i].AvgTime)
+		}
+		s.Mean, s.StdDev, s.P95 = sampleStats(s.Samples)
+		stats[i] = s
+	}
+	return stats
+}
+
+// sampleStats returns the mean, sample standard deviation and 95th
+// percentile (by nearest rank) of samples.
+func sampleStats(samples []time.Duration) (mean, stddev, p95 time.Duration) {
+	n := len(samples)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += float64(s)
+	}
+	m := sum / float64(n)
+	if n > 1 {
+		sq := 0.0
+		for _, s := range samples {
+			sq += (float64(s) - m) * (float64(s) - m)
+		}
+		stddev = time.Duration(math.Sqrt(sq / float64(n-1)))
+	}
+	sorted := slices.Sorted(slices.Values(samples))
+	rank := int(math.Ceil(0.95 * float64(n)))
+	return time.Duration(m), stddev, sorted[rank-1]
+}
+
+// BenchmarkBaseline is the file bench -save writes and -baseline and bench
+// compare read: the statistics of every benchmark of a run.
+type BenchmarkBaseline struct {
+	Benchmarks []BenchmarkStats `json:"benchmarks"`
+}
+
+// SaveBaseline writes stats to path as a baseline.
+func SaveBaseline(path string, stats []BenchmarkStats) error {
+	data, err := json.MarshalIndent(BenchmarkBaseline{Benchmarks: stats}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadBaseline reads the statistics of a baseline from path.
+func LoadBaseline(path string) ([]BenchmarkStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var baseline BenchmarkBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return baseline.Benchmarks, nil
+}
+
+// BenchmarkComparison compares the mean time of a benchmark in two runs.
+type BenchmarkComparison struct {
+	Suite  string
+	Name   string
+	Old    time.Duration
+	New    time.Duration
+	Change float64 // (New-Old)/Old, so 0.05 means 5% slower
+}
+
+// CompareBenchmarks compares the benchmarks of current with those of the
+// same suite and name in baseline. Benchmarks that are missing from either,
+// or that failed in either, are left out.
+func CompareBenchmarks(baseline, current []BenchmarkStats) []BenchmarkComparison {
+	type key struct{ suite, name string }
+	old := make(map[key]BenchmarkStats, len(baseline))
+	for _, s := range baseline {
+		old[key{s.Suite, s.Name}] = s
+	}
+	var comparisons []BenchmarkComparison
+	for _, s := range current {
+		o, ok := old[key{s.Suite, s.Name}]
+		if !ok || o.Error != "" || s.Error != "" || o.Mean <= 0 {
+			continue
+		}
+		comparisons = append(comparisons, BenchmarkComparison{
+			Suite:  s.Suite,
+			Name:   s.Name,
+			Old:    o.Mean,
+			New:    s.Mean,
+			Change: float64(s.Mean-o.Mean) / float64(o.Mean),
+		})
+	}
+	return comparisons
+}
+
+// Regressions returns the comparisons whose benchmark got slower by more
+// than threshold, a fraction such as 0.05 for 5%.
+func Regressions(comparisons []BenchmarkComparison, threshold float64) []BenchmarkComparison {
+	var regressions []BenchmarkComparison
+	for _, c := range comparisons {
+		if c.Change > threshold {
+			regressions = append(regressions, c)
+		}
+	}
+	return regressions
+}
+
+// ParsePercent parses a threshold such as "5%" or "5" into a fraction.
+func ParsePercent(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return v / 100, nil
+}
@@ -11,12 +11,22 @@ import (
 )
 
 func main() {
-	var hostPath string
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(compare(os.Args[2:]))
+	}
+
+	var hostPath, baselinePath, savePath, failOn string
+	var count int
 	flag.StringVar(&hostPath, "host", "", "Path to the host executable")
+	flag.IntVar(&count, "count", 1, "Number of times to run each benchmark file")
+	flag.StringVar(&baselinePath, "baseline", "", "Baseline file to compare the results with")
+	flag.StringVar(&savePath, "save", "", "File to write the results to, for use as a baseline")
+	flag.StringVar(&failOn, "fail-on-regression", "", "Fail if a benchmark is slower than the baseline by more than this (e.g. 5%)")
 	flag.Parse()
 
 	if hostPath == "" {
-		fmt.Fprintf(os.Stderr, "Usage: bench -host <host-executable> <benchmark-files...>\n")
+		fmt.Fprintf(os.Stderr, "Usage: bench -host <host-executable> [-count n] [-baseline file] [-save file] [-fail-on-regression pct] <benchmark-files...>\n")
+		fmt.Fprintf(os.Stderr, "       bench compare [-fail-on-regression pct] <old.json> <new.json>\n")
 		os.Exit(1)
 	}
 
@@ -25,10 +35,28 @@ func main() {
 		os.Exit(1)
 	}
 
+	threshold, err := parseThreshold(failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if threshold >= 0 && baselinePath == "" {
+		fmt.Fprintf(os.Stderr, "Error: -fail-on-regression needs -baseline\n")
+		os.Exit(1)
+	}
+	var baseline []harness.BenchmarkStats
+	if baselinePath != "" {
+		if baseline, err = harness.LoadBaseline(baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	runner := harness.NewBenchmarkRunner(hostPath, os.Stdout)
 	reporter := harness.NewBenchmarkReporter(os.Stdout)
 
 	allSuccess := true
+	var stats []harness.BenchmarkStats
 	for _, path := range flag.Args() {
 		suite, err := harness.ParseBenchmarkFile(path)
 		if err != nil {
@@ -41,18 +69,88 @@ func main() {
 		origPath := suite.Path
 		suite.Path, _ = filepath.Abs(origPath)
 
-		results := runner.RunSuite(suite)
-		reporter.ReportSuite(suite, results)
+		runs := runner.RunSamples(suite, count)
+		reporter.ReportSuite(suite, runs[0])
+		suiteStats := harness.SummarizeSamples(suite, runs)
+		if count > 1 {
+			reporter.ReportStats(suiteStats)
+		}
+		stats = append(stats, suiteStats...)
 
-		// Check if any benchmark failed
-		for _, result := range results {
-			if !result.Success {
+		// Check if any benchmark failed in any run
+		for _, s := range suiteStats {
+			if s.Error != "" {
 				allSuccess = false
 			}
 		}
 	}
 
+	if savePath != "" {
+		if err := harness.SaveBaseline(savePath, stats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if baseline != nil && !reportComparison(reporter, baseline, stats, threshold) {
+		allSuccess = false
+	}
+
 	if !allSuccess {
 		os.Exit(1)
 	}
 }
+
+// compare implements bench compare, which compares two saved results.
+func compare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	failOn := fs.String("fail-on-regression", "", "Fail if a benchmark is slower by more than this (e.g. 5%)")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: bench compare [-fail-on-regression pct] <old.json> <new.json>\n")
+		return 1
+	}
+	threshold, err := parseThreshold(*failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	old, err := harness.LoadBaseline(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	current, err := harness.LoadBaseline(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if !reportComparison(harness.NewBenchmarkReporter(os.Stdout), old, current, threshold) {
+		return 1
+	}
+	return 0
+}
+
+// parseThreshold parses the value of -fail-on-regression, returning -1 if
+// it is not set.
+func parseThreshold(s string) (float64, error) {
+	if s == "" {
+		return -1, nil
+	}
+	return harness.ParsePercent(s)
+}
+
+// reportComparison reports how current compares with baseline and returns
+// false if a benchmark regressed by more than threshold.
+func reportComparison(reporter *harness.BenchmarkReporter, baseline, current []harness.BenchmarkStats, threshold float64) bool {
+	comparisons := harness.CompareBenchmarks(baseline, current)
+	reporter.ReportComparison(comparisons, threshold)
+	if threshold < 0 {
+		return true
+	}
+	regressions := harness.Regressions(comparisons, threshold)
+	if len(regressions) > 0 {
+		fmt.Fprintf(os.Stderr, "%d benchmarks regressed by more than %g%%\n", len(regressions), threshold*100)
+		return false
+	}
+	return true
+}
@@ -11,6 +11,8 @@ func main() {
 	var hostPath string
 	var verbose bool
 	var namePattern string
+	var parallel int
+	var format string
 
 	rootCmd := &cobra.Command{
 		Use:   "harness",
@@ -29,6 +31,8 @@ func main() {
 				Output:      os.Stdout,
 				ErrOutput:   os.Stderr,
 				Verbose:     verbose,
+				Parallel:    parallel,
+				Format:      format,
 			})
 			os.Exit(exitCode)
 		},
@@ -37,6 +41,8 @@ func main() {
 	runCmd.MarkFlagRequired("host")
 	runCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show all test results, not just failures")
 	runCmd.Flags().StringVar(&namePattern, "name", "", "regex pattern to filter test names")
+	runCmd.Flags().IntVar(&parallel, "parallel", 1, "number of test files to run at once")
+	runCmd.Flags().StringVar(&format, "format", "text", "output format: text, junit, json or tap")
 
 	listCmd := &cobra.Command{
 		Use:   "list <test-files-or-dirs>...",
@@ -77,6 +83,38 @@ to match the actual output. Use this to update tests after intentional behavior
 	updateCmd.MarkFlagRequired("host")
 	updateCmd.Flags().StringVar(&updateNamePattern, "name", "", "regex pattern to filter test names")
 
-	rootCmd.AddCommand(runCmd, listCmd, updateCmd)
+	var fuzzHostPath, oraclePath, corpusDir string
+	var fuzzCount int
+	var fuzzSeed int64
+	fuzzCmd := &cobra.Command{
+		Use:   "fuzz [flags] <feature>",
+		Short: "Compare the host against the oracle on random scripts",
+		Long: `Fuzz generates random scripts exercising a feature (expr, list, string or all),
+runs each against the host and the oracle, and reports the scripts whose outcomes
+differ. Each divergence is minimized and added to the corpus as a test case that
+expects the oracle's outcome.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			exitCode := harness.Fuzz(harness.FuzzConfig{
+				HostPath:   fuzzHostPath,
+				OraclePath: oraclePath,
+				Feature:    args[0],
+				Count:      fuzzCount,
+				Seed:       fuzzSeed,
+				CorpusDir:  corpusDir,
+				Output:     os.Stdout,
+				ErrOutput:  os.Stderr,
+			})
+			os.Exit(exitCode)
+		},
+	}
+	fuzzCmd.Flags().StringVar(&fuzzHostPath, "host", "", "path to the host executable (required)")
+	fuzzCmd.MarkFlagRequired("host")
+	fuzzCmd.Flags().StringVar(&oraclePath, "oracle", "bin/oracle", "path to the oracle executable")
+	fuzzCmd.Flags().IntVar(&fuzzCount, "count", 100, "number of scripts to generate")
+	fuzzCmd.Flags().Int64Var(&fuzzSeed, "seed", 0, "seed for the generator (0 picks one)")
+	fuzzCmd.Flags().StringVar(&corpusDir, "corpus", "testcases/fuzz", "directory to add divergences to (empty to not add them)")
+
+	rootCmd.AddCommand(runCmd, listCmd, updateCmd, fuzzCmd)
 	rootCmd.Execute()
 }
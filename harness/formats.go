@@ -0,0 +1,165 @@
+package harness
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// junitTestsuites is the root element of a JUnit XML report.
+type junitTestsuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestsuite `xml:"testsuite"`
+}
+
+// junitTestsuite holds the test cases of one test file.
+type junitTestsuite struct {
+	Name     string          `xml:"name,attr"`
+	File     string          `xml:"file,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestcase `xml:"testcase"`
+}
+
+// junitTestcase is the outcome of one test case.
+type junitTestcase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure describes why a test case failed.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the results as a JUnit XML report, with a testsuite
+// element for each test file.
+func writeJUnit(w io.Writer, suites []reportedSuite, summary Summary) {
+	report := junitTestsuites{Tests: summary.Total, Failures: summary.Failed}
+	var total time.Duration
+	for _, s := range suites {
+		js := junitTestsuite{Name: s.Name, File: s.File, Tests: len(s.Results)}
+		var elapsed time.Duration
+		for _, result := range s.Results {
+			tc := junitTestcase{
+				Name:      result.TestCase.Name,
+				Classname: s.Name,
+				Time:      seconds(result.Duration),
+			}
+			if !result.Passed {
+				js.Failures++
+				tc.Failure = &junitFailure{
+					Message: firstLine(result.Failures),
+					Text:    failureText(result),
+				}
+			}
+			js.Cases = append(js.Cases, tc)
+			elapsed += result.Duration
+		}
+		js.Time = seconds(elapsed)
+		report.Suites = append(report.Suites, js)
+		total += elapsed
+	}
+	report.Time = seconds(total)
+
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(report)
+	fmt.Fprintln(w)
+}
+
+// jsonReport is the report written in the json format.
+type jsonReport struct {
+	Tests  []jsonTest `json:"tests"`
+	Total  int        `json:"total"`
+	Passed int        `json:"passed"`
+	Failed int        `json:"failed"`
+}
+
+// jsonTest is the outcome of one test case in the json format.
+type jsonTest struct {
+	File     string   `json:"file"`
+	Suite    string   `json:"suite"`
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Seconds  float64  `json:"seconds"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// writeJSON writes the results as a single JSON object.
+func writeJSON(w io.Writer, suites []reportedSuite, summary Summary) {
+	report := jsonReport{Tests: []jsonTest{}, Total: summary.Total, Passed: summary.Passed, Failed: summary.Failed}
+	for _, s := range suites {
+		for _, result := range s.Results {
+			report.Tests = append(report.Tests, jsonTest{
+				File:     s.File,
+				Suite:    s.Name,
+				Name:     result.TestCase.Name,
+				Passed:   result.Passed,
+				Seconds:  result.Duration.Seconds(),
+				Failures: result.Failures,
+			})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+// writeTAP writes the results in the Test Anything Protocol, with the
+// reasons a test failed as diagnostic lines after it.
+func writeTAP(w io.Writer, suites []reportedSuite, summary Summary) {
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", summary.Total)
+	n := 0
+	for _, s := range suites {
+		for _, result := range s.Results {
+			n++
+			status := "ok"
+			if !result.Passed {
+				status = "not ok"
+			}
+			fmt.Fprintf(w, "%s %d - %s > %s # time=%s\n", status, n, s.Name, result.TestCase.Name, formatDuration(result.Duration))
+			if !result.Passed {
+				for _, line := range strings.Split(failureText(result), "\n") {
+					fmt.Fprintf(w, "# %s\n", line)
+				}
+			}
+		}
+	}
+}
+
+// failureText describes why a test failed: each failure, then the script.
+func failureText(result TestResult) string {
+	var b strings.Builder
+	for _, failure := range result.Failures {
+		b.WriteString(failure + "\n")
+	}
+	b.WriteString("script:\n    " + indentScript(result.TestCase.Script))
+	return b.String()
+}
+
+// firstLine returns the first line of the first failure.
+func firstLine(failures []string) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	line, _, _ := strings.Cut(failures[0], "\n")
+	return line
+}
+
+// seconds formats a duration in seconds, as JUnit reports time.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
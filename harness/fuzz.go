@@ -0,0 +1,246 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuzzConfig holds the configuration for fuzzing.
+type FuzzConfig struct {
+	HostPath   string // The host under test
+	OraclePath string // The reference host, built from oracle/ on the real TCL
+	Feature    string // One of FuzzFeatures
+	Count      int    // Number of scripts to generate
+	Seed       int64  // Seed for the generator (0 picks one from the clock)
+	CorpusDir  string // Where divergences are added as test cases ("" to not add them)
+	Output     io.Writer
+	ErrOutput  io.Writer
+}
+
+// minimizeBudget bounds the number of scripts run to minimize one
+// divergence.
+const minimizeBudget = 200
+
+// Fuzz generates random scripts for a feature from a grammar, runs each on
+// the host and on the oracle, and reports the scripts whose outcomes
+// differ. Each divergence is minimized, by dropping statements and
+// replacing parts of the script with simpler ones while the outcomes still
+// differ, and added to the corpus as a test case expecting the oracle's
+// outcome, so that harness run fails until the host agrees.
+// Returns 0 if there were no divergences, 1 otherwise.
+func Fuzz(cfg FuzzConfig) int {
+	if !slices.Contains(FuzzFeatures, cfg.Feature) {
+		fmt.Fprintf(cfg.ErrOutput, "error: unknown feature %q: must be %s\n", cfg.Feature, strings.Join(FuzzFeatures, ", "))
+		return 1
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Fprintf(cfg.Output, "fuzzing %s with seed %d\n", cfg.Feature, seed)
+
+	host := NewRunner(cfg.HostPath, cfg.Output)
+	oracle := NewRunner(cfg.OraclePath, cfg.Output)
+	gen := &fuzzGen{rng: rand.New(rand.NewSource(seed)), feature: cfg.Feature}
+	divergences := 0
+
+	for n := 1; n <= cfg.Count; n++ {
+		stmts := gen.script()
+		d, err := compareHosts(host, oracle, renderScript(stmts))
+		if err != nil {
+			fmt.Fprintf(cfg.ErrOutput, "error: %v\n", err)
+			return 1
+		}
+		if d == nil {
+			continue
+		}
+		divergences++
+		original, originalDiff := renderScript(stmts), d
+
+		tries := 0
+		stmts = minimize(stmts, func(stmts []*fuzzNode) bool {
+			if tries++; tries > minimizeBudget {
+				return false
+			}
+			d, err := compareHosts(host, oracle, renderScript(stmts))
+			return err == nil && d != nil
+		})
+		script := renderScript(stmts)
+		if d, err = compareHosts(host, oracle, script); err != nil || d == nil {
+			// Scripts that run into the timeout may not diverge every time
+			script, d = original, originalDiff
+		}
+
+		fmt.Fprintf(cfg.Output, "\nDIVERGENCE: script %d\n", n)
+		fmt.Fprintf(cfg.Output, "  script:\n    %s\n", indentScript(script))
+		for _, diff := range d.diffs {
+			fmt.Fprintf(cfg.Output, "  %s\n", diff)
+		}
+		if cfg.CorpusDir == "" {
+			continue
+		}
+		name := fmt.Sprintf("%s seed %d script %d", cfg.Feature, seed, n)
+		path, added, err := addToCorpus(cfg.CorpusDir, cfg.Feature, name, script, d.want)
+		if err != nil {
+			fmt.Fprintf(cfg.ErrOutput, "error: %v\n", err)
+			return 1
+		}
+		if added {
+			fmt.Fprintf(cfg.Output, "  added to %s as %q\n", path, name)
+		}
+	}
+
+	fmt.Fprintf(cfg.Output, "\n%d scripts, %d divergences\n", cfg.Count, divergences)
+	if divergences > 0 {
+		return 1
+	}
+	return 0
+}
+
+// divergence describes a script the host and the oracle disagree on.
+type divergence struct {
+	want  ActualResult // What the oracle did
+	got   ActualResult // What the host did
+	diffs []string
+}
+
+// compareHosts runs script on both hosts and returns how their outcomes
+// differ, or nil if they agree.
+func compareHosts(host, oracle *Runner, script string) (*divergence, error) {
+	want, err := oracle.execute(script, DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("oracle: %v", err)
+	}
+	got, err := host.execute(script, DefaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("host: %v", err)
+	}
+	d := &divergence{want: want, got: got}
+	mismatch := func(what, want, got string) {
+		if want != got {
+			d.diffs = append(d.diffs, fmt.Sprintf("%s mismatch:\n    tclsh:   %q\n    feather: %q", what, want, got))
+		}
+	}
+	mismatch("stdout", want.Stdout, got.Stdout)
+	mismatch("stderr", want.Stderr, got.Stderr)
+	mismatch("exit code", strconv.Itoa(want.ExitCode), strconv.Itoa(got.ExitCode))
+	mismatch("return", want.Return, got.Return)
+	mismatch("result", want.Result, got.Result)
+	mismatch("error", want.Error, got.Error)
+	if len(d.diffs) == 0 {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// renderScript returns the text of a script, one statement per line.
+func renderScript(stmts []*fuzzNode) string {
+	lines := make([]string, len(stmts))
+	for i, s := range stmts {
+		lines[i] = s.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// minimize returns a smaller script that still diverges: it drops the
+// statements that are not needed, then replaces nodes with a descendant
+// or leaf of the same kind until no replacement keeps the divergence.
+func minimize(stmts []*fuzzNode, diverges func([]*fuzzNode) bool) []*fuzzNode {
+	for i := 0; i < len(stmts) && len(stmts) > 1; {
+		without := slices.Delete(slices.Clone(stmts), i, i+1)
+		if diverges(without) {
+			stmts = without
+		} else {
+			i++
+		}
+	}
+	for simplify(stmts, diverges) {
+	}
+	return stmts
+}
+
+// simplify makes the first replacement that keeps the script diverging,
+// and reports whether it found one.
+func simplify(stmts []*fuzzNode, diverges func([]*fuzzNode) bool) bool {
+	for _, n := range descendants(stmts) {
+		for _, c := range replacements(n) {
+			saved := *n
+			*n = *c
+			if diverges(stmts) {
+				return true
+			}
+			*n = saved
+		}
+	}
+	return false
+}
+
+// descendants returns the nodes under roots, and the roots, breadth first.
+func descendants(roots []*fuzzNode) []*fuzzNode {
+	nodes := slices.Clone(roots)
+	for i := 0; i < len(nodes); i++ {
+		nodes = append(nodes, nodes[i].kids...)
+	}
+	return nodes
+}
+
+// replacements returns the simpler nodes that could stand in for n.
+func replacements(n *fuzzNode) []*fuzzNode {
+	var out []*fuzzNode
+	for _, d := range descendants(n.kids) {
+		if d.kind == n.kind {
+			out = append(out, d)
+		}
+	}
+	if text, ok := simplestLeaf[n.kind]; ok && n.String() != text {
+		out = append(out, leaf(n.kind, text))
+	}
+	return out
+}
+
+// addToCorpus adds a test case expecting the oracle's outcome to the
+// corpus file for feature, unless the file already has the script. It
+// returns the path of the file and whether the case was added.
+func addToCorpus(dir, feature, name, script string, want ActualResult) (string, bool, error) {
+	path := filepath.Join(dir, feature+".html")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		data = []byte(fmt.Sprintf("<test-suite name=\"fuzz-%s\">\n</test-suite>\n", feature))
+	} else if err != nil {
+		return path, false, err
+	}
+	content := string(data)
+	if strings.Contains(content, "<script>"+script+"</script>") {
+		return path, false, nil
+	}
+	end := strings.LastIndex(content, "</test-suite>")
+	if end < 0 {
+		return path, false, fmt.Errorf("%s: no </test-suite> element", path)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  <test-case name=\"%s\">\n", html.EscapeString(name))
+	fmt.Fprintf(&b, "    <script>%s</script>\n", script)
+	fmt.Fprintf(&b, "    <return>%s</return>\n", html.EscapeString(want.Return))
+	fmt.Fprintf(&b, "    <error>%s</error>\n", html.EscapeString(want.Error))
+	fmt.Fprintf(&b, "    <stdout>%s</stdout>\n", html.EscapeString(want.Stdout))
+	fmt.Fprintf(&b, "    <stderr>%s</stderr>\n", html.EscapeString(want.Stderr))
+	fmt.Fprintf(&b, "    <exit-code>%d</exit-code>\n", want.ExitCode)
+	fmt.Fprintf(&b, "  </test-case>\n")
+	content = content[:end] + b.String() + content[end:]
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return path, false, err
+	}
+	return path, true, os.WriteFile(path, []byte(content), 0644)
+}
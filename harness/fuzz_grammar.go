@@ -0,0 +1,321 @@
+package harness
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// FuzzFeatures are the features Fuzz can generate scripts for.
+var FuzzFeatures = []string{"expr", "list", "string", "all"}
+
+// Kinds of fuzzNode, which say what a node can stand for.
+const (
+	kindStmt  = "stmt"  // a command whose result is printed
+	kindNum   = "num"   // an operand of an expression
+	kindSmall = "small" // a small non-negative integer, such as a repeat count
+	kindIndex = "index" // a list or string index
+	kindStr   = "str"   // a word holding a string
+	kindList  = "list"  // a word holding a list
+	kindCmd   = "cmd"   // a command, inside brackets or printed by a statement
+)
+
+// fuzzNode is a piece of a generated script. Its text is format with each
+// %s replaced by the text of a child in turn. The minimizer replaces a node
+// only with a descendant or leaf of the same kind.
+type fuzzNode struct {
+	kind   string
+	format string
+	kids   []*fuzzNode
+}
+
+// String returns the text of the node.
+func (n *fuzzNode) String() string {
+	args := make([]any, len(n.kids))
+	for i, k := range n.kids {
+		args[i] = k.String()
+	}
+	return fmt.Sprintf(n.format, args...)
+}
+
+// leaf returns a node of the given kind with fixed text.
+func leaf(kind, text string) *fuzzNode {
+	return &fuzzNode{kind: kind, format: strings.ReplaceAll(text, "%", "%%")}
+}
+
+// node returns a node of the given kind built from its children.
+func node(kind, format string, kids ...*fuzzNode) *fuzzNode {
+	return &fuzzNode{kind: kind, format: format, kids: kids}
+}
+
+// simplestLeaf is the leaf the minimizer tries in place of a node of each kind.
+var simplestLeaf = map[string]string{
+	kindNum:   "1",
+	kindSmall: "1",
+	kindIndex: "0",
+	kindStr:   "a",
+	kindList:  "{a b}",
+}
+
+// Values the generator draws literals from.
+var (
+	fuzzNumbers = []string{
+		"0", "1", "2", "-1", "7", "10", "255", "-128", "0x1f", "0b101", "0o17",
+		"9223372036854775807", "-9223372036854775808", "18446744073709551616",
+		"1.5", "-0.5", "0.1", "1e3", "2.5e-3", "1e308", "0.0",
+	}
+	fuzzIndices = []string{"0", "1", "2", "-1", "end", "end-1", "end+1", "end-0", "1+1", "end-2"}
+	fuzzWords   = []string{
+		"", "a", "abc", "b c", "A", "Abc", "ä", "ß", "x y z", "{", "}", "\\", "\"",
+		"*", "?", "[", "]", "$", ";", "a,b", ",", "  padded  ", "0", "1", "-1", "3.5", "10",
+		"a{b", "{a b}", "tab\there", "line\nbreak", "a*c", "[ab]c",
+	}
+	fuzzBinaryOps = []string{
+		"+", "-", "*", "/", "%", "&", "|", "^", "&&", "||", "<", "<=", ">", ">=", "==", "!=",
+		"eq", "ne",
+	}
+	fuzzFunctions = []string{"abs", "int", "double", "round", "wide", "entier", "sqrt", "bool"}
+)
+
+// fuzzGen generates random scripts for a feature.
+type fuzzGen struct {
+	rng     *rand.Rand
+	feature string
+}
+
+// script returns the statements of a new script.
+func (g *fuzzGen) script() []*fuzzNode {
+	stmts := make([]*fuzzNode, 1+g.rng.Intn(4))
+	for i := range stmts {
+		stmts[i] = g.stmt()
+	}
+	return stmts
+}
+
+// stmt returns a statement that prints the result of a command.
+func (g *fuzzGen) stmt() *fuzzNode {
+	feature := g.feature
+	if feature == "all" {
+		feature = FuzzFeatures[g.rng.Intn(len(FuzzFeatures)-1)]
+	}
+	switch feature {
+	case "expr":
+		return node(kindStmt, "puts [expr {%s}]", g.num(3))
+	case "list":
+		return node(kindStmt, "puts [%s]", g.listCmd(2))
+	default:
+		return node(kindStmt, "puts [%s]", g.stringCmd(2))
+	}
+}
+
+// choose returns one of options at random.
+func (g *fuzzGen) choose(options []string) string {
+	return options[g.rng.Intn(len(options))]
+}
+
+// num returns an expression operand nested at most depth deep.
+func (g *fuzzGen) num(depth int) *fuzzNode {
+	if depth == 0 || g.rng.Intn(3) == 0 {
+		return leaf(kindNum, g.choose(fuzzNumbers))
+	}
+	switch g.rng.Intn(7) {
+	case 0, 1, 2:
+		op := strings.ReplaceAll(g.choose(fuzzBinaryOps), "%", "%%")
+		return node(kindNum, "(%s "+op+" %s)", g.num(depth-1), g.num(depth-1))
+	case 3:
+		// Exponents and shifts are kept small so that results stay small
+		op := g.choose([]string{"**", "<<", ">>"})
+		return node(kindNum, "(%s "+op+" %s)", g.num(depth-1), g.small())
+	case 4:
+		op := g.choose([]string{"-", "!", "~", "+"})
+		return node(kindNum, op+"(%s)", g.num(depth-1))
+	case 5:
+		return node(kindNum, "(%s ? %s : %s)", g.num(depth-1), g.num(depth-1), g.num(depth-1))
+	default:
+		if g.rng.Intn(3) == 0 {
+			fn := g.choose([]string{"max", "min", "fmod", "pow", "hypot"})
+			return node(kindNum, fn+"(%s, %s)", g.num(depth-1), g.num(depth-1))
+		}
+		return node(kindNum, g.choose(fuzzFunctions)+"(%s)", g.num(depth-1))
+	}
+}
+
+// small returns a small non-negative integer.
+func (g *fuzzGen) small() *fuzzNode {
+	return leaf(kindSmall, fmt.Sprint(g.rng.Intn(6)))
+}
+
+// index returns a list or string index.
+func (g *fuzzGen) index() *fuzzNode {
+	return leaf(kindIndex, g.choose(fuzzIndices))
+}
+
+// str returns a word holding a string, possibly the result of a string
+// command nested at most depth deep.
+func (g *fuzzGen) str(depth int) *fuzzNode {
+	if depth > 0 && g.rng.Intn(4) == 0 {
+		return node(kindStr, "[%s]", g.stringCmd(depth-1))
+	}
+	return leaf(kindStr, fuzzQuote(g.choose(fuzzWords)))
+}
+
+// list returns a word holding a list, possibly the result of a list
+// command nested at most depth deep. Some literal lists are malformed.
+func (g *fuzzGen) list(depth int) *fuzzNode {
+	if depth > 0 && g.rng.Intn(3) == 0 {
+		return node(kindList, "[%s]", g.listCmd(depth-1))
+	}
+	elems := make([]string, g.rng.Intn(5))
+	for i := range elems {
+		elems[i] = g.choose(fuzzWords)
+		if g.rng.Intn(4) != 0 {
+			elems[i] = listElement(elems[i])
+		}
+	}
+	return leaf(kindList, fuzzQuote(strings.Join(elems, " ")))
+}
+
+// listCmd returns a command that works on lists.
+func (g *fuzzGen) listCmd(depth int) *fuzzNode {
+	switch g.rng.Intn(14) {
+	case 0:
+		return node(kindCmd, "llength %s", g.list(depth))
+	case 1:
+		return node(kindCmd, "lindex %s %s", g.list(depth), g.index())
+	case 2:
+		return node(kindCmd, "lrange %s %s %s", g.list(depth), g.index(), g.index())
+	case 3:
+		return node(kindCmd, "lreverse %s", g.list(depth))
+	case 4:
+		opt := g.choose([]string{"", " -unique", " -decreasing", " -integer", " -dictionary", " -nocase"})
+		return node(kindCmd, "lsort"+opt+" %s", g.list(depth))
+	case 5:
+		opt := g.choose([]string{"", " -all", " -exact", " -glob", " -inline", " -not"})
+		return node(kindCmd, "lsearch"+opt+" %s %s", g.list(depth), g.str(depth))
+	case 6:
+		return node(kindCmd, "join %s %s", g.list(depth), g.str(depth))
+	case 7:
+		return node(kindCmd, "concat %s %s", g.list(depth), g.list(depth))
+	case 8:
+		return node(kindCmd, "linsert %s %s %s", g.list(depth), g.index(), g.str(depth))
+	case 9:
+		return node(kindCmd, "lreplace %s %s %s %s", g.list(depth), g.index(), g.index(), g.str(depth))
+	case 10:
+		return node(kindCmd, "lrepeat %s %s", g.small(), g.str(depth))
+	case 11:
+		return node(kindCmd, "list %s %s %s", g.str(depth), g.list(depth), g.str(depth))
+	case 12:
+		return node(kindCmd, "split %s %s", g.str(depth), g.str(depth))
+	default:
+		return node(kindCmd, "lindex %s %s %s", g.list(depth), g.index(), g.index())
+	}
+}
+
+// stringCmd returns a command that works on strings.
+func (g *fuzzGen) stringCmd(depth int) *fuzzNode {
+	switch g.rng.Intn(16) {
+	case 0:
+		op := g.choose([]string{"length", "toupper", "tolower", "totitle", "reverse", "trim", "trimleft", "trimright"})
+		return node(kindCmd, "string "+op+" %s", g.str(depth))
+	case 1:
+		return node(kindCmd, "string index %s %s", g.str(depth), g.index())
+	case 2:
+		return node(kindCmd, "string range %s %s %s", g.str(depth), g.index(), g.index())
+	case 3:
+		op := g.choose([]string{"first", "last"})
+		return node(kindCmd, "string "+op+" %s %s", g.str(depth), g.str(depth))
+	case 4:
+		return node(kindCmd, "string repeat %s %s", g.str(depth), g.small())
+	case 5:
+		op := g.choose([]string{"trim", "trimleft", "trimright"})
+		return node(kindCmd, "string "+op+" %s %s", g.str(depth), g.str(depth))
+	case 6:
+		opt := g.choose([]string{"", " -nocase"})
+		return node(kindCmd, "string match"+opt+" %s %s", g.str(depth), g.str(depth))
+	case 7:
+		op := g.choose([]string{"equal", "compare", "equal -nocase", "compare -nocase"})
+		return node(kindCmd, "string "+op+" %s %s", g.str(depth), g.str(depth))
+	case 8:
+		return node(kindCmd, "string replace %s %s %s %s", g.str(depth), g.index(), g.index(), g.str(depth))
+	case 9:
+		class := g.choose([]string{"integer", "double", "alpha", "digit", "space", "upper", "boolean", "list"})
+		return node(kindCmd, "string is "+class+" %s", g.str(depth))
+	case 10:
+		return node(kindCmd, "string map %s %s", g.list(depth), g.str(depth))
+	case 11:
+		return node(kindCmd, "string cat %s %s", g.str(depth), g.str(depth))
+	case 12:
+		return node(kindCmd, "string range %s %s end", g.str(depth), g.index())
+	case 13:
+		return node(kindCmd, "string first %s %s %s", g.str(depth), g.str(depth), g.index())
+	case 14:
+		return node(kindCmd, "string is integer -strict %s", g.str(depth))
+	default:
+		return node(kindCmd, "string index %s end", g.str(depth))
+	}
+}
+
+// fuzzQuote returns s as a word that a script reads back as s, with
+// backslashes before the characters that are special in words.
+func fuzzQuote(s string) string {
+	if s == "" {
+		return "{}"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case ' ', '\\', '{', '}', '[', ']', '$', '"', ';':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// listElement returns s quoted as an element of a list, in braces if it
+// has characters that would split it or change its meaning.
+func listElement(s string) string {
+	if s == "" {
+		return "{}"
+	}
+	if !strings.ContainsAny(s, " \t\n{}\\\"[]$;") {
+		return s
+	}
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth < 0 {
+			break
+		}
+	}
+	if depth == 0 && !strings.HasSuffix(s, "\\") {
+		return "{" + s + "}"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case slices.Contains([]rune(" {}\\\"[]$;"), r):
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -2,9 +2,12 @@ module github.com/feather-lang/feather/harness
 
 go 1.24.3
 
+require (
+	github.com/spf13/cobra v1.10.2
+	golang.org/x/net v0.48.0
+)
+
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.10.2 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
-	golang.org/x/net v0.48.0 // indirect
 )
@@ -17,6 +17,8 @@ type Config struct {
 	Output      io.Writer
 	ErrOutput   io.Writer
 	Verbose     bool
+	Parallel    int    // Number of test files run at once (0 or 1 runs them serially)
+	Format      string // Output format: text (the default), junit, json or tap
 }
 
 // testFullName returns the display name for a test case: "suite > test"
@@ -85,42 +87,30 @@ func Run(cfg Config) int {
 		return 1
 	}
 
+	if _, err := regexp.Compile(cfg.NamePattern); err != nil {
+		fmt.Fprintf(cfg.ErrOutput, "error: invalid pattern: %v\n", err)
+		return 1
+	}
+
+	reporter, err := NewFormatReporter(cfg.Output, cfg.Format, cfg.Verbose)
+	if err != nil {
+		fmt.Fprintf(cfg.ErrOutput, "error: %v\n", err)
+		return 1
+	}
+
 	runner := NewRunner(cfg.HostPath, cfg.Output)
-	reporter := NewReporter(cfg.Output, cfg.Verbose)
 	var allResults []TestResult
 	hasErrors := false
 
-	for _, testFile := range testFiles {
-		suite, err := ParseFile(testFile)
-		if err != nil {
-			fmt.Fprintf(cfg.ErrOutput, "error parsing %s: %v\n", testFile, err)
+	runFiles(cfg, runner, testFiles, func(run fileRun) {
+		if run.Err != nil {
+			fmt.Fprintf(cfg.ErrOutput, "error parsing %s: %v\n", run.File, run.Err)
 			hasErrors = true
-			continue
+			return
 		}
-
-		// Filter test cases by name pattern
-		var filteredCases []TestCase
-		for i := range suite.Cases {
-			tc := &suite.Cases[i]
-			fullName := testFullName(suite, tc)
-			matches, err := matchesFilter(cfg, fullName)
-			if err != nil {
-				fmt.Fprintf(cfg.ErrOutput, "error: invalid pattern: %v\n", err)
-				return 1
-			}
-			if matches {
-				filteredCases = append(filteredCases, *tc)
-			}
-		}
-		suite.Cases = filteredCases
-
-		results := runner.RunSuite(suite)
-		allResults = append(allResults, results...)
-
-		for _, result := range results {
-			reporter.ReportResult(testFile, result)
-		}
-	}
+		allResults = append(allResults, run.Results...)
+		reporter.ReportSuite(run.File, run.Suite, run.Results)
+	})
 
 	summary := Summarize(allResults)
 	reporter.ReportSummary(summary)
@@ -131,6 +121,61 @@ func Run(cfg Config) int {
 	return 0
 }
 
+// fileRun holds the results of running the tests in one file.
+type fileRun struct {
+	File    string
+	Suite   *TestSuite
+	Results []TestResult
+	Err     error // The file could not be parsed
+}
+
+// runFiles runs the tests in each file, cfg.Parallel files at a time, and
+// calls report with the results of each file in the order of files.
+func runFiles(cfg Config, runner *Runner, files []string, report func(fileRun)) {
+	runs := make([]chan fileRun, len(files))
+	for i := range runs {
+		runs[i] = make(chan fileRun, 1)
+	}
+	jobs := make(chan int)
+	for range min(max(cfg.Parallel, 1), len(files)) {
+		go func() {
+			for i := range jobs {
+				runs[i] <- runFile(cfg, runner, files[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	for _, run := range runs {
+		report(<-run)
+	}
+}
+
+// runFile parses a test file and runs the test cases that match the
+// configured name pattern.
+func runFile(cfg Config, runner *Runner, testFile string) fileRun {
+	suite, err := ParseFile(testFile)
+	if err != nil {
+		return fileRun{File: testFile, Err: err}
+	}
+
+	// Filter test cases by name pattern
+	var filteredCases []TestCase
+	for i := range suite.Cases {
+		tc := &suite.Cases[i]
+		if matches, _ := matchesFilter(cfg, testFullName(suite, tc)); matches {
+			filteredCases = append(filteredCases, *tc)
+		}
+	}
+	suite.Cases = filteredCases
+
+	return fileRun{File: testFile, Suite: suite, Results: runner.RunSuite(suite)}
+}
+
 // Update runs tests against the host and updates test file expectations.
 // Returns 0 on success, 1 on error.
 func Update(cfg Config) int {
@@ -6,10 +6,30 @@ import (
 	"strings"
 )
 
+// Output formats accepted by NewFormatReporter.
+const (
+	FormatText  = "text"
+	FormatJUnit = "junit"
+	FormatJSON  = "json"
+	FormatTAP   = "tap"
+)
+
 // Reporter outputs test results.
 type Reporter struct {
 	Out     io.Writer
 	Verbose bool
+	Format  string // One of the Format constants; empty means FormatText
+
+	// Results held for the structured formats, which are written by
+	// ReportSummary once every suite has run
+	suites []reportedSuite
+}
+
+// reportedSuite holds the results of one test file for a structured format.
+type reportedSuite struct {
+	File    string
+	Name    string
+	Results []TestResult
 }
 
 // NewReporter creates a reporter that writes to the given output.
@@ -17,14 +37,39 @@ func NewReporter(out io.Writer, verbose bool) *Reporter {
 	return &Reporter{Out: out, Verbose: verbose}
 }
 
+// NewFormatReporter creates a reporter that writes results to the given
+// output in format, one of the Format constants.
+func NewFormatReporter(out io.Writer, format string, verbose bool) (*Reporter, error) {
+	switch format {
+	case "", FormatText, FormatJUnit, FormatJSON, FormatTAP:
+		return &Reporter{Out: out, Verbose: verbose, Format: format}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q: must be text, junit, json or tap", format)
+}
+
+// ReportSuite outputs the results of the tests in one file.
+func (r *Reporter) ReportSuite(testFile string, suite *TestSuite, results []TestResult) {
+	if r.Format != "" && r.Format != FormatText {
+		r.suites = append(r.suites, reportedSuite{File: testFile, Name: suite.Name, Results: results})
+		return
+	}
+	for _, result := range results {
+		r.ReportResult(testFile, result)
+	}
+}
+
 // ReportResult outputs the result of a single test.
 func (r *Reporter) ReportResult(testFile string, result TestResult) {
 	if result.Passed {
 		if r.Verbose {
-			fmt.Fprintf(r.Out, "PASS: %s: %s\n", testFile, result.TestCase.Name)
+			fmt.Fprintf(r.Out, "PASS: %s: %s (%s)\n", testFile, result.TestCase.Name, formatDuration(result.Duration))
 		}
 	} else {
-		fmt.Fprintf(r.Out, "FAIL: %s: %s\n", testFile, result.TestCase.Name)
+		if r.Verbose {
+			fmt.Fprintf(r.Out, "FAIL: %s: %s (%s)\n", testFile, result.TestCase.Name, formatDuration(result.Duration))
+		} else {
+			fmt.Fprintf(r.Out, "FAIL: %s: %s\n", testFile, result.TestCase.Name)
+		}
 		for _, failure := range result.Failures {
 			fmt.Fprintf(r.Out, "  %s\n", failure)
 		}
@@ -33,9 +78,19 @@ func (r *Reporter) ReportResult(testFile string, result TestResult) {
 	}
 }
 
-// ReportSummary outputs the final summary.
+// ReportSummary outputs the final summary. For the structured formats it
+// writes the whole report.
 func (r *Reporter) ReportSummary(summary Summary) {
-	fmt.Fprintf(r.Out, "\n%d tests, %d passed, %d failed\n", summary.Total, summary.Passed, summary.Failed)
+	switch r.Format {
+	case FormatJUnit:
+		writeJUnit(r.Out, r.suites, summary)
+	case FormatJSON:
+		writeJSON(r.Out, r.suites, summary)
+	case FormatTAP:
+		writeTAP(r.Out, r.suites, summary)
+	default:
+		fmt.Fprintf(r.Out, "\n%d tests, %d passed, %d failed\n", summary.Total, summary.Passed, summary.Failed)
+	}
 }
 
 // indentScript adds indentation to each line of a multi-line script.
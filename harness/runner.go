@@ -18,6 +18,7 @@ type TestResult struct {
 	Passed   bool
 	Actual   ActualResult
 	Failures []string
+	Duration time.Duration // How long the host took to run the test
 }
 
 // ActualResult captures what actually happened when the test ran.
@@ -62,11 +63,13 @@ func (r *Runner) RunTest(tc TestCase) TestResult {
 
 // runTestWithTimeout executes a test case with timeout inheritance.
 // Timeout priority: test case > suite > DefaultTimeout
-func (r *Runner) runTestWithTimeout(tc TestCase, suiteTimeout time.Duration) TestResult {
-	result := TestResult{
+func (r *Runner) runTestWithTimeout(tc TestCase, suiteTimeout time.Duration) (result TestResult) {
+	result = TestResult{
 		TestCase: tc,
 		Passed:   true,
 	}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
 
 	// Determine effective timeout: test > suite > default
 	timeout := DefaultTimeout
@@ -77,64 +80,13 @@ func (r *Runner) runTestWithTimeout(tc TestCase, suiteTimeout time.Duration) Tes
 		timeout = tc.Timeout
 	}
 
-	// Create a pipe for the harness communication channel (fd 3)
-	harnessReader, harnessWriter, err := os.Pipe()
+	actual, err := r.execute(tc.Script, timeout)
+	result.Actual = actual
 	if err != nil {
 		result.Passed = false
-		result.Failures = append(result.Failures, fmt.Sprintf("failed to create pipe: %v", err))
+		result.Failures = append(result.Failures, err.Error())
 		return result
 	}
-	defer harnessReader.Close()
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, r.HostPath)
-	cmd.Stdin = strings.NewReader(tc.Script)
-	cmd.Env = append(os.Environ(), "FEATHER_IN_HARNESS=1")
-
-	// Set up the extra file descriptor (will be fd 3 in the child)
-	cmd.ExtraFiles = []*os.File{harnessWriter}
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err = cmd.Start()
-	if err != nil {
-		harnessWriter.Close()
-		result.Passed = false
-		result.Failures = append(result.Failures, fmt.Sprintf("failed to start host: %v", err))
-		return result
-	}
-
-	// Close the write end in the parent so we can read EOF
-	harnessWriter.Close()
-
-	// Read harness output
-	harnessOutput := parseHarnessOutput(harnessReader)
-
-	err = cmd.Wait()
-
-	result.Actual.Stdout = normalizeLines(stdout.String())
-	result.Actual.Stderr = normalizeLines(stderr.String())
-	result.Actual.Return = harnessOutput.Return
-	result.Actual.Result = harnessOutput.Result
-	result.Actual.Error = harnessOutput.Error
-
-	if err != nil {
-		// Check if the error was due to context timeout
-		if ctx.Err() == context.DeadlineExceeded {
-			result.Actual.ExitCode = 124 // Standard timeout exit code
-		} else if exitErr, ok := err.(*exec.ExitError); ok {
-			result.Actual.ExitCode = exitErr.ExitCode()
-		} else {
-			result.Passed = false
-			result.Failures = append(result.Failures, fmt.Sprintf("failed to run host: %v", err))
-			return result
-		}
-	}
 
 	// Compare results
 	if tc.StdoutSet && tc.Stdout != result.Actual.Stdout {
@@ -179,6 +131,66 @@ func (r *Runner) runTestWithTimeout(tc TestCase, suiteTimeout time.Duration) Tes
 	return result
 }
 
+// execute runs script on the host and returns what happened. The error
+// reports a host that could not be run at all.
+func (r *Runner) execute(script string, timeout time.Duration) (ActualResult, error) {
+	var actual ActualResult
+
+	// Create a pipe for the harness communication channel (fd 3)
+	harnessReader, harnessWriter, err := os.Pipe()
+	if err != nil {
+		return actual, fmt.Errorf("failed to create pipe: %v", err)
+	}
+	defer harnessReader.Close()
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.HostPath)
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Env = append(os.Environ(), "FEATHER_IN_HARNESS=1")
+
+	// Set up the extra file descriptor (will be fd 3 in the child)
+	cmd.ExtraFiles = []*os.File{harnessWriter}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Start()
+	if err != nil {
+		harnessWriter.Close()
+		return actual, fmt.Errorf("failed to start host: %v", err)
+	}
+
+	// Close the write end in the parent so we can read EOF
+	harnessWriter.Close()
+
+	// Read harness output
+	harnessOutput := parseHarnessOutput(harnessReader)
+
+	err = cmd.Wait()
+
+	actual.Stdout = normalizeLines(stdout.String())
+	actual.Stderr = normalizeLines(stderr.String())
+	actual.Return = harnessOutput.Return
+	actual.Result = harnessOutput.Result
+	actual.Error = harnessOutput.Error
+
+	if err != nil {
+		// Check if the error was due to context timeout
+		if ctx.Err() == context.DeadlineExceeded {
+			actual.ExitCode = 124 // Standard timeout exit code
+		} else if exitErr, ok := err.(*exec.ExitError); ok {
+			actual.ExitCode = exitErr.ExitCode()
+		} else {
+			return actual, fmt.Errorf("failed to run host: %v", err)
+		}
+	}
+	return actual, nil
+}
+
 // harnessOutput holds parsed output from the harness channel
 type harnessOutput struct {
 	Return string // TCL_OK, TCL_ERROR, etc.
@@ -0,0 +1,838 @@
+// Package lineedit implements the interactive line editor of the feather
+// command line tools: editing keys, history with reverse search, and a
+// completion popup.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Candidate represents a single completion suggestion.
+type Candidate struct {
+	Text string
+	Type string
+	Help string
+	Name string // for arg-placeholder type
+}
+
+// keyResult holds a key press result
+type keyResult struct {
+	key string
+	err error
+}
+
+// Editor provides an interactive line editor with completion support.
+type Editor struct {
+	// Complete returns the completions for input, the text before the
+	// cursor including any accumulated multi-line input. If nil, Tab does
+	// nothing.
+	Complete func(input string) []Candidate
+
+	oldState *term.State
+	fd       int
+
+	// Current line state
+	line   []rune
+	cursor int
+
+	// Completion state
+	completions    []Candidate
+	selected       int
+	showPopup      bool
+	popupLineCount int // Number of popup lines currently displayed
+
+	// Multi-line input accumulator
+	inputBuffer string
+
+	// History, oldest first. histPos is the entry being shown while
+	// browsing, len(history) when not browsing; histLine is the line that
+	// was being edited when browsing started.
+	history  []string
+	histPos  int
+	histLine []rune
+
+	// Reverse search state
+	searching    bool
+	query        []rune
+	searchPos    int  // index in history of the current match
+	searchFailed bool // no entry matches the query
+	searchSaved  []rune
+
+	// Pending input bytes (for when we read multiple bytes at once)
+	pendingInput []byte
+
+	// Persistent key reader
+	keyChan       chan keyResult
+	readerRunning bool
+}
+
+// New creates a new line editor reading from standard input.
+func New() *Editor {
+	return &Editor{fd: int(os.Stdin.Fd())}
+}
+
+// enterRawMode puts the terminal in raw mode.
+func (e *Editor) enterRawMode() error {
+	oldState, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return err
+	}
+	e.oldState = oldState
+	return nil
+}
+
+// exitRawMode restores the terminal to its original state.
+func (e *Editor) exitRawMode() {
+	if e.oldState != nil {
+		term.Restore(e.fd, e.oldState)
+		e.oldState = nil
+	}
+}
+
+// getTerminalWidth returns the terminal width or a default.
+func (e *Editor) getTerminalWidth() int {
+	width, _, err := term.GetSize(e.fd)
+	if err != nil || width <= 0 {
+		return 80
+	}
+	// Safety margin - some terminals report width including scrollbar area
+	if width > 80 {
+		return width - 1
+	}
+	return width
+}
+
+// debugLog writes debug info to stderr
+var debugEnabled = os.Getenv("DEBUG_KEYS") == "1"
+
+func debugLog(format string, args ...interface{}) {
+	if debugEnabled {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
+
+// readByte reads a single byte, using pending buffer first
+func (e *Editor) readByte() (byte, error) {
+	if len(e.pendingInput) > 0 {
+		b := e.pendingInput[0]
+		e.pendingInput = e.pendingInput[1:]
+		return b, nil
+	}
+
+	buf := make([]byte, 32)
+	n, err := os.Stdin.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	debugLog("readByte: read %d bytes: %v %q", n, buf[:n], string(buf[:n]))
+
+	// Store all but first byte in pending buffer
+	if n > 1 {
+		e.pendingInput = append(e.pendingInput, buf[1:n]...)
+	}
+	return buf[0], nil
+}
+
+// skipToTerminator skips bytes until we find a CSI sequence terminator (0x40-0x7E)
+func (e *Editor) skipToTerminator() {
+	for {
+		b, err := e.readByte()
+		if err != nil {
+			return
+		}
+		// CSI terminators are in range 0x40-0x7E (@ to ~)
+		if b >= 0x40 && b <= 0x7E {
+			return
+		}
+	}
+}
+
+// readKey reads a single key press, handling escape sequences.
+func (e *Editor) readKey() (key string, err error) {
+	ch, err := e.readByte()
+	if err != nil {
+		return "", err
+	}
+
+	// Handle escape sequences
+	if ch == 0x1b {
+		ch2, err := e.readByte()
+		if err != nil {
+			return "escape", nil
+		}
+		if ch2 == '[' {
+			ch3, err := e.readByte()
+			if err != nil {
+				return "escape", nil
+			}
+			switch ch3 {
+			case 'A':
+				return "up", nil
+			case 'B':
+				return "down", nil
+			case 'C':
+				return "right", nil
+			case 'D':
+				return "left", nil
+			case 'H':
+				return "home", nil
+			case 'F':
+				return "end", nil
+			case 'Z':
+				return "shift-tab", nil
+			case '3':
+				// Delete key: ESC[3~
+				e.readByte() // skip ~
+				return "delete", nil
+			case 'I':
+				// Focus gained - ignore
+				return e.readKey()
+			case 'O':
+				// Focus lost - ignore
+				return e.readKey()
+			}
+			// Handle CSI sequences like ESC[200~ (bracketed paste)
+			if ch3 >= '0' && ch3 <= '9' {
+				// Skip the whole sequence
+				debugLog("readKey: skipping CSI sequence starting with %c", ch3)
+				e.skipToTerminator()
+				return e.readKey()
+			}
+			// Unknown CSI sequence - skip to terminator
+			debugLog("readKey: unknown CSI %c, skipping", ch3)
+			if ch3 < 0x40 || ch3 > 0x7E {
+				e.skipToTerminator()
+			}
+			return e.readKey()
+		}
+		// Unknown escape sequence - treat as escape
+		debugLog("readKey: unknown escape sequence starting with 0x%02x", ch2)
+		return "escape", nil
+	}
+
+	// Control characters
+	switch ch {
+	case 0x01: // Ctrl-A
+		return "home", nil
+	case 0x03: // Ctrl-C
+		return "ctrl-c", nil
+	case 0x04: // Ctrl-D
+		return "ctrl-d", nil
+	case 0x05: // Ctrl-E
+		return "end", nil
+	case 0x07: // Ctrl-G
+		return "ctrl-g", nil
+	case 0x09: // Tab
+		return "tab", nil
+	case 0x0d, 0x0a: // Enter
+		return "enter", nil
+	case 0x0e: // Ctrl-N
+		return "down", nil
+	case 0x10: // Ctrl-P
+		return "up", nil
+	case 0x12: // Ctrl-R
+		return "ctrl-r", nil
+	case 0x7f, 0x08: // Backspace
+		return "backspace", nil
+	case 0x15: // Ctrl-U
+		return "ctrl-u", nil
+	case 0x17: // Ctrl-W
+		return "ctrl-w", nil
+	}
+
+	return string(ch), nil
+}
+
+// clearLine clears the current line display.
+func (e *Editor) clearLine() {
+	// Move to start of line, clear to end
+	fmt.Print("\r\033[K")
+}
+
+// render displays the current line with prompt and any completion popup.
+// During a reverse search the prompt shows the query instead.
+func (e *Editor) render(prompt string) {
+	if e.searching {
+		prompt = fmt.Sprintf("(reverse-i-search)`%s': ", string(e.query))
+		if e.searchFailed {
+			prompt = "(failed " + prompt[1:]
+		}
+	}
+
+	// STEP 1: Clear any previously displayed popup lines
+	if e.popupLineCount > 0 {
+		for i := 0; i < e.popupLineCount; i++ {
+			fmt.Print("\n\033[2K")
+		}
+		fmt.Printf("\033[%dA\r", e.popupLineCount)
+		e.popupLineCount = 0
+	}
+
+	// STEP 2: Clear and redraw input line
+	fmt.Print("\r\033[K")
+	fmt.Print(prompt)
+	fmt.Print(string(e.line))
+
+	// STEP 3: Draw popup below if active
+	if e.showPopup && len(e.completions) > 0 {
+		e.renderPopup(prompt)
+	}
+
+	// STEP 4: Position cursor on input line
+	fmt.Printf("\r\033[%dC", len(prompt)+e.cursor)
+}
+
+// typeIndicator returns a single-letter type indicator for completion types.
+func typeIndicator(t string) string {
+	switch t {
+	case "arg-placeholder":
+		return "A"
+	case "flag":
+		return "F"
+	case "command":
+		return "C"
+	case "subcommand":
+		return "S"
+	case "value":
+		return "V"
+	default:
+		if len(t) > 0 {
+			return strings.ToUpper(t[:1])
+		}
+		return "?"
+	}
+}
+
+// completionText returns the display text for a completion candidate.
+func completionText(c Candidate) string {
+	if c.Type == "arg-placeholder" && c.Name != "" {
+		return fmt.Sprintf("<%s>", c.Name)
+	}
+	return c.Text
+}
+
+// renderPopup displays the completion popup below the current line.
+func (e *Editor) renderPopup(prompt string) {
+	maxDisplay := min(len(e.completions), 10)
+	termWidth := e.getTerminalWidth()
+
+	// Leave safety margin to prevent wrapping
+	maxLen := termWidth - 2
+	if maxLen < 40 {
+		maxLen = 40
+	}
+
+	e.popupLineCount = maxDisplay
+
+	// Calculate the width needed for the name column based on longest name
+	nameWidth := 0
+	for i := 0; i < maxDisplay; i++ {
+		text := completionText(e.completions[i])
+		if len(text) > nameWidth {
+			nameWidth = len(text)
+		}
+	}
+	// Add 2 for surrounding spaces, cap at reasonable max
+	nameWidth += 2
+	if nameWidth > 30 {
+		nameWidth = 30
+	}
+	if nameWidth < 8 {
+		nameWidth = 8
+	}
+
+	for i := 0; i < maxDisplay; i++ {
+		c := e.completions[i]
+
+		// Move to next line, go to column 1, and clear the line
+		fmt.Print("\n\r\033[K")
+
+		// Build the display line
+		prefix := "  "
+		if i == e.selected {
+			prefix = "> "
+		}
+
+		text := completionText(c)
+		if len(text) > nameWidth-2 {
+			text = text[:nameWidth-5] + "..."
+		}
+
+		// Format: "> name     [T] help..."
+		// Use dynamic width for name column
+		formatStr := fmt.Sprintf("%%s%%-%ds [%%s]", nameWidth)
+		line := fmt.Sprintf(formatStr, prefix, text, typeIndicator(c.Type))
+
+		// Add help text if there's room
+		if c.Help != "" {
+			remaining := maxLen - len(line) - 1
+			if remaining > 10 {
+				help := c.Help
+				if len(help) > remaining {
+					help = help[:remaining-3] + "..."
+				}
+				line += " " + help
+			}
+		}
+
+		// Final truncation safety
+		if len(line) > maxLen {
+			line = line[:maxLen]
+		}
+
+		// Color: inverse for selected, dim for others
+		if i == e.selected {
+			fmt.Printf("\033[7m%s\033[0m", line)
+		} else {
+			fmt.Printf("\033[2m%s\033[0m", line)
+		}
+	}
+
+	// Move cursor back up to the input line
+	if maxDisplay > 0 {
+		fmt.Printf("\033[%dA\r", maxDisplay)
+	}
+}
+
+// clearPopup removes the popup display.
+func (e *Editor) clearPopup() {
+	if e.popupLineCount == 0 {
+		return
+	}
+
+	// Move down to popup area and clear each line
+	for i := 0; i < e.popupLineCount; i++ {
+		fmt.Print("\n\033[2K")
+	}
+	// Move back up
+	fmt.Printf("\033[%dA", e.popupLineCount)
+	fmt.Print("\r")
+	e.popupLineCount = 0
+}
+
+// getCompletions fetches completions for the text before the cursor.
+func (e *Editor) getCompletions() {
+	e.completions = nil
+	if e.Complete == nil {
+		return
+	}
+	// Build the full script including any accumulated multi-line input
+	input := e.inputBuffer
+	if input != "" {
+		input += "\n"
+	}
+	input += string(e.line[:e.cursor])
+
+	debugLog("getCompletions: input=%q", input)
+	e.completions = e.Complete(input)
+}
+
+// applyCompletion inserts the selected completion into the line.
+func (e *Editor) applyCompletion() {
+	if len(e.completions) == 0 || e.selected < 0 || e.selected >= len(e.completions) {
+		return
+	}
+
+	c := e.completions[e.selected]
+	if c.Type == "arg-placeholder" {
+		// Don't insert placeholders, just close popup
+		e.showPopup = false
+		e.completions = nil
+		return
+	}
+
+	// Find the start of the word being completed
+	wordStart := e.cursor
+	for wordStart > 0 && !isWordBreak(e.line[wordStart-1]) {
+		wordStart--
+	}
+
+	// Replace the current word with the completion
+	newLine := make([]rune, 0, len(e.line)+len(c.Text))
+	newLine = append(newLine, e.line[:wordStart]...)
+	newLine = append(newLine, []rune(c.Text)...)
+	if !strings.HasSuffix(c.Text, "::") {
+		newLine = append(newLine, ' ') // Add space after completion, unless inside a namespace
+	}
+	cursor := len(newLine)
+	newLine = append(newLine, e.line[e.cursor:]...)
+
+	e.line = newLine
+	e.cursor = cursor
+
+	e.showPopup = false
+	e.completions = nil
+}
+
+func isWordBreak(r rune) bool {
+	return r == ' ' || r == '\t' || r == ';' || r == '\n' || r == '{' || r == '}' || r == '['
+}
+
+// startKeyReader starts the persistent key reader goroutine if not already running
+func (e *Editor) startKeyReader() {
+	if e.readerRunning {
+		return
+	}
+	e.keyChan = make(chan keyResult, 16) // Larger buffer to prevent blocking
+	e.readerRunning = true
+	go func() {
+		for {
+			key, err := e.readKey()
+			debugLog("readKey returned: %q err=%v", key, err)
+			e.keyChan <- keyResult{key, err}
+			if err != nil {
+				e.readerRunning = false
+				return
+			}
+		}
+	}()
+}
+
+// ReadLine reads a complete line of input with completion support.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if err := e.enterRawMode(); err != nil {
+		return "", err
+	}
+	defer e.exitRawMode()
+
+	// Set up SIGWINCH handler for terminal resize (no-op on Windows)
+	sigwinch, stopSigwinch := setupResizeSignal()
+	defer stopSigwinch()
+
+	// Start the persistent key reader if not already running
+	e.startKeyReader()
+
+	e.line = nil
+	e.cursor = 0
+	e.showPopup = false
+	e.completions = nil
+	e.selected = 0
+	e.histPos = len(e.history)
+	e.searching = false
+
+	e.render(prompt)
+
+	for {
+		// Wait for either a key press or a resize signal
+		var key string
+		var err error
+
+		select {
+		case <-sigwinch:
+			// Terminal resized - just re-render
+			e.render(prompt)
+			continue
+		case kr := <-e.keyChan:
+			key = kr.key
+			err = kr.err
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return "", io.EOF
+			}
+			return "", err
+		}
+
+		debugLog("processing key: %q", key)
+
+		if e.searching && e.searchKey(key) {
+			e.render(prompt)
+			continue
+		}
+
+		switch key {
+		case "enter":
+			if e.showPopup && len(e.completions) > 0 {
+				// Apply selected completion
+				e.applyCompletion()
+				e.render(prompt)
+			} else {
+				e.clearPopup()
+				fmt.Print("\r\n")
+				return string(e.line), nil
+			}
+
+		case "ctrl-c":
+			e.clearPopup()
+			fmt.Print("\r\n")
+			return "", ErrInterrupted
+
+		case "ctrl-d":
+			if len(e.line) == 0 {
+				e.clearPopup()
+				fmt.Print("\r\n")
+				return "", io.EOF
+			}
+			// Delete char at cursor
+			if e.cursor < len(e.line) {
+				e.line = append(e.line[:e.cursor], e.line[e.cursor+1:]...)
+				e.hidePopup()
+			}
+
+		case "tab":
+			if e.showPopup && len(e.completions) > 0 {
+				// Cycle forward through completions
+				e.selected = (e.selected + 1) % len(e.completions)
+			} else {
+				// Get completions
+				e.getCompletions()
+				e.selected = 0
+				e.showPopup = len(e.completions) > 0
+			}
+
+		case "shift-tab":
+			if e.showPopup && len(e.completions) > 0 {
+				// Cycle backward through completions
+				e.selected--
+				if e.selected < 0 {
+					e.selected = len(e.completions) - 1
+				}
+			} else {
+				// Get completions and select last item
+				e.getCompletions()
+				if len(e.completions) > 0 {
+					e.selected = len(e.completions) - 1
+					e.showPopup = true
+				}
+			}
+
+		case "up":
+			if e.showPopup && len(e.completions) > 0 {
+				e.selected--
+				if e.selected < 0 {
+					e.selected = len(e.completions) - 1
+				}
+			} else {
+				e.historyMove(-1)
+			}
+
+		case "down":
+			if e.showPopup && len(e.completions) > 0 {
+				e.selected = (e.selected + 1) % len(e.completions)
+			} else {
+				e.historyMove(1)
+			}
+
+		case "ctrl-r":
+			e.hidePopup()
+			e.searching = true
+			e.searchFailed = false
+			e.query = nil
+			e.searchPos = len(e.history)
+			e.searchSaved = e.line
+
+		case "left":
+			if e.cursor > 0 {
+				e.cursor--
+			}
+			e.hidePopup()
+
+		case "right":
+			if e.cursor < len(e.line) {
+				e.cursor++
+			}
+			e.hidePopup()
+
+		case "home":
+			e.cursor = 0
+			e.hidePopup()
+
+		case "end":
+			e.cursor = len(e.line)
+			e.hidePopup()
+
+		case "backspace":
+			if e.cursor > 0 {
+				e.line = append(e.line[:e.cursor-1], e.line[e.cursor:]...)
+				e.cursor--
+				e.hidePopup()
+			}
+
+		case "delete":
+			if e.cursor < len(e.line) {
+				e.line = append(e.line[:e.cursor], e.line[e.cursor+1:]...)
+				e.hidePopup()
+			}
+
+		case "ctrl-u":
+			// Clear line before cursor
+			e.line = e.line[e.cursor:]
+			e.cursor = 0
+			e.hidePopup()
+
+		case "ctrl-w":
+			// Delete word before cursor
+			newCursor := e.cursor
+			// Skip trailing spaces
+			for newCursor > 0 && e.line[newCursor-1] == ' ' {
+				newCursor--
+			}
+			// Skip word
+			for newCursor > 0 && e.line[newCursor-1] != ' ' {
+				newCursor--
+			}
+			e.line = append(e.line[:newCursor], e.line[e.cursor:]...)
+			e.cursor = newCursor
+			e.hidePopup()
+
+		case "escape":
+			if e.showPopup {
+				e.hidePopup()
+			}
+
+		default:
+			// Insert character
+			if len(key) == 1 {
+				ch := rune(key[0])
+				if ch >= 32 && ch < 127 {
+					newLine := make([]rune, len(e.line)+1)
+					copy(newLine, e.line[:e.cursor])
+					newLine[e.cursor] = ch
+					copy(newLine[e.cursor+1:], e.line[e.cursor:])
+					e.line = newLine
+					e.cursor++
+					e.hidePopup()
+				}
+			}
+		}
+
+		e.render(prompt)
+	}
+}
+
+// hidePopup clears and hides the completion popup.
+func (e *Editor) hidePopup() {
+	if e.showPopup || e.popupLineCount > 0 {
+		e.clearPopup()
+		e.showPopup = false
+		e.completions = nil
+	}
+}
+
+// SetInputBuffer sets the accumulated multi-line input for context.
+func (e *Editor) SetInputBuffer(buf string) {
+	e.inputBuffer = buf
+}
+
+// maxHistory is the number of history entries kept.
+const maxHistory = 1000
+
+// AddHistory appends line to the history, unless it is blank or repeats
+// the latest entry.
+func (e *Editor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" || strings.Contains(line, "\n") {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// LoadHistory appends the entries in the file at path, one per line, to
+// the history.
+func (e *Editor) LoadHistory(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e.AddHistory(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// SaveHistory writes the history to the file at path, one entry per line.
+func (e *Editor) SaveHistory(path string) error {
+	var b strings.Builder
+	for _, line := range e.history {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+// historyMove replaces the line with the history entry delta steps from
+// the one shown. Moving past the newest entry restores the line that was
+// being edited.
+func (e *Editor) historyMove(delta int) {
+	pos := e.histPos + delta
+	if pos < 0 || pos > len(e.history) {
+		return
+	}
+	e.hidePopup()
+	if e.histPos == len(e.history) {
+		e.histLine = e.line
+	}
+	e.histPos = pos
+	if pos == len(e.history) {
+		e.line = e.histLine
+	} else {
+		e.line = []rune(e.history[pos])
+	}
+	e.cursor = len(e.line)
+}
+
+// searchKey handles a key during a reverse search and reports whether it
+// consumed it. Other keys end the search, keeping the match as the line,
+// and are then handled as usual.
+func (e *Editor) searchKey(key string) bool {
+	switch key {
+	case "ctrl-r":
+		e.search(e.searchPos - 1)
+	case "backspace":
+		if len(e.query) > 0 {
+			e.query = e.query[:len(e.query)-1]
+			e.search(len(e.history) - 1)
+		}
+	case "ctrl-g", "escape":
+		e.line = e.searchSaved
+		e.cursor = len(e.line)
+		e.searching = false
+	default:
+		if len(key) == 1 && key[0] >= 32 && key[0] < 127 {
+			e.query = append(e.query, rune(key[0]))
+			e.search(e.searchPos)
+			return true
+		}
+		e.searching = false
+		return false
+	}
+	return true
+}
+
+// search makes the newest history entry at or before index from that
+// contains the query the line.
+func (e *Editor) search(from int) {
+	query := string(e.query)
+	for n := min(from, len(e.history)-1); n >= 0; n-- {
+		if strings.Contains(e.history[n], query) {
+			e.searchPos = n
+			e.searchFailed = false
+			e.line = []rune(e.history[n])
+			e.cursor = len(e.line)
+			return
+		}
+	}
+	e.searchFailed = true
+}
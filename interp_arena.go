@@ -0,0 +1,113 @@
+package feather
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// builderHandleBit marks string builder handles, so that they never alias
+// object handles.
+const builderHandleBit FeatherObj = 1 << 62
+
+// maxRetainedSlots is the largest arena kept for reuse when it is reset.
+// Larger arenas, left behind by an unusually big eval, are released to the
+// garbage collector instead of pinning their memory.
+const maxRetainedSlots = 1 << 16
+
+// handleTable maps handles to values. It is a slice indexed by handle, so
+// looking up a handle is an index operation and adding one is usually an
+// append with no allocation.
+//
+// Handle n|tag refers to slots[n-1]; the zero handle is never issued.
+// Released handles are reused by later calls to add.
+type handleTable[T comparable] struct {
+	slots []T
+	free  []FeatherObj
+	tag   FeatherObj // bits set in every handle issued by this table
+}
+
+// add stores v and returns its handle.
+func (t *handleTable[T]) add(v T) FeatherObj {
+	if n := len(t.free); n > 0 {
+		h := t.free[n-1]
+		t.free = t.free[:n-1]
+		t.slots[h&^t.tag-1] = v
+		return h
+	}
+	t.slots = append(t.slots, v)
+	return FeatherObj(len(t.slots)) | t.tag
+}
+
+// get returns the value of h, or the zero value if h was not issued by
+// this table or has been released.
+func (t *handleTable[T]) get(h FeatherObj) T {
+	n := h &^ t.tag
+	if n == 0 || n > FeatherObj(len(t.slots)) {
+		var zero T
+		return zero
+	}
+	return t.slots[n-1]
+}
+
+// release frees h for reuse. Releasing a handle twice has no effect.
+func (t *handleTable[T]) release(h FeatherObj) {
+	var zero T
+	n := h &^ t.tag
+	if n == 0 || n > FeatherObj(len(t.slots)) || t.slots[n-1] == zero {
+		return
+	}
+	t.slots[n-1] = zero
+	t.free = append(t.free, h)
+}
+
+// live returns the number of handles issued and not released.
+func (t *handleTable[T]) live() int {
+	return len(t.slots) - len(t.free)
+}
+
+// reset releases every handle at once, keeping the slots for reuse unless
+// the table has grown past maxRetainedSlots.
+func (t *handleTable[T]) reset() {
+	if cap(t.slots) > maxRetainedSlots {
+		t.slots = nil
+	} else {
+		clear(t.slots)
+		t.slots = t.slots[:0]
+	}
+	t.free = t.free[:0]
+}
+
+// stringPins keeps the bytes of strings handed to C in place, for as long
+// as the scratch arena keeps the objects holding them.
+type stringPins struct {
+	pinner runtime.Pinner
+	pinned map[*byte]int // the pinned bytes, with their length
+
+	lent     uint64 // bytes pinned since the interpreter was created
+	released uint64 // bytes unpinned since the interpreter was created
+}
+
+// pin pins the bytes of s and returns a pointer to them.
+func (p *stringPins) pin(s string) *byte {
+	data := unsafe.StringData(s)
+	if _, ok := p.pinned[data]; !ok {
+		if p.pinned == nil {
+			p.pinned = make(map[*byte]int)
+		}
+		p.pinner.Pin(data)
+		p.pinned[data] = len(s)
+		p.lent += uint64(len(s))
+	}
+	return data
+}
+
+// reset unpins everything pinned since the last reset.
+func (p *stringPins) reset() {
+	if len(p.pinned) > 0 {
+		p.pinner.Unpin()
+		for _, n := range p.pinned {
+			p.released += uint64(n)
+		}
+		clear(p.pinned)
+	}
+}
@@ -0,0 +1,199 @@
+package feather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Futures are the pending results of commands that returned [Async]. The
+// work runs on its own goroutine, and its result is delivered through the
+// event loop, so the interpreter keeps serving other events meanwhile:
+//
+//	await future
+//	future then future command
+//	future cancel future
+//	future status future
+//	future names
+
+// future is the pending result of an Async command.
+type future struct {
+	id        string
+	cancel    context.CancelFunc
+	done      bool
+	value     *Obj
+	err       error
+	callbacks []*Obj // command prefixes added with future then
+}
+
+// errCancelled is the error of a future cancelled with future cancel.
+var errCancelled = errors.New("future cancelled")
+
+// Async returns a result that completes later: the command returns a
+// future at once and fn runs on a new goroutine. Scripts wait for the
+// future with await, which services the event loop meanwhile, or pass a
+// callback to future then:
+//
+//	interp.RegisterCommand("fetch", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+//	    url := args[0].String()
+//	    return feather.Async(func(ctx context.Context) (any, error) {
+//	        req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+//	        resp, err := http.DefaultClient.Do(req)
+//	        if err != nil {
+//	            return nil, err
+//	        }
+//	        defer resp.Body.Close()
+//	        return io.ReadAll(resp.Body)
+//	    })
+//	})
+//
+//	set page [await [fetch https://example.com]]
+//
+// fn must not use the interpreter. The value it returns is converted as
+// for [Interp.Value] on the goroutine servicing the event loop; an error
+// becomes the error of await. ctx is cancelled by future cancel, and when
+// the interpreter is reset or closed.
+func Async(fn func(ctx context.Context) (any, error)) Result {
+	return Result{code: ResultOK, async: fn}
+}
+
+// startFuture runs fn for Async and returns the id of its future.
+func (i *Interp) startFuture(fn func(ctx context.Context) (any, error)) string {
+	q := i.events
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &future{id: fmt.Sprintf("future#%d", q.nextFuture), cancel: cancel}
+	q.nextFuture++
+	q.futures[f.id] = f
+	go func() {
+		v, err := fn(ctx)
+		i.Post(func() {
+			if f.done {
+				return
+			}
+			if err == nil {
+				f.value = i.Value(v)
+			}
+			i.completeFuture(f, err)
+		})
+	}()
+	return f.id
+}
+
+// completeFuture records the outcome of f and queues its callbacks.
+func (i *Interp) completeFuture(f *future, err error) {
+	f.cancel()
+	f.done, f.err = true, err
+	if len(f.callbacks) > 0 {
+		i.Post(func() { i.runFutureCallbacks(f) })
+	}
+}
+
+// runFutureCallbacks calls the callbacks of the completed future f with
+// its status and value, as events, and forgets f.
+func (i *Interp) runFutureCallbacks(f *future) {
+	delete(i.events.futures, f.id)
+	status, value := "ok", f.value
+	if f.err != nil {
+		status, value = "error", i.String(f.err.Error())
+	}
+	callbacks := f.callbacks
+	f.callbacks = nil
+	for _, cb := range callbacks {
+		items, _ := cb.List()
+		script := i.List(append(slices.Clone(items), i.String(status), value)...)
+		i.runEvent(&afterEvent{id: f.id, script: script})
+	}
+}
+
+// cancelFutures cancels every pending future and forgets them all, for
+// Reset and Close.
+func (i *Interp) cancelFutures() {
+	q := i.events
+	for _, f := range q.futures {
+		f.done = true
+		f.cancel()
+	}
+	clear(q.futures)
+}
+
+// lookupFuture returns the future id.
+func (i *Interp) lookupFuture(id *Obj) (*future, error) {
+	f, ok := i.events.futures[id.String()]
+	if !ok {
+		return nil, fmt.Errorf("future \"%s\" doesn't exist", id.String())
+	}
+	return f, nil
+}
+
+// cmdAwait implements: await future
+//
+// await processes events until the future completes, then returns its
+// value or raises its error. The future is forgotten afterwards.
+func cmdAwait(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "await future"`)
+	}
+	f, err := i.lookupFuture(args[0])
+	if err != nil {
+		return Error(err.Error())
+	}
+	for !f.done {
+		i.doOneEvent(context.Background(), true)
+	}
+	delete(i.events.futures, f.id)
+	if f.err != nil {
+		return Error(f.err.Error())
+	}
+	return OK(f.value)
+}
+
+// cmdFuture implements: future subcommand ?arg ...?
+func cmdFuture(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "future subcommand ?arg ...?"`)
+	}
+	sub, err := i.GetIndexFromObj(args[0], []string{"cancel", "names", "status", "then"}, "subcommand")
+	if err != nil {
+		return Error(err.Error())
+	}
+	if sub == 1 {
+		if len(args) != 1 {
+			return Error(`wrong # args: should be "future names"`)
+		}
+		return OK(slices.Sorted(maps.Keys(i.events.futures)))
+	}
+
+	want := map[int]string{0: "future cancel future", 2: "future status future", 3: "future then future command"}
+	if (sub == 3 && len(args) != 3) || (sub != 3 && len(args) != 2) {
+		return Errorf("wrong # args: should be \"%s\"", want[sub])
+	}
+	f, err := i.lookupFuture(args[1])
+	if err != nil {
+		return Error(err.Error())
+	}
+	switch sub {
+	case 0:
+		if !f.done {
+			i.completeFuture(f, errCancelled)
+		}
+		return OK("")
+	case 2:
+		switch {
+		case !f.done:
+			return OK("pending")
+		case f.err != nil:
+			return OK("error")
+		}
+		return OK("ok")
+	}
+	if _, err := args[2].List(); err != nil {
+		return Error(err.Error())
+	}
+	f.callbacks = append(f.callbacks, args[2])
+	if f.done {
+		i.Post(func() { i.runFutureCallbacks(f) })
+	}
+	return OK("")
+}
@@ -0,0 +1,667 @@
+package feather
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The binary command converts between TCL values and binary data:
+//
+//	binary format formatString ?arg ...?
+//	binary scan value formatString ?varName ...?
+//	binary encode hex|base64 ?-option value ...? data
+//	binary decode hex|base64 ?-strict? data
+//
+// Binary data is held in a [ByteArrayType], so it keeps its exact bytes
+// when it is stored in variables and passed between commands.
+
+// registerBinary installs the binary command.
+func (i *Interp) registerBinary() {
+	i.RegisterCommand("binary", cmdBinary)
+}
+
+// cmdBinary implements: binary subcommand ?arg ...?
+func cmdBinary(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "binary subcommand ?arg ...?"`)
+	}
+	sub, args := args[0].String(), args[1:]
+	switch sub {
+	case "format":
+		return binaryFormat(i, args)
+	case "scan":
+		return binaryScan(i, args)
+	case "encode":
+		return binaryEncode(i, args)
+	case "decode":
+		return binaryDecode(i, args)
+	}
+	return Errorf(`unknown or ambiguous subcommand "%s": must be decode, encode, format, or scan`, sub)
+}
+
+// binaryField is one field specifier of a format string, such as a*, c4 or iu.
+type binaryField struct {
+	typ      byte
+	unsigned bool // u flag, only meaningful for scan
+	count    int  // -1 when no count was given
+	star     bool // count was *
+}
+
+// countOr returns the field's count, or def when none was given.
+func (f binaryField) countOr(def int) int {
+	if f.count < 0 {
+		return def
+	}
+	return f.count
+}
+
+// nextBinaryField parses the field specifier at format[*pos] and advances
+// *pos past it. It reports false once the format string is exhausted.
+func nextBinaryField(format string, pos *int) (binaryField, bool) {
+	for *pos < len(format) && strings.IndexByte(" \t\n\r\v\f", format[*pos]) >= 0 {
+		*pos++
+	}
+	if *pos >= len(format) {
+		return binaryField{}, false
+	}
+	f := binaryField{typ: format[*pos], count: -1}
+	*pos++
+	if *pos < len(format) && format[*pos] == 'u' {
+		f.unsigned = true
+		*pos++
+	}
+	if *pos < len(format) && format[*pos] == '*' {
+		f.star = true
+		*pos++
+		return f, true
+	}
+	start := *pos
+	for *pos < len(format) && format[*pos] >= '0' && format[*pos] <= '9' {
+		*pos++
+	}
+	if *pos > start {
+		f.count, _ = strconv.Atoi(format[start:*pos])
+	}
+	return f, true
+}
+
+// badBinaryField returns the error for an unknown field type at the start of s.
+func badBinaryField(s string) Result {
+	r, _ := utf8.DecodeRuneInString(s)
+	return Errorf(`bad field specifier "%c"`, r)
+}
+
+// binaryNumeric describes a numeric field type.
+type binaryNumeric struct {
+	size  int
+	float bool
+	big   bool // big-endian byte order
+}
+
+// nativeBigEndian reports whether the host stores numbers big-endian.
+var nativeBigEndian = binary.NativeEndian.Uint16([]byte{0, 1}) == 1
+
+// binaryNumerics maps numeric field types to their encoding.
+// Types t, n, m, f and d use the host's byte order.
+var binaryNumerics = map[byte]binaryNumeric{
+	'c': {size: 1},
+	's': {size: 2},
+	'S': {size: 2, big: true},
+	't': {size: 2, big: nativeBigEndian},
+	'i': {size: 4},
+	'I': {size: 4, big: true},
+	'n': {size: 4, big: nativeBigEndian},
+	'w': {size: 8},
+	'W': {size: 8, big: true},
+	'm': {size: 8, big: nativeBigEndian},
+	'f': {size: 4, float: true, big: nativeBigEndian},
+	'r': {size: 4, float: true},
+	'R': {size: 4, float: true, big: true},
+	'd': {size: 8, float: true, big: nativeBigEndian},
+	'q': {size: 8, float: true},
+	'Q': {size: 8, float: true, big: true},
+}
+
+const errBinaryArgs = "not enough arguments for all format specifiers"
+
+// binaryFormat implements: binary format formatString ?arg ...?
+func binaryFormat(i *Interp, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "binary format formatString ?arg ...?"`)
+	}
+	format, values := args[0].String(), args[1:]
+
+	var buf []byte
+	cursor := 0
+	// write stores data at the cursor, growing the buffer as needed
+	write := func(data []byte) {
+		if end := cursor + len(data); end > len(buf) {
+			buf = append(buf, make([]byte, end-len(buf))...)
+		}
+		copy(buf[cursor:], data)
+		cursor += len(data)
+	}
+
+	for pos := 0; ; {
+		start := pos
+		f, ok := nextBinaryField(format, &pos)
+		if !ok {
+			break
+		}
+		switch f.typ {
+		case 'a', 'A', 'b', 'B', 'h', 'H':
+			if len(values) == 0 {
+				return Error(errBinaryArgs)
+			}
+			value := values[0]
+			values = values[1:]
+			data, err := formatBinaryString(f, value)
+			if err != nil {
+				return Error(err.Error())
+			}
+			write(data)
+
+		case 'x':
+			if !f.star {
+				write(make([]byte, f.countOr(1)))
+			}
+
+		case 'X':
+			if n := f.countOr(1); f.star || n > cursor {
+				cursor = 0
+			} else {
+				cursor -= n
+			}
+
+		case '@':
+			switch {
+			case f.star:
+				cursor = len(buf)
+			case f.count < 0:
+				return Error(`missing count for "@" field specifier`)
+			default:
+				if f.count > len(buf) {
+					buf = append(buf, make([]byte, f.count-len(buf))...)
+				}
+				cursor = f.count
+			}
+
+		default:
+			num, ok := binaryNumerics[f.typ]
+			if !ok {
+				return badBinaryField(format[start:])
+			}
+			if len(values) == 0 {
+				return Error(errBinaryArgs)
+			}
+			value := values[0]
+			values = values[1:]
+			items := []*Obj{value}
+			if f.star || f.count >= 0 {
+				list, err := value.List()
+				if err != nil {
+					return Error(err.Error())
+				}
+				n := len(list)
+				if !f.star {
+					if n < f.count {
+						return Error("number of elements in list does not match count")
+					}
+					n = f.count
+				}
+				items = list[:n]
+			}
+			for _, item := range items {
+				data, err := formatBinaryNumber(num, item)
+				if err != nil {
+					return Error(err.Error())
+				}
+				write(data)
+			}
+		}
+	}
+	return OK(i.ByteArray(buf))
+}
+
+// formatBinaryString encodes value for one of the a, A, b, B, h or H fields.
+func formatBinaryString(f binaryField, value *Obj) ([]byte, error) {
+	switch f.typ {
+	case 'a', 'A':
+		data := value.ByteArray()
+		n := len(data)
+		if !f.star {
+			n = f.countOr(1)
+		}
+		out := make([]byte, n)
+		copied := copy(out, data)
+		if f.typ == 'A' {
+			for j := copied; j < n; j++ {
+				out[j] = ' '
+			}
+		}
+		return out, nil
+
+	case 'b', 'B':
+		s := value.String()
+		n := len(s)
+		if !f.star {
+			n = f.countOr(1)
+		}
+		out := make([]byte, (n+7)/8)
+		for j := 0; j < n && j < len(s); j++ {
+			switch s[j] {
+			case '0':
+			case '1':
+				if f.typ == 'b' {
+					out[j/8] |= 1 << (j % 8)
+				} else {
+					out[j/8] |= 0x80 >> (j % 8)
+				}
+			default:
+				return nil, fmt.Errorf(`expected binary string but got "%s" instead`, s)
+			}
+		}
+		return out, nil
+	}
+
+	s := value.String()
+	n := len(s)
+	if !f.star {
+		n = f.countOr(1)
+	}
+	out := make([]byte, (n+1)/2)
+	for j := 0; j < n && j < len(s); j++ {
+		d, ok := hexDigit(s[j])
+		if !ok {
+			return nil, fmt.Errorf(`expected hexadecimal string but got "%s" instead`, s)
+		}
+		// h puts the first digit in the low nibble, H in the high nibble
+		if (j%2 == 0) == (f.typ == 'H') {
+			d <<= 4
+		}
+		out[j/2] |= d
+	}
+	return out, nil
+}
+
+// formatBinaryNumber encodes one numeric value.
+func formatBinaryNumber(num binaryNumeric, value *Obj) ([]byte, error) {
+	var bits uint64
+	switch {
+	case num.float:
+		f, err := value.Double()
+		if err != nil {
+			return nil, err
+		}
+		if num.size == 4 {
+			bits = uint64(math.Float32bits(float32(f)))
+		} else {
+			bits = math.Float64bits(f)
+		}
+	default:
+		n, err := binaryInt(value)
+		if err != nil {
+			return nil, err
+		}
+		bits = n
+	}
+	out := make([]byte, num.size)
+	for j := 0; j < num.size; j++ {
+		b := byte(bits >> (8 * j))
+		if num.big {
+			out[num.size-1-j] = b
+		} else {
+			out[j] = b
+		}
+	}
+	return out, nil
+}
+
+// binaryInt returns the bits of an integer value. Besides decimal integers
+// it accepts the 0x, 0o and 0b prefixes and unsigned 64-bit values.
+func binaryInt(value *Obj) (uint64, error) {
+	if n, err := value.Int(); err == nil {
+		return uint64(n), nil
+	}
+	s := strings.TrimSpace(value.String())
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return uint64(n), nil
+	}
+	if n, err := strconv.ParseUint(s, 0, 64); err == nil {
+		return n, nil
+	}
+	return 0, fmt.Errorf("expected integer but got %q", value.String())
+}
+
+// binaryScan implements: binary scan value formatString ?varName ...?
+//
+// Scanning stops quietly when the data runs out; the result is the number
+// of variables that were set.
+func binaryScan(i *Interp, args []*Obj) Result {
+	if len(args) < 2 {
+		return Error(`wrong # args: should be "binary scan value formatString ?varName ...?"`)
+	}
+	data := args[0].ByteArray()
+	format, vars := args[1].String(), args[2:]
+	cursor := 0
+	converted := 0
+
+scan:
+	for pos := 0; ; {
+		start := pos
+		f, ok := nextBinaryField(format, &pos)
+		if !ok {
+			break
+		}
+		remaining := len(data) - cursor
+
+		var value *Obj
+		switch f.typ {
+		case 'x':
+			if n := f.countOr(1); f.star || n > remaining {
+				cursor = len(data)
+			} else {
+				cursor += n
+			}
+			continue
+
+		case 'X':
+			if n := f.countOr(1); f.star || n > cursor {
+				cursor = 0
+			} else {
+				cursor -= n
+			}
+			continue
+
+		case '@':
+			switch {
+			case f.star:
+				cursor = len(data)
+			case f.count < 0:
+				return Error(`missing count for "@" field specifier`)
+			default:
+				cursor = min(f.count, len(data))
+			}
+			continue
+
+		case 'a', 'A':
+			if len(vars) == 0 {
+				return Error(errBinaryArgs)
+			}
+			n := remaining
+			if !f.star {
+				n = f.countOr(1)
+			}
+			if n > remaining {
+				break scan
+			}
+			b := slices.Clone(data[cursor : cursor+n])
+			cursor += n
+			if f.typ == 'A' {
+				b = []byte(strings.TrimRight(string(b), " \x00"))
+			}
+			value = i.ByteArray(b)
+
+		case 'b', 'B', 'h', 'H':
+			if len(vars) == 0 {
+				return Error(errBinaryArgs)
+			}
+			perByte := 8
+			if f.typ == 'h' || f.typ == 'H' {
+				perByte = 2
+			}
+			n := remaining * perByte
+			if !f.star {
+				n = f.countOr(1)
+			}
+			size := (n + perByte - 1) / perByte
+			if size > remaining {
+				break scan
+			}
+			value = i.String(scanBinaryDigits(f.typ, data[cursor:cursor+size], n))
+			cursor += size
+
+		default:
+			num, ok := binaryNumerics[f.typ]
+			if !ok {
+				return badBinaryField(format[start:])
+			}
+			if len(vars) == 0 {
+				return Error(errBinaryArgs)
+			}
+			if !f.star && f.count < 0 {
+				if num.size > remaining {
+					break scan
+				}
+				value = scanBinaryNumber(i, num, f.unsigned, data[cursor:])
+				cursor += num.size
+				break
+			}
+			n := remaining / num.size
+			if !f.star {
+				n = f.count
+			}
+			if n*num.size > remaining {
+				break scan
+			}
+			items := make([]*Obj, n)
+			for j := range items {
+				items[j] = scanBinaryNumber(i, num, f.unsigned, data[cursor:])
+				cursor += num.size
+			}
+			value = i.List(items...)
+		}
+
+		if err := i.setVarObj(vars[0].String(), value); err != nil {
+			return Error(err.Error())
+		}
+		vars = vars[1:]
+		converted++
+	}
+	return OK(converted)
+}
+
+// scanBinaryDigits returns the first n binary (b, B) or hex (h, H) digits of data.
+func scanBinaryDigits(typ byte, data []byte, n int) string {
+	const digits = "0123456789abcdef"
+	var out strings.Builder
+	out.Grow(n)
+	for j := 0; j < n; j++ {
+		switch typ {
+		case 'b':
+			out.WriteByte(digits[data[j/8]>>(j%8)&1])
+		case 'B':
+			out.WriteByte(digits[data[j/8]>>(7-j%8)&1])
+		case 'h':
+			out.WriteByte(digits[data[j/2]>>(4*(j%2))&0xf])
+		case 'H':
+			out.WriteByte(digits[data[j/2]>>(4*(1-j%2))&0xf])
+		}
+	}
+	return out.String()
+}
+
+// scanBinaryNumber decodes one numeric value from the start of data.
+func scanBinaryNumber(i *Interp, num binaryNumeric, unsigned bool, data []byte) *Obj {
+	var bits uint64
+	for j := 0; j < num.size; j++ {
+		if num.big {
+			bits = bits<<8 | uint64(data[j])
+		} else {
+			bits |= uint64(data[j]) << (8 * j)
+		}
+	}
+	if num.float {
+		if num.size == 4 {
+			return i.Double(float64(math.Float32frombits(uint32(bits))))
+		}
+		return i.Double(math.Float64frombits(bits))
+	}
+	if unsigned {
+		if bits > math.MaxInt64 {
+			return i.String(strconv.FormatUint(bits, 10))
+		}
+		return i.Int(int64(bits))
+	}
+	// Sign-extend from the field width
+	shift := 64 - 8*num.size
+	return i.Int(int64(bits<<shift) >> shift)
+}
+
+// binaryEncode implements: binary encode format ?-option value ...? data
+func binaryEncode(i *Interp, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "binary encode format ?-option value ...? data"`)
+	}
+	switch args[0].String() {
+	case "hex":
+		if len(args) != 2 {
+			return Error(`wrong # args: should be "binary encode hex data"`)
+		}
+		return OK(hex.EncodeToString(args[1].ByteArray()))
+
+	case "base64":
+		if len(args) < 2 || len(args)%2 != 0 {
+			return Error(`wrong # args: should be "binary encode base64 ?-maxlen len? ?-wrapchar char? data"`)
+		}
+		maxlen, wrapchar := 0, "\n"
+		for j := 1; j < len(args)-1; j += 2 {
+			switch opt := args[j].String(); opt {
+			case "-maxlen":
+				n, err := args[j+1].Int()
+				if err != nil {
+					return Error(err.Error())
+				}
+				if n < 0 {
+					return Error("line length out of range")
+				}
+				maxlen = int(n)
+			case "-wrapchar":
+				wrapchar = args[j+1].String()
+			default:
+				return Errorf(`bad option "%s": must be -maxlen or -wrapchar`, opt)
+			}
+		}
+		encoded := base64.StdEncoding.EncodeToString(args[len(args)-1].ByteArray())
+		if maxlen == 0 || len(encoded) <= maxlen {
+			return OK(encoded)
+		}
+		var out strings.Builder
+		for len(encoded) > maxlen {
+			out.WriteString(encoded[:maxlen])
+			out.WriteString(wrapchar)
+			encoded = encoded[maxlen:]
+		}
+		out.WriteString(encoded)
+		return OK(out.String())
+	}
+	return Errorf(`unknown or ambiguous subcommand "%s": must be base64 or hex`, args[0].String())
+}
+
+// binaryDecode implements: binary decode format ?-strict? data
+//
+// Whitespace in the data is ignored unless -strict is given.
+func binaryDecode(i *Interp, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "binary decode format ?-option value ...? data"`)
+	}
+	format := args[0].String()
+	if format != "hex" && format != "base64" {
+		return Errorf(`unknown or ambiguous subcommand "%s": must be base64 or hex`, format)
+	}
+	strict := false
+	switch {
+	case len(args) == 3 && args[1].String() == "-strict":
+		strict = true
+	case len(args) == 3:
+		return Errorf(`bad option "%s": must be -strict`, args[1].String())
+	case len(args) != 2:
+		return Errorf(`wrong # args: should be "binary decode %s ?-strict? data"`, format)
+	}
+
+	var data []byte
+	var err error
+	if format == "hex" {
+		data, err = decodeHex(args[len(args)-1].String(), strict)
+	} else {
+		data, err = decodeBase64(args[len(args)-1].String(), strict)
+	}
+	if err != nil {
+		return Error(err.Error())
+	}
+	return OK(i.ByteArray(data))
+}
+
+// decodeHex decodes hex digits. A trailing unpaired digit is dropped
+// unless strict is set.
+func decodeHex(s string, strict bool) ([]byte, error) {
+	out := make([]byte, 0, len(s)/2)
+	var b byte
+	odd := false
+	for pos, r := range s {
+		if isBinarySpace(r) && !strict {
+			continue
+		}
+		d, ok := byte(0), false
+		if r < utf8.RuneSelf {
+			d, ok = hexDigit(byte(r))
+		}
+		if !ok {
+			return nil, fmt.Errorf(`invalid hexadecimal digit "%c" at position %d`, r, utf8.RuneCountInString(s[:pos]))
+		}
+		if odd {
+			out = append(out, b<<4|d)
+		} else {
+			b = d
+		}
+		odd = !odd
+	}
+	if odd && strict {
+		return nil, fmt.Errorf("incomplete hexadecimal data")
+	}
+	return out, nil
+}
+
+// decodeBase64 decodes base64 data. Padding is optional.
+func decodeBase64(s string, strict bool) ([]byte, error) {
+	var clean strings.Builder
+	for pos, r := range s {
+		if isBinarySpace(r) && !strict {
+			continue
+		}
+		if !strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=", r) {
+			return nil, fmt.Errorf(`invalid base64 character "%c" at position %d`, r, utf8.RuneCountInString(s[:pos]))
+		}
+		clean.WriteRune(r)
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(clean.String(), "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 data")
+	}
+	return data, nil
+}
+
+// hexDigit returns the value of the hex digit c.
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// isBinarySpace reports whether r is whitespace skipped by binary decode.
+func isBinarySpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\v' || r == '\f'
+}
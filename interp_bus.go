@@ -0,0 +1,229 @@
+package feather
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// The bus command sends values between scripts, in one interpreter or in
+// several connected to the same [Bus]:
+//
+//	bus publish topic value     - send value to the subscribers of topic,
+//	                              returning how many there are
+//	bus subscribe topic command - call command with the topic and value of
+//	                              each message published to topic, and
+//	                              return an id for unsubscribe
+//	bus unsubscribe id          - end a subscription
+//
+// A subscribed command runs as an event, when its interpreter services the
+// event loop, even for a message the interpreter published itself. Errors
+// in it are background errors, as for after scripts. Values cross the bus
+// frozen, so no [*Obj] is shared between interpreters: each subscriber gets
+// an object of its own, made by [Interp.Thaw].
+
+// Bus carries messages between the interpreters connected to it with
+// [Interp.SetBus], and to Go code subscribed with [Bus.Subscribe]. It is
+// safe to use from any goroutine.
+//
+//	bus := feather.NewBus()
+//	for _, interp := range workers {
+//	    interp.SetBus(bus)
+//	    go interp.Serve(ctx)
+//	}
+//	bus.Publish("config", map[string]any{"debug": true})
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[string][]*busSub // subscriptions by topic, oldest first
+	nextID int
+}
+
+// busSub is a subscription to a topic, by a command of an interpreter or
+// by a Go function.
+type busSub struct {
+	id      string
+	topic   string
+	interp  *Interp                     // nil for Go subscribers
+	command *Obj                        // the command prefix run in interp
+	fn      func(topic string, v Value) // the Go subscriber
+	ended   atomic.Bool                 // messages still queued are dropped
+}
+
+// NewBus creates a message bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]*busSub)}
+}
+
+// Publish sends v to the subscribers of topic and returns how many there
+// are. A [Value] is sent as it is, an [*Obj] is frozen with [Obj.Freeze],
+// and other Go values are converted as by [NewValue].
+//
+// Go subscribers are called before Publish returns; interpreters get the
+// message the next time they service their event loop.
+func (b *Bus) Publish(topic string, v any) int {
+	var val Value
+	switch v := v.(type) {
+	case Value:
+		val = v
+	case *Obj:
+		val = v.Freeze()
+	default:
+		val = NewValue(v).Freeze()
+	}
+	b.mu.Lock()
+	subs := slices.Clone(b.subs[topic])
+	b.mu.Unlock()
+	for _, s := range subs {
+		if s.interp == nil {
+			s.fn(topic, val)
+			continue
+		}
+		i := s.interp
+		i.Post(func() { i.deliverMessage(s, topic, val) })
+	}
+	return len(subs)
+}
+
+// Subscribe calls fn with each message published to topic until the
+// returned function is called. fn runs on the goroutine that publishes the
+// message, so it must not block, nor use that goroutine's interpreter.
+func (b *Bus) Subscribe(topic string, fn func(topic string, v Value)) (unsubscribe func()) {
+	s := b.add(&busSub{topic: topic, fn: fn})
+	return func() { b.remove(s) }
+}
+
+// add records the subscription s and gives it an id.
+func (b *Bus) add(s *busSub) *busSub {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	s.id = fmt.Sprintf("bus#%d", b.nextID)
+	b.subs[s.topic] = append(b.subs[s.topic], s)
+	return s
+}
+
+// remove ends the subscription s.
+func (b *Bus) remove(s *busSub) {
+	s.ended.Store(true)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subs := slices.DeleteFunc(b.subs[s.topic], func(t *busSub) bool { return t == s })
+	if len(subs) == 0 {
+		delete(b.subs, s.topic)
+	} else {
+		b.subs[s.topic] = subs
+	}
+}
+
+// find returns the subscription id made by i, or nil.
+func (b *Bus) find(i *Interp, id string) *busSub {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subs := range b.subs {
+		for _, s := range subs {
+			if s.id == id && s.interp == i {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// removeInterp ends the subscriptions made by i.
+func (b *Bus) removeInterp(i *Interp) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for topic, subs := range b.subs {
+		subs = slices.DeleteFunc(subs, func(s *busSub) bool {
+			if s.interp == i {
+				s.ended.Store(true)
+				return true
+			}
+			return false
+		})
+		if len(subs) == 0 {
+			delete(b.subs, topic)
+		} else {
+			b.subs[topic] = subs
+		}
+	}
+}
+
+// SetBus connects the bus command of i to b, ending the subscriptions its
+// scripts made on the bus it used before. Until SetBus is called, the
+// bus command uses a bus of the interpreter's own.
+func (i *Interp) SetBus(b *Bus) {
+	if i.bus != nil {
+		i.bus.removeInterp(i)
+	}
+	i.bus = b
+}
+
+// Bus returns the bus the bus command of i uses, so that Go code can
+// publish to its scripts.
+func (i *Interp) Bus() *Bus {
+	if i.bus == nil {
+		i.bus = NewBus()
+	}
+	return i.bus
+}
+
+// leaveBus ends the subscriptions of i, for Reset and Close.
+func (i *Interp) leaveBus() {
+	if i.bus != nil {
+		i.bus.removeInterp(i)
+	}
+}
+
+// deliverMessage runs the command of s with a message, unless s ended
+// after the message was published.
+func (i *Interp) deliverMessage(s *busSub, topic string, v Value) {
+	if s.ended.Load() || i.closed {
+		return
+	}
+	items, _ := s.command.List()
+	script := i.List(append(slices.Clone(items), i.String(topic), i.Thaw(v))...)
+	i.runEvent(&afterEvent{id: s.id, script: script})
+}
+
+// registerBus installs the bus command.
+func (i *Interp) registerBus() {
+	i.RegisterCommand("bus", cmdBus)
+}
+
+// cmdBus implements: bus subcommand ?arg ...?
+func cmdBus(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "bus subcommand ?arg ...?"`)
+	}
+	sub, err := i.GetIndexFromObj(args[0], []string{"publish", "subscribe", "unsubscribe"}, "subcommand")
+	if err != nil {
+		return Error(err.Error())
+	}
+	switch sub {
+	case 0:
+		if len(args) != 3 {
+			return Error(`wrong # args: should be "bus publish topic value"`)
+		}
+		return OK(i.Bus().Publish(args[1].String(), args[2].Freeze()))
+	case 1:
+		if len(args) != 3 {
+			return Error(`wrong # args: should be "bus subscribe topic command"`)
+		}
+		if _, err := args[2].List(); err != nil {
+			return Error(err.Error())
+		}
+		s := i.Bus().add(&busSub{topic: args[1].String(), interp: i, command: args[2]})
+		return OK(s.id)
+	}
+	if len(args) != 2 {
+		return Error(`wrong # args: should be "bus unsubscribe id"`)
+	}
+	s := i.Bus().find(i, args[1].String())
+	if s == nil {
+		return Errorf(`subscription "%s" doesn't exist`, args[1].String())
+	}
+	i.bus.remove(s)
+	return OK("")
+}
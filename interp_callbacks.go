@@ -18,11 +18,15 @@ static inline int call_list_compare(FeatherInterp interp, FeatherObj a, FeatherO
 import "C"
 
 import (
+	"errors"
+	"log/slog"
 	"math"
+	"math/big"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 	"unsafe"
@@ -207,6 +211,21 @@ func goStringByteLength(interp C.FeatherInterp, obj C.FeatherObj) C.size_t {
 	return C.size_t(len(str))
 }
 
+//export goStringBytes
+func goStringBytes(interp C.FeatherInterp, obj C.FeatherObj, length *C.size_t) *C.char {
+	i := getInterp(interp)
+	if i == nil {
+		return nil
+	}
+	str := i.getString(FeatherObj(obj))
+	if len(str) == 0 {
+		// Nothing to pin; byte_at answers every read of an empty string
+		return nil
+	}
+	*length = C.size_t(len(str))
+	return (*C.char)(unsafe.Pointer(i.pins.pin(str)))
+}
+
 //export goStringSlice
 func goStringSlice(interp C.FeatherInterp, obj C.FeatherObj, start C.size_t, end C.size_t) C.FeatherObj {
 	i := getInterp(interp)
@@ -259,6 +278,45 @@ func goStringMatch(interp C.FeatherInterp, pattern C.FeatherObj, str C.FeatherOb
 	return 0
 }
 
+// cIndexTables caches the Go form of the static name tables C passes to
+// goStringGetIndex, keyed by table address.
+var cIndexTables sync.Map
+
+//export goStringGetIndex
+func goStringGetIndex(interp C.FeatherInterp, obj C.FeatherObj, table **C.char, what *C.char, flags C.int, index *C.int) C.FeatherResult {
+	i := getInterp(interp)
+	if i == nil {
+		return C.TCL_ERROR
+	}
+	o := i.getObject(FeatherObj(obj))
+	if o == nil {
+		return C.TCL_ERROR
+	}
+	key := uintptr(unsafe.Pointer(table))
+	var names []string
+	if v, ok := cIndexTables.Load(key); ok {
+		names = v.([]string)
+	} else {
+		for p := table; *p != nil; p = (**C.char)(unsafe.Add(unsafe.Pointer(p), unsafe.Sizeof(*p))) {
+			names = append(names, C.GoString(*p))
+		}
+		cIndexTables.Store(key, names)
+	}
+	kind := "option"
+	if what != nil {
+		kind = C.GoString(what)
+	}
+	n, err := getIndex(o, key, names, kind, flags&C.FEATHER_INDEX_EXACT != 0)
+	if err != nil {
+		if what != nil {
+			i.result = i.String(err.Error())
+		}
+		return C.TCL_ERROR
+	}
+	*index = C.int(n)
+	return C.TCL_OK
+}
+
 //export goStringBuilderNew
 func goStringBuilderNew(interp C.FeatherInterp, capacity C.size_t) C.FeatherObj {
 	i := getInterp(interp)
@@ -317,8 +375,11 @@ func goRuneLength(interp C.FeatherInterp, str C.FeatherObj) C.size_t {
 	if i == nil {
 		return 0
 	}
-	s := i.getString(FeatherObj(str))
-	return C.size_t(utf8.RuneCountInString(s))
+	obj := i.getObject(FeatherObj(str))
+	if obj == nil {
+		return 0
+	}
+	return C.size_t(obj.runes().count)
 }
 
 //export goRuneAt
@@ -327,13 +388,16 @@ func goRuneAt(interp C.FeatherInterp, str C.FeatherObj, index C.size_t) C.Feathe
 	if i == nil {
 		return 0
 	}
-	s := i.getString(FeatherObj(str))
-	runes := []rune(s)
+	obj := i.getObject(FeatherObj(str))
+	if obj == nil {
+		return C.FeatherObj(i.internString(""))
+	}
+	runes := obj.runes()
 	idx := int(index)
-	if idx < 0 || idx >= len(runes) {
+	if idx >= runes.count {
 		return C.FeatherObj(i.internString(""))
 	}
-	return C.FeatherObj(i.internString(string(runes[idx])))
+	return C.FeatherObj(i.internString(runes.slice(idx, idx)))
 }
 
 //export goRuneRange
@@ -342,9 +406,11 @@ func goRuneRange(interp C.FeatherInterp, str C.FeatherObj, first C.int64_t, last
 	if i == nil {
 		return 0
 	}
-	s := i.getString(FeatherObj(str))
-	runes := []rune(s)
-	length := len(runes)
+	obj := i.getObject(FeatherObj(str))
+	if obj == nil {
+		return C.FeatherObj(i.internString(""))
+	}
+	runes := obj.runes()
 
 	// Clamp indices
 	f := int(first)
@@ -352,14 +418,14 @@ func goRuneRange(interp C.FeatherInterp, str C.FeatherObj, first C.int64_t, last
 	if f < 0 {
 		f = 0
 	}
-	if l >= length {
-		l = length - 1
+	if l >= runes.count {
+		l = runes.count - 1
 	}
-	if f > l || length == 0 {
+	if f > l || runes.count == 0 {
 		return C.FeatherObj(i.internString(""))
 	}
 
-	return C.FeatherObj(i.internString(string(runes[f : l+1])))
+	return C.FeatherObj(i.internString(runes.slice(f, l)))
 }
 
 //export goRuneToUpper
@@ -538,14 +604,6 @@ func goListCreate(interp C.FeatherInterp) C.FeatherObj {
 	return C.FeatherObj(i.registerObj(i.List()))
 }
 
-//export goListIsNil
-func goListIsNil(interp C.FeatherInterp, obj C.FeatherObj) C.int {
-	if obj == 0 {
-		return 1
-	}
-	return 0
-}
-
 //export goListFrom
 func goListFrom(interp C.FeatherInterp, obj C.FeatherObj) C.FeatherObj {
 	i := getInterp(interp)
@@ -553,18 +611,14 @@ func goListFrom(interp C.FeatherInterp, obj C.FeatherObj) C.FeatherObj {
 		return 0
 	}
 	// Get the list items (with shimmering)
-	items, err := i.getList(FeatherObj(obj))
+	items, err := i.listItems(FeatherObj(obj))
 	if err != nil {
 		// Set error message as result *Obj directly
 		i.result = i.String(err.Error())
 		return 0
 	}
-	// Create a new list with copied items as *Obj
-	copiedItems := make([]*Obj, len(items))
-	for idx, h := range items {
-		copiedItems[idx] = i.getObject(h)
-	}
-	return C.FeatherObj(i.registerObj(&Obj{intrep: ListType(copiedItems), interp: i}))
+	// The new list shares the items until one of the lists is modified
+	return C.FeatherObj(i.registerObj(i.shareList(i.getObject(FeatherObj(obj)), items, 0, len(items))))
 }
 
 //export goListPush
@@ -573,26 +627,21 @@ func goListPush(interp C.FeatherInterp, list C.FeatherObj, item C.FeatherObj) C.
 	if i == nil {
 		return list
 	}
+	list = C.FeatherObj(i.unshareLiteral(FeatherObj(list)))
 	o := i.getObject(FeatherObj(list))
 	if o == nil {
 		return list
 	}
-	itemObj := i.getObject(FeatherObj(item))
+	itemObj := dictShared(i.getObject(FeatherObj(item)))
 	if itemObj == nil {
 		return list
 	}
 	// Ensure it's a list, shimmer if needed
-	listItems, err := asList(o)
-	if err != nil {
-		// Try parsing from string
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return list
-		}
-		listItems, _ = asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
+	if err != nil || i.refuseGrowth(i.listTooLong(len(listItems)+1)) {
+		return list
 	}
-	// Append and update intrep
-	o.intrep = ListType(append(listItems, itemObj))
-	o.invalidate()
+	o.appendList(listItems, itemObj)
 	return list
 }
 
@@ -602,17 +651,15 @@ func goListPop(interp C.FeatherInterp, list C.FeatherObj) C.FeatherObj {
 	if i == nil {
 		return 0
 	}
+	list = C.FeatherObj(i.unshareLiteral(FeatherObj(list)))
 	o := i.getObject(FeatherObj(list))
 	if o == nil {
 		return 0
 	}
 	// Ensure it's a list
-	listItems, err := asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
 	if err != nil {
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return 0
-		}
-		listItems, _ = asList(o)
+		return 0
 	}
 	if len(listItems) == 0 {
 		return 0
@@ -629,21 +676,19 @@ func goListUnshift(interp C.FeatherInterp, list C.FeatherObj, item C.FeatherObj)
 	if i == nil {
 		return list
 	}
+	list = C.FeatherObj(i.unshareLiteral(FeatherObj(list)))
 	o := i.getObject(FeatherObj(list))
 	if o == nil {
 		return list
 	}
-	itemObj := i.getObject(FeatherObj(item))
+	itemObj := dictShared(i.getObject(FeatherObj(item)))
 	if itemObj == nil {
 		return list
 	}
 	// Ensure it's a list
-	listItems, err := asList(o)
-	if err != nil {
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return list
-		}
-		listItems, _ = asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
+	if err != nil || i.refuseGrowth(i.listTooLong(len(listItems)+1)) {
+		return list
 	}
 	// Prepend item to the list
 	o.intrep = ListType(append([]*Obj{itemObj}, listItems...))
@@ -657,17 +702,15 @@ func goListShift(interp C.FeatherInterp, list C.FeatherObj) C.FeatherObj {
 	if i == nil {
 		return 0
 	}
+	list = C.FeatherObj(i.unshareLiteral(FeatherObj(list)))
 	o := i.getObject(FeatherObj(list))
 	if o == nil {
 		return 0
 	}
 	// Ensure it's a list
-	listItems, err := asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
 	if err != nil {
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return 0
-		}
-		listItems, _ = asList(o)
+		return 0
 	}
 	if len(listItems) == 0 {
 		return 0
@@ -684,8 +727,8 @@ func goListLength(interp C.FeatherInterp, list C.FeatherObj) C.size_t {
 	if i == nil {
 		return 0
 	}
-	// Use GetList for shimmering (string → list)
-	items, err := i.getList(FeatherObj(list))
+	// Use listItems for shimmering (string → list)
+	items, err := i.listItems(FeatherObj(list))
 	if err != nil {
 		return 0
 	}
@@ -698,7 +741,7 @@ func goListAt(interp C.FeatherInterp, list C.FeatherObj, index C.size_t) C.Feath
 	if i == nil {
 		return 0
 	}
-	items, err := i.getList(FeatherObj(list))
+	items, err := i.listItems(FeatherObj(list))
 	if err != nil {
 		return 0
 	}
@@ -706,7 +749,7 @@ func goListAt(interp C.FeatherInterp, list C.FeatherObj, index C.size_t) C.Feath
 	if idx < 0 || idx >= len(items) {
 		return 0
 	}
-	return C.FeatherObj(items[idx])
+	return C.FeatherObj(i.registerObj(items[idx]))
 }
 
 //export goListSlice
@@ -715,7 +758,7 @@ func goListSlice(interp C.FeatherInterp, list C.FeatherObj, first C.size_t, last
 	if i == nil {
 		return 0
 	}
-	items, err := i.getList(FeatherObj(list))
+	items, err := i.listItems(FeatherObj(list))
 	if err != nil {
 		return 0
 	}
@@ -737,12 +780,8 @@ func goListSlice(interp C.FeatherInterp, list C.FeatherObj, first C.size_t, last
 		return C.FeatherObj(i.registerObj(i.List()))
 	}
 
-	// Create new list with sliced items - convert FeatherObj handles to *Obj
-	slicedObjs := make([]*Obj, l-f+1)
-	for idx, h := range items[f : l+1] {
-		slicedObjs[idx] = i.getObject(h)
-	}
-	return C.FeatherObj(i.registerObj(&Obj{intrep: ListType(slicedObjs), interp: i}))
+	// The slice shares the items until one of the lists is modified
+	return C.FeatherObj(i.registerObj(i.shareList(i.getObject(FeatherObj(list)), items, f, l+1)))
 }
 
 //export goListSetAt
@@ -751,6 +790,7 @@ func goListSetAt(interp C.FeatherInterp, list C.FeatherObj, index C.size_t, valu
 	if i == nil {
 		return C.TCL_ERROR
 	}
+	list = C.FeatherObj(i.unshareLiteral(FeatherObj(list)))
 
 	o := i.getObject(FeatherObj(list))
 	if o == nil {
@@ -758,12 +798,9 @@ func goListSetAt(interp C.FeatherInterp, list C.FeatherObj, index C.size_t, valu
 	}
 
 	// Use asList for direct access, or GetList for shimmering (string → list)
-	listItems, err := asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
 	if err != nil {
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return C.TCL_ERROR
-		}
-		listItems, _ = asList(o)
+		return C.TCL_ERROR
 	}
 
 	idx := int(index)
@@ -772,10 +809,11 @@ func goListSetAt(interp C.FeatherInterp, list C.FeatherObj, index C.size_t, valu
 	}
 
 	// Mutate in place
-	valueObj := i.getObject(FeatherObj(value))
+	valueObj := dictShared(i.getObject(FeatherObj(value)))
 	if valueObj == nil {
 		return C.TCL_ERROR
 	}
+	listItems = ownList(o, listItems)
 	listItems[idx] = valueObj
 	o.invalidate()
 
@@ -795,12 +833,9 @@ func goListSplice(interp C.FeatherInterp, list C.FeatherObj, first C.size_t, del
 	}
 
 	// Get list items via shimmering
-	listItems, err := asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
 	if err != nil {
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return 0
-		}
-		listItems, _ = asList(o)
+		return 0
 	}
 
 	f := int(first)
@@ -810,20 +845,7 @@ func goListSplice(interp C.FeatherInterp, list C.FeatherObj, first C.size_t, del
 	// Get insertion items
 	var insertObjs []*Obj
 	if insertions != 0 {
-		insObj := i.getObject(FeatherObj(insertions))
-		if insObj != nil {
-			insItems, err := asList(insObj)
-			if err != nil {
-				if insHandles, err := i.getList(FeatherObj(insertions)); err == nil {
-					insertObjs = make([]*Obj, len(insHandles))
-					for idx, h := range insHandles {
-						insertObjs[idx] = i.getObject(h)
-					}
-				}
-			} else {
-				insertObjs = insItems
-			}
-		}
+		insertObjs, _ = i.listItems(FeatherObj(insertions))
 	}
 
 	// Clamp first index
@@ -841,6 +863,9 @@ func goListSplice(interp C.FeatherInterp, list C.FeatherObj, first C.size_t, del
 
 	// Build new list: [0:first] + insertObjs + [first+deleteCount:]
 	newLen := length - dc + len(insertObjs)
+	if i.refuseGrowth(i.listTooLong(newLen)) {
+		return list
+	}
 	newItems := make([]*Obj, 0, newLen)
 	newItems = append(newItems, listItems[:f]...)
 	newItems = append(newItems, insertObjs...)
@@ -867,6 +892,7 @@ func goListSort(interp C.FeatherInterp, list C.FeatherObj, cmpFunc unsafe.Pointe
 	if i == nil {
 		return C.TCL_ERROR
 	}
+	list = C.FeatherObj(i.unshareLiteral(FeatherObj(list)))
 
 	o := i.getObject(FeatherObj(list))
 	if o == nil {
@@ -874,17 +900,15 @@ func goListSort(interp C.FeatherInterp, list C.FeatherObj, cmpFunc unsafe.Pointe
 	}
 
 	// Get list items via shimmering
-	listItems, err := asList(o)
+	listItems, err := i.listItems(FeatherObj(list))
 	if err != nil {
-		if _, err := i.getList(FeatherObj(list)); err != nil {
-			return C.TCL_ERROR
-		}
-		listItems, _ = asList(o)
+		return C.TCL_ERROR
 	}
 
 	if len(listItems) <= 1 {
 		return C.TCL_OK // Already sorted
 	}
+	listItems = ownList(o, listItems)
 
 	// Set up sort context
 	currentSortCtx = &ListSortContext{
@@ -893,9 +917,10 @@ func goListSort(interp C.FeatherInterp, list C.FeatherObj, cmpFunc unsafe.Pointe
 		ctx:     ctx,
 	}
 
-	// Sort using Go's sort with the C comparison function
+	// Sort using Go's stable sort with the C comparison function; lsort relies
+	// on equal elements keeping their original order
 	// We need to sort the underlying slice and register handles for comparison
-	sort.Slice(listItems, func(a, b int) bool {
+	sort.SliceStable(listItems, func(a, b int) bool {
 		handleA := i.registerObj(listItems[a])
 		handleB := i.registerObj(listItems[b])
 		result := C.call_list_compare(currentSortCtx.interp, C.FeatherObj(handleA), C.FeatherObj(handleB),
@@ -920,7 +945,9 @@ func goDictCreate(interp C.FeatherInterp) C.FeatherObj {
 	if i == nil {
 		return 0
 	}
-	return C.FeatherObj(i.registerObj(i.Dict()))
+	o := i.Dict()
+	o.intrep.(*DictType).holder = heldByNone
+	return C.FeatherObj(i.registerObj(o))
 }
 
 //export goDictIsDict
@@ -944,19 +971,19 @@ func goDictFrom(interp C.FeatherInterp, obj C.FeatherObj) C.FeatherObj {
 	if i == nil {
 		return 0
 	}
+	o := i.getObject(FeatherObj(obj))
+	if o == nil {
+		return 0
+	}
 	// Try to convert to dict
-	dictItems, dictOrder, err := i.getDict(FeatherObj(obj))
+	d, err := o.Dict()
 	if err != nil {
 		return 0 // Return nil on error
 	}
-	// Create new dict object with copied *Obj values
-	newItems := make(map[string]*Obj, len(dictItems))
-	for k, h := range dictItems {
-		newItems[k] = i.getObject(h)
-	}
-	newOrder := make([]string, len(dictOrder))
-	copy(newOrder, dictOrder)
-	return C.FeatherObj(i.registerObj(&Obj{intrep: &DictType{Items: newItems, Order: newOrder}, interp: i}))
+	// The new dict shares the items until one of the dicts is modified
+	c := d.share()
+	c.holder = heldByNone
+	return C.FeatherObj(i.registerObj(i.Obj(c)))
 }
 
 //export goDictGet
@@ -986,6 +1013,7 @@ func goDictSet(interp C.FeatherInterp, dict C.FeatherObj, key C.FeatherObj, valu
 	if i == nil {
 		return 0
 	}
+	dict = C.FeatherObj(i.ownDict(FeatherObj(dict)))
 	o := i.getObject(FeatherObj(dict))
 	if o == nil {
 		return 0
@@ -995,12 +1023,17 @@ func goDictSet(interp C.FeatherInterp, dict C.FeatherObj, key C.FeatherObj, valu
 		return 0
 	}
 	keyStr := i.getString(FeatherObj(key))
-	valueObj := i.getObject(FeatherObj(value))
+	valueObj := dictShared(i.getObject(FeatherObj(value)))
 	if valueObj == nil {
 		return 0
 	}
 	// Add key to order if new
-	if _, exists := d.Items[keyStr]; !exists {
+	_, exists := d.Items[keyStr]
+	if !exists && i.refuseGrowth(i.dictTooLarge(len(d.Order)+1)) {
+		return dict
+	}
+	d.unshare()
+	if !exists {
 		d.Order = append(d.Order, keyStr)
 	}
 	d.Items[keyStr] = valueObj
@@ -1031,6 +1064,7 @@ func goDictRemove(interp C.FeatherInterp, dict C.FeatherObj, key C.FeatherObj) C
 	if i == nil {
 		return 0
 	}
+	dict = C.FeatherObj(i.ownDict(FeatherObj(dict)))
 	o := i.getObject(FeatherObj(dict))
 	if o == nil {
 		return 0
@@ -1048,6 +1082,9 @@ func goDictRemove(interp C.FeatherInterp, dict C.FeatherObj, key C.FeatherObj) C
 		return 0
 	}
 	keyStr := i.getString(FeatherObj(key))
+	if _, ok := d.Items[keyStr]; ok {
+		d.unshare()
+	}
 	// Remove from map
 	delete(d.Items, keyStr)
 	// Remove from order
@@ -1125,6 +1162,13 @@ func goIntGet(interp C.FeatherInterp, obj C.FeatherObj, out *C.int64_t) C.Feathe
 	if i == nil {
 		return C.TCL_ERROR
 	}
+	// As in TCL, a floating-point value is not an integer to the C core,
+	// even though Obj.Int truncates it for Go callers
+	if o := i.getObject(FeatherObj(obj)); o != nil {
+		if _, isDouble := o.intrep.(DoubleType); isDouble {
+			return C.TCL_ERROR
+		}
+	}
 	val, err := i.getInt(FeatherObj(obj))
 	if err != nil {
 		return C.TCL_ERROR
@@ -1346,6 +1390,156 @@ func goDoubleMath(interp C.FeatherInterp, op C.FeatherMathOp, a C.double, b C.do
 	return C.TCL_OK
 }
 
+// maxBignumBits bounds the size of integers that ** and << may produce.
+const maxBignumBits = 1 << 24
+
+//export goBignumGet
+func goBignumGet(interp C.FeatherInterp, obj C.FeatherObj, out *C.FeatherObj) C.FeatherResult {
+	i := getInterp(interp)
+	if i == nil {
+		return C.TCL_ERROR
+	}
+	o := i.getObject(FeatherObj(obj))
+	if o == nil {
+		return C.TCL_ERROR
+	}
+	v, err := asBigInt(o)
+	if err != nil {
+		return C.TCL_ERROR
+	}
+	*out = C.FeatherObj(i.registerObj(i.BigInt(v)))
+	return C.TCL_OK
+}
+
+//export goBignumArith
+func goBignumArith(interp C.FeatherInterp, op C.FeatherBignumOp, a C.FeatherObj, b C.FeatherObj, out *C.FeatherObj) C.FeatherResult {
+	i := getInterp(interp)
+	if i == nil {
+		return C.TCL_ERROR
+	}
+	va, err := asBigInt(i.getObject(FeatherObj(a)))
+	if err != nil {
+		i.result = i.String(err.Error())
+		return C.TCL_ERROR
+	}
+	vb := new(big.Int)
+	if op < C.FEATHER_BIG_NEG {
+		if vb, err = asBigInt(i.getObject(FeatherObj(b))); err != nil {
+			i.result = i.String(err.Error())
+			return C.TCL_ERROR
+		}
+	}
+
+	result, err := bignumArith(op, va, vb)
+	if err != nil {
+		i.result = i.String(err.Error())
+		return C.TCL_ERROR
+	}
+	*out = C.FeatherObj(i.registerObj(i.BigInt(result)))
+	return C.TCL_OK
+}
+
+// bignumArith computes one FeatherBignumOp with TCL semantics.
+func bignumArith(op C.FeatherBignumOp, a, b *big.Int) (*big.Int, error) {
+	r := new(big.Int)
+	switch op {
+	case C.FEATHER_BIG_ADD:
+		return r.Add(a, b), nil
+	case C.FEATHER_BIG_SUB:
+		return r.Sub(a, b), nil
+	case C.FEATHER_BIG_MUL:
+		return r.Mul(a, b), nil
+	case C.FEATHER_BIG_DIV, C.FEATHER_BIG_MOD:
+		if b.Sign() == 0 {
+			return nil, errors.New("divide by zero")
+		}
+		// Round the quotient toward negative infinity, so the remainder
+		// takes the sign of the divisor.
+		q, m := r.QuoRem(a, b, new(big.Int))
+		if m.Sign() != 0 && m.Sign() != b.Sign() {
+			q.Sub(q, big.NewInt(1))
+			m.Add(m, b)
+		}
+		if op == C.FEATHER_BIG_DIV {
+			return q, nil
+		}
+		return m, nil
+	case C.FEATHER_BIG_POW:
+		if b.Sign() < 0 {
+			switch {
+			case a.Sign() == 0:
+				return nil, errors.New("exponentiation of zero by negative power")
+			case a.CmpAbs(big.NewInt(1)) != 0:
+				return r, nil
+			case a.Sign() < 0 && b.Bit(0) == 1:
+				return r.SetInt64(-1), nil
+			}
+			return r.SetInt64(1), nil
+		}
+		if a.CmpAbs(big.NewInt(1)) > 0 && (!b.IsInt64() || b.Int64() > maxBignumBits/int64(a.BitLen()-1)) {
+			return nil, errors.New("exponent too large")
+		}
+		return r.Exp(a, b, nil), nil
+	case C.FEATHER_BIG_AND:
+		return r.And(a, b), nil
+	case C.FEATHER_BIG_OR:
+		return r.Or(a, b), nil
+	case C.FEATHER_BIG_XOR:
+		return r.Xor(a, b), nil
+	case C.FEATHER_BIG_SHL, C.FEATHER_BIG_SHR:
+		if b.Sign() < 0 {
+			return nil, errors.New("negative shift argument")
+		}
+		if op == C.FEATHER_BIG_SHL {
+			if a.Sign() != 0 && (!b.IsInt64() || b.Int64() > maxBignumBits-int64(a.BitLen())) {
+				return nil, errors.New("integer value too large to represent")
+			}
+			return r.Lsh(a, uint(b.Uint64())), nil
+		}
+		if !b.IsInt64() || b.Int64() >= int64(a.BitLen()) {
+			// Everything is shifted out; only the sign remains.
+			return r.SetInt64(int64(min(a.Sign(), 0))), nil
+		}
+		return r.Rsh(a, uint(b.Uint64())), nil
+	case C.FEATHER_BIG_NEG:
+		return r.Neg(a), nil
+	case C.FEATHER_BIG_NOT:
+		return r.Not(a), nil
+	}
+	return nil, errors.New("unknown bignum operation")
+}
+
+//export goBignumCompare
+func goBignumCompare(interp C.FeatherInterp, a C.FeatherObj, b C.FeatherObj) C.int {
+	i := getInterp(interp)
+	if i == nil {
+		return 0
+	}
+	va, errA := asBigInt(i.getObject(FeatherObj(a)))
+	vb, errB := asBigInt(i.getObject(FeatherObj(b)))
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return C.int(va.Cmp(vb))
+}
+
+//export goBignumFormat
+func goBignumFormat(interp C.FeatherInterp, obj C.FeatherObj, base C.int, uppercase C.int) C.FeatherObj {
+	i := getInterp(interp)
+	if i == nil {
+		return 0
+	}
+	v, err := asBigInt(i.getObject(FeatherObj(obj)))
+	if err != nil {
+		return 0
+	}
+	s := v.Text(int(base))
+	if uppercase != 0 {
+		s = strings.ToUpper(s)
+	}
+	return C.FeatherObj(i.internString(s))
+}
+
 //export goFramePush
 func goFramePush(interp C.FeatherInterp, cmd C.FeatherObj, args C.FeatherObj) C.FeatherResult {
 	i := getInterp(interp)
@@ -1359,6 +1553,9 @@ func goFramePush(interp C.FeatherInterp, cmd C.FeatherObj, args C.FeatherObj) C.
 		limit = DefaultRecursionLimit
 	}
 	if newLevel >= limit {
+		if i.logging(slog.LevelWarn) {
+			i.log(slog.LevelWarn, "recursion limit reached", "limit", limit, "command", i.getString(FeatherObj(cmd)))
+		}
 		// Set error message and return error
 		i.result = i.String("too many nested evaluations (infinite loop?)")
 		return C.TCL_ERROR
@@ -1395,6 +1592,11 @@ func goFramePop(interp C.FeatherInterp) C.FeatherResult {
 	if len(i.frames) <= 1 {
 		return C.TCL_ERROR
 	}
+	// Run scripts registered with defer while the frame is still active
+	if frame := i.frames[len(i.frames)-1]; len(frame.deferred) > 0 {
+		i.active = len(i.frames) - 1
+		i.runDeferred(frame)
+	}
 	i.frames = i.frames[:len(i.frames)-1]
 	i.active = len(i.frames) - 1
 	return C.TCL_OK
@@ -1516,6 +1718,7 @@ func goVarSet(interp C.FeatherInterp, name C.FeatherObj, value C.FeatherObj) {
 			if link.targetLevel == -1 {
 				// Namespace variable link
 				if ns, ok := i.namespaces[link.nsPath]; ok {
+					dictStored(ns.vars[link.nsName], valueObj)
 					ns.vars[link.nsName] = valueObj
 				}
 				return
@@ -1529,6 +1732,7 @@ func goVarSet(interp C.FeatherInterp, name C.FeatherObj, value C.FeatherObj) {
 			break
 		}
 	}
+	dictStored(frame.locals.vars[varName], valueObj)
 	frame.locals.vars[varName] = valueObj
 }
 
@@ -1628,18 +1832,47 @@ func callCEval(interpHandle FeatherInterp, scriptHandle FeatherObj) C.FeatherRes
 	return C.feather_script_eval_obj(nil, C.FeatherInterp(interpHandle), C.FeatherObj(scriptHandle), C.TCL_EVAL_LOCAL)
 }
 
-// callCParse invokes the C parser
+// callCSet invokes the set builtin
+func callCSet(interpHandle FeatherInterp, argsHandle FeatherObj) C.FeatherResult {
+	return C.feather_builtin_set(C.feather_get_ops(nil), C.FeatherInterp(interpHandle), 0, C.FeatherObj(argsHandle))
+}
+
+// callCParse invokes the C parser on every command of the script, checking
+// syntax only: no variables are read and no commands are run.
 func callCParse(interpHandle FeatherInterp, scriptHandle FeatherObj) C.FeatherParseStatus {
 	ops := C.feather_get_ops(nil)
 	len := C.feather_host_string_byte_length(C.FeatherInterp(interpHandle), C.FeatherObj(scriptHandle))
 	var ctx C.FeatherParseContextObj
 	C.feather_parse_init_obj(&ctx, C.FeatherObj(scriptHandle), len)
-	status := C.feather_parse_command_obj(ops, C.FeatherInterp(interpHandle), &ctx)
-	if status == C.TCL_PARSE_DONE {
-		C.feather_host_interp_set_result(C.FeatherInterp(interpHandle), C.feather_host_list_create(C.FeatherInterp(interpHandle)))
-		return C.TCL_PARSE_OK
+	ctx.check_only = 1
+	for {
+		status := C.feather_parse_command_obj(ops, C.FeatherInterp(interpHandle), &ctx)
+		if status == C.TCL_PARSE_DONE {
+			C.feather_host_interp_set_result(C.FeatherInterp(interpHandle), C.feather_host_list_create(C.FeatherInterp(interpHandle)))
+			return C.TCL_PARSE_OK
+		}
+		if status != C.TCL_PARSE_OK {
+			return status
+		}
 	}
-	return status
+}
+
+// callCEvalHooksEnable adjusts the number of eval hooks on the interpreter
+func callCEvalHooksEnable(interpHandle FeatherInterp, delta int) {
+	C.feather_eval_hooks_enable(nil, C.FeatherInterp(interpHandle), C.int(delta))
+}
+
+// callCEvalLimitsEnable adjusts the number of limits set on the interpreter
+func callCEvalLimitsEnable(interpHandle FeatherInterp, delta int) {
+	C.feather_eval_limits_enable(nil, C.FeatherInterp(interpHandle), C.int(delta))
+}
+
+// callCTracesCount adjusts the number of traces of kind the C core
+// counts for the interpreter, for traces discarded without the trace command
+func callCTracesCount(interpHandle FeatherInterp, kind string, delta int) {
+	ckind := C.CString(kind)
+	defer C.free(unsafe.Pointer(ckind))
+	C.feather_traces_count(nil, C.FeatherInterp(interpHandle), ckind, C.int(delta))
 }
 
 // callCInterpInit invokes the C interpreter initialization
@@ -1687,6 +1920,32 @@ func (i *Interp) ensureNamespace(path string) *Namespace {
 	return current
 }
 
+// deleteNamespace deletes ns, its children and their commands.
+func (i *Interp) deleteNamespace(ns *Namespace) {
+	// Delete all children recursively
+	var deleteRecursive func(n *Namespace)
+	deleteRecursive = func(n *Namespace) {
+		for _, child := range n.children {
+			deleteRecursive(child)
+		}
+		for name := range n.commands {
+			i.deleteCommand(n, name)
+		}
+		delete(i.namespaces, n.fullPath)
+	}
+	deleteRecursive(ns)
+
+	// Remove from parent's children
+	if ns.parent != nil {
+		for name, child := range ns.parent.children {
+			if child == ns {
+				delete(ns.parent.children, name)
+				break
+			}
+		}
+	}
+}
+
 //export goNsCreate
 func goNsCreate(interp C.FeatherInterp, path C.FeatherObj) C.FeatherResult {
 	i := getInterp(interp)
@@ -1716,26 +1975,7 @@ func goNsDelete(interp C.FeatherInterp, path C.FeatherObj) C.FeatherResult {
 		return C.TCL_ERROR
 	}
 
-	// Delete all children recursively
-	var deleteRecursive func(n *Namespace)
-	deleteRecursive = func(n *Namespace) {
-		for _, child := range n.children {
-			deleteRecursive(child)
-		}
-		delete(i.namespaces, n.fullPath)
-	}
-	deleteRecursive(ns)
-
-	// Remove from parent's children
-	if ns.parent != nil {
-		for name, child := range ns.parent.children {
-			if child == ns {
-				delete(ns.parent.children, name)
-				break
-			}
-		}
-	}
-
+	i.deleteNamespace(ns)
 	return C.TCL_OK
 }
 
@@ -1847,7 +2087,9 @@ func goNsSetVar(interp C.FeatherInterp, nsPath C.FeatherObj, name C.FeatherObj,
 
 	// Create namespace if needed
 	ns := i.ensureNamespace(pathStr)
-	ns.vars[nameStr] = i.getObject(FeatherObj(value))
+	valueObj := i.getObject(FeatherObj(value))
+	dictStored(ns.vars[nameStr], valueObj)
+	ns.vars[nameStr] = valueObj
 }
 
 //export goNsVarExists
@@ -1999,7 +2241,7 @@ func goNsSetCommand(interp C.FeatherInterp, nsPath C.FeatherObj, name C.FeatherO
 			body:   i.getObject(FeatherObj(body)),
 		}
 	}
-	ns.commands[nameStr] = cmd
+	i.setCommand(ns, nameStr, cmd)
 }
 
 //export goNsDeleteCommand
@@ -2016,11 +2258,9 @@ func goNsDeleteCommand(interp C.FeatherInterp, nsPath C.FeatherObj, name C.Feath
 		return C.TCL_ERROR
 	}
 
-	if _, ok := ns.commands[nameStr]; !ok {
+	if !i.deleteCommand(ns, nameStr) {
 		return C.TCL_ERROR
 	}
-
-	delete(ns.commands, nameStr)
 	return C.TCL_OK
 }
 
@@ -2138,6 +2378,35 @@ func goFrameGetLine(interp C.FeatherInterp, level C.size_t) C.size_t {
 	return C.size_t(i.frames[lvl].line)
 }
 
+//export goFrameSetFile
+func goFrameSetFile(interp C.FeatherInterp, file C.FeatherObj) C.FeatherResult {
+	i := getInterp(interp)
+	if i == nil {
+		return C.TCL_ERROR
+	}
+	if i.active >= len(i.frames) {
+		return C.TCL_ERROR
+	}
+	i.frames[i.active].file = nil
+	if file != 0 {
+		i.frames[i.active].file = i.getObject(FeatherObj(file))
+	}
+	return C.TCL_OK
+}
+
+//export goFrameGetFile
+func goFrameGetFile(interp C.FeatherInterp, level C.size_t) C.FeatherObj {
+	i := getInterp(interp)
+	if i == nil {
+		return 0
+	}
+	lvl := int(level)
+	if lvl < 0 || lvl >= len(i.frames) || i.frames[lvl].file == nil {
+		return 0
+	}
+	return C.FeatherObj(i.registerObjScratch(i.frames[lvl].file))
+}
+
 //export goFrameSetLambda
 func goFrameSetLambda(interp C.FeatherInterp, lambda C.FeatherObj) C.FeatherResult {
 	i := getInterp(interp)
@@ -2167,6 +2436,35 @@ func goFrameGetLambda(interp C.FeatherInterp, level C.size_t) C.FeatherObj {
 	return C.FeatherObj(i.registerObjScratch(i.frames[lvl].lambda))
 }
 
+//export goFrameSetWord
+func goFrameSetWord(interp C.FeatherInterp, word C.FeatherObj) C.FeatherResult {
+	i := getInterp(interp)
+	if i == nil {
+		return C.TCL_ERROR
+	}
+	if i.active >= len(i.frames) {
+		return C.TCL_ERROR
+	}
+	i.frames[i.active].word = i.getObject(FeatherObj(word))
+	return C.TCL_OK
+}
+
+//export goFrameGetWord
+func goFrameGetWord(interp C.FeatherInterp, level C.size_t) C.FeatherObj {
+	i := getInterp(interp)
+	if i == nil {
+		return 0
+	}
+	lvl := int(level)
+	if lvl < 0 || lvl >= len(i.frames) {
+		return 0
+	}
+	if i.frames[lvl].word == nil {
+		return 0
+	}
+	return C.FeatherObj(i.registerObjScratch(i.frames[lvl].word))
+}
+
 //export goVarLinkNs
 func goVarLinkNs(interp C.FeatherInterp, local C.FeatherObj, nsPath C.FeatherObj, name C.FeatherObj) {
 	i := getInterp(interp)
@@ -2209,6 +2507,94 @@ func goInterpSetScript(interp C.FeatherInterp, path C.FeatherObj) {
 	i.scriptPath = i.getObject(FeatherObj(path))
 }
 
+//export goInterpState
+func goInterpState(interp C.FeatherInterp) *C.FeatherInterpState {
+	return getInterp(interp).core
+}
+
+//export goInterpEvalOnNewStack
+func goInterpEvalOnNewStack(interp C.FeatherInterp, script C.FeatherObj, flags C.FeatherEvalFlags) C.FeatherResult {
+	// A goroutine calling into C does so on a thread, and so a C stack, of
+	// its own. This one waits for it, so only one of them runs at a time,
+	// as with coroutines.
+	done := make(chan C.FeatherResult)
+	go func() {
+		done <- C.feather_script_eval_obj(nil, interp, script, flags)
+	}()
+	return <-done
+}
+
+//export goInterpEvalHook
+func goInterpEvalHook(interp C.FeatherInterp, command C.FeatherObj, line C.size_t) {
+	i := getInterp(interp)
+	if i == nil {
+		return
+	}
+	i.reportEval(FeatherObj(command), int(line))
+}
+
+//export goInterpEvalDoneHook
+func goInterpEvalDoneHook(interp C.FeatherInterp, command C.FeatherObj, code C.FeatherResult) {
+	i := getInterp(interp)
+	if i == nil {
+		return
+	}
+	i.reportEvalDone()
+}
+
+//export goInterpLimitCheck
+func goInterpLimitCheck(interp C.FeatherInterp) C.FeatherResult {
+	i := getInterp(interp)
+	if i == nil || !i.limitExceeded() {
+		return C.TCL_OK
+	}
+	return C.TCL_ERROR
+}
+
+//export goInterpTraceHook
+func goInterpTraceHook(interp C.FeatherInterp, kind *C.char, command C.FeatherObj) {
+	i := getInterp(interp)
+	if i == nil || !i.logging(slog.LevelDebug) {
+		return
+	}
+	i.log(slog.LevelDebug, "trace fired", "kind", C.GoString(kind), "command", i.getString(FeatherObj(command)))
+}
+
+//export goInterpSetSource
+func goInterpSetSource(interp C.FeatherInterp, obj C.FeatherObj, file C.FeatherObj, line C.size_t) {
+	i := getInterp(interp)
+	if i == nil {
+		return
+	}
+	o := i.getObject(FeatherObj(obj))
+	if o == nil || i.isLiteral(o) {
+		// Literals appear in many places, so they have no one source
+		return
+	}
+	loc := &sourceLoc{line: int(line)}
+	if file != 0 {
+		loc.file = i.getObject(FeatherObj(file))
+	}
+	o.source = loc
+}
+
+//export goInterpGetSource
+func goInterpGetSource(interp C.FeatherInterp, obj C.FeatherObj, file *C.FeatherObj) C.size_t {
+	*file = 0
+	i := getInterp(interp)
+	if i == nil {
+		return 0
+	}
+	o := i.getObject(FeatherObj(obj))
+	if o == nil || o.source == nil {
+		return 0
+	}
+	if o.source.file != nil {
+		*file = C.FeatherObj(i.registerObjScratch(o.source.file))
+	}
+	return C.size_t(o.source.line)
+}
+
 //export goVarNames
 func goVarNames(interp C.FeatherInterp, ns C.FeatherObj) C.FeatherObj {
 	i := getInterp(interp)
@@ -2413,7 +2799,7 @@ func goNsCopyCommand(interp C.FeatherInterp, srcNs C.FeatherObj, srcName C.Feath
 	dstNsObj := i.ensureNamespace(dstNsStr)
 
 	// Copy command to destination (it's a pointer copy, so both share the same Command)
-	dstNsObj.commands[dstNameStr] = cmd
+	i.setCommand(dstNsObj, dstNameStr, cmd)
 
 	return C.TCL_OK
 }
@@ -2494,17 +2880,7 @@ func goForeignMethods(interp C.FeatherInterp, obj C.FeatherObj) C.FeatherObj {
 	}
 
 	// Use the high-level registry if available
-	var methods []string
-	if i.ForeignRegistry != nil {
-		i.ForeignRegistry.mu.RLock()
-		if info, ok := i.ForeignRegistry.types[typeName]; ok {
-			for name := range info.methods {
-				methods = append(methods, name)
-			}
-			methods = append(methods, "destroy")
-		}
-		i.ForeignRegistry.mu.RUnlock()
-	}
+	methods := i.GetForeignMethods(typeName)
 	// Build a list of method names as *Obj
 	methodObjs := make([]*Obj, len(methods))
 	for j, m := range methods {
@@ -2520,19 +2896,16 @@ func goForeignInvoke(interp C.FeatherInterp, obj C.FeatherObj, method C.FeatherO
 		return C.TCL_ERROR
 	}
 	o := i.getObject(FeatherObj(obj))
-	if o == nil {
+	if o == nil || !i.IsForeignHandle(FeatherObj(obj)) {
 		i.SetResult(i.internString("not a foreign object"))
 		return C.TCL_ERROR
 	}
-	if _, ok := o.intrep.(*ForeignType); !ok {
-		i.SetResult(i.internString("not a foreign object"))
+	argv, err := i.getList(FeatherObj(args))
+	if err != nil {
+		i.SetErrorString(err.Error())
 		return C.TCL_ERROR
 	}
-	// For now, method invocation is not implemented at the low level.
-	// The high-level library will handle method dispatch via the type registry.
-	methodStr := i.getString(FeatherObj(method))
-	i.SetResult(i.internString("method invocation not implemented: " + methodStr))
-	return C.TCL_ERROR
+	return C.FeatherResult(i.foreignInvoke(o, i.getString(FeatherObj(method)), argv))
 }
 
 //export goForeignDestroy
@@ -2573,7 +2946,7 @@ func goVarResolveLink(interp C.FeatherInterp, name C.FeatherObj) C.FeatherObj {
 		if link, ok := frame.links[varName]; ok {
 			if link.targetLevel == -1 {
 				// Namespace variable link - return the namespace variable name
-				return C.FeatherObj(i.internString(link.nsName))
+				return C.FeatherObj(i.internString(qualifyVarName(link.nsPath, link.nsName)))
 			} else if link.targetLevel >= 0 && link.targetLevel < len(i.frames) {
 				frame = i.frames[link.targetLevel]
 				varName = link.targetName
@@ -2584,6 +2957,28 @@ func goVarResolveLink(interp C.FeatherInterp, name C.FeatherObj) C.FeatherObj {
 			break
 		}
 	}
-	// Return the resolved variable name (or original if not a link)
+
+	// Namespace variables, including globals, resolve to their fully
+	// qualified name, so that their traces apply however they are named
+	switch {
+	case strings.HasPrefix(varName, "::"):
+	case strings.Contains(varName, "::"):
+		nsPath := "::"
+		if frame.ns != nil {
+			nsPath = frame.ns.fullPath
+		}
+		varName = qualifyVarName(nsPath, varName)
+	case frame.locals.fullPath != "":
+		varName = qualifyVarName(frame.locals.fullPath, varName)
+	}
 	return C.FeatherObj(i.internString(varName))
 }
+
+// qualifyVarName returns the qualified name of the variable name in the
+// namespace nsPath.
+func qualifyVarName(nsPath, name string) string {
+	if nsPath == "::" || nsPath == "" {
+		return "::" + name
+	}
+	return nsPath + "::" + name
+}
@@ -0,0 +1,825 @@
+package feather
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Buffering modes for channels, as accepted by fconfigure -buffering.
+const (
+	BufferNone = "none"
+	BufferLine = "line"
+	BufferFull = "full"
+)
+
+// defaultBufferSize is the -buffersize of a newly created channel.
+const defaultBufferSize = 4096
+
+// DefaultFlushTimeout is how long the interpreter waits for the output
+// queued on a non-blocking channel to be written, unless
+// [Interp.SetFlushTimeout] changes it.
+const DefaultFlushTimeout = 5 * time.Second
+
+// ErrFlushTimeout is the error returned when the output queued on a
+// non-blocking channel is not written within the flush timeout.
+var ErrFlushTimeout = errors.New("feather: timed out writing queued output")
+
+// channel is a host-side TCL channel backed by Go readers and writers.
+//
+// The C core does not perform I/O; channels live entirely in the Go host
+// and are exposed to scripts through puts, flush, fconfigure and chan.
+type channel struct {
+	name      string
+	w         io.Writer
+	r         io.Reader
+	blocking  bool
+	buffering string
+	bufSize   int
+	buf       []byte       // output not yet handed to the writer
+	async     *asyncWriter // drains output when the channel is non-blocking
+	in        *bufio.Reader
+	eof       bool // the last read reached the end of input
+}
+
+// asyncWriter hands output to a writer from a background goroutine,
+// so that a slow destination never stalls the interpreter.
+type asyncWriter struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	w       io.Writer
+	queue   [][]byte
+	pending int // bytes queued but not yet written
+	err     error
+	closed  bool
+}
+
+func newAsyncWriter(w io.Writer) *asyncWriter {
+	a := &asyncWriter{w: w}
+	a.cond = sync.NewCond(&a.mu)
+	go a.run()
+	return a
+}
+
+func (a *asyncWriter) run() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		for len(a.queue) == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if len(a.queue) == 0 && a.closed {
+			return
+		}
+		chunk := a.queue[0]
+		a.queue = a.queue[1:]
+		a.mu.Unlock()
+		_, err := a.w.Write(chunk)
+		a.mu.Lock()
+		a.pending -= len(chunk)
+		if err != nil && a.err == nil {
+			a.err = err
+		}
+		a.cond.Broadcast()
+	}
+}
+
+// enqueue schedules p for writing and returns immediately.
+func (a *asyncWriter) enqueue(p []byte) {
+	a.mu.Lock()
+	a.queue = append(a.queue, p)
+	a.pending += len(p)
+	a.cond.Broadcast()
+	a.mu.Unlock()
+}
+
+// drain blocks until all queued output has been written, or for at most
+// d, after which it returns ErrFlushTimeout.
+func (a *asyncWriter) drain(d time.Duration) error {
+	// The timer wakes the wait below once the deadline has passed
+	deadline := time.Now().Add(d)
+	timer := time.AfterFunc(d, func() {
+		a.mu.Lock()
+		a.cond.Broadcast()
+		a.mu.Unlock()
+	})
+	defer timer.Stop()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.pending > 0 {
+		if !time.Now().Before(deadline) {
+			return ErrFlushTimeout
+		}
+		a.cond.Wait()
+	}
+	return a.err
+}
+
+// close drains outstanding output for at most d and stops the background
+// goroutine. Output still queued after d is discarded; a write in progress
+// is left to finish on its own.
+func (a *asyncWriter) close(d time.Duration) error {
+	err := a.drain(d)
+	a.mu.Lock()
+	a.closed = true
+	a.queue = nil
+	a.cond.Broadcast()
+	a.mu.Unlock()
+	return err
+}
+
+// queued returns the number of bytes waiting to be written.
+func (a *asyncWriter) queued() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pending
+}
+
+// write buffers p according to the channel's buffering mode.
+func (c *channel) write(p []byte) error {
+	c.buf = append(c.buf, p...)
+	switch c.buffering {
+	case BufferNone:
+		return c.flush()
+	case BufferLine:
+		if bytes.IndexByte(p, '\n') >= 0 {
+			return c.flush()
+		}
+	}
+	if len(c.buf) >= c.bufSize {
+		return c.flush()
+	}
+	return nil
+}
+
+// flush hands buffered output to the writer. In non-blocking mode the
+// data is queued on the background writer and flush returns immediately.
+func (c *channel) flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	data := c.buf
+	c.buf = nil
+	if c.async != nil {
+		c.async.enqueue(data)
+		return nil
+	}
+	_, err := c.w.Write(data)
+	return err
+}
+
+// input returns the buffered reader for the channel's input.
+func (c *channel) input() *bufio.Reader {
+	if c.in == nil {
+		c.in = bufio.NewReader(c.r)
+	}
+	return c.in
+}
+
+// readLine reads the next line without its line ending. ok is false when
+// the input is at its end and there was nothing left to read.
+func (c *channel) readLine() (line string, ok bool, err error) {
+	line, err = c.input().ReadString('\n')
+	if err == io.EOF {
+		c.eof = true
+		return line, line != "", nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	c.eof = false
+	return strings.TrimSuffix(line[:len(line)-1], "\r"), true, nil
+}
+
+// readChars reads up to n characters, or all remaining input if n < 0.
+func (c *channel) readChars(n int64) (string, error) {
+	in := c.input()
+	if n < 0 {
+		data, err := io.ReadAll(in)
+		c.eof = true
+		return string(data), err
+	}
+	var sb strings.Builder
+	for ; n > 0; n-- {
+		r, _, err := in.ReadRune()
+		if err == io.EOF {
+			c.eof = true
+			break
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), nil
+}
+
+// pendingInput returns the number of bytes read ahead but not yet consumed.
+func (c *channel) pendingInput() int {
+	if c.in == nil {
+		return 0
+	}
+	return c.in.Buffered()
+}
+
+// setBlocking switches between blocking and non-blocking output. Going
+// back to blocking waits for at most d for the queued output to be
+// written; if it is not, the channel stays non-blocking.
+func (c *channel) setBlocking(blocking bool, d time.Duration) error {
+	if blocking == c.blocking {
+		return nil
+	}
+	if blocking {
+		err := c.flush()
+		if c.async != nil {
+			if aerr := c.async.drain(d); aerr == ErrFlushTimeout {
+				return aerr
+			} else if err == nil {
+				err = aerr
+			}
+			c.async.close(0)
+			c.async = nil
+		}
+		c.blocking = true
+		return err
+	}
+	c.blocking = false
+	if c.w != nil {
+		c.async = newAsyncWriter(c.w)
+	}
+	return nil
+}
+
+// pendingOutput returns the number of bytes buffered or queued for output.
+func (c *channel) pendingOutput() int {
+	n := len(c.buf)
+	if c.async != nil {
+		n += c.async.queued()
+	}
+	return n
+}
+
+// close flushes all output, waiting for at most d for non-blocking
+// writes to complete.
+func (c *channel) close(d time.Duration) error {
+	err := c.flush()
+	if c.async != nil {
+		if aerr := c.async.close(d); err == nil {
+			err = aerr
+		}
+		c.async = nil
+	}
+	return err
+}
+
+// newChannel creates a blocking channel with the given buffering mode.
+func newChannel(name string, r io.Reader, w io.Writer, buffering string) *channel {
+	return &channel{
+		name:      name,
+		r:         r,
+		w:         w,
+		blocking:  true,
+		buffering: buffering,
+		bufSize:   defaultBufferSize,
+	}
+}
+
+// RegisterChannel makes a Go reader and/or writer available to scripts
+// under the given channel name. Either r or w may be nil.
+//
+// Output channels start out blocking and fully buffered; scripts can change
+// this with fconfigure or chan configure:
+//
+//	interp.RegisterChannel("sock1", conn, conn)
+//	interp.Eval(`fconfigure sock1 -blocking 0 -buffering line`)
+//	interp.Eval(`puts sock1 "hello"`)
+func (i *Interp) RegisterChannel(name string, r io.Reader, w io.Writer) {
+	if old, ok := i.channels[name]; ok {
+		old.close(i.getFlushTimeout())
+	}
+	i.channels[name] = newChannel(name, r, w, BufferFull)
+}
+
+// UnregisterChannel flushes and removes a channel previously added with
+// [Interp.RegisterChannel]. The underlying reader and writer are not closed.
+// Output queued on a non-blocking channel that is not written within the
+// flush timeout is discarded, and [ErrFlushTimeout] returned.
+func (i *Interp) UnregisterChannel(name string) error {
+	c, ok := i.channels[name]
+	if !ok {
+		return fmt.Errorf("can not find channel named \"%s\"", name)
+	}
+	delete(i.channels, name)
+	return c.close(i.getFlushTimeout())
+}
+
+// FlushChannels writes out all buffered output, waiting for any
+// non-blocking channels to drain, each for at most the flush timeout.
+func (i *Interp) FlushChannels() error {
+	var first error
+	for _, c := range i.channels {
+		err := c.flush()
+		if err == nil && c.async != nil {
+			err = c.async.drain(i.getFlushTimeout())
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// SetFlushTimeout sets how long flush, fconfigure -blocking 1,
+// [Interp.FlushChannels], [Interp.UnregisterChannel] and [Interp.Close]
+// wait for the output queued on a non-blocking channel to be written to a
+// slow destination. Past it they fail with [ErrFlushTimeout], and closing a
+// channel discards what is still queued. If d is 0 or negative, the
+// default ([DefaultFlushTimeout]) is used.
+func (i *Interp) SetFlushTimeout(d time.Duration) {
+	i.flushTimeout = d
+}
+
+// getFlushTimeout returns the effective flush timeout.
+func (i *Interp) getFlushTimeout() time.Duration {
+	if i.flushTimeout <= 0 {
+		return DefaultFlushTimeout
+	}
+	return i.flushTimeout
+}
+
+// SetStdin makes scripts read stdin, as with gets and read, from r instead
+// of the process's standard input. Input read ahead from the previous
+// reader is discarded.
+//
+//	interp.SetStdin(strings.NewReader("alice\n"))
+//	name, _ := interp.Eval(`gets stdin`) // "alice"
+func (i *Interp) SetStdin(r io.Reader) {
+	c, ok := i.channels["stdin"]
+	if !ok {
+		i.channels["stdin"] = newChannel("stdin", r, nil, BufferLine)
+		return
+	}
+	c.r = r
+	c.in = nil
+	c.eof = false
+}
+
+// SetStdout sends what scripts write to stdout, as with puts, to w instead
+// of the process's standard output. Output still buffered for the previous
+// writer is flushed to it first.
+func (i *Interp) SetStdout(w io.Writer) {
+	i.setChannelWriter("stdout", w, BufferLine)
+}
+
+// SetStderr sends what scripts write to stderr to w instead of the
+// process's standard error. Output still buffered for the previous writer
+// is flushed to it first.
+func (i *Interp) SetStderr(w io.Writer) {
+	i.setChannelWriter("stderr", w, BufferNone)
+}
+
+// Stdout returns a writer for the interpreter's stdout channel, for
+// commands implemented in Go to print through. What they write is
+// buffered and ordered with the output of puts, and goes wherever
+// [Interp.SetStdout] sends it.
+func (i *Interp) Stdout() io.Writer {
+	return channelWriter{i, "stdout"}
+}
+
+// Stderr returns a writer for the interpreter's stderr channel, as
+// [Interp.Stdout] does for stdout.
+func (i *Interp) Stderr() io.Writer {
+	return channelWriter{i, "stderr"}
+}
+
+// channelWriter writes to a channel looked up at each write, so that it
+// follows the channel to a new writer.
+type channelWriter struct {
+	interp *Interp
+	name   string
+}
+
+func (cw channelWriter) Write(p []byte) (int, error) {
+	c, err := cw.interp.lookupChannel(cw.name)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// setChannelWriter points a standard output channel at w, keeping its
+// configuration. The channel is recreated with the given buffering if it
+// is gone.
+func (i *Interp) setChannelWriter(name string, w io.Writer, buffering string) {
+	c, ok := i.channels[name]
+	if !ok {
+		i.channels[name] = newChannel(name, nil, w, buffering)
+		return
+	}
+	c.close(i.getFlushTimeout())
+	c.w = w
+	if !c.blocking {
+		c.async = newAsyncWriter(w)
+	}
+}
+
+// CaptureOutput evaluates script and returns what it wrote to stdout and
+// stderr along with its result, for tests and servers that need the
+// output of a script rather than the process's:
+//
+//	out, _, _, err := interp.CaptureOutput(`puts "hello"`)
+//	// out == "hello\n"
+//
+// Output goes back to the previous writers afterwards. As with
+// [Interp.Eval], result is nil when err is not.
+func (i *Interp) CaptureOutput(script string) (stdout, stderr string, result *Obj, err error) {
+	var outBuf, errBuf bytes.Buffer
+	restoreOut := i.redirectChannel("stdout", &outBuf, BufferLine)
+	restoreErr := i.redirectChannel("stderr", &errBuf, BufferNone)
+	result, err = i.Eval(script)
+	restoreOut()
+	restoreErr()
+	return outBuf.String(), errBuf.String(), result, err
+}
+
+// redirectChannel points a standard output channel at w and returns a
+// function that flushes it and points it back.
+func (i *Interp) redirectChannel(name string, w io.Writer, buffering string) func() {
+	old, existed := i.channels[name]
+	var oldW io.Writer
+	if existed {
+		oldW = old.w
+	}
+	i.setChannelWriter(name, w, buffering)
+	return func() {
+		c, ok := i.channels[name]
+		if !ok {
+			return
+		}
+		if !existed {
+			c.close(i.getFlushTimeout())
+			delete(i.channels, name)
+			return
+		}
+		i.setChannelWriter(name, oldW, buffering)
+	}
+}
+
+// registerChannels installs the standard channels and the channel commands.
+func (i *Interp) registerChannels() {
+	i.channels = map[string]*channel{
+		"stdin":  newChannel("stdin", os.Stdin, nil, BufferLine),
+		"stdout": newChannel("stdout", nil, os.Stdout, BufferLine),
+		"stderr": newChannel("stderr", nil, os.Stderr, BufferNone),
+	}
+	i.RegisterCommand("puts", cmdPuts)
+	i.RegisterCommand("gets", cmdGets)
+	i.RegisterCommand("read", cmdRead)
+	i.RegisterCommand("eof", cmdEOF)
+	i.RegisterCommand("flush", cmdFlush)
+	i.RegisterCommand("fconfigure", cmdFconfigure)
+	i.RegisterCommand("chan", cmdChan)
+}
+
+// lookupChannel returns the named channel or a TCL-style error.
+func (i *Interp) lookupChannel(name string) (*channel, error) {
+	c, ok := i.channels[name]
+	if !ok {
+		return nil, fmt.Errorf("can not find channel named \"%s\"", name)
+	}
+	return c, nil
+}
+
+// cmdPuts implements: puts ?-nonewline? ?channelId? string
+func cmdPuts(i *Interp, cmd *Obj, args []*Obj) Result {
+	newline := true
+	if len(args) > 1 && args[0].String() == "-nonewline" {
+		newline = false
+		args = args[1:]
+	}
+	var chanName string
+	switch len(args) {
+	case 1:
+		chanName = "stdout"
+	case 2:
+		chanName = args[0].String()
+	default:
+		return Error("wrong # args: should be \"puts ?-nonewline? ?channelId? string\"")
+	}
+	c, err := i.lookupChannel(chanName)
+	if err != nil {
+		return Error(err.Error())
+	}
+	if c.w == nil {
+		return Errorf("channel \"%s\" wasn't opened for writing", chanName)
+	}
+	s := args[len(args)-1].String()
+	if newline {
+		s += "\n"
+	}
+	if err := c.write([]byte(s)); err != nil {
+		return Errorf("error writing \"%s\": %v", chanName, err)
+	}
+	return OK("")
+}
+
+// readChannel returns the named channel if it can be read from.
+func (i *Interp) readChannel(name string) (*channel, error) {
+	c, err := i.lookupChannel(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.r == nil {
+		return nil, fmt.Errorf("channel \"%s\" wasn't opened for reading", name)
+	}
+	return c, nil
+}
+
+// cmdGets implements: gets channelId ?varName?
+func cmdGets(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 || len(args) > 2 {
+		return Error("wrong # args: should be \"gets channelId ?varName?\"")
+	}
+	name := args[0].String()
+	c, err := i.readChannel(name)
+	if err != nil {
+		return Error(err.Error())
+	}
+	line, ok, err := c.readLine()
+	if err != nil {
+		return Errorf("error reading \"%s\": %v", name, err)
+	}
+	if len(args) == 1 {
+		return OK(line)
+	}
+	if err := i.SetVarObj(args[1].String(), i.String(line)); err != nil {
+		return Error(err.Error())
+	}
+	if !ok {
+		return OK(-1)
+	}
+	return OK(utf8.RuneCountInString(line))
+}
+
+// cmdRead implements: read channelId ?numChars? and read ?-nonewline? channelId
+func cmdRead(i *Interp, cmd *Obj, args []*Obj) Result {
+	usage := Error("wrong # args: should be \"read channelId ?numChars?\" or \"read ?-nonewline? channelId\"")
+	nonewline := false
+	if len(args) > 0 && args[0].String() == "-nonewline" {
+		nonewline = true
+		args = args[1:]
+		if len(args) != 1 {
+			return usage
+		}
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return usage
+	}
+	n := int64(-1)
+	if len(args) == 2 {
+		v, err := args[1].Int()
+		if err != nil || v < 0 {
+			return Errorf("expected non-negative integer but got \"%s\"", args[1].String())
+		}
+		n = v
+	}
+	name := args[0].String()
+	c, err := i.readChannel(name)
+	if err != nil {
+		return Error(err.Error())
+	}
+	data, err := c.readChars(n)
+	if err != nil {
+		return Errorf("error reading \"%s\": %v", name, err)
+	}
+	if nonewline {
+		data = strings.TrimSuffix(data, "\n")
+	}
+	return OK(data)
+}
+
+// cmdEOF implements: eof channelId
+func cmdEOF(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error("wrong # args: should be \"eof channelId\"")
+	}
+	c, err := i.lookupChannel(args[0].String())
+	if err != nil {
+		return Error(err.Error())
+	}
+	return OK(c.eof)
+}
+
+// cmdFlush implements: flush channelId
+func cmdFlush(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Errorf("wrong # args: should be \"%s channelId\"", cmd.String())
+	}
+	return i.chanFlush(args[0].String())
+}
+
+func (i *Interp) chanFlush(name string) Result {
+	c, err := i.lookupChannel(name)
+	if err != nil {
+		return Error(err.Error())
+	}
+	if c.w == nil {
+		return Errorf("channel \"%s\" wasn't opened for writing", name)
+	}
+	if err := c.flush(); err != nil {
+		return Errorf("error flushing \"%s\": %v", name, err)
+	}
+	if c.blocking && c.async != nil {
+		if err := c.async.drain(i.getFlushTimeout()); err != nil {
+			return Errorf("error flushing \"%s\": %v", name, err)
+		}
+	}
+	return OK("")
+}
+
+// channelOptions lists the options understood by fconfigure in display order.
+var channelOptions = []string{"-blocking", "-buffering", "-buffersize"}
+
+// cmdFconfigure implements: fconfigure channelId ?-option? ?value -option value ...?
+func cmdFconfigure(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Errorf("wrong # args: should be \"%s channelId ?-option value ...?\"", cmd.String())
+	}
+	return i.chanConfigure(cmd.String(), args[0].String(), args[1:])
+}
+
+func (i *Interp) chanConfigure(cmdName, name string, opts []*Obj) Result {
+	c, err := i.lookupChannel(name)
+	if err != nil {
+		return Error(err.Error())
+	}
+	if len(opts) == 0 {
+		items := make([]*Obj, 0, len(channelOptions)*2)
+		for _, opt := range channelOptions {
+			items = append(items, i.String(opt), i.String(c.option(opt)))
+		}
+		return OK(i.List(items...))
+	}
+	if len(opts) == 1 {
+		opt := opts[0].String()
+		if !isChannelOption(opt) {
+			return badChannelOption(opt)
+		}
+		return OK(c.option(opt))
+	}
+	if len(opts)%2 != 0 {
+		return Errorf("wrong # args: should be \"%s channelId ?-option value ...?\"", cmdName)
+	}
+	for j := 0; j < len(opts); j += 2 {
+		opt := opts[j].String()
+		val := opts[j+1]
+		switch opt {
+		case "-blocking":
+			b, err := val.Bool()
+			if err != nil {
+				return Error(err.Error())
+			}
+			if err := c.setBlocking(b, i.getFlushTimeout()); err != nil {
+				return Errorf("error flushing \"%s\": %v", name, err)
+			}
+		case "-buffering":
+			mode := val.String()
+			if mode != BufferNone && mode != BufferLine && mode != BufferFull {
+				return Error("bad value for -buffering: must be one of full, line, or none")
+			}
+			c.buffering = mode
+			if mode == BufferNone {
+				if err := c.flush(); err != nil {
+					return Errorf("error flushing \"%s\": %v", name, err)
+				}
+			}
+		case "-buffersize":
+			n, err := val.Int()
+			if err != nil {
+				return Error(err.Error())
+			}
+			if n < 1 {
+				n = 1
+			}
+			c.bufSize = int(n)
+		default:
+			return badChannelOption(opt)
+		}
+	}
+	return OK("")
+}
+
+// option returns the string value of a channel option.
+func (c *channel) option(name string) string {
+	switch name {
+	case "-blocking":
+		if c.blocking {
+			return "1"
+		}
+		return "0"
+	case "-buffering":
+		return c.buffering
+	case "-buffersize":
+		return strconv.Itoa(c.bufSize)
+	}
+	return ""
+}
+
+func isChannelOption(name string) bool {
+	for _, opt := range channelOptions {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+func badChannelOption(opt string) Result {
+	return Errorf("bad option \"%s\": should be one of -blocking, -buffering, or -buffersize", opt)
+}
+
+// cmdChan implements the chan ensemble: configure, eof, flush, gets, names,
+// pending, puts, read.
+func cmdChan(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error("wrong # args: should be \"chan subcommand ?arg ...?\"")
+	}
+	sub := args[0].String()
+	rest := args[1:]
+	switch sub {
+	case "configure":
+		if len(rest) < 1 {
+			return Error("wrong # args: should be \"chan configure channelId ?-option value ...?\"")
+		}
+		return i.chanConfigure("chan configure", rest[0].String(), rest[1:])
+	case "eof":
+		if len(rest) != 1 {
+			return Error("wrong # args: should be \"chan eof channelId\"")
+		}
+		return cmdEOF(i, cmd, rest)
+	case "flush":
+		if len(rest) != 1 {
+			return Error("wrong # args: should be \"chan flush channelId\"")
+		}
+		return i.chanFlush(rest[0].String())
+	case "gets":
+		if len(rest) < 1 || len(rest) > 2 {
+			return Error("wrong # args: should be \"chan gets channelId ?varName?\"")
+		}
+		return cmdGets(i, cmd, rest)
+	case "names":
+		if len(rest) > 1 {
+			return Error("wrong # args: should be \"chan names ?pattern?\"")
+		}
+		names := make([]string, 0, len(i.channels))
+		for name := range i.channels {
+			if len(rest) == 0 || globMatch(rest[0].String(), name) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return OK(i.ListFrom(names))
+	case "pending":
+		if len(rest) != 2 {
+			return Error("wrong # args: should be \"chan pending mode channelId\"")
+		}
+		c, err := i.lookupChannel(rest[1].String())
+		if err != nil {
+			return Error(err.Error())
+		}
+		switch rest[0].String() {
+		case "output":
+			if c.w == nil {
+				return OK(-1)
+			}
+			return OK(c.pendingOutput())
+		case "input":
+			if c.r == nil {
+				return OK(-1)
+			}
+			return OK(c.pendingInput())
+		default:
+			return Errorf("bad mode \"%s\": must be input or output", rest[0].String())
+		}
+	case "puts":
+		return cmdPuts(i, cmd, rest)
+	case "read":
+		return cmdRead(i, cmd, rest)
+	default:
+		subs := []string{"configure", "eof", "flush", "gets", "names", "pending", "puts", "read"}
+		return Errorf("unknown or ambiguous subcommand \"%s\": must be %s, or %s",
+			sub, strings.Join(subs[:len(subs)-1], ", "), subs[len(subs)-1])
+	}
+}
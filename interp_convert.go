@@ -2,6 +2,7 @@ package feather
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 )
@@ -18,10 +19,14 @@ func asInt(o *Obj) (int64, error) {
 			return v, nil
 		}
 	}
-	// Fallback: parse string
+	// Fallback: parse string, trying plain decimal first
 	v, err := strconv.ParseInt(o.String(), 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("expected integer but got %q", o.String())
+		b, ok := parseBigInt(o.String())
+		if !ok || !b.IsInt64() {
+			return 0, fmt.Errorf("expected integer but got %q", o.String())
+		}
+		v = b.Int64()
 	}
 	// Shimmer: update internal representation
 	o.intrep = IntType(v)
@@ -49,6 +54,82 @@ func asDouble(o *Obj) (float64, error) {
 	return v, nil
 }
 
+// asBigInt converts o to an integer of any size, shimmering if needed.
+// It accepts the integer literal syntax of expr: an optional sign and a
+// 0x, 0o, 0b or 0d prefix.
+func asBigInt(o *Obj) (*big.Int, error) {
+	if o == nil {
+		return new(big.Int), nil
+	}
+	switch t := o.intrep.(type) {
+	case IntType:
+		return big.NewInt(int64(t)), nil
+	case BignumType:
+		return t.Value, nil
+	}
+	v, ok := parseBigInt(o.String())
+	if !ok {
+		return nil, fmt.Errorf("expected integer but got %q", o.String())
+	}
+	if v.IsInt64() {
+		o.intrep = IntType(v.Int64())
+	} else {
+		o.intrep = BignumType{Value: v}
+	}
+	return v, nil
+}
+
+// asUint converts o to uint64, accepting the bignums that hold values
+// past the range of int64.
+func asUint(o *Obj) (uint64, error) {
+	if n, err := asInt(o); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("expected unsigned integer but got %d", n)
+		}
+		return uint64(n), nil
+	}
+	v, err := asBigInt(o)
+	if err != nil {
+		return 0, err
+	}
+	if v.Sign() < 0 || !v.IsUint64() {
+		return 0, fmt.Errorf("integer value %s out of range", v)
+	}
+	return v.Uint64(), nil
+}
+
+// intPrefixBases maps the lowercased radix prefix letter of an integer literal to its base.
+var intPrefixBases = map[byte]int{'x': 16, 'o': 8, 'b': 2, 'd': 10}
+
+// parseBigInt parses an integer literal of any size.
+func parseBigInt(s string) (*big.Int, bool) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	base := 10
+	if len(s) > 2 && s[0] == '0' {
+		if b, ok := intPrefixBases[s[1]|0x20]; ok {
+			base = b
+			s = s[2:]
+		}
+	}
+	// SetString would accept another sign after the prefix.
+	if s == "" || s[0] == '-' || s[0] == '+' {
+		return nil, false
+	}
+	v, ok := new(big.Int).SetString(s, base)
+	if !ok {
+		return nil, false
+	}
+	if neg {
+		v.Neg(v)
+	}
+	return v, true
+}
+
 // asList converts o to a list if it has a list-compatible internal representation.
 // For string-to-list conversion, use obj.List() which handles parsing.
 func asList(o *Obj) ([]*Obj, error) {
@@ -58,6 +139,11 @@ func asList(o *Obj) ([]*Obj, error) {
 	// Try direct conversion via IntoList interface
 	if c, ok := o.intrep.(IntoList); ok {
 		if v, ok := c.IntoList(); ok {
+			// Numeric vectors box their elements once, on first list access
+			switch o.intrep.(type) {
+			case IntSliceType, FloatSliceType:
+				o.intrep = ListType(v)
+			}
 			return v, nil
 		}
 	}
@@ -71,6 +157,9 @@ func asDict(o *Obj) (*DictType, error) {
 	if o == nil {
 		return &DictType{Items: make(map[string]*Obj)}, nil
 	}
+	if d, ok := o.intrep.(*DictType); ok {
+		return d, nil
+	}
 	// Try direct conversion via IntoDict interface
 	if c, ok := o.intrep.(IntoDict); ok {
 		if items, order, ok := c.IntoDict(); ok {
@@ -108,3 +197,20 @@ func asBool(o *Obj) (bool, error) {
 	}
 	return false, fmt.Errorf("expected boolean but got %q", o.String())
 }
+
+// asByteArray converts o to binary data, shimmering if needed.
+// Characters above U+00FF keep only their low 8 bits, as in TCL.
+func asByteArray(o *Obj) []byte {
+	if o == nil {
+		return nil
+	}
+	if b, ok := o.intrep.(ByteArrayType); ok {
+		return b
+	}
+	b := encodeBinary(o.String())
+	// Keep other internal reps; they are more useful than the bytes
+	if o.intrep == nil {
+		o.intrep = ByteArrayType(b)
+	}
+	return b
+}
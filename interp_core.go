@@ -4,12 +4,15 @@ package feather
 #cgo CFLAGS: -I${SRCDIR}/src
 #include "feather.h"
 #include "host.h"
+#include "error_trace.h"
 #include <stdlib.h>
 */
 import "C"
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"runtime/cgo"
 	"strings"
 	"unsafe"
@@ -64,6 +67,22 @@ type FeatherInterp Handle
 // FeatherObj is a handle to an object
 type FeatherObj Handle
 
+// coreState is the state the C core keeps for an interpreter. It is in C
+// memory, so that the core reaches it without calling back into Go.
+type coreState = C.FeatherInterpState
+
+// coreTailcall is a tail call the C core has requested, kept in coreState.
+type coreTailcall = C.FeatherTailcall
+
+// newCoreState allocates a zeroed coreState, to be freed with freeCoreState.
+func newCoreState() *coreState {
+	return (*coreState)(C.calloc(1, C.sizeof_FeatherInterpState))
+}
+
+func freeCoreState(s *coreState) {
+	C.free(unsafe.Pointer(s))
+}
+
 // InternalCommandFunc is the signature for host command implementations.
 // Commands receive the interpreter, the command name and a list of argument objects.
 //
@@ -98,14 +117,17 @@ type Namespace struct {
 // CallFrame represents an execution frame on the call stack.
 // Each frame has its own variable environment.
 type CallFrame struct {
-	cmd    *Obj               // command being evaluated (persistent)
-	args   *Obj               // arguments to the command (persistent)
-	locals *Namespace         // local variable storage (for global frame, this IS the :: namespace)
-	links  map[string]varLink // upvar links: local name -> target variable
-	level  int                // frame index on the call stack
-	ns     *Namespace         // current namespace context
-	line   int                // line number where command was invoked (0 = not set)
-	lambda *Obj               // lambda expression for apply frames (nil = not apply)
+	cmd      *Obj               // command being evaluated (persistent)
+	args     *Obj               // arguments to the command (persistent)
+	locals   *Namespace         // local variable storage (for global frame, this IS the :: namespace)
+	links    map[string]varLink // upvar links: local name -> target variable
+	level    int                // frame index on the call stack
+	ns       *Namespace         // current namespace context
+	line     int                // line of the command running in the frame (0 = not set)
+	file     *Obj               // file of the script running in the frame (nil = none)
+	lambda   *Obj               // lambda expression for apply frames (nil = not apply)
+	word     *Obj               // word the proc was invoked by (nil = cmd)
+	deferred []*Obj             // scripts registered with defer, run when the frame is popped
 }
 
 // Procedure represents a user-defined procedure
@@ -129,6 +151,12 @@ type Command struct {
 	cmdType InternalCommandType      // type of command
 	builtin C.FeatherBuiltinCmd  // function pointer (only for CmdBuiltin)
 	proc    *Procedure       // procedure info (only for CmdProc)
+
+	fn       InternalCommandFunc // Go implementation (nil builtin only)
+	alias    string              // target of an alias created with Alias
+	aliasCmd []*Obj              // the target and its prefix arguments, as given to Alias
+	refs     int                 // namespace entries referring to this command
+	onDelete func()              // called when the last entry is removed
 }
 
 // scratchHandleBit is the high bit used to mark scratch arena handles.
@@ -144,17 +172,14 @@ func isScratchHandle(h FeatherObj) bool {
 // resetScratch clears the scratch arena, releasing all temporary objects.
 // Called after each top-level eval completes.
 func (i *Interp) resetScratch() {
-	i.scratch = make(map[FeatherObj]*Obj)
-	i.scratchNextID = scratchHandleBit | 1
+	i.scratch.reset()
+	i.pins.reset()
 }
 
 // internStringScratch creates a string object in the scratch arena.
 // Use for temporary strings that don't need to persist after eval.
 func (i *Interp) internStringScratch(s string) FeatherObj {
-	id := i.scratchNextID
-	i.scratchNextID++
-	i.scratch[id] = i.String(s)
-	return id
+	return i.scratch.add(i.String(s))
 }
 
 // registerObjScratch stores an *Obj in the scratch arena and returns its handle.
@@ -163,39 +188,181 @@ func (i *Interp) registerObjScratch(obj *Obj) FeatherObj {
 	if obj == nil {
 		return 0
 	}
-	id := i.scratchNextID
-	i.scratchNextID++
-	i.scratch[id] = obj
-	return id
+	if featherDebug {
+		i.checkOwner(obj)
+	}
+	return i.scratch.add(obj)
 }
 
 // register adds a Go command to the interpreter (internal).
 // The command will be invoked when the C layer doesn't find a builtin or proc.
 func (i *Interp) register(name string, fn InternalCommandFunc) {
+	i.log(slog.LevelDebug, "command registered", "name", name)
 	i.Commands[name] = fn
 	// Also register in interpreter's namespace storage for enumeration.
 	// These are Go commands dispatched via bind.unknown, not C builtins.
 	// We set builtin to nil so the C code falls through to unknown handler.
-	i.globalNamespace.commands[name] = &Command{
+	i.setCommand(i.globalNamespace, name, &Command{
 		cmdType: CmdBuiltin,
 		builtin: nil, // nil means dispatch via bind.unknown
+		fn:      fn,
+	})
+}
+
+// setCommand stores cmd as name in ns, releasing the command it replaces.
+func (i *Interp) setCommand(ns *Namespace, name string, cmd *Command) {
+	if old, ok := ns.commands[name]; ok {
+		if old == cmd {
+			return
+		}
+		i.releaseCommand(ns, name, old)
+	}
+	ns.commands[name] = cmd
+	cmd.refs++
+	i.recordCommand(ns, name)
+}
+
+// deleteCommand removes the entry name from ns. It reports whether there
+// was one.
+func (i *Interp) deleteCommand(ns *Namespace, name string) bool {
+	cmd, ok := ns.commands[name]
+	if !ok {
+		return false
+	}
+	delete(ns.commands, name)
+	i.releaseCommand(ns, name, cmd)
+	i.recordCommand(ns, name)
+	return true
+}
+
+// releaseCommand drops the reference the entry name in ns held on cmd.
+// A deleted Go command stops being reachable through Commands, and the
+// command's onDelete runs once no entry refers to it.
+func (i *Interp) releaseCommand(ns *Namespace, name string, cmd *Command) {
+	if cmd.fn != nil {
+		key := name
+		if ns != i.globalNamespace {
+			key = ns.fullPath + "::" + name
+		}
+		delete(i.Commands, key)
+	}
+	cmd.refs--
+	if cmd.refs <= 0 && cmd.onDelete != nil {
+		cmd.onDelete()
+	}
+}
+
+// resolveCommand finds the command a name refers to from the current
+// namespace: absolute names directly, others in the current namespace and
+// then the global namespace.
+func (i *Interp) resolveCommand(name string) *Command {
+	_, cmd := i.resolveCommandName(name)
+	return cmd
+}
+
+// resolveCommandName is like resolveCommand, and also returns the fully
+// qualified name of the command found.
+func (i *Interp) resolveCommandName(name string) (string, *Command) {
+	var candidates []string
+	if strings.HasPrefix(name, "::") {
+		candidates = []string{name}
+	} else {
+		if ns := i.frames[i.active].ns; ns != nil && ns != i.globalNamespace {
+			candidates = append(candidates, ns.fullPath+"::"+name)
+		}
+		candidates = append(candidates, "::"+name)
 	}
+	for _, qualified := range candidates {
+		sep := strings.LastIndex(qualified, "::")
+		path := qualified[:sep]
+		if path == "" {
+			path = "::"
+		}
+		if ns, ok := i.namespaces[path]; ok {
+			if cmd, ok := ns.commands[qualified[sep+2:]]; ok {
+				return qualified, cmd
+			}
+		}
+	}
+	return "", nil
+}
+
+// qualifyCommand qualifies a new command's name relative to the current
+// namespace, the way proc does. It returns the namespace, creating it if
+// needed, the simple name, and the fully qualified name.
+func (i *Interp) qualifyCommand(name string) (*Namespace, string, string) {
+	if !strings.HasPrefix(name, "::") {
+		if ns := i.frames[i.active].ns; ns != nil && ns != i.globalNamespace {
+			name = ns.fullPath + "::" + name
+		} else {
+			name = "::" + name
+		}
+	}
+	sep := strings.LastIndex(name, "::")
+	path := name[:sep]
+	if path == "" {
+		path = "::"
+	}
+	return i.ensureNamespace(path), name[sep+2:], name
+}
+
+// commandName returns the fully qualified name cmd is registered under,
+// which changes when the command is renamed. name, its last known name, is
+// tried first and returned if cmd is no longer registered.
+func (i *Interp) commandName(cmd *Command, name string) string {
+	sep := strings.LastIndex(name, "::")
+	if sep >= 0 {
+		path := name[:sep]
+		if path == "" {
+			path = "::"
+		}
+		if ns := i.namespaces[path]; ns != nil && ns.commands[name[sep+2:]] == cmd {
+			return name
+		}
+	}
+	for path, ns := range i.namespaces {
+		for simple, c := range ns.commands {
+			if c == cmd {
+				if path == "::" {
+					return "::" + simple
+				}
+				return path + "::" + simple
+			}
+		}
+	}
+	return name
 }
 
 // dispatch handles command lookup and execution for Go-registered commands.
 func (i *Interp) dispatch(cmd FeatherObj, args []FeatherObj) FeatherResult {
 	cmdStr := i.getString(cmd)
-	if fn, ok := i.Commands[cmdStr]; ok {
-		return fn(i, cmd, args)
+	fn, ok := i.Commands[cmdStr]
+	if c := i.resolveCommand(cmdStr); c != nil && c.fn != nil {
+		fn, ok = c.fn, true
+	}
+	if ok {
+		code := i.invoke(fn, cmd, args)
+		if i.reportLimit > 0 {
+			words := make([]string, len(args)+1)
+			words[0] = quote(cmdStr)
+			for j, arg := range args {
+				words[j+1] = quote(i.getString(arg))
+			}
+			i.recordEvent("command", strings.Join(words, " "), code, i.resultString())
+		}
+		return code
+	}
+	if i.logging(slog.LevelDebug) {
+		i.log(slog.LevelDebug, "unknown command", "name", cmdStr, "args", i.logWords(args), "handled", i.unknownHandler != nil)
 	}
 	if i.unknownHandler != nil {
-		return i.unknownHandler(i, cmd, args)
+		return i.invoke(i.unknownHandler, cmd, args)
 	}
 	i.SetErrorString("invalid command name \"" + cmdStr + "\"")
 	return ResultError
 }
 
-// SetUnknownHandler sets a handler that is called when a command is not found.
+// setUnknownHandler sets a handler that is called when a command is not found.
 // The handler receives the command name and arguments, and can implement
 // custom command resolution (e.g., auto-loading, dynamic dispatch).
 // Set to nil to restore default behavior (return error).
@@ -208,6 +375,11 @@ const DefaultRecursionLimit = 1000
 
 // SetRecursionLimit sets the maximum call stack depth.
 // If limit is 0 or negative, the default limit (1000) is used.
+//
+// A proc or lambda that ends with tailcall leaves the stack before its
+// replacement runs, so tail recursion is not limited by depth. Other
+// recursion is limited by this alone: once a proc or lambda finds half the
+// C stack limit used, its body runs on the stack of a new thread.
 func (i *Interp) SetRecursionLimit(limit int) {
 	if limit <= 0 {
 		i.recursionLimit = DefaultRecursionLimit
@@ -216,6 +388,32 @@ func (i *Interp) SetRecursionLimit(limit int) {
 	}
 }
 
+// DefaultStackLimit is the default number of bytes of C stack evaluation
+// in an interpreter may use on a thread.
+const DefaultStackLimit = 4 << 20
+
+// SetStackLimit sets the number of bytes of C stack evaluation in the
+// interpreter may use on a thread before commands fail with "out of stack
+// space (infinite loop?)", with the error code TCL LIMIT STACK. If bytes is
+// 0 or negative, the default ([DefaultStackLimit]) is used.
+//
+// The recursion limit counts calls to procs, which move to a new stack
+// once they have used half of the limit, but substitutions and
+// expressions nested deeply within one command use the C stack too. The
+// limit stops them with an error before they overflow the stack and crash
+// the process. It must be smaller than the stacks of the threads that
+// evaluate scripts, 8MB on most Linux systems, with room to spare for the
+// commands written in Go that they call.
+//
+// Each interpreter has its own limit. SetStackLimit returns the previous
+// one, so that it can be restored.
+func (i *Interp) SetStackLimit(bytes int) int {
+	if bytes <= 0 {
+		bytes = DefaultStackLimit
+	}
+	return int(C.feather_set_stack_limit(nil, C.FeatherInterp(i.handle), C.size_t(bytes)))
+}
+
 // getRecursionLimit returns the effective recursion limit.
 func (i *Interp) getRecursionLimit() int {
 	if i.recursionLimit <= 0 {
@@ -367,17 +565,27 @@ func (i *Interp) resultString() string {
 
 // eval evaluates a script string using the C interpreter (internal).
 func (i *Interp) eval(script string) (string, error) {
+	if err := i.checkOpen(); err != nil {
+		return "", err
+	}
+	if i.evalDepth == 0 {
+		i.reclaimForeign()
+	}
 	scriptHandle := i.internStringScratch(script)
 
 	// Track nesting depth to support nested evals (e.g., source command)
 	i.evalDepth++
 
-	// Reset scratch arena only at the END of the outermost eval
+	// Reset scratch arena only at the END of the outermost eval, and not
+	// while a coroutine, whose arena is swapped in, is running
 	defer func() {
 		i.evalDepth--
-		if i.evalDepth == 0 {
+		if i.evalDepth == 0 && i.currentCoroutine == nil {
 			i.resetScratch()
 		}
+		if i.evalDepth == 0 {
+			i.valueTooLarge = ""
+		}
 	}()
 
 	// Call the C interpreter
@@ -408,7 +616,7 @@ func (i *Interp) eval(script string) (string, error) {
 			return i.resultString(), nil
 		}
 		if code == C.TCL_ERROR {
-			return "", &EvalError{Message: i.resultString()}
+			return "", i.evalError(scriptHandle)
 		}
 		if code == C.TCL_BREAK {
 			return "", &EvalError{Message: "invoked \"break\" outside of a loop"}
@@ -428,7 +636,48 @@ func (i *Interp) eval(script string) (string, error) {
 		return "", &EvalError{Message: "invoked \"continue\" outside of a loop"}
 	}
 
-	return "", &EvalError{Message: i.resultString()}
+	e := i.evalError(scriptHandle)
+	if i.valueTooLarge != "" {
+		e.Message, e.ErrorCode = i.valueTooLarge, "TCL LIMIT VALUE"
+	}
+	return "", e
+}
+
+// throwError sets up the error a Go command raised with Throw as the throw
+// command does, so that the error collects -errorinfo as it propagates.
+func (i *Interp) throwError(code *Obj, cmd FeatherObj, args []FeatherObj) {
+	i.returnOptions = i.List(i.String("-code"), i.Int(1), i.String("-errorcode"), code)
+	if C.feather_error_is_active(nil, C.FeatherInterp(i.handle)) != 0 {
+		return
+	}
+	words := make([]*Obj, len(args))
+	for n, h := range args {
+		words[n] = i.objForHandle(h)
+	}
+	C.feather_error_init(nil, C.FeatherInterp(i.handle), C.FeatherObj(i.handleForObj(i.result)),
+		C.FeatherObj(cmd), C.FeatherObj(i.handleForObj(i.List(words...))))
+}
+
+// evalError describes the error a script evaluated at the top level ended
+// with. The error information collected as the error propagated is
+// finished as catch would, setting ::errorInfo and ::errorCode.
+func (i *Interp) evalError(script FeatherObj) *EvalError {
+	msg := i.resultString()
+	e := &EvalError{Message: msg, ErrorCode: "NONE", ErrorInfo: msg}
+	if C.feather_error_is_active(nil, C.FeatherInterp(i.handle)) == 0 {
+		return e
+	}
+	C.feather_error_finalize(nil, C.FeatherInterp(i.handle), C.FeatherObj(script))
+	opts, _ := asList(i.returnOptions)
+	for j := 0; j+1 < len(opts); j += 2 {
+		switch opts[j].String() {
+		case "-errorcode":
+			e.ErrorCode = opts[j+1].String()
+		case "-errorinfo":
+			e.ErrorInfo = opts[j+1].String()
+		}
+	}
+	return e
 }
 
 // Result returns the current result string
@@ -447,26 +696,29 @@ func (i *Interp) ResultHandle() FeatherObj {
 
 // EvalError represents an evaluation error
 type EvalError struct {
-	Message string
+	Message   string
+	ErrorCode string // the -errorcode of the error, as set by throw; NONE if it has none
+	ErrorInfo string // the stack trace, as in ::errorInfo; the message if there is none
 }
 
 func (e *EvalError) Error() string {
 	return e.Message
 }
 
-// internString stores a string in the scratch arena and returns its handle.
+// internString returns the handle of s in the literal table if it has one,
+// and otherwise stores s in the scratch arena and returns its handle.
 // Use internStringPermanent for strings that need to persist after eval.
 func (i *Interp) internString(s string) FeatherObj {
+	if h := i.literal(s); h != 0 {
+		return h
+	}
 	return i.internStringScratch(s)
 }
 
 // internStringPermanent stores a string in permanent storage.
 // Use for strings that must persist across evals (e.g., namespace paths).
 func (i *Interp) internStringPermanent(s string) FeatherObj {
-	id := i.nextID
-	i.nextID++
-	i.objects[id] = i.String(s)
-	return id
+	return i.objects.add(i.String(s))
 }
 
 
@@ -482,10 +734,27 @@ func (i *Interp) registerObjPermanent(obj *Obj) FeatherObj {
 	if obj == nil {
 		return 0
 	}
-	id := i.nextID
-	i.nextID++
-	i.objects[id] = obj
-	return id
+	if featherDebug {
+		i.checkOwner(obj)
+	}
+	return i.objects.add(obj)
+}
+
+// checkOwner panics if obj belongs to another interpreter. featherdebug
+// builds check each object handed to the interpreter. Objects without an
+// interpreter may hold objects of any.
+func (i *Interp) checkOwner(obj *Obj) {
+	if i != nil && obj != nil && obj.interp != nil && obj.interp != i {
+		panic(fmt.Sprintf("feather: *Obj %q created by interpreter %p used in interpreter %p", obj.String(), obj.interp, i))
+	}
+}
+
+// releaseObjPermanent frees a handle returned by registerObjPermanent, so
+// that permanent storage can reuse its slot.
+func (i *Interp) releaseObjPermanent(h FeatherObj) {
+	if !isScratchHandle(h) && !isLiteralHandle(h) {
+		i.objects.release(h)
+	}
 }
 
 // NewForeignHandle creates a new foreign object with the given type name and Go value.
@@ -493,13 +762,11 @@ func (i *Interp) registerObjPermanent(obj *Obj) FeatherObj {
 // Returns the handle to the new foreign object.
 // Foreign objects are stored in permanent storage (not scratch) for explicit lifecycle.
 func (i *Interp) NewForeignHandle(typeName string, value any) FeatherObj {
-	id := i.nextID
-	i.nextID++
 	obj := &Obj{intrep: &ForeignType{TypeName: typeName, Value: value}, interp: i}
+	// Use permanent storage - foreign objects have explicit lifecycle management
+	id := i.objects.add(obj)
 	// Override the string representation to include the handle ID
 	obj.bytes = fmt.Sprintf("<%s:%d>", typeName, id)
-	// Use permanent storage - foreign objects have explicit lifecycle management
-	i.objects[id] = obj
 	return id
 }
 
@@ -510,10 +777,7 @@ func (i *Interp) NewForeignHandleNamed(typeName, handleName string, value any) (
 	obj := &Obj{intrep: &ForeignType{TypeName: typeName, Value: value}, interp: i}
 	obj.bytes = handleName
 	// Store in permanent storage
-	id := i.nextID
-	i.nextID++
-	i.objects[id] = obj
-	return obj, id
+	return obj, i.objects.add(obj)
 }
 
 // IsForeignHandle returns true if the object is a foreign object.
@@ -566,15 +830,19 @@ func (i *Interp) getForeignValue(h FeatherObj) any {
 	return nil
 }
 
-// getObject retrieves an object by handle from either arena.
+// getObject retrieves an object by handle from either arena or the
+// literal table.
 func (i *Interp) getObject(h FeatherObj) *Obj {
 	if h == 0 {
 		return nil
 	}
 	if isScratchHandle(h) {
-		return i.scratch[h]
+		return i.scratch.get(h)
+	}
+	if isLiteralHandle(h) {
+		return i.literals.objs.get(h)
 	}
-	return i.objects[h]
+	return i.objects.get(h)
 }
 
 // handleForObj returns a FeatherObj handle for a *Obj, registering it if needed.
@@ -631,18 +899,30 @@ func (i *Interp) getDouble(h FeatherObj) (float64, error) {
 // Performs shimmering: parses string representation as list if needed.
 // Returns an error if the value cannot be converted to a list.
 func (i *Interp) getList(h FeatherObj) ([]FeatherObj, error) {
+	items, err := i.listItems(h)
+	if err != nil {
+		return nil, err
+	}
+	// Convert []*Obj to []FeatherObj handles
+	handles := make([]FeatherObj, len(items))
+	for idx, item := range items {
+		handles[idx] = i.registerObj(item)
+	}
+	return handles, nil
+}
+
+// listItems returns the elements of a list object, shimmering like getList.
+// Unlike getList it does not create a handle per element, so prefer it when
+// only the length, a single element or the *Obj values are needed.
+// The returned slice is the object's internal representation.
+func (i *Interp) listItems(h FeatherObj) ([]*Obj, error) {
 	obj := i.getObject(h)
 	if obj == nil {
 		return nil, fmt.Errorf("nil object")
 	}
 	// Try to get list via asList (works for ListType)
 	if list, err := asList(obj); err == nil {
-		// Convert []*Obj to []FeatherObj handles
-		handles := make([]FeatherObj, len(list))
-		for idx, item := range list {
-			handles[idx] = i.registerObj(item)
-		}
-		return handles, nil
+		return list, nil
 	}
 	// Shimmer: string → list via C's feather_list_parse_obj
 	strHandle := i.internString(obj.String())
@@ -656,6 +936,10 @@ func (i *Interp) getList(h FeatherObj) ([]FeatherObj, error) {
 		}
 		return nil, fmt.Errorf("failed to parse list")
 	}
+	// A list past the limit of SetValueLimits stopped growing part way
+	if i.valueTooLarge != "" {
+		return nil, errors.New(i.valueTooLarge)
+	}
 
 	// Get the list items from the parsed result
 	listObj := i.getObject(listHandle)
@@ -667,15 +951,9 @@ func (i *Interp) getList(h FeatherObj) ([]FeatherObj, error) {
 		return nil, err
 	}
 
-	// Convert []*Obj to []FeatherObj handles
-	handles := make([]FeatherObj, len(items))
-	for idx, item := range items {
-		handles[idx] = i.registerObj(item)
-	}
-
 	// Store as ListType on the original object for future lookups
 	obj.intrep = ListType(items)
-	return handles, nil
+	return items, nil
 }
 
 // GetDict returns the dict representation of an object as handles.
@@ -697,7 +975,7 @@ func (i *Interp) getDict(h FeatherObj) (map[string]FeatherObj, []string, error)
 	}
 	// Shimmer: string/list → dict
 	// First get as list (which handles parsing if needed)
-	items, err := i.getList(h)
+	items, err := i.listItems(h)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -709,10 +987,13 @@ func (i *Interp) getDict(h FeatherObj) (map[string]FeatherObj, []string, error)
 	dictItems := make(map[string]*Obj)
 	var dictOrder []string
 	for j := 0; j < len(items); j += 2 {
-		key := i.getString(items[j])
-		val := i.getObject(items[j+1])
+		key := items[j].String()
+		val := items[j+1]
 		// If key already exists, update value but keep order position
 		if _, exists := dictItems[key]; !exists {
+			if i.refuseGrowth(i.dictTooLarge(len(dictOrder) + 1)) {
+				return nil, nil, errors.New(i.valueTooLarge)
+			}
 			dictOrder = append(dictOrder, key)
 		}
 		dictItems[key] = val
@@ -735,7 +1016,7 @@ func (i *Interp) getDict(h FeatherObj) (map[string]FeatherObj, []string, error)
 // Note: For dicts, this returns the number of key-value pairs times 2
 // (the list representation length).
 func (i *Interp) ListLen(h FeatherObj) int {
-	items, err := i.getList(h)
+	items, err := i.listItems(h)
 	if err != nil {
 		return 0
 	}
@@ -750,11 +1031,11 @@ func (i *Interp) ListLen(h FeatherObj) int {
 //   - The object cannot be converted to a list
 //   - The object handle is invalid
 func (i *Interp) ListIndex(h FeatherObj, idx int) FeatherObj {
-	items, err := i.getList(h)
+	items, err := i.listItems(h)
 	if err != nil || idx < 0 || idx >= len(items) {
 		return 0
 	}
-	return items[idx]
+	return i.registerObj(items[idx])
 }
 
 // DictGet retrieves the value for a key in a dict object.
@@ -842,6 +1123,24 @@ func (i *Interp) setVar(name, value string) {
 	frame.locals.vars[name] = i.String(value)
 }
 
+// setVarObj sets a variable in the current frame the way the set command
+// does, following qualified names and upvar links and firing write traces.
+func (i *Interp) setVarObj(name string, value *Obj) error {
+	if callCSet(i.handle, i.handleForObj(i.List(i.String(name), value))) != C.TCL_OK {
+		return errors.New(i.result.String())
+	}
+	return nil
+}
+
+// getVarObj reads a variable in the current frame the way the set command
+// does, following qualified names and upvar links and firing read traces.
+func (i *Interp) getVarObj(name string) (*Obj, error) {
+	if callCSet(i.handle, i.handleForObj(i.List(i.String(name)))) != C.TCL_OK {
+		return nil, errors.New(i.result.String())
+	}
+	return dictShared(i.result), nil
+}
+
 // GetVar returns the string value of a variable from the current frame, or empty string if not found.
 func (i *Interp) GetVar(name string) string {
 	frame := i.frames[i.active]
@@ -867,20 +1166,17 @@ func getInterp(h C.FeatherInterp) *Interp {
 
 // storeBuilder stores a string builder and returns a handle for it.
 func (i *Interp) storeBuilder(b *strings.Builder) FeatherObj {
-	id := i.nextID
-	i.nextID++
-	i.builders[id] = b
-	return id
+	return i.builders.add(b)
 }
 
 // getBuilder retrieves a string builder by handle.
 func (i *Interp) getBuilder(h FeatherObj) *strings.Builder {
-	return i.builders[h]
+	return i.builders.get(h)
 }
 
 // releaseBuilder removes a builder from storage.
 func (i *Interp) releaseBuilder(h FeatherObj) {
-	delete(i.builders, h)
+	i.builders.release(h)
 }
 
 // Keep unused import to ensure cgo is used
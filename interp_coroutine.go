@@ -0,0 +1,281 @@
+package feather
+
+// Coroutines follow TCL 8.6:
+//
+//	proc gen {} {
+//	    yield [info coroutine]
+//	    for {set i 0} {1} {incr i} { yield $i }
+//	}
+//	coroutine next gen   ;# returns ::next
+//	next                 ;# returns 0
+//	next                 ;# returns 1
+//
+// The C evaluator keeps its state on the C stack, so each coroutine body runs
+// on its own goroutine, and therefore its own C stack. Control is handed back
+// and forth over channels: exactly one side runs at a time, so the
+// interpreter is never used concurrently. A coroutine also has its own call
+// stack, rooted at the global frame, which is swapped in while it runs.
+//
+// Scratch objects created by a suspended coroutine may still be referenced
+// from its C stack, so each coroutine has a scratch arena of its own, swapped
+// in with its call stack, which lasts until the coroutine finishes. The
+// interpreter's own arena is reset as usual when the outermost eval returns.
+
+// coroutine is a suspended or running coroutine.
+type coroutine struct {
+	name  string   // fully qualified name, as returned by info coroutine
+	entry *Command // the command that resumes it
+
+	// The coroutine's own call stack while it is suspended.
+	frames      []*CallFrame
+	active      int
+	savedLocals []*Namespace
+
+	// The coroutine's scratch arena and the strings lent to its C stack,
+	// swapped in while it runs.
+	scratch handleTable[*Obj]
+	pins    stringPins
+
+	// The tail calls requested on the coroutine's call stack, swapped into
+	// the core's state while it runs.
+	tailcallPending, tailcallReady coreTailcall
+
+	resume chan coroMessage // to the coroutine: resume value or kill
+	yield  chan coroMessage // from the coroutine: yielded value or result
+
+	running bool // the body is executing
+	yieldto bool // suspended by yieldto, so it is resumed with any number of args
+	killed  bool // its command was deleted
+	unwind  bool // killed while suspended, so every command fails until it finishes
+	done    bool // the body has finished
+}
+
+// coroMessage passes control between a coroutine and the code resuming it.
+type coroMessage struct {
+	code    FeatherResult
+	value   *Obj
+	yieldto *Obj // command for the resumer to run in its own context
+	done    bool // the body finished with code and value
+}
+
+// registerCoroutines installs the coroutine commands.
+func (i *Interp) registerCoroutines() {
+	i.coroutines = make(map[*coroutine]struct{})
+	i.RegisterCommand("coroutine", cmdCoroutine)
+	i.RegisterCommand("yield", cmdYield)
+	i.RegisterCommand("yieldto", cmdYieldto)
+}
+
+// Coroutine creates a coroutine called name whose body is script, evaluated
+// like the body of a proc with no arguments. It returns the value of the
+// first yield, or the result of script if it finishes without yielding.
+//
+//	first, err := interp.Coroutine("next", "yield a; yield b; return c")
+//	second, err := interp.Resume("next", nil)
+func (i *Interp) Coroutine(name, script string) (*Obj, error) {
+	return i.Call("coroutine", name, "apply", i.List(i.List(), i.String(script)))
+}
+
+// Resume resumes the coroutine called name. The yield that suspended it
+// returns value, converted as for [Interp.Call]; a nil value resumes it with
+// the empty string. Resume returns the value of the next yield, or the
+// coroutine's result once it finishes.
+func (i *Interp) Resume(name string, value any) (*Obj, error) {
+	return i.Call(name, value)
+}
+
+// cmdCoroutine implements: coroutine name cmd ?arg ...?
+func cmdCoroutine(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 2 {
+		return Error(`wrong # args: should be "coroutine name cmd ?arg ...?"`)
+	}
+	ns, simple, name := i.qualifyCommand(args[0].String())
+
+	global := *i.frames[0]
+	co := &coroutine{
+		name:    name,
+		frames:  []*CallFrame{&global},
+		scratch: handleTable[*Obj]{tag: scratchHandleBit},
+		resume:  make(chan coroMessage),
+		yield:   make(chan coroMessage),
+	}
+	co.entry = &Command{
+		cmdType:  CmdBuiltin,
+		fn:       i.wrapCommand(co.cmdResume),
+		onDelete: func() { i.killCoroutine(co) },
+	}
+	i.setCommand(ns, simple, co.entry)
+	i.coroutines[co] = struct{}{}
+
+	command := i.List(args[1:]...)
+	go func() {
+		<-co.resume
+		code := FeatherResult(callCEval(i.handle, i.handleForObj(command)))
+		co.yield <- coroMessage{code: code, value: i.result, done: true}
+	}()
+	return i.coroutineReply(co, i.enterCoroutine(co, coroMessage{}))
+}
+
+// cmdResume implements the command named after a coroutine: name ?value?,
+// or name ?arg ...? after yieldto.
+func (co *coroutine) cmdResume(i *Interp, cmd *Obj, args []*Obj) Result {
+	if co.running {
+		return Errorf("coroutine %q is already running", cmd.String())
+	}
+	value := i.String("")
+	if co.yieldto {
+		value = i.List(args...)
+	} else if len(args) > 1 {
+		return Errorf("wrong # args: should be \"%s ?arg?\"", cmd.String())
+	} else if len(args) == 1 {
+		value = args[0]
+	}
+	return i.coroutineReply(co, i.enterCoroutine(co, coroMessage{code: ResultOK, value: value}))
+}
+
+// cmdYield implements: yield ?value?
+func cmdYield(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) > 1 {
+		return Error(`wrong # args: should be "yield ?value?"`)
+	}
+	co := i.currentCoroutine
+	if co == nil {
+		return Error("yield can only be called in a coroutine")
+	}
+	value := i.String("")
+	if len(args) == 1 {
+		value = args[0]
+	}
+	return co.suspend(coroMessage{value: value})
+}
+
+// cmdYieldto implements: yieldto command ?arg ...?
+func cmdYieldto(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "yieldto command ?arg ...?"`)
+	}
+	co := i.currentCoroutine
+	if co == nil {
+		return Error("yieldto can only be called in a coroutine")
+	}
+	return co.suspend(coroMessage{yieldto: i.List(args...)})
+}
+
+// suspend hands control back to the resumer and waits to be resumed.
+// It runs on the coroutine's goroutine.
+func (co *coroutine) suspend(msg coroMessage) Result {
+	if co.killed {
+		return Error("coroutine deleted")
+	}
+	co.yieldto = msg.yieldto != nil
+	co.yield <- msg
+	reply := <-co.resume
+	if reply.code != ResultOK {
+		return Result{code: reply.code, obj: reply.value, hasObj: true}
+	}
+	return OK(reply.value)
+}
+
+// enterCoroutine runs co with its own call stack until it yields or
+// finishes, and returns what it passed back.
+func (i *Interp) enterCoroutine(co *coroutine, msg coroMessage) coroMessage {
+	frames, active, savedLocals, caller := i.frames, i.active, i.savedLocals, i.currentCoroutine
+	i.frames, i.active, i.savedLocals, i.currentCoroutine = co.frames, co.active, co.savedLocals, co
+	i.scratch, co.scratch = co.scratch, i.scratch
+	i.pins, co.pins = co.pins, i.pins
+	co.swapTailcalls(i.core)
+	i.setInfoCoroutine(co)
+	co.running = true
+
+	co.resume <- msg
+	reply := <-co.yield
+
+	co.running = false
+	co.frames, co.active, co.savedLocals = i.frames, i.active, i.savedLocals
+	i.frames, i.active, i.savedLocals, i.currentCoroutine = frames, active, savedLocals, caller
+	i.scratch, co.scratch = co.scratch, i.scratch
+	i.pins, co.pins = co.pins, i.pins
+	co.swapTailcalls(i.core)
+	i.setInfoCoroutine(caller)
+	return reply
+}
+
+// swapTailcalls exchanges the tail calls pending in the core's state with
+// those of the coroutine, since they belong to the call stack running.
+func (co *coroutine) swapTailcalls(core *coreState) {
+	core.tailcall_pending, co.tailcallPending = co.tailcallPending, core.tailcall_pending
+	core.tailcall_ready, co.tailcallReady = co.tailcallReady, core.tailcall_ready
+}
+
+// coroutineReply turns what a coroutine passed back into the result of the
+// command that resumed it.
+func (i *Interp) coroutineReply(co *coroutine, reply coroMessage) Result {
+	if reply.done {
+		i.finishCoroutine(co)
+		switch reply.code {
+		case ResultOK, ResultReturn:
+			return OK(reply.value)
+		case ResultBreak:
+			return Error(`invoked "break" outside of a loop`)
+		case ResultContinue:
+			return Error(`invoked "continue" outside of a loop`)
+		}
+		return Result{code: reply.code, obj: reply.value, hasObj: true}
+	}
+	if reply.yieldto != nil {
+		code := FeatherResult(callCEval(i.handle, i.handleForObj(reply.yieldto)))
+		return Result{code: code, obj: i.result, hasObj: true}
+	}
+	return OK(reply.value)
+}
+
+// finishCoroutine forgets a coroutine whose body has finished, releases its
+// scratch arena and deletes its command.
+func (i *Interp) finishCoroutine(co *coroutine) {
+	co.done = true
+	delete(i.coroutines, co)
+	co.scratch.reset()
+	co.pins.reset()
+	i.pins.lent += co.pins.lent
+	i.pins.released += co.pins.released
+	for _, ns := range i.namespaces {
+		for name, cmd := range ns.commands {
+			if cmd == co.entry {
+				i.deleteCommand(ns, name)
+			}
+		}
+	}
+}
+
+// killCoroutine unwinds a coroutine whose command was deleted by resuming
+// it with an error. Until its body finishes every command it runs fails, as
+// when a deadline passes, so that catch cannot keep it running. A running
+// coroutine fails its next yield instead.
+func (i *Interp) killCoroutine(co *coroutine) {
+	if co.done {
+		return
+	}
+	co.killed = true
+	if co.running {
+		return
+	}
+	result, options := i.result, i.returnOptions
+	// A killed coroutine cannot yield again, so this returns once its body
+	// has finished
+	co.unwind = true
+	callCEvalLimitsEnable(i.handle, 1)
+	i.enterCoroutine(co, coroMessage{code: ResultError, value: i.String("coroutine deleted")})
+	callCEvalLimitsEnable(i.handle, -1)
+	i.finishCoroutine(co)
+	i.result, i.returnOptions = result, options
+}
+
+// setInfoCoroutine records the running coroutine for info coroutine.
+func (i *Interp) setInfoCoroutine(co *coroutine) {
+	name := ""
+	if co != nil {
+		co.name = i.commandName(co.entry, co.name)
+		name = co.name
+	}
+	i.ensureNamespace("::tcl::coroutine").vars["current"] = i.String(name)
+}
@@ -0,0 +1,111 @@
+package feather
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// FileCoverage holds the lines of one file that ran while coverage was
+// enabled, in a [Interp.CoverageReport].
+type FileCoverage struct {
+	File  string      // the file, as given to source; "" for scripts passed to Eval
+	Lines map[int]int // the number of commands that started on each line, by line
+}
+
+// coverage counts the commands that start on each line of each file.
+type coverage struct {
+	on    bool
+	files map[string]map[int]int
+}
+
+// EnableCoverage starts counting the commands that run on each line of
+// each file the interpreter sources, discarding the counts of any earlier
+// coverage, to find which lines of a script a test suite exercises. Call
+// [Interp.CoverageReport] for the counts and [Interp.DisableCoverage] to
+// stop.
+//
+// Lines are found as for [EvalEvent]: commands in scripts passed to Eval
+// are counted under the file "", by their line in the script.
+//
+//	interp.EnableCoverage()
+//	interp.SourceFile("vendor/lib.tcl")
+//	runTests(interp)
+//	for _, f := range interp.CoverageReport() {
+//	    fmt.Println(f.File, len(f.Lines), "lines run")
+//	}
+func (i *Interp) EnableCoverage() {
+	i.coverage = &coverage{on: true, files: make(map[string]map[int]int)}
+	i.updateEvalHooks()
+}
+
+// DisableCoverage stops counting lines, keeping the counts for
+// [Interp.CoverageReport].
+func (i *Interp) DisableCoverage() {
+	if i.coverage != nil {
+		i.coverage.on = false
+		i.updateEvalHooks()
+	}
+}
+
+// CoverageReport returns the lines run in each file, sorted by file, since
+// coverage was last enabled.
+func (i *Interp) CoverageReport() []FileCoverage {
+	if i.coverage == nil {
+		return nil
+	}
+	report := make([]FileCoverage, 0, len(i.coverage.files))
+	for _, file := range slices.Sorted(maps.Keys(i.coverage.files)) {
+		report = append(report, FileCoverage{File: file, Lines: maps.Clone(i.coverage.files[file])})
+	}
+	return report
+}
+
+// covering reports whether coverage is enabled.
+func (i *Interp) covering() bool {
+	return i.coverage != nil && i.coverage.on
+}
+
+// hit counts a command starting on line of file.
+func (c *coverage) hit(file string, line int) {
+	lines := c.files[file]
+	if lines == nil {
+		lines = make(map[int]int)
+		c.files[file] = lines
+	}
+	lines[line]++
+}
+
+// cmdCoverage implements the coverage command:
+//
+//	coverage on
+//	coverage off
+//	coverage report
+//
+// report returns a dict from each file to a dict from each line run in it
+// to the number of commands that started on it, with the files and lines
+// in order.
+func cmdCoverage(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "coverage on|off|report"`)
+	}
+	switch args[0].String() {
+	case "on":
+		i.EnableCoverage()
+		return OK("")
+	case "off":
+		i.DisableCoverage()
+		return OK("")
+	case "report":
+		files := i.NewDictBuilder()
+		for _, f := range i.CoverageReport() {
+			lines := i.NewDictBuilder()
+			for _, line := range slices.Sorted(maps.Keys(f.Lines)) {
+				lines.Set(strconv.Itoa(line), f.Lines[line])
+			}
+			files.Set(f.File, lines.Obj())
+		}
+		return OK(files.Obj())
+	}
+	return Errorf("bad option \"%s\": must be off, on, or report", args[0].String())
+}
@@ -0,0 +1,233 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DebugAction tells the interpreter how to continue after the debugger
+// installed with [Interp.SetDebugger] paused it.
+type DebugAction int
+
+const (
+	// DebugContinue runs until the next breakpoint.
+	DebugContinue DebugAction = iota
+	// DebugStepInto pauses at the next command, including commands in the
+	// procs the current command calls.
+	DebugStepInto
+	// DebugStepOver pauses at the next command in the current frame or a
+	// frame that calls it.
+	DebugStepOver
+	// DebugStepOut pauses at the next command in a frame that calls the
+	// current one.
+	DebugStepOut
+)
+
+// FrameInfo describes a call frame, as returned by [Interp.Frames].
+type FrameInfo struct {
+	Level     int    // the frame level, as used by uplevel #level
+	Command   string // the command that created the frame; empty for the global frame
+	Args      []*Obj // the arguments of that command
+	Namespace string // the namespace the frame runs in
+}
+
+// debugger holds the state of the debugger installed with SetDebugger.
+type debugger struct {
+	pause       func(EvalEvent) DebugAction
+	breakpoints map[string]map[int]bool // file -> lines
+	step        DebugAction             // how to continue after the last pause
+	stepLevel   int                     // frame level of the last pause
+}
+
+// SetDebugger installs pause as the interpreter's debugger, or removes it
+// when pause is nil.
+//
+// pause is called before a command runs when the command is on a line with
+// a breakpoint, or when a step requested by the previous pause ends. The
+// event describes the command about to run. While paused, pause can look at
+// the call stack with [Interp.Frames], read and change variables with
+// [Interp.VarAtLevel] and [Interp.SetVarAtLevel], and evaluate scripts with
+// [Interp.EvalInFrame]; commands it evaluates are not reported to the
+// debugger. Its return value says how execution continues.
+//
+//	interp.SetBreakpoint("", 3)
+//	interp.SetDebugger(func(e feather.EvalEvent) feather.DebugAction {
+//	    n, _ := interp.VarAtLevel(e.Level, "n")
+//	    fmt.Printf("line %d: %s, n = %s\n", e.Line, e.Name, n)
+//	    return feather.DebugStepOver
+//	})
+func (i *Interp) SetDebugger(pause func(EvalEvent) DebugAction) {
+	switch {
+	case pause == nil:
+		i.debug = nil
+	case i.debug == nil:
+		i.debug = &debugger{pause: pause, breakpoints: make(map[string]map[int]bool)}
+	default:
+		i.debug.pause = pause
+	}
+	i.updateEvalHooks()
+}
+
+// SetBreakpoint makes the debugger pause before commands that start on
+// line of file. file is the File reported in [EvalEvent]; use "" for
+// scripts passed to Eval. Lines count as described for [EvalEvent].
+//
+// Breakpoints only take effect while a debugger is installed with
+// [Interp.SetDebugger].
+func (i *Interp) SetBreakpoint(file string, line int) {
+	if i.debug == nil {
+		i.debug = &debugger{breakpoints: make(map[string]map[int]bool)}
+	}
+	if i.debug.breakpoints[file] == nil {
+		i.debug.breakpoints[file] = make(map[int]bool)
+	}
+	i.debug.breakpoints[file][line] = true
+}
+
+// ClearBreakpoint removes a breakpoint set with [Interp.SetBreakpoint].
+func (i *Interp) ClearBreakpoint(file string, line int) {
+	if i.debug != nil {
+		delete(i.debug.breakpoints[file], line)
+	}
+}
+
+// Pause makes the debugger pause before the next command, as if the last
+// pause had returned [DebugStepInto]. Use it to stop at the start of a
+// script.
+func (i *Interp) Pause() {
+	if i.debug != nil {
+		i.debug.step = DebugStepInto
+	}
+}
+
+// debugEval decides whether to pause before the command described by e.
+func (i *Interp) debugEval(e EvalEvent) {
+	d := i.debug
+	if d.pause == nil {
+		return
+	}
+	stop := d.breakpoints[e.File][e.Line]
+	switch d.step {
+	case DebugStepInto:
+		stop = true
+	case DebugStepOver:
+		stop = stop || e.Level <= d.stepLevel
+	case DebugStepOut:
+		stop = stop || e.Level < d.stepLevel
+	}
+	if !stop {
+		return
+	}
+	d.step = d.pause(e)
+	d.stepLevel = e.Level
+}
+
+// Frames returns the call stack, starting with the global frame.
+func (i *Interp) Frames() []FrameInfo {
+	frames := make([]FrameInfo, len(i.frames))
+	for n := range i.frames {
+		frames[n] = i.frameInfo(n)
+	}
+	return frames
+}
+
+// frameInfo describes the call frame at level.
+func (i *Interp) frameInfo(level int) FrameInfo {
+	f := i.frames[level]
+	info := FrameInfo{Level: level, Namespace: "::"}
+	if f.ns != nil {
+		info.Namespace = f.ns.fullPath
+	}
+	if level > 0 {
+		if f.cmd != nil {
+			info.Command = f.cmd.String()
+		}
+		if f.args != nil {
+			info.Args, _ = f.args.List()
+		}
+	}
+	return info
+}
+
+// EvalInFrame evaluates script in the call frame at level, as
+// [Interp.EvalAtLevel] does, but returns the result as [Interp.Eval] does:
+// an error, break or continue is an error.
+func (i *Interp) EvalInFrame(level int, script string) (*Obj, error) {
+	var result *Obj
+	err := i.atLevel(level, func() error {
+		var err error
+		result, err = i.Eval(script)
+		return err
+	})
+	return result, err
+}
+
+// atLevel runs fn with the call frame at level active, as uplevel #level
+// does for its script, and fails if there is no such frame.
+func (i *Interp) atLevel(level int, fn func() error) error {
+	if level < 0 || level >= len(i.frames) {
+		return fmt.Errorf("bad level \"%d\"", level)
+	}
+	active := i.active
+	i.active = level
+	defer func() { i.active = active }()
+	return fn()
+}
+
+// CallerFrame describes the call frame the running command was called
+// from. Commands implemented in Go do not get frames of their own, so this
+// is the frame a proc-implemented command would reach with uplevel 1, and
+// its level is the one to pass to [Interp.EvalAtLevel] and
+// [Interp.LinkVar].
+func (i *Interp) CallerFrame() FrameInfo {
+	return i.frameInfo(i.active)
+}
+
+// EvalAtLevel evaluates script in the call frame at level, as
+// uplevel #level script does, for use by commands implemented in Go. The
+// result keeps the return code of the script, so a command can return it
+// as it is, or handle break and continue as a loop does:
+//
+//	interp.RegisterCommand("repeat", func(i *feather.Interp, cmd *feather.Obj, args []*feather.Obj) feather.Result {
+//	    n, _ := args[0].Int()
+//	    level := i.CallerFrame().Level
+//	    for range n {
+//	        r := i.EvalAtLevel(level, args[1].String())
+//	        if r.Code() == feather.ResultBreak {
+//	            break
+//	        }
+//	        if r.Code() != feather.ResultOK && r.Code() != feather.ResultContinue {
+//	            return r
+//	        }
+//	    }
+//	    return feather.OK("")
+//	})
+func (i *Interp) EvalAtLevel(level int, script string) Result {
+	if err := i.checkOpen(); err != nil {
+		return Error(err.Error())
+	}
+	var code FeatherResult
+	if err := i.atLevel(level, func() error {
+		code = FeatherResult(callCEval(i.handle, i.handleForObj(i.String(script))))
+		return nil
+	}); err != nil {
+		return Error(err.Error())
+	}
+	return Result{code: code, obj: dictShared(i.result), hasObj: true}
+}
+
+// LinkVar makes localName in the frame of the running command's caller
+// refer to the variable remoteName in the call frame at level, as
+// upvar #level remoteName localName does in that frame.
+func (i *Interp) LinkVar(localName string, level int, remoteName string) error {
+	if level < 0 || level >= len(i.frames) {
+		return fmt.Errorf("bad level \"%d\"", level)
+	}
+	result := i.result
+	defer func() { i.result = result }()
+	link := i.List(i.String("::upvar"), i.String(fmt.Sprintf("#%d", level)), i.String(remoteName), i.String(localName))
+	if FeatherResult(callCEval(i.handle, i.handleForObj(link))) != ResultOK {
+		return errors.New(i.result.String())
+	}
+	return nil
+}
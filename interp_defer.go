@@ -0,0 +1,64 @@
+package feather
+
+// The defer command is a feather extension that registers cleanup scripts
+// on the current call frame:
+//
+//	proc withFile {path} {
+//	    set f [open $path]
+//	    defer [list close $f]
+//	    ...
+//	}
+//
+// Deferred scripts run in last-in, first-out order when the frame is popped,
+// whether the body returned normally or with an error. They are evaluated in
+// the scope of the exiting frame, so they can read its local variables.
+
+// registerDefer installs the defer command.
+func (i *Interp) registerDefer() {
+	i.RegisterCommand("defer", cmdDefer)
+}
+
+// cmdDefer implements: defer script
+func cmdDefer(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "defer script"`)
+	}
+	if i.active == 0 {
+		return Error("defer can only be called from a proc or lambda")
+	}
+	frame := i.frames[i.active]
+	frame.deferred = append(frame.deferred, args[0])
+	return OK("")
+}
+
+// runDeferred evaluates the scripts deferred on frame, most recent first.
+//
+// The interpreter result, return options and error trace of the exiting
+// frame are preserved, so deferred scripts cannot change what the frame
+// returns. Errors raised by deferred scripts are discarded for the same
+// reason.
+func (i *Interp) runDeferred(frame *CallFrame) {
+	savedResult, savedOptions := i.result, i.returnOptions
+	var savedErrors map[string]*Obj
+	errorsNS := i.namespaces["::tcl::errors"]
+	if errorsNS != nil {
+		savedErrors = make(map[string]*Obj, len(errorsNS.vars))
+		for k, v := range errorsNS.vars {
+			savedErrors[k] = v
+		}
+	}
+
+	// Scripts deferred while running a deferred script run before the
+	// remaining ones, matching LIFO order.
+	for len(frame.deferred) > 0 {
+		n := len(frame.deferred) - 1
+		script := frame.deferred[n]
+		frame.deferred = frame.deferred[:n]
+		callCEval(i.handle, i.handleForObj(script))
+	}
+
+	i.result, i.returnOptions = savedResult, savedOptions
+	if errorsNS != nil {
+		errorsNS.vars = savedErrors
+	}
+}
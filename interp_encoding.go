@@ -0,0 +1,162 @@
+package feather
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The encoding command converts between strings and binary data:
+//
+//	encoding convertto ?encoding? string  - encode string as a byte array
+//	encoding convertfrom ?encoding? data  - decode a byte array to a string
+//	encoding names                        - list supported encodings
+//	encoding system ?encoding?            - query or set the default encoding
+//
+// Characters that cannot be represented in the target encoding become "?".
+
+// textEncoding converts between strings and bytes for one encoding.
+type textEncoding struct {
+	encode func(s string) []byte
+	decode func(b []byte) string
+}
+
+// textEncodings holds the supported encodings by name.
+var textEncodings = map[string]textEncoding{
+	"utf-8":     {encode: func(s string) []byte { return []byte(s) }, decode: decodeUTF8},
+	"iso8859-1": {encode: func(s string) []byte { return encodeSingleByte(s, 0xff) }, decode: decodeSingleByte},
+	"ascii":     {encode: func(s string) []byte { return encodeSingleByte(s, 0x7f) }, decode: decodeSingleByte},
+	"binary":    {encode: encodeBinary, decode: decodeSingleByte},
+	"utf-16le":  {encode: func(s string) []byte { return encodeUTF16(s, false) }, decode: func(b []byte) string { return decodeUTF16(b, false) }},
+	"utf-16be":  {encode: func(s string) []byte { return encodeUTF16(s, true) }, decode: func(b []byte) string { return decodeUTF16(b, true) }},
+	"unicode":   {encode: func(s string) []byte { return encodeUTF16(s, nativeBigEndian) }, decode: func(b []byte) string { return decodeUTF16(b, nativeBigEndian) }},
+}
+
+// registerEncoding installs the encoding command.
+func (i *Interp) registerEncoding() {
+	i.encoding = "utf-8"
+	i.RegisterCommand("encoding", cmdEncoding)
+}
+
+// cmdEncoding implements: encoding subcommand ?arg ...?
+func cmdEncoding(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "encoding subcommand ?arg ...?"`)
+	}
+	sub, args := args[0].String(), args[1:]
+	switch sub {
+	case "convertto", "convertfrom":
+		if len(args) < 1 || len(args) > 2 {
+			return Errorf(`wrong # args: should be "encoding %s ?encoding? data"`, sub)
+		}
+		name := i.encoding
+		if len(args) == 2 {
+			name = args[0].String()
+		}
+		enc, ok := textEncodings[name]
+		if !ok {
+			return Errorf(`unknown encoding "%s"`, name)
+		}
+		data := args[len(args)-1]
+		if sub == "convertto" {
+			return OK(i.ByteArray(enc.encode(data.String())))
+		}
+		return OK(enc.decode(data.ByteArray()))
+
+	case "names":
+		if len(args) != 0 {
+			return Error(`wrong # args: should be "encoding names"`)
+		}
+		names := make([]string, 0, len(textEncodings))
+		for name := range textEncodings {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return OK(names)
+
+	case "system":
+		if len(args) > 1 {
+			return Error(`wrong # args: should be "encoding system ?encoding?"`)
+		}
+		if len(args) == 1 {
+			name := args[0].String()
+			if _, ok := textEncodings[name]; !ok {
+				return Errorf(`unknown encoding "%s"`, name)
+			}
+			i.encoding = name
+			return OK("")
+		}
+		return OK(i.encoding)
+	}
+	return Errorf(`unknown or ambiguous subcommand "%s": must be convertfrom, convertto, names, or system`, sub)
+}
+
+// decodeUTF8 decodes UTF-8, mapping each invalid byte to the character
+// with the same code point.
+func decodeUTF8(b []byte) string {
+	var out strings.Builder
+	out.Grow(len(b))
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			r = rune(b[0])
+		}
+		out.WriteRune(r)
+		b = b[size:]
+	}
+	return out.String()
+}
+
+// encodeSingleByte encodes characters up to max as one byte each.
+func encodeSingleByte(s string, max rune) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > max {
+			r = '?'
+		}
+		out = append(out, byte(r))
+	}
+	return out
+}
+
+// encodeBinary keeps the low 8 bits of each character, like a byte array conversion.
+func encodeBinary(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		out = append(out, byte(r))
+	}
+	return out
+}
+
+// decodeSingleByte maps each byte to the character with the same code point.
+func decodeSingleByte(b []byte) string {
+	return ByteArrayType(b).UpdateString()
+}
+
+// encodeUTF16 encodes s as UTF-16 in the given byte order.
+func encodeUTF16(s string, big bool) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(units))
+	for j, u := range units {
+		if big {
+			out[2*j], out[2*j+1] = byte(u>>8), byte(u)
+		} else {
+			out[2*j], out[2*j+1] = byte(u), byte(u>>8)
+		}
+	}
+	return out
+}
+
+// decodeUTF16 decodes UTF-16 in the given byte order. A trailing odd byte is ignored.
+func decodeUTF16(b []byte, big bool) string {
+	units := make([]uint16, len(b)/2)
+	for j := range units {
+		if big {
+			units[j] = uint16(b[2*j])<<8 | uint16(b[2*j+1])
+		} else {
+			units[j] = uint16(b[2*j]) | uint16(b[2*j+1])<<8
+		}
+	}
+	return string(utf16.Decode(units))
+}
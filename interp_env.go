@@ -0,0 +1,304 @@
+package feather
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"os/user"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// New sets the global variables scripts expect to find:
+//
+//	env            - the environment, as a dict
+//	tcl_platform   - a dict describing the platform: byteOrder, engine,
+//	                 machine, os, pathSeparator, platform, pointerSize,
+//	                 user and wordSize
+//	tcl_version    - the version of TCL feather implements
+//	tcl_patchLevel - the same, with a patch level, as info patchlevel
+//	                 reports it
+//
+// It also records the name of the machine and the path of the program in
+// ::tcl::info, where info hostname and info nameofexecutable find them.
+//
+// Feather has no arrays, so where TCL scripts write $env(HOME) they write
+// [dict get $env HOME]. Reading env returns the variables of the
+// interpreter's [Environment], and changing it, as with dict set env or
+// dict unset env, sets and unsets them there, so that programs started by
+// exec see the change. Unsetting env leaves the environment alone and ends
+// the link.
+//
+// Hosts that run scripts from the command line set argv0, argv and argc
+// with [Interp.SetArgs].
+
+// Environment is the process environment as the env variable presents it
+// to scripts. [OSEnvironment], [MapEnvironment] and [FilterEnvironment]
+// return the environments feather provides.
+type Environment interface {
+	Environ() []string // the variables, as key=value strings
+	Setenv(key, value string) error
+	Unsetenv(key string) error
+}
+
+// OSEnvironment returns the environment of the process, which the env
+// variable presents by default. Changes scripts make to it change the
+// environment of the whole process.
+func OSEnvironment() Environment {
+	return osEnvironment{}
+}
+
+// osEnvironment is the environment of [OSEnvironment].
+type osEnvironment struct{}
+
+func (osEnvironment) Environ() []string              { return os.Environ() }
+func (osEnvironment) Setenv(key, value string) error { return os.Setenv(key, value) }
+func (osEnvironment) Unsetenv(key string) error      { return os.Unsetenv(key) }
+
+// MapEnvironment returns a synthetic environment holding the variables of
+// vars, which is copied. Changes scripts make stay in the environment, and
+// exec passes it to the programs it runs.
+func MapEnvironment(vars map[string]string) Environment {
+	return &mapEnvironment{vars: maps.Clone(vars)}
+}
+
+// mapEnvironment is the environment of [MapEnvironment].
+type mapEnvironment struct {
+	mu   sync.Mutex
+	vars map[string]string
+}
+
+func (m *mapEnvironment) Environ() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	env := make([]string, 0, len(m.vars))
+	for _, key := range slices.Sorted(maps.Keys(m.vars)) {
+		env = append(env, key+"="+m.vars[key])
+	}
+	return env
+}
+
+func (m *mapEnvironment) Setenv(key, value string) error {
+	if key == "" || strings.Contains(key, "=") {
+		return errors.New("invalid environment variable name")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.vars == nil {
+		m.vars = make(map[string]string)
+	}
+	m.vars[key] = value
+	return nil
+}
+
+func (m *mapEnvironment) Unsetenv(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.vars, key)
+	return nil
+}
+
+// FilterEnvironment returns the variables of env for which allow returns
+// true. Scripts cannot see the others, and changing them fails.
+//
+//	interp.SetEnvPolicy(feather.FilterEnvironment(feather.OSEnvironment(), func(key string) bool {
+//	    return key == "HOME" || strings.HasPrefix(key, "APP_")
+//	}))
+func FilterEnvironment(env Environment, allow func(key string) bool) Environment {
+	return filterEnvironment{env, allow}
+}
+
+// filterEnvironment is the environment of [FilterEnvironment].
+type filterEnvironment struct {
+	env   Environment
+	allow func(key string) bool
+}
+
+func (f filterEnvironment) Environ() []string {
+	return slices.DeleteFunc(f.env.Environ(), func(kv string) bool {
+		key, _, _ := strings.Cut(kv, "=")
+		return !f.allow(key)
+	})
+}
+
+func (f filterEnvironment) Setenv(key, value string) error {
+	if !f.allow(key) {
+		return errors.New("environment variable is not accessible")
+	}
+	return f.env.Setenv(key, value)
+}
+
+func (f filterEnvironment) Unsetenv(key string) error {
+	if !f.allow(key) {
+		return errors.New("environment variable is not accessible")
+	}
+	return f.env.Unsetenv(key)
+}
+
+// SetEnvPolicy sets the environment the env variable presents to scripts
+// and that exec passes to the programs it runs, so that an embedder can
+// hide variables such as credentials or give scripts a synthetic
+// environment:
+//
+//	interp.SetEnvPolicy(feather.MapEnvironment(map[string]string{
+//	    "HOME": "/sandbox",
+//	    "LANG": "C.UTF-8",
+//	}))
+//
+// A nil environment restores the default, [OSEnvironment].
+func (i *Interp) SetEnvPolicy(env Environment) {
+	i.envPolicy = env
+}
+
+// environment returns the environment the env variable presents.
+func (i *Interp) environment() Environment {
+	if i.envPolicy == nil {
+		return osEnvironment{}
+	}
+	return i.envPolicy
+}
+
+// envDict returns the variables of the environment as a dict.
+func (i *Interp) envDict() *Obj {
+	b := i.NewDictBuilder()
+	for _, kv := range i.environment().Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok && key != "" {
+			b.Set(key, value)
+		}
+	}
+	return b.Obj()
+}
+
+// setEnv changes the environment to hold the variables of the dict val.
+func (i *Interp) setEnv(val *Obj) error {
+	vars, err := val.Dict()
+	if err != nil {
+		return err
+	}
+	env := i.environment()
+	current := make(map[string]string)
+	for _, kv := range env.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			current[key] = value
+		}
+	}
+	for _, key := range vars.Order {
+		value := vars.Items[key].String()
+		if old, ok := current[key]; ok && old == value {
+			continue
+		}
+		if err := env.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	for key := range current {
+		if _, ok := vars.Items[key]; !ok {
+			if err := env.Unsetenv(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// registerEnv installs the trace that links the global variable env to
+// the environment.
+func (i *Interp) registerEnv() {
+	ns := i.ensureNamespace("::tcl::trace")
+	i.setCommand(ns, "env", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(traceEnv)})
+}
+
+// bindEnv sets the global variable env and adds the trace that links it
+// to the environment. The trace is added after New records the state Reset
+// returns to, since Reset discards traces, and Reset binds env again.
+func (i *Interp) bindEnv() {
+	i.globalNamespace.vars["env"] = i.envDict()
+	i.Call("trace", "add", "variable", "::env", "read write unset", "::tcl::trace::env")
+}
+
+// traceEnv implements the trace on env. Values are stored in the namespace
+// directly, which does not fire the trace again.
+func traceEnv(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 3 {
+		return Error("wrong # args: should be \"trace name1 name2 op\"")
+	}
+	vars := i.globalNamespace.vars
+	switch args[2].String() {
+	case "read":
+		vars["env"] = i.envDict()
+	case "write":
+		err := i.setEnv(vars["env"])
+		vars["env"] = i.envDict()
+		if err != nil {
+			return Error(err.Error())
+		}
+	}
+	return OK("")
+}
+
+// registerPlatform sets tcl_platform, tcl_version and tcl_patchLevel, and
+// records the host name and executable for info.
+func (i *Interp) registerPlatform() {
+	byteOrder := "littleEndian"
+	if nativeBigEndian {
+		byteOrder = "bigEndian"
+	}
+	machine := runtime.GOARCH
+	switch {
+	case machine == "amd64":
+		machine = "x86_64"
+	case machine == "386":
+		machine = "i686"
+	case machine == "arm64" && runtime.GOOS == "linux":
+		machine = "aarch64"
+	}
+	osName := map[string]string{
+		"linux":   "Linux",
+		"darwin":  "Darwin",
+		"windows": "Windows NT",
+		"freebsd": "FreeBSD",
+		"openbsd": "OpenBSD",
+		"netbsd":  "NetBSD",
+	}[runtime.GOOS]
+	if osName == "" {
+		osName = runtime.GOOS
+	}
+	platform := "unix"
+	if runtime.GOOS == "windows" {
+		platform = "windows"
+	}
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	i.SetVarObj("::tcl_platform", i.DictKV(
+		"byteOrder", byteOrder,
+		"engine", "Feather",
+		"machine", machine,
+		"os", osName,
+		"pathSeparator", string(os.PathListSeparator),
+		"platform", platform,
+		"pointerSize", strconv.IntSize/8,
+		"user", username,
+		"wordSize", strconv.IntSize/8,
+	))
+	i.SetVar("tcl_version", "8.6")
+	i.SetVar("tcl_patchLevel", "8.6.0")
+	hostname, _ := os.Hostname()
+	executable, _ := os.Executable()
+	info := i.ensureNamespace("::tcl::info")
+	info.vars["hostname"] = i.String(hostname)
+	info.vars["nameofexecutable"] = i.String(executable)
+}
+
+// SetArgs sets argv0 to the name of the script being run, argv to the list
+// of its arguments and argc to their number, as tclsh does for the script
+// it is given on the command line.
+func (i *Interp) SetArgs(argv0 string, argv []string) {
+	i.SetVar("argv0", argv0)
+	i.SetVarObj("::argv", i.ListFrom(argv))
+	i.SetVar("argc", len(argv))
+}
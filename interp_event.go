@@ -0,0 +1,521 @@
+package feather
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The event loop runs scripts scheduled with after, and functions posted
+// from Go with [Interp.Post]:
+//
+//	after ms ?script ...?
+//	after idle script ?script ...?
+//	after cancel id|script ...
+//	after info ?id?
+//	vwait name
+//	update ?idletasks?
+//
+// Events only run while the loop is serviced: by vwait and update from a
+// script, or by [Interp.DoOneEvent] and [Interp.RunEventLoop] from Go.
+// Expired timers run before idle callbacks, and idle callbacks only run
+// when nothing else is pending, as in TCL.
+
+// eventQueue holds the events waiting to run. Only posted functions may be
+// added from other goroutines; everything else belongs to the goroutine
+// that owns the interpreter.
+type eventQueue struct {
+	mu      sync.Mutex
+	posted  []postedWork
+	wake    chan struct{} // signalled when work is posted
+	stopped error         // why Send and EvalAsync fail now (nil = queue them)
+
+	timers []*afterEvent // ordered by due time, then creation
+	idle   []*afterEvent // in creation order
+	nextID int
+
+	vwaits  map[int]bool // vwait token -> variable was written
+	nextTok int
+
+	futures    map[string]*future // futures of Async commands, by id
+	nextFuture int
+}
+
+// postedWork is a function queued from Go. Work queued by Send and
+// EvalAsync has a caller waiting for it, which fail tells why it will
+// not run.
+type postedWork struct {
+	run  func()
+	fail func(error) // nil for Post
+}
+
+// afterEvent is a script scheduled with after.
+type afterEvent struct {
+	id     string
+	script *Obj
+	due    time.Time // zero for idle callbacks
+}
+
+// registerEvents installs the event loop commands.
+func (i *Interp) registerEvents() {
+	i.events = &eventQueue{
+		wake:    make(chan struct{}, 1),
+		vwaits:  make(map[int]bool),
+		futures: make(map[string]*future),
+	}
+	i.RegisterCommand("after", cmdAfter)
+	i.RegisterCommand("vwait", cmdVwait)
+	i.RegisterCommand("update", cmdUpdate)
+	i.RegisterCommand("await", cmdAwait)
+	i.RegisterCommand("future", cmdFuture)
+	i.setCommand(i.ensureNamespace("::tcl::event"), "vwaitset", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(cmdVwaitSet)})
+}
+
+// Post queues fn to run on the goroutine servicing the interpreter's event
+// loop, the next time it processes an event. Post is safe to call from any
+// goroutine; fn itself may use the interpreter freely.
+//
+//	go func() {
+//	    data := fetch()
+//	    interp.Post(func() { interp.Call("handle", data) })
+//	}()
+//	interp.RunEventLoop(ctx)
+func (i *Interp) Post(fn func()) {
+	i.post(postedWork{run: fn})
+}
+
+// post queues work and wakes the event loop.
+func (i *Interp) post(w postedWork) {
+	q := i.events
+	q.mu.Lock()
+	if err := q.stopped; err != nil && w.fail != nil {
+		q.mu.Unlock()
+		w.fail(err)
+		return
+	}
+	q.posted = append(q.posted, w)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Send runs fn on the goroutine servicing the interpreter's event loop and
+// waits for it to finish. It is the way for other goroutines to use an
+// interpreter owned by a goroutine running [Interp.Serve],
+// [Interp.RunEventLoop], or a script blocked in vwait:
+//
+//	go interp.Serve(ctx)
+//
+//	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+//	    interp.Send(func(i *feather.Interp) {
+//	        v, _ := i.Call("render", r.URL.Path)
+//	        io.WriteString(w, v.String())
+//	    })
+//	})
+//
+// Send returns an error without running fn if Serve has stopped, or stops
+// before fn runs, or the interpreter is closed. Calling Send from the goroutine servicing
+// the event loop deadlocks.
+func (i *Interp) Send(fn func(*Interp)) error {
+	done := make(chan error, 1)
+	i.post(postedWork{
+		run:  func() { fn(i); done <- nil },
+		fail: func(err error) { done <- err },
+	})
+	return <-done
+}
+
+// EvalResult is the outcome of a script evaluated with [Interp.EvalAsync].
+// The value is returned as a string because an [*Obj] must not leave the
+// goroutine that owns its interpreter.
+type EvalResult struct {
+	Value string
+	Err   error
+}
+
+// EvalAsync queues script to be evaluated on the goroutine servicing the
+// interpreter's event loop, and returns a channel that receives the result.
+// Like [Interp.Send], it is safe to call from any goroutine.
+//
+//	res := <-interp.EvalAsync("expr {6 * 7}")
+//	if res.Err == nil {
+//	    fmt.Println(res.Value) // 42
+//	}
+func (i *Interp) EvalAsync(script string) <-chan EvalResult {
+	ch := make(chan EvalResult, 1)
+	i.post(postedWork{
+		run: func() {
+			v, err := i.Eval(script)
+			if err != nil {
+				ch <- EvalResult{Err: err}
+				return
+			}
+			ch <- EvalResult{Value: v.String()}
+		},
+		fail: func(err error) { ch <- EvalResult{Err: err} },
+	})
+	return ch
+}
+
+// Serve makes the calling goroutine the owner of the interpreter: it
+// processes events, including work queued with [Interp.Send] and
+// [Interp.EvalAsync], until ctx is done. From then until Serve is called
+// again, Send and EvalAsync fail with ctx.Err() instead of waiting for a
+// loop that is no longer running; functions queued with [Interp.Post] stay
+// queued. Serve returns ctx.Err().
+func (i *Interp) Serve(ctx context.Context) error {
+	q := i.events
+	q.mu.Lock()
+	q.stopped = nil
+	q.mu.Unlock()
+	err := i.RunEventLoop(ctx)
+	i.failPosted(err)
+	return err
+}
+
+// failPosted makes Send and EvalAsync fail with err, including the work
+// they already queued.
+func (i *Interp) failPosted(err error) {
+	q := i.events
+	q.mu.Lock()
+	q.stopped = err
+	var failed []postedWork
+	kept := q.posted[:0]
+	for _, w := range q.posted {
+		if w.fail != nil {
+			failed = append(failed, w)
+		} else {
+			kept = append(kept, w)
+		}
+	}
+	q.posted = kept
+	q.mu.Unlock()
+	for _, w := range failed {
+		w.fail(err)
+	}
+}
+
+// DoOneEvent processes one pending event without waiting: a posted
+// function, then an expired after timer, then, if neither is pending, the
+// idle callbacks. It reports whether anything was processed.
+func (i *Interp) DoOneEvent() bool {
+	return i.doOneEvent(context.Background(), false)
+}
+
+// RunEventLoop processes events, waiting for more when none are pending,
+// until ctx is done. It returns ctx.Err().
+func (i *Interp) RunEventLoop(ctx context.Context) error {
+	for ctx.Err() == nil {
+		i.doOneEvent(ctx, true)
+	}
+	return ctx.Err()
+}
+
+// doOneEvent processes one event. If wait is set and nothing is pending,
+// it first waits until a timer expires, a function is posted, or ctx is
+// done. It reports whether an event was processed.
+func (i *Interp) doOneEvent(ctx context.Context, wait bool) bool {
+	q := i.events
+	for {
+		q.mu.Lock()
+		var w postedWork
+		if len(q.posted) > 0 {
+			w = q.posted[0]
+			q.posted = q.posted[1:]
+		}
+		q.mu.Unlock()
+		if w.run != nil {
+			w.run()
+			return true
+		}
+		if len(q.timers) > 0 && !q.timers[0].due.After(time.Now()) {
+			ev := q.timers[0]
+			q.timers = q.timers[1:]
+			i.runEvent(ev)
+			return true
+		}
+		if len(q.idle) > 0 {
+			i.runIdle()
+			return true
+		}
+		if !wait {
+			return false
+		}
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if len(q.timers) > 0 {
+			timer = time.NewTimer(time.Until(q.timers[0].due))
+			expired = timer.C
+		}
+		select {
+		case <-q.wake:
+		case <-expired:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// runIdle runs the idle callbacks scheduled so far. Callbacks scheduled
+// while they run wait for the next round.
+func (i *Interp) runIdle() {
+	idle := i.events.idle
+	i.events.idle = nil
+	for _, ev := range idle {
+		i.runEvent(ev)
+	}
+}
+
+// runEvent evaluates an after script at the global level, reporting an
+// error as a background error.
+func (i *Interp) runEvent(ev *afterEvent) {
+	active := i.active
+	i.active = 0
+	defer func() { i.active = active }()
+
+	ns := i.ensureNamespace("::tcl::event")
+	_, err := i.eval(i.List(i.String("catch"), ev.script,
+		i.String("::tcl::event::result"), i.String("::tcl::event::options")).String())
+	if err != nil {
+		return
+	}
+	code, _ := i.result.Int()
+	msg := ns.vars["result"]
+	info := ""
+	switch FeatherResult(code) {
+	case ResultOK, ResultReturn:
+		return
+	case ResultBreak:
+		msg = i.String(`invoked "break" outside of a loop`)
+	case ResultContinue:
+		msg = i.String(`invoked "continue" outside of a loop`)
+	default:
+		if opts, err := asList(ns.vars["options"]); err == nil {
+			for j := 0; j+1 < len(opts); j += 2 {
+				if opts[j].String() == "-errorinfo" {
+					info = opts[j+1].String()
+				}
+			}
+		}
+	}
+	if info == "" {
+		info = msg.String()
+	}
+	i.backgroundError(msg, info+"\n    (\"after\" script)")
+}
+
+// backgroundError reports an error from an event that has no caller to
+// return it to. It calls the bgerror command if the application defined
+// one, and otherwise writes the error information to stderr.
+func (i *Interp) backgroundError(msg *Obj, info string) {
+	i.globalNamespace.vars["errorInfo"] = i.String(info)
+	if i.resolveCommand("::bgerror") != nil {
+		if _, err := i.eval(i.List(i.String("bgerror"), msg).String()); err == nil {
+			return
+		}
+	}
+	if c, ok := i.channels["stderr"]; ok && c.w != nil {
+		c.write([]byte(info + "\n"))
+	}
+}
+
+// schedule adds an after event and returns its id.
+func (q *eventQueue) schedule(script *Obj, idle bool, delay time.Duration) string {
+	ev := &afterEvent{id: fmt.Sprintf("after#%d", q.nextID), script: script}
+	q.nextID++
+	if idle {
+		q.idle = append(q.idle, ev)
+		return ev.id
+	}
+	ev.due = time.Now().Add(delay)
+	n := len(q.timers)
+	for n > 0 && q.timers[n-1].due.After(ev.due) {
+		n--
+	}
+	q.timers = append(q.timers, nil)
+	copy(q.timers[n+1:], q.timers[n:])
+	q.timers[n] = ev
+	return ev.id
+}
+
+// find returns the pending event matching fn, and its queue.
+func (q *eventQueue) find(match func(*afterEvent) bool) (*afterEvent, *[]*afterEvent) {
+	for _, list := range []*[]*afterEvent{&q.timers, &q.idle} {
+		for _, ev := range *list {
+			if match(ev) {
+				return ev, list
+			}
+		}
+	}
+	return nil, nil
+}
+
+// concatScripts joins the script arguments of after like concat does.
+func (i *Interp) concatScripts(args []*Obj) *Obj {
+	if len(args) == 1 {
+		return args[0]
+	}
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		if s := strings.TrimSpace(a.String()); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return i.String(strings.Join(parts, " "))
+}
+
+// cmdAfter implements: after option ?arg ...?
+func cmdAfter(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "after option ?arg ...?"`)
+	}
+	q := i.events
+	if ms, err := args[0].Int(); err == nil {
+		delay := time.Duration(max(ms, 0)) * time.Millisecond
+		if len(args) == 1 {
+			time.Sleep(delay)
+			return OK("")
+		}
+		return OK(q.schedule(i.concatScripts(args[1:]), false, delay))
+	}
+
+	switch args[0].String() {
+	case "idle":
+		if len(args) < 2 {
+			return Error(`wrong # args: should be "after idle script ?script ...?"`)
+		}
+		return OK(q.schedule(i.concatScripts(args[1:]), true, 0))
+	case "cancel":
+		if len(args) < 2 {
+			return Error(`wrong # args: should be "after cancel id|command"`)
+		}
+		var ev *afterEvent
+		var list *[]*afterEvent
+		if len(args) == 2 {
+			id := args[1].String()
+			ev, list = q.find(func(ev *afterEvent) bool { return ev.id == id })
+		}
+		if ev == nil {
+			script := i.concatScripts(args[1:]).String()
+			ev, list = q.find(func(ev *afterEvent) bool { return ev.script.String() == script })
+		}
+		if ev != nil {
+			for n, e := range *list {
+				if e == ev {
+					*list = append((*list)[:n], (*list)[n+1:]...)
+					break
+				}
+			}
+		}
+		return OK("")
+	case "info":
+		switch len(args) {
+		case 1:
+			var ids []*Obj
+			for _, list := range [][]*afterEvent{q.timers, q.idle} {
+				for _, ev := range list {
+					ids = append(ids, i.String(ev.id))
+				}
+			}
+			// Most recently created first, as in TCL.
+			for a, b := 0, len(ids)-1; a < b; a, b = a+1, b-1 {
+				ids[a], ids[b] = ids[b], ids[a]
+			}
+			return OK(i.List(ids...))
+		case 2:
+			id := args[1].String()
+			ev, _ := q.find(func(ev *afterEvent) bool { return ev.id == id })
+			if ev == nil {
+				return Errorf("event \"%s\" doesn't exist", id)
+			}
+			kind := "timer"
+			if ev.due.IsZero() {
+				kind = "idle"
+			}
+			return OK(i.List(ev.script, i.String(kind)))
+		}
+		return Error(`wrong # args: should be "after info ?id?"`)
+	}
+	return Errorf("bad argument \"%s\": must be cancel, idle, info, or an integer", args[0].String())
+}
+
+// cmdVwait implements: vwait name
+//
+// The variable is global, as in TCL. vwait processes events until the
+// variable is written or unset, waiting for more when none are pending.
+func cmdVwait(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "vwait name"`)
+	}
+	name := strings.TrimPrefix(args[0].String(), "::")
+	q := i.events
+	tok := q.nextTok
+	q.nextTok++
+	q.vwaits[tok] = false
+	defer delete(q.vwaits, tok)
+
+	// Variable traces are keyed by the name used to reach the variable:
+	// the plain name at the global level and through global or upvar
+	// links, the qualified name everywhere else.
+	callback := i.List(i.String("::tcl::event::vwaitset"), i.Int(int64(tok)))
+	trace := func(op string) error {
+		for _, v := range []string{name, "::" + name} {
+			_, err := i.eval(i.List(i.String("trace"), i.String(op), i.String("variable"),
+				i.String(v), i.String("write unset"), callback).String())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := trace("add"); err != nil {
+		return Error(err.Error())
+	}
+	defer trace("remove")
+
+	for !q.vwaits[tok] {
+		i.doOneEvent(context.Background(), true)
+	}
+	return OK("")
+}
+
+// cmdVwaitSet is the variable trace installed by vwait.
+func cmdVwaitSet(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) > 0 {
+		if tok, err := args[0].Int(); err == nil {
+			if _, ok := i.events.vwaits[int(tok)]; ok {
+				i.events.vwaits[int(tok)] = true
+			}
+		}
+	}
+	return OK("")
+}
+
+// cmdUpdate implements: update ?idletasks?
+func cmdUpdate(i *Interp, cmd *Obj, args []*Obj) Result {
+	switch len(args) {
+	case 0:
+		for i.doOneEvent(context.Background(), false) {
+		}
+		return OK("")
+	case 1:
+		if args[0].String() != "idletasks" {
+			return Errorf("bad option \"%s\": must be idletasks", args[0].String())
+		}
+		for len(i.events.idle) > 0 {
+			i.runIdle()
+		}
+		return OK("")
+	}
+	return Error(`wrong # args: should be "update ?idletasks?"`)
+}
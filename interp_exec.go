@@ -0,0 +1,561 @@
+package feather
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// The exec command runs a pipeline of external programs:
+//
+//	exec ?-ignorestderr? ?-keepnewline? ?--? arg ?arg ...?
+//
+// The words of the pipeline are program names and arguments, separated by
+// | (or |&, which pipes standard error too), and redirections:
+//
+//	< file, << value, <@ chan           - standard input
+//	> file, >> file, >@ chan            - standard output
+//	2> file, 2>> file, 2>@ chan, 2>@1   - standard error
+//	>& file, >>& file, >&@ chan         - both
+//
+// A final & runs the pipeline in the background and returns the process
+// ids of its programs. Otherwise exec waits for the pipeline and returns
+// its standard output, less a final newline unless -keepnewline is given.
+// Standard input is empty unless it is redirected.
+
+// execRedirects are the redirection operators of exec, longest first so
+// that a word is matched by the longest operator it starts with.
+var execRedirects = []string{
+	">>&", ">&@", "2>@1", "2>>", "2>@",
+	"<<", "<@", ">>", ">&", ">@", "2>",
+	"<", ">",
+}
+
+// execStage is one program of an exec pipeline.
+type execStage struct {
+	words    []string
+	pipeBoth bool // the stage is piped to the next with |&, standard error included
+}
+
+// execPipeline is a parsed exec command line.
+type execPipeline struct {
+	stages     []execStage
+	background bool
+
+	stdin          io.Reader // nil for empty input
+	stdout, stderr *execOutput
+	stderrToOut    bool // 2>@1
+}
+
+// execOutput is where a redirected output of a pipeline goes: a file, or a
+// channel written to once the pipeline is done.
+type execOutput struct {
+	file    *os.File
+	channel *channel
+	buf     bytes.Buffer
+}
+
+// SetExecPolicy sets a function exec calls for each program of a pipeline
+// before any of them starts, to deny or adjust what untrusted scripts run.
+// An error from policy stops the pipeline, and exec fails with it. policy
+// may change cmd, such as its Env, Dir or Path:
+//
+//	interp.SetExecPolicy(func(cmd *exec.Cmd) error {
+//	    if filepath.Base(cmd.Path) != "git" {
+//	        return errors.New("only git may be run")
+//	    }
+//	    cmd.Env = []string{"PATH=/usr/bin"}
+//	    return nil
+//	})
+//
+// A nil policy, the default, lets exec run any program. Hide or unregister
+// exec to turn it off entirely.
+func (i *Interp) SetExecPolicy(policy func(cmd *exec.Cmd) error) {
+	i.execPolicy = policy
+}
+
+// registerExec installs the exec command.
+func (i *Interp) registerExec() {
+	i.RegisterCommand("exec", cmdExec)
+}
+
+// cmdExec implements: exec ?-option ...? arg ?arg ...?
+func cmdExec(i *Interp, cmd *Obj, args []*Obj) Result {
+	keepNewline, ignoreStderr := false, false
+	for len(args) > 0 && strings.HasPrefix(args[0].String(), "-") {
+		opt, err := i.GetIndexFromObj(args[0], []string{"-ignorestderr", "-keepnewline", "--"}, "option")
+		if err != nil {
+			return Throw([]string{"TCL", "LOOKUP", "INDEX", "option", args[0].String()}, err.Error())
+		}
+		args = args[1:]
+		if opt == 2 {
+			break
+		}
+		if opt == 0 {
+			ignoreStderr = true
+		} else {
+			keepNewline = true
+		}
+	}
+	if len(args) == 0 {
+		return Throw([]string{"TCL", "WRONGARGS"}, `wrong # args: should be "exec ?-option ...? arg ?arg ...?"`)
+	}
+	words := make([]string, len(args))
+	for n, arg := range args {
+		words[n] = arg.String()
+	}
+
+	p, res := i.parseExec(words)
+	if res != nil {
+		return *res
+	}
+	defer p.closeFiles()
+	cmds, res := i.startExec(p, ignoreStderr)
+	if res != nil {
+		return *res
+	}
+	if p.background {
+		pids := make([]int, len(cmds))
+		for n, c := range cmds {
+			pids[n] = c.Process.Pid
+			go c.Wait()
+		}
+		return OK(pids)
+	}
+	return i.finishExec(p, cmds, keepNewline, ignoreStderr)
+}
+
+// parseExec parses the words of an exec pipeline, opening the files it
+// redirects to.
+func (i *Interp) parseExec(words []string) (*execPipeline, *Result) {
+	p := &execPipeline{}
+	fail := func(code []string, msg string) (*execPipeline, *Result) {
+		p.closeFiles()
+		r := Throw(code, msg)
+		return nil, &r
+	}
+	if words[len(words)-1] == "&" {
+		p.background = true
+		words = words[:len(words)-1]
+	}
+
+	var stage execStage
+	for n := 0; n < len(words); n++ {
+		word := words[n]
+		if word == "|" || word == "|&" {
+			if len(stage.words) == 0 || n == len(words)-1 {
+				return fail([]string{"TCL", "OPERATION", "EXEC", "PIPESYNTAX"}, "illegal use of | or |& in command")
+			}
+			stage.pipeBoth = word == "|&"
+			p.stages = append(p.stages, stage)
+			stage = execStage{}
+			continue
+		}
+		op := ""
+		for _, r := range execRedirects {
+			if strings.HasPrefix(word, r) {
+				op = r
+				break
+			}
+		}
+		if op == "" {
+			stage.words = append(stage.words, word)
+			continue
+		}
+		if op == "2>@1" {
+			if word != op {
+				stage.words = append(stage.words, word)
+				continue
+			}
+			p.stderrToOut = true
+			continue
+		}
+		target := word[len(op):]
+		if target == "" {
+			if n == len(words)-1 {
+				return fail([]string{"TCL", "OPERATION", "EXEC", "SYNTAX"}, fmt.Sprintf(`can't specify "%s" as last word in command`, op))
+			}
+			n++
+			target = words[n]
+		}
+		if err := i.redirectExec(p, op, target); err != nil {
+			return fail(err.code, err.msg)
+		}
+	}
+	if len(stage.words) == 0 {
+		if len(p.stages) > 0 {
+			return fail([]string{"TCL", "OPERATION", "EXEC", "PIPESYNTAX"}, "illegal use of | or |& in command")
+		}
+		return fail([]string{"TCL", "OPERATION", "EXEC", "NOCMD"}, "didn't specify command to execute")
+	}
+	p.stages = append(p.stages, stage)
+	return p, nil
+}
+
+// execError is an error of exec with its error code.
+type execError struct {
+	code []string
+	msg  string
+}
+
+// redirectExec applies the redirection op target to p.
+func (i *Interp) redirectExec(p *execPipeline, op, target string) *execError {
+	if op == "<<" {
+		p.stdin = strings.NewReader(target)
+		return nil
+	}
+	if strings.HasSuffix(op, "@") {
+		c, err := i.lookupChannel(target)
+		if err != nil {
+			return &execError{[]string{"TCL", "LOOKUP", "CHANNEL", target}, err.Error()}
+		}
+		if op == "<@" {
+			data, err := io.ReadAll(c.input())
+			if err != nil {
+				return &execError{posixErrorCode(err), fmt.Sprintf(`error reading "%s": %s`, target, posixMessage(err))}
+			}
+			p.stdin = bytes.NewReader(data)
+			return nil
+		}
+		out := &execOutput{channel: c}
+		p.setOutput(op, out)
+		return nil
+	}
+	if op == "<" {
+		f, err := os.Open(target)
+		if err != nil {
+			return &execError{posixErrorCode(err), fmt.Sprintf(`couldn't read file "%s": %s`, target, posixMessage(err))}
+		}
+		p.stdin = f
+		return nil
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if strings.HasPrefix(op, ">>") || op == "2>>" {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(target, flags, 0o666)
+	if err != nil {
+		return &execError{posixErrorCode(err), fmt.Sprintf(`couldn't write file "%s": %s`, target, posixMessage(err))}
+	}
+	p.setOutput(op, &execOutput{file: f})
+	return nil
+}
+
+// setOutput sends the outputs that the redirection op names to out.
+func (p *execPipeline) setOutput(op string, out *execOutput) {
+	if !strings.HasPrefix(op, "2") {
+		p.stdout = out
+	}
+	if strings.HasPrefix(op, "2") || strings.Contains(op, "&") {
+		p.stderr = out
+	}
+}
+
+// closeFiles closes the files the pipeline was redirected to or from.
+func (p *execPipeline) closeFiles() {
+	if p == nil {
+		return
+	}
+	if f, ok := p.stdin.(*os.File); ok {
+		f.Close()
+	}
+	for _, out := range []*execOutput{p.stdout, p.stderr} {
+		if out != nil && out.file != nil {
+			out.file.Close()
+		}
+	}
+}
+
+// execWriter returns the writer for the output out of a pipeline. Buffers
+// are written to from the goroutines of several programs, so writes to them
+// are serialized. In the background, output that is not to a file goes to
+// its channel, or else the channel name, through the event loop.
+func (i *Interp) execWriter(out *execOutput, background bool, name string) io.Writer {
+	switch {
+	case out.file != nil:
+		return out.file
+	case background && out.channel != nil:
+		return i.postWriter(out.channel.name)
+	case background:
+		return i.postWriter(name)
+	}
+	return &lockedWriter{w: &out.buf}
+}
+
+// startExec starts the programs of p, connected by pipes, after checking
+// them with the exec policy. Output not redirected goes to buffers that
+// finishExec reads, or, in the background, to the interpreter's channels
+// through the event loop.
+func (i *Interp) startExec(p *execPipeline, ignoreStderr bool) ([]*exec.Cmd, *Result) {
+	cmds := make([]*exec.Cmd, len(p.stages))
+	for n, stage := range p.stages {
+		path, err := exec.LookPath(stage.words[0])
+		if err != nil {
+			err = errors.Unwrap(err)
+			if errors.Is(err, exec.ErrNotFound) {
+				err = fs.ErrNotExist
+			}
+			r := Throw(posixErrorCode(err), fmt.Sprintf(`couldn't execute "%s": %s`, stage.words[0], posixMessage(err)))
+			return nil, &r
+		}
+		cmds[n] = &exec.Cmd{Path: path, Args: stage.words}
+		if i.envPolicy != nil {
+			cmds[n].Env = i.envPolicy.Environ()
+		}
+		if i.execPolicy != nil {
+			if err := i.execPolicy(cmds[n]); err != nil {
+				r := Throw([]string{"TCL", "OPERATION", "EXEC", "DENIED"}, fmt.Sprintf(`couldn't execute "%s": %s`, stage.words[0], err))
+				return nil, &r
+			}
+		}
+	}
+
+	if p.stdout == nil {
+		p.stdout = &execOutput{}
+	}
+	if p.stderr == nil && !p.stderrToOut {
+		p.stderr = &execOutput{}
+		if ignoreStderr {
+			p.stderr.channel = i.channels["stderr"]
+		}
+	}
+	stdout := i.execWriter(p.stdout, p.background, "stdout")
+	stderr := stdout
+	if p.stderr != p.stdout && !p.stderrToOut {
+		stderr = i.execWriter(p.stderr, p.background, "stderr")
+	}
+
+	var pipes []*os.File
+	closePipes := func() {
+		for _, f := range pipes {
+			f.Close()
+		}
+	}
+	cmds[0].Stdin = p.stdin
+	for n, c := range cmds {
+		c.Stderr = stderr
+		if n == len(cmds)-1 {
+			c.Stdout = stdout
+			break
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			closePipes()
+			res := Throw(posixErrorCode(err), "couldn't create pipe: "+posixMessage(err))
+			return nil, &res
+		}
+		pipes = append(pipes, r, w)
+		c.Stdout = w
+		if p.stages[n].pipeBoth {
+			c.Stderr = w
+		}
+		cmds[n+1].Stdin = r
+	}
+	for n, c := range cmds {
+		if err := c.Start(); err != nil {
+			closePipes()
+			for _, started := range cmds[:n] {
+				started.Process.Kill()
+				started.Wait()
+			}
+			res := Throw(posixErrorCode(err), fmt.Sprintf(`couldn't execute "%s": %s`, c.Args[0], posixMessage(err)))
+			return nil, &res
+		}
+	}
+	closePipes()
+	return cmds, nil
+}
+
+// finishExec waits for the programs of p and returns the result of exec.
+func (i *Interp) finishExec(p *execPipeline, cmds []*exec.Cmd, keepNewline, ignoreStderr bool) Result {
+	var failure []any // the error code of the last abnormal exit
+	var message string
+	for _, c := range cmds {
+		err := c.Wait()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			continue
+		}
+		pid := c.ProcessState.Pid()
+		status, _ := c.ProcessState.Sys().(syscall.WaitStatus)
+		if status.Signaled() {
+			name, msg := signalName(status.Signal())
+			failure = []any{"CHILDKILLED", pid, name, msg}
+			message = "child killed: " + msg
+			continue
+		}
+		failure = []any{"CHILDSTATUS", pid, c.ProcessState.ExitCode()}
+		message = "child process exited abnormally"
+	}
+
+	// Output to channels is written once the programs are done
+	for _, out := range []*execOutput{p.stdout, p.stderr} {
+		if out != nil && out.channel != nil && out.buf.Len() > 0 {
+			out.channel.write(out.buf.Bytes())
+			out.buf.Reset()
+		}
+	}
+
+	result := ""
+	if p.stdout != nil && p.stdout.file == nil && p.stdout.channel == nil {
+		result = p.stdout.buf.String()
+	}
+	errText := ""
+	if p.stderr != nil && p.stderr.file == nil && p.stderr.channel == nil {
+		errText = p.stderr.buf.String()
+	}
+	if !keepNewline {
+		result = strings.TrimSuffix(result, "\n")
+	}
+	errText = strings.TrimSuffix(errText, "\n")
+
+	if failure == nil && (errText == "" || ignoreStderr) {
+		return OK(result)
+	}
+	msg := result
+	if errText != "" {
+		if msg != "" {
+			msg += "\n"
+		}
+		msg += errText
+	} else if failure != nil {
+		if msg != "" {
+			msg += "\n"
+		}
+		msg += message
+	}
+	if failure == nil {
+		return Throw([]string{"NONE"}, msg)
+	}
+	return Throw(failure, msg)
+}
+
+// postWriter returns a writer, safe to use from any goroutine, that writes
+// to the channel name from the event loop.
+func (i *Interp) postWriter(name string) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		data := bytes.Clone(p)
+		i.Post(func() {
+			if c, err := i.lookupChannel(name); err == nil {
+				c.write(data)
+			}
+		})
+		return len(p), nil
+	})
+}
+
+// writerFunc is a function that is an io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// lockedWriter serializes the writes to w.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// posixErrors names the errno values of POSIX error codes.
+var posixErrors = map[syscall.Errno]string{
+	syscall.EACCES:    "EACCES",
+	syscall.EEXIST:    "EEXIST",
+	syscall.EINVAL:    "EINVAL",
+	syscall.EISDIR:    "EISDIR",
+	syscall.ENOENT:    "ENOENT",
+	syscall.ENOEXEC:   "ENOEXEC",
+	syscall.ENOSPC:    "ENOSPC",
+	syscall.ENOTDIR:   "ENOTDIR",
+	syscall.ENOTEMPTY: "ENOTEMPTY",
+	syscall.EPERM:     "EPERM",
+	syscall.EPIPE:     "EPIPE",
+	syscall.EROFS:     "EROFS",
+}
+
+// posixMessages are TCL's messages for the errno values where they differ
+// from the C library's.
+var posixMessages = map[syscall.Errno]string{
+	syscall.EEXIST: "file already exists",
+	syscall.EISDIR: "illegal operation on a directory",
+	syscall.EPERM:  "not owner",
+}
+
+// posixErrno returns the errno value of err, and whether it has one.
+func posixErrno(err error) (syscall.Errno, bool) {
+	var errno syscall.Errno
+	switch {
+	case errors.As(err, &errno):
+		return errno, true
+	case errors.Is(err, fs.ErrNotExist):
+		return syscall.ENOENT, true
+	case errors.Is(err, fs.ErrPermission):
+		return syscall.EACCES, true
+	case errors.Is(err, fs.ErrExist):
+		return syscall.EEXIST, true
+	}
+	return 0, false
+}
+
+// posixErrorCode returns the error code for err, such as
+// {POSIX ENOENT {no such file or directory}}.
+func posixErrorCode(err error) []string {
+	errno, ok := posixErrno(err)
+	if !ok {
+		return []string{"POSIX", "EUNKNOWN", posixMessage(err)}
+	}
+	name, ok := posixErrors[errno]
+	if !ok {
+		name = "E" + strconv.Itoa(int(errno))
+	}
+	return []string{"POSIX", name, posixMessage(err)}
+}
+
+// posixMessage returns the message of err without the operation and path
+// that Go errors carry, as TCL reports system errors.
+func posixMessage(err error) string {
+	errno, ok := posixErrno(err)
+	if !ok {
+		return err.Error()
+	}
+	if msg, ok := posixMessages[errno]; ok {
+		return msg
+	}
+	return errno.Error()
+}
+
+// signalName returns the name and description of sig, as TCL reports a
+// program killed by a signal.
+func signalName(sig syscall.Signal) (name, msg string) {
+	switch sig {
+	case syscall.SIGABRT:
+		return "SIGABRT", "SIGABRT"
+	case syscall.SIGHUP:
+		return "SIGHUP", "hangup"
+	case syscall.SIGINT:
+		return "SIGINT", "interrupt"
+	case syscall.SIGKILL:
+		return "SIGKILL", "kill signal"
+	case syscall.SIGPIPE:
+		return "SIGPIPE", "write on pipe with no readers"
+	case syscall.SIGQUIT:
+		return "SIGQUIT", "quit signal"
+	case syscall.SIGSEGV:
+		return "SIGSEGV", "segmentation violation"
+	case syscall.SIGTERM:
+		return "SIGTERM", "software termination signal"
+	}
+	return fmt.Sprintf("SIG%d", int(sig)), sig.String()
+}
@@ -0,0 +1,569 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// The file command works with file names and the files they name:
+//
+//	file copy ?-force? ?--? source ?source ...? target
+//	file delete ?-force? ?--? ?pathname ...?
+//	file dirname name
+//	file exists name
+//	file extension name
+//	file glob pattern
+//	file join name ?name ...?
+//	file mkdir ?dir ...?
+//	file rename ?-force? ?--? source ?source ...? target
+//	file stat name ?varName?
+//	file tail name
+//
+// dirname, extension, join and tail only look at the names. The other
+// subcommands go through the interpreter's [FilesystemDriver], which is the
+// operating system's file system unless [Interp.SetFilesystem] replaces it.
+// file stat returns a dict of the fields TCL stores in an array, and also
+// stores the dict in varName if one is given.
+
+// FilesystemDriver is a file system the file command works on. Names are
+// passed as scripts give them, so a driver decides how relative and
+// absolute names map onto its files. Errors should wrap the [fs] errors,
+// such as [fs.ErrNotExist], or [syscall.Errno] values, which scripts see
+// as POSIX error codes.
+//
+// [OSFilesystem], [ReadOnlyFilesystem] and [DeniedFilesystem] return the
+// drivers feather provides.
+type FilesystemDriver interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Mkdir(name string, perm fs.FileMode) error
+	Remove(name string) error // removes a file or an empty directory
+	Rename(oldname, newname string) error
+}
+
+// OSFilesystem returns the driver for the operating system's file system,
+// which the file command uses by default.
+func OSFilesystem() FilesystemDriver {
+	return osFilesystem{}
+}
+
+// osFilesystem is the driver of [OSFilesystem].
+type osFilesystem struct{}
+
+func (osFilesystem) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFilesystem) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFilesystem) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFilesystem) Mkdir(name string, perm fs.FileMode) error  { return os.Mkdir(name, perm) }
+func (osFilesystem) Remove(name string) error                   { return os.Remove(name) }
+func (osFilesystem) Rename(oldname, newname string) error       { return os.Rename(oldname, newname) }
+
+func (osFilesystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// ReadOnlyFilesystem returns a driver that reads the files of fsys, such
+// as an [embed.FS] or an [fstest.MapFS], and fails to change them with
+// EROFS. Absolute names are taken relative to the root of fsys.
+//
+//	//go:embed data
+//	var data embed.FS
+//
+//	interp.SetFilesystem(feather.ReadOnlyFilesystem(data))
+func ReadOnlyFilesystem(fsys fs.FS) FilesystemDriver {
+	return readOnlyFilesystem{fsys}
+}
+
+// readOnlyFilesystem is the driver of [ReadOnlyFilesystem].
+type readOnlyFilesystem struct {
+	fsys fs.FS
+}
+
+// name returns the name in the file system of the file name, or an error
+// for op if name is outside it.
+func (r readOnlyFilesystem) name(op, name string) (string, error) {
+	clean := strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if clean == "" {
+		clean = "."
+	}
+	if !fs.ValidPath(clean) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return clean, nil
+}
+
+func (r readOnlyFilesystem) Stat(name string) (fs.FileInfo, error) {
+	clean, err := r.name("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(r.fsys, clean)
+}
+
+func (r readOnlyFilesystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	clean, err := r.name("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(r.fsys, clean)
+}
+
+func (r readOnlyFilesystem) ReadFile(name string) ([]byte, error) {
+	clean, err := r.name("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(r.fsys, clean)
+}
+
+func (r readOnlyFilesystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return &fs.PathError{Op: "open", Path: name, Err: syscall.EROFS}
+}
+
+func (r readOnlyFilesystem) Mkdir(name string, perm fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: syscall.EROFS}
+}
+
+func (r readOnlyFilesystem) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: syscall.EROFS}
+}
+
+func (r readOnlyFilesystem) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EROFS}
+}
+
+// DeniedFilesystem returns a driver that fails every operation with
+// EACCES, for interpreters whose scripts must not see any files while the
+// file command's name subcommands stay available.
+func DeniedFilesystem() FilesystemDriver {
+	return deniedFilesystem{}
+}
+
+// deniedFilesystem is the driver of [DeniedFilesystem].
+type deniedFilesystem struct{}
+
+// denied returns the error of every operation.
+func denied(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+}
+
+func (deniedFilesystem) Stat(name string) (fs.FileInfo, error) { return nil, denied("stat", name) }
+func (deniedFilesystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	return nil, denied("readdir", name)
+}
+func (deniedFilesystem) ReadFile(name string) ([]byte, error)      { return nil, denied("open", name) }
+func (deniedFilesystem) Mkdir(name string, perm fs.FileMode) error { return denied("mkdir", name) }
+func (deniedFilesystem) Remove(name string) error                  { return denied("remove", name) }
+func (deniedFilesystem) Rename(oldname, newname string) error      { return denied("rename", oldname) }
+
+func (deniedFilesystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return denied("open", name)
+}
+
+// SetFilesystem sets the file system the file command works on, so that
+// scripts can be confined to an [fs.FS] or kept away from files entirely:
+//
+//	interp.SetFilesystem(feather.ReadOnlyFilesystem(fstest.MapFS{
+//	    "config.tcl": {Data: []byte("set port 8080")},
+//	}))
+//
+// A nil driver restores the default, [OSFilesystem].
+func (i *Interp) SetFilesystem(fsys FilesystemDriver) {
+	i.filesystem = fsys
+}
+
+// fs returns the driver the file command uses.
+func (i *Interp) fs() FilesystemDriver {
+	if i.filesystem == nil {
+		return osFilesystem{}
+	}
+	return i.filesystem
+}
+
+// fileSubcommands are the subcommands of file, for GetIndexFromObj.
+var fileSubcommands = []string{
+	"copy", "delete", "dirname", "exists", "extension", "glob",
+	"join", "mkdir", "rename", "stat", "tail",
+}
+
+// fileOptions are the options of file copy, delete and rename.
+var fileOptions = []string{"-force", "--"}
+
+// registerFile installs the file command.
+func (i *Interp) registerFile() {
+	i.RegisterCommand("file", cmdFile)
+}
+
+// cmdFile implements: file subcommand ?arg ...?
+func cmdFile(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "file subcommand ?arg ...?"`)
+	}
+	n, err := i.GetIndexFromObj(args[0], fileSubcommands, "subcommand")
+	if err != nil {
+		return Throw([]string{"TCL", "LOOKUP", "SUBCOMMAND", args[0].String()}, err.Error())
+	}
+	sub, args := fileSubcommands[n], args[1:]
+	switch sub {
+	case "copy", "rename":
+		return fileCopy(i, sub, args)
+	case "delete":
+		return fileDelete(i, args)
+	case "join":
+		if len(args) == 0 {
+			return Error(`wrong # args: should be "file join name ?name ...?"`)
+		}
+		names := make([]string, len(args))
+		for k, arg := range args {
+			names[k] = arg.String()
+		}
+		return OK(joinFileNames(names...))
+	case "mkdir":
+		for _, arg := range args {
+			if failed, err := makeDirs(i.fs(), arg.String()); err != nil {
+				return Throw(posixErrorCode(err), fmt.Sprintf(`can't create directory "%s": %s`, failed, posixMessage(err)))
+			}
+		}
+		return OK("")
+	case "stat":
+		return fileStat(i, args)
+	case "glob":
+		if len(args) != 1 {
+			return Error(`wrong # args: should be "file glob pattern"`)
+		}
+		matches, res := globFiles(i.fs(), "", args[0].String(), nil)
+		if res != nil {
+			return *res
+		}
+		return OK(matches)
+	}
+
+	if len(args) != 1 {
+		return Errorf(`wrong # args: should be "file %s name"`, sub)
+	}
+	name := args[0].String()
+	switch sub {
+	case "dirname":
+		return OK(fileDirname(name))
+	case "exists":
+		_, err := i.fs().Stat(name)
+		return OK(err == nil)
+	case "extension":
+		return OK(fileExtension(name))
+	}
+	return OK(fileTail(name))
+}
+
+// parseFileOptions parses the options of file copy, delete and rename,
+// returning whether -force was given and the arguments after the options.
+func parseFileOptions(i *Interp, args []*Obj) (bool, []*Obj, *Result) {
+	force := false
+	for len(args) > 0 && strings.HasPrefix(args[0].String(), "-") {
+		opt, err := i.GetIndexFromObj(args[0], fileOptions, "option")
+		if err != nil {
+			r := Throw([]string{"TCL", "LOOKUP", "INDEX", "option", args[0].String()}, err.Error())
+			return false, nil, &r
+		}
+		args = args[1:]
+		if opt == 1 {
+			break
+		}
+		force = true
+	}
+	return force, args, nil
+}
+
+// fileDelete implements: file delete ?-force? ?--? ?pathname ...?
+func fileDelete(i *Interp, args []*Obj) Result {
+	force, args, res := parseFileOptions(i, args)
+	if res != nil {
+		return *res
+	}
+	fsys := i.fs()
+	for _, arg := range args {
+		name := arg.String()
+		info, err := fsys.Stat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			if info.IsDir() && force {
+				err = removeAll(fsys, name)
+			} else {
+				err = fsys.Remove(name)
+			}
+		}
+		if err != nil {
+			return Throw(posixErrorCode(err), fmt.Sprintf(`error deleting "%s": %s`, name, posixMessage(err)))
+		}
+	}
+	return OK("")
+}
+
+// fileCopy implements file copy and file rename, which op names:
+//
+//	file op ?-force? ?--? source ?source ...? target
+//
+// A target that is a directory receives the sources under their tails.
+func fileCopy(i *Interp, op string, args []*Obj) Result {
+	force, args, res := parseFileOptions(i, args)
+	if res != nil {
+		return *res
+	}
+	if len(args) < 2 {
+		return Errorf(`wrong # args: should be "file %s ?-option value ...? source ?source ...? target"`, op)
+	}
+	verb := map[string]string{"copy": "copying", "rename": "renaming"}[op]
+	fsys := i.fs()
+	sources, target := args[:len(args)-1], args[len(args)-1].String()
+	info, err := fsys.Stat(target)
+	intoDir := err == nil && info.IsDir()
+	if len(sources) > 1 && !intoDir {
+		return Throw(posixErrorCode(syscall.ENOTDIR), fmt.Sprintf(`error %s: target "%s" is not a directory`, verb, target))
+	}
+
+	for _, src := range sources {
+		name := src.String()
+		info, err := fsys.Stat(name)
+		if err != nil {
+			return Throw(posixErrorCode(err), fmt.Sprintf(`error %s "%s": %s`, verb, name, posixMessage(err)))
+		}
+		dest := target
+		if intoDir {
+			dest = joinFileNames(target, fileTail(name))
+		}
+		if _, err := fsys.Stat(dest); err == nil {
+			if force {
+				err = fsys.Remove(dest)
+			} else {
+				err = syscall.EEXIST
+			}
+			if err != nil {
+				return Throw(posixErrorCode(err), fmt.Sprintf(`error %s "%s" to "%s": %s`, verb, name, dest, posixMessage(err)))
+			}
+		}
+		if op == "copy" {
+			err = copyFile(fsys, name, dest, info)
+		} else {
+			err = fsys.Rename(name, dest)
+		}
+		if err != nil {
+			return Throw(posixErrorCode(err), fmt.Sprintf(`error %s "%s" to "%s": %s`, verb, name, dest, posixMessage(err)))
+		}
+	}
+	return OK("")
+}
+
+// fileStat implements: file stat name ?varName?
+func fileStat(i *Interp, args []*Obj) Result {
+	if len(args) < 1 || len(args) > 2 {
+		return Error(`wrong # args: should be "file stat name ?varName?"`)
+	}
+	name := args[0].String()
+	info, err := i.fs().Stat(name)
+	if err != nil {
+		return Throw(posixErrorCode(err), fmt.Sprintf(`could not read "%s": %s`, name, posixMessage(err)))
+	}
+	st := statFields(info)
+	stat := i.DictKV(
+		"atime", st.atime, "blksize", st.blksize, "blocks", st.blocks,
+		"ctime", st.ctime, "dev", st.dev, "gid", st.gid, "ino", st.ino,
+		"mode", fileMode(info.Mode()), "mtime", info.ModTime().Unix(),
+		"nlink", st.nlink, "size", info.Size(), "type", fileType(info.Mode()), "uid", st.uid,
+	)
+	if len(args) == 2 {
+		if err := i.SetVarObj(args[1].String(), stat); err != nil {
+			return Error(err.Error())
+		}
+	}
+	return OK(stat)
+}
+
+// fileStatFields are the fields of file stat that [fs.FileInfo] has no
+// methods for.
+type fileStatFields struct {
+	atime, ctime              int64
+	dev, ino, nlink, uid, gid int64
+	blksize, blocks           int64
+}
+
+// fileMode returns the mode of file stat for mode, as the st_mode of a
+// POSIX stat call.
+func fileMode(mode fs.FileMode) int64 {
+	bits := int64(mode.Perm())
+	switch {
+	case mode&fs.ModeDir != 0:
+		bits |= 0o040000
+	case mode&fs.ModeSymlink != 0:
+		bits |= 0o120000
+	case mode&fs.ModeNamedPipe != 0:
+		bits |= 0o010000
+	case mode&fs.ModeSocket != 0:
+		bits |= 0o140000
+	case mode&fs.ModeCharDevice != 0:
+		bits |= 0o020000
+	case mode&fs.ModeDevice != 0:
+		bits |= 0o060000
+	default:
+		bits |= 0o100000
+	}
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
+
+// fileType returns the type of file stat for mode.
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode&fs.ModeDir != 0:
+		return "directory"
+	case mode&fs.ModeSymlink != 0:
+		return "link"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "characterSpecial"
+	case mode&fs.ModeDevice != 0:
+		return "blockSpecial"
+	}
+	return "file"
+}
+
+// joinFileNames joins names as file join does: an absolute name discards
+// the names before it, and empty elements are dropped.
+func joinFileNames(names ...string) string {
+	joined := ""
+	for _, name := range names {
+		if strings.HasPrefix(name, "/") {
+			joined = "/"
+		}
+		for _, elem := range strings.Split(name, "/") {
+			if elem == "" {
+				continue
+			}
+			if joined != "" && !strings.HasSuffix(joined, "/") {
+				joined += "/"
+			}
+			joined += elem
+		}
+	}
+	return joined
+}
+
+// fileDirname returns all but the last element of name, as file dirname
+// does.
+func fileDirname(name string) string {
+	trimmed := strings.TrimRight(name, "/")
+	if trimmed == "" && name != "" {
+		return "/"
+	}
+	slash := strings.LastIndex(trimmed, "/")
+	if slash < 0 {
+		return "."
+	}
+	if dir := strings.TrimRight(trimmed[:slash], "/"); dir != "" {
+		return dir
+	}
+	return "/"
+}
+
+// fileTail returns the last element of name, as file tail does.
+func fileTail(name string) string {
+	trimmed := strings.TrimRight(name, "/")
+	return trimmed[strings.LastIndex(trimmed, "/")+1:]
+}
+
+// fileExtension returns the part of the last element of name from its
+// last dot, as file extension does.
+func fileExtension(name string) string {
+	last := name[strings.LastIndex(name, "/")+1:]
+	if dot := strings.LastIndex(last, "."); dot >= 0 {
+		return last[dot:]
+	}
+	return ""
+}
+
+// makeDirs creates dir and any missing parents, as file mkdir does. On
+// failure it returns the directory it could not create with the error.
+func makeDirs(fsys FilesystemDriver, dir string) (string, error) {
+	info, err := fsys.Stat(dir)
+	if err == nil {
+		if info.IsDir() {
+			return "", nil
+		}
+		return dir, syscall.EEXIST
+	}
+	if parent := fileDirname(dir); parent != dir && parent != "." {
+		if failed, err := makeDirs(fsys, parent); err != nil {
+			return failed, err
+		}
+	}
+	if err := fsys.Mkdir(dir, 0o777); err != nil {
+		return dir, err
+	}
+	return "", nil
+}
+
+// removeAll removes the directory name and everything in it.
+func removeAll(fsys FilesystemDriver, name string) error {
+	entries, err := fsys.ReadDir(name)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		child := joinFileNames(name, e.Name())
+		if e.IsDir() {
+			err = removeAll(fsys, child)
+		} else {
+			err = fsys.Remove(child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return fsys.Remove(name)
+}
+
+// copyFile copies the file or directory src, whose info is info, to dest.
+func copyFile(fsys FilesystemDriver, src, dest string, info fs.FileInfo) error {
+	if !info.IsDir() {
+		data, err := fsys.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		return fsys.WriteFile(dest, data, info.Mode().Perm())
+	}
+	if err := fsys.Mkdir(dest, info.Mode().Perm()); err != nil {
+		return err
+	}
+	entries, err := fsys.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		child, err := e.Info()
+		if err != nil {
+			return err
+		}
+		if err := copyFile(fsys, joinFileNames(src, e.Name()), joinFileNames(dest, e.Name()), child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
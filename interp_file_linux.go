@@ -0,0 +1,29 @@
+//go:build linux
+
+package feather
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// statFields returns the fields of file stat for info that [fs.FileInfo]
+// has no methods for, from the stat call behind it if it has one.
+func statFields(info fs.FileInfo) fileStatFields {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		mtime := info.ModTime().Unix()
+		return fileStatFields{atime: mtime, ctime: mtime, nlink: 1}
+	}
+	return fileStatFields{
+		atime:   st.Atim.Sec,
+		ctime:   st.Ctim.Sec,
+		dev:     int64(st.Dev),
+		ino:     int64(st.Ino),
+		nlink:   int64(st.Nlink),
+		uid:     int64(st.Uid),
+		gid:     int64(st.Gid),
+		blksize: int64(st.Blksize),
+		blocks:  int64(st.Blocks),
+	}
+}
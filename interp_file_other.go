@@ -0,0 +1,13 @@
+//go:build !linux
+
+package feather
+
+import "io/fs"
+
+// statFields returns the fields of file stat for info that [fs.FileInfo]
+// has no methods for. Outside Linux only the times are filled in, from the
+// modification time.
+func statFields(info fs.FileInfo) fileStatFields {
+	mtime := info.ModTime().Unix()
+	return fileStatFields{atime: mtime, ctime: mtime, nlink: 1}
+}
@@ -2,36 +2,171 @@ package feather
 
 import (
 	"fmt"
+	"log/slog"
+	"maps"
 	"reflect"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"weak"
 )
 
 // foreignTypeInfo stores runtime information about a registered foreign type.
 type foreignTypeInfo struct {
-	name       string
-	newFunc    reflect.Value        // constructor function
-	methods    map[string]reflect.Value // method name -> function
-	stringRep  reflect.Value        // optional string representation function
-	destroy    reflect.Value        // optional destructor function
-	receiverType reflect.Type       // type of the receiver (T)
+	name         string
+	newFunc      reflect.Value             // constructor function
+	methods      map[string]*foreignMethod // method name -> method
+	statics      map[string]*foreignMethod // static method name -> method
+	stringRep    reflect.Value             // optional string representation function
+	destroy      reflect.Value             // optional destructor function
+	receiverType reflect.Type              // type of the receiver (T)
+	autoDestroy  bool                      // destroy instances once unreachable
+}
+
+// foreignMethod is a method of a registered foreign type.
+type foreignMethod struct {
+	fn       reflect.Value // implementing function; invalid for a field method
+	field    []int         // index of the field a field method accesses
+	readOnly bool          // the field method cannot set the field
+	variadic bool          // the last parameter takes the remaining arguments
+
+	// receiver finds the receiver of an inherited method in the value of
+	// an object. It is nil for the type's own methods.
+	receiver func(reflect.Value) (reflect.Value, error)
+}
+
+// newForeignMethod returns the TCL name and the method for the entry key:m
+// of TypeDef Methods or Statics. m is a function or a Method. recv is the
+// receiver type, or nil for a static method.
+func newForeignMethod(key string, m any, recv reflect.Type) (string, *foreignMethod, error) {
+	opts, ok := m.(Method)
+	if !ok {
+		opts = Method{Func: m}
+	}
+	name := key
+	if opts.Name != "" {
+		name = opts.Name
+	}
+	method := &foreignMethod{readOnly: opts.ReadOnly, variadic: opts.Variadic}
+
+	if opts.Field != "" {
+		if recv == nil {
+			return "", nil, fmt.Errorf("static method %s cannot be a field", key)
+		}
+		if recv.Kind() != reflect.Pointer || recv.Elem().Kind() != reflect.Struct {
+			return "", nil, fmt.Errorf("method %s: %v is not a pointer to a struct", key, recv)
+		}
+		f, ok := recv.Elem().FieldByName(opts.Field)
+		if !ok || !f.IsExported() {
+			return "", nil, fmt.Errorf("method %s: %v has no exported field %s", key, recv.Elem(), opts.Field)
+		}
+		method.field = f.Index
+		return name, method, nil
+	}
+
+	fn := reflect.ValueOf(opts.Func)
+	if fn.Kind() != reflect.Func {
+		return "", nil, fmt.Errorf("method %s is %T, not a function", key, opts.Func)
+	}
+	t := fn.Type()
+	fixed := 0
+	if recv != nil {
+		if t.NumIn() < 1 || !recv.AssignableTo(t.In(0)) {
+			return "", nil, fmt.Errorf("method %s must take %v as its first parameter", key, recv)
+		}
+		fixed = 1
+	}
+	if t.IsVariadic() {
+		method.variadic = true
+	}
+	if method.variadic && (t.NumIn() == fixed || t.In(t.NumIn()-1).Kind() != reflect.Slice) {
+		return "", nil, fmt.Errorf("variadic method %s must have a slice as its last parameter", key)
+	}
+	method.fn = fn
+	return name, method, nil
+}
+
+// inherit adds the methods of base that info does not define, calling them
+// on the value of base embedded in objects of info.
+func (info *foreignTypeInfo) inherit(base *foreignTypeInfo) error {
+	if base.receiverType == nil {
+		return fmt.Errorf("cannot embed %s, which is not a Go type", base.name)
+	}
+	find, err := embeddedReceiver(info.receiverType, base.receiverType)
+	if err != nil {
+		return err
+	}
+	for name, m := range base.methods {
+		if _, ok := info.methods[name]; ok {
+			continue
+		}
+		inherited := *m
+		inherited.receiver = find
+		if outer := m.receiver; outer != nil {
+			inherited.receiver = func(v reflect.Value) (reflect.Value, error) {
+				e, err := find(v)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				return outer(e)
+			}
+		}
+		info.methods[name] = &inherited
+	}
+	return nil
+}
+
+// embeddedReceiver returns a function that finds the value of type base
+// embedded in a value of type recv, which must point to a struct. The
+// struct embeds either base or, if base is a pointer *S, S.
+func embeddedReceiver(recv, base reflect.Type) (func(reflect.Value) (reflect.Value, error), error) {
+	if recv.Kind() == reflect.Pointer && recv.Elem().Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(recv.Elem()) {
+			if !f.Anonymous || !f.IsExported() {
+				continue
+			}
+			addr := base.Kind() == reflect.Pointer && f.Type == base.Elem()
+			if f.Type != base && !addr {
+				continue
+			}
+			index := f.Index
+			return func(v reflect.Value) (reflect.Value, error) {
+				e, err := v.Elem().FieldByIndexErr(index)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("embedded %v is nil", base)
+				}
+				if addr {
+					return e.Addr(), nil
+				}
+				if e.Kind() == reflect.Pointer && e.IsNil() {
+					return reflect.Value{}, fmt.Errorf("embedded %v is nil", base)
+				}
+				return e, nil
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("%v does not embed %v", recv, base)
 }
 
 // foreignInstance stores information about a live foreign object instance.
 type foreignInstance struct {
 	typeName   string
-	handleName string     // e.g., "mux1"
-	objHandle  FeatherObj     // the FeatherObj handle
-	value      any        // the actual Go value
+	handleName string            // e.g., "mux1"
+	objHandle  FeatherObj        // the FeatherObj handle
+	obj        weak.Pointer[Obj] // the object, if it is still reachable
+	value      any               // the actual Go value
 }
 
 // ForeignRegistry manages foreign type definitions and object instances.
 type ForeignRegistry struct {
 	mu           sync.RWMutex
-	types        map[string]*foreignTypeInfo    // type name -> type info
-	instances    map[string]*foreignInstance    // handle name -> instance
-	counters     map[string]int                 // type name -> next counter
-	handleToType map[FeatherObj]*foreignInstance    // FeatherObj handle -> instance
+	types        map[string]*foreignTypeInfo     // type name -> type info
+	instances    map[string]*foreignInstance     // handle name -> instance
+	counters     map[string]int                  // type name -> next counter
+	handleToType map[FeatherObj]*foreignInstance // FeatherObj handle -> instance
+	scopes       [][]*foreignInstance            // instances created in each open handle scope
+	unreachable  []*foreignInstance              // instances to destroy, found by the garbage collector
 }
 
 // newForeignRegistry creates a new foreign registry.
@@ -52,10 +187,6 @@ func (i *Interp) foreignConstructor(typeName string, cmd FeatherObj, args []Feat
 	}
 
 	subCmd := i.getString(args[0])
-	if subCmd != "new" {
-		i.SetErrorString(fmt.Sprintf("unknown subcommand \"%s\": must be new", subCmd))
-		return ResultError
-	}
 
 	// Get type info
 	i.ForeignRegistry.mu.RLock()
@@ -66,6 +197,19 @@ func (i *Interp) foreignConstructor(typeName string, cmd FeatherObj, args []Feat
 		return ResultError
 	}
 
+	if subCmd != "new" {
+		if static, ok := info.statics[subCmd]; ok {
+			return i.callForeignFunc(static, nil, args[1:])
+		}
+		names := []string{"new"}
+		for name := range info.statics {
+			names = append(names, name)
+		}
+		slices.Sort(names[1:])
+		i.SetErrorString(fmt.Sprintf("unknown subcommand \"%s\": must be %s", subCmd, strings.Join(names, ", ")))
+		return ResultError
+	}
+
 	// Call the constructor
 	results := info.newFunc.Call(nil)
 	if len(results) == 0 {
@@ -80,6 +224,17 @@ func (i *Interp) foreignConstructor(typeName string, cmd FeatherObj, args []Feat
 	i.ForeignRegistry.counters[typeName] = counter + 1
 	handleName := fmt.Sprintf("%s%d", strings.ToLower(typeName), counter)
 	i.ForeignRegistry.mu.Unlock()
+	if info.stringRep.IsValid() {
+		handleName = info.stringRep.Call([]reflect.Value{reflect.ValueOf(value)})[0].String()
+		if handleName == "" {
+			i.SetErrorString(fmt.Sprintf("%s new: object name is empty", typeName))
+			return ResultError
+		}
+		if _, exists := i.globalNamespace.commands[handleName]; exists {
+			i.SetErrorString(fmt.Sprintf("%s new: object name \"%s\" is already in use", typeName, handleName))
+			return ResultError
+		}
+	}
 
 	// Create the foreign object
 	objHandle := i.newForeignObj(typeName, value)
@@ -96,16 +251,32 @@ func (i *Interp) foreignConstructor(typeName string, cmd FeatherObj, args []Feat
 		objHandle:  objHandle,
 		value:      value,
 	}
-	i.ForeignRegistry.mu.Lock()
-	i.ForeignRegistry.instances[handleName] = instance
-	i.ForeignRegistry.handleToType[objHandle] = instance
-	i.ForeignRegistry.mu.Unlock()
+	reg := i.ForeignRegistry
+	reg.mu.Lock()
+	reg.instances[handleName] = instance
+	reg.handleToType[objHandle] = instance
+	if n := len(reg.scopes); n > 0 {
+		reg.scopes[n-1] = append(reg.scopes[n-1], instance)
+	}
+	reg.mu.Unlock()
+	if obj := i.getObject(objHandle); obj != nil {
+		instance.obj = weak.Make(obj)
+		if info.autoDestroy {
+			runtime.AddCleanup(obj, func(instance *foreignInstance) {
+				reg.mu.Lock()
+				reg.unreachable = append(reg.unreachable, instance)
+				reg.mu.Unlock()
+			}, instance)
+		}
+	}
 
 	// Register the handle as a command (object-as-command pattern)
 	i.register(handleName, func(interp *Interp, cmd FeatherObj, args []FeatherObj) FeatherResult {
 		return interp.foreignMethodDispatch(handleName, cmd, args)
 	})
 
+	i.log(slog.LevelDebug, "foreign object created", "type", typeName, "name", handleName)
+
 	// Return the handle object (preserves foreign type)
 	i.SetResult(objHandle)
 	return ResultOK
@@ -119,9 +290,6 @@ func (i *Interp) foreignMethodDispatch(handleName string, cmd FeatherObj, args [
 		return ResultError
 	}
 
-	methodName := i.getString(args[0])
-	methodArgs := args[1:]
-
 	// Get the instance
 	i.ForeignRegistry.mu.RLock()
 	instance, ok := i.ForeignRegistry.instances[handleName]
@@ -132,71 +300,205 @@ func (i *Interp) foreignMethodDispatch(handleName string, cmd FeatherObj, args [
 	}
 
 	// Handle built-in methods
+	methodName := i.getString(args[0])
 	if methodName == "destroy" {
 		return i.foreignDestroy(handleName)
 	}
+	return i.invokeForeignMethod(instance.typeName, instance.value, methodName, args[1:])
+}
 
-	// Get type info
-	i.ForeignRegistry.mu.RLock()
-	info, ok := i.ForeignRegistry.types[instance.typeName]
-	i.ForeignRegistry.mu.RUnlock()
+// foreignInvoke calls a method on obj, which is a foreign object or the
+// name of a live one. It implements the foreign invoke operation, which the
+// C core uses for objects that are not also commands, such as those made by
+// [Interp.NewForeignHandle] or whose command was renamed.
+func (i *Interp) foreignInvoke(obj *Obj, methodName string, args []FeatherObj) FeatherResult {
+	var instance *foreignInstance
+	if i.ForeignRegistry != nil {
+		i.ForeignRegistry.mu.RLock()
+		instance = i.ForeignRegistry.instances[obj.String()]
+		i.ForeignRegistry.mu.RUnlock()
+	}
+	if instance != nil {
+		if methodName == "destroy" {
+			i.destroyForeign(instance)
+			i.SetResultString("")
+			return ResultOK
+		}
+		return i.invokeForeignMethod(instance.typeName, instance.value, methodName, args)
+	}
+
+	ft, ok := obj.intrep.(*ForeignType)
 	if !ok {
-		i.SetErrorString(fmt.Sprintf("unknown foreign type \"%s\"", instance.typeName))
+		i.SetErrorString(fmt.Sprintf("invalid object handle \"%s\"", obj.String()))
+		return ResultError
+	}
+	if methodName == "destroy" {
+		// Objects without an instance have no command to delete
+		if info := i.foreignTypeInfo(ft.TypeName); info != nil && info.destroy.IsValid() {
+			info.destroy.Call([]reflect.Value{reflect.ValueOf(ft.Value)})
+		}
+		obj.intrep = nil
+		i.SetResultString("")
+		return ResultOK
+	}
+	return i.invokeForeignMethod(ft.TypeName, ft.Value, methodName, args)
+}
+
+// foreignTypeInfo returns the registered type typeName, or nil.
+func (i *Interp) foreignTypeInfo(typeName string) *foreignTypeInfo {
+	if i.ForeignRegistry == nil {
+		return nil
+	}
+	i.ForeignRegistry.mu.RLock()
+	defer i.ForeignRegistry.mu.RUnlock()
+	return i.ForeignRegistry.types[typeName]
+}
+
+// invokeForeignMethod calls the method methodName of an object of the
+// foreign type typeName whose Go value is value.
+func (i *Interp) invokeForeignMethod(typeName string, value any, methodName string, args []FeatherObj) FeatherResult {
+	// Get type info
+	info := i.foreignTypeInfo(typeName)
+	if info == nil {
+		i.SetErrorString(fmt.Sprintf("unknown foreign type \"%s\"", typeName))
 		return ResultError
 	}
 
 	// Look up the method
-	methodFunc, ok := info.methods[methodName]
+	method, ok := info.methods[methodName]
 	if !ok {
-		// List available methods in error message
-		var methodList []string
-		for name := range info.methods {
-			methodList = append(methodList, name)
-		}
-		methodList = append(methodList, "destroy")
-		i.SetErrorString(fmt.Sprintf("unknown method \"%s\": must be %s", methodName, strings.Join(methodList, ", ")))
+		i.SetErrorString(fmt.Sprintf("unknown method \"%s\": must be %s", methodName, strings.Join(info.methodNames(), ", ")))
+		return ResultError
+	}
+	if !method.fn.IsValid() && method.field == nil {
+		// Methods of types registered through the C API are their commands
+		i.SetErrorString(fmt.Sprintf("method \"%s\" of %s can only be called through the object's command", methodName, typeName))
 		return ResultError
 	}
 
 	// Call the method with argument conversion
-	return i.callForeignMethod(instance.value, methodFunc, methodArgs)
+	return i.callForeignMethod(value, method, args)
 }
 
-// callForeignMethod calls a method with automatic argument conversion.
-func (i *Interp) callForeignMethod(receiver any, methodFunc reflect.Value, args []FeatherObj) FeatherResult {
-	methodType := methodFunc.Type()
-	numParams := methodType.NumIn()
+// methodNames returns the sorted names of the methods of objects of the
+// type, including destroy.
+func (info *foreignTypeInfo) methodNames() []string {
+	names := make([]string, 0, len(info.methods)+1)
+	for name := range info.methods {
+		names = append(names, name)
+	}
+	names = append(names, "destroy")
+	slices.Sort(names)
+	return names
+}
 
-	// First parameter is the receiver
-	if numParams < 1 {
-		i.SetErrorString("method must have at least one parameter (receiver)")
+// callForeignMethod calls a method on the value of an object.
+func (i *Interp) callForeignMethod(value any, method *foreignMethod, args []FeatherObj) FeatherResult {
+	receiver := reflect.ValueOf(value)
+	if method.receiver != nil {
+		var err error
+		if receiver, err = method.receiver(receiver); err != nil {
+			i.SetErrorString(err.Error())
+			return ResultError
+		}
+	}
+	if method.field != nil {
+		return i.accessForeignField(receiver, method, args)
+	}
+	return i.callForeignFunc(method, []reflect.Value{receiver}, args)
+}
+
+// accessForeignField returns the field of a field method, first setting it
+// if a value is given.
+func (i *Interp) accessForeignField(receiver reflect.Value, method *foreignMethod, args []FeatherObj) FeatherResult {
+	field, err := receiver.Elem().FieldByIndexErr(method.field)
+	if err != nil {
+		i.SetErrorString(err.Error())
 		return ResultError
 	}
+	switch {
+	case len(args) == 0:
+		return i.convertResult(field)
+	case method.readOnly:
+		i.SetErrorString(fmt.Sprintf("wrong # args: expected 0, got %d", len(args)))
+		return ResultError
+	case len(args) > 1:
+		i.SetErrorString(fmt.Sprintf("wrong # args: expected 0 or 1, got %d", len(args)))
+		return ResultError
+	}
+	v, err := i.convertArg(args[0], field.Type())
+	if err == nil && !v.Type().AssignableTo(field.Type()) {
+		if !v.Type().ConvertibleTo(field.Type()) {
+			err = fmt.Errorf("cannot convert to %v", field.Type())
+		} else {
+			v = v.Convert(field.Type())
+		}
+	}
+	if err != nil {
+		i.SetErrorString(fmt.Sprintf("argument 1: %v", err))
+		return ResultError
+	}
+	field.Set(v)
+	return i.convertResult(field)
+}
+
+// callForeignFunc calls the function of method with automatic argument
+// conversion. fixed holds the leading arguments that are not converted,
+// such as the receiver.
+func (i *Interp) callForeignFunc(method *foreignMethod, fixed []reflect.Value, args []FeatherObj) FeatherResult {
+	methodType := method.fn.Type()
+	numParams := methodType.NumIn()
 
-	// Check argument count (excluding receiver)
-	expectedArgs := numParams - 1
-	if len(args) != expectedArgs {
+	// Check argument count (excluding the fixed ones)
+	expectedArgs := numParams - len(fixed)
+	if method.variadic {
+		expectedArgs--
+		if len(args) < expectedArgs {
+			i.SetErrorString(fmt.Sprintf("wrong # args: expected at least %d, got %d", expectedArgs, len(args)))
+			return ResultError
+		}
+	} else if len(args) != expectedArgs {
 		i.SetErrorString(fmt.Sprintf("wrong # args: expected %d, got %d", expectedArgs, len(args)))
 		return ResultError
 	}
 
 	// Build argument list
-	callArgs := make([]reflect.Value, numParams)
-	callArgs[0] = reflect.ValueOf(receiver)
+	callArgs := make([]reflect.Value, 0, numParams)
+	callArgs = append(callArgs, fixed...)
 
 	// Convert each argument
-	for j := 0; j < len(args); j++ {
-		paramType := methodType.In(j + 1)
+	for j := 0; j < expectedArgs; j++ {
+		paramType := methodType.In(len(fixed) + j)
 		converted, err := i.convertArg(args[j], paramType)
 		if err != nil {
 			i.SetErrorString(fmt.Sprintf("argument %d: %v", j+1, err))
 			return ResultError
 		}
-		callArgs[j+1] = converted
+		callArgs = append(callArgs, converted)
+	}
+
+	// Collect the remaining arguments into the last parameter
+	if method.variadic {
+		sliceType := methodType.In(numParams - 1)
+		rest := reflect.MakeSlice(sliceType, 0, len(args)-expectedArgs)
+		for j := expectedArgs; j < len(args); j++ {
+			converted, err := i.convertArg(args[j], sliceType.Elem())
+			if err != nil {
+				i.SetErrorString(fmt.Sprintf("argument %d: %v", j+1, err))
+				return ResultError
+			}
+			rest = reflect.Append(rest, converted)
+		}
+		callArgs = append(callArgs, rest)
 	}
 
 	// Call the method
-	results := methodFunc.Call(callArgs)
+	var results []reflect.Value
+	if methodType.IsVariadic() {
+		results = method.fn.CallSlice(callArgs)
+	} else {
+		results = method.fn.Call(callArgs)
+	}
 
 	// Process results
 	return i.processResults(results, methodType)
@@ -432,19 +734,33 @@ func (i *Interp) convertResult(result reflect.Value) FeatherResult {
 
 // foreignDestroy handles the "destroy" method on foreign objects.
 func (i *Interp) foreignDestroy(handleName string) FeatherResult {
-	i.ForeignRegistry.mu.Lock()
+	i.ForeignRegistry.mu.RLock()
 	instance, ok := i.ForeignRegistry.instances[handleName]
+	i.ForeignRegistry.mu.RUnlock()
 	if !ok {
-		i.ForeignRegistry.mu.Unlock()
 		i.SetErrorString(fmt.Sprintf("invalid object handle \"%s\"", handleName))
 		return ResultError
 	}
+	i.destroyForeign(instance)
+	i.SetResultString("")
+	return ResultOK
+}
+
+// destroyForeign destroys a foreign object: it removes the instance from
+// the registry, calls the destructor of its type and deletes its command.
+// Instances that were already destroyed are ignored.
+func (i *Interp) destroyForeign(instance *foreignInstance) {
+	i.ForeignRegistry.mu.Lock()
+	if i.ForeignRegistry.instances[instance.handleName] != instance {
+		i.ForeignRegistry.mu.Unlock()
+		return
+	}
 
 	// Get type info for destructor
 	info := i.ForeignRegistry.types[instance.typeName]
 
 	// Remove from registry
-	delete(i.ForeignRegistry.instances, handleName)
+	delete(i.ForeignRegistry.instances, instance.handleName)
 	delete(i.ForeignRegistry.handleToType, instance.objHandle)
 	i.ForeignRegistry.mu.Unlock()
 
@@ -454,16 +770,114 @@ func (i *Interp) foreignDestroy(handleName string) FeatherResult {
 	}
 
 	// Clear the foreign object
-	if obj := i.getObject(instance.objHandle); obj != nil {
+	if obj := instance.obj.Value(); obj != nil {
 		obj.intrep = nil // Clear the foreign type
 	}
+	i.releaseObjPermanent(instance.objHandle)
 
 	// Remove the command
-	delete(i.Commands, handleName)
-	delete(i.globalNamespace.commands, handleName)
+	delete(i.Commands, instance.handleName)
+	delete(i.globalNamespace.commands, instance.handleName)
+	i.log(slog.LevelDebug, "foreign object destroyed", "type", instance.typeName, "name", instance.handleName)
+}
 
-	i.SetResultString("")
-	return ResultOK
+// reclaimForeign destroys the objects of types with AutoDestroy that the
+// garbage collector found unreachable.
+func (i *Interp) reclaimForeign() {
+	reg := i.ForeignRegistry
+	if reg == nil {
+		return
+	}
+	reg.mu.Lock()
+	unreachable := reg.unreachable
+	reg.unreachable = nil
+	reg.mu.Unlock()
+	for _, instance := range unreachable {
+		i.destroyForeign(instance)
+	}
+}
+
+// destroyAllForeign destroys every foreign object, as their destroy method
+// does, in the order of their names.
+func (i *Interp) destroyAllForeign() {
+	reg := i.ForeignRegistry
+	if reg == nil {
+		return
+	}
+	reg.mu.Lock()
+	names := slices.Sorted(maps.Keys(reg.instances))
+	instances := make([]*foreignInstance, len(names))
+	for n, name := range names {
+		instances[n] = reg.instances[name]
+	}
+	reg.mu.Unlock()
+	for _, instance := range instances {
+		i.destroyForeign(instance)
+	}
+}
+
+// WithHandleScope calls fn and then destroys the foreign objects created
+// while it ran that are still alive, as their destroy method does, most
+// recent first. Scopes can be nested; an object belongs to the innermost
+// scope open when it is created.
+//
+// Use a scope around work that creates objects for a single task, such as a
+// request in a server loop, so that they do not outlive it:
+//
+//	interp.WithHandleScope(func() {
+//	    interp.Eval("handle [Request new]")
+//	})
+func (i *Interp) WithHandleScope(fn func()) {
+	if i.ForeignRegistry == nil {
+		i.ForeignRegistry = newForeignRegistry()
+	}
+	reg := i.ForeignRegistry
+	reg.mu.Lock()
+	reg.scopes = append(reg.scopes, nil)
+	depth := len(reg.scopes)
+	reg.mu.Unlock()
+
+	defer func() {
+		reg.mu.Lock()
+		created := reg.scopes[depth-1]
+		reg.scopes = reg.scopes[:depth-1]
+		reg.mu.Unlock()
+		for _, instance := range slices.Backward(created) {
+			i.destroyForeign(instance)
+		}
+	}()
+	fn()
+}
+
+// ForeignInstance describes a live foreign object.
+type ForeignInstance struct {
+	// Name is the object's handle, which is also the name of its command.
+	Name string
+
+	// Type is the name of the object's foreign type.
+	Type string
+
+	// Value is the Go value of the object, or nil for objects created
+	// through the C API.
+	Value any
+}
+
+// ForeignInstances returns the foreign objects that have not been
+// destroyed, sorted by name, such as to find objects a script leaks.
+func (i *Interp) ForeignInstances() []ForeignInstance {
+	if i.ForeignRegistry == nil {
+		return nil
+	}
+	i.ForeignRegistry.mu.RLock()
+	defer i.ForeignRegistry.mu.RUnlock()
+	instances := make([]ForeignInstance, 0, len(i.ForeignRegistry.instances))
+	for _, instance := range i.ForeignRegistry.instances {
+		instances = append(instances, ForeignInstance{Name: instance.handleName, Type: instance.typeName, Value: instance.value})
+	}
+	slices.SortFunc(instances, func(a, b ForeignInstance) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return instances
 }
 
 // GetForeignMethods returns the method names for a foreign type.
@@ -479,16 +893,11 @@ func (i *Interp) GetForeignMethods(typeName string) []string {
 	if !ok {
 		return nil
 	}
-
-	methods := make([]string, 0, len(info.methods)+1)
-	for name := range info.methods {
-		methods = append(methods, name)
-	}
-	methods = append(methods, "destroy")
-	return methods
+	return info.methodNames()
 }
 
-// GetForeignStringRep returns a custom string representation for a foreign object.
+// GetForeignStringRep returns the string representation of a foreign object:
+// its handle name, which is set by the type's String function if it has one.
 // Used by the goForeignStringRep callback.
 func (i *Interp) GetForeignStringRep(obj FeatherObj) string {
 	if i.ForeignRegistry == nil {
@@ -501,17 +910,6 @@ func (i *Interp) GetForeignStringRep(obj FeatherObj) string {
 		return ""
 	}
 
-	i.ForeignRegistry.mu.RLock()
-	info := i.ForeignRegistry.types[instance.typeName]
-	i.ForeignRegistry.mu.RUnlock()
-
-	if info != nil && info.stringRep.IsValid() {
-		results := info.stringRep.Call([]reflect.Value{reflect.ValueOf(instance.value)})
-		if len(results) > 0 {
-			return results[0].String()
-		}
-	}
-
 	return instance.handleName
 }
 
@@ -537,8 +935,7 @@ func (i *Interp) listAppendObj(list FeatherObj, item FeatherObj) FeatherObj {
 	if err != nil {
 		return list
 	}
-	obj.intrep = ListType(append(listItems, itemObj))
-	obj.invalidate()
+	obj.appendList(listItems, itemObj)
 	return list
 }
 
@@ -572,6 +969,7 @@ func (i *Interp) dictSetObj(dict FeatherObj, key string, val FeatherObj) Feather
 	if err != nil {
 		return dict
 	}
+	d.unshare()
 	if _, exists := d.Items[key]; !exists {
 		d.Order = append(d.Order, key)
 	}
@@ -625,10 +1023,10 @@ func (i *Interp) RegisterCForeignType(typeName string, methods []string) {
 	// Create a foreignTypeInfo with dummy method values
 	info := &foreignTypeInfo{
 		name:    typeName,
-		methods: make(map[string]reflect.Value),
+		methods: make(map[string]*foreignMethod),
 	}
 	for _, m := range methods {
-		info.methods[m] = reflect.Value{} // dummy value, only key matters for info methods
+		info.methods[m] = &foreignMethod{} // dummy value, only key matters for info methods
 	}
 
 	i.ForeignRegistry.mu.Lock()
@@ -655,4 +1053,3 @@ func (i *Interp) RegisterCForeignInstance(handleName, typeName string, objHandle
 	i.ForeignRegistry.handleToType[objHandle] = instance
 	i.ForeignRegistry.mu.Unlock()
 }
-
@@ -0,0 +1,374 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// The glob command returns the names of the files that match patterns:
+//
+//	glob ?-directory dir? ?-nocomplain? ?-tails? ?-types typeList? ?--? ?pattern ...?
+//
+// Each element of a pattern is matched as by string match against the
+// names in a directory, and {a,b} matches either alternative. Names that
+// start with a dot are only matched by elements that do too, and a pattern
+// ending in a slash matches only directories. It is an error if nothing
+// matches, unless -nocomplain is given.
+//
+// -directory matches the patterns in dir, and -tails leaves dir off the
+// names returned. -types keeps the files of the given types (b, c, d, f, l,
+// p, s) that have all the given permissions (r, w, x) or are hidden.
+//
+// glob reads directories with the interpreter's [FilesystemDriver], as the
+// file command does.
+
+// globOptions are the options of glob, for GetIndexFromObj.
+var globOptions = []string{"-directory", "-nocomplain", "-tails", "-types", "--"}
+
+// globTypeLetters are the file types of glob -types, by the type file stat
+// reports.
+var globTypeLetters = map[string]string{
+	"blockSpecial":     "b",
+	"characterSpecial": "c",
+	"directory":        "d",
+	"file":             "f",
+	"link":             "l",
+	"fifo":             "p",
+	"socket":           "s",
+}
+
+// globPerms are the permissions of glob -types, by the mode bits that give
+// them to someone.
+var globPerms = map[string]fs.FileMode{"r": 0o444, "w": 0o222, "x": 0o111}
+
+// registerGlob installs the glob command.
+func (i *Interp) registerGlob() {
+	i.RegisterCommand("glob", cmdGlob)
+}
+
+// cmdGlob implements: glob ?-option ...? ?pattern ...?
+func cmdGlob(i *Interp, cmd *Obj, args []*Obj) Result {
+	var dir string
+	var keep func(name string, info fs.FileInfo) bool
+	hasDir, nocomplain, tails := false, false, false
+	for len(args) > 0 && strings.HasPrefix(args[0].String(), "-") {
+		opt, err := i.GetIndexFromObj(args[0], globOptions, "option")
+		if err != nil {
+			return Throw([]string{"TCL", "LOOKUP", "INDEX", "option", args[0].String()}, err.Error())
+		}
+		name := globOptions[opt]
+		args = args[1:]
+		if name == "--" {
+			break
+		}
+		switch name {
+		case "-nocomplain":
+			nocomplain = true
+			continue
+		case "-tails":
+			tails = true
+			continue
+		}
+		if len(args) == 0 {
+			return Throw([]string{"TCL", "ARGUMENT", "MISSING"}, fmt.Sprintf(`missing argument to "%s"`, name))
+		}
+		if name == "-directory" {
+			dir, hasDir = args[0].String(), true
+		} else {
+			types, err := args[0].List()
+			if err != nil {
+				return Error(err.Error())
+			}
+			if keep, err = globKeep(types); err != nil {
+				return Throw([]string{"TCL", "ARGUMENT", "BAD"}, err.Error())
+			}
+		}
+		args = args[1:]
+	}
+	if tails && !hasDir {
+		return Error(`"-tails" must be used with "-directory"`)
+	}
+
+	fsys := i.fs()
+	matches := []string{}
+	patterns := make([]string, len(args))
+	for k, arg := range args {
+		patterns[k] = arg.String()
+		found, res := globFiles(fsys, dir, patterns[k], keep)
+		if res != nil {
+			return *res
+		}
+		matches = append(matches, found...)
+	}
+	if len(matches) == 0 && !nocomplain {
+		plural := "s"
+		if len(patterns) == 1 {
+			plural = ""
+		}
+		return Throw([]string{"TCL", "OPERATION", "GLOB", "NOMATCH"},
+			fmt.Sprintf(`no files matched glob pattern%s "%s"`, plural, strings.Join(patterns, " ")))
+	}
+	if tails {
+		prefix := joinFileNames(dir, "x")
+		prefix = prefix[:len(prefix)-1]
+		for k, m := range matches {
+			matches[k] = strings.TrimPrefix(m, prefix)
+		}
+	}
+	return OK(matches)
+}
+
+// globKeep returns the filter for the list of glob -types, or nil for an
+// empty list.
+func globKeep(types []*Obj) (func(name string, info fs.FileInfo) bool, error) {
+	var kinds []string
+	var perms fs.FileMode
+	hidden := false
+	for _, t := range types {
+		switch s := t.String(); {
+		case slices.Contains([]string{"b", "c", "d", "f", "l", "p", "s"}, s):
+			kinds = append(kinds, s)
+		case globPerms[s] != 0:
+			perms |= globPerms[s]
+		case s == "hidden":
+			hidden = true
+		default:
+			return nil, fmt.Errorf(`bad argument to "-types": %s`, s)
+		}
+	}
+	if len(kinds) == 0 && perms == 0 && !hidden {
+		return nil, nil
+	}
+	return func(name string, info fs.FileInfo) bool {
+		if len(kinds) > 0 && !slices.Contains(kinds, globTypeLetters[fileType(info.Mode())]) {
+			return false
+		}
+		for _, bits := range globPerms {
+			if perms&bits != 0 && info.Mode().Perm()&bits == 0 {
+				return false
+			}
+		}
+		return !hidden || strings.HasPrefix(fileTail(name), ".")
+	}, nil
+}
+
+// globber matches the elements of a glob pattern against a file system.
+type globber struct {
+	fsys    FilesystemDriver
+	keep    func(name string, info fs.FileInfo) bool // nil keeps every file
+	dirOnly bool                                     // the pattern ends in a slash
+	matches []string
+}
+
+// globFiles returns the names of the files under dir that match pattern,
+// and that keep keeps unless it is nil. An absolute pattern ignores dir.
+func globFiles(fsys FilesystemDriver, dir, pattern string, keep func(name string, info fs.FileInfo) bool) ([]string, *Result) {
+	alternatives, err := expandBraces(pattern)
+	if err != nil {
+		r := Throw([]string{"TCL", "OPERATION", "GLOB", "BALANCE"}, err.Error())
+		return nil, &r
+	}
+	g := &globber{fsys: fsys, keep: keep}
+	for _, alt := range alternatives {
+		start := dir
+		if strings.HasPrefix(alt, "/") {
+			start = "/"
+		}
+		g.dirOnly = strings.HasSuffix(alt, "/") && strings.Trim(alt, "/") != ""
+		var elems []string
+		for _, elem := range strings.Split(alt, "/") {
+			if elem != "" {
+				elems = append(elems, elem)
+			}
+		}
+		if err := g.walk(start, elems, nil); err != nil {
+			r := Throw(posixErrorCode(err), fmt.Sprintf(`couldn't read directory "%s": %s`, pattern, posixMessage(err)))
+			return nil, &r
+		}
+	}
+	return g.matches, nil
+}
+
+// walk adds the files under dir that match elems to the matches. info is
+// the information of dir if the caller has it.
+func (g *globber) walk(dir string, elems []string, info fs.FileInfo) error {
+	if len(elems) == 0 {
+		if g.keep != nil || g.dirOnly {
+			if info == nil {
+				var err error
+				if info, err = g.fsys.Stat(dir); err != nil {
+					return nil
+				}
+			}
+			if g.dirOnly && !info.IsDir() || g.keep != nil && !g.keep(dir, info) {
+				return nil
+			}
+		}
+		if g.dirOnly {
+			dir += "/"
+		}
+		g.matches = append(g.matches, dir)
+		return nil
+	}
+
+	elem, rest := elems[0], elems[1:]
+	if !strings.ContainsAny(elem, `*?[\`) {
+		name := joinFileNames(dir, elem)
+		info, err := g.fsys.Stat(name)
+		if err != nil {
+			return nil
+		}
+		return g.walk(name, rest, info)
+	}
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := g.fsys.ReadDir(readDir)
+	if err != nil {
+		if dir == "" || errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(elem, ".") {
+			continue
+		}
+		if !globMatchName(elem, e.Name()) || len(rest) > 0 && !e.IsDir() {
+			continue
+		}
+		var info fs.FileInfo
+		if len(rest) == 0 {
+			// The entry's own information, so that -types l sees links
+			if info, err = e.Info(); err != nil {
+				continue
+			}
+		}
+		if err := g.walk(joinFileNames(dir, e.Name()), rest, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandBraces returns the patterns that pattern stands for once each
+// {a,b,...} in it is replaced by each of its alternatives in turn.
+func expandBraces(pattern string) ([]string, error) {
+	open := -1
+	for k := 0; k < len(pattern); k++ {
+		switch pattern[k] {
+		case '\\':
+			k++
+			continue
+		case '}':
+			return nil, errors.New("unmatched close-brace in file name")
+		}
+		if pattern[k] == '{' {
+			open = k
+			break
+		}
+	}
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+
+	var alternatives []string
+	depth, start := 0, open+1
+	for k := open + 1; k < len(pattern); k++ {
+		switch pattern[k] {
+		case '\\':
+			k++
+		case '{':
+			depth++
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, pattern[start:k])
+				start = k + 1
+			}
+		case '}':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			alternatives = append(alternatives, pattern[start:k])
+			var expanded []string
+			for _, alt := range alternatives {
+				more, err := expandBraces(pattern[:open] + alt + pattern[k+1:])
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, more...)
+			}
+			return expanded, nil
+		}
+	}
+	return nil, errors.New("unmatched open-brace in file name")
+}
+
+// globMatchName reports whether name matches pattern as string match
+// would: * matches any run of characters, ? any one character, [chars] one
+// of chars or of a range like a-z, and \x the character x.
+func globMatchName(pattern, name string) bool {
+	p, s := []rune(pattern), []rune(name)
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for len(p) > 0 && p[0] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				return true
+			}
+			for k := 0; k <= len(s); k++ {
+				if globMatchName(string(p), string(s[k:])) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := 1
+			matched := false
+			for end < len(p) && p[end] != ']' {
+				lo, hi := p[end], p[end]
+				if end+2 < len(p) && p[end+1] == '-' && p[end+2] != ']' {
+					hi = p[end+2]
+					end += 2
+				}
+				if lo > hi {
+					lo, hi = hi, lo
+				}
+				if s[0] >= lo && s[0] <= hi {
+					matched = true
+				}
+				end++
+			}
+			if !matched {
+				return false
+			}
+			p = p[min(end, len(p)-1):]
+		case '\\':
+			if len(p) > 1 {
+				p = p[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+		}
+		p, s = p[1:], s[1:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,362 @@
+package feather
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/src
+#include "feather.h"
+#include "host.h"
+
+// Helper to call a builtin through its function pointer
+static inline FeatherResult call_builtin(FeatherBuiltinCmd fn, FeatherInterp interp, FeatherObj name, FeatherObj args) {
+    return fn(feather_get_ops(NULL), interp, name, args);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Hide hides the global command name from scripts, as interp hide does:
+// calling it fails as if it did not exist and info commands no longer
+// lists it, but Go can still run it with [Interp.InvokeHidden].
+// [Interp.Expose] makes it visible again. Only commands in the global
+// namespace can be hidden.
+//
+//	interp.Hide("exec")
+//	interp.Eval("exec rm -rf /")       // invalid command name "exec"
+//	interp.InvokeHidden("exec", "ls")  // runs
+func (i *Interp) Hide(name string) error {
+	return i.hide(name, strings.TrimPrefix(name, "::"))
+}
+
+// hide hides the global command name under hiddenName.
+func (i *Interp) hide(name, hiddenName string) error {
+	simple := strings.TrimPrefix(name, "::")
+	if strings.Contains(simple, "::") {
+		return fmt.Errorf("can only hide global namespace commands (use rename then hide)")
+	}
+	if strings.Contains(hiddenName, "::") {
+		return fmt.Errorf("cannot use namespace qualifiers in hidden command token (rename)")
+	}
+	cmd, ok := i.globalNamespace.commands[simple]
+	if !ok {
+		return fmt.Errorf("unknown command \"%s\"", name)
+	}
+	if _, ok := i.hidden[hiddenName]; ok {
+		return fmt.Errorf("hidden command named \"%s\" already exists", hiddenName)
+	}
+	delete(i.globalNamespace.commands, simple)
+	delete(i.Commands, simple)
+	if i.hidden == nil {
+		i.hidden = make(map[string]*Command)
+	}
+	i.hidden[hiddenName] = cmd
+	i.recordCommand(i.globalNamespace, simple)
+	return nil
+}
+
+// Expose makes the hidden command name visible to scripts again, in the
+// global namespace. It fails if a command of that name exists.
+func (i *Interp) Expose(name string) error {
+	return i.expose(name, name)
+}
+
+// expose makes the hidden command name visible as the global command
+// exposedName.
+func (i *Interp) expose(name, exposedName string) error {
+	cmd, ok := i.hidden[name]
+	if !ok {
+		return fmt.Errorf("unknown hidden command \"%s\"", name)
+	}
+	if strings.Contains(exposedName, "::") {
+		return fmt.Errorf("cannot expose to a namespace (use expose to toplevel, then rename)")
+	}
+	if _, ok := i.globalNamespace.commands[exposedName]; ok {
+		return fmt.Errorf("exposed command \"%s\" already exists", exposedName)
+	}
+	delete(i.hidden, name)
+	i.globalNamespace.commands[exposedName] = cmd
+	if cmd.fn != nil {
+		i.Commands[exposedName] = cmd.fn
+	}
+	i.recordCommand(i.globalNamespace, exposedName)
+	return nil
+}
+
+// HiddenCommands returns the names of the hidden commands, sorted.
+func (i *Interp) HiddenCommands() []string {
+	names := make([]string, 0, len(i.hidden))
+	for name := range i.hidden {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// InvokeHidden runs the hidden command name with args, converted as for
+// [Interp.Call], as interp invokehidden does. The command runs in the
+// global namespace; commands it evaluates still cannot call hidden
+// commands.
+func (i *Interp) InvokeHidden(name string, args ...any) (*Obj, error) {
+	cmd, ok := i.hidden[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid hidden command name \"%s\"", name)
+	}
+	if cmd.cmdType == CmdProc {
+		lambda := i.List(cmd.proc.params, cmd.proc.body, i.String("::"))
+		return i.Call("apply", append([]any{lambda}, args...)...)
+	}
+
+	i.evalDepth++
+	defer func() {
+		i.evalDepth--
+		if i.evalDepth == 0 && i.currentCoroutine == nil {
+			i.resetScratch()
+		}
+	}()
+	nameHandle := i.internStringScratch(name)
+	argv := make([]*Obj, len(args))
+	for n, arg := range args {
+		argv[n] = i.Value(arg)
+	}
+	var code FeatherResult
+	if cmd.fn != nil {
+		handles := make([]FeatherObj, len(argv))
+		for n, arg := range argv {
+			handles[n] = i.registerObjScratch(arg)
+		}
+		code = cmd.fn(i, nameHandle, handles)
+	} else {
+		list := i.registerObjScratch(i.List(argv...))
+		code = FeatherResult(C.call_builtin(cmd.builtin, C.FeatherInterp(i.handle), C.FeatherObj(nameHandle), C.FeatherObj(list)))
+	}
+	if code == ResultError {
+		return nil, &EvalError{Message: i.resultString()}
+	}
+	return i.result, nil
+}
+
+// Alias creates the command newName, which runs targetName with prefixArgs
+// inserted before its own arguments, as interp alias does within one
+// interpreter:
+//
+//	interp.Alias("log", "puts", interp.String("stderr"))
+//	interp.Eval("log {disk full}") // puts stderr {disk full}
+//
+// newName is qualified relative to the current namespace, like a proc.
+// targetName is resolved from the global namespace each time the alias is
+// called, so the alias follows the target when it is redefined.
+func (i *Interp) Alias(newName, targetName string, prefixArgs ...*Obj) {
+	target := targetName
+	if !strings.HasPrefix(target, "::") {
+		target = "::" + target
+	}
+	prefix := slices.Clone(prefixArgs)
+	aliasCmd := append([]*Obj{i.String(targetName)}, prefix...)
+	ns, simple, _ := i.qualifyCommand(newName)
+	i.setCommand(ns, simple, &Command{cmdType: CmdBuiltin, alias: target, aliasCmd: aliasCmd, fn: func(ii *Interp, cmd FeatherObj, args []FeatherObj) FeatherResult {
+		words := make([]*Obj, 0, 1+len(prefix)+len(args))
+		words = append(words, ii.String(target))
+		words = append(words, prefix...)
+		for _, h := range args {
+			words = append(words, ii.objForHandle(h))
+		}
+		list := ii.registerObjScratch(ii.List(words...))
+		code := FeatherResult(C.feather_command_exec(nil, C.FeatherInterp(ii.handle), C.FeatherObj(list), C.TCL_EVAL_LOCAL))
+		// A missing target is reported by the name the alias was given
+		if code == ResultError && ii.resultString() == `invalid command name "`+target+`"` {
+			ii.SetErrorString(`invalid command name "` + targetName + `"`)
+		}
+		return code
+	}})
+}
+
+// registerInterp installs the interp command.
+func (i *Interp) registerInterp() {
+	i.RegisterCommand("interp", cmdInterp)
+}
+
+// cmdInterp implements the interp subcommands that work within one
+// interpreter, whose path is the empty list: alias, aliases, exists,
+// expose, hide, hidden and invokehidden.
+func cmdInterp(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "interp cmd ?arg ...?"`)
+	}
+	sub := args[0].String()
+	rest := args[1:]
+	switch sub {
+	case "alias":
+		if len(rest) < 2 || len(rest) == 3 && rest[2].String() != "" {
+			return Error(`wrong # args: should be "interp alias slavePath slaveCmd ?masterPath masterCmd? ?arg ...?"`)
+		}
+		if err := checkInterpPath(rest[0]); err != nil {
+			return Error(err.Error())
+		}
+		if len(rest) > 3 {
+			if err := checkInterpPath(rest[2]); err != nil {
+				return Error(err.Error())
+			}
+			i.Alias(globalCommandName(rest[1].String()), rest[3].String(), rest[4:]...)
+			return OK(rest[1])
+		}
+		name, target := i.resolveCommandName(globalCommandName(rest[1].String()))
+		if len(rest) == 2 {
+			if target == nil || target.alias == "" {
+				return OK("")
+			}
+			return OK(i.List(target.aliasCmd...))
+		}
+		if target == nil || target.alias == "" {
+			return Errorf("alias \"%s\" not found", rest[1].String())
+		}
+		if err := i.Unregister(name); err != nil {
+			return Error(err.Error())
+		}
+		return OK("")
+	case "aliases":
+		if len(rest) > 1 {
+			return Error(`wrong # args: should be "interp aliases ?path?"`)
+		}
+		if len(rest) == 1 {
+			if err := checkInterpPath(rest[0]); err != nil {
+				return Error(err.Error())
+			}
+		}
+		var names []string
+		for path, ns := range i.namespaces {
+			for name, c := range ns.commands {
+				if c.alias == "" {
+					continue
+				}
+				if path != "::" {
+					name = strings.TrimPrefix(path, "::") + "::" + name
+				}
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return OK(names)
+	case "exists":
+		if len(rest) > 1 {
+			return Error(`wrong # args: should be "interp exists ?path?"`)
+		}
+		return OK(len(rest) == 0 || checkInterpPath(rest[0]) == nil)
+	case "expose":
+		if len(rest) < 2 || len(rest) > 3 {
+			return Error(`wrong # args: should be "interp expose path hiddenCmdName ?cmdName?"`)
+		}
+		if err := checkInterpPath(rest[0]); err != nil {
+			return Error(err.Error())
+		}
+		exposedName := rest[1].String()
+		if len(rest) == 3 {
+			exposedName = rest[2].String()
+		}
+		if err := i.expose(rest[1].String(), exposedName); err != nil {
+			return Error(err.Error())
+		}
+		return OK("")
+	case "hide":
+		if len(rest) < 2 || len(rest) > 3 {
+			return Error(`wrong # args: should be "interp hide path cmdName ?hiddenCmdName?"`)
+		}
+		if err := checkInterpPath(rest[0]); err != nil {
+			return Error(err.Error())
+		}
+		hiddenName := strings.TrimPrefix(rest[1].String(), "::")
+		if len(rest) == 3 {
+			hiddenName = rest[2].String()
+		}
+		if err := i.hide(rest[1].String(), hiddenName); err != nil {
+			return Error(err.Error())
+		}
+		return OK("")
+	case "hidden":
+		if len(rest) > 1 {
+			return Error(`wrong # args: should be "interp hidden ?path?"`)
+		}
+		if len(rest) == 1 {
+			if err := checkInterpPath(rest[0]); err != nil {
+				return Error(err.Error())
+			}
+		}
+		return OK(i.HiddenCommands())
+	case "invokehidden":
+		const usage = `wrong # args: should be "interp invokehidden path ?-namespace ns? ?-global? ?--? cmd ?arg ..?"`
+		if len(rest) < 2 {
+			return Error(usage)
+		}
+		if err := checkInterpPath(rest[0]); err != nil {
+			return Error(err.Error())
+		}
+		// The last of -global and -namespace decides where the command runs
+		words := rest[1:]
+		global, nsName := false, ""
+		for len(words) > 0 && strings.HasPrefix(words[0].String(), "-") {
+			opt := words[0].String()
+			words = words[1:]
+			if opt == "--" {
+				break
+			}
+			switch opt {
+			case "-global":
+				global, nsName = true, ""
+			case "-namespace":
+				if len(words) == 0 {
+					return Error(usage)
+				}
+				global, nsName = false, words[0].String()
+				words = words[1:]
+			default:
+				return Errorf("bad option \"%s\": must be -global, -namespace, or --", opt)
+			}
+		}
+		if len(words) == 0 {
+			return Error(usage)
+		}
+		callArgs := make([]any, len(words)-1)
+		for n, w := range words[1:] {
+			callArgs[n] = w
+		}
+		active := i.active
+		if global {
+			i.active = 0
+		}
+		frame := i.frames[i.active]
+		ns := frame.ns
+		defer func() { frame.ns, i.active = ns, active }()
+		if nsName != "" {
+			if !strings.HasPrefix(nsName, "::") {
+				nsName = "::" + nsName
+			}
+			frame.ns = i.ensureNamespace(nsName)
+		}
+		result, err := i.InvokeHidden(words[0].String(), callArgs...)
+		if err != nil {
+			return Error(err.Error())
+		}
+		return OK(result)
+	}
+	return Errorf("bad option \"%s\": must be alias, aliases, exists, expose, hide, hidden, or invokehidden", sub)
+}
+
+// checkInterpPath fails unless path names the current interpreter, the
+// only one there is.
+func checkInterpPath(path *Obj) error {
+	if items, err := path.List(); err == nil && len(items) == 0 {
+		return nil
+	}
+	return fmt.Errorf("could not find interpreter \"%s\"", path.String())
+}
+
+// globalCommandName qualifies name relative to the global namespace, where
+// interp resolves the commands it is given.
+func globalCommandName(name string) string {
+	if strings.HasPrefix(name, "::") {
+		return name
+	}
+	return "::" + name
+}
@@ -0,0 +1,117 @@
+package feather
+
+import "slices"
+
+// EvalEvent describes a command about to be executed, as reported to the
+// hook installed with [Interp.SetEvalHook].
+//
+// Line counts from the start of File, or from the start of the script passed
+// to Eval when there is no file. Proc bodies, loop bodies and command
+// substitutions written as literals in that script report the lines they
+// occupy in it. Scripts built at run time and passed to eval count their own
+// lines from 1.
+type EvalEvent struct {
+	Name  string // the command name as written, after substitution
+	Args  []*Obj // the arguments, after substitution
+	Level int    // the call frame level, as reported by info level
+	File  string // the file the command is in, if any
+	Line  int    // the line where the command starts
+}
+
+// SetEvalHook installs fn to be called before every command the evaluator
+// runs from a script, including commands in proc bodies and command
+// substitutions. It is meant for profilers, step debuggers and coverage
+// tools. Passing nil removes the hook.
+//
+// The hook runs synchronously on the goroutine evaluating the script, so a
+// debugger can pause execution by blocking in it. Commands the hook itself
+// evaluates, for example to inspect variables, are not reported. When no
+// hook is installed, the evaluator does not call into Go for it at all.
+//
+//	interp.SetEvalHook(func(e feather.EvalEvent) {
+//	    counts[e.Name]++
+//	})
+func (i *Interp) SetEvalHook(fn func(EvalEvent)) {
+	i.evalHook = fn
+	i.updateEvalHooks()
+}
+
+// updateEvalHooks tells the C core whether to report commands, after the
+// eval hook, the debugger or the profiler changed.
+func (i *Interp) updateEvalHooks() {
+	if i.closed {
+		return
+	}
+	want := i.evalHook != nil || (i.debug != nil && i.debug.pause != nil) || i.profiling() || i.covering()
+	switch {
+	case want && !i.evalHooksOn:
+		callCEvalHooksEnable(i.handle, 1)
+	case !want && i.evalHooksOn:
+		callCEvalHooksEnable(i.handle, -1)
+	}
+	i.evalHooksOn = want
+}
+
+// reportEval passes a command from the evaluation loop to the eval hook,
+// the debugger, the profiler and coverage.
+func (i *Interp) reportEval(command FeatherObj, line int) {
+	if !i.evalHooksOn || i.inEvalHook {
+		return
+	}
+	if i.covering() {
+		file := ""
+		if f := i.frames[i.active].file; f != nil {
+			file = f.String()
+		}
+		i.coverage.hit(file, line)
+	}
+	if i.evalHook == nil && (i.debug == nil || i.debug.pause == nil) && !i.profiling() {
+		return
+	}
+	items, err := i.listItems(command)
+	if err != nil || len(items) == 0 {
+		return
+	}
+	if i.evalHook == nil && (i.debug == nil || i.debug.pause == nil) {
+		// Only the profiler is listening; it needs just the name
+		if i.profiling() {
+			i.profile.enter(i, items[0].String())
+		}
+		return
+	}
+	event := EvalEvent{
+		Name:  items[0].String(),
+		Args:  slices.Clone(items[1:]),
+		Level: i.active,
+		Line:  line,
+	}
+	if f := i.frames[i.active].file; f != nil {
+		event.File = f.String()
+	}
+
+	// Scripts evaluated by the hooks must not disturb the command about to run
+	result, options := i.result, i.returnOptions
+	i.inEvalHook = true
+	defer func() {
+		i.inEvalHook = false
+		i.result, i.returnOptions = result, options
+	}()
+	if i.evalHook != nil {
+		i.evalHook(event)
+	}
+	if i.debug != nil {
+		i.debugEval(event)
+	}
+	if i.profiling() {
+		i.profile.enter(i, event.Name)
+	}
+}
+
+// reportEvalDone tells the profiler that a command reported by reportEval
+// finished.
+func (i *Interp) reportEvalDone() {
+	if i.inEvalHook || !i.profiling() {
+		return
+	}
+	i.profile.done()
+}
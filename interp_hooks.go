@@ -0,0 +1,257 @@
+package feather
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// HookErrors is what [Hooks.Run] does when a handler fails.
+type HookErrors int
+
+const (
+	StopOnError   HookErrors = iota // return the first error, skipping the handlers after it
+	CollectErrors                   // run every handler and return all of their errors
+)
+
+// HookSpec describes a hook defined with [Hooks.Define].
+type HookSpec struct {
+	Params []string   // the names of the arguments handlers are called with
+	Errors HookErrors // what Run does when a handler fails
+}
+
+// Hooks is the registry of the hooks of an interpreter: extension points
+// that Go code defines and runs, and that scripts add handlers to with the
+// hook command. Get it with [Interp.Hooks].
+type Hooks struct {
+	interp *Interp
+	specs  map[string]HookSpec
+	added  map[string][]*hookHandler // handlers, by hook, in the order added
+	nextID int
+}
+
+// hookHandler is a handler added with hook add.
+type hookHandler struct {
+	id       string
+	lambda   *Obj // the handler as an apply lambda
+	priority int64
+}
+
+// Hooks returns the hook registry of the interpreter:
+//
+//	hooks := interp.Hooks()
+//	hooks.Define("on-request", feather.HookSpec{Params: []string{"req"}})
+//
+//	interp.Eval(`hook add on-request {req} { log "got [dict get $req path]" }`)
+//
+//	results, err := hooks.Run("on-request", req)
+//
+// Handlers run in order of their -priority, lowest first, and in the order
+// they were added for equal priorities. [Interp.Reset] removes the handlers
+// scripts added and keeps the hooks.
+func (i *Interp) Hooks() *Hooks {
+	return i.hooks
+}
+
+// registerHooks installs the hook command.
+func (i *Interp) registerHooks() {
+	i.hooks = &Hooks{
+		interp: i,
+		specs:  make(map[string]HookSpec),
+		added:  make(map[string][]*hookHandler),
+	}
+	i.RegisterCommand("hook", cmdHook)
+}
+
+// Define defines the hook name, which handlers are called for with the
+// arguments spec.Params names. Defining a hook again changes its spec and
+// keeps its handlers.
+func (h *Hooks) Define(name string, spec HookSpec) {
+	h.specs[name] = spec
+}
+
+// Run calls the handlers of the hook name with args, converted as for
+// [Interp.Call], and returns the results of those that succeeded, in the
+// order they ran. Errors are [*EvalError] values; with CollectErrors, they
+// are joined with [errors.Join].
+//
+// The handlers run are those the hook has when Run is called, even if a
+// handler adds or removes some.
+func (h *Hooks) Run(name string, args ...any) ([]*Obj, error) {
+	spec, ok := h.specs[name]
+	if !ok {
+		return nil, fmt.Errorf("no such hook \"%s\"", name)
+	}
+	if len(args) != len(spec.Params) {
+		return nil, fmt.Errorf("wrong # args: hook \"%s\" takes %s", name, describeParams(spec.Params))
+	}
+	var results []*Obj
+	var errs []error
+	for _, handler := range h.handlers(name) {
+		result, err := h.interp.Apply(handler.lambda, args...)
+		if err != nil {
+			if spec.Errors == StopOnError {
+				return results, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// Handlers returns the identifiers of the handlers of the hook name, in the
+// order they run.
+func (h *Hooks) Handlers(name string) []string {
+	var ids []string
+	for _, handler := range h.handlers(name) {
+		ids = append(ids, handler.id)
+	}
+	return ids
+}
+
+// Remove removes the handler with the identifier id, as returned by hook
+// add, from whichever hook it was added to.
+func (h *Hooks) Remove(id string) error {
+	for name, handlers := range h.added {
+		n := slices.IndexFunc(handlers, func(handler *hookHandler) bool { return handler.id == id })
+		if n >= 0 {
+			h.added[name] = slices.Delete(handlers, n, n+1)
+			return nil
+		}
+	}
+	return fmt.Errorf("no such hook handler \"%s\"", id)
+}
+
+// handlers returns the handlers of the hook name in the order they run.
+func (h *Hooks) handlers(name string) []*hookHandler {
+	handlers := slices.Clone(h.added[name])
+	slices.SortStableFunc(handlers, func(a, b *hookHandler) int {
+		return cmp.Compare(a.priority, b.priority)
+	})
+	return handlers
+}
+
+// reset removes every handler, for Interp.Reset.
+func (h *Hooks) reset() {
+	clear(h.added)
+	h.nextID = 0
+}
+
+// describeParams describes the arguments of a hook for error messages.
+func describeParams(params []string) string {
+	switch len(params) {
+	case 0:
+		return "no arguments"
+	case 1:
+		return "1 argument: " + params[0]
+	}
+	return fmt.Sprintf("%d arguments: %s", len(params), strings.Join(params, " "))
+}
+
+// cmdHook implements the hook command:
+//
+//	hook add ?-priority priority? hook params body
+//	hook list ?hook?
+//	hook remove id
+//	hook run hook ?arg ...?
+func cmdHook(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "hook subcommand ?arg ...?"`)
+	}
+	sub, err := i.GetIndexFromObj(args[0], []string{"add", "list", "remove", "run"}, "subcommand")
+	if err != nil {
+		return Error(err.Error())
+	}
+	h := i.hooks
+	switch sub {
+	case 0:
+		return hookAdd(i, args[1:])
+	case 1:
+		if len(args) > 2 {
+			return Error(`wrong # args: should be "hook list ?hook?"`)
+		}
+		if len(args) == 1 {
+			return OK(slices.Sorted(maps.Keys(h.specs)))
+		}
+		if _, ok := h.specs[args[1].String()]; !ok {
+			return Errorf("no such hook \"%s\"", args[1].String())
+		}
+		return OK(h.Handlers(args[1].String()))
+	case 2:
+		if len(args) != 2 {
+			return Error(`wrong # args: should be "hook remove id"`)
+		}
+		if err := h.Remove(args[1].String()); err != nil {
+			return Error(err.Error())
+		}
+		return OK("")
+	}
+	if len(args) < 2 {
+		return Error(`wrong # args: should be "hook run hook ?arg ...?"`)
+	}
+	runArgs := make([]any, len(args)-2)
+	for n, arg := range args[2:] {
+		runArgs[n] = arg
+	}
+	results, err := h.Run(args[1].String(), runArgs...)
+	if err != nil {
+		return Error(err.Error())
+	}
+	return OK(results)
+}
+
+// hookAdd implements hook add, returning the identifier of the handler.
+func hookAdd(i *Interp, args []*Obj) Result {
+	a, err := Args(i.String("hook add"), args).
+		Option("-priority", Int, 0).
+		Require("hook", String).
+		Require("params", List).
+		Require("body", Any).
+		Parse()
+	if err != nil {
+		return Error(err.Error())
+	}
+	h := i.hooks
+	name := a.String("hook")
+	spec, ok := h.specs[name]
+	if !ok {
+		return Errorf("no such hook \"%s\"", name)
+	}
+	if !acceptsArgs(a.List("params"), len(spec.Params)) {
+		return Errorf("handler for hook \"%s\" must take %s", name, describeParams(spec.Params))
+	}
+
+	h.nextID++
+	handler := &hookHandler{
+		id:       fmt.Sprintf("hook%d", h.nextID),
+		lambda:   i.List(i.List(a.List("params")...), a.Obj("body"), i.String(i.frames[i.active].ns.fullPath)),
+		priority: a.Int("-priority"),
+	}
+	h.added[name] = append(h.added[name], handler)
+	return OK(handler.id)
+}
+
+// acceptsArgs reports whether a proc with the parameters params can be
+// called with n arguments.
+func acceptsArgs(params []*Obj, n int) bool {
+	required, max := 0, len(params)
+	for k, p := range params {
+		spec, err := p.List()
+		if err != nil || len(spec) == 0 {
+			return false
+		}
+		if k == len(params)-1 && spec[0].String() == "args" {
+			max = -1
+			continue
+		}
+		if len(spec) == 1 {
+			required = k + 1
+		}
+	}
+	return n >= required && (max < 0 || n <= max)
+}
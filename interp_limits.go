@@ -0,0 +1,92 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrValueTooLarge is the error a script stopped by the limits of
+// [Interp.SetValueLimits] fails with, wrapped in an [*EvalError]:
+//
+//	if errors.Is(err, feather.ErrValueTooLarge) {
+//	    log.Print("script built a value that is too large")
+//	}
+var ErrValueTooLarge = errors.New("feather: value size limit exceeded")
+
+// ValueLimits caps the size of the lists and dicts scripts build, so that
+// a hostile script cannot exhaust the host's memory with a gigantic value.
+// A zero field sets no limit.
+type ValueLimits struct {
+	MaxListLength int // most elements a list may have
+	MaxDictSize   int // most keys a dict may have
+}
+
+// SetValueLimits sets the limits on the size of the values scripts build.
+// The zero ValueLimits, the default, sets none.
+//
+//	interp.SetValueLimits(feather.ValueLimits{MaxListLength: 1 << 20, MaxDictSize: 1 << 16})
+//
+// A list or dict that would grow past its limit stays as it is, and the
+// script stops, as it does when it runs past the deadline of
+// [Interp.EvalTimeout]: the command building the value fails when it
+// returns, and so does every command after it, so catch and try cannot
+// keep the script running. The error is an [*EvalError] with a message
+// such as "list size limit exceeded: 1048576 elements" and the error code
+// TCL LIMIT VALUE, and errors.Is reports it as [ErrValueTooLarge]. The
+// interpreter is left ready for the next script.
+//
+// The limits apply to the values the evaluator builds, including lists
+// and dicts converted from strings. Values built from Go, such as with
+// [Interp.List], are not checked.
+func (i *Interp) SetValueLimits(limits ValueLimits) {
+	wasSet, set := i.valueLimits != ValueLimits{}, limits != ValueLimits{}
+	i.valueLimits = limits
+	if wasSet != set && !i.closed {
+		// The evaluator only asks whether a limit was exceeded while the
+		// interpreter has one set
+		if set {
+			callCEvalLimitsEnable(i.handle, 1)
+		} else {
+			callCEvalLimitsEnable(i.handle, -1)
+		}
+	}
+}
+
+// listTooLong returns an error if a list may not have n elements.
+func (i *Interp) listTooLong(n int) error {
+	if limit := i.valueLimits.MaxListLength; limit > 0 && n > limit {
+		return fmt.Errorf("list size limit exceeded: %d elements", limit)
+	}
+	return nil
+}
+
+// dictTooLarge returns an error if a dict may not have n keys.
+func (i *Interp) dictTooLarge(n int) error {
+	if limit := i.valueLimits.MaxDictSize; limit > 0 && n > limit {
+		return fmt.Errorf("dict size limit exceeded: %d keys", limit)
+	}
+	return nil
+}
+
+// refuseGrowth reports whether err, from listTooLong or dictTooLarge, is
+// set, and if so records it to stop the script once the command building
+// the value returns.
+func (i *Interp) refuseGrowth(err error) bool {
+	if err == nil {
+		return false
+	}
+	i.valueTooLarge = err.Error()
+	return true
+}
+
+// valueLimitExceeded reports whether a value ran into a limit during the
+// outermost eval, and if so sets the error that stops the script.
+func (i *Interp) valueLimitExceeded() bool {
+	if i.valueTooLarge == "" {
+		return false
+	}
+	i.result = i.String(i.valueTooLarge)
+	i.returnOptions = i.List(i.String("-code"), i.Int(1),
+		i.String("-errorcode"), i.List(i.String("TCL"), i.String("LIMIT"), i.String("VALUE")))
+	return true
+}
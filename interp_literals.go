@@ -0,0 +1,91 @@
+package feather
+
+// literalHandleBit marks the handles of the literal table, so that they
+// never alias handles of the arenas.
+const literalHandleBit FeatherObj = 1 << 61
+
+// literalMaxLen is the length of the longest string looked up in the
+// literal table. Longer strings are never literals, and hashing them would
+// only slow down interning.
+const literalMaxLen = 32
+
+// commonLiterals are the strings, besides command names, that scripts and
+// the C core produce most often.
+var commonLiterals = []string{
+	"", "0", "1", "-1", "2", "::",
+	"ok", "error", "return", "break", "continue",
+	"-code", "-level", "-errorcode", "-errorinfo", "-errorline", "-errorstack",
+	"NONE", "true", "false", "args",
+}
+
+// literalTable holds one object for each of the strings interned most
+// often: the common literals and the names of the commands an interpreter
+// starts with. Interning one of them returns the same handle in every
+// eval, instead of a new object in the scratch arena.
+//
+// The table is filled by New and never changes after that, and neither do
+// its objects: their string is fixed, so at most their internal
+// representation is computed and cached. A literal also reaches the C core
+// through other handles, as the value of a variable set to it, so host
+// operations that change a list or dict in place look its object up in
+// shared and copy it first; see unshareLiteral.
+type literalTable struct {
+	objs    handleTable[*Obj]
+	handles map[string]FeatherObj
+	shared  map[*Obj]struct{}
+}
+
+// isLiteralHandle returns true if the handle belongs to the literal table.
+func isLiteralHandle(h FeatherObj) bool {
+	return h&literalHandleBit != 0
+}
+
+// initLiterals fills the literal table with the common literals and the
+// names of the commands in the global namespace.
+func (i *Interp) initLiterals() {
+	t := &i.literals
+	t.objs.tag = literalHandleBit
+	n := len(commonLiterals) + len(i.globalNamespace.commands)
+	t.handles = make(map[string]FeatherObj, n)
+	t.shared = make(map[*Obj]struct{}, n)
+	add := func(s string) {
+		if _, ok := t.handles[s]; !ok && len(s) <= literalMaxLen {
+			obj := i.String(s)
+			t.handles[s] = t.objs.add(obj)
+			t.shared[obj] = struct{}{}
+		}
+	}
+	for _, s := range commonLiterals {
+		add(s)
+	}
+	for name := range i.globalNamespace.commands {
+		add(name)
+	}
+}
+
+// literal returns the handle of s in the literal table, or 0 if s is not a
+// literal.
+func (i *Interp) literal(s string) FeatherObj {
+	if len(s) > literalMaxLen {
+		return 0
+	}
+	return i.literals.handles[s]
+}
+
+// isLiteral returns true if obj is the object of a literal.
+func (i *Interp) isLiteral(obj *Obj) bool {
+	_, ok := i.literals.shared[obj]
+	return ok
+}
+
+// unshareLiteral returns h, or a copy of its object in the scratch arena if
+// that is a literal, so that the caller may change the object in place.
+// Operations that return no new handle, such as sorting a list, then change
+// only the copy; the C core copies lists before such operations, so this
+// only keeps a literal from changing for every later eval.
+func (i *Interp) unshareLiteral(h FeatherObj) FeatherObj {
+	if obj := i.getObject(h); obj != nil && i.isLiteral(obj) {
+		return i.registerObjScratch(obj.Clone())
+	}
+	return h
+}
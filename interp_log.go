@@ -0,0 +1,52 @@
+package feather
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SetLogger sets the logger the interpreter reports what it does to, so
+// that problems in embedded scripts can be traced without adding puts to
+// them. nil, the default, turns logging off. It logs:
+//
+//	command registered       a command was registered from Go (name)
+//	unknown command          a command that does not exist was called, and
+//	                         was passed to the OnUnknown handler if there
+//	                         is one (name, args, handled)
+//	script error             Eval, Call or another Go API failed, at Error
+//	                         level (error, errorcode, errorinfo)
+//	trace fired              a trace is about to run (kind, command)
+//	recursion limit reached  a call was nested too deeply, at Warn level
+//	                         (limit, command)
+//	foreign object created   a foreign object was made (type, name)
+//	foreign object destroyed a foreign object was destroyed (type, name)
+//
+// Events not given a level above are logged at Debug level.
+//
+//	interp.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+//	    Level: slog.LevelDebug,
+//	})))
+func (i *Interp) SetLogger(logger *slog.Logger) {
+	i.logger = logger
+}
+
+// logging reports whether events of level are logged.
+func (i *Interp) logging(level slog.Level) bool {
+	return i.logger != nil && i.logger.Enabled(context.Background(), level)
+}
+
+// log logs the event msg at level, if it is logged.
+func (i *Interp) log(level slog.Level, msg string, args ...any) {
+	if i.logging(level) {
+		i.logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+// logWords returns the strings of the handles words, for logging.
+func (i *Interp) logWords(words []FeatherObj) []string {
+	strs := make([]string, len(words))
+	for n, w := range words {
+		strs[n] = i.getString(w)
+	}
+	return strs
+}
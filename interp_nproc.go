@@ -0,0 +1,326 @@
+package feather
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The nproc command is a feather extension that defines a procedure with
+// named options in addition to the usual positional parameters:
+//
+//	nproc fetch {-verbose {-timeout 30} url {method GET} args} {
+//	    ...
+//	}
+//	fetch -timeout 5 http://example.com
+//
+// A parameter written as -name is a flag: its variable is 1 when the option
+// is given and 0 otherwise. A parameter written as {-name default} takes a
+// value. Options must come before positional arguments, and -- ends option
+// parsing. Positional parameters, defaults and a trailing args behave exactly
+// as they do for proc.
+//
+// nproc is implemented as an ordinary proc taking args whose body starts
+// with a call to ::tcl::nargs, which binds the parameters.
+
+// nprocParam describes one parameter of an nproc.
+type nprocParam struct {
+	name   string // variable name (without the leading - for options)
+	def    *Obj   // default value; nil if required
+	option bool   // named option rather than positional parameter
+	flag   bool   // option that takes no value
+}
+
+// nprocSpec is the parsed parameter list of an nproc.
+type nprocSpec struct {
+	options    []nprocParam
+	positional []nprocParam
+	variadic   bool // last positional parameter is args
+	refs       int  // nprocs defined with it, while it is cached
+}
+
+// registerNproc installs the nproc command and its binding helper.
+func (i *Interp) registerNproc() {
+	i.nprocSpecs = make(map[string]*nprocSpec)
+	i.nprocTraces = make(map[string]func())
+	i.RegisterCommand("nproc", cmdNproc)
+
+	// The helper lives in ::tcl so it does not clutter the global namespace
+	i.setCommand(i.ensureNamespace("::tcl"), "nargs", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(cmdNargs)})
+}
+
+// cmdNproc implements: nproc name params body
+func cmdNproc(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 3 {
+		return Error(`wrong # args: should be "nproc name params body"`)
+	}
+	spec, err := i.nprocSpec(args[1])
+	if err != nil {
+		return Error(err.Error())
+	}
+	// Keep the prelude on the first line so body line numbers are unchanged
+	body := "::tcl::nargs " + quote(args[1].String()) + "; " + args[2].String()
+	if _, err := i.Call("proc", args[0], "args", body); err != nil {
+		return Error(err.Error())
+	}
+	name, _ := i.resolveCommandName(args[0].String())
+	i.trackNproc(name, args[1].String(), spec)
+	return OK("")
+}
+
+// trackNproc keeps spec cached under key while the nproc name exists,
+// following it through renames with a command trace. Defining name again
+// drops what the previous definition kept.
+func (i *Interp) trackNproc(name, key string, spec *nprocSpec) {
+	if untrack, ok := i.nprocTraces[name]; ok {
+		untrack()
+	}
+	if cached, ok := i.nprocSpecs[key]; ok {
+		spec = cached
+	} else {
+		i.nprocSpecs[key] = spec
+	}
+	spec.refs++
+
+	var remove func()
+	untrack := func() {
+		remove()
+		delete(i.nprocTraces, name)
+		i.releaseNprocSpec(key)
+	}
+	remove, err := i.TraceCommand(name, "rename delete", func(t CommandTraceInfo) {
+		untrack()
+		if t.Op == "rename" {
+			i.trackNproc(t.NewName, key, spec)
+		}
+	})
+	if err != nil {
+		i.releaseNprocSpec(key)
+		return
+	}
+	i.nprocTraces[name] = untrack
+}
+
+// releaseNprocSpec drops a reference to the spec cached under key, and
+// the spec once no nproc uses it.
+func (i *Interp) releaseNprocSpec(key string) {
+	spec, ok := i.nprocSpecs[key]
+	if !ok {
+		return
+	}
+	if spec.refs--; spec.refs <= 0 {
+		delete(i.nprocSpecs, key)
+	}
+}
+
+// cmdNargs implements: ::tcl::nargs params
+//
+// It runs in the frame of the nproc and binds its parameters from args.
+func cmdNargs(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "::tcl::nargs params"`)
+	}
+	if i.active == 0 {
+		return Error("::tcl::nargs can only be called from a proc")
+	}
+	spec, err := i.nprocSpec(args[0])
+	if err != nil {
+		return Error(err.Error())
+	}
+	frame := i.frames[i.active]
+	var words []*Obj
+	if v := frame.locals.vars["args"]; v != nil {
+		if words, err = v.List(); err != nil {
+			return Error(err.Error())
+		}
+	}
+	delete(frame.locals.vars, "args")
+
+	vars, err := spec.bind(i, words)
+	if err != nil {
+		name := strings.TrimPrefix(frame.cmd.String(), "::")
+		if err == errNprocArgs {
+			return Errorf(`wrong # args: should be "%s"`, strings.TrimSpace(name+" "+spec.usage()))
+		}
+		return Error(err.Error())
+	}
+	for name, val := range vars {
+		frame.locals.vars[name] = val
+	}
+	return OK("")
+}
+
+// nprocSpec returns the parsed form of a parameter list, from the cache
+// of those of the nprocs defined if it is there.
+func (i *Interp) nprocSpec(params *Obj) (*nprocSpec, error) {
+	if spec, ok := i.nprocSpecs[params.String()]; ok {
+		return spec, nil
+	}
+	return parseNprocSpec(params)
+}
+
+// parseNprocSpec parses and validates an nproc parameter list.
+func parseNprocSpec(params *Obj) (*nprocSpec, error) {
+	items, err := params.List()
+	if err != nil {
+		return nil, err
+	}
+	spec := &nprocSpec{}
+	for j, item := range items {
+		fields, err := item.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("too many fields in argument specifier \"%s\"", item.String())
+		}
+		if len(fields) == 0 || fields[0].String() == "" {
+			return nil, fmt.Errorf("argument with no name")
+		}
+		p := nprocParam{name: fields[0].String()}
+		if len(fields) == 2 {
+			p.def = fields[1]
+		}
+		if strings.HasPrefix(p.name, "-") {
+			p.option = true
+			p.flag = p.def == nil
+			p.name = p.name[1:]
+			if p.name == "" || p.name == "-" {
+				return nil, fmt.Errorf("bad option name \"%s\"", fields[0].String())
+			}
+		}
+		if strings.Contains(p.name, "::") {
+			return nil, fmt.Errorf("formal parameter \"%s\" is not a simple name", p.name)
+		}
+		if strings.Contains(p.name, "(") && strings.HasSuffix(p.name, ")") {
+			return nil, fmt.Errorf("formal parameter \"%s\" is an array element", p.name)
+		}
+		if p.option {
+			spec.options = append(spec.options, p)
+			continue
+		}
+		if p.name == "args" && j == len(items)-1 {
+			spec.variadic = true
+		}
+		spec.positional = append(spec.positional, p)
+	}
+	return spec, nil
+}
+
+// errNprocArgs reports a positional argument count mismatch.
+var errNprocArgs = fmt.Errorf("wrong # args")
+
+// bind matches words against the spec and returns the variables to set.
+func (s *nprocSpec) bind(i *Interp, words []*Obj) (map[string]*Obj, error) {
+	vars := make(map[string]*Obj, len(s.options)+len(s.positional))
+	for _, opt := range s.options {
+		if opt.flag {
+			vars[opt.name] = i.Int(0)
+		} else {
+			vars[opt.name] = opt.def
+		}
+	}
+
+	// Options come first; -- or the first non-option word ends them
+	for len(words) > 0 && len(s.options) > 0 {
+		word := words[0].String()
+		if word == "--" {
+			words = words[1:]
+			break
+		}
+		if !strings.HasPrefix(word, "-") {
+			break
+		}
+		opt := s.option(word[1:])
+		if opt == nil {
+			return nil, fmt.Errorf("bad option \"%s\": must be %s", word, s.optionNames())
+		}
+		if opt.flag {
+			vars[opt.name] = i.Int(1)
+			words = words[1:]
+			continue
+		}
+		if len(words) < 2 {
+			return nil, fmt.Errorf("value for \"%s\" missing", word)
+		}
+		vars[opt.name] = words[1]
+		words = words[2:]
+	}
+
+	// Positional parameters follow proc's rules
+	bindable := s.positional
+	if s.variadic {
+		bindable = bindable[:len(bindable)-1]
+	}
+	minArgs := 0
+	for j, p := range bindable {
+		if p.def == nil {
+			minArgs = j + 1
+		}
+	}
+	if len(words) < minArgs || (!s.variadic && len(words) > len(bindable)) {
+		return nil, errNprocArgs
+	}
+	for j, p := range bindable {
+		if j < len(words) {
+			vars[p.name] = words[j]
+		} else {
+			vars[p.name] = p.def
+		}
+	}
+	if s.variadic {
+		var rest []*Obj
+		if len(words) > len(bindable) {
+			rest = words[len(bindable):]
+		}
+		vars["args"] = i.List(rest...)
+	}
+	return vars, nil
+}
+
+// option returns the option named name, or nil.
+func (s *nprocSpec) option(name string) *nprocParam {
+	for j := range s.options {
+		if s.options[j].name == name {
+			return &s.options[j]
+		}
+	}
+	return nil
+}
+
+// optionNames lists the options for error messages: "-a, -b, or -c".
+func (s *nprocSpec) optionNames() string {
+	names := make([]string, len(s.options))
+	for j, opt := range s.options {
+		names[j] = "-" + opt.name
+	}
+	switch len(names) {
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " or " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
+
+// usage returns the argument part of a wrong # args message.
+func (s *nprocSpec) usage() string {
+	var parts []string
+	for _, opt := range s.options {
+		if opt.flag {
+			parts = append(parts, "?-"+opt.name+"?")
+		} else {
+			parts = append(parts, "?-"+opt.name+" "+opt.name+"?")
+		}
+	}
+	for j, p := range s.positional {
+		switch {
+		case s.variadic && j == len(s.positional)-1:
+			parts = append(parts, "?arg ...?")
+		case p.def != nil:
+			parts = append(parts, "?"+p.name+"?")
+		default:
+			parts = append(parts, p.name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,661 @@
+package feather
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The oo commands are a subset of TclOO:
+//
+//	oo::class create Animal {
+//	    variable name
+//	    constructor {n} { set name $n }
+//	    method speak {} { return "$name speaks" }
+//	}
+//	oo::class create Dog {
+//	    superclass Animal
+//	    method speak {} { return "[next] loudly" }
+//	}
+//	set d [Dog new rex]
+//	$d speak    ;# rex speaks loudly
+//	$d destroy
+//
+// Classes support constructor, destructor, method, superclass, variable,
+// export and unexport definitions, given in the script passed to
+// oo::class create or added later with oo::define. Methods run with
+// apply in the object's namespace, where the my, self and next commands
+// are defined, so instance variables are namespace variables of the object.
+// Methods whose names start with a lowercase letter are exported and can be
+// called on the object; the others can only be called through my.
+//
+// Every class inherits from oo::object, which provides the destroy, eval,
+// variable and varname methods.
+
+// ooClass is a class created with oo::class.
+type ooClass struct {
+	obj          *ooObject // the class is itself an object
+	superclasses []*ooClass
+	methods      map[string]*ooMethod
+	constructor  *ooMethod
+	destructor   *ooMethod
+	variables    []string
+	exports      map[string]bool // explicit export (true) or unexport (false)
+}
+
+// ooMethod is a method, constructor or destructor defined by a class.
+type ooMethod struct {
+	class   *ooClass
+	params  *Obj
+	body    *Obj
+	builtin func(i *Interp, obj *ooObject, args []*Obj) Result // oo::object methods
+}
+
+// ooObject is an object, or a class.
+type ooObject struct {
+	name      string // fully qualified command name when last looked up
+	ns        string // namespace holding the object's variables
+	entry     *Command
+	class     *ooClass // class of an instance (nil for classes)
+	isClass   *ooClass // the class this object is (nil for instances)
+	destroyed bool
+}
+
+// ooCall is a method call in progress, for self and next.
+type ooCall struct {
+	obj    *ooObject
+	method string
+	chain  []*ooMethod // implementations, most specific first
+	index  int         // position of the running implementation in chain
+	usage  string      // command prefix for wrong # args messages
+}
+
+// ooState holds an interpreter's object system.
+type ooState struct {
+	objects  map[*Command]*ooObject
+	root     *ooClass   // oo::object
+	meta     *ooClass   // oo::class
+	calls    []*ooCall  // method calls in progress, innermost last
+	defining []*ooClass // classes whose definition script is running
+	nextID   int
+}
+
+// registerOO installs oo::class, oo::object and oo::define.
+func (i *Interp) registerOO() {
+	i.oo = &ooState{objects: make(map[*Command]*ooObject)}
+	ooNs := i.ensureNamespace("::oo")
+
+	root := i.newClass(ooNs, "object", "::oo::object")
+	root.exports = map[string]bool{"eval": false, "variable": false, "varname": false}
+	root.methods = map[string]*ooMethod{
+		"destroy":  {class: root, builtin: ooDestroyMethod},
+		"eval":     {class: root, builtin: ooEvalMethod},
+		"variable": {class: root, builtin: ooVariableMethod},
+		"varname":  {class: root, builtin: ooVarnameMethod},
+	}
+	i.oo.root = root
+	i.oo.meta = i.newClass(ooNs, "class", "::oo::class")
+
+	i.setCommand(ooNs, "define", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(cmdOODefine)})
+	defineNs := i.ensureNamespace("::oo::define")
+	for name, fn := range map[string]CommandFunc{
+		"constructor": ooDefineConstructor,
+		"destructor":  ooDefineDestructor,
+		"method":      ooDefineMethod,
+		"superclass":  ooDefineSuperclass,
+		"variable":    ooDefineVariable,
+		"export":      ooDefineExport,
+		"unexport":    ooDefineUnexport,
+	} {
+		i.setCommand(defineNs, name, &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(fn)})
+	}
+}
+
+// newClass creates a class object named simple in ns.
+func (i *Interp) newClass(ns *Namespace, simple, name string) *ooClass {
+	cls := &ooClass{methods: make(map[string]*ooMethod), exports: make(map[string]bool)}
+	cls.obj = i.newObject(ns, simple, name, "", nil)
+	cls.obj.isClass = cls
+	return cls
+}
+
+// newObject registers the command for a new object or class.
+func (i *Interp) newObject(ns *Namespace, simple, name, objNs string, class *ooClass) *ooObject {
+	obj := &ooObject{name: name, ns: objNs, class: class}
+	obj.entry = &Command{
+		cmdType:  CmdBuiltin,
+		fn:       i.wrapCommand(obj.cmdObject),
+		onDelete: func() { i.destroyObject(obj) },
+	}
+	i.setCommand(ns, simple, obj.entry)
+	i.oo.objects[obj.entry] = obj
+	return obj
+}
+
+// lookupObject finds the object a command name refers to.
+func (i *Interp) lookupObject(name string) (*ooObject, error) {
+	if cmd := i.resolveCommand(name); cmd != nil {
+		if obj, ok := i.oo.objects[cmd]; ok {
+			obj.name = i.commandName(obj.entry, obj.name)
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("%s does not refer to an object", name)
+}
+
+// lookupClass finds the class a command name refers to.
+func (i *Interp) lookupClass(name string) (*ooClass, error) {
+	obj, err := i.lookupObject(name)
+	if err != nil {
+		return nil, err
+	}
+	if obj.isClass == nil {
+		return nil, fmt.Errorf("\"%s\" is not a class", name)
+	}
+	return obj.isClass, nil
+}
+
+// lineage returns cls and its superclasses in method resolution order,
+// ending with oo::object.
+func (i *Interp) lineage(cls *ooClass) []*ooClass {
+	var order []*ooClass
+	var walk func(c *ooClass)
+	walk = func(c *ooClass) {
+		if c == i.oo.root || slices.Contains(order, c) {
+			return
+		}
+		order = append(order, c)
+		for _, s := range c.superclasses {
+			walk(s)
+		}
+	}
+	walk(cls)
+	return append(order, i.oo.root)
+}
+
+// methodChain returns the implementations of a method for cls, most
+// specific first, and whether the method is exported.
+func (i *Interp) methodChain(cls *ooClass, name string) ([]*ooMethod, bool) {
+	var chain []*ooMethod
+	exported, decided := false, false
+	for _, c := range i.lineage(cls) {
+		if m, ok := c.methods[name]; ok {
+			chain = append(chain, m)
+		}
+		if e, ok := c.exports[name]; ok && !decided {
+			exported, decided = e, true
+		}
+	}
+	if !decided && name != "" && name[0] >= 'a' && name[0] <= 'z' {
+		exported = true
+	}
+	return chain, exported
+}
+
+// methodNames returns the sorted names of the methods of cls, only the
+// exported ones unless all is set.
+func (i *Interp) methodNames(cls *ooClass, all bool) []string {
+	var names []string
+	for _, c := range i.lineage(cls) {
+		for name := range c.methods {
+			if slices.Contains(names, name) {
+				continue
+			}
+			if _, exported := i.methodChain(cls, name); exported || all {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// unknownMethod reports a method that cannot be called.
+func unknownMethod(name string, names []string) Result {
+	list := names[len(names)-1]
+	if len(names) > 1 {
+		list = strings.Join(names[:len(names)-1], ", ") + " or " + list
+	}
+	return Errorf("unknown method \"%s\": must be %s", name, list)
+}
+
+// cmdObject implements the command of an object or class:
+// obj method ?arg ...?
+func (obj *ooObject) cmdObject(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Errorf("wrong # args: should be \"%s method ?arg ...?\"", cmd.String())
+	}
+	name := args[0].String()
+	if cls := obj.isClass; cls != nil {
+		switch name {
+		case "create":
+			if len(args) < 2 {
+				return Errorf("wrong # args: should be \"%s create objectName ?arg ...?\"", cmd.String())
+			}
+			return i.createObject(cls, args[1].String(), args[2:], cmd.String()+" create "+args[1].String())
+		case "new":
+			if cls != i.oo.meta {
+				return i.createObject(cls, "", args[1:], cmd.String()+" new")
+			}
+		case "destroy":
+			i.destroyObject(obj)
+			return OK("")
+		}
+		if cls == i.oo.meta {
+			return unknownMethod(name, []string{"create", "destroy"})
+		}
+		return unknownMethod(name, []string{"create", "destroy", "new"})
+	}
+
+	chain, exported := i.methodChain(obj.class, name)
+	if len(chain) == 0 || !exported {
+		return unknownMethod(name, i.methodNames(obj.class, false))
+	}
+	return i.invokeMethod(&ooCall{obj: obj, method: name, chain: chain, usage: cmd.String() + " " + name}, args[1:])
+}
+
+// createObject implements cls create name ?arg ...? and cls new ?arg ...?.
+// An empty name creates an object named after its namespace.
+func (i *Interp) createObject(cls *ooClass, name string, args []*Obj, usage string) Result {
+	i.oo.nextID++
+	objNs := fmt.Sprintf("::oo::Obj%d", i.oo.nextID)
+	if name == "" {
+		name = objNs
+	}
+	ns, simple, qualified := i.qualifyCommand(name)
+	if _, exists := ns.commands[simple]; exists {
+		return Errorf("can't create object \"%s\": command already exists with that name", name)
+	}
+
+	if cls == i.oo.meta {
+		created := i.newClass(ns, simple, qualified)
+		if len(args) > 1 {
+			i.destroyObject(created.obj)
+			return Errorf("wrong # args: should be \"%s ?definitionScript?\"", usage)
+		}
+		if len(args) == 1 {
+			if r := i.defineClass(created, args[0]); r.code != ResultOK {
+				i.destroyObject(created.obj)
+				return r
+			}
+		}
+		return OK(qualified)
+	}
+
+	i.ensureNamespace(objNs)
+	obj := i.newObject(ns, simple, qualified, objNs, cls)
+	objNamespace := i.namespaces[objNs]
+	for cmdName, fn := range map[string]CommandFunc{"my": obj.cmdMy, "self": obj.cmdSelf, "next": obj.cmdNext} {
+		i.setCommand(objNamespace, cmdName, &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(fn)})
+	}
+
+	var chain []*ooMethod
+	for _, c := range i.lineage(cls) {
+		if c.constructor != nil {
+			chain = append(chain, c.constructor)
+		}
+	}
+	if len(chain) == 0 {
+		if len(args) > 0 {
+			i.destroyObject(obj)
+			return Errorf("wrong # args: should be \"%s\"", usage)
+		}
+		return OK(qualified)
+	}
+	r := i.invokeMethod(&ooCall{obj: obj, method: "<constructor>", chain: chain, usage: usage}, args)
+	if r.code != ResultOK && r.code != ResultReturn {
+		i.destroyObject(obj)
+		return r
+	}
+	return OK(qualified)
+}
+
+// invokeMethod runs the implementation at call.index with args.
+func (i *Interp) invokeMethod(call *ooCall, args []*Obj) Result {
+	m := call.chain[call.index]
+	i.oo.calls = append(i.oo.calls, call)
+	defer func() { i.oo.calls = i.oo.calls[:len(i.oo.calls)-1] }()
+
+	if m.builtin != nil {
+		return m.builtin(i, call.obj, args)
+	}
+	if usage, ok := i.methodUsage(m.params, len(args)); !ok {
+		return Errorf("wrong # args: should be \"%s\"", strings.TrimSpace(call.usage+" "+usage))
+	}
+
+	// Keep the prelude on the first line so body line numbers are unchanged
+	var prelude strings.Builder
+	for _, v := range m.class.variables {
+		prelude.WriteString("variable " + quote(v) + "; ")
+	}
+	lambda := i.List(m.params, i.String(prelude.String()+m.body.String()), i.String(call.obj.ns))
+	words := append([]*Obj{i.String("::apply"), lambda}, args...)
+	code := FeatherResult(callCEval(i.handle, i.handleForObj(i.List(words...))))
+	return Result{code: code, obj: i.result, hasObj: true}
+}
+
+// methodUsage checks argc against a parameter list. It returns the usage
+// string for a wrong # args message and whether argc is acceptable.
+func (i *Interp) methodUsage(params *Obj, argc int) (string, bool) {
+	items, err := params.List()
+	if err != nil {
+		return "", true // let apply report the malformed parameter list
+	}
+	var words []string
+	min, variadic := 0, false
+	for n, p := range items {
+		parts, err := p.List()
+		if err != nil {
+			return "", true
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		name := parts[0].String()
+		switch {
+		case name == "args" && n == len(items)-1:
+			variadic = true
+			words = append(words, "?arg ...?")
+		case len(parts) > 1:
+			words = append(words, "?"+name+"?")
+		default:
+			min = n + 1
+			words = append(words, name)
+		}
+	}
+	ok := argc >= min && (variadic || argc <= len(words))
+	return strings.Join(words, " "), ok
+}
+
+// destroyObject runs an object's destructors and deletes its namespace
+// and command. Destroying a class destroys its subclasses and instances.
+func (i *Interp) destroyObject(obj *ooObject) {
+	if obj.destroyed {
+		return
+	}
+	obj.destroyed = true
+	result, options := i.result, i.returnOptions
+
+	if cls := obj.isClass; cls != nil {
+		for _, other := range i.oo.objects {
+			if other.class != nil && slices.Contains(i.lineage(other.class), cls) {
+				i.destroyObject(other)
+			} else if other.isClass != nil && other.isClass != cls && slices.Contains(i.lineage(other.isClass), cls) {
+				i.destroyObject(other)
+			}
+		}
+	} else {
+		var chain []*ooMethod
+		for _, c := range i.lineage(obj.class) {
+			if c.destructor != nil {
+				chain = append(chain, c.destructor)
+			}
+		}
+		if len(chain) > 0 {
+			i.invokeMethod(&ooCall{obj: obj, method: "<destructor>", chain: chain}, nil)
+		}
+		i.eval(i.List(i.String("namespace"), i.String("delete"), i.String(obj.ns)).String())
+	}
+
+	delete(i.oo.objects, obj.entry)
+	for _, ns := range i.namespaces {
+		for name, cmd := range ns.commands {
+			if cmd == obj.entry {
+				i.deleteCommand(ns, name)
+			}
+		}
+	}
+	i.result, i.returnOptions = result, options
+}
+
+// ooDestroyMethod implements obj destroy.
+func ooDestroyMethod(i *Interp, obj *ooObject, args []*Obj) Result {
+	if len(args) != 0 {
+		return Errorf("wrong # args: should be \"%s destroy\"", obj.name)
+	}
+	i.destroyObject(obj)
+	return OK("")
+}
+
+// ooEvalMethod implements my eval ?arg ...?, which evaluates its arguments
+// as a script in the object's namespace.
+func ooEvalMethod(i *Interp, obj *ooObject, args []*Obj) Result {
+	script := i.concatScripts(args)
+	words := i.List(i.String("::namespace"), i.String("eval"), i.String(obj.ns), script)
+	code := FeatherResult(callCEval(i.handle, i.handleForObj(words)))
+	return Result{code: code, obj: i.result, hasObj: true}
+}
+
+// ooVariableMethod implements my variable ?name ...?, which makes the
+// object's variables available in the calling method.
+func ooVariableMethod(i *Interp, obj *ooObject, args []*Obj) Result {
+	// Methods run in the object's namespace, so variable links to its variables
+	for _, name := range args {
+		words := i.List(i.String("::variable"), name)
+		if code := FeatherResult(callCEval(i.handle, i.handleForObj(words))); code != ResultOK {
+			return Result{code: code, obj: i.result, hasObj: true}
+		}
+	}
+	return OK("")
+}
+
+// ooVarnameMethod implements my varname name, which returns the fully
+// qualified name of an object variable.
+func ooVarnameMethod(i *Interp, obj *ooObject, args []*Obj) Result {
+	if len(args) != 1 {
+		return Errorf("wrong # args: should be \"%s varname varName\"", obj.name)
+	}
+	return OK(obj.ns + "::" + args[0].String())
+}
+
+// cmdMy implements: my method ?arg ...?
+//
+// Unlike calling the object's command, my can call unexported methods.
+func (obj *ooObject) cmdMy(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "my method ?arg ...?"`)
+	}
+	name := args[0].String()
+	chain, _ := i.methodChain(obj.class, name)
+	if len(chain) == 0 {
+		return unknownMethod(name, i.methodNames(obj.class, true))
+	}
+	return i.invokeMethod(&ooCall{obj: obj, method: name, chain: chain, usage: "my " + name}, args[1:])
+}
+
+// cmdSelf implements: self ?class|method|namespace|object?
+func (obj *ooObject) cmdSelf(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) > 1 {
+		return Error(`wrong # args: should be "self ?subcommand?"`)
+	}
+	obj.name = i.commandName(obj.entry, obj.name)
+	sub := "object"
+	if len(args) == 1 {
+		sub = args[0].String()
+	}
+	call := i.currentCall(obj)
+	switch sub {
+	case "object":
+		return OK(obj.name)
+	case "namespace":
+		return OK(obj.ns)
+	case "class", "method":
+		if call == nil {
+			return Error("self may only be called from inside a method")
+		}
+		if sub == "method" {
+			return OK(call.method)
+		}
+		cls := call.chain[call.index].class
+		return OK(i.commandName(cls.obj.entry, cls.obj.name))
+	}
+	return Errorf("bad subcommand \"%s\": must be class, method, namespace, or object", sub)
+}
+
+// cmdNext implements: next ?arg ...?
+//
+// It calls the implementation of the running method that the current one
+// overrides, in the next class of the method resolution order.
+func (obj *ooObject) cmdNext(i *Interp, cmd *Obj, args []*Obj) Result {
+	call := i.currentCall(obj)
+	if call == nil {
+		return Error("next may only be called from inside a method")
+	}
+	if call.index+1 >= len(call.chain) {
+		return Error("no next method implementation")
+	}
+	next := *call
+	next.index++
+	return i.invokeMethod(&next, args)
+}
+
+// currentCall returns the innermost method call running on obj.
+func (i *Interp) currentCall(obj *ooObject) *ooCall {
+	for n := len(i.oo.calls) - 1; n >= 0; n-- {
+		if i.oo.calls[n].obj == obj {
+			return i.oo.calls[n]
+		}
+	}
+	return nil
+}
+
+// defineClass evaluates a definition script for cls.
+func (i *Interp) defineClass(cls *ooClass, script *Obj) Result {
+	return i.runDefinition(cls, i.List(i.String("::namespace"), i.String("eval"), i.String("::oo::define"), script))
+}
+
+// runDefinition evaluates words with cls as the class being defined.
+func (i *Interp) runDefinition(cls *ooClass, words *Obj) Result {
+	i.oo.defining = append(i.oo.defining, cls)
+	defer func() { i.oo.defining = i.oo.defining[:len(i.oo.defining)-1] }()
+	code := FeatherResult(callCEval(i.handle, i.handleForObj(words)))
+	if code != ResultOK {
+		return Result{code: code, obj: i.result, hasObj: true}
+	}
+	return OK("")
+}
+
+// cmdOODefine implements: oo::define className script
+// and: oo::define className subcommand ?arg ...?
+func cmdOODefine(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 2 {
+		return Error(`wrong # args: should be "oo::define className arg ?arg ...?"`)
+	}
+	cls, err := i.lookupClass(args[0].String())
+	if err != nil {
+		return Error(err.Error())
+	}
+	if len(args) == 2 {
+		return i.defineClass(cls, args[1])
+	}
+	sub := i.String("::oo::define::" + args[1].String())
+	if i.resolveCommand(sub.String()) == nil {
+		return Errorf("invalid command name \"%s\"", args[1].String())
+	}
+	return i.runDefinition(cls, i.List(append([]*Obj{sub}, args[2:]...)...))
+}
+
+// definingClass returns the class whose definition is running.
+func (i *Interp) definingClass(what string) (*ooClass, Result, bool) {
+	if len(i.oo.defining) == 0 {
+		return nil, Errorf("%s may only be used in a class definition", what), false
+	}
+	return i.oo.defining[len(i.oo.defining)-1], Result{}, true
+}
+
+// ooDefineConstructor implements: constructor arguments body
+func ooDefineConstructor(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 2 {
+		return Error(`wrong # args: should be "constructor arguments body"`)
+	}
+	cls, r, ok := i.definingClass("constructor")
+	if !ok {
+		return r
+	}
+	cls.constructor = &ooMethod{class: cls, params: args[0], body: args[1]}
+	return OK("")
+}
+
+// ooDefineDestructor implements: destructor body
+func ooDefineDestructor(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "destructor body"`)
+	}
+	cls, r, ok := i.definingClass("destructor")
+	if !ok {
+		return r
+	}
+	cls.destructor = &ooMethod{class: cls, params: i.List(), body: args[0]}
+	return OK("")
+}
+
+// ooDefineMethod implements: method name arguments body
+func ooDefineMethod(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 3 {
+		return Error(`wrong # args: should be "method name arguments body"`)
+	}
+	cls, r, ok := i.definingClass("method")
+	if !ok {
+		return r
+	}
+	cls.methods[args[0].String()] = &ooMethod{class: cls, params: args[1], body: args[2]}
+	return OK("")
+}
+
+// ooDefineSuperclass implements: superclass className ?className ...?
+func ooDefineSuperclass(i *Interp, cmd *Obj, args []*Obj) Result {
+	cls, r, ok := i.definingClass("superclass")
+	if !ok {
+		return r
+	}
+	supers := make([]*ooClass, 0, len(args))
+	for _, name := range args {
+		super, err := i.lookupClass(name.String())
+		if err != nil {
+			return Error(err.Error())
+		}
+		if slices.Contains(i.lineage(super), cls) {
+			return Error("attempt to form circular dependency graph")
+		}
+		supers = append(supers, super)
+	}
+	cls.superclasses = supers
+	return OK("")
+}
+
+// ooDefineVariable implements: variable ?name ...?
+//
+// The named object variables are available in the class's methods without
+// declaring them.
+func ooDefineVariable(i *Interp, cmd *Obj, args []*Obj) Result {
+	cls, r, ok := i.definingClass("variable")
+	if !ok {
+		return r
+	}
+	cls.variables = cls.variables[:0]
+	for _, name := range args {
+		cls.variables = append(cls.variables, name.String())
+	}
+	return OK("")
+}
+
+// ooDefineExport implements: export name ?name ...?
+func ooDefineExport(i *Interp, cmd *Obj, args []*Obj) Result {
+	return i.setExports("export", args, true)
+}
+
+// ooDefineUnexport implements: unexport name ?name ...?
+func ooDefineUnexport(i *Interp, cmd *Obj, args []*Obj) Result {
+	return i.setExports("unexport", args, false)
+}
+
+func (i *Interp) setExports(what string, names []*Obj, exported bool) Result {
+	cls, r, ok := i.definingClass(what)
+	if !ok {
+		return r
+	}
+	for _, name := range names {
+		cls.exports[name.String()] = exported
+	}
+	return OK("")
+}
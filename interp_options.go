@@ -0,0 +1,183 @@
+package feather
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OptionSpec describes an option for [Interp.ParseOptions], given as
+// -name value, or as -name alone for a flag.
+type OptionSpec struct {
+	Name    string  // the option, with its leading dash, such as "-port"
+	Type    ArgType // the type its value is converted to; ignored for flags
+	Default any     // the value when the option is not given; nil for none
+	Flag    bool    // the option takes no value and is true when given
+}
+
+// optionTypes names the option types of the options command, in the
+// order of its error messages.
+var optionTypes = []string{"any", "bool", "dict", "double", "flag", "int", "list", "string"}
+
+// optionArgTypes maps the option types of the options command to the
+// types they convert to.
+var optionArgTypes = map[string]ArgType{
+	"any":    Any,
+	"bool":   Bool,
+	"dict":   Dict,
+	"double": Double,
+	"int":    Int,
+	"list":   List,
+	"string": String,
+}
+
+// ParseOptions parses the options at the start of args, as commands such
+// as lsort and the options command do, and returns their values and the
+// arguments after them:
+//
+//	opts, rest, err := interp.ParseOptions(args, []feather.OptionSpec{
+//	    {Name: "-port", Type: feather.Int, Default: 8080},
+//	    {Name: "-verbose", Flag: true},
+//	})
+//	port := opts.Int("-port")
+//
+// Options may be abbreviated to unique prefixes. Parsing stops at the first
+// argument that does not start with a dash, or after "--". Values are read
+// from the result by option name, dash included; [Args] declares options
+// for commands the same way.
+func (i *Interp) ParseOptions(args []*Obj, options []OptionSpec) (*ArgValues, []*Obj, error) {
+	return i.parseOptions(args, options, 0)
+}
+
+// parseOptions is ParseOptions, leaving the last keep arguments alone for
+// the positional arguments that must follow the options.
+func (i *Interp) parseOptions(args []*Obj, options []OptionSpec, keep int) (*ArgValues, []*Obj, error) {
+	v := &ArgValues{values: make(map[string]any), given: make(map[string]bool)}
+	names := make([]string, len(options))
+	for n, o := range options {
+		names[n] = o.Name
+		switch {
+		case o.Flag:
+			v.values[o.Name] = false
+		case o.Default != nil:
+			v.values[o.Name] = defaultValue(i, o.Type, o.Default)
+		}
+	}
+	for len(options) > 0 && len(args) > keep && strings.HasPrefix(args[0].String(), "-") {
+		word := args[0].String()
+		args = args[1:]
+		if word == "--" {
+			break
+		}
+		index, _, ambiguous := lookupIndex(word, names, false)
+		if index < 0 {
+			kind := "bad"
+			if ambiguous {
+				kind = "ambiguous"
+			}
+			return nil, nil, fmt.Errorf("%s option \"%s\": must be %s", kind, word, joinAlternatives(names))
+		}
+		o := options[index]
+		v.given[o.Name] = true
+		if o.Flag {
+			v.values[o.Name] = true
+			continue
+		}
+		if len(args) == 0 {
+			return nil, nil, fmt.Errorf("value for \"%s\" missing", o.Name)
+		}
+		val, err := convertArg(o.Type, args[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		v.values[o.Name] = val
+		args = args[1:]
+	}
+	return v, args, nil
+}
+
+func (i *Interp) registerOptions() {
+	i.RegisterCommand("options", cmdOptions)
+}
+
+// cmdOptions implements the options command:
+//
+//	options parse arguments spec ?restVar?
+//
+// spec lists each option with its type, and for options that are not
+// flags a default value, as in {-port int 8080 -verbose flag}. The result
+// is a dict of option names, without the dash, and their values. The
+// arguments after the options are stored in restVar; without it, there
+// must be none.
+func cmdOptions(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) == 0 {
+		return Error(`wrong # args: should be "options subcommand ?arg ...?"`)
+	}
+	if _, err := i.GetIndexFromObj(args[0], []string{"parse"}, "subcommand"); err != nil {
+		return Error(err.Error())
+	}
+	if len(args) != 3 && len(args) != 4 {
+		return Error(`wrong # args: should be "options parse arguments spec ?restVar?"`)
+	}
+	words, err := args[1].List()
+	if err != nil {
+		return Error(err.Error())
+	}
+	options, err := parseOptionSpec(args[2])
+	if err != nil {
+		return Error(err.Error())
+	}
+	v, rest, err := i.parseOptions(words, options, 0)
+	if err != nil {
+		return Error(err.Error())
+	}
+	if len(args) == 4 {
+		if err := i.SetVarObj(args[3].String(), i.List(rest...)); err != nil {
+			return Error(err.Error())
+		}
+	} else if len(rest) > 0 {
+		return Errorf("unexpected argument \"%s\": must be an option", rest[0].String())
+	}
+
+	b := i.NewDictBuilder()
+	for _, o := range options {
+		val, ok := v.values[o.Name]
+		if !ok {
+			continue
+		}
+		if d, isDict := val.(*DictType); isDict {
+			val = i.Obj(d)
+		}
+		b.Set(strings.TrimPrefix(o.Name, "-"), val)
+	}
+	return OK(b.Obj())
+}
+
+// parseOptionSpec parses the spec of the options command.
+func parseOptionSpec(spec *Obj) ([]OptionSpec, error) {
+	words, err := spec.List()
+	if err != nil {
+		return nil, err
+	}
+	var options []OptionSpec
+	for n := 0; n < len(words); {
+		name := words[n].String()
+		if !strings.HasPrefix(name, "-") || n+1 >= len(words) {
+			return nil, fmt.Errorf("bad option spec at \"%s\": must be -name type ?default?", name)
+		}
+		typ, err := getIndex(words[n+1], tableKey(optionTypes), optionTypes, "option type", true)
+		if err != nil {
+			return nil, err
+		}
+		if optionTypes[typ] == "flag" {
+			options = append(options, OptionSpec{Name: name, Flag: true})
+			n += 2
+			continue
+		}
+		if n+2 >= len(words) {
+			return nil, fmt.Errorf("option \"%s\" has no default value", name)
+		}
+		options = append(options, OptionSpec{Name: name, Type: optionArgTypes[optionTypes[typ]], Default: words[n+2]})
+		n += 3
+	}
+	return options, nil
+}
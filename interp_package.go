@@ -0,0 +1,528 @@
+package feather
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The package command loads libraries of commands on demand:
+//
+//	package provide package ?version?
+//	package require ?-exact? package ?requirement ...?
+//	package ifneeded package version ?script?
+//	package present ?-exact? package ?requirement ...?
+//	package names
+//	package versions package
+//	package forget ?package ...?
+//	package vcompare version1 version2
+//	package vsatisfies version ?requirement ...?
+//
+// package require loads the highest stable version that satisfies the
+// requirements, from the script given to package ifneeded or the Go
+// function given to [Interp.RegisterPackage]. If no version is known, it
+// searches each directory in the auto_path variable, through the source
+// loader, for a pkgIndex.tcl, a pkgIndex.tcl in a subdirectory named after
+// the package, and a script named after the package, such as
+// lib/json.tcl for the package json.
+//
+// Versions are integers separated by dots, with an optional a or b in
+// place of one dot for alpha and beta releases, as in 1.2b3. A requirement
+// is a minimum version (1.2 accepts 1.x from 1.2 up), a range with an
+// exclusive upper bound (1.2-2.0), or an open range (1.2-).
+
+// packageState holds the packages provided and available to an interpreter.
+type packageState struct {
+	provided  map[string]string                   // version provided, by package
+	available map[string]map[string]packageLoader // loaders, by package and version
+	indexed   map[string]bool                     // package index files already evaluated
+}
+
+// packageLoader loads one version of a package: by evaluating a script
+// given to package ifneeded, or by calling a function given to
+// RegisterPackage.
+type packageLoader struct {
+	script *Obj
+	init   func(*Interp) error
+}
+
+// registerPackages installs the package command.
+func (i *Interp) registerPackages() {
+	i.packages = &packageState{
+		provided:  make(map[string]string),
+		available: make(map[string]map[string]packageLoader),
+		indexed:   make(map[string]bool),
+	}
+	i.RegisterCommand("package", cmdPackage)
+}
+
+// RegisterPackage makes a package implemented in Go available to package
+// require. init is called the first time a script requires the package,
+// typically to register its commands, so that interpreters only pay for
+// the packages their scripts use. The package is provided at version once
+// init returns, unless init provides it itself; an error from init is the
+// error of package require.
+//
+//	interp.RegisterPackage("geo", "1.0", func(i *feather.Interp) error {
+//	    i.Register("geo::distance", distance)
+//	    return nil
+//	})
+//
+// RegisterPackage panics if version is not a valid version number.
+func (i *Interp) RegisterPackage(name, version string, init func(*Interp) error) {
+	if _, err := parseVersion(version); err != nil {
+		panic(fmt.Sprintf("feather: RegisterPackage(%q, %q): %v", name, version, err))
+	}
+	i.packages.setLoader(name, version, packageLoader{init: init})
+}
+
+// setLoader records how to load version of the package name.
+func (p *packageState) setLoader(name, version string, l packageLoader) {
+	if p.available[name] == nil {
+		p.available[name] = make(map[string]packageLoader)
+	}
+	p.available[name][version] = l
+}
+
+// cmdPackage implements: package option ?arg ...?
+func cmdPackage(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "package option ?arg ...?"`)
+	}
+	sub, args := args[0].String(), args[1:]
+	p := i.packages
+	switch sub {
+	case "forget":
+		for _, name := range args {
+			delete(p.provided, name.String())
+			delete(p.available, name.String())
+		}
+		return OK("")
+	case "ifneeded":
+		return packageIfneeded(i, args)
+	case "names":
+		if len(args) != 0 {
+			return Error(`wrong # args: should be "package names"`)
+		}
+		var names []string
+		for name := range p.provided {
+			names = append(names, name)
+		}
+		for name := range p.available {
+			if _, ok := p.provided[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		return OK(names)
+	case "present", "require":
+		return packageRequire(i, sub, args)
+	case "provide":
+		return packageProvide(i, args)
+	case "vcompare":
+		if len(args) != 2 {
+			return Error(`wrong # args: should be "package vcompare version1 version2"`)
+		}
+		a, err := parseVersion(args[0].String())
+		if err != nil {
+			return Error(err.Error())
+		}
+		b, err := parseVersion(args[1].String())
+		if err != nil {
+			return Error(err.Error())
+		}
+		return OK(compareVersions(a, b))
+	case "versions":
+		if len(args) != 1 {
+			return Error(`wrong # args: should be "package versions package"`)
+		}
+		return OK(sortedVersions(p.available[args[0].String()]))
+	case "vsatisfies":
+		if len(args) < 2 {
+			return Error(`wrong # args: should be "package vsatisfies version ?requirement ...?"`)
+		}
+		v, err := parseVersion(args[0].String())
+		if err != nil {
+			return Error(err.Error())
+		}
+		reqs, err := parseRequirements(args[1:])
+		if err != nil {
+			return Error(err.Error())
+		}
+		return OK(reqs.satisfiedBy(v))
+	}
+	return Errorf(`bad option "%s": must be forget, ifneeded, names, present, provide, require, vcompare, versions, or vsatisfies`, sub)
+}
+
+// packageIfneeded implements: package ifneeded package version ?script?
+func packageIfneeded(i *Interp, args []*Obj) Result {
+	if len(args) != 2 && len(args) != 3 {
+		return Error(`wrong # args: should be "package ifneeded package version ?script?"`)
+	}
+	name, version := args[0].String(), args[1].String()
+	if _, err := parseVersion(version); err != nil {
+		return Error(err.Error())
+	}
+	if len(args) == 3 {
+		i.packages.setLoader(name, version, packageLoader{script: args[2]})
+		return OK("")
+	}
+	if l, ok := i.packages.available[name][version]; ok && l.script != nil {
+		return OK(l.script)
+	}
+	return OK("")
+}
+
+// packageProvide implements: package provide package ?version?
+func packageProvide(i *Interp, args []*Obj) Result {
+	if len(args) != 1 && len(args) != 2 {
+		return Error(`wrong # args: should be "package provide package ?version?"`)
+	}
+	name := args[0].String()
+	if len(args) == 1 {
+		return OK(i.packages.provided[name])
+	}
+	version := args[1].String()
+	if _, err := parseVersion(version); err != nil {
+		return Error(err.Error())
+	}
+	if have, ok := i.packages.provided[name]; ok && have != version {
+		return Errorf(`conflicting versions provided for package "%s": %s, then %s`, name, have, version)
+	}
+	i.packages.provided[name] = version
+	return OK("")
+}
+
+// packageRequire implements package require and package present, which
+// share their arguments.
+func packageRequire(i *Interp, sub string, args []*Obj) Result {
+	usage := fmt.Sprintf(`wrong # args: should be "package %s ?-exact? package ?requirement ...?"`, sub)
+	exact := len(args) > 0 && args[0].String() == "-exact"
+	if exact {
+		args = args[1:]
+		if len(args) != 2 {
+			return Error(usage)
+		}
+	}
+	if len(args) < 1 {
+		return Error(usage)
+	}
+	name, want := args[0].String(), args[1:]
+	reqs, err := parseRequirements(want)
+	if err != nil {
+		return Error(err.Error())
+	}
+	if exact {
+		reqs[0].max, reqs[0].bounded = reqs[0].min, true
+	}
+	// Describe the requirements for error messages as they were given
+	var need []string
+	for _, w := range want {
+		need = append(need, w.String())
+	}
+	desc := name
+	if exact {
+		desc += " exactly " + need[0]
+	} else if len(need) > 0 {
+		desc += " " + strings.Join(need, " ")
+	}
+
+	p := i.packages
+	if have, ok := p.provided[name]; ok {
+		v, _ := parseVersion(have)
+		if !reqs.satisfiedBy(v) {
+			return Errorf(`version conflict for package "%s": have %s, need %s`, name, have, strings.Join(need, " "))
+		}
+		return OK(have)
+	}
+	if sub == "present" {
+		return Errorf("package %s is not present", desc)
+	}
+
+	version, ok := p.best(name, reqs)
+	if !ok {
+		if res := i.searchPackagePath(name); res.code != ResultOK {
+			return res
+		}
+		if have, ok := p.provided[name]; ok {
+			if v, _ := parseVersion(have); reqs.satisfiedBy(v) {
+				return OK(have)
+			}
+		}
+		if version, ok = p.best(name, reqs); !ok {
+			return Errorf("can't find package %s", desc)
+		}
+	}
+	return i.loadPackage(name, version)
+}
+
+// best returns the version of the package name to load for reqs: the
+// highest stable version that satisfies them, or else the highest alpha or
+// beta version that does.
+func (p *packageState) best(name string, reqs versionRequirements) (string, bool) {
+	var best, bestStable []int
+	var version, stableVersion string
+	for s := range p.available[name] {
+		v, _ := parseVersion(s)
+		if !reqs.satisfiedBy(v) {
+			continue
+		}
+		if best == nil || compareVersions(v, best) > 0 {
+			best, version = v, s
+		}
+		if !slices.ContainsFunc(v, func(n int) bool { return n < 0 }) &&
+			(bestStable == nil || compareVersions(v, bestStable) > 0) {
+			bestStable, stableVersion = v, s
+		}
+	}
+	if bestStable != nil {
+		return stableVersion, true
+	}
+	return version, best != nil
+}
+
+// loadPackage loads version of the package name, at the global level and
+// outside any sourced script, and checks that it provided that version.
+func (i *Interp) loadPackage(name, version string) Result {
+	failed := fmt.Sprintf("attempt to provide package %s %s failed", name, version)
+	l := i.packages.available[name][version]
+	res := i.atGlobalLevel(func() Result {
+		if l.init == nil {
+			code := FeatherResult(callCEval(i.handle, i.handleForObj(l.script)))
+			return Result{code: code, obj: i.result, hasObj: true}
+		}
+		if err := l.init(i); err != nil {
+			return Error(err.Error())
+		}
+		if _, ok := i.packages.provided[name]; !ok {
+			i.packages.provided[name] = version
+		}
+		return OK("")
+	})
+	switch res.code {
+	case ResultOK:
+	case ResultError:
+		return res
+	default:
+		return Errorf("%s: bad return code: %d", failed, res.code)
+	}
+
+	have, ok := i.packages.provided[name]
+	if !ok {
+		return Errorf("%s: no version of package %s provided", failed, name)
+	}
+	if have != version {
+		return Errorf("%s: package %s %s provided instead", failed, name, have)
+	}
+	return OK(version)
+}
+
+// searchPackagePath looks for the package name in the directories of
+// auto_path, until one of them makes a version of it available or
+// provides it. Package index files are evaluated once each, with the
+// variable dir set to their directory; a script named after the package is
+// sourced at the global level.
+func (i *Interp) searchPackagePath(name string) Result {
+	path, ok := i.GetVarObj("::auto_path")
+	if !ok {
+		return OK("")
+	}
+	dirs, err := path.List()
+	if err != nil {
+		return Error(err.Error())
+	}
+	p := i.packages
+	found := func() bool {
+		_, provided := p.provided[name]
+		return provided || len(p.available[name]) > 0
+	}
+	for _, d := range dirs {
+		dir := filepath.Clean(d.String())
+		for _, indexDir := range []string{dir, filepath.Join(dir, name)} {
+			index := filepath.Join(indexDir, "pkgIndex.tcl")
+			if p.indexed[index] {
+				continue
+			}
+			if _, err := i.loadSource(index); err != nil {
+				continue
+			}
+			p.indexed[index] = true
+			lambda := i.List(i.String("dir"), i.String("::source "+quote(index)))
+			words := i.List(i.String("::apply"), lambda, i.String(indexDir))
+			res := i.atGlobalLevel(func() Result {
+				code := FeatherResult(callCEval(i.handle, i.handleForObj(words)))
+				return Result{code: code, obj: i.result, hasObj: true}
+			})
+			if res.code != ResultOK {
+				return res
+			}
+		}
+		if found() {
+			return OK("")
+		}
+
+		file := filepath.Join(dir, strings.ReplaceAll(name, "::", "/")+".tcl")
+		text, err := i.loadSource(file)
+		if err != nil {
+			continue
+		}
+		res := i.atGlobalLevel(func() Result {
+			return Result{code: i.evalSource(file, text), obj: i.result, hasObj: true}
+		})
+		if res.code != ResultOK {
+			return res
+		}
+		if found() {
+			return OK("")
+		}
+	}
+	return OK("")
+}
+
+// atGlobalLevel calls fn with the global frame active and outside any
+// sourced script, as packages are loaded whoever requires them.
+func (i *Interp) atGlobalLevel(fn func() Result) Result {
+	savedActive, savedScript := i.active, i.scriptPath
+	i.active, i.scriptPath = 0, nil
+	defer func() { i.active, i.scriptPath = savedActive, savedScript }()
+	return fn()
+}
+
+// parseVersion parses a version number into its components, with a as -2
+// and b as -1 followed by the release number, so that 1.2b3 is {1 2 -1 3}.
+func parseVersion(s string) ([]int, error) {
+	bad := fmt.Errorf("expected version number but got \"%s\"", s)
+	var v []int
+	start := 0
+	for j := 0; j <= len(s); j++ {
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			continue
+		}
+		if j == start {
+			return nil, bad
+		}
+		n, err := strconv.Atoi(s[start:j])
+		if err != nil {
+			return nil, bad
+		}
+		v = append(v, n)
+		if j == len(s) {
+			break
+		}
+		switch s[j] {
+		case '.':
+		case 'a':
+			v = append(v, -2)
+		case 'b':
+			v = append(v, -1)
+		default:
+			return nil, bad
+		}
+		start = j + 1
+	}
+	return v, nil
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, the same as or
+// newer than b. Missing components count as 0, so 1.2 is the same as 1.2.0.
+func compareVersions(a, b []int) int {
+	for j := 0; j < max(len(a), len(b)); j++ {
+		var x, y int
+		if j < len(a) {
+			x = a[j]
+		}
+		if j < len(b) {
+			y = b[j]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// sortedVersions returns the versions in loaders, oldest first.
+func sortedVersions(loaders map[string]packageLoader) []string {
+	versions := make([]string, 0, len(loaders))
+	for v := range loaders {
+		versions = append(versions, v)
+	}
+	slices.SortFunc(versions, func(a, b string) int {
+		x, _ := parseVersion(a)
+		y, _ := parseVersion(b)
+		return compareVersions(x, y)
+	})
+	return versions
+}
+
+// versionRequirement is one requirement of package require or package
+// vsatisfies.
+type versionRequirement struct {
+	min     []int
+	max     []int // exclusive, unless equal to min
+	bounded bool  // max applies; an open range has no upper bound
+}
+
+// versionRequirements is satisfied by a version that satisfies any of its
+// requirements, or by every version if it is empty.
+type versionRequirements []versionRequirement
+
+// parseRequirements parses requirements of the form min, min- or min-max.
+// A bare min accepts versions up to the next major version.
+func parseRequirements(args []*Obj) (versionRequirements, error) {
+	reqs := make(versionRequirements, 0, len(args))
+	for _, arg := range args {
+		s := arg.String()
+		minStr, maxStr, ranged := strings.Cut(s, "-")
+		if strings.Contains(maxStr, "-") {
+			return nil, fmt.Errorf("expected versionMin-versionMax but got \"%s\"", s)
+		}
+		min, err := parseVersion(minStr)
+		if err != nil {
+			return nil, err
+		}
+		r := versionRequirement{min: min, bounded: true}
+		switch {
+		case !ranged:
+			r.max = []int{min[0] + 1}
+		case maxStr == "":
+			r.bounded = false
+		default:
+			if r.max, err = parseVersion(maxStr); err != nil {
+				return nil, err
+			}
+		}
+		reqs = append(reqs, r)
+	}
+	return reqs, nil
+}
+
+// satisfiedBy reports whether the version v satisfies reqs.
+func (reqs versionRequirements) satisfiedBy(v []int) bool {
+	if len(reqs) == 0 {
+		return true
+	}
+	for _, r := range reqs {
+		if compareVersions(v, r.min) < 0 {
+			continue
+		}
+		if !r.bounded {
+			return true
+		}
+		if compareVersions(r.min, r.max) == 0 {
+			if compareVersions(v, r.min) == 0 {
+				return true
+			}
+			continue
+		}
+		if compareVersions(v, r.max) < 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package feather
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// SetRecoverPanics sets whether a panic in a command registered from Go
+// becomes an error of the command, which it does by default. The error has
+// the code GO PANIC, and the Go stack of the panic is in its -errorinfo
+// and so in ::errorInfo, before the commands that were running:
+//
+//	interp.Register("boom", func() { var m map[string]int; m["x"] = 1 })
+//	_, err := interp.Eval("boom")
+//	// err: command "boom" panicked: assignment to entry in nil map
+//
+// With recover false, a panic propagates out of the command and through the
+// C evaluator, which crashes the process. That suits programs that would
+// rather stop than carry on after a bug.
+func (i *Interp) SetRecoverPanics(recover bool) {
+	i.keepPanics = !recover
+}
+
+// invoke calls the Go command fn, turning a panic into an error of the
+// command unless SetRecoverPanics turned that off. A panic must not unwind
+// through the C frames between the evaluator and the command.
+func (i *Interp) invoke(fn InternalCommandFunc, cmd FeatherObj, args []FeatherObj) (code FeatherResult) {
+	if i.keepPanics {
+		return fn(i, cmd, args)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			code = i.commandPanicked(r, string(debug.Stack()), cmd, args)
+		}
+	}()
+	return fn(i, cmd, args)
+}
+
+// commandPanicked sets the error for the command cmd that panicked with r,
+// and adds stack to its -errorinfo.
+func (i *Interp) commandPanicked(r any, stack string, cmd FeatherObj, args []FeatherObj) FeatherResult {
+	msg := fmt.Sprintf("command \"%s\" panicked: %v", i.getString(cmd), r)
+	i.SetErrorString(msg)
+	i.throwError(i.List(i.String("GO"), i.String("PANIC")), cmd, args)
+
+	// The error information starts with the message unless an earlier error
+	// is still being collected
+	if ns := i.namespaces["::tcl::errors"]; ns != nil {
+		if info := ns.vars["info"]; info != nil && strings.HasPrefix(info.String(), msg+"\n") {
+			rest := strings.TrimPrefix(info.String(), msg)
+			ns.vars["info"] = i.String(msg + "\n\n" + strings.TrimRight(stack, "\n") + rest)
+		}
+	}
+	return ResultError
+}
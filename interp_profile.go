@@ -0,0 +1,192 @@
+package feather
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ProfileEntry holds the timings of one command in a [ProfileReport].
+type ProfileEntry struct {
+	Name      string        // fully qualified command name; as written if it did not resolve
+	Kind      string        // "proc", "builtin", or "" if the command did not resolve
+	Calls     int           // number of times the command ran
+	Inclusive time.Duration // time in the command, including the commands it ran
+	Exclusive time.Duration // time in the command, excluding the commands it ran
+}
+
+// ProfileReport is the result of profiling with [Interp.StartProfile] and
+// [Interp.StopProfile].
+type ProfileReport struct {
+	Elapsed time.Duration  // time between StartProfile and StopProfile
+	Entries []ProfileEntry // one per command, by decreasing exclusive time
+}
+
+// String formats the report as a table, one command per line.
+func (r ProfileReport) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "calls\tinclusive\texclusive\t\tcommand\n")
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t\t%s\n", e.Calls, e.Inclusive, e.Exclusive, e.Name)
+	}
+	w.Flush()
+	fmt.Fprintf(&b, "elapsed %s\n", r.Elapsed)
+	return b.String()
+}
+
+// profiler aggregates command timings reported by the evaluation loop.
+type profiler struct {
+	start, stop time.Time // stop is zero while profiling
+	entries     map[string]*ProfileEntry
+	calls       []profileCall  // commands that have not finished, innermost last
+	running     map[string]int // unfinished calls per command
+}
+
+// profileCall is a command that has started but not finished.
+type profileCall struct {
+	entry    *ProfileEntry
+	start    time.Time
+	children time.Duration // inclusive time of the commands it ran
+}
+
+// StartProfile starts timing every command the interpreter runs from a
+// script, discarding the results of any earlier profile. Call
+// [Interp.StopProfile] to stop and get the results.
+//
+// Each command is timed from when the evaluator starts it until it
+// finishes. Time in a proc's body counts towards the proc's inclusive time
+// and towards the commands in the body; a proc's exclusive time is the part
+// not spent in any of them, such as binding arguments. Recursive calls are
+// counted once in inclusive time.
+//
+//	interp.StartProfile()
+//	interp.Eval(script)
+//	fmt.Print(interp.StopProfile())
+func (i *Interp) StartProfile() {
+	now := time.Now()
+	i.profile = &profiler{
+		start:   now,
+		entries: make(map[string]*ProfileEntry),
+		running: make(map[string]int),
+	}
+	i.updateEvalHooks()
+}
+
+// StopProfile stops the profiler started with [Interp.StartProfile] and
+// returns what it measured. If the profiler is not running, it returns the
+// results of the last profile.
+func (i *Interp) StopProfile() ProfileReport {
+	p := i.profile
+	if p == nil {
+		return ProfileReport{}
+	}
+	if p.stop.IsZero() {
+		p.stop = time.Now()
+		p.calls = nil
+		i.updateEvalHooks()
+	}
+	return p.report(p.stop)
+}
+
+// profiling reports whether a profile is running.
+func (i *Interp) profiling() bool {
+	return i.profile != nil && i.profile.stop.IsZero()
+}
+
+// enter records the start of the command name.
+func (p *profiler) enter(i *Interp, name string) {
+	key, kind := name, ""
+	if qualified, cmd := i.resolveCommandName(name); cmd != nil {
+		key, kind = qualified, "builtin"
+		if cmd.cmdType == CmdProc {
+			kind = "proc"
+		}
+	}
+	e := p.entries[key]
+	if e == nil {
+		e = &ProfileEntry{Name: key, Kind: kind}
+		p.entries[key] = e
+	}
+	p.running[key]++
+	p.calls = append(p.calls, profileCall{entry: e, start: time.Now()})
+}
+
+// done records the end of the innermost unfinished command. Commands that
+// started before the profile did are ignored.
+func (p *profiler) done() {
+	now := time.Now()
+	n := len(p.calls)
+	if n == 0 {
+		return
+	}
+	c := p.calls[n-1]
+	p.calls = p.calls[:n-1]
+	d := now.Sub(c.start)
+	e := c.entry
+	e.Calls++
+	e.Exclusive += d - c.children
+	if p.running[e.Name]--; p.running[e.Name] == 0 {
+		e.Inclusive += d
+	}
+	if n > 1 {
+		p.calls[n-2].children += d
+	}
+}
+
+// report returns the timings so far, as of now.
+func (p *profiler) report(now time.Time) ProfileReport {
+	r := ProfileReport{Elapsed: now.Sub(p.start)}
+	for _, e := range p.entries {
+		if e.Calls > 0 {
+			r.Entries = append(r.Entries, *e)
+		}
+	}
+	slices.SortFunc(r.Entries, func(a, b ProfileEntry) int {
+		if c := cmp.Compare(b.Exclusive, a.Exclusive); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return r
+}
+
+// cmdProfile implements the profile command:
+//
+//	profile on
+//	profile off
+//	profile report
+//
+// report returns a list with a dict for each command, with the keys name,
+// kind, calls, inclusive and exclusive; times are in microseconds.
+func cmdProfile(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "profile on|off|report"`)
+	}
+	switch args[0].String() {
+	case "on":
+		i.StartProfile()
+		return OK("")
+	case "off":
+		i.StopProfile()
+		return OK("")
+	case "report":
+		var r ProfileReport
+		switch {
+		case i.profiling():
+			r = i.profile.report(time.Now())
+		case i.profile != nil:
+			r = i.profile.report(i.profile.stop)
+		}
+		entries := make([]*Obj, len(r.Entries))
+		for n, e := range r.Entries {
+			entries[n] = i.DictKV("name", e.Name, "kind", e.Kind, "calls", e.Calls,
+				"inclusive", e.Inclusive.Microseconds(), "exclusive", e.Exclusive.Microseconds())
+		}
+		return OK(i.List(entries...))
+	}
+	return Errorf("bad option \"%s\": must be off, on, or report", args[0].String())
+}
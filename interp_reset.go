@@ -0,0 +1,188 @@
+package feather
+
+import (
+	"maps"
+	"slices"
+)
+
+// Reset returns the interpreter to the state it had before any script ran,
+// so that a pooled interpreter can be recycled between tenants without
+// closing it:
+//
+//	interp.Reset()
+//	pool.Put(interp)
+//
+// Reset destroys foreign objects, calling the Destroy function of their
+// types, and deletes the procs, namespaces, variables, coroutines, objects,
+// traces, after events and bus subscriptions scripts created, including
+// variables set and traces added from Go. Commands installed from Go outside of a script, by
+// [Interp.RegisterCommand], [Interp.Register], [RegisterType] and the
+// like, are kept, along with the builtins; if a script renamed or deleted
+// them, they are restored. Packages registered with [Interp.RegisterPackage]
+// can be required again. Hooks defined with [Interp.Hooks] are kept and
+// the handlers scripts added to them removed. Channels, the bus, the eval
+// hook and the source loader are kept, and env is linked to the environment
+// again.
+//
+// Reset panics if it is called while a script is running.
+func (i *Interp) Reset() {
+	if i.evalDepth > 0 {
+		panic("feather: Reset called while a script is running")
+	}
+	i.resetting = true
+	defer func() { i.resetting = false }()
+
+	i.destroyAllForeign()
+	if reg := i.ForeignRegistry; reg != nil {
+		reg.mu.Lock()
+		for typeName := range reg.counters {
+			reg.counters[typeName] = 1
+		}
+		reg.mu.Unlock()
+	}
+	i.discardTraces()
+
+	// Namespaces created by scripts go first, longest paths first so that
+	// children are gone before their parents
+	paths := slices.Collect(maps.Keys(i.namespaces))
+	slices.SortFunc(paths, func(a, b string) int { return len(b) - len(a) })
+	for _, path := range paths {
+		ns, ok := i.namespaces[path]
+		if !ok || path == "::" {
+			continue
+		}
+		if _, kept := i.baseline[path]; !kept {
+			i.deleteNamespace(ns)
+		}
+	}
+	for path, cmds := range i.baseline {
+		ns := i.ensureNamespace(path)
+		for name, cmd := range ns.commands {
+			if cmds[name] != cmd {
+				i.deleteCommand(ns, name)
+			}
+		}
+		for name, cmd := range cmds {
+			if ns.commands[name] != cmd {
+				i.setCommand(ns, name, cmd)
+			}
+		}
+		ns.vars = copyVars(i.baseVars[path])
+	}
+	// The command paths scripts set went with ::tcl::namespace::path
+	i.core.paths = 0
+	for co := range i.coroutines {
+		i.killCoroutine(co)
+	}
+
+	global := i.frames[0]
+	global.links = make(map[string]varLink)
+	global.ns = i.globalNamespace
+	global.line, global.file = 0, nil
+	i.result, i.returnOptions, i.scriptPath = nil, nil, nil
+	clear(i.nprocSpecs)
+	clear(i.nprocTraces)
+
+	i.cancelFutures()
+	i.leaveBus()
+	q := i.events
+	q.mu.Lock()
+	q.timers, q.idle = nil, nil
+	clear(q.vwaits)
+	q.mu.Unlock()
+
+	p := i.packages
+	clear(p.provided)
+	clear(p.indexed)
+	for name, loaders := range p.available {
+		maps.DeleteFunc(loaders, func(_ string, l packageLoader) bool { return l.init == nil })
+		if len(loaders) == 0 {
+			delete(p.available, name)
+		}
+	}
+	i.hooks.reset()
+	i.bindEnv()
+	i.resetScratch()
+}
+
+// recordBaseline takes the state New leaves the interpreter in as the
+// state Reset returns it to.
+func (i *Interp) recordBaseline() {
+	for path, ns := range i.namespaces {
+		if i.baseline[path] == nil {
+			i.baseline[path] = make(map[string]*Command)
+		}
+		i.baseVars[path] = copyVars(ns.vars)
+	}
+}
+
+// recordCommand notes a change to the command name in ns made by the host,
+// outside of any script, in the commands Reset restores.
+func (i *Interp) recordCommand(ns *Namespace, name string) {
+	if (i.evalDepth > 0 && i.hostChanges == 0) || i.resetting {
+		return
+	}
+	cmds := i.baseline[ns.fullPath]
+	if cmds == nil {
+		cmds = make(map[string]*Command)
+		i.baseline[ns.fullPath] = cmds
+	}
+	if cmd, ok := ns.commands[name]; ok {
+		cmds[name] = cmd
+	} else {
+		delete(cmds, name)
+	}
+}
+
+// asHost calls fn, which changes commands through scripts on behalf of a
+// Go API, so that Reset keeps its changes as it keeps those the API makes
+// directly. Ensembles keep their configuration in ::tcl::ensemble, which is
+// kept too.
+func (i *Interp) asHost(fn func()) {
+	if i.evalDepth > 0 {
+		fn()
+		return
+	}
+	i.hostChanges++
+	fn()
+	i.hostChanges--
+	if ns := i.namespaces["::tcl::ensemble"]; ns != nil {
+		i.baseVars[ns.fullPath] = copyVars(ns.vars)
+		if i.baseline[ns.fullPath] == nil {
+			i.baseline[ns.fullPath] = make(map[string]*Command)
+		}
+	}
+}
+
+// discardTraces removes every variable, command and execution trace.
+func (i *Interp) discardTraces() {
+	ns := i.namespaces["::tcl::trace"]
+	if ns == nil {
+		return
+	}
+	for _, kind := range []string{"variable", "command", "execution"} {
+		d, err := asDict(ns.vars[kind])
+		if err != nil || d == nil {
+			continue
+		}
+		n := 0
+		for _, traces := range d.Items {
+			if items, err := traces.List(); err == nil {
+				n += len(items)
+			}
+		}
+		if n > 0 {
+			callCTracesCount(i.handle, kind, -n)
+		}
+	}
+}
+
+// copyVars returns a copy of vars with copies of their values, so that
+// values changed in place in one are not changed in the other.
+func copyVars(vars map[string]*Obj) map[string]*Obj {
+	c := make(map[string]*Obj, len(vars))
+	for name, v := range vars {
+		c[name] = v.Clone()
+	}
+	return c
+}
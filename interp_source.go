@@ -0,0 +1,193 @@
+package feather
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The source command evaluates the script in a file in the current frame:
+//
+//	source fileName
+//
+// The script is read with the interpreter's source loader, which reads
+// files from disk unless [Interp.SetSourceLoader] replaces it. While the
+// script runs, info script returns its file name, and a relative fileName
+// given to a nested source is resolved against that file's directory.
+
+// registerSource installs the source command.
+func (i *Interp) registerSource() {
+	i.RegisterCommand("source", cmdSource)
+}
+
+// SetSourceLoader sets the function the source command uses to read
+// scripts, so that they can come from an [embed.FS], a database or over
+// HTTP instead of from disk. The loader is given the path after relative
+// paths have been resolved against the directory of the sourcing script.
+// A nil loader restores the default, which reads files with [os.ReadFile].
+//
+//	interp.SetSourceLoader(func(path string) (string, error) {
+//	    data, err := scripts.ReadFile(path)
+//	    return string(data), err
+//	})
+func (i *Interp) SetSourceLoader(load func(path string) (string, error)) {
+	i.sourceLoader = load
+}
+
+// SourceFile evaluates the script at path as the source command does, so
+// that info script and nested source commands see its file name.
+func (i *Interp) SourceFile(path string) (*Obj, error) {
+	return i.EvalObj(i.List(i.String("::source"), i.String(path)))
+}
+
+// readSourceFile is the default source loader.
+func readSourceFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// cmdSource implements: source fileName
+func cmdSource(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 1 {
+		return Error(`wrong # args: should be "source fileName"`)
+	}
+	name := args[0].String()
+	path := name
+	if !filepath.IsAbs(path) && i.scriptPath != nil && i.scriptPath.String() != "" {
+		path = filepath.Join(filepath.Dir(i.scriptPath.String()), path)
+	}
+	text, err := i.loadSource(path)
+	if err != nil {
+		// Report the reason without repeating the path, as TCL does
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return Errorf(`couldn't read file "%s": %v`, name, err)
+	}
+	code := i.evalSource(path, text)
+	return Result{code: code, obj: i.result, hasObj: true}
+}
+
+// loadSource reads the script at path with the source loader.
+func (i *Interp) loadSource(path string) (string, error) {
+	if i.sourceLoader == nil {
+		return readSourceFile(path)
+	}
+	return i.sourceLoader(path)
+}
+
+// evalSource evaluates text, read from path, in the current frame, with
+// info script returning path while it runs.
+func (i *Interp) evalSource(path, text string) FeatherResult {
+	file := i.String(path)
+	script := &Obj{bytes: text, interp: i, source: &sourceLoc{file: file, line: 1}}
+	saved := i.scriptPath
+	i.scriptPath = file
+	code := FeatherResult(callCEval(i.handle, i.handleForObj(script)))
+	i.scriptPath = saved
+	if code == ResultReturn {
+		code = i.sourceReturn()
+	}
+	return code
+}
+
+// sourceReturn applies the options of a return from the top level of a
+// sourced script, which ends the script as it would end a proc body.
+func (i *Interp) sourceReturn() FeatherResult {
+	code, level := ResultOK, int64(1)
+	if i.returnOptions != nil {
+		items, _ := i.returnOptions.List()
+		for j := 0; j+1 < len(items); j += 2 {
+			switch items[j].String() {
+			case "-code":
+				if v, err := asInt(items[j+1]); err == nil {
+					code = FeatherResult(v)
+				}
+			case "-level":
+				if v, err := asInt(items[j+1]); err == nil {
+					level = v
+				}
+			}
+		}
+	}
+	if level--; level <= 0 {
+		return code
+	}
+	i.returnOptions = i.List(i.String("-code"), i.Int(int64(code)), i.String("-level"), i.Int(level))
+	return ResultReturn
+}
+
+// ScriptBundle is a tree of TCL scripts shipped inside the program, such
+// as a library embedded with go:embed. Create one with [Bundle].
+type ScriptBundle struct {
+	fsys fs.FS
+	root string
+}
+
+// Bundle returns the scripts under the directory root of fsys, so that an
+// application built as a single binary can carry its TCL libraries:
+//
+//	//go:embed lib
+//	var lib embed.FS
+//
+//	feather.Bundle(lib, "lib").Install(interp)
+//
+// Scripts keep their paths within fsys, such as lib/mylib/mylib.tcl, which
+// is what info script returns while they run.
+func Bundle(fsys fs.FS, root string) *ScriptBundle {
+	return &ScriptBundle{fsys: fsys, root: path.Clean(filepath.ToSlash(root))}
+}
+
+// Install makes the bundle's scripts available to source and package
+// require in i. Paths under the bundle's root are read from the bundle,
+// and others from the source loader installed before, so bundles can be
+// layered over files on disk. The root is added to auto_path, so packages
+// in the bundle are found by package require.
+func (b *ScriptBundle) Install(i *Interp) {
+	next := i.sourceLoader
+	if next == nil {
+		next = readSourceFile
+	}
+	i.SetSourceLoader(func(p string) (string, error) {
+		if name, ok := b.name(p); ok {
+			data, err := fs.ReadFile(b.fsys, name)
+			if err == nil {
+				return string(data), nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+		return next(p)
+	})
+
+	var dirs []*Obj
+	if autoPath, ok := i.GetVarObj("::auto_path"); ok {
+		dirs, _ = autoPath.List()
+	}
+	for _, d := range dirs {
+		if d.String() == b.root {
+			return
+		}
+	}
+	dirs = append(slices.Clip(dirs), i.String(b.root))
+	i.SetVarObj("::auto_path", i.List(dirs...))
+}
+
+// name returns the name in the bundle's file system of the script at p,
+// and whether p is under the bundle's root.
+func (b *ScriptBundle) name(p string) (string, bool) {
+	name := path.Clean(filepath.ToSlash(p))
+	if !fs.ValidPath(name) {
+		return "", false
+	}
+	if b.root != "." && name != b.root && !strings.HasPrefix(name, b.root+"/") {
+		return "", false
+	}
+	return name, true
+}
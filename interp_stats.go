@@ -0,0 +1,164 @@
+package feather
+
+// Stats describes the storage an interpreter keeps besides the Go values
+// scripts and commands hold, for finding leaks in long-running
+// interpreters. [Interp.Stats] returns it.
+//
+// The C core allocates no memory of its own. Objects it works on live in
+// one of two arenas: the permanent one, for objects that outlive an eval,
+// and the scratch arena, which is emptied when the outermost eval returns.
+// Each coroutine has a scratch arena of its own, emptied when it finishes.
+// Strings interned often, such as "", "0", "1" and the names of the
+// builtin commands, have one object each in a table of literals instead,
+// which New fills and which never changes.
+// The strings it reads are Go memory pinned for it until the scratch arena
+// is emptied; CBytesAllocated and CBytesFreed count those bytes.
+type Stats struct {
+	PermanentObjects int // objects in the permanent arena
+	InternedStrings  int // permanent objects that hold only a string
+	ScratchObjects   int // objects in the scratch arenas, the coroutines' included
+	Literals         int // objects in the table of literals
+	Builders         int // string builders the C core is using
+
+	CBytesAllocated uint64 // bytes lent to the C core since New
+	CBytesFreed     uint64 // bytes the C core has given back since New
+
+	ForeignInstances int // foreign objects that have not been destroyed
+}
+
+// Stats returns the counts of the objects in each arena, the bytes lent to
+// the C core and the number of live foreign objects. After an eval the
+// scratch arena is empty and every byte lent has been given back, so a
+// count that keeps growing from one eval to the next points at a leak:
+//
+//	before := interp.Stats()
+//	interp.Eval(script)
+//	if after := interp.Stats(); after.PermanentObjects > before.PermanentObjects {
+//	    log.Printf("script left %d objects behind", after.PermanentObjects-before.PermanentObjects)
+//	}
+func (i *Interp) Stats() Stats {
+	s := Stats{
+		PermanentObjects: i.objects.live(),
+		ScratchObjects:   i.scratch.live(),
+		Literals:         i.literals.objs.live(),
+		Builders:         i.builders.live(),
+		CBytesAllocated:  i.pins.lent,
+		CBytesFreed:      i.pins.released,
+		ForeignInstances: len(i.ForeignInstances()),
+	}
+	for co := range i.coroutines {
+		s.ScratchObjects += co.scratch.live()
+		s.CBytesAllocated += co.pins.lent
+		s.CBytesFreed += co.pins.released
+	}
+	for _, obj := range i.objects.slots {
+		if obj != nil && obj.intrep == nil {
+			s.InternedStrings++
+		}
+	}
+	return s
+}
+
+// ObjectCount returns the number of objects the interpreter has handed to
+// the C core, in the permanent and scratch arenas together.
+func (i *Interp) ObjectCount() int {
+	return i.objects.live() + i.scratch.live()
+}
+
+// ScratchSize returns the number of objects in the scratch arena. It is
+// zero once the outermost eval returns; suspended coroutines keep their
+// objects in arenas of their own.
+func (i *Interp) ScratchSize() int {
+	return i.scratch.live()
+}
+
+// CompactPermanent releases the objects in the permanent arena that
+// nothing refers to any more, and returns how many it released. An object
+// is kept if it is the value of a variable or is part of one, the name,
+// parameters or body of a procedure, on the call stack, the interpreter's
+// result, or a foreign object in the registry. Handles to the objects
+// released, such as those [Interp.NewForeignHandle] returned, become
+// invalid.
+//
+// Nothing is released while a script is being evaluated or a coroutine is
+// suspended, since the C core may still hold handles.
+func (i *Interp) CompactPermanent() int {
+	if i.closed || i.evalDepth > 0 || len(i.coroutines) > 0 {
+		return 0
+	}
+	keep := make(map[FeatherObj]bool)
+	keep[i.globalNS] = true
+	if reg := i.ForeignRegistry; reg != nil {
+		reg.mu.RLock()
+		for _, instance := range reg.instances {
+			keep[instance.objHandle] = true
+		}
+		for h := range reg.handleToType {
+			keep[h] = true
+		}
+		reg.mu.RUnlock()
+	}
+
+	reached := make(map[*Obj]bool)
+	var mark func(obj *Obj)
+	mark = func(obj *Obj) {
+		if obj == nil || reached[obj] {
+			return
+		}
+		reached[obj] = true
+		switch rep := obj.intrep.(type) {
+		case ListType:
+			for _, item := range rep {
+				mark(item)
+			}
+		case *DictType:
+			for _, item := range rep.Items {
+				mark(item)
+			}
+		}
+	}
+	markCommand := func(cmd *Command) {
+		if cmd != nil && cmd.proc != nil {
+			mark(cmd.proc.name)
+			mark(cmd.proc.params)
+			mark(cmd.proc.body)
+		}
+	}
+	for _, ns := range i.namespaces {
+		for _, v := range ns.vars {
+			mark(v)
+		}
+		for _, cmd := range ns.commands {
+			markCommand(cmd)
+		}
+	}
+	for _, cmd := range i.hidden {
+		markCommand(cmd)
+	}
+	for _, frame := range i.frames {
+		mark(frame.cmd)
+		mark(frame.args)
+		mark(frame.file)
+		mark(frame.lambda)
+		mark(frame.word)
+		if frame.locals != nil {
+			for _, v := range frame.locals.vars {
+				mark(v)
+			}
+		}
+	}
+	mark(i.result)
+	mark(i.returnOptions)
+	mark(i.scriptPath)
+
+	released := 0
+	for n, obj := range i.objects.slots {
+		h := FeatherObj(n+1) | i.objects.tag
+		if obj == nil || keep[h] || reached[obj] {
+			continue
+		}
+		i.objects.release(h)
+		released++
+	}
+	return released
+}
@@ -0,0 +1,56 @@
+package feather
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/src
+#include "feather.h"
+#include "host.h"
+*/
+import "C"
+
+// SubstFlags selects the substitutions [Interp.Subst] performs. Combine
+// them with |.
+type SubstFlags int
+
+const (
+	// SubstBackslashes replaces backslash sequences such as \n and \x41.
+	SubstBackslashes SubstFlags = C.TCL_SUBST_BACKSLASHES
+	// SubstVariables replaces $name and ${name} with the variable's value.
+	SubstVariables SubstFlags = C.TCL_SUBST_VARIABLES
+	// SubstCommands replaces [script] with the result of running script.
+	SubstCommands SubstFlags = C.TCL_SUBST_COMMANDS
+	// SubstAll performs every substitution, as subst does by default.
+	SubstAll SubstFlags = C.TCL_SUBST_ALL
+)
+
+// Subst performs the substitutions selected by flags on s, as the subst
+// command does with the switches that turn the others off, and returns
+// the result. Variables are read in the current frame.
+//
+// Without [SubstCommands], no command runs: brackets are kept as they are,
+// including in the index of a name like $a([cmd]). This makes Subst with
+// [SubstVariables] a safe way to fill user-supplied text with variables:
+//
+//	interp.SetVar("name", "world")
+//	out, _ := interp.Subst("hello $name [exec rm -rf /]", feather.SubstVariables)
+//	// out.String() == "hello world [exec rm -rf /]"
+//
+// Reading a variable that does not exist returns an [*EvalError], as do
+// errors in command substitutions.
+func (i *Interp) Subst(s string, flags SubstFlags) (*Obj, error) {
+	if err := i.checkOpen(); err != nil {
+		return nil, err
+	}
+	i.evalDepth++
+	defer func() {
+		i.evalDepth--
+		if i.evalDepth == 0 && i.currentCoroutine == nil {
+			i.resetScratch()
+		}
+	}()
+	str := i.internStringScratch(s)
+	code := C.feather_subst_string(nil, C.FeatherInterp(i.handle), C.FeatherObj(str), C.int(flags&SubstAll))
+	if code != C.TCL_OK {
+		return nil, i.evalError(str)
+	}
+	return i.result, nil
+}
@@ -0,0 +1,498 @@
+package feather
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The tcltest package runs test suites written for TCL's tcltest:
+//
+//	package require tcltest
+//	namespace import ::tcltest::*
+//
+//	testConstraint bigMemory 0
+//	test add-1.1 {adds two numbers} -body {
+//	    expr {1 + 2}
+//	} -result 3
+//	test add-1.2 {error for a word} -body {
+//	    expr {1 + "one"}
+//	} -returnCodes error -match glob -result {can't use non-numeric*}
+//
+//	cleanupTests
+//
+// It provides these commands in the ::tcltest namespace:
+//
+//	test name description ?-option value ...?
+//	test name description ?constraints? body result
+//	testConstraint constraint ?boolean?
+//	cleanupTests
+//
+// test runs the -setup, -body and -cleanup scripts in the caller's frame.
+// It passes if the body completes with one of -returnCodes (ok and return
+// unless given; error, break, continue or a number) and its result matches
+// -result as -match says: exact, the default, glob or regexp. When the body
+// fails with an error, -errorCode is matched against its error code as by
+// string match. -output and -errorOutput, when given, are matched against
+// what the body writes to stdout and stderr. The second form is tcltest's
+// older one, which compares the result exactly.
+//
+// A test is skipped unless all of its -constraints are true. The
+// constraints are a list of names set with testConstraint, or an expression
+// in them such as {unix && !knownBug}; names never set are false. unix,
+// win, nonPortable and singleTestInterp start true, and knownBug, emptyTest,
+// interactive and userInteraction false.
+//
+// A failing test prints tcltest's report of what went wrong to stdout.
+// cleanupTests prints the number of tests run, passed, skipped and failed
+// since it was last called, and the constraints that skipped them.
+// [RunTestFile] runs a file of tests and returns their results to Go.
+
+// TestStatus is the outcome of a test run by the tcltest package.
+type TestStatus int
+
+const (
+	TestPassed TestStatus = iota
+	TestSkipped
+	TestFailed
+)
+
+func (s TestStatus) String() string {
+	switch s {
+	case TestPassed:
+		return "passed"
+	case TestSkipped:
+		return "skipped"
+	case TestFailed:
+		return "failed"
+	}
+	return "TestStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// TestResult is the result of one test run by the tcltest package's test
+// command.
+type TestResult struct {
+	Name        string
+	Description string
+	Status      TestStatus
+	SkippedBy   string // for a skipped test, the constraint that was not satisfied
+	Report      string // for a failed test, the report test printed
+}
+
+// TestReport holds the results of the tests in a file run by
+// [RunTestFile], in the order they ran.
+type TestReport struct {
+	File  string
+	Tests []TestResult
+}
+
+// Count returns the number of tests with the given status.
+func (r *TestReport) Count(status TestStatus) int {
+	n := 0
+	for _, t := range r.Tests {
+		if t.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// RunTestFile sources the tcltest suite at path and returns the results
+// of its tests, so that a Go test can run a TCL test suite and report each
+// of its tests:
+//
+//	report, err := feather.RunTestFile(interp, "tests/parse.test")
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	for _, r := range report.Tests {
+//	    if r.Status == feather.TestFailed {
+//	        t.Errorf("%s: %s", r.Name, r.Report)
+//	    }
+//	}
+//
+// The commands of the tcltest package are imported into the global
+// namespace first, so the file need not require the package itself. If the
+// file fails outside of a test, RunTestFile returns the error along with
+// the results of the tests that ran.
+func RunTestFile(i *Interp, path string) (*TestReport, error) {
+	if _, err := i.Eval("package require tcltest; namespace import ::tcltest::*"); err != nil {
+		return nil, err
+	}
+	start := len(i.tcltest.results)
+	_, err := i.SourceFile(path)
+	return &TestReport{File: path, Tests: slices.Clone(i.tcltest.results[start:])}, err
+}
+
+// tcltestState holds the constraints of the tcltest package and the
+// results of the tests it has run.
+type tcltestState struct {
+	constraints map[string]bool
+	results     []TestResult
+	reported    int // results before this one were counted by cleanupTests
+}
+
+// tcltestOptions are the options of test, for GetIndexFromObj.
+var tcltestOptions = []string{"-body", "-cleanup", "-constraints", "-errorCode", "-errorOutput",
+	"-match", "-output", "-result", "-returnCodes", "-setup"}
+
+// tcltestCodes are the names of return codes that -returnCodes accepts.
+var tcltestCodes = map[string]string{"ok": "0", "error": "1", "return": "2", "break": "3", "continue": "4"}
+
+// tcltestConstraintExpr matches constraints that are an expression rather
+// than a list of names, and tcltestConstraintWord the names in one.
+var (
+	tcltestConstraintExpr = regexp.MustCompile(`[^.:_a-zA-Z0-9 \n\r\t]`)
+	tcltestConstraintWord = regexp.MustCompile(`[.\w]+`)
+)
+
+// tcltestSpec is what a test command asks for.
+type tcltestSpec struct {
+	name, description    string
+	constraints          string
+	setup, body, cleanup *Obj // nil = none
+	result, match        string
+	errorCode            string
+	returnCodes          []string
+	output, errorOutput  *string
+}
+
+// registerTcltest makes the tcltest package available to package require.
+func (i *Interp) registerTcltest() {
+	i.RegisterPackage("tcltest", "2.5", loadTcltest)
+}
+
+// loadTcltest installs the commands of the tcltest package.
+func loadTcltest(i *Interp) error {
+	i.tcltest = &tcltestState{constraints: map[string]bool{
+		"unix":             runtime.GOOS != "windows",
+		"win":              runtime.GOOS == "windows",
+		"nonPortable":      true,
+		"singleTestInterp": true,
+		"knownBug":         false,
+		"emptyTest":        false,
+		"interactive":      false,
+		"userInteraction":  false,
+	}}
+	ns := i.ensureNamespace("::tcltest")
+	i.setCommand(ns, "test", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(cmdTest)})
+	i.setCommand(ns, "testConstraint", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(cmdTestConstraint)})
+	i.setCommand(ns, "cleanupTests", &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(cmdCleanupTests)})
+	ns.exportPatterns = []string{"test", "testConstraint", "cleanupTests"}
+	return nil
+}
+
+// cmdTest implements: test name description ?-option value ...?
+func cmdTest(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 3 {
+		return Error(`wrong # args: should be "test name description ?-option value ...?"`)
+	}
+	spec := tcltestSpec{
+		name:        args[0].String(),
+		description: args[1].String(),
+		match:       "exact",
+		errorCode:   "*",
+		returnCodes: []string{"0", "2"},
+	}
+	args = args[2:]
+	if !slices.Contains(tcltestOptions, args[0].String()) {
+		// The older form: test name description ?constraints? body result
+		switch len(args) {
+		case 3:
+			spec.constraints, args = args[0].String(), args[1:]
+			fallthrough
+		case 2:
+			spec.body, spec.result = args[0], args[1].String()
+		default:
+			return Error(`wrong # args: should be "test name description ?constraints? body result"`)
+		}
+		return i.runTest(spec)
+	}
+	if len(args)%2 != 0 {
+		return Error(`wrong # args: should be "test name description ?-option value ...?"`)
+	}
+	for ; len(args) > 0; args = args[2:] {
+		opt, err := i.GetIndexFromObj(args[0], tcltestOptions, "option")
+		if err != nil {
+			return Error(err.Error())
+		}
+		value := args[1].String()
+		switch tcltestOptions[opt] {
+		case "-body":
+			spec.body = args[1]
+		case "-cleanup":
+			spec.cleanup = args[1]
+		case "-constraints":
+			spec.constraints = value
+		case "-errorCode":
+			spec.errorCode = value
+		case "-errorOutput":
+			spec.errorOutput = &value
+		case "-match":
+			if value != "exact" && value != "glob" && value != "regexp" {
+				return Errorf(`bad -match value "%s": must be exact, glob, or regexp`, value)
+			}
+			spec.match = value
+		case "-output":
+			spec.output = &value
+		case "-result":
+			spec.result = value
+		case "-returnCodes":
+			codes, err := args[1].List()
+			if err != nil {
+				return Error(err.Error())
+			}
+			spec.returnCodes = spec.returnCodes[:0:0]
+			for _, c := range codes {
+				if n, ok := tcltestCodes[c.String()]; ok {
+					spec.returnCodes = append(spec.returnCodes, n)
+				} else {
+					spec.returnCodes = append(spec.returnCodes, c.String())
+				}
+			}
+		case "-setup":
+			spec.setup = args[1]
+		}
+	}
+	return i.runTest(spec)
+}
+
+// runTest runs the test spec, records its result and prints a report if it
+// fails.
+func (i *Interp) runTest(spec tcltestSpec) Result {
+	t := i.tcltest
+	res := TestResult{Name: spec.name, Description: spec.description, Status: TestPassed}
+	if by, ok := i.testSkipped(spec.constraints); ok {
+		res.Status, res.SkippedBy = TestSkipped, by
+		t.results = append(t.results, res)
+		return OK("")
+	}
+
+	var report strings.Builder
+	failed := false
+	fail := func(format string, args ...any) {
+		failed = true
+		fmt.Fprintf(&report, format, args...)
+	}
+	var code FeatherResult
+	var result string
+	var evalErr *EvalError
+	var outBuf, errBuf bytes.Buffer
+	setupCode, setupMsg, setupErr := i.testEval(spec.setup)
+	if setupCode != ResultOK {
+		fail("---- Test setup failed:\n%s\n", setupMsg)
+		if setupErr != nil {
+			fail("---- errorInfo(setup): %s\n---- errorCode(setup): %s\n", setupErr.ErrorInfo, setupErr.ErrorCode)
+		}
+	} else {
+		if spec.output != nil || spec.errorOutput != nil {
+			restoreOut := i.redirectChannel("stdout", &outBuf, BufferLine)
+			restoreErr := i.redirectChannel("stderr", &errBuf, BufferNone)
+			code, result, evalErr = i.testEval(spec.body)
+			restoreOut()
+			restoreErr()
+		} else {
+			code, result, evalErr = i.testEval(spec.body)
+		}
+	}
+	cleanupCode, cleanupMsg, cleanupErr := i.testEval(spec.cleanup)
+
+	if setupCode == ResultOK {
+		if !slices.Contains(spec.returnCodes, strconv.Itoa(int(code))) {
+			fail("---- %s; Return code was: %d\n---- Return code should have been one of: %s\n",
+				tcltestCodeMessage(code), code, strings.Join(spec.returnCodes, " "))
+			if evalErr != nil {
+				fail("---- errorInfo: %s\n---- errorCode: %s\n", evalErr.ErrorInfo, evalErr.ErrorCode)
+			}
+		} else {
+			if ok, err := testMatch(spec.match, spec.result, result); err != nil {
+				fail("---- Error testing result: %s\n", err)
+			} else if !ok {
+				fail("---- Result was:\n%s\n---- Result should have been (%s matching):\n%s\n", result, spec.match, spec.result)
+			}
+			if evalErr != nil && !globMatchName(spec.errorCode, evalErr.ErrorCode) {
+				fail("---- Error code was: '%s'\n---- Error code should have been: '%s'\n", evalErr.ErrorCode, spec.errorCode)
+			}
+		}
+		if spec.output != nil {
+			if ok, err := testMatch(spec.match, *spec.output, outBuf.String()); err != nil || !ok {
+				fail("---- Output was:\n%s\n---- Output should have been (%s matching):\n%s\n", outBuf.String(), spec.match, *spec.output)
+			}
+		}
+		if spec.errorOutput != nil {
+			if ok, err := testMatch(spec.match, *spec.errorOutput, errBuf.String()); err != nil || !ok {
+				fail("---- Error output was:\n%s\n---- Error output should have been (%s matching):\n%s\n", errBuf.String(), spec.match, *spec.errorOutput)
+			}
+		}
+	}
+	if cleanupCode != ResultOK {
+		fail("---- Test cleanup failed:\n%s\n", cleanupMsg)
+		if cleanupErr != nil {
+			fail("---- errorInfo(cleanup): %s\n---- errorCode(cleanup): %s\n", cleanupErr.ErrorInfo, cleanupErr.ErrorCode)
+		}
+	}
+
+	if failed {
+		body := ""
+		if spec.body != nil {
+			body = spec.body.String()
+		}
+		res.Status = TestFailed
+		res.Report = fmt.Sprintf("\n\n==== %s %s FAILED\n==== Contents of test case:\n%s\n%s==== %s FAILED\n\n",
+			spec.name, strings.TrimSpace(spec.description), body, report.String(), spec.name)
+		fmt.Fprint(i.Stdout(), res.Report)
+	}
+	t.results = append(t.results, res)
+	return OK("")
+}
+
+// testEval evaluates script in the current frame, as catch would, for the
+// test command. It returns the completion code, the result and, for an
+// error, the error with its error information.
+func (i *Interp) testEval(script *Obj) (FeatherResult, string, *EvalError) {
+	if script == nil {
+		return ResultOK, "", nil
+	}
+	h := i.handleForObj(script)
+	code := FeatherResult(callCEval(i.handle, h))
+	if code == ResultError {
+		err := i.evalError(h)
+		return code, err.Message, err
+	}
+	return code, i.resultString(), nil
+}
+
+// tcltestCodeMessage describes a return code, for the report of a test
+// that completed with the wrong one.
+func tcltestCodeMessage(code FeatherResult) string {
+	switch code {
+	case ResultOK:
+		return "Test completed normally"
+	case ResultError:
+		return "Test generated error"
+	case ResultReturn:
+		return "Test generated return exception"
+	case ResultBreak:
+		return "Test generated break exception"
+	case ResultContinue:
+		return "Test generated continue exception"
+	}
+	return "Test generated exception"
+}
+
+// testMatch reports whether actual matches expected in the given -match
+// mode.
+func testMatch(mode, expected, actual string) (bool, error) {
+	switch mode {
+	case "glob":
+		return globMatchName(expected, actual), nil
+	case "regexp":
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("couldn't compile regular expression pattern: %v", err)
+		}
+		return re.MatchString(actual), nil
+	}
+	return expected == actual, nil
+}
+
+// testSkipped reports whether a test with the given constraints is
+// skipped, and the constraint that skips it: the first name that is not
+// true, or the whole expression.
+func (i *Interp) testSkipped(constraints string) (string, bool) {
+	t := i.tcltest
+	if strings.TrimSpace(constraints) == "" {
+		return "", false
+	}
+	var expr string
+	switch {
+	case strings.ContainsAny(constraints, "$["):
+		expr = constraints
+	case tcltestConstraintExpr.MatchString(constraints):
+		expr = tcltestConstraintWord.ReplaceAllStringFunc(constraints, func(name string) string {
+			if t.constraints[name] {
+				return "1"
+			}
+			return "0"
+		})
+	default:
+		names, err := i.String(constraints).List()
+		if err != nil {
+			return constraints, true
+		}
+		for _, name := range names {
+			if !t.constraints[name.String()] {
+				return name.String(), true
+			}
+		}
+		return "", false
+	}
+	var ok bool
+	i.atGlobalLevel(func() Result {
+		if v, err := i.Call("expr", expr); err == nil {
+			ok, _ = v.Bool()
+		}
+		return OK("")
+	})
+	return constraints, !ok
+}
+
+// cmdTestConstraint implements: testConstraint constraint ?boolean?
+func cmdTestConstraint(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 || len(args) > 2 {
+		return Error(`wrong # args: should be "testConstraint constraint ?boolean?"`)
+	}
+	t := i.tcltest
+	name := args[0].String()
+	if len(args) == 2 {
+		v, err := args[1].Bool()
+		if err != nil {
+			return Error(err.Error())
+		}
+		t.constraints[name] = v
+	}
+	return OK(t.constraints[name])
+}
+
+// cmdCleanupTests implements: cleanupTests
+func cmdCleanupTests(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) != 0 {
+		return Error(`wrong # args: should be "cleanupTests"`)
+	}
+	t := i.tcltest
+	var passed, skipped, failed int
+	skippedBy := make(map[string]int)
+	for _, r := range t.results[t.reported:] {
+		switch r.Status {
+		case TestPassed:
+			passed++
+		case TestSkipped:
+			skipped++
+			skippedBy[r.SkippedBy]++
+		case TestFailed:
+			failed++
+		}
+	}
+	t.reported = len(t.results)
+
+	file := ""
+	if i.scriptPath != nil && i.scriptPath.String() != "" {
+		file = filepath.Base(i.scriptPath.String())
+	}
+	out := i.Stdout()
+	fmt.Fprintf(out, "%s:\tTotal\t%d\tPassed\t%d\tSkipped\t%d\tFailed\t%d\n",
+		file, passed+skipped+failed, passed, skipped, failed)
+	if len(skippedBy) > 0 {
+		fmt.Fprintln(out, "Number of tests skipped for each constraint:")
+		for _, c := range slices.Sorted(maps.Keys(skippedBy)) {
+			fmt.Fprintf(out, "\t%d\t%s\n", skippedBy[c], c)
+		}
+	}
+	return OK("")
+}
@@ -0,0 +1,104 @@
+package feather
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout is the error [Interp.EvalTimeout] returns, wrapped in an
+// [*EvalError], when a script runs past its deadline:
+//
+//	if errors.Is(err, feather.ErrTimeout) {
+//	    log.Print("script took too long")
+//	}
+var ErrTimeout = errors.New("feather: time limit exceeded")
+
+// Is reports whether the error stopped a script that ran past the deadline
+// of [Interp.EvalTimeout], for errors.Is(err, ErrTimeout), or one that
+// built a value larger than [Interp.SetValueLimits] allows, for
+// errors.Is(err, ErrValueTooLarge).
+func (e *EvalError) Is(target error) bool {
+	return target == ErrTimeout && e.ErrorCode == "TCL LIMIT TIME" ||
+		target == ErrValueTooLarge && e.ErrorCode == "TCL LIMIT VALUE"
+}
+
+// EvalTimeout evaluates script as [Interp.Eval] does, but stops it with an
+// error if it runs for longer than d. The error is an [*EvalError] with
+// the message "time limit exceeded" and the error code TCL LIMIT TIME,
+// and errors.Is reports it as [ErrTimeout].
+//
+//	result, err := interp.EvalTimeout(untrusted, 100*time.Millisecond)
+//	if errors.Is(err, feather.ErrTimeout) {
+//	    // the interpreter can still be used
+//	}
+//
+// The evaluator checks the deadline before each command and each time it
+// starts a script, such as the body of a loop, and stops a script that is
+// past it as if the command had failed, so that procs return and loops
+// end as they would for any other error. The interpreter is left ready
+// for the next script. Once the deadline passes every command fails, so
+// catch and try cannot keep a script running. Commands registered from Go
+// are not interrupted: the deadline is checked again when they return.
+//
+// EvalTimeout may be called by a command a script is running; the inner
+// script then stops at whichever deadline comes first.
+func (i *Interp) EvalTimeout(script string, d time.Duration) (*Obj, error) {
+	if err := i.checkOpen(); err != nil {
+		return nil, err
+	}
+	outer, outerTimedOut := i.deadline, i.timedOut
+	deadline := time.Now().Add(d)
+	if !outer.IsZero() && outer.Before(deadline) {
+		deadline = outer
+	}
+	if outer.IsZero() {
+		callCEvalLimitsEnable(i.handle, 1)
+	}
+	i.deadline, i.timedOut = deadline, false
+
+	timedOut := false
+	defer func() {
+		// The outer script is stopped too if its deadline was the one that
+		// passed
+		i.deadline = outer
+		i.timedOut = outerTimedOut || timedOut && deadline.Equal(outer)
+		if outer.IsZero() {
+			callCEvalLimitsEnable(i.handle, -1)
+		}
+	}()
+
+	result, err := i.Eval(script)
+	if timedOut = i.timedOut; timedOut {
+		// A script that caught the error may have finished all the same
+		info := "time limit exceeded"
+		var e *EvalError
+		if errors.As(err, &e) {
+			info = e.ErrorInfo
+		}
+		return nil, &EvalError{Message: "time limit exceeded", ErrorCode: "TCL LIMIT TIME", ErrorInfo: info}
+	}
+	return result, err
+}
+
+// limitExceeded reports whether the deadline set by EvalTimeout has passed,
+// a value ran into the limits of SetValueLimits or the coroutine running is
+// being unwound after its deletion, and if so sets the error that stops the
+// script. The C core asks before each command while a limit is set.
+func (i *Interp) limitExceeded() bool {
+	if co := i.currentCoroutine; co != nil && co.unwind {
+		i.result = i.String("coroutine deleted")
+		i.returnOptions = i.List(i.String("-code"), i.Int(1))
+		return true
+	}
+	if i.valueLimitExceeded() {
+		return true
+	}
+	if i.deadline.IsZero() || !i.timedOut && time.Now().Before(i.deadline) {
+		return false
+	}
+	i.timedOut = true
+	i.result = i.String("time limit exceeded")
+	i.returnOptions = i.List(i.String("-code"), i.Int(1),
+		i.String("-errorcode"), i.List(i.String("TCL"), i.String("LIMIT"), i.String("TIME")))
+	return true
+}
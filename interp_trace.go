@@ -0,0 +1,308 @@
+package feather
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// VarTraceInfo describes a variable access reported to a [Interp.TraceVar]
+// callback.
+type VarTraceInfo struct {
+	Name string // the variable name as used by the code that accessed it
+	Op   string // "read", "write" or "unset"
+}
+
+// CommandTraceInfo describes a change to a command reported to a
+// [Interp.TraceCommand] callback.
+type CommandTraceInfo struct {
+	OldName string // fully qualified name before the change
+	NewName string // fully qualified name after a rename; empty for a delete
+	Op      string // "rename" or "delete"
+}
+
+// ExecTraceInfo describes a command execution reported to a
+// [Interp.TraceExecution] callback.
+type ExecTraceInfo struct {
+	Command *Obj          // the command and its arguments, as a list
+	Op      string        // "enter", "leave", "enterstep" or "leavestep"
+	Code    FeatherResult // result code of the command, for leave and leavestep
+	Result  *Obj          // result of the command, for leave and leavestep
+}
+
+// TraceVar calls fn when the variable name is accessed in one of the ways
+// listed in ops, a list of "read", "write" and "unset", as with
+// trace add variable. It returns a function that removes the trace.
+//
+// An error returned by fn for a read or write fails the access with the
+// message can't read "name": ... or can't set "name": ...; errors from
+// unset traces are ignored.
+//
+//	untrace, err := interp.TraceVar("config", "write", func(t feather.VarTraceInfo) error {
+//	    log.Printf("%s changed", t.Name)
+//	    return nil
+//	})
+//	defer untrace()
+func (i *Interp) TraceVar(name, ops string, fn func(VarTraceInfo) error) (func(), error) {
+	return i.addTrace("variable", name, ops, func(i *Interp, cmd *Obj, args []*Obj) Result {
+		if len(args) != 3 {
+			return Error("wrong # args: should be \"trace name1 name2 op\"")
+		}
+		if err := fn(VarTraceInfo{Name: args[0].String(), Op: args[2].String()}); err != nil {
+			return Error(err.Error())
+		}
+		return OK("")
+	})
+}
+
+// BindVar links the TCL variable name to the Go variable ptr points to, as
+// Tcl_LinkVar does: reading the variable in a script returns the current Go
+// value, and setting it stores the new value in the Go variable. It returns
+// a function that removes the link, leaving the TCL variable with the last
+// value. Unsetting the variable also removes the link.
+//
+// ptr is a pointer to a string, bool, integer or floating-point variable,
+// such as a struct field, or an [atomic.Int32], [atomic.Int64],
+// [atomic.Uint32], [atomic.Uint64] or [atomic.Bool] that other goroutines
+// update. Setting the variable to a value that does not convert to the Go
+// type fails, leaving both values unchanged. name is a global variable or
+// a qualified namespace variable.
+//
+//	unbind, err := interp.BindVar("port", &cfg.Port)
+//	interp.Eval("set port 8080") // cfg.Port == 8080
+func (i *Interp) BindVar(name string, ptr any) (func(), error) {
+	return i.bindVar(name, ptr, false)
+}
+
+// BindVarReadOnly is like [Interp.BindVar], but setting the variable fails
+// with the error can't set "name": linked variable is read-only.
+func (i *Interp) BindVarReadOnly(name string, ptr any) (func(), error) {
+	return i.bindVar(name, ptr, true)
+}
+
+func (i *Interp) bindVar(name string, ptr any, readOnly bool) (func(), error) {
+	get, set, err := i.varAccessors(ptr)
+	if err != nil {
+		return nil, err
+	}
+	qualified := "::" + strings.TrimPrefix(name, "::")
+	k := strings.LastIndex(qualified, "::")
+	ns, simple := i.ensureNamespace(qualified[:k]), qualified[k+2:]
+	ns.vars[simple] = get()
+
+	// Values are stored in the namespace directly, which does not fire the
+	// traces again
+	var untrace func()
+	untrace, err = i.TraceVar(qualified, "read write unset", func(t VarTraceInfo) error {
+		switch t.Op {
+		case "read":
+			ns.vars[simple] = get()
+		case "write":
+			if readOnly {
+				ns.vars[simple] = get()
+				return errors.New("linked variable is read-only")
+			}
+			if err := set(ns.vars[simple]); err != nil {
+				ns.vars[simple] = get()
+				return err
+			}
+		case "unset":
+			untrace()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if _, ok := ns.vars[simple]; ok {
+			ns.vars[simple] = get()
+		}
+		untrace()
+	}, nil
+}
+
+// varAccessors returns functions that get the value of the Go variable ptr
+// points to as an object and set it from one, for [Interp.BindVar].
+func (i *Interp) varAccessors(ptr any) (get func() *Obj, set func(*Obj) error, err error) {
+	switch p := ptr.(type) {
+	case *atomic.Int32:
+		return func() *Obj { return i.Int(int64(p.Load())) }, func(o *Obj) error {
+			v, err := o.Int()
+			if err == nil && int64(int32(v)) != v {
+				err = fmt.Errorf("integer value %d out of range", v)
+			}
+			if err == nil {
+				p.Store(int32(v))
+			}
+			return err
+		}, nil
+	case *atomic.Int64:
+		return func() *Obj { return i.Int(p.Load()) }, func(o *Obj) error {
+			v, err := o.Int()
+			if err == nil {
+				p.Store(v)
+			}
+			return err
+		}, nil
+	case *atomic.Uint32:
+		return func() *Obj { return i.Int(int64(p.Load())) }, func(o *Obj) error {
+			v, err := o.Int()
+			if err == nil && int64(uint32(v)) != v {
+				err = fmt.Errorf("integer value %d out of range", v)
+			}
+			if err == nil {
+				p.Store(uint32(v))
+			}
+			return err
+		}, nil
+	case *atomic.Uint64:
+		return func() *Obj { return i.BigInt(new(big.Int).SetUint64(p.Load())) }, func(o *Obj) error {
+			v, err := o.BigInt()
+			if err == nil && (v.Sign() < 0 || !v.IsUint64()) {
+				err = fmt.Errorf("integer value %s out of range", v)
+			}
+			if err == nil {
+				p.Store(v.Uint64())
+			}
+			return err
+		}, nil
+	case *atomic.Bool:
+		return func() *Obj { return i.Bool(p.Load()) }, func(o *Obj) error {
+			v, err := o.Bool()
+			if err == nil {
+				p.Store(v)
+			}
+			return err
+		}, nil
+	}
+
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil, nil, fmt.Errorf("BindVar: %T is not a non-nil pointer", ptr)
+	}
+	v := rv.Elem()
+	switch v.Kind() {
+	case reflect.String:
+		return func() *Obj { return i.String(v.String()) }, func(o *Obj) error {
+			v.SetString(o.String())
+			return nil
+		}, nil
+	case reflect.Bool:
+		return func() *Obj { return i.Bool(v.Bool()) }, func(o *Obj) error {
+			b, err := o.Bool()
+			if err == nil {
+				v.SetBool(b)
+			}
+			return err
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func() *Obj { return i.Int(v.Int()) }, func(o *Obj) error {
+			n, err := o.Int()
+			if err == nil && v.OverflowInt(n) {
+				err = fmt.Errorf("integer value %d out of range", n)
+			}
+			if err == nil {
+				v.SetInt(n)
+			}
+			return err
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func() *Obj { return i.BigInt(new(big.Int).SetUint64(v.Uint())) }, func(o *Obj) error {
+			n, err := o.BigInt()
+			if err == nil && (n.Sign() < 0 || !n.IsUint64() || v.OverflowUint(n.Uint64())) {
+				err = fmt.Errorf("integer value %s out of range", n)
+			}
+			if err == nil {
+				v.SetUint(n.Uint64())
+			}
+			return err
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func() *Obj { return i.Double(v.Float()) }, func(o *Obj) error {
+			f, err := o.Double()
+			if err == nil {
+				v.SetFloat(f)
+			}
+			return err
+		}, nil
+	}
+	return nil, nil, fmt.Errorf("BindVar: unsupported variable type %v", v.Type())
+}
+
+// TraceCommand calls fn when the command name is renamed or deleted, for the
+// operations listed in ops ("rename" and "delete"), as with trace add command.
+// It returns a function that removes the trace.
+func (i *Interp) TraceCommand(name, ops string, fn func(CommandTraceInfo)) (func(), error) {
+	return i.addTrace("command", name, ops, func(i *Interp, cmd *Obj, args []*Obj) Result {
+		if len(args) != 3 {
+			return Error("wrong # args: should be \"trace oldName newName op\"")
+		}
+		fn(CommandTraceInfo{OldName: args[0].String(), NewName: args[1].String(), Op: args[2].String()})
+		return OK("")
+	})
+}
+
+// TraceExecution calls fn when the command name is executed, as with
+// trace add execution. ops lists "enter" and "leave" to be called before
+// and after the command runs, and "enterstep" and "leavestep" to be called
+// for every command run inside it. It returns a function that removes the
+// trace.
+//
+// An error returned by fn fails the command with that error.
+//
+//	interp.TraceExecution("deploy", "enter leave", func(t feather.ExecTraceInfo) error {
+//	    if t.Op == "leave" {
+//	        audit.Record(t.Command.String(), t.Code, t.Result.String())
+//	    }
+//	    return nil
+//	})
+func (i *Interp) TraceExecution(name, ops string, fn func(ExecTraceInfo) error) (func(), error) {
+	return i.addTrace("execution", name, ops, func(i *Interp, cmd *Obj, args []*Obj) Result {
+		var info ExecTraceInfo
+		switch len(args) {
+		case 2:
+			info = ExecTraceInfo{Command: args[0], Op: args[1].String()}
+		case 4:
+			code, err := strconv.Atoi(args[1].String())
+			if err != nil {
+				return Error(err.Error())
+			}
+			info = ExecTraceInfo{Command: args[0], Op: args[3].String(), Code: FeatherResult(code), Result: args[2]}
+		default:
+			return Error("wrong # args: should be \"trace command ?code result? op\"")
+		}
+		if err := fn(info); err != nil {
+			return Error(err.Error())
+		}
+		return OK("")
+	})
+}
+
+// addTrace registers handler as a hidden command in ::tcl::trace and adds
+// it as a trace of the given kind on name.
+func (i *Interp) addTrace(kind, name, ops string, handler CommandFunc) (func(), error) {
+	i.traceCount++
+	ns := i.ensureNamespace("::tcl::trace")
+	simple := fmt.Sprintf("go%d", i.traceCount)
+	qualified := "::tcl::trace::" + simple
+	i.setCommand(ns, simple, &Command{cmdType: CmdBuiltin, fn: i.wrapCommand(handler)})
+
+	if _, err := i.Call("trace", "add", kind, name, ops, qualified); err != nil {
+		i.deleteCommand(ns, simple)
+		return nil, err
+	}
+	removed := false
+	return func() {
+		if removed {
+			return
+		}
+		removed = true
+		i.Call("trace", "remove", kind, name, ops, qualified)
+		i.deleteCommand(ns, simple)
+	}, nil
+}
@@ -0,0 +1,55 @@
+package feather
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// The unicode command converts strings between Unicode normalization forms:
+//
+//	unicode tonfc string   - canonical composition (NFC)
+//	unicode tonfd string   - canonical decomposition (NFD)
+//	unicode tonfkc string  - compatibility composition (NFKC)
+//	unicode tonfkd string  - compatibility decomposition (NFKD)
+//	unicode isnfc string   - whether string is already in NFC, and so on
+//	                         for isnfd, isnfkc and isnfkd
+//
+// Strings that compare equal after normalization, such as "é" written as
+// one character or as "e" and a combining accent, are different strings to
+// every other command, so normalize text from outside before comparing it.
+
+// unicodeForms holds the normalization forms by the suffix of their
+// subcommands.
+var unicodeForms = map[string]norm.Form{
+	"nfc":  norm.NFC,
+	"nfd":  norm.NFD,
+	"nfkc": norm.NFKC,
+	"nfkd": norm.NFKD,
+}
+
+// registerUnicode installs the unicode command.
+func (i *Interp) registerUnicode() {
+	i.RegisterCommand("unicode", cmdUnicode)
+}
+
+// cmdUnicode implements: unicode subcommand string
+func cmdUnicode(i *Interp, cmd *Obj, args []*Obj) Result {
+	if len(args) < 1 {
+		return Error(`wrong # args: should be "unicode subcommand string"`)
+	}
+	sub := args[0].String()
+	verb, name, _ := strings.Cut(sub, "nf")
+	form, ok := unicodeForms["nf"+name]
+	if !ok || (verb != "is" && verb != "to") {
+		return Errorf(`unknown or ambiguous subcommand "%s": must be isnfc, isnfd, isnfkc, isnfkd, tonfc, tonfd, tonfkc, or tonfkd`, sub)
+	}
+	if len(args) != 2 {
+		return Errorf(`wrong # args: should be "unicode %s string"`, sub)
+	}
+	s := args[1].String()
+	if verb == "is" {
+		return OK(form.IsNormalString(s))
+	}
+	return OK(form.String(s))
+}
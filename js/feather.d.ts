@@ -10,6 +10,21 @@ export declare const TCL_CONTINUE: 4;
 
 export type TclResultCode = typeof TCL_OK | typeof TCL_ERROR | typeof TCL_RETURN | typeof TCL_BREAK | typeof TCL_CONTINUE;
 
+export declare const TCL_PARSE_OK: 0;
+export declare const TCL_PARSE_INCOMPLETE: 1;
+export declare const TCL_PARSE_ERROR: 2;
+
+export type TclParseStatus = typeof TCL_PARSE_OK | typeof TCL_PARSE_INCOMPLETE | typeof TCL_PARSE_ERROR;
+
+export interface ParseResult {
+  /** Whether the first command of the script is complete, incomplete, or invalid */
+  status: TclParseStatus;
+  /** For incomplete and invalid scripts, "{TYPE start end}" or "{ERROR start end {message}}" */
+  result: string;
+  /** The parse error message, for TCL_PARSE_ERROR */
+  errorMessage?: string;
+}
+
 export interface TclError extends Error {
   code: TclResultCode;
 }
@@ -63,6 +78,26 @@ export interface Feather {
    */
   eval(interpId: number, script: string): string;
 
+  /**
+   * Check whether a script is complete without evaluating it, as needed by
+   * a REPL deciding whether to read another line.
+   * @param interpId The interpreter ID
+   * @param script The TCL script to check
+   * @returns The parse status
+   */
+  parse(interpId: number, script: string): ParseResult;
+
+  /**
+   * Call a command with arguments, without quoting them into a script.
+   * Numbers become int or double values and arrays become lists.
+   * @param interpId The interpreter ID
+   * @param cmdName The command name
+   * @param args The arguments
+   * @returns The result string
+   * @throws {TclError} If the command fails
+   */
+  call(interpId: number, cmdName: string, ...args: Array<string | number | any[]>): string;
+
   /**
    * Get the current result from the interpreter.
    * @param interpId The interpreter ID
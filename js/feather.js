@@ -347,6 +347,17 @@ async function createFeather(wasmSource) {
     return [ptr, bytes.length];
   };
 
+  const readCString = (ptr) => {
+    const bytes = new Uint8Array(wasmMemory.buffer);
+    let end = ptr;
+    while (bytes[end] !== 0) end++;
+    return readString(ptr, end - ptr);
+  };
+
+  // Name tables passed to feather_host_string_get_index, keyed by address.
+  // C tables are static, so each one is decoded once.
+  const indexTables = new Map();
+
   const writeI32 = (ptr, value) => {
     new DataView(wasmMemory.buffer).setInt32(ptr, value, true);
   };
@@ -363,6 +374,20 @@ async function createFeather(wasmSource) {
     return new DataView(wasmMemory.buffer).getBigInt64(ptr, true);
   };
 
+  // Parse an integer of any size in TCL literal syntax, or return null
+  const parseBigInt = (interp, handle) => {
+    const o = interp.get(handle);
+    if (o?.type === 'int') return BigInt(o.value);
+    const m = /^([-+]?)(0[xX][0-9a-fA-F]+|0[oO][0-7]+|0[bB][01]+|(?:0[dD])?[0-9]+)$/.exec(interp.getString(handle).trim());
+    if (!m) return null;
+    const digits = /^0[dD]/.test(m[2]) ? m[2].slice(2) : m[2];
+    const val = BigInt(digits);
+    return m[1] === '-' ? -val : val;
+  };
+
+  const INT64_MIN = -(1n << 63n);
+  const INT64_MAX = (1n << 63n) - 1n;
+
   const writeF64 = (ptr, value) => {
     new DataView(wasmMemory.buffer).setFloat64(ptr, value, true);
   };
@@ -385,8 +410,8 @@ async function createFeather(wasmSource) {
       const parentNs = interp.frames[interp.frames.length - 1].ns;
       // New frames get their own vars Map (NOT shared with namespace)
       // links is separate from vars to avoid overwriting values with links
-      // line and lambda fields for info frame support
-      interp.frames.push({ vars: new Map(), links: new Map(), cmd, args, ns: parentNs, line: 0, lambda: 0 });
+      // line and lambda fields for info frame support, word for info level
+      interp.frames.push({ vars: new Map(), links: new Map(), cmd, args, ns: parentNs, line: 0, lambda: 0, word: 0 });
       interp.activeLevel = interp.frames.length - 1;
       return TCL_OK;
     },
@@ -439,6 +464,16 @@ async function createFeather(wasmSource) {
       if (level >= interp.frames.length) return 0;
       return interp.frames[level].line || 0;
     },
+    feather_host_frame_set_file: (interpId, file) => {
+      const interp = interpreters.get(interpId);
+      interp.currentFrame().file = file ? interp.getString(file) : null;
+      return TCL_OK;
+    },
+    feather_host_frame_get_file: (interpId, level) => {
+      const interp = interpreters.get(interpId);
+      if (level >= interp.frames.length || interp.frames[level].file == null) return 0;
+      return interp.store({ type: 'string', value: interp.frames[level].file });
+    },
     feather_host_frame_set_lambda: (interpId, lambda) => {
       const interp = interpreters.get(interpId);
       interp.currentFrame().lambda = lambda;
@@ -449,6 +484,16 @@ async function createFeather(wasmSource) {
       if (level >= interp.frames.length) return 0;
       return interp.frames[level].lambda || 0;
     },
+    feather_host_frame_set_word: (interpId, word) => {
+      const interp = interpreters.get(interpId);
+      interp.currentFrame().word = word;
+      return TCL_OK;
+    },
+    feather_host_frame_get_word: (interpId, level) => {
+      const interp = interpreters.get(interpId);
+      if (level >= interp.frames.length) return 0;
+      return interp.frames[level].word || 0;
+    },
     feather_host_frame_push_locals: (interpId, ns) => {
       const interp = interpreters.get(interpId);
       const frame = interp.currentFrame();
@@ -605,6 +650,10 @@ async function createFeather(wasmSource) {
       const interp = interpreters.get(interpId);
       let varName = interp.getString(name);
       let frame = interp.currentFrame();
+      const qualify = (nsPath, n) => {
+        const path = nsPath.replace(/^::/, '');
+        return path ? `::${path}::${n}` : `::${n}`;
+      };
       // Follow links to find the target variable name
       while (frame.links.has(varName)) {
         const link = frame.links.get(varName);
@@ -612,13 +661,23 @@ async function createFeather(wasmSource) {
           frame = interp.frames[link.level];
           varName = link.name;
         } else if (link.nsPath !== undefined) {
-          // Namespace link - return the namespace variable name
-          return interp.store({ type: 'string', value: link.nsName });
+          // Namespace link - return the qualified namespace variable name
+          return interp.store({ type: 'string', value: qualify(link.nsPath, link.nsName) });
         } else {
           break;
         }
       }
-      // Return the resolved variable name
+      // Namespace variables, including globals, are returned fully qualified
+      if (varName.includes('::')) {
+        if (!varName.startsWith('::')) varName = qualify(frame.ns, varName);
+      } else {
+        for (const [path, ns] of interp.namespaces) {
+          if (ns.vars === frame.vars) {
+            varName = qualify(path, varName);
+            break;
+          }
+        }
+      }
       return interp.store({ type: 'string', value: varName });
     },
 
@@ -830,6 +889,8 @@ async function createFeather(wasmSource) {
       const str = interp.getString(obj);
       return new TextEncoder().encode(str).length;
     },
+    // Strings live in JS, not in WASM memory; C falls back to byte_at
+    feather_host_string_bytes: () => 0,
     feather_host_string_slice: (interpId, obj, start, end) => {
       const interp = interpreters.get(interpId);
       const str = interp.getString(obj);
@@ -863,6 +924,48 @@ async function createFeather(wasmSource) {
         .replace(/\*/g, '.*').replace(/\?/g, '.') + '$');
       return regex.test(s) ? 1 : 0;
     },
+    feather_host_string_get_index: (interpId, obj, tablePtr, whatPtr, flags, indexPtr) => {
+      const interp = interpreters.get(interpId);
+      const exact = (flags & 1) !== 0;
+      const o = interp.get(obj);
+      const cached = o && o.index;
+      if (cached && cached.table === tablePtr && (cached.exact || !exact)) {
+        writeI32(indexPtr, cached.index);
+        return TCL_OK;
+      }
+      let names = indexTables.get(tablePtr);
+      if (!names) {
+        names = [];
+        for (let p = tablePtr; readI32(p) !== 0; p += 4) {
+          names.push(readCString(readI32(p)));
+        }
+        indexTables.set(tablePtr, names);
+      }
+      const word = interp.getString(obj);
+      let index = names.indexOf(word);
+      let full = index >= 0;
+      let ambiguous = false;
+      if (!full && !exact && word !== '') {
+        const matches = names.filter(n => n.startsWith(word));
+        if (matches.length === 1) index = names.indexOf(matches[0]);
+        ambiguous = matches.length > 1;
+      }
+      if (index < 0) {
+        if (whatPtr) {
+          const list = names.length < 3
+            ? names.join(' or ')
+            : names.slice(0, -1).join(', ') + ', or ' + names[names.length - 1];
+          const kind = ambiguous ? 'ambiguous' : 'bad';
+          interp.result = interp.store({ type: 'string', value: `${kind} ${readCString(whatPtr)} "${word}": must be ${list}` });
+        }
+        return TCL_ERROR;
+      }
+      if (o && o.type === 'string') {
+        o.index = { table: tablePtr, index, exact: full };
+      }
+      writeI32(indexPtr, index);
+      return TCL_OK;
+    },
     feather_host_string_regex_match: (interpId, pattern, string, nocase, resultPtr, matchesPtr, indicesPtr) => {
       const interp = interpreters.get(interpId);
       let patStr = interp.getString(pattern);
@@ -1351,11 +1454,11 @@ async function createFeather(wasmSource) {
     feather_host_integer_get: (interpId, obj, outPtr) => {
       const interp = interpreters.get(interpId);
       const o = interp.get(obj);
-      if (o?.type === 'int') {
+      const str = interp.getString(obj).trim();
+      if (o?.type === 'int' && o.value >= INT64_MIN && o.value <= INT64_MAX) {
         writeI64(outPtr, o.value);
         return TCL_OK;
       }
-      const str = interp.getString(obj).trim();
       // Must be a valid integer string (not a float that parseInt would truncate)
       if (!/^-?(?:0x[0-9a-fA-F]+|0o[0-7]+|0b[01]+|[0-9]+)$/.test(str)) {
         interp.result = interp.store({ type: 'string', value: `expected integer but got "${str}"` });
@@ -1373,6 +1476,8 @@ async function createFeather(wasmSource) {
         } else {
           val = BigInt(str);
         }
+        // Larger values are bignums, reached through feather_host_bignum_*
+        if (val < INT64_MIN || val > INT64_MAX) throw new RangeError(str);
         writeI64(outPtr, val);
         return TCL_OK;
       } catch (e) {
@@ -1394,7 +1499,7 @@ async function createFeather(wasmSource) {
         return TCL_OK;
       }
       if (o?.type === 'int') {
-        writeF64(outPtr, o.value);
+        writeF64(outPtr, Number(o.value));
         return TCL_OK;
       }
       const str = interp.getString(obj).trim();
@@ -1462,7 +1567,13 @@ async function createFeather(wasmSource) {
           break;
         case 'f':
         case 'F':
-          result = val.toFixed(prec);
+          // toFixed switches to exponential notation from 1e21, where
+          // doubles are whole numbers and BigInt gives their exact digits
+          if (Math.abs(val) >= 1e21) {
+            result = BigInt(val).toString() + (prec > 0 ? '.' + '0'.repeat(prec) : '');
+          } else {
+            result = val.toFixed(prec);
+          }
           break;
         case 'g':
         case 'G':
@@ -1557,6 +1668,84 @@ async function createFeather(wasmSource) {
       return TCL_OK;
     },
 
+    // Bignum operations - int objects hold a BigInt of any size
+    feather_host_bignum_get: (interpId, obj, outPtr) => {
+      const interp = interpreters.get(interpId);
+      const val = parseBigInt(interp, obj);
+      if (val === null) return TCL_ERROR;
+      writeI32(outPtr, interp.store({ type: 'int', value: val }));
+      return TCL_OK;
+    },
+    feather_host_bignum_arith: (interpId, op, a, b, outPtr) => {
+      const interp = interpreters.get(interpId);
+      const fail = (msg) => {
+        interp.result = interp.store({ type: 'string', value: msg });
+        return TCL_ERROR;
+      };
+      const x = parseBigInt(interp, a);
+      const y = op < 11 ? parseBigInt(interp, b) : 0n;
+      if (x === null) return fail(`expected integer but got "${interp.getString(a)}"`);
+      if (y === null) return fail(`expected integer but got "${interp.getString(b)}"`);
+      const maxBits = 1n << 24n;
+      let result;
+      switch (op) {
+        case 0: result = x + y; break;            // FEATHER_BIG_ADD
+        case 1: result = x - y; break;            // FEATHER_BIG_SUB
+        case 2: result = x * y; break;            // FEATHER_BIG_MUL
+        case 3:                                   // FEATHER_BIG_DIV
+        case 4: {                                 // FEATHER_BIG_MOD
+          if (y === 0n) return fail('divide by zero');
+          // Round toward negative infinity so the remainder has the divisor's sign
+          let q = x / y, m = x % y;
+          if (m !== 0n && (m < 0n) !== (y < 0n)) { q -= 1n; m += y; }
+          result = op === 3 ? q : m;
+          break;
+        }
+        case 5: {                                 // FEATHER_BIG_POW
+          const ax = x < 0n ? -x : x;
+          if (y < 0n) {
+            if (x === 0n) return fail('exponentiation of zero by negative power');
+            result = ax !== 1n ? 0n : (x < 0n && (y & 1n) ? -1n : 1n);
+          } else {
+            if (ax > 1n && y * BigInt(ax.toString(2).length - 1) > maxBits) return fail('exponent too large');
+            result = x ** y;
+          }
+          break;
+        }
+        case 6: result = x & y; break;            // FEATHER_BIG_AND
+        case 7: result = x | y; break;            // FEATHER_BIG_OR
+        case 8: result = x ^ y; break;            // FEATHER_BIG_XOR
+        case 9:                                   // FEATHER_BIG_SHL
+          if (y < 0n) return fail('negative shift argument');
+          if (x !== 0n && y > maxBits) return fail('integer value too large to represent');
+          result = x << y;
+          break;
+        case 10:                                  // FEATHER_BIG_SHR
+          if (y < 0n) return fail('negative shift argument');
+          result = y > maxBits ? (x < 0n ? -1n : 0n) : x >> y;
+          break;
+        case 11: result = -x; break;              // FEATHER_BIG_NEG
+        case 12: result = ~x; break;              // FEATHER_BIG_NOT
+        default:
+          return fail('unknown bignum operation');
+      }
+      writeI32(outPtr, interp.store({ type: 'int', value: result }));
+      return TCL_OK;
+    },
+    feather_host_bignum_compare: (interpId, a, b) => {
+      const interp = interpreters.get(interpId);
+      const x = parseBigInt(interp, a), y = parseBigInt(interp, b);
+      if (x === null || y === null) return 0;
+      return x < y ? -1 : (x > y ? 1 : 0);
+    },
+    feather_host_bignum_format: (interpId, obj, base, uppercase) => {
+      const interp = interpreters.get(interpId);
+      const val = parseBigInt(interp, obj);
+      if (val === null) return 0;
+      const s = val.toString(base);
+      return interp.store({ type: 'string', value: uppercase ? s.toUpperCase() : s });
+    },
+
     // Interp operations
     feather_host_interp_set_result: (interpId, result) => {
       const interp = interpreters.get(interpId);
@@ -1621,6 +1810,29 @@ async function createFeather(wasmSource) {
       const interp = interpreters.get(interpId);
       interp.scriptPath = interp.getString(path);
     },
+    feather_host_interp_eval_hook: () => {},
+    feather_host_interp_eval_done_hook: () => {},
+    feather_host_interp_limit_check: () => TCL_OK,
+    feather_host_interp_trace_hook: () => {},
+    feather_host_interp_set_source: (interpId, obj, file, line) => {
+      const interp = interpreters.get(interpId);
+      const o = interp.get(obj);
+      if (o) o.source = { file: file ? interp.getString(file) : null, line };
+    },
+    feather_host_interp_get_source: (interpId, obj, filePtr) => {
+      const interp = interpreters.get(interpId);
+      const o = interp.get(obj);
+      writeI32(filePtr, 0);
+      if (!o || !o.source) return 0;
+      if (o.source.file != null) {
+        writeI32(filePtr, interp.store({ type: 'string', value: o.source.file }));
+      }
+      return o.source.line;
+    },
+    feather_host_interp_state: (interpId) => interpreters.get(interpId).state,
+    // WebAssembly has a single stack, so deep bodies stay on it
+    feather_host_interp_eval_on_new_stack: (interpId, script, flags) =>
+      wasmInstance.exports.feather_script_eval_obj(0, interpId, script, flags),
 
     // Bind operations
     feather_host_bind_unknown: (interpId, cmd, args, valuePtr) => {
@@ -1673,22 +1885,50 @@ async function createFeather(wasmSource) {
     feather_host_foreign_invoke: (interpId, obj, method, args) => {
       const interp = interpreters.get(interpId);
       const o = interp.get(obj);
-      if (o?.type !== 'foreign') return TCL_ERROR;
-      const typeDef = interp.foreignTypes.get(o.typeName);
+      const instance = interp.getForeignInstance(obj);
+      const fail = (message) => {
+        interp.result = interp.store({ type: 'string', value: message });
+        return TCL_ERROR;
+      };
+      // Host commands are not in the core's command table, so an object
+      // whose command was registered by the host is dispatched through it
+      const hostFn = interp.hostCommands.get(interp.getString(obj));
+      if (hostFn) {
+        try {
+          const argList = interp.getList(args).items.map(h => interp.getString(h));
+          const result = hostFn([interp.getString(method), ...argList]);
+          interp.result = interp.store({ type: 'string', value: String(result ?? '') });
+          return TCL_OK;
+        } catch (e) {
+          return fail(e.message);
+        }
+      }
+      if (!instance && o?.type !== 'foreign') return fail('not a foreign object');
+      const typeName = instance ? instance.typeName : o.typeName;
+      const value = instance ? instance.value : o.value;
+      const typeDef = interp.foreignTypes.get(typeName);
       const methodName = interp.getString(method);
+      if (methodName === 'destroy') {
+        if (instance?.handleName) {
+          interp.foreignInstances.delete(instance.handleName);
+          interp.hostCommands.delete(instance.handleName);
+        }
+        typeDef?.destroy?.(value);
+        interp.result = interp.store({ type: 'string', value: '' });
+        return TCL_OK;
+      }
       const fn = typeDef?.methods?.[methodName];
       if (!fn) {
-        interp.result = interp.store({ type: 'string', value: `unknown method "${methodName}"` });
-        return TCL_ERROR;
+        const available = Object.keys(typeDef?.methods || {}).concat('destroy').sort().join(', ');
+        return fail(`unknown method "${methodName}": must be ${available}`);
       }
       try {
         const argList = interp.getList(args).items.map(h => interp.getString(h));
-        const result = fn(o.value, ...argList);
+        const result = fn(value, ...argList);
         interp.result = interp.store({ type: 'string', value: String(result ?? '') });
         return TCL_OK;
       } catch (e) {
-        interp.result = interp.store({ type: 'string', value: e.message });
-        return TCL_ERROR;
+        return fail(e.message);
       }
     },
     feather_host_foreign_destroy: (interpId, obj) => {
@@ -1749,6 +1989,8 @@ async function createFeather(wasmSource) {
         return wasmInstance.exports.feather_list_parse_obj(0, id, strHandle);
       };
 
+      // The core's state for the interpreter, outside the arena
+      interp.state = wasmInstance.exports.feather_state_new();
       wasmInstance.exports.feather_interp_init(0, id);
       return id;
     },
@@ -1778,7 +2020,7 @@ async function createFeather(wasmSource) {
           }
           const methodFn = typeDef?.methods?.[method];
           if (!methodFn) {
-            const available = Object.keys(typeDef?.methods || {}).concat('destroy').join(', ');
+            const available = Object.keys(typeDef?.methods || {}).concat('destroy').sort().join(', ');
             throw new Error(`unknown method "${method}": must be ${available}`);
           }
           return methodFn(value, ...args.slice(1));
@@ -1993,6 +2235,7 @@ async function createFeather(wasmSource) {
     },
 
     destroy(interpId) {
+      wasmInstance.exports.feather_state_free(interpreters.get(interpId).state);
       interpreters.delete(interpId);
     },
 
@@ -121,7 +121,7 @@ function registerTestCommands(feather, interp) {
       const rest = methodArgs.slice(1);
       const def = methodDefs[method];
       if (!def) {
-        const methodList = Object.keys(methodDefs).join(', ');
+        const methodList = Object.keys(methodDefs).sort().join(', ');
         throw new Error(`unknown method "${method}": must be ${methodList}`);
       }
       // Check argument count
@@ -0,0 +1,81 @@
+package feather
+
+// The functions in this file create objects that belong to no interpreter,
+// for Go code that builds values before it has one, such as tests, or for
+// several at once, such as a marshaling layer.
+//
+// An object without an interpreter can be given to any interpreter: as a
+// command argument or result, a variable value, or an element of a list or
+// dict. The interpreter shimmers it as its commands need, and [Interp.Value]
+// binds it to the interpreter. Shimmering may cache objects of that
+// interpreter inside it, such as the elements of a list parsed from its
+// string, so give each object to one interpreter only, and pass copies
+// made by [Interp.Duplicate] to the others.
+//
+// Go code needs an interpreter only to parse a string as a list or dict.
+// [Obj.List] and [Obj.Dict] return an error for a list written as a string,
+// such as NewString("a b c"), since parsing is done by an interpreter;
+// objects built by [NewListOf] and [NewDictOf] already hold their elements.
+// Create such strings with [Interp.String], or bind them to an interpreter
+// with [Interp.Duplicate].
+//
+// Like any object, an object without an interpreter must not be used by two
+// goroutines at once, as shimmering caches its internal representation.
+
+// noInterp is the nil interpreter. Its methods that create objects, like
+// [Interp.List] and [Interp.Value], create objects without an interpreter.
+var noInterp *Interp
+
+// NewString creates a string object that belongs to no interpreter.
+//
+//	s := feather.NewString("hello world")
+//	s.String() // "hello world"
+func NewString(s string) *Obj {
+	return noInterp.String(s)
+}
+
+// NewIntObj creates an integer object that belongs to no interpreter.
+//
+//	n := feather.NewIntObj(42)
+//	n.Type() // "int"
+func NewIntObj(v int64) *Obj {
+	return noInterp.Int(v)
+}
+
+// NewDoubleObj creates a floating-point object that belongs to no
+// interpreter.
+func NewDoubleObj(v float64) *Obj {
+	return noInterp.Double(v)
+}
+
+// NewBoolObj creates a boolean object, int 1 or 0, that belongs to no
+// interpreter.
+func NewBoolObj(v bool) *Obj {
+	return noInterp.Bool(v)
+}
+
+// NewListOf creates a list object of the given items that belongs to no
+// interpreter.
+//
+//	list := feather.NewListOf(feather.NewString("a"), feather.NewIntObj(1))
+//	list.String() // "a 1"
+func NewListOf(items ...*Obj) *Obj {
+	return noInterp.List(items...)
+}
+
+// NewDictOf creates a dict object from alternating key-value pairs that
+// belongs to no interpreter. Keys and values are converted as by
+// [Interp.DictKV].
+//
+//	dict := feather.NewDictOf("name", "Alice", "age", 30)
+//	dict.String() // "name Alice age 30"
+func NewDictOf(kvs ...any) *Obj {
+	return noInterp.DictKV(kvs...)
+}
+
+// NewValue converts a Go value to an object that belongs to no
+// interpreter, as [Interp.Value] does. Types implementing [Marshaler]
+// are passed a nil interpreter.
+func NewValue(v any) *Obj {
+	return noInterp.Value(v)
+}
@@ -1,14 +1,41 @@
 package feather
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+)
 
 // Obj is a Feather value.
 // It follows TCL semantics where values have both a string representation
 // and an optional internal representation that can be lazily computed.
+//
+// The same Obj is often referred to from several places at once: two
+// variables set to one value, the elements of a list, the result of an
+// eval. Objects are therefore copy-on-write:
+//
+//   - The value of an Obj never changes once anything else may refer to
+//     it. Shimmering, such as [Obj.List] parsing a string, only computes
+//     and caches an internal representation of the same value.
+//   - The slices and maps returned by [Obj.List] and [Obj.Dict] belong to
+//     the Obj and must not be modified. [Obj.Clone] or
+//     [Interp.Duplicate] it first, and modify the copy.
+//   - Commands such as dict set and lappend change the value of a variable
+//     in place only when nothing else refers to it, and copy it otherwise.
 type Obj struct {
-	bytes  string  // string representation ("" = empty string if intrep == nil)
-	intrep ObjType // internal representation (nil = pure string)
-	interp *Interp // owning interpreter (for shimmering that requires parsing)
+	bytes  string     // string representation ("" = empty string if intrep == nil)
+	intrep ObjType    // internal representation (nil = pure string)
+	interp *Interp    // owning interpreter (for shimmering that requires parsing)
+	source *sourceLoc // where the parser found the text (nil = unknown)
+	list   *listState // sharing and string reuse of a ListType
+
+	runeIdx *runeIndex // character offsets of a long string (nil = not built)
+}
+
+// sourceLoc records where a word appeared in a script, so that scripts
+// passed to commands like proc report lines of the enclosing script.
+type sourceLoc struct {
+	file *Obj // file the enclosing script came from (nil = none)
+	line int  // line the word starts on
 }
 
 // ObjType defines the core behavior for an internal representation.
@@ -55,8 +82,15 @@ func (o *Obj) String() string {
 	if o == nil {
 		return ""
 	}
+	if featherDebug {
+		o.checkOpen()
+	}
 	if o.bytes == "" && o.intrep != nil {
-		o.bytes = o.intrep.UpdateString()
+		if items, ok := o.intrep.(ListType); ok && o.list != nil && len(items) >= o.list.count {
+			o.bytes = o.list.listString(items)
+		} else {
+			o.bytes = o.intrep.UpdateString()
+		}
 	}
 	return o.bytes
 }
@@ -92,12 +126,18 @@ func (o *Obj) invalidate() {
 		return
 	}
 	o.bytes = ""
+	o.runeIdx = nil
+	if o.list != nil {
+		o.list.prefix, o.list.count = "", 0
+	}
 }
 
-// Copy creates a shallow copy of the object.
-// If the object has an internal representation, it is duplicated via Dup().
+// Clone returns a copy of the object that can be modified without
+// changing o. If the object has an internal representation, it is
+// duplicated via Dup(), so the slice and maps of a list or dict are the
+// copy's own; their elements are shared, as their values never change.
 // The copy remains tied to the same interpreter as the original.
-func (o *Obj) Copy() *Obj {
+func (o *Obj) Clone() *Obj {
 	if o == nil {
 		return nil
 	}
@@ -107,6 +147,20 @@ func (o *Obj) Copy() *Obj {
 	return &Obj{bytes: o.bytes, intrep: o.intrep.Dup(), interp: o.interp}
 }
 
+// Copy is the same as [Obj.Clone].
+func (o *Obj) Copy() *Obj {
+	return o.Clone()
+}
+
+// checkOpen returns ErrInterpClosed if the interpreter o belongs to is
+// closed, or panics in featherdebug builds.
+func (o *Obj) checkOpen() error {
+	if o == nil || o.interp == nil {
+		return nil
+	}
+	return o.interp.checkOpen()
+}
+
 // setBytes sets the string representation directly (used by Interp for handle-based naming).
 func (o *Obj) setBytes(s string) {
 	if o != nil {
@@ -116,22 +170,50 @@ func (o *Obj) setBytes(s string) {
 
 // Int returns the integer value of this object, shimmering if needed.
 func (o *Obj) Int() (int64, error) {
+	if err := o.checkOpen(); err != nil {
+		return 0, err
+	}
 	return asInt(o)
 }
 
 // Double returns the float64 value of this object, shimmering if needed.
 func (o *Obj) Double() (float64, error) {
+	if err := o.checkOpen(); err != nil {
+		return 0, err
+	}
 	return asDouble(o)
 }
 
+// BigInt returns the integer value of this object without the int64 range
+// limit of [Obj.Int], shimmering if needed. The result must not be modified.
+func (o *Obj) BigInt() (*big.Int, error) {
+	if err := o.checkOpen(); err != nil {
+		return nil, err
+	}
+	return asBigInt(o)
+}
+
 // Bool returns the boolean value of this object using TCL boolean rules.
 func (o *Obj) Bool() (bool, error) {
+	if err := o.checkOpen(); err != nil {
+		return false, err
+	}
 	return asBool(o)
 }
 
+// ByteArray returns the binary data held by this object, shimmering if needed.
+// For string values each character contributes one byte: its code point,
+// truncated to 8 bits.
+func (o *Obj) ByteArray() []byte {
+	return asByteArray(o)
+}
+
 // List returns the list elements of this object, shimmering if needed.
 // If the object is a pure string, it will be parsed as a TCL list.
 func (o *Obj) List() ([]*Obj, error) {
+	if err := o.checkOpen(); err != nil {
+		return nil, err
+	}
 	// Try existing list rep first
 	if list, err := asList(o); err == nil {
 		return list, nil
@@ -151,6 +233,9 @@ func (o *Obj) List() ([]*Obj, error) {
 // Dict returns the dict representation of this object, shimmering if needed.
 // If the object is a pure string, it will be parsed as a TCL dict.
 func (o *Obj) Dict() (*DictType, error) {
+	if err := o.checkOpen(); err != nil {
+		return nil, err
+	}
 	// Try existing dict rep first
 	if d, err := asDict(o); err == nil {
 		return d, nil
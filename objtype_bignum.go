@@ -0,0 +1,23 @@
+package feather
+
+import "math/big"
+
+// BignumType is the internal representation for integers outside the int64 range.
+//
+// expr promotes to it when integer arithmetic overflows. Values that fit in
+// an int64 are always stored as [IntType] instead, so commands that need a
+// machine integer reject bignums with the usual "expected integer" error.
+type BignumType struct {
+	Value *big.Int
+}
+
+func (t BignumType) Name() string         { return "bignum" }
+func (t BignumType) Dup() ObjType         { return BignumType{Value: new(big.Int).Set(t.Value)} }
+func (t BignumType) UpdateString() string { return t.Value.String() }
+
+func (t BignumType) IntoDouble() (float64, bool) {
+	f, _ := new(big.Float).SetInt(t.Value).Float64()
+	return f, true
+}
+
+func (t BignumType) IntoBool() (bool, bool) { return t.Value.Sign() != 0, true }
@@ -0,0 +1,24 @@
+package feather
+
+import (
+	"slices"
+	"strings"
+)
+
+// ByteArrayType is the internal representation for binary data.
+//
+// Its string form maps each byte to the character with the same code point
+// (U+0000 to U+00FF), so binary data keeps its exact bytes no matter how
+// often the value is converted to a string and back.
+type ByteArrayType []byte
+
+func (t ByteArrayType) Name() string { return "bytearray" }
+func (t ByteArrayType) Dup() ObjType { return ByteArrayType(slices.Clone(t)) }
+func (t ByteArrayType) UpdateString() string {
+	var result strings.Builder
+	result.Grow(len(t))
+	for _, b := range t {
+		result.WriteRune(rune(b))
+	}
+	return result.String()
+}
@@ -1,13 +1,35 @@
 package feather
 
-import "strings"
+import (
+	"maps"
+	"slices"
+)
 
 // DictType is the internal representation for dictionary values.
+//
+// Copies of a dict made by the interpreter share Items and Order until
+// one of them is modified, so modify them directly only in dicts built
+// from Go, such as with [Interp.Dict] or a [DictBuilder].
 type DictType struct {
 	Items map[string]*Obj
 	Order []string
+
+	shared bool       // Items and Order may be shared with another dict
+	holder dictHolder // what may refer to the object holding the dict
 }
 
+// dictHolder records what may refer to the object holding a dict, which
+// decides whether the dict commands may change the object in place or must
+// change a copy. Objects are not reference counted, so the record only
+// ever moves towards heldAnywhere, except for a copy that replaces it.
+type dictHolder uint8
+
+const (
+	heldAnywhere dictHolder = iota // the object may be shared, so it is copied before a change
+	heldByNone                     // the object was created by the command changing it
+	heldByVar                      // the object is the value of one variable and nothing else
+)
+
 func (t *DictType) Name() string { return "dict" }
 
 func (t *DictType) Dup() ObjType {
@@ -20,33 +42,82 @@ func (t *DictType) Dup() ObjType {
 	return &DictType{Items: newItems, Order: newOrder}
 }
 
-func (t *DictType) UpdateString() string {
-	var result strings.Builder
-	for i, key := range t.Order {
-		if i > 0 {
-			result.WriteByte(' ')
-		}
-		// Quote key if needed
-		if len(key) == 0 || strings.ContainsAny(key, " \t\n{}") {
-			result.WriteByte('{')
-			result.WriteString(key)
-			result.WriteByte('}')
+// share returns a dict with the contents of t that shares its storage
+// until either of them is modified.
+func (t *DictType) share() *DictType {
+	t.shared = true
+	return &DictType{Items: t.Items, Order: t.Order, shared: true}
+}
+
+// dictStored records that o, whose variable held old, is now the value of
+// that variable. A dict that was held by nothing is held by the variable
+// alone; one that was held elsewhere is now shared.
+func dictStored(old, o *Obj) {
+	if o == nil || o == old {
+		return
+	}
+	if d, ok := o.intrep.(*DictType); ok {
+		if d.holder == heldByNone {
+			d.holder = heldByVar
 		} else {
-			result.WriteString(key)
+			d.holder = heldAnywhere
 		}
-		result.WriteByte(' ')
-		// Quote value if needed
-		val := t.Items[key]
-		s := val.String()
-		if len(s) == 0 || strings.ContainsAny(s, " \t\n{}") {
-			result.WriteByte('{')
-			result.WriteString(s)
-			result.WriteByte('}')
-		} else {
-			result.WriteString(s)
+	}
+}
+
+// dictShared records that o may now be referred to from anywhere, as an
+// element of a list or dict, or by Go code, and returns o.
+func dictShared(o *Obj) *Obj {
+	if o != nil {
+		if d, ok := o.intrep.(*DictType); ok {
+			d.holder = heldAnywhere
+		}
+	}
+	return o
+}
+
+// ownDict returns h, or the handle of a copy of its object that shares the
+// dict's storage if the object may be shared, so that the caller may change
+// the dict of the object returned in place.
+func (i *Interp) ownDict(h FeatherObj) FeatherObj {
+	o := i.getObject(h)
+	if o == nil {
+		return h
+	}
+	if d, ok := o.intrep.(*DictType); ok && d.holder != heldAnywhere {
+		return h
+	}
+	d, err := o.Dict()
+	if err != nil {
+		return h
+	}
+	c := d.share()
+	c.holder = heldByNone
+	return i.registerObj(i.Obj(c))
+}
+
+// unshare gives t storage of its own if it may share it with another dict,
+// so that it can be modified in place.
+func (t *DictType) unshare() {
+	if !t.shared {
+		return
+	}
+	t.Items = maps.Clone(t.Items)
+	t.Order = slices.Clone(t.Order)
+	t.shared = false
+}
+
+func (t *DictType) UpdateString() string {
+	var buf []byte
+	for _, key := range t.Order {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
 		}
+		buf = appendListElement(buf, key, len(buf) == 0)
+		buf = append(buf, ' ')
+		buf = appendListElement(buf, t.Items[key].String(), false)
 	}
-	return result.String()
+	return string(buf)
 }
 
 func (t *DictType) IntoDict() (map[string]*Obj, []string, bool) {
@@ -0,0 +1,88 @@
+package feather
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// IndexType is the internal representation of a word that was looked up in
+// a table of names, such as a subcommand or option name.
+//
+// It remembers the table and the position of the match, so looking up the
+// same object again, as happens when a literal word is reused by a loop
+// body or proc, skips the string comparisons.
+type IndexType struct {
+	table uintptr // identity of the table the lookup used
+	exact bool    // whether the word matched the entry in full
+	word  string
+	Index int
+}
+
+func (t IndexType) Name() string         { return "index" }
+func (t IndexType) Dup() ObjType         { return t }
+func (t IndexType) UpdateString() string { return t.word }
+
+// tableKey identifies a Go lookup table by the address of its first element.
+func tableKey(table []string) uintptr {
+	return uintptr(unsafe.Pointer(unsafe.SliceData(table)))
+}
+
+// lookupIndex finds s in table. An exact match wins; otherwise a unique
+// prefix matches unless exact is set. ambiguous reports a prefix that
+// matched more than one entry.
+func lookupIndex(s string, table []string, exact bool) (index int, full, ambiguous bool) {
+	index = -1
+	for j, name := range table {
+		if name == s {
+			return j, true, false
+		}
+		if !exact && s != "" && strings.HasPrefix(name, s) {
+			if index >= 0 {
+				ambiguous = true
+			}
+			index = j
+		}
+	}
+	if ambiguous {
+		return -1, false, true
+	}
+	return index, false, false
+}
+
+// getIndex resolves obj against table, using and updating the cached index
+// on obj. key identifies the table and must stay the same across calls.
+func getIndex(obj *Obj, key uintptr, table []string, what string, exact bool) (int, error) {
+	if t, ok := obj.intrep.(IndexType); ok && t.table == key && (t.exact || !exact) && t.Index < len(table) {
+		return t.Index, nil
+	}
+	s := obj.String()
+	index, full, ambiguous := lookupIndex(s, table, exact)
+	if index < 0 {
+		kind := "bad"
+		if ambiguous {
+			kind = "ambiguous"
+		}
+		return -1, fmt.Errorf("%s %s \"%s\": must be %s", kind, what, s, joinAlternatives(table))
+	}
+	// Keep richer representations such as lists; only pure strings and
+	// earlier lookups are worth replacing.
+	if obj.intrep == nil || obj.Type() == "index" {
+		obj.bytes = s
+		obj.intrep = IndexType{table: key, exact: full, word: s, Index: index}
+	}
+	return index, nil
+}
+
+// joinAlternatives lists names for error messages: "a", "a or b", "a, b, or c".
+func joinAlternatives(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " or " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
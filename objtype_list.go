@@ -2,7 +2,7 @@ package feather
 
 import (
 	"slices"
-	"strings"
+	"unsafe"
 )
 
 // ListType is the internal representation for list values.
@@ -11,22 +11,19 @@ type ListType []*Obj
 func (t ListType) Name() string { return "list" }
 func (t ListType) Dup() ObjType { return ListType(slices.Clone(t)) }
 func (t ListType) UpdateString() string {
-	var result strings.Builder
-	for i, item := range t {
-		if i > 0 {
-			result.WriteByte(' ')
-		}
-		s := item.String()
-		// Quote strings that contain spaces, special chars, or are empty
-		if len(s) == 0 || strings.ContainsAny(s, " \t\n{}") {
-			result.WriteByte('{')
-			result.WriteString(s)
-			result.WriteByte('}')
-		} else {
-			result.WriteString(s)
+	return string(appendListText(nil, t))
+}
+
+// appendListText appends the string of items to buf, which holds the
+// string of the elements before them, if any.
+func appendListText(buf []byte, items []*Obj) []byte {
+	for _, item := range items {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
 		}
+		buf = appendListElement(buf, item.String(), len(buf) == 0)
 	}
-	return result.String()
+	return buf
 }
 
 func (t ListType) IntoList() ([]*Obj, bool) { return t, true }
@@ -46,3 +43,124 @@ func (t ListType) IntoDict() (map[string]*Obj, []string, bool) {
 	}
 	return items, order, true
 }
+
+// listState is kept for list objects that share their elements with other
+// lists or have been appended to. It lets appends work in place and lets
+// the string of the list be extended rather than regenerated.
+type listState struct {
+	share *listShare // lists viewing the same backing array, if any
+
+	// prefix is the string of the first count elements, kept when the list
+	// is appended to. It may be the start of text, in which case the list
+	// extends text in place if no other list has done so.
+	prefix string
+	count  int
+	text   *listText
+}
+
+// listText is a buffer holding list strings, shared by the lists whose
+// prefix it holds.
+type listText struct {
+	buf []byte
+}
+
+// listShare is shared by the list objects that view one backing array, so
+// that one of them at a time can append in place. A list may append in
+// place only if its free capacity equals free, meaning no other list has
+// claimed the elements after its own.
+type listShare struct {
+	end  **Obj // last element of the backing array, identifying it
+	free int   // free capacity after the longest list
+}
+
+// listEnd returns the address of the last element of the backing array of
+// items, or nil if it has no capacity.
+func listEnd(items []*Obj) **Obj {
+	if cap(items) == 0 {
+		return nil
+	}
+	return &items[:cap(items)][cap(items)-1]
+}
+
+// listState returns the list state of o, creating it if needed.
+func (o *Obj) listState() *listState {
+	if o.list == nil {
+		o.list = &listState{}
+	}
+	return o.list
+}
+
+// shareList returns a new list object with the elements items[first:last]
+// of the list o, sharing its backing array rather than copying it.
+func (i *Interp) shareList(o *Obj, items []*Obj, first, last int) *Obj {
+	end := listEnd(items)
+	if end == nil || first >= last {
+		return i.List()
+	}
+	st := o.listState()
+	if st.share == nil || st.share.end != end {
+		st.share = &listShare{end: end, free: cap(items) - len(items)}
+	}
+	view := &listState{share: st.share}
+	if first == 0 && o.bytes != "" && o.bytes == st.prefix && last >= st.count {
+		view.prefix, view.count, view.text = st.prefix, st.count, st.text
+	}
+	return &Obj{intrep: ListType(items[first:last]), interp: i, list: view}
+}
+
+// appendList appends item to items, the elements of the list o, and
+// invalidates the string of o. It appends in place unless that would
+// change a list sharing the backing array, in which case the elements are
+// copied first. The string of the elements before item is kept, so that
+// the new string only adds that of item.
+func (o *Obj) appendList(items []*Obj, item *Obj) {
+	st := o.listState()
+	if o.bytes != "" && o.bytes != st.prefix {
+		// The string was not built by the list, so it may not be canonical
+		st.prefix, st.count = "", 0
+	}
+	o.bytes = ""
+	if s := st.share; s != nil && s.end == listEnd(items) {
+		free := cap(items) - len(items)
+		if free == 0 || free != s.free {
+			st.share = nil
+			o.intrep = ListType(append(items[:len(items):len(items)], item))
+			return
+		}
+		s.free--
+	}
+	o.intrep = ListType(append(items, item))
+}
+
+// listString returns the string of items, the elements of a list with
+// state st, extending the kept prefix.
+func (st *listState) listString(items []*Obj) string {
+	t := st.text
+	if t == nil || len(st.prefix) == 0 || len(t.buf) != len(st.prefix) || unsafe.StringData(st.prefix) != &t.buf[0] {
+		// Another list has extended the text, so start a new one
+		buf := make([]byte, len(st.prefix), 2*len(st.prefix))
+		copy(buf, st.prefix)
+		t = &listText{buf: buf}
+		st.text = t
+	}
+	t.buf = appendListText(t.buf, items[st.count:])
+	if len(t.buf) == 0 {
+		st.prefix, st.count = "", 0
+		return ""
+	}
+	st.prefix = unsafe.String(&t.buf[0], len(t.buf))
+	st.count = len(items)
+	return st.prefix
+}
+
+// ownList returns items, the elements of the list o, copied first if their
+// backing array is shared with another list, so that they can be modified
+// in place.
+func ownList(o *Obj, items []*Obj) []*Obj {
+	if o.list != nil && o.list.share != nil && o.list.share.end == listEnd(items) {
+		items = slices.Clone(items)
+		o.intrep = ListType(items)
+		o.list.share = nil
+	}
+	return items
+}
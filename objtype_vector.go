@@ -0,0 +1,61 @@
+package feather
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IntSliceType is the internal representation for a list of integers
+// created from Go with [Interp.IntSlice].
+//
+// It stores the numbers unboxed, so handing a large vector to a script and
+// reading it back with [AsIntSlice] costs one copy instead of one *Obj per
+// element. The first list operation on the value converts it to a [ListType].
+type IntSliceType []int64
+
+func (t IntSliceType) Name() string { return "intslice" }
+func (t IntSliceType) Dup() ObjType { return IntSliceType(slices.Clone(t)) }
+func (t IntSliceType) UpdateString() string {
+	var result strings.Builder
+	for i, v := range t {
+		if i > 0 {
+			result.WriteByte(' ')
+		}
+		result.WriteString(strconv.FormatInt(v, 10))
+	}
+	return result.String()
+}
+
+func (t IntSliceType) IntoList() ([]*Obj, bool) {
+	items := make([]*Obj, len(t))
+	for i, v := range t {
+		items[i] = &Obj{intrep: IntType(v)}
+	}
+	return items, true
+}
+
+// FloatSliceType is the internal representation for a list of floating-point
+// numbers created from Go with [Interp.FloatSlice]. See [IntSliceType].
+type FloatSliceType []float64
+
+func (t FloatSliceType) Name() string { return "floatslice" }
+func (t FloatSliceType) Dup() ObjType { return FloatSliceType(slices.Clone(t)) }
+func (t FloatSliceType) UpdateString() string {
+	var result strings.Builder
+	for i, v := range t {
+		if i > 0 {
+			result.WriteByte(' ')
+		}
+		result.WriteString(DoubleType(v).UpdateString())
+	}
+	return result.String()
+}
+
+func (t FloatSliceType) IntoList() ([]*Obj, bool) {
+	items := make([]*Obj, len(t))
+	for i, v := range t {
+		items[i] = &Obj{intrep: DoubleType(v)}
+	}
+	return items, true
+}
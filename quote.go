@@ -0,0 +1,301 @@
+package feather
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuoteListElement returns s quoted as an element of a TCL list, in the
+// canonical form the list command produces: unchanged if it needs no
+// quoting, in braces if braces can hold it, and with backslash escapes
+// otherwise, as for unbalanced braces or a trailing backslash. The result
+// also reads back as s when used as a word of a script, so it is safe for
+// building commands.
+//
+//	feather.QuoteListElement("a b")  // {a b}
+//	feather.QuoteListElement("a{b")  // a\{b
+//	feather.QuoteListElement("")     // {}
+func QuoteListElement(s string) string {
+	return string(appendListElement(nil, s, true))
+}
+
+// JoinList returns the canonical string of a list with the given elements,
+// each quoted as by [QuoteListElement] and separated by single spaces.
+// [SplitList] returns the elements again.
+//
+//	feather.JoinList([]string{"a", "b c", ""})  // a {b c} {}
+func JoinList(elems []string) string {
+	var buf []byte
+	for _, s := range elems {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendListElement(buf, s, len(buf) == 0)
+	}
+	return string(buf)
+}
+
+// SplitList parses s as a TCL list and returns its elements, without
+// needing an interpreter. Elements are separated by whitespace; an element
+// in braces is taken literally, while backslash escapes are substituted in
+// elements in double quotes or without quotes. It returns an error for an
+// unmatched brace or quote, or for a brace or quote that closes an element
+// but is not followed by whitespace.
+//
+//	feather.SplitList(`a {b c} "d\te"`)  // ["a", "b c", "d\te"]
+func SplitList(s string) ([]string, error) {
+	var elems []string
+	pos := 0
+	for {
+		for pos < len(s) && isListSpace(s[pos]) {
+			pos++
+		}
+		if pos >= len(s) {
+			return elems, nil
+		}
+
+		switch s[pos] {
+		case '{':
+			start := pos + 1
+			depth := 1
+			for pos++; pos < len(s) && depth > 0; pos++ {
+				switch s[pos] {
+				case '\\':
+					if pos+1 < len(s) {
+						pos++
+					}
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if depth > 0 {
+				return nil, errors.New("unmatched open brace in list")
+			}
+			if err := checkListElementEnd(s, pos, "braces"); err != nil {
+				return nil, err
+			}
+			elems = append(elems, s[start:pos-1])
+		case '"':
+			var word strings.Builder
+			pos++
+			for pos < len(s) && s[pos] != '"' {
+				pos = appendListChar(&word, s, pos)
+			}
+			if pos >= len(s) {
+				return nil, errors.New("unmatched open quote in list")
+			}
+			pos++
+			if err := checkListElementEnd(s, pos, "quotes"); err != nil {
+				return nil, err
+			}
+			elems = append(elems, word.String())
+		default:
+			var word strings.Builder
+			for pos < len(s) && !isListSpace(s[pos]) {
+				pos = appendListChar(&word, s, pos)
+			}
+			elems = append(elems, word.String())
+		}
+	}
+}
+
+// isListSpace reports whether c separates list elements.
+func isListSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+// checkListElementEnd returns an error unless pos, just after the closing
+// brace or quote of an element, is at whitespace or the end of s.
+func checkListElementEnd(s string, pos int, kind string) error {
+	if pos >= len(s) || isListSpace(s[pos]) {
+		return nil
+	}
+	end := pos
+	for end < len(s) && !isListSpace(s[end]) {
+		end++
+	}
+	return errors.New("list element in " + kind + " followed by \"" + s[pos:end] + "\" instead of space")
+}
+
+// appendListChar appends the character of s at pos to word, substituting
+// it first if it starts a backslash escape, and returns the position after
+// it.
+func appendListChar(word *strings.Builder, s string, pos int) int {
+	if s[pos] != '\\' || pos+1 >= len(s) {
+		word.WriteByte(s[pos])
+		return pos + 1
+	}
+	pos++
+	switch c := s[pos]; c {
+	case 'a':
+		word.WriteByte('\a')
+	case 'b':
+		word.WriteByte('\b')
+	case 'f':
+		word.WriteByte('\f')
+	case 'n':
+		word.WriteByte('\n')
+	case 'r':
+		word.WriteByte('\r')
+	case 't':
+		word.WriteByte('\t')
+	case 'v':
+		word.WriteByte('\v')
+	case '\n':
+		// A backslash-newline and the spaces after it become one space
+		pos++
+		for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t') {
+			pos++
+		}
+		word.WriteByte(' ')
+		return pos
+	case 'x', 'u', 'U':
+		maxDigits := 2
+		if c == 'u' {
+			maxDigits = 4
+		} else if c == 'U' {
+			maxDigits = 8
+		}
+		r, n := 0, 0
+		for n < maxDigits && pos+1+n < len(s) {
+			d, ok := hexDigit(s[pos+1+n])
+			if !ok || r*16+int(d) > utf8.MaxRune {
+				break
+			}
+			r = r*16 + int(d)
+			n++
+		}
+		if n == 0 {
+			word.WriteByte(c)
+			return pos + 1
+		}
+		word.WriteRune(rune(r))
+		return pos + 1 + n
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		r, n := 0, 0
+		for n < 3 && pos+n < len(s) && s[pos+n] >= '0' && s[pos+n] <= '7' && r*8+int(s[pos+n]-'0') <= 0377 {
+			r = r*8 + int(s[pos+n]-'0')
+			n++
+		}
+		word.WriteRune(rune(r))
+		return pos + n
+	default:
+		// Any other character stands for itself
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		word.WriteString(s[pos : pos+size])
+		return pos + size
+	}
+	return pos + 1
+}
+
+// How appendListElement quotes an element
+const (
+	quoteNone       = iota
+	quoteBraces     // in braces
+	quoteEscapes    // with backslashes, leaving balanced braces alone
+	quoteEscapesAll // with backslashes, braces too
+)
+
+// scanListElement returns how s is quoted as a list element, following
+// TCL's rules: braces where they are enough, as they keep the element
+// readable, and backslash escapes where braces cannot hold it or where only
+// a ] or " needs quoting. A leading # is quoted when quoteHash is set, as it
+// is for the first element of a list, which would otherwise start a comment
+// when the list is evaluated.
+func scanListElement(s string, quoteHash bool) int {
+	if s == "" {
+		return quoteBraces
+	}
+	needsQuote := s[0] == '{' || s[0] == '"' || (quoteHash && s[0] == '#')
+	preferBraces := needsQuote
+	preferEscapes := false
+	requireEscapes := false
+	depth := 0
+	for j := 0; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				requireEscapes = true
+			}
+		case ']', '"':
+			needsQuote = true
+			preferEscapes = true
+		case '[', '$', ';', ' ', '\f', '\n', '\r', '\t', '\v':
+			needsQuote = true
+			preferBraces = true
+		case '\\':
+			if j+1 == len(s) || s[j+1] == '\n' {
+				// A trailing backslash would escape the closing brace, and
+				// braces would keep a backslash-newline from reading back
+				requireEscapes = true
+				j++
+				continue
+			}
+			if s[j+1] == '{' || s[j+1] == '}' || s[j+1] == '\\' {
+				j++
+			}
+			needsQuote = true
+			preferBraces = true
+		}
+	}
+	switch {
+	case requireEscapes || depth != 0:
+		return quoteEscapesAll
+	case preferEscapes && !preferBraces:
+		return quoteEscapes
+	case needsQuote:
+		return quoteBraces
+	}
+	return quoteNone
+}
+
+// appendListElement appends s to buf quoted as a list element, as
+// described by scanListElement.
+func appendListElement(buf []byte, s string, quoteHash bool) []byte {
+	quoting := scanListElement(s, quoteHash)
+	switch quoting {
+	case quoteNone:
+		return append(buf, s...)
+	case quoteBraces:
+		buf = append(buf, '{')
+		buf = append(buf, s...)
+		return append(buf, '}')
+	}
+	if quoteHash && s[0] == '#' {
+		buf = append(buf, '\\')
+	}
+	for j := 0; j < len(s); j++ {
+		switch c := s[j]; c {
+		case ']', '[', '$', ';', ' ', '\\', '"':
+			buf = append(buf, '\\', c)
+		case '{', '}':
+			if quoting == quoteEscapesAll {
+				buf = append(buf, '\\')
+			}
+			buf = append(buf, c)
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\v':
+			buf = append(buf, '\\', 'v')
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
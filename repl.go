@@ -0,0 +1,133 @@
+package feather
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// REPL evaluates interactive input a line at a time. Lines are buffered
+// until they form complete commands, so a brace, bracket or quote left
+// open on one line continues the command on the next, as in tclsh.
+//
+//	repl := feather.NewREPL(interp)
+//	repl.Run(os.Stdin, os.Stdout)
+//
+// Programs that read lines themselves, such as with a line editor, call
+// [REPL.Feed] with each line instead of Run.
+type REPL struct {
+	// Prompt1 is shown before the first line of a command and Prompt2
+	// before the lines that continue an incomplete one. NewREPL sets them
+	// to "% " and "> ".
+	Prompt1, Prompt2 string
+
+	// PrintEmpty makes Run print empty results as blank lines. By default
+	// they are not printed.
+	PrintEmpty bool
+
+	// Errors is where Run writes error messages. If nil, they are written
+	// with the results.
+	Errors io.Writer
+
+	// History, if set, is called with each complete command that is not
+	// blank, before it is evaluated.
+	History func(command string)
+
+	interp  *Interp
+	pending []string // lines of the incomplete command
+}
+
+// ReplResult is the outcome of a line fed to a [REPL].
+type ReplResult struct {
+	// Incomplete reports that the line did not complete a command, so
+	// nothing was evaluated.
+	Incomplete bool
+
+	// Command is the command that was evaluated: the line, joined to the
+	// lines buffered before it.
+	Command string
+
+	// Value is the result of the command, if it succeeded.
+	Value *Obj
+}
+
+// NewREPL returns a REPL that evaluates commands in interp.
+func NewREPL(interp *Interp) *REPL {
+	return &REPL{Prompt1: "% ", Prompt2: "> ", interp: interp}
+}
+
+// Feed adds a line of input, without its line terminator. If the line
+// completes a command, Feed evaluates it and returns its result; the error
+// is that of the evaluation. Otherwise the line is buffered and Feed returns
+// a result with Incomplete set.
+//
+// Input with a syntax error other than an unclosed brace, bracket or quote
+// is evaluated as it is, so that it fails with the same error as it would
+// in a script.
+func (r *REPL) Feed(line string) (ReplResult, error) {
+	r.pending = append(r.pending, line)
+	command := strings.Join(r.pending, "\n")
+	if r.interp.Parse(command).Status == ParseIncomplete {
+		return ReplResult{Incomplete: true}, nil
+	}
+	r.pending = r.pending[:0]
+	if r.History != nil && strings.TrimSpace(command) != "" {
+		r.History(command)
+	}
+	value, err := r.interp.Eval(command)
+	return ReplResult{Command: command, Value: value}, err
+}
+
+// Prompt returns the prompt for the next line: Prompt2 if an incomplete
+// command is buffered and Prompt1 otherwise.
+func (r *REPL) Prompt() string {
+	if len(r.pending) > 0 {
+		return r.Prompt2
+	}
+	return r.Prompt1
+}
+
+// Pending returns the buffered lines of an incomplete command, joined by
+// newlines, or "" if there are none.
+func (r *REPL) Pending() string {
+	return strings.Join(r.pending, "\n")
+}
+
+// Discard drops the buffered lines of an incomplete command, as when the
+// user interrupts it.
+func (r *REPL) Discard() {
+	r.pending = r.pending[:0]
+}
+
+// Run reads lines from in until it ends, writing prompts and results to
+// out. An error message is written for each command that fails, prefixed
+// with "error: ". At the end of in, an incomplete command is discarded.
+// Run returns an error only if reading or writing fails.
+func (r *REPL) Run(in io.Reader, out io.Writer) error {
+	errs := r.Errors
+	if errs == nil {
+		errs = out
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		if _, err := io.WriteString(out, r.Prompt()); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		res, err := r.Feed(scanner.Text())
+		switch {
+		case res.Incomplete:
+			continue
+		case err != nil:
+			_, err = fmt.Fprintf(errs, "error: %s\n", err.Error())
+		case r.PrintEmpty || res.Value.String() != "":
+			_, err = fmt.Fprintln(out, res.Value.String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package feather
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// modulePath is the import path reported as the feather version in a [Report].
+const modulePath = "github.com/feather-lang/feather"
+
+// ReportEvent is one entry in the recent activity an interpreter keeps for
+// [Interp.Report].
+type ReportEvent struct {
+	Kind   string `json:"kind"`   // "eval" for a script passed to Eval, "command" for a Go command
+	Text   string `json:"text"`   // the script, or the command and its arguments as a list
+	Code   string `json:"code"`   // ok, error, return, break or continue
+	Result string `json:"result"` // the result or error message
+}
+
+// Report is a snapshot of an interpreter's environment for attaching to a
+// bug report: the script that shows the problem, the interpreter settings,
+// the commands it had, version information and the last trace events.
+//
+// Capture one with [Interp.Report], save it with [Report.Write], and load
+// it elsewhere with [ReadReport] and [Report.Replay]:
+//
+//	interp.SetReportEvents(100)
+//	_, err := interp.Eval(script)
+//	if err != nil {
+//	    f, _ := os.Create("feather-report.zip")
+//	    interp.Report(script).Write(f)
+//	    f.Close()
+//	}
+type Report struct {
+	Version   string            `json:"version"`   // feather module version, "(devel)" for a local build
+	GoVersion string            `json:"goVersion"` // Go toolchain the program was built with
+	Platform  string            `json:"platform"`  // GOOS/GOARCH
+	Config    map[string]string `json:"config"`    // interpreter settings
+	Commands  []string          `json:"commands"`  // fully qualified names of builtin and Go commands, sorted
+	Events    []ReportEvent     `json:"events"`    // oldest first
+	Script    string            `json:"-"`         // stored as script.tcl in the archive
+}
+
+// SetReportEvents sets how many trace events the interpreter keeps for
+// [Interp.Report]. Events are recorded for each [Interp.Eval] and each call
+// to a command registered from Go. Recording is off by default; a limit of
+// 0 or less turns it off and discards the events kept so far.
+func (i *Interp) SetReportEvents(n int) {
+	if n <= 0 {
+		i.reportLimit = 0
+		i.reportEvents = nil
+		return
+	}
+	i.reportLimit = n
+	if len(i.reportEvents) > n {
+		i.reportEvents = slices.Clone(i.reportEvents[len(i.reportEvents)-n:])
+	}
+}
+
+// recordEvent adds an event, dropping the oldest one once the limit is reached.
+func (i *Interp) recordEvent(kind, text string, code FeatherResult, result string) {
+	if i.reportLimit <= 0 {
+		return
+	}
+	if len(i.reportEvents) == i.reportLimit {
+		copy(i.reportEvents, i.reportEvents[1:])
+		i.reportEvents = i.reportEvents[:len(i.reportEvents)-1]
+	}
+	i.reportEvents = append(i.reportEvents, ReportEvent{
+		Kind:   kind,
+		Text:   text,
+		Code:   resultCodeName(code),
+		Result: result,
+	})
+}
+
+// resultCodeName returns the name return -code uses for code.
+func resultCodeName(code FeatherResult) string {
+	switch code {
+	case ResultOK:
+		return "ok"
+	case ResultError:
+		return "error"
+	case ResultReturn:
+		return "return"
+	case ResultBreak:
+		return "break"
+	case ResultContinue:
+		return "continue"
+	}
+	return strconv.Itoa(int(code))
+}
+
+// Report captures the interpreter's environment together with script, the
+// script that reproduces the problem being reported.
+func (i *Interp) Report(script string) *Report {
+	r := &Report{
+		Version:   moduleVersion(),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Config: map[string]string{
+			"recursionlimit": strconv.Itoa(i.getRecursionLimit()),
+			"encoding":       i.encoding,
+			"unknownhandler": strconv.FormatBool(i.unknownHandler != nil),
+		},
+		Events: slices.Clone(i.reportEvents),
+		Script: script,
+	}
+	if i.ForeignRegistry != nil {
+		i.ForeignRegistry.mu.RLock()
+		types := make([]string, 0, len(i.ForeignRegistry.types))
+		for name := range i.ForeignRegistry.types {
+			types = append(types, name)
+		}
+		i.ForeignRegistry.mu.RUnlock()
+		slices.Sort(types)
+		r.Config["types"] = strings.Join(types, " ")
+	}
+	r.Commands = i.registeredCommands()
+	return r
+}
+
+// registeredCommands returns the fully qualified names of all commands
+// except procs, sorted. Procs are left out because scripts define them.
+func (i *Interp) registeredCommands() []string {
+	var names []string
+	for path, ns := range i.namespaces {
+		prefix := path + "::"
+		if path == "::" {
+			prefix = "::"
+		}
+		for name, cmd := range ns.commands {
+			if cmd.cmdType == CmdProc {
+				continue
+			}
+			names = append(names, prefix+name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// moduleVersion returns the version of feather linked into the program.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(unknown)"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+// Write stores the report as a zip archive holding report.json and
+// script.tcl.
+func (r *Report) Write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("report.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return err
+	}
+	f, err = zw.Create("script.tcl")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, r.Script); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadReport loads a report written by [Report.Write].
+func ReadReport(ra io.ReaderAt, size int64) (*Report, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readZipFile(zr, "report.json")
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("report.json: %w", err)
+	}
+	script, err := readZipFile(zr, "script.tcl")
+	if err != nil {
+		return nil, err
+	}
+	r.Script = string(script)
+	return r, nil
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("report archive: %w", err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// MissingCommands returns the commands the reporting interpreter had that
+// interp lacks, typically commands the reporting program registered from
+// Go. Register them before calling [Report.Replay].
+func (r *Report) MissingCommands(interp *Interp) []string {
+	have := interp.registeredCommands()
+	var missing []string
+	for _, name := range r.Commands {
+		if _, found := slices.BinarySearch(have, name); !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Replay applies the reported settings to interp and evaluates the
+// reported script in it.
+func (r *Report) Replay(interp *Interp) (*Obj, error) {
+	if v, err := strconv.Atoi(r.Config["recursionlimit"]); err == nil {
+		interp.SetRecursionLimit(v)
+	}
+	if enc := r.Config["encoding"]; enc != "" {
+		if _, err := interp.Call("encoding", "system", enc); err != nil {
+			return nil, err
+		}
+	}
+	return interp.Eval(r.Script)
+}
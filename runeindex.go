@@ -0,0 +1,94 @@
+package feather
+
+import "unicode/utf8"
+
+// runeIndexMin is the length in bytes below which strings are decoded
+// directly instead of being indexed.
+const runeIndexMin = 64
+
+// runeIndexStride is the number of characters between recorded offsets.
+const runeIndexStride = 32
+
+// runeIndex finds the characters of a string without decoding it from the
+// start, so that loops over string index and string range stay linear.
+// It is cached on the Obj whose string it indexes.
+type runeIndex struct {
+	s     string // the string indexed
+	count int    // number of characters in s
+
+	// offsets[k] is the byte offset of character k*runeIndexStride.
+	// It is nil if s is ASCII, where characters and bytes coincide.
+	offsets []int
+}
+
+func newRuneIndex(s string) *runeIndex {
+	ri := &runeIndex{s: s}
+	ascii := true
+	for n := 0; n < len(s); n++ {
+		if s[n] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		ri.count = len(s)
+		return ri
+	}
+	for off := range s {
+		if ri.count%runeIndexStride == 0 {
+			ri.offsets = append(ri.offsets, off)
+		}
+		ri.count++
+	}
+	return ri
+}
+
+// offset returns the byte offset of character k, or len(s) if k is the
+// character count.
+func (ri *runeIndex) offset(k int) int {
+	if k >= ri.count {
+		return len(ri.s)
+	}
+	if ri.offsets == nil {
+		return k
+	}
+	off := ri.offsets[k/runeIndexStride]
+	for range k % runeIndexStride {
+		_, size := utf8.DecodeRuneInString(ri.s[off:])
+		off += size
+	}
+	return off
+}
+
+// slice returns characters first through last inclusive, which must be in
+// range.
+func (ri *runeIndex) slice(first, last int) string {
+	start := ri.offset(first)
+	if ri.offsets == nil {
+		return ri.s[start : last+1]
+	}
+	end := start
+	if last/runeIndexStride == first/runeIndexStride {
+		for range last - first + 1 {
+			_, size := utf8.DecodeRuneInString(ri.s[end:])
+			end += size
+		}
+	} else {
+		end = ri.offset(last + 1)
+	}
+	return ri.s[start:end]
+}
+
+// runes returns the rune index of o's string, building it if the string
+// has changed since it was last built. Short strings are indexed afresh
+// each time rather than cached.
+func (o *Obj) runes() *runeIndex {
+	s := o.String()
+	if len(s) < runeIndexMin {
+		return newRuneIndex(s)
+	}
+	if o.runeIdx == nil || o.runeIdx.s != s {
+		o.runeIdx = newRuneIndex(s)
+	}
+	return o.runeIdx
+}
@@ -1,4 +1,5 @@
 #include "arena.h"
+#include "feather.h"
 
 #ifdef FEATHER_WASM_BUILD
 
@@ -27,6 +28,33 @@ size_t feather_arena_capacity(void) {
     return feather_arena_used();
 }
 
+/*
+ * The states of the interpreters outlive each eval, so they come from a
+ * pool of their own rather than the arena. The host takes one when it
+ * creates an interpreter and gives it back when it deletes it.
+ */
+#define FEATHER_MAX_STATES 64
+
+static FeatherInterpState states[FEATHER_MAX_STATES];
+static unsigned char states_used[FEATHER_MAX_STATES];
+
+FeatherInterpState *feather_state_new(void) {
+    for (size_t i = 0; i < FEATHER_MAX_STATES; i++) {
+        if (!states_used[i]) {
+            states_used[i] = 1;
+            states[i] = (FeatherInterpState){0};
+            return &states[i];
+        }
+    }
+    return NULL;
+}
+
+void feather_state_free(FeatherInterpState *state) {
+    if (state != NULL) {
+        states_used[state - states] = 0;
+    }
+}
+
 /* Compatibility shims for existing code */
 void *alloc(size_t size) {
     return feather_arena_alloc(size);
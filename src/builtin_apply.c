@@ -49,15 +49,22 @@ FeatherResult feather_builtin_apply(const FeatherHostOps *ops, FeatherInterp int
   if (paramc > 64) paramc = 64;  // Safety limit
 
   FeatherObj paramsCopy = ops->list.from(interp, params);
+  for (size_t i = 0; i < paramc; i++) {
+    if (feather_check_param_spec(ops, interp, ops->list.at(interp, paramsCopy, i)) != TCL_OK) {
+      return TCL_ERROR;
+    }
+  }
   for (size_t i = 0; i < paramc; i++) {
     FeatherObj param = ops->list.at(interp, paramsCopy, i);
 
     size_t paramListLen = ops->list.length(interp, param);
-    if (paramListLen == 2) {
-      param_types[i] = 1;  // optional
-    } else if (feather_obj_is_args_param(ops, interp, param)) {
+    FeatherObj paramName = paramListLen == 2 ? ops->list.at(interp, param, 0) : param;
+    if (i == paramc - 1 && feather_obj_is_args_param(ops, interp, paramName)) {
+      // Only a trailing "args" is variadic; any default it has is ignored
       param_types[i] = 2;  // args
       is_variadic = 1;
+    } else if (paramListLen == 2) {
+      param_types[i] = 1;  // optional
     } else {
       param_types[i] = 0;  // required
     }
@@ -134,6 +141,9 @@ FeatherResult feather_builtin_apply(const FeatherHostOps *ops, FeatherInterp int
   if (ops->frame.push(interp, applyName, args) != TCL_OK) {
     return TCL_ERROR;
   }
+  FeatherInterpState *state = ops->interp.state(interp);
+  FeatherTailcall savedTail = feather_tailcall_begin(state);
+  size_t lambdaLevel = ops->frame.level(interp);
 
   // Copy line number from parent and store the lambda expression
   ops->frame.set_line(interp, parentLine);
@@ -164,14 +174,15 @@ FeatherResult feather_builtin_apply(const FeatherHostOps *ops, FeatherInterp int
 
     size_t paramListLen = ops->list.length(interp, param);
 
-    if (feather_obj_is_args_param(ops, interp, param) && paramListLen == 1) {
+    if (param_types[i] == 2) {
       FeatherObj collectedArgs = ops->list.create(interp);
       while (arg_index < provided_argc) {
         FeatherObj arg = ops->list.shift(interp, argsCopy);
         collectedArgs = ops->list.push(interp, collectedArgs, arg);
         arg_index++;
       }
-      ops->var.set(interp, param, collectedArgs);
+      FeatherObj argsName = paramListLen == 2 ? ops->list.at(interp, param, 0) : param;
+      ops->var.set(interp, argsName, collectedArgs);
     } else if (paramListLen == 2) {
       FeatherObj paramName = ops->list.at(interp, param, 0);
       if (arg_index < provided_argc) {
@@ -189,9 +200,10 @@ FeatherResult feather_builtin_apply(const FeatherHostOps *ops, FeatherInterp int
     }
   }
 
-  FeatherResult result = feather_script_eval_obj(ops, interp, body, TCL_EVAL_LOCAL);
+  FeatherResult result = feather_body_eval(ops, interp, body);
 
   ops->frame.pop(interp);
+  result = feather_tailcall_end(state, savedTail, lambdaLevel, result);
 
   if (result == TCL_RETURN) {
     FeatherObj opts = ops->interp.get_return_options(interp, result);
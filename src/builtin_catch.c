@@ -68,7 +68,7 @@ FeatherResult feather_builtin_catch(const FeatherHostOps *ops, FeatherInterp int
   // Finalize error state before getting options (transfers accumulated trace to opts)
   if (code == TCL_ERROR) {
     if (feather_error_is_active(ops, interp)) {
-      feather_error_finalize(ops, interp);
+      feather_error_finalize(ops, interp, script);
     } else {
       // Even without active error trace, set ::errorCode and ::errorInfo from return options
       FeatherObj opts = ops->interp.get_return_options(interp, code);
@@ -109,12 +109,8 @@ FeatherResult feather_builtin_catch(const FeatherHostOps *ops, FeatherInterp int
     FeatherObj options = ops->interp.get_return_options(interp, code);
 
     // If no return options were explicitly set, create default ones
-    if (ops->list.is_nil(interp, options)) {
-      options = ops->list.create(interp);
-      options = ops->list.push(interp, options, ops->string.intern(interp, S("-code")));
-      options = ops->list.push(interp, options, ops->integer.create(interp, (int64_t)code));
-      options = ops->list.push(interp, options, ops->string.intern(interp, S("-level")));
-      options = ops->list.push(interp, options, ops->integer.create(interp, 0));
+    if (ops->list.length(interp, options) == 0) {
+      options = feather_return_options(ops, interp, code);
     }
 
     if (feather_set_var(ops, interp, optionsVar, options) != TCL_OK) {
@@ -122,6 +118,11 @@ FeatherResult feather_builtin_catch(const FeatherHostOps *ops, FeatherInterp int
     }
   }
 
+  // The options have been consumed
+  if (code != TCL_OK) {
+    ops->interp.set_return_options(interp, ops->list.create(interp));
+  }
+
   // Return the code as an integer result
   FeatherObj codeResult = ops->integer.create(interp, (int64_t)code);
   ops->interp.set_result(interp, codeResult);
@@ -1,6 +1,120 @@
 #include "feather.h"
 #include "internal.h"
 
+// Maximum number of nested keys handled by dict set, unset and with.
+#define DICT_MAX_DEPTH 64
+
+// dict_from returns obj as a dictionary in *out. If obj is not a valid
+// dictionary it leaves the list parse error, or "missing value to go with
+// key" for an odd number of elements, in the interpreter result.
+static FeatherResult dict_from(const FeatherHostOps *ops, FeatherInterp interp,
+                               FeatherObj obj, FeatherObj *out) {
+  if (ops->dict.is_dict(interp, obj)) {
+    *out = obj;
+    return TCL_OK;
+  }
+  FeatherObj dict = ops->dict.from(interp, obj);
+  if (!ops->list.is_nil(interp, dict)) {
+    *out = dict;
+    return TCL_OK;
+  }
+  if (ops->list.is_nil(interp, ops->list.from(interp, obj))) {
+    return TCL_ERROR; // list parse error already set
+  }
+  ops->interp.set_result(interp, ops->string.intern(interp, "missing value to go with key", 28));
+  return TCL_ERROR;
+}
+
+// dict_read_var reads the dictionary stored in varName into *out. A missing
+// variable reads as an empty dictionary unless mustExist is set.
+static FeatherResult dict_read_var(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj varName, int mustExist, FeatherObj *out) {
+  FeatherObj value;
+  if (feather_get_var(ops, interp, varName, &value) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  if (ops->list.is_nil(interp, value)) {
+    if (mustExist) {
+      FeatherObj msg = ops->string.intern(interp, "can't read \"", 12);
+      msg = ops->string.concat(interp, msg, varName);
+      FeatherObj suffix = ops->string.intern(interp, "\": no such variable", 19);
+      msg = ops->string.concat(interp, msg, suffix);
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
+    }
+    *out = ops->dict.create(interp);
+    return TCL_OK;
+  }
+  return dict_from(ops, interp, value, out);
+}
+
+// dict_key_unknown reports a key missing from a dictionary.
+static FeatherResult dict_key_unknown(const FeatherHostOps *ops, FeatherInterp interp,
+                                      FeatherObj key) {
+  FeatherObj msg = ops->string.intern(interp, "key \"", 5);
+  msg = ops->string.concat(interp, msg, key);
+  FeatherObj suffix = ops->string.intern(interp, "\" not known in dictionary", 25);
+  msg = ops->string.concat(interp, msg, suffix);
+  ops->interp.set_result(interp, msg);
+  return TCL_ERROR;
+}
+
+// dict_path_get follows the first n keys from levels[0], storing each
+// nested dictionary in levels[1..n]. Every key must exist and every level
+// must be a dictionary.
+static FeatherResult dict_path_get(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj keys, size_t n, FeatherObj *levels) {
+  for (size_t i = 0; i < n; i++) {
+    FeatherObj key = ops->list.at(interp, keys, i);
+    FeatherObj nested = ops->dict.get(interp, levels[i], key);
+    if (ops->list.is_nil(interp, nested)) {
+      return dict_key_unknown(ops, interp, key);
+    }
+    if (dict_from(ops, interp, nested, &levels[i + 1]) != TCL_OK) {
+      return TCL_ERROR;
+    }
+  }
+  return TCL_OK;
+}
+
+// dict_path_put stores levels[n] back into its parents, innermost first,
+// and returns the updated outermost dictionary.
+static FeatherObj dict_path_put(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj keys, size_t n, FeatherObj *levels) {
+  for (size_t i = n; i > 0; i--) {
+    FeatherObj key = ops->list.at(interp, keys, i - 1);
+    levels[i - 1] = ops->dict.set(interp, levels[i - 1], key, levels[i]);
+  }
+  return levels[0];
+}
+
+// dict_too_deep reports more nested keys than DICT_MAX_DEPTH.
+static FeatherResult dict_too_deep(const FeatherHostOps *ops, FeatherInterp interp) {
+  FeatherObj msg = ops->string.intern(interp, "too many nested keys", 20);
+  ops->interp.set_result(interp, msg);
+  return TCL_ERROR;
+}
+
+// dict_script_bool interprets the result of a dict filter script.
+static FeatherResult dict_script_bool(const FeatherHostOps *ops, FeatherInterp interp,
+                                      FeatherObj value, int *out) {
+  if (feather_obj_to_bool_literal(ops, interp, value, out)) {
+    return TCL_OK;
+  }
+  int64_t intVal;
+  if (ops->integer.get(interp, value, &intVal) == TCL_OK) {
+    *out = intVal != 0;
+    return TCL_OK;
+  }
+  double dblVal;
+  if (ops->dbl.get(interp, value, &dblVal) == TCL_OK) {
+    *out = dblVal != 0;
+    return TCL_OK;
+  }
+  feather_error_expected(ops, interp, "boolean value", value);
+  return TCL_ERROR;
+}
+
 // dict create ?key value ...?
 static FeatherResult dict_create(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
   size_t argc = ops->list.length(interp, args);
@@ -319,9 +433,8 @@ static FeatherResult dict_append(const FeatherHostOps *ops, FeatherInterp interp
 
   // Get current dict
   FeatherObj dict;
-  feather_get_var(ops, interp, varName, &dict);
-  if (ops->list.is_nil(interp, dict)) {
-    dict = ops->dict.create(interp);
+  if (dict_read_var(ops, interp, varName, 0, &dict) != TCL_OK) {
+    return TCL_ERROR;
   }
 
   // Get current value or empty string
@@ -368,9 +481,8 @@ static FeatherResult dict_incr(const FeatherHostOps *ops, FeatherInterp interp,
 
   // Get current dict
   FeatherObj dict;
-  feather_get_var(ops, interp, varName, &dict);
-  if (ops->list.is_nil(interp, dict)) {
-    dict = ops->dict.create(interp);
+  if (dict_read_var(ops, interp, varName, 0, &dict) != TCL_OK) {
+    return TCL_ERROR;
   }
 
   // Get current value or 0
@@ -408,9 +520,8 @@ static FeatherResult dict_lappend(const FeatherHostOps *ops, FeatherInterp inter
 
   // Get current dict
   FeatherObj dict;
-  feather_get_var(ops, interp, varName, &dict);
-  if (ops->list.is_nil(interp, dict)) {
-    dict = ops->dict.create(interp);
+  if (dict_read_var(ops, interp, varName, 0, &dict) != TCL_OK) {
+    return TCL_ERROR;
   }
 
   // Get current value or empty list
@@ -420,6 +531,9 @@ static FeatherResult dict_lappend(const FeatherHostOps *ops, FeatherInterp inter
   } else {
     // Convert to list if needed (make mutable copy)
     val = ops->list.from(interp, val);
+    if (ops->list.is_nil(interp, val)) {
+      return TCL_ERROR; // list parse error already set
+    }
   }
 
   // Append all values
@@ -447,62 +561,26 @@ static FeatherResult dict_unset(const FeatherHostOps *ops, FeatherInterp interp,
   }
 
   FeatherObj varName = ops->list.shift(interp, args);
-
-  // Get current dict
-  FeatherObj dict;
-  feather_get_var(ops, interp, varName, &dict);
-  if (ops->list.is_nil(interp, dict)) {
-    dict = ops->dict.create(interp);
+  FeatherObj keys = args;
+  size_t numKeys = argc - 1;
+  if (numKeys > DICT_MAX_DEPTH) {
+    return dict_too_deep(ops, interp);
   }
 
-  // Collect keys
-  FeatherObj keys = ops->list.create(interp);
-  while (ops->list.length(interp, args) > 0) {
-    FeatherObj key = ops->list.shift(interp, args);
-    keys = ops->list.push(interp, keys, key);
+  // Every key but the last must lead to an existing dictionary; a missing
+  // last key is not an error
+  FeatherObj levels[DICT_MAX_DEPTH];
+  if (dict_read_var(ops, interp, varName, 0, &levels[0]) != TCL_OK) {
+    return TCL_ERROR;
   }
-
-  size_t numKeys = ops->list.length(interp, keys);
-  if (numKeys == 1) {
-    // Simple case
-    FeatherObj key = ops->list.at(interp, keys, 0);
-    dict = ops->dict.remove(interp, dict, key);
-  } else {
-    // Nested case: navigate and unset
-    FeatherObj dicts[64];
-    if (numKeys > 64) {
-      FeatherObj msg = ops->string.intern(interp, "too many nested keys", 20);
-      ops->interp.set_result(interp, msg);
-      return TCL_ERROR;
-    }
-
-    dicts[0] = dict;
-    for (size_t i = 0; i < numKeys - 1; i++) {
-      FeatherObj key = ops->list.at(interp, keys, i);
-      FeatherObj nested = ops->dict.get(interp, dicts[i], key);
-      if (ops->list.is_nil(interp, nested)) {
-        // Key doesn't exist, nothing to unset
-        if (feather_set_var(ops, interp, varName, dict) != TCL_OK) {
-          return TCL_ERROR;
-        }
-        ops->interp.set_result(interp, dict);
-        return TCL_OK;
-      }
-      dicts[i + 1] = nested;
-    }
-
-    // Remove from innermost
-    FeatherObj innerKey = ops->list.at(interp, keys, numKeys - 1);
-    dicts[numKeys - 1] = ops->dict.remove(interp, dicts[numKeys - 1], innerKey);
-
-    // Rebuild from inside out
-    for (size_t i = numKeys - 1; i > 0; i--) {
-      FeatherObj key = ops->list.at(interp, keys, i - 1);
-      dicts[i - 1] = ops->dict.set(interp, dicts[i - 1], key, dicts[i]);
-    }
-    dict = dicts[0];
+  if (dict_path_get(ops, interp, keys, numKeys - 1, levels) != TCL_OK) {
+    return TCL_ERROR;
   }
 
+  FeatherObj innerKey = ops->list.at(interp, keys, numKeys - 1);
+  levels[numKeys - 1] = ops->dict.remove(interp, levels[numKeys - 1], innerKey);
+  FeatherObj dict = dict_path_put(ops, interp, keys, numKeys - 1, levels);
+
   if (feather_set_var(ops, interp, varName, dict) != TCL_OK) {
     return TCL_ERROR;
   }
@@ -510,17 +588,17 @@ static FeatherResult dict_unset(const FeatherHostOps *ops, FeatherInterp interp,
   return TCL_OK;
 }
 
-// dict for {keyVar valueVar} dictValue body
+// dict for {keyVar valueVar} dictValue script
 static FeatherResult dict_for(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
   if (ops->list.length(interp, args) != 3) {
     FeatherObj msg = ops->string.intern(interp,
-      "wrong # args: should be \"dict for {keyVarName valueVarName} dictionary body\"", 77);
+      "wrong # args: should be \"dict for {keyVarName valueVarName} dictionary script\"", 78);
     ops->interp.set_result(interp, msg);
     return TCL_ERROR;
   }
 
   FeatherObj varSpec = ops->list.shift(interp, args);
-  FeatherObj dict = ops->list.shift(interp, args);
+  FeatherObj dictArg = ops->list.shift(interp, args);
   FeatherObj body = ops->list.shift(interp, args);
 
   // Parse varSpec to get keyVar and valueVar
@@ -534,6 +612,10 @@ static FeatherResult dict_for(const FeatherHostOps *ops, FeatherInterp interp, F
   FeatherObj keyVar = ops->list.at(interp, varList, 0);
   FeatherObj valVar = ops->list.at(interp, varList, 1);
 
+  FeatherObj dict;
+  if (dict_from(ops, interp, dictArg, &dict) != TCL_OK) {
+    return TCL_ERROR;
+  }
   FeatherObj keys = ops->dict.keys(interp, dict);
   size_t numKeys = ops->list.length(interp, keys);
 
@@ -630,6 +712,11 @@ static FeatherResult dict_getdef(const FeatherHostOps *ops, FeatherInterp interp
   return TCL_OK;
 }
 
+// Filter types in the order of DictFilterType, for string.get_index.
+static const char *const dict_filter_types[] = {"key", "script", "value", NULL};
+
+typedef enum { FILTER_KEY, FILTER_SCRIPT, FILTER_VALUE } DictFilterType;
+
 // dict filter dictionary filterType ?arg ...?
 static FeatherResult dict_filter(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
   size_t argc = ops->list.length(interp, args);
@@ -640,60 +727,37 @@ static FeatherResult dict_filter(const FeatherHostOps *ops, FeatherInterp interp
     return TCL_ERROR;
   }
 
-  FeatherObj dict = ops->list.shift(interp, args);
+  FeatherObj dict;
+  if (dict_from(ops, interp, ops->list.shift(interp, args), &dict) != TCL_OK) {
+    return TCL_ERROR;
+  }
   FeatherObj filterType = ops->list.shift(interp, args);
+  int filter;
+  if (ops->string.get_index(interp, filterType, dict_filter_types, "filterType", 0, &filter) != TCL_OK) {
+    return TCL_ERROR;
+  }
 
   FeatherObj result = ops->dict.create(interp);
   FeatherObj keys = ops->dict.keys(interp, dict);
   size_t numKeys = ops->list.length(interp, keys);
 
-  if (feather_obj_eq_literal(ops, interp, filterType, "key")) {
-    // dict filter dictionary key ?pattern ...?
-    for (size_t i = 0; i < numKeys; i++) {
-      FeatherObj key = ops->list.at(interp, keys, i);
-      // Check if key matches any pattern
-      int matched = 0;
-      size_t numPatterns = ops->list.length(interp, args);
-      if (numPatterns == 0) {
-        matched = 1; // No patterns means match all
-      } else {
-        for (size_t p = 0; p < numPatterns; p++) {
-          FeatherObj pattern = ops->list.at(interp, args, p);
-          if (feather_obj_glob_match(ops, interp, pattern, key)) {
-            matched = 1;
-            break;
-          }
-        }
-      }
-      if (matched) {
-        FeatherObj val = ops->dict.get(interp, dict, key);
-        result = ops->dict.set(interp, result, key, val);
-      }
-    }
-  } else if (feather_obj_eq_literal(ops, interp, filterType, "value")) {
-    // dict filter dictionary value ?pattern ...?
+  if (filter == FILTER_KEY || filter == FILTER_VALUE) {
+    // dict filter dictionary key|value ?pattern ...?
+    // A pair is kept when any pattern matches, so no patterns keep nothing
+    size_t numPatterns = ops->list.length(interp, args);
     for (size_t i = 0; i < numKeys; i++) {
       FeatherObj key = ops->list.at(interp, keys, i);
       FeatherObj val = ops->dict.get(interp, dict, key);
-      // Check if value matches any pattern
-      int matched = 0;
-      size_t numPatterns = ops->list.length(interp, args);
-      if (numPatterns == 0) {
-        matched = 1;
-      } else {
-        for (size_t p = 0; p < numPatterns; p++) {
-          FeatherObj pattern = ops->list.at(interp, args, p);
-          if (feather_obj_glob_match(ops, interp, pattern, val)) {
-            matched = 1;
-            break;
-          }
+      FeatherObj subject = filter == FILTER_KEY ? key : val;
+      for (size_t p = 0; p < numPatterns; p++) {
+        FeatherObj pattern = ops->list.at(interp, args, p);
+        if (feather_obj_glob_match(ops, interp, pattern, subject)) {
+          result = ops->dict.set(interp, result, key, val);
+          break;
         }
       }
-      if (matched) {
-        result = ops->dict.set(interp, result, key, val);
-      }
     }
-  } else if (feather_obj_eq_literal(ops, interp, filterType, "script")) {
+  } else {
     // dict filter dictionary script {keyVar valueVar} script
     if (ops->list.length(interp, args) != 2) {
       FeatherObj msg = ops->string.intern(interp,
@@ -734,29 +798,15 @@ static FeatherResult dict_filter(const FeatherHostOps *ops, FeatherInterp interp
         return res;
       }
 
-      // Check if result is true
-      FeatherObj scriptResult = ops->interp.get_result(interp);
-      int boolVal;
-      if (feather_obj_to_bool_literal(ops, interp, scriptResult, &boolVal)) {
-        if (boolVal) {
-          result = ops->dict.set(interp, result, key, val);
-        }
-      } else {
-        int64_t intVal;
-        if (ops->integer.get(interp, scriptResult, &intVal) == TCL_OK) {
-          if (intVal != 0) {
-            result = ops->dict.set(interp, result, key, val);
-          }
-        }
+      // Keep the pair if the script result is true
+      int keep;
+      if (dict_script_bool(ops, interp, ops->interp.get_result(interp), &keep) != TCL_OK) {
+        return TCL_ERROR;
+      }
+      if (keep) {
+        result = ops->dict.set(interp, result, key, val);
       }
     }
-  } else {
-    FeatherObj msg = ops->string.intern(interp, "bad filterType \"", 16);
-    msg = ops->string.concat(interp, msg, filterType);
-    FeatherObj suffix = ops->string.intern(interp, "\": must be key, script, or value", 32);
-    msg = ops->string.concat(interp, msg, suffix);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
   }
 
   ops->interp.set_result(interp, result);
@@ -773,7 +823,7 @@ static FeatherResult dict_map(const FeatherHostOps *ops, FeatherInterp interp, F
   }
 
   FeatherObj varSpec = ops->list.shift(interp, args);
-  FeatherObj dict = ops->list.shift(interp, args);
+  FeatherObj dictArg = ops->list.shift(interp, args);
   FeatherObj script = ops->list.shift(interp, args);
 
   FeatherObj varList = ops->list.from(interp, varSpec);
@@ -785,6 +835,11 @@ static FeatherResult dict_map(const FeatherHostOps *ops, FeatherInterp interp, F
   FeatherObj keyVar = ops->list.at(interp, varList, 0);
   FeatherObj valVar = ops->list.at(interp, varList, 1);
 
+  FeatherObj dict;
+  if (dict_from(ops, interp, dictArg, &dict) != TCL_OK) {
+    return TCL_ERROR;
+  }
+
   FeatherObj result = ops->dict.create(interp);
   FeatherObj keys = ops->dict.keys(interp, dict);
   size_t numKeys = ops->list.length(interp, keys);
@@ -812,8 +867,21 @@ static FeatherResult dict_map(const FeatherHostOps *ops, FeatherInterp interp, F
       return res;
     }
 
+    // The script result is the new value; the key variable, which the
+    // script may have changed, is the new key
     FeatherObj newVal = ops->interp.get_result(interp);
-    result = ops->dict.set(interp, result, key, newVal);
+    FeatherObj newKey;
+    if (feather_get_var(ops, interp, keyVar, &newKey) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (ops->list.is_nil(interp, newKey)) {
+      FeatherObj msg = ops->string.intern(interp, "can't read \"", 12);
+      msg = ops->string.concat(interp, msg, keyVar);
+      msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\": no such variable", 19));
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
+    }
+    result = ops->dict.set(interp, result, newKey, newVal);
   }
 
   ops->interp.set_result(interp, result);
@@ -836,9 +904,8 @@ static FeatherResult dict_update(const FeatherHostOps *ops, FeatherInterp interp
 
   // Get current dict
   FeatherObj dict;
-  feather_get_var(ops, interp, dictVarName, &dict);
-  if (ops->list.is_nil(interp, dict)) {
-    dict = ops->dict.create(interp);
+  if (dict_read_var(ops, interp, dictVarName, 1, &dict) != TCL_OK) {
+    return TCL_ERROR;
   }
 
   // Collect key-varName pairs
@@ -855,12 +922,14 @@ static FeatherResult dict_update(const FeatherHostOps *ops, FeatherInterp interp
     dictKeys[i] = ops->list.shift(interp, args);
     varNames[i] = ops->list.shift(interp, args);
 
-    // Set variable to dict value (or leave unset if key doesn't exist)
+    // Set variable to dict value, or unset it if the key doesn't exist
     FeatherObj val = ops->dict.get(interp, dict, dictKeys[i]);
     if (!ops->list.is_nil(interp, val)) {
       if (feather_set_var(ops, interp, varNames[i], val) != TCL_OK) {
         return TCL_ERROR;
       }
+    } else if (feather_var_exists(ops, interp, varNames[i])) {
+      feather_unset_var(ops, interp, varNames[i]);
     }
   }
 
@@ -868,7 +937,21 @@ static FeatherResult dict_update(const FeatherHostOps *ops, FeatherInterp interp
   FeatherResult res = feather_script_eval_obj(ops, interp, script, TCL_EVAL_LOCAL);
   FeatherObj scriptResult = ops->interp.get_result(interp);
 
-  // Update dict from variables
+  // Write the variables back into the dictionary as it is now, since the
+  // script may have replaced it; if the script unset it, there is nothing
+  // to update
+  FeatherObj current;
+  if (feather_get_var(ops, interp, dictVarName, &current) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  if (ops->list.is_nil(interp, current)) {
+    ops->interp.set_result(interp, scriptResult);
+    return res;
+  }
+  if (dict_from(ops, interp, current, &dict) != TCL_OK) {
+    return TCL_ERROR;
+  }
+
   for (size_t i = 0; i < numPairs; i++) {
     FeatherObj val;
     feather_get_var(ops, interp, varNames[i], &val);
@@ -886,12 +969,8 @@ static FeatherResult dict_update(const FeatherHostOps *ops, FeatherInterp interp
     return TCL_ERROR;
   }
 
-  if (res != TCL_OK) {
-    return res;
-  }
-
   ops->interp.set_result(interp, scriptResult);
-  return TCL_OK;
+  return res;
 }
 
 // dict with dictVarName ?key ...? script
@@ -907,24 +986,21 @@ static FeatherResult dict_with(const FeatherHostOps *ops, FeatherInterp interp,
   FeatherObj dictVarName = ops->list.shift(interp, args);
   FeatherObj script = ops->list.pop(interp, args);
 
-  // Get current dict
-  FeatherObj dict;
-  feather_get_var(ops, interp, dictVarName, &dict);
-  if (ops->list.is_nil(interp, dict)) {
-    dict = ops->dict.create(interp);
+  // The remaining arguments are the path to a nested dict
+  FeatherObj path = args;
+  size_t depth = argc - 2;
+  if (depth > DICT_MAX_DEPTH) {
+    return dict_too_deep(ops, interp);
   }
 
-  // Navigate to nested dict if keys provided
-  FeatherObj nestedKeys = ops->list.create(interp);
-  while (ops->list.length(interp, args) > 0) {
-    FeatherObj key = ops->list.shift(interp, args);
-    nestedKeys = ops->list.push(interp, nestedKeys, key);
-    FeatherObj nested = ops->dict.get(interp, dict, key);
-    if (ops->list.is_nil(interp, nested)) {
-      nested = ops->dict.create(interp);
-    }
-    dict = nested;
+  FeatherObj levels[DICT_MAX_DEPTH + 1];
+  if (dict_read_var(ops, interp, dictVarName, 1, &levels[0]) != TCL_OK) {
+    return TCL_ERROR;
   }
+  if (dict_path_get(ops, interp, path, depth, levels) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  FeatherObj dict = levels[depth];
 
   // Get all keys from the target dict
   FeatherObj keys = ops->dict.keys(interp, dict);
@@ -943,6 +1019,24 @@ static FeatherResult dict_with(const FeatherHostOps *ops, FeatherInterp interp,
   FeatherResult res = feather_script_eval_obj(ops, interp, script, TCL_EVAL_LOCAL);
   FeatherObj scriptResult = ops->interp.get_result(interp);
 
+  // Re-read the dict, which the script may have changed; if the script
+  // unset it, there is nothing to update
+  FeatherObj current;
+  if (feather_get_var(ops, interp, dictVarName, &current) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  if (ops->list.is_nil(interp, current)) {
+    ops->interp.set_result(interp, scriptResult);
+    return res;
+  }
+  if (dict_from(ops, interp, current, &levels[0]) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  if (dict_path_get(ops, interp, path, depth, levels) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  dict = levels[depth];
+
   // Update dict from variables (only for keys that existed in original dict)
   for (size_t i = 0; i < numKeys; i++) {
     FeatherObj key = ops->list.at(interp, keys, i);
@@ -957,50 +1051,15 @@ static FeatherResult dict_with(const FeatherHostOps *ops, FeatherInterp interp,
     }
   }
 
-  // If we navigated into nested dict, rebuild from inside out
-  size_t numNestedKeys = ops->list.length(interp, nestedKeys);
-  if (numNestedKeys > 0) {
-    // Need to rebuild the nested structure
-    FeatherObj rootDict;
-    feather_get_var(ops, interp, dictVarName, &rootDict);
-    if (ops->list.is_nil(interp, rootDict)) {
-      rootDict = ops->dict.create(interp);
-    }
-
-    // Navigate and rebuild
-    FeatherObj dicts[65];
-    dicts[0] = rootDict;
-    for (size_t i = 0; i < numNestedKeys; i++) {
-      FeatherObj key = ops->list.at(interp, nestedKeys, i);
-      FeatherObj nested = ops->dict.get(interp, dicts[i], key);
-      if (ops->list.is_nil(interp, nested)) {
-        nested = ops->dict.create(interp);
-      }
-      dicts[i + 1] = nested;
-    }
-
-    // Set innermost dict
-    dicts[numNestedKeys] = dict;
-
-    // Rebuild from inside out
-    for (size_t i = numNestedKeys; i > 0; i--) {
-      FeatherObj key = ops->list.at(interp, nestedKeys, i - 1);
-      dicts[i - 1] = ops->dict.set(interp, dicts[i - 1], key, dicts[i]);
-    }
-    dict = dicts[0];
-  }
-
-  // Store updated dict
+  // Store the updated dict back along the path
+  levels[depth] = dict;
+  dict = dict_path_put(ops, interp, path, depth, levels);
   if (feather_set_var(ops, interp, dictVarName, dict) != TCL_OK) {
     return TCL_ERROR;
   }
 
-  if (res != TCL_OK) {
-    return res;
-  }
-
   ops->interp.set_result(interp, scriptResult);
-  return TCL_OK;
+  return res;
 }
 
 void feather_register_dict_usage(const FeatherHostOps *ops, FeatherInterp interp) {
@@ -1433,6 +1492,20 @@ void feather_register_dict_usage(const FeatherHostOps *ops, FeatherInterp interp
   feather_usage_register(ops, interp, "dict", spec);
 }
 
+// Subcommand names in the order of DictSubcommand, for string.get_index.
+static const char *const dict_subcommands[] = {
+  "append", "create", "exists", "filter", "for", "get", "getdef",
+  "getwithdefault", "incr", "info", "keys", "lappend", "map", "merge",
+  "remove", "replace", "set", "size", "unset", "update", "values", "with", NULL
+};
+
+typedef enum {
+  DICT_APPEND, DICT_CREATE, DICT_EXISTS, DICT_FILTER, DICT_FOR, DICT_GET,
+  DICT_GETDEF, DICT_GETWITHDEFAULT, DICT_INCR, DICT_INFO, DICT_KEYS,
+  DICT_LAPPEND, DICT_MAP, DICT_MERGE, DICT_REMOVE, DICT_REPLACE, DICT_SET,
+  DICT_SIZE, DICT_UNSET, DICT_UPDATE, DICT_VALUES, DICT_WITH
+} DictSubcommand;
+
 // Main dict command dispatcher
 FeatherResult feather_builtin_dict(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj cmd, FeatherObj args) {
@@ -1447,56 +1520,40 @@ FeatherResult feather_builtin_dict(const FeatherHostOps *ops, FeatherInterp inte
   }
 
   FeatherObj subcmd = ops->list.shift(interp, args);
-
-  if (feather_obj_eq_literal(ops, interp, subcmd, "create")) {
-    return dict_create(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "get")) {
-    return dict_get(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "set")) {
-    return dict_set(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "exists")) {
-    return dict_exists(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "keys")) {
-    return dict_keys(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "values")) {
-    return dict_values(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "size")) {
-    return dict_size(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "remove")) {
-    return dict_remove(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "replace")) {
-    return dict_replace(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "merge")) {
-    return dict_merge(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "append")) {
-    return dict_append(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "incr")) {
-    return dict_incr(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "lappend")) {
-    return dict_lappend(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "unset")) {
-    return dict_unset(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "for")) {
-    return dict_for(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "info")) {
-    return dict_info(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "getdef") || feather_obj_eq_literal(ops, interp, subcmd, "getwithdefault")) {
-    return dict_getdef(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "filter")) {
-    return dict_filter(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "map")) {
-    return dict_map(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "update")) {
-    return dict_update(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "with")) {
-    return dict_with(ops, interp, args);
-  } else {
-    FeatherObj msg = ops->string.intern(interp, "unknown or ambiguous subcommand \"", 33);
-    msg = ops->string.concat(interp, msg, subcmd);
-    FeatherObj suffix = ops->string.intern(interp,
-      "\": must be append, create, exists, filter, for, get, getdef, getwithdefault, incr, info, keys, lappend, map, merge, remove, replace, set, size, unset, update, values, or with", 174);
-    msg = ops->string.concat(interp, msg, suffix);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
+  int index;
+
+  if (ops->string.get_index(interp, subcmd, dict_subcommands, NULL, 0, &index) == TCL_OK) {
+    switch ((DictSubcommand)index) {
+    case DICT_APPEND: return dict_append(ops, interp, args);
+    case DICT_CREATE: return dict_create(ops, interp, args);
+    case DICT_EXISTS: return dict_exists(ops, interp, args);
+    case DICT_FILTER: return dict_filter(ops, interp, args);
+    case DICT_FOR: return dict_for(ops, interp, args);
+    case DICT_GET: return dict_get(ops, interp, args);
+    case DICT_GETDEF:
+    case DICT_GETWITHDEFAULT: return dict_getdef(ops, interp, args);
+    case DICT_INCR: return dict_incr(ops, interp, args);
+    case DICT_INFO: return dict_info(ops, interp, args);
+    case DICT_KEYS: return dict_keys(ops, interp, args);
+    case DICT_LAPPEND: return dict_lappend(ops, interp, args);
+    case DICT_MAP: return dict_map(ops, interp, args);
+    case DICT_MERGE: return dict_merge(ops, interp, args);
+    case DICT_REMOVE: return dict_remove(ops, interp, args);
+    case DICT_REPLACE: return dict_replace(ops, interp, args);
+    case DICT_SET: return dict_set(ops, interp, args);
+    case DICT_SIZE: return dict_size(ops, interp, args);
+    case DICT_UNSET: return dict_unset(ops, interp, args);
+    case DICT_UPDATE: return dict_update(ops, interp, args);
+    case DICT_VALUES: return dict_values(ops, interp, args);
+    case DICT_WITH: return dict_with(ops, interp, args);
+    }
   }
+
+  FeatherObj msg = ops->string.intern(interp, "unknown or ambiguous subcommand \"", 33);
+  msg = ops->string.concat(interp, msg, subcmd);
+  FeatherObj suffix = ops->string.intern(interp,
+    "\": must be append, create, exists, filter, for, get, getdef, getwithdefault, incr, info, keys, lappend, map, merge, remove, replace, set, size, unset, update, values, or with", 174);
+  msg = ops->string.concat(interp, msg, suffix);
+  ops->interp.set_result(interp, msg);
+  return TCL_ERROR;
 }
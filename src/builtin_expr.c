@@ -1,6 +1,7 @@
 #include "feather.h"
 #include "internal.h"
 #include "charclass.h"
+#include "parse_helpers.h"
 
 /**
  * Expression parser for TCL expr command.
@@ -36,21 +37,26 @@ typedef struct {
   FeatherObj str_val;      // 0 means no string value
   int is_int;          // 1 if has valid integer rep
   int is_double;       // 1 if has valid double rep
+  int is_big;          // 1 if str_val holds an integer outside the int64 range
+  size_t lit_start;    // Where an unsigned number literal was written, as
+  size_t lit_end;      // string operators see it; lit_end 0 means none
 } ExprValue;
 
 typedef struct {
   const FeatherHostOps *ops;
   FeatherInterp interp;
+  FeatherBytes src;    // Bytes of the expression
   FeatherObj expr_obj; // Expression object
   size_t len;          // Length of expression in bytes
   size_t pos;          // Current position (byte index)
   int has_error;
   FeatherObj error_msg;
-  int skip_mode;       // When true, skip evaluation (for lazy eval)
+  int skip_mode;       // When true, parse without evaluating (for lazy eval):
+                       // operands are dummies that operators don't combine
 } ExprParser;
 
 // Helper macro for byte access
-#define BYTE_AT(p, i) ((p)->ops->string.byte_at((p)->interp, (p)->expr_obj, (i)))
+#define BYTE_AT(p, i) feather_bytes_at((p)->ops, (p)->interp, &(p)->src, (i))
 #define CUR_BYTE(p) BYTE_AT(p, (p)->pos)
 #define AT_END(p) ((p)->pos >= (p)->len)
 
@@ -88,6 +94,12 @@ static ExprValue make_str(FeatherObj obj) {
   return v;
 }
 
+// Create a bignum ExprValue from a host integer object outside the int64 range
+static ExprValue make_big(FeatherObj obj) {
+  ExprValue v = {.int_val = 0, .dbl_val = 0, .str_val = obj, .is_int = 0, .is_double = 0, .is_big = 1};
+  return v;
+}
+
 // Create an error ExprValue (signals error without value)
 static ExprValue make_error(void) {
   ExprValue v = {.int_val = 0, .dbl_val = 0, .str_val = 0, .is_int = 0, .is_double = 0};
@@ -111,8 +123,11 @@ static int get_int(ExprParser *p, ExprValue *v, int64_t *out) {
     *out = v->int_val;
     return 1;
   }
-  // Shimmer from double
-  if (v->is_double) {
+  if (v->is_big) {
+    return 0;
+  }
+  // Shimmer from double, unless it is out of range
+  if (v->is_double && v->dbl_val >= -9223372036854775808.0 && v->dbl_val < 9223372036854775808.0) {
     v->int_val = (int64_t)v->dbl_val;
     v->is_int = 1;
     *out = v->int_val;
@@ -157,7 +172,117 @@ static int get_double(ExprParser *p, ExprValue *v, double *out) {
 
 // Check if ExprValue is a floating-point type (has decimal point)
 static int is_floating(ExprValue *v) {
-  return v->is_double && !v->is_int;
+  return v->is_double && !v->is_int && !v->is_big;
+}
+
+// Resolve an ExprValue to an integer of any size.
+// Returns 0 if it is not an integer, 1 if it fits in an int64 (stored in
+// *out) and 2 if it is a bignum (held in v->str_val).
+static int get_integer(ExprParser *p, ExprValue *v, int64_t *out) {
+  if (v->is_big) {
+    return 2;
+  }
+  if (get_int(p, v, out)) {
+    return 1;
+  }
+  FeatherObj big;
+  if (v->str_val == 0 || p->ops->bignum.get(p->interp, v->str_val, &big) != TCL_OK) {
+    return 0;
+  }
+  // Literal syntax such as 0x10 may still fit in an int64
+  if (p->ops->integer.get(p->interp, big, out) == TCL_OK) {
+    v->int_val = *out;
+    v->is_int = 1;
+    return 1;
+  }
+  v->str_val = big;
+  v->is_big = 1;
+  return 2;
+}
+
+// Resolve both operands of a binary operator to integers of any size.
+// Returns 0 if either is not an integer, 1 if both fit in an int64 and 2 if
+// at least one is a bignum.
+static int get_integers(ExprParser *p, ExprValue *a, ExprValue *b, int64_t *av, int64_t *bv) {
+  int ka = get_integer(p, a, av);
+  if (ka == 0) return 0;
+  int kb = get_integer(p, b, bv);
+  if (kb == 0) return 0;
+  return (ka == 2 || kb == 2) ? 2 : 1;
+}
+
+// Set TCL's error for an operand that an integer-only operator such as |
+// or << cannot use, naming the kind of value it got instead.
+static void set_operand_error(ExprParser *p, ExprValue *v, const char *op) {
+  if (p->has_error) return;
+  const char *what = "non-numeric string";
+  double dval;
+  if (is_floating(v) || get_double(p, v, &dval)) {
+    what = "floating-point value";
+  } else if (v->str_val != 0 && p->ops->string.byte_length(p->interp, v->str_val) == 0) {
+    what = "empty string";
+  }
+  FeatherObj msg = p->ops->string.intern(p->interp, "can't use ", 10);
+  msg = p->ops->string.concat(p->interp, msg,
+                              p->ops->string.intern(p->interp, what, feather_strlen(what)));
+  msg = p->ops->string.concat(p->interp, msg,
+                              p->ops->string.intern(p->interp, " as operand of \"", 16));
+  msg = p->ops->string.concat(p->interp, msg,
+                              p->ops->string.intern(p->interp, op, feather_strlen(op)));
+  msg = p->ops->string.concat(p->interp, msg, p->ops->string.intern(p->interp, "\"", 1));
+  p->has_error = 1;
+  p->error_msg = msg;
+}
+
+// Resolve the operand of an integer-only operator. Unlike get_integer, a
+// floating-point value is an error rather than truncated. Returns the kind
+// as get_integer does, or 0 with the error set.
+static int get_int_operand(ExprParser *p, ExprValue *v, const char *op, int64_t *out) {
+  int kind = is_floating(v) ? 0 : get_integer(p, v, out);
+  if (kind == 0) set_operand_error(p, v, op);
+  return kind;
+}
+
+// Resolve both operands of an integer-only binary operator, as
+// get_int_operand does.
+static int get_int_operands(ExprParser *p, ExprValue *a, ExprValue *b, const char *op,
+                            int64_t *av, int64_t *bv) {
+  int ka = get_int_operand(p, a, op, av);
+  if (ka == 0) return 0;
+  int kb = get_int_operand(p, b, op, bv);
+  if (kb == 0) return 0;
+  return (ka == 2 || kb == 2) ? 2 : 1;
+}
+
+// Get the truth value of an ExprValue used as a condition. Numbers are true
+// when non-zero, and other strings must be boolean words such as yes or off.
+// On failure, sets the error and returns 0.
+static int get_truth(ExprParser *p, ExprValue *v, int64_t *out) {
+  if (is_floating(v)) {
+    *out = v->dbl_val != 0;
+    return 1;
+  }
+  int kind = get_integer(p, v, out);
+  // Bignums are never zero
+  if (kind == 2) *out = 1;
+  if (kind != 0) return 1;
+  double dval;
+  if (get_double(p, v, &dval)) {
+    *out = dval != 0;
+    return 1;
+  }
+  FeatherObj obj = v->str_val;
+  int b = obj != 0 ? feather_parse_boolean(p->ops, p->interp, obj) : -1;
+  if (b >= 0) {
+    *out = b;
+    return 1;
+  }
+  if (!p->has_error) {
+    p->has_error = 1;
+    feather_error_expected(p->ops, p->interp, "boolean value", obj);
+    p->error_msg = p->ops->interp.get_result(p->interp);
+  }
+  return 0;
 }
 
 // Get FeatherObj from ExprValue
@@ -176,6 +301,49 @@ static FeatherObj get_obj(ExprParser *p, ExprValue *v) {
   return 0;
 }
 
+// Get the string of an ExprValue for string operators, which see a number
+// literal as written, so that 05 ne 5
+static FeatherObj get_string(ExprParser *p, ExprValue *v) {
+  if (v->lit_end != 0) {
+    return p->ops->string.slice(p->interp, p->expr_obj, v->lit_start, v->lit_end);
+  }
+  return get_obj(p, v);
+}
+
+// Wrap a host integer object as an ExprValue, keeping it native if it fits in an int64
+static ExprValue make_integer_obj(ExprParser *p, FeatherObj obj) {
+  int64_t val;
+  if (p->ops->integer.get(p->interp, obj, &val) == TCL_OK) {
+    return make_int(val);
+  }
+  return make_big(obj);
+}
+
+// Apply a bignum operation to integer operands. b is ignored by unary operations.
+static ExprValue big_arith(ExprParser *p, FeatherBignumOp op, ExprValue *a, ExprValue *b) {
+  FeatherObj out;
+  FeatherObj bo = b ? get_obj(p, b) : 0;
+  if (p->ops->bignum.arith(p->interp, op, get_obj(p, a), bo, &out) != TCL_OK) {
+    p->has_error = 1;
+    p->error_msg = p->ops->interp.get_result(p->interp);
+    return make_error();
+  }
+  return make_integer_obj(p, out);
+}
+
+// Compare two integer operands of any size. Returns 0 if either is not an integer.
+static int compare_integers(ExprParser *p, ExprValue *a, ExprValue *b, int *cmp) {
+  int64_t av, bv;
+  int kind = get_integers(p, a, b, &av, &bv);
+  if (kind == 0) return 0;
+  if (kind == 2) {
+    *cmp = p->ops->bignum.compare(p->interp, get_obj(p, a), get_obj(p, b));
+  } else {
+    *cmp = (av > bv) - (av < bv);
+  }
+  return 1;
+}
+
 // Check if an object's string representation looks like a floating-point number.
 // Returns 1 if the string contains '.', 'e', 'E', or special values like "Inf", "NaN".
 // This is used to preserve numeric type when getting results from command/function calls.
@@ -290,7 +458,8 @@ static int is_alnum(int c) {
          (c >= '0' && c <= '9') || c == '_';
 }
 
-// Check if we're at a word boundary for keyword matching
+// Check for an operator keyword such as eq. Like TCL, only a letter after
+// it makes it part of a longer word, so 1eq1 compares 1 with 1.
 static int match_keyword(ExprParser *p, const char *kw, size_t kwlen) {
   if (p->len - p->pos < kwlen) return 0;
   for (size_t i = 0; i < kwlen; i++) {
@@ -298,8 +467,10 @@ static int match_keyword(ExprParser *p, const char *kw, size_t kwlen) {
     if (c >= 'A' && c <= 'Z') c = c - 'A' + 'a';
     if (c != kw[i]) return 0;
   }
-  // Ensure not followed by alphanumeric
-  if (p->pos + kwlen < p->len && is_alnum(BYTE_AT(p, p->pos + kwlen))) return 0;
+  if (p->pos + kwlen < p->len) {
+    int next = BYTE_AT(p, p->pos + kwlen);
+    if ((next >= 'a' && next <= 'z') || (next >= 'A' && next <= 'Z')) return 0;
+  }
   return 1;
 }
 
@@ -515,6 +686,16 @@ static ExprValue parse_float_string(ExprParser *p, size_t start, int negative) {
   return make_error();
 }
 
+// Check for the exponent of a floating-point literal: an e or E followed by
+// digits, with an optional sign. Otherwise the e starts an operator, as in
+// 1eq1.
+static int at_exponent(ExprParser *p) {
+  if (AT_END(p) || (CUR_BYTE(p) != 'e' && CUR_BYTE(p) != 'E')) return 0;
+  size_t i = p->pos + 1;
+  if (i < p->len && (BYTE_AT(p, i) == '-' || BYTE_AT(p, i) == '+')) i++;
+  return i < p->len && BYTE_AT(p, i) >= '0' && BYTE_AT(p, i) <= '9';
+}
+
 // Parse number literal (integer or floating-point)
 // Integers: 123, 0x1f, 0b101, 0o17, with optional underscores
 // Floats: 3.14, .5, 5., 1e10, 3.14e-5
@@ -552,7 +733,7 @@ static ExprValue parse_number(ExprParser *p) {
       p->pos++;
     }
     // Check for exponent
-    if (p->pos < p->len && (CUR_BYTE(p) == 'e' || CUR_BYTE(p) == 'E')) {
+    if (at_exponent(p)) {
       p->pos++;
       if (p->pos < p->len && (CUR_BYTE(p) == '-' || CUR_BYTE(p) == '+')) p->pos++;
       while (p->pos < p->len && CUR_BYTE(p) >= '0' && CUR_BYTE(p) <= '9') {
@@ -590,8 +771,9 @@ static ExprValue parse_number(ExprParser *p) {
     }
   }
 
-  // Parse integer part
+  // Parse integer part, switching to a bignum if it overflows
   int64_t int_value = 0;
+  FeatherObj big_value = 0;
   while (p->pos < p->len) {
     c = CUR_BYTE(p);
     if (c == '_') { has_underscores = 1; p->pos++; continue; }
@@ -600,7 +782,7 @@ static ExprValue parse_number(ExprParser *p) {
     else if (c >= 'a' && c <= 'f') digit = c - 'a' + 10;
     else if (c >= 'A' && c <= 'F') digit = c - 'A' + 10;
     if (digit < 0 || digit >= base) break;
-    int_value = int_value * base + digit;
+    feather_accumulate_digit(p->ops, p->interp, &int_value, &big_value, base, digit);
     p->pos++;
   }
 
@@ -618,7 +800,7 @@ static ExprValue parse_number(ExprParser *p) {
         p->pos++;
       }
       // Check for exponent
-      if (p->pos < p->len && (CUR_BYTE(p) == 'e' || CUR_BYTE(p) == 'E')) {
+      if (at_exponent(p)) {
         p->pos++;
         if (p->pos < p->len && (CUR_BYTE(p) == '-' || CUR_BYTE(p) == '+')) p->pos++;
         while (p->pos < p->len && CUR_BYTE(p) >= '0' && CUR_BYTE(p) <= '9') {
@@ -631,7 +813,7 @@ static ExprValue parse_number(ExprParser *p) {
   }
 
   // Check for exponent without decimal point (e.g., 1e10) - only base 10
-  if (base == 10 && p->pos < p->len && (CUR_BYTE(p) == 'e' || CUR_BYTE(p) == 'E')) {
+  if (base == 10 && at_exponent(p)) {
     is_float = 1;
     p->pos++;
     if (p->pos < p->len && (CUR_BYTE(p) == '-' || CUR_BYTE(p) == '+')) p->pos++;
@@ -642,6 +824,10 @@ static ExprValue parse_number(ExprParser *p) {
     return parse_float_string(p, num_start, negative);
   }
 
+  if (big_value != 0) {
+    ExprValue big = make_big(big_value);
+    return negative ? big_arith(p, FEATHER_BIG_NEG, &big, NULL) : big;
+  }
   return make_int(negative ? -int_value : int_value);
 }
 
@@ -653,7 +839,10 @@ static ExprValue parse_function_call(ExprParser *p, FeatherObj func_name_obj) {
   FeatherObj prefix = p->ops->string.intern(p->interp, "tcl::mathfunc::", 15);
   FeatherObj full_cmd = p->ops->string.concat(p->interp, prefix, func_name_obj);
 
-  FeatherObj args = p->ops->list.create(p->interp);
+  // Arguments are collected into the command list, so values holding lists
+  // or whitespace reach the function as single words
+  FeatherObj cmd_list = p->ops->list.create(p->interp);
+  cmd_list = p->ops->list.push(p->interp, cmd_list, full_cmd);
 
   // Parse arguments
   expr_skip_whitespace(p);
@@ -664,7 +853,7 @@ static ExprValue parse_function_call(ExprParser *p, FeatherObj func_name_obj) {
     // Only collect argument values when not in skip mode
     if (!p->skip_mode) {
       FeatherObj arg_obj = get_obj(p, &arg);
-      args = p->ops->list.push(p->interp, args, arg_obj);
+      cmd_list = p->ops->list.push(p->interp, cmd_list, arg_obj);
     }
 
     expr_skip_whitespace(p);
@@ -685,18 +874,7 @@ static ExprValue parse_function_call(ExprParser *p, FeatherObj func_name_obj) {
     return make_int(0);
   }
 
-  // Build the command string: "tcl::mathfunc::name arg1 arg2 ..."
-  FeatherObj cmd_str = full_cmd;
-  size_t argc = p->ops->list.length(p->interp, args);
-  for (size_t i = 0; i < argc; i++) {
-    FeatherObj space = p->ops->string.intern(p->interp, " ", 1);
-    FeatherObj arg = p->ops->list.at(p->interp, args, i);
-    cmd_str = p->ops->string.concat(p->interp, cmd_str, space);
-    cmd_str = p->ops->string.concat(p->interp, cmd_str, arg);
-  }
-
-  // Evaluate the command using the object-based API
-  FeatherResult result = feather_script_eval_obj(p->ops, p->interp, cmd_str, TCL_EVAL_LOCAL);
+  FeatherResult result = feather_command_exec(p->ops, p->interp, cmd_list, TCL_EVAL_LOCAL);
   if (result != TCL_OK) {
     p->has_error = 1;
     p->error_msg = p->ops->interp.get_result(p->interp);
@@ -787,7 +965,14 @@ static ExprValue parse_primary(ExprParser *p) {
       ((c == '-' || c == '+') && p->pos + 1 < p->len &&
        (BYTE_AT(p, p->pos + 1) >= '0' && BYTE_AT(p, p->pos + 1) <= '9' || BYTE_AT(p, p->pos + 1) == '.')) ||
       (c == '.' && p->pos + 1 < p->len && BYTE_AT(p, p->pos + 1) >= '0' && BYTE_AT(p, p->pos + 1) <= '9')) {
-    return parse_number(p);
+    size_t start = p->pos;
+    ExprValue v = parse_number(p);
+    // A sign makes the literal an operation, whose string is its value
+    if (!p->has_error && c != '-' && c != '+') {
+      v.lit_start = start;
+      v.lit_end = p->pos;
+    }
+    return v;
   }
 
   // Boolean literals and function names (identifiers)
@@ -865,6 +1050,13 @@ static ExprValue parse_unary(ExprParser *p) {
   expr_skip_whitespace(p);
   if (p->has_error) return make_error();
 
+  // Each level of nesting, in parentheses or unary operators, recurses here
+  if (feather_stack_check(p->ops, p->interp) != TCL_OK) {
+    p->has_error = 1;
+    p->error_msg = p->ops->interp.get_result(p->interp);
+    return make_error();
+  }
+
   if (p->pos < p->len) {
     int c = CUR_BYTE(p);
 
@@ -878,10 +1070,17 @@ static ExprValue parse_unary(ExprParser *p) {
       }
       ExprValue v = parse_unary(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) return v;
       // Try integer first, fall back to double
       int64_t ival;
-      if (v.is_int && get_int(p, &v, &ival)) {
-        return make_int(-ival);
+      if (!is_floating(&v)) {
+        int kind = get_integer(p, &v, &ival);
+        if (kind == 2 || (kind == 1 && ival == INT64_MIN)) {
+          return big_arith(p, FEATHER_BIG_NEG, &v, NULL);
+        }
+        if (kind == 1) {
+          return make_int(-ival);
+        }
       }
       double dval;
       if (get_double(p, &v, &dval)) {
@@ -908,11 +1107,12 @@ static ExprValue parse_unary(ExprParser *p) {
       p->pos++;
       ExprValue v = parse_unary(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) return v;
       int64_t val;
-      if (!get_int(p, &v, &val)) {
-        FeatherObj obj = get_obj(p, &v);
-        set_integer_error_obj(p, obj);
-        return make_error();
+      int kind = get_int_operand(p, &v, "~", &val);
+      if (kind == 0) return make_error();
+      if (kind == 2) {
+        return big_arith(p, FEATHER_BIG_NOT, &v, NULL);
       }
       return make_int(~val);
     }
@@ -922,19 +1122,12 @@ static ExprValue parse_unary(ExprParser *p) {
       p->pos++;
       ExprValue v = parse_unary(p);
       if (p->has_error) return make_error();
-      // Try integer first
+      if (p->skip_mode) return v;
       int64_t ival;
-      if (get_int(p, &v, &ival)) {
-        return make_int(ival ? 0 : 1);
-      }
-      // Try double
-      double dval;
-      if (get_double(p, &v, &dval)) {
-        return make_int(dval != 0.0 ? 0 : 1);
+      if (!get_truth(p, &v, &ival)) {
+        return make_error();
       }
-      FeatherObj obj = get_obj(p, &v);
-      set_integer_error_obj(p, obj);
-      return make_error();
+      return make_int(ival ? 0 : 1);
     }
   }
 
@@ -943,7 +1136,7 @@ static ExprValue parse_unary(ExprParser *p) {
 
 // Check if we need floating-point math (either operand is a float)
 static int needs_float_math(ExprValue *a, ExprValue *b) {
-  return (a->is_double && !a->is_int) || (b->is_double && !b->is_int);
+  return is_floating(a) || is_floating(b);
 }
 
 // Parse exponentiation: unary ** exponentiation (right-to-left)
@@ -956,6 +1149,7 @@ static ExprValue parse_exponentiation(ExprParser *p) {
     p->pos += 2;
     ExprValue right = parse_exponentiation(p); // right-to-left
     if (p->has_error) return make_error();
+    if (p->skip_mode) return left;
 
     // Use floating-point if either operand is a float
     if (needs_float_math(&left, &right)) {
@@ -975,7 +1169,8 @@ static ExprValue parse_exponentiation(ExprParser *p) {
     }
 
     int64_t base, exp;
-    if (!get_int(p, &left, &base) || !get_int(p, &right, &exp)) {
+    int kind = get_integers(p, &left, &right, &base, &exp);
+    if (kind == 0) {
       // Fall back to double if int conversion fails
       double dbase, dexp;
       if (!get_double(p, &left, &dbase) || !get_double(p, &right, &dexp)) {
@@ -992,15 +1187,22 @@ static ExprValue parse_exponentiation(ExprParser *p) {
       return make_double(result);
     }
 
-    // Simple integer exponentiation
+    // Negative exponents and results beyond int64 are left to the host
+    if (kind == 2 || exp < 0) {
+      return big_arith(p, FEATHER_BIG_POW, &left, &right);
+    }
+
+    // Exponentiation by squaring
     int64_t result = 1;
-    int neg = exp < 0;
-    if (neg) exp = -exp;
-    for (int64_t i = 0; i < exp; i++) {
-      result *= base;
+    int overflow = 0;
+    while (exp > 0 && !overflow) {
+      if (exp & 1) overflow |= __builtin_mul_overflow(result, base, &result);
+      exp >>= 1;
+      if (exp > 0) overflow |= __builtin_mul_overflow(base, base, &base);
+    }
+    if (overflow) {
+      return big_arith(p, FEATHER_BIG_POW, &left, &right);
     }
-    // Negative exponent gives 0 for integer math (truncation)
-    if (neg && base != 1 && base != -1) result = 0;
     return make_int(result);
   }
 
@@ -1024,6 +1226,7 @@ static ExprValue parse_multiplicative(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_exponentiation(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       // Use float math if either operand is a float
       if (needs_float_math(&left, &right)) {
         double lv, rv;
@@ -1036,7 +1239,8 @@ static ExprValue parse_multiplicative(ExprParser *p) {
         if (p->has_error) return make_error();
       } else {
         int64_t lv, rv;
-        if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
+        int kind = get_integers(p, &left, &right, &lv, &rv);
+        if (kind == 0) {
           // Fall back to double
           double dlv, drv;
           if (!get_double(p, &left, &dlv) || !get_double(p, &right, &drv)) {
@@ -1046,14 +1250,18 @@ static ExprValue parse_multiplicative(ExprParser *p) {
           // NaN can occur from Inf * 0
           left = make_double_checked(p, dlv * drv);
           if (p->has_error) return make_error();
+        } else if (kind == 2 || __builtin_mul_overflow(lv, rv, &lv)) {
+          left = big_arith(p, FEATHER_BIG_MUL, &left, &right);
+          if (p->has_error) return make_error();
         } else {
-          left = make_int(lv * rv);
+          left = make_int(lv);
         }
       }
     } else if (c == '/') {
       p->pos++;
       ExprValue right = parse_exponentiation(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       // Use float math if either operand is a float
       if (needs_float_math(&left, &right)) {
         double lv, rv;
@@ -1068,7 +1276,8 @@ static ExprValue parse_multiplicative(ExprParser *p) {
       } else {
         // Integer division
         int64_t lv, rv;
-        if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
+        int kind = get_integers(p, &left, &right, &lv, &rv);
+        if (kind == 0) {
           // Fall back to double
           double dlv, drv;
           if (!get_double(p, &left, &dlv) || !get_double(p, &right, &drv)) {
@@ -1079,29 +1288,42 @@ static ExprValue parse_multiplicative(ExprParser *p) {
           // NaN is treated as a domain error in TCL
           left = make_double_checked(p, dlv / drv);
           if (p->has_error) return make_error();
+        } else if (kind == 2 || (lv == INT64_MIN && rv == -1)) {
+          left = big_arith(p, FEATHER_BIG_DIV, &left, &right);
+          if (p->has_error) return make_error();
         } else {
           if (rv == 0) {
             set_error(p, "divide by zero", 14);
             return make_error();
           }
-          left = make_int(lv / rv);
+          // Integer division rounds toward negative infinity
+          int64_t q = lv / rv;
+          if (lv % rv != 0 && (lv < 0) != (rv < 0)) q--;
+          left = make_int(q);
         }
       }
     } else if (c == '%') {
       p->pos++;
       ExprValue right = parse_exponentiation(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       // Modulo is always integer in TCL
       int64_t lv, rv;
-      if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
-        set_syntax_error(p);
-        return make_error();
+      int kind = get_int_operands(p, &left, &right, "%", &lv, &rv);
+      if (kind == 0) return make_error();
+      if (kind == 2) {
+        left = big_arith(p, FEATHER_BIG_MOD, &left, &right);
+        if (p->has_error) return make_error();
+        continue;
       }
       if (rv == 0) {
         set_error(p, "divide by zero", 14);
         return make_error();
       }
-      left = make_int(lv % rv);
+      // The remainder takes the sign of the divisor
+      int64_t m = rv == -1 ? 0 : lv % rv;
+      if (m != 0 && (m < 0) != (rv < 0)) m += rv;
+      left = make_int(m);
     } else {
       break;
     }
@@ -1124,6 +1346,7 @@ static ExprValue parse_additive(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_multiplicative(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       // Use float math if either operand is a float
       if (needs_float_math(&left, &right)) {
         double lv, rv;
@@ -1136,7 +1359,8 @@ static ExprValue parse_additive(ExprParser *p) {
         if (p->has_error) return make_error();
       } else {
         int64_t lv, rv;
-        if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
+        int kind = get_integers(p, &left, &right, &lv, &rv);
+        if (kind == 0) {
           // Fall back to double
           double dlv, drv;
           if (!get_double(p, &left, &dlv) || !get_double(p, &right, &drv)) {
@@ -1146,14 +1370,18 @@ static ExprValue parse_additive(ExprParser *p) {
           // NaN can occur from Inf + (-Inf)
           left = make_double_checked(p, dlv + drv);
           if (p->has_error) return make_error();
+        } else if (kind == 2 || __builtin_add_overflow(lv, rv, &lv)) {
+          left = big_arith(p, FEATHER_BIG_ADD, &left, &right);
+          if (p->has_error) return make_error();
         } else {
-          left = make_int(lv + rv);
+          left = make_int(lv);
         }
       }
     } else if (c == '-') {
       p->pos++;
       ExprValue right = parse_multiplicative(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       // Use float math if either operand is a float
       if (needs_float_math(&left, &right)) {
         double lv, rv;
@@ -1166,7 +1394,8 @@ static ExprValue parse_additive(ExprParser *p) {
         if (p->has_error) return make_error();
       } else {
         int64_t lv, rv;
-        if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
+        int kind = get_integers(p, &left, &right, &lv, &rv);
+        if (kind == 0) {
           // Fall back to double
           double dlv, drv;
           if (!get_double(p, &left, &dlv) || !get_double(p, &right, &drv)) {
@@ -1176,8 +1405,11 @@ static ExprValue parse_additive(ExprParser *p) {
           // NaN can occur from Inf - Inf
           left = make_double_checked(p, dlv - drv);
           if (p->has_error) return make_error();
+        } else if (kind == 2 || __builtin_sub_overflow(lv, rv, &lv)) {
+          left = big_arith(p, FEATHER_BIG_SUB, &left, &right);
+          if (p->has_error) return make_error();
         } else {
-          left = make_int(lv - rv);
+          left = make_int(lv);
         }
       }
     } else {
@@ -1202,24 +1434,33 @@ static ExprValue parse_shift(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_additive(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       int64_t lv, rv;
-      if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
-        set_syntax_error(p);
-        return make_error();
+      int kind = get_int_operands(p, &left, &right, "<<", &lv, &rv);
+      if (kind == 0) return make_error();
+      int64_t shifted = (kind == 1 && rv >= 0 && rv < 64) ? (int64_t)((uint64_t)lv << rv) : 0;
+      if (kind == 2 || rv < 0 || rv >= 64 || (shifted >> rv) != lv) {
+        left = big_arith(p, FEATHER_BIG_SHL, &left, &right);
+        if (p->has_error) return make_error();
+      } else {
+        left = make_int(shifted);
       }
-      left = make_int(lv << rv);
     }
     // Right shift >>
     else if (p->pos + 1 < p->len && CUR_BYTE(p) == '>' && BYTE_AT(p, p->pos + 1) == '>') {
       p->pos += 2;
       ExprValue right = parse_additive(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       int64_t lv, rv;
-      if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
-        set_syntax_error(p);
-        return make_error();
+      int kind = get_int_operands(p, &left, &right, ">>", &lv, &rv);
+      if (kind == 0) return make_error();
+      if (kind == 2 || rv < 0) {
+        left = big_arith(p, FEATHER_BIG_SHR, &left, &right);
+        if (p->has_error) return make_error();
+      } else {
+        left = make_int(rv >= 64 ? (lv < 0 ? -1 : 0) : lv >> rv);
       }
-      left = make_int(lv >> rv);
     } else {
       break;
     }
@@ -1246,32 +1487,36 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
-      FeatherObj lo = get_obj(p, &left);
-      FeatherObj ro = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj lo = get_string(p, &left);
+      FeatherObj ro = get_string(p, &right);
       int cmp = p->ops->string.compare(p->interp, lo, ro);
       left = make_int(cmp < 0 ? 1 : 0);
     } else if (match_keyword(p, "le", 2)) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
-      FeatherObj lo = get_obj(p, &left);
-      FeatherObj ro = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj lo = get_string(p, &left);
+      FeatherObj ro = get_string(p, &right);
       int cmp = p->ops->string.compare(p->interp, lo, ro);
       left = make_int(cmp <= 0 ? 1 : 0);
     } else if (match_keyword(p, "gt", 2)) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
-      FeatherObj lo = get_obj(p, &left);
-      FeatherObj ro = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj lo = get_string(p, &left);
+      FeatherObj ro = get_string(p, &right);
       int cmp = p->ops->string.compare(p->interp, lo, ro);
       left = make_int(cmp > 0 ? 1 : 0);
     } else if (match_keyword(p, "ge", 2)) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
-      FeatherObj lo = get_obj(p, &left);
-      FeatherObj ro = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj lo = get_string(p, &left);
+      FeatherObj ro = get_string(p, &right);
       int cmp = p->ops->string.compare(p->interp, lo, ro);
       left = make_int(cmp >= 0 ? 1 : 0);
     }
@@ -1281,27 +1526,28 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       if (needs_float_math(&left, &right)) {
         double lv, rv;
         if (get_double(p, &left, &lv) && get_double(p, &right, &rv)) {
           left = make_int(lv <= rv ? 1 : 0);
         } else {
-          FeatherObj lo = get_obj(p, &left);
-          FeatherObj ro = get_obj(p, &right);
+          FeatherObj lo = get_string(p, &left);
+          FeatherObj ro = get_string(p, &right);
           int cmp = p->ops->string.compare(p->interp, lo, ro);
           left = make_int(cmp <= 0 ? 1 : 0);
         }
       } else {
-        int64_t lv, rv;
-        if (get_int(p, &left, &lv) && get_int(p, &right, &rv)) {
-          left = make_int(lv <= rv ? 1 : 0);
+        int icmp;
+        if (compare_integers(p, &left, &right, &icmp)) {
+          left = make_int(icmp <= 0 ? 1 : 0);
         } else {
           double dlv, drv;
           if (get_double(p, &left, &dlv) && get_double(p, &right, &drv)) {
             left = make_int(dlv <= drv ? 1 : 0);
           } else {
-            FeatherObj lo = get_obj(p, &left);
-            FeatherObj ro = get_obj(p, &right);
+            FeatherObj lo = get_string(p, &left);
+            FeatherObj ro = get_string(p, &right);
             int cmp = p->ops->string.compare(p->interp, lo, ro);
             left = make_int(cmp <= 0 ? 1 : 0);
           }
@@ -1312,27 +1558,28 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       if (needs_float_math(&left, &right)) {
         double lv, rv;
         if (get_double(p, &left, &lv) && get_double(p, &right, &rv)) {
           left = make_int(lv < rv ? 1 : 0);
         } else {
-          FeatherObj lo = get_obj(p, &left);
-          FeatherObj ro = get_obj(p, &right);
+          FeatherObj lo = get_string(p, &left);
+          FeatherObj ro = get_string(p, &right);
           int cmp = p->ops->string.compare(p->interp, lo, ro);
           left = make_int(cmp < 0 ? 1 : 0);
         }
       } else {
-        int64_t lv, rv;
-        if (get_int(p, &left, &lv) && get_int(p, &right, &rv)) {
-          left = make_int(lv < rv ? 1 : 0);
+        int icmp;
+        if (compare_integers(p, &left, &right, &icmp)) {
+          left = make_int(icmp < 0 ? 1 : 0);
         } else {
           double dlv, drv;
           if (get_double(p, &left, &dlv) && get_double(p, &right, &drv)) {
             left = make_int(dlv < drv ? 1 : 0);
           } else {
-            FeatherObj lo = get_obj(p, &left);
-            FeatherObj ro = get_obj(p, &right);
+            FeatherObj lo = get_string(p, &left);
+            FeatherObj ro = get_string(p, &right);
             int cmp = p->ops->string.compare(p->interp, lo, ro);
             left = make_int(cmp < 0 ? 1 : 0);
           }
@@ -1343,27 +1590,28 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       if (needs_float_math(&left, &right)) {
         double lv, rv;
         if (get_double(p, &left, &lv) && get_double(p, &right, &rv)) {
           left = make_int(lv >= rv ? 1 : 0);
         } else {
-          FeatherObj lo = get_obj(p, &left);
-          FeatherObj ro = get_obj(p, &right);
+          FeatherObj lo = get_string(p, &left);
+          FeatherObj ro = get_string(p, &right);
           int cmp = p->ops->string.compare(p->interp, lo, ro);
           left = make_int(cmp >= 0 ? 1 : 0);
         }
       } else {
-        int64_t lv, rv;
-        if (get_int(p, &left, &lv) && get_int(p, &right, &rv)) {
-          left = make_int(lv >= rv ? 1 : 0);
+        int icmp;
+        if (compare_integers(p, &left, &right, &icmp)) {
+          left = make_int(icmp >= 0 ? 1 : 0);
         } else {
           double dlv, drv;
           if (get_double(p, &left, &dlv) && get_double(p, &right, &drv)) {
             left = make_int(dlv >= drv ? 1 : 0);
           } else {
-            FeatherObj lo = get_obj(p, &left);
-            FeatherObj ro = get_obj(p, &right);
+            FeatherObj lo = get_string(p, &left);
+            FeatherObj ro = get_string(p, &right);
             int cmp = p->ops->string.compare(p->interp, lo, ro);
             left = make_int(cmp >= 0 ? 1 : 0);
           }
@@ -1374,27 +1622,28 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       if (needs_float_math(&left, &right)) {
         double lv, rv;
         if (get_double(p, &left, &lv) && get_double(p, &right, &rv)) {
           left = make_int(lv > rv ? 1 : 0);
         } else {
-          FeatherObj lo = get_obj(p, &left);
-          FeatherObj ro = get_obj(p, &right);
+          FeatherObj lo = get_string(p, &left);
+          FeatherObj ro = get_string(p, &right);
           int cmp = p->ops->string.compare(p->interp, lo, ro);
           left = make_int(cmp > 0 ? 1 : 0);
         }
       } else {
-        int64_t lv, rv;
-        if (get_int(p, &left, &lv) && get_int(p, &right, &rv)) {
-          left = make_int(lv > rv ? 1 : 0);
+        int icmp;
+        if (compare_integers(p, &left, &right, &icmp)) {
+          left = make_int(icmp > 0 ? 1 : 0);
         } else {
           double dlv, drv;
           if (get_double(p, &left, &dlv) && get_double(p, &right, &drv)) {
             left = make_int(dlv > drv ? 1 : 0);
           } else {
-            FeatherObj lo = get_obj(p, &left);
-            FeatherObj ro = get_obj(p, &right);
+            FeatherObj lo = get_string(p, &left);
+            FeatherObj ro = get_string(p, &right);
             int cmp = p->ops->string.compare(p->interp, lo, ro);
             left = make_int(cmp > 0 ? 1 : 0);
           }
@@ -1406,10 +1655,16 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
-      FeatherObj needle = get_obj(p, &left);
-      FeatherObj haystack = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj needle = get_string(p, &left);
+      FeatherObj haystack = get_string(p, &right);
       // Convert haystack to list and search
       FeatherObj list = p->ops->list.from(p->interp, haystack);
+      if (list == 0) {
+        p->has_error = 1;
+        p->error_msg = p->ops->interp.get_result(p->interp);
+        return make_error();
+      }
       size_t len = p->ops->list.length(p->interp, list);
       int found = 0;
       for (size_t i = 0; i < len; i++) {
@@ -1424,10 +1679,16 @@ static ExprValue parse_comparison(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_shift(p);
       if (p->has_error) return make_error();
-      FeatherObj needle = get_obj(p, &left);
-      FeatherObj haystack = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj needle = get_string(p, &left);
+      FeatherObj haystack = get_string(p, &right);
       // Convert haystack to list and search
       FeatherObj list = p->ops->list.from(p->interp, haystack);
+      if (list == 0) {
+        p->has_error = 1;
+        p->error_msg = p->ops->interp.get_result(p->interp);
+        return make_error();
+      }
       size_t len = p->ops->list.length(p->interp, list);
       int found = 0;
       for (size_t i = 0; i < len; i++) {
@@ -1460,16 +1721,18 @@ static ExprValue parse_equality(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_comparison(p);
       if (p->has_error) return make_error();
-      FeatherObj lo = get_obj(p, &left);
-      FeatherObj ro = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj lo = get_string(p, &left);
+      FeatherObj ro = get_string(p, &right);
       int cmp = p->ops->string.compare(p->interp, lo, ro);
       left = make_int(cmp == 0 ? 1 : 0);
     } else if (match_keyword(p, "ne", 2)) {
       p->pos += 2;
       ExprValue right = parse_comparison(p);
       if (p->has_error) return make_error();
-      FeatherObj lo = get_obj(p, &left);
-      FeatherObj ro = get_obj(p, &right);
+      if (p->skip_mode) continue;
+      FeatherObj lo = get_string(p, &left);
+      FeatherObj ro = get_string(p, &right);
       int cmp = p->ops->string.compare(p->interp, lo, ro);
       left = make_int(cmp != 0 ? 1 : 0);
     }
@@ -1478,21 +1741,22 @@ static ExprValue parse_equality(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_comparison(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       if (needs_float_math(&left, &right)) {
         double lv, rv;
         if (get_double(p, &left, &lv) && get_double(p, &right, &rv)) {
           left = make_int(lv == rv ? 1 : 0);
         } else {
           // Fall back to string comparison
-          FeatherObj lo = get_obj(p, &left);
-          FeatherObj ro = get_obj(p, &right);
+          FeatherObj lo = get_string(p, &left);
+          FeatherObj ro = get_string(p, &right);
           int cmp = p->ops->string.compare(p->interp, lo, ro);
           left = make_int(cmp == 0 ? 1 : 0);
         }
       } else {
-        int64_t lv, rv;
-        if (get_int(p, &left, &lv) && get_int(p, &right, &rv)) {
-          left = make_int(lv == rv ? 1 : 0);
+        int icmp;
+        if (compare_integers(p, &left, &right, &icmp)) {
+          left = make_int(icmp == 0 ? 1 : 0);
         } else {
           // Try double
           double dlv, drv;
@@ -1500,8 +1764,8 @@ static ExprValue parse_equality(ExprParser *p) {
             left = make_int(dlv == drv ? 1 : 0);
           } else {
             // Fall back to string comparison
-            FeatherObj lo = get_obj(p, &left);
-            FeatherObj ro = get_obj(p, &right);
+            FeatherObj lo = get_string(p, &left);
+            FeatherObj ro = get_string(p, &right);
             int cmp = p->ops->string.compare(p->interp, lo, ro);
             left = make_int(cmp == 0 ? 1 : 0);
           }
@@ -1511,21 +1775,22 @@ static ExprValue parse_equality(ExprParser *p) {
       p->pos += 2;
       ExprValue right = parse_comparison(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       if (needs_float_math(&left, &right)) {
         double lv, rv;
         if (get_double(p, &left, &lv) && get_double(p, &right, &rv)) {
           left = make_int(lv != rv ? 1 : 0);
         } else {
           // Fall back to string comparison
-          FeatherObj lo = get_obj(p, &left);
-          FeatherObj ro = get_obj(p, &right);
+          FeatherObj lo = get_string(p, &left);
+          FeatherObj ro = get_string(p, &right);
           int cmp = p->ops->string.compare(p->interp, lo, ro);
           left = make_int(cmp != 0 ? 1 : 0);
         }
       } else {
-        int64_t lv, rv;
-        if (get_int(p, &left, &lv) && get_int(p, &right, &rv)) {
-          left = make_int(lv != rv ? 1 : 0);
+        int icmp;
+        if (compare_integers(p, &left, &right, &icmp)) {
+          left = make_int(icmp != 0 ? 1 : 0);
         } else {
           // Try double
           double dlv, drv;
@@ -1533,8 +1798,8 @@ static ExprValue parse_equality(ExprParser *p) {
             left = make_int(dlv != drv ? 1 : 0);
           } else {
             // Fall back to string comparison
-            FeatherObj lo = get_obj(p, &left);
-            FeatherObj ro = get_obj(p, &right);
+            FeatherObj lo = get_string(p, &left);
+            FeatherObj ro = get_string(p, &right);
             int cmp = p->ops->string.compare(p->interp, lo, ro);
             left = make_int(cmp != 0 ? 1 : 0);
           }
@@ -1562,12 +1827,16 @@ static ExprValue parse_bitwise_and(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_equality(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       int64_t lv, rv;
-      if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
-        set_syntax_error(p);
-        return make_error();
+      int kind = get_int_operands(p, &left, &right, "&", &lv, &rv);
+      if (kind == 0) return make_error();
+      if (kind == 2) {
+        left = big_arith(p, FEATHER_BIG_AND, &left, &right);
+        if (p->has_error) return make_error();
+      } else {
+        left = make_int(lv & rv);
       }
-      left = make_int(lv & rv);
     } else {
       break;
     }
@@ -1589,12 +1858,16 @@ static ExprValue parse_bitwise_xor(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_bitwise_and(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       int64_t lv, rv;
-      if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
-        set_syntax_error(p);
-        return make_error();
+      int kind = get_int_operands(p, &left, &right, "^", &lv, &rv);
+      if (kind == 0) return make_error();
+      if (kind == 2) {
+        left = big_arith(p, FEATHER_BIG_XOR, &left, &right);
+        if (p->has_error) return make_error();
+      } else {
+        left = make_int(lv ^ rv);
       }
-      left = make_int(lv ^ rv);
     } else {
       break;
     }
@@ -1617,12 +1890,16 @@ static ExprValue parse_bitwise_or(ExprParser *p) {
       p->pos++;
       ExprValue right = parse_bitwise_xor(p);
       if (p->has_error) return make_error();
+      if (p->skip_mode) continue;
       int64_t lv, rv;
-      if (!get_int(p, &left, &lv) || !get_int(p, &right, &rv)) {
-        set_syntax_error(p);
-        return make_error();
+      int kind = get_int_operands(p, &left, &right, "|", &lv, &rv);
+      if (kind == 0) return make_error();
+      if (kind == 2) {
+        left = big_arith(p, FEATHER_BIG_OR, &left, &right);
+        if (p->has_error) return make_error();
+      } else {
+        left = make_int(lv | rv);
       }
-      left = make_int(lv | rv);
     } else {
       break;
     }
@@ -1642,30 +1919,26 @@ static ExprValue parse_logical_and(ExprParser *p) {
 
     if (p->pos + 1 < p->len && CUR_BYTE(p) == '&' && BYTE_AT(p, p->pos + 1) == '&') {
       p->pos += 2;
-      int64_t lv;
-      if (!get_int(p, &left, &lv)) {
-        set_syntax_error(p);
+      int64_t lv = 1;
+      if (!p->skip_mode && !get_truth(p, &left, &lv)) {
         return make_error();
       }
-      // Short-circuit: if left is false, don't evaluate right
-      if (!lv) {
-        // Skip parsing the right operand (no side effects)
-        int saved_skip = p->skip_mode;
-        p->skip_mode = 1;
-        parse_bitwise_or(p);
-        p->skip_mode = saved_skip;
-        if (p->has_error) return make_error();
+      // Short-circuit: if left is false, parse the right operand without
+      // evaluating it
+      int saved_skip = p->skip_mode;
+      p->skip_mode = saved_skip || !lv;
+      ExprValue right = parse_bitwise_or(p);
+      p->skip_mode = saved_skip;
+      if (p->has_error) return make_error();
+      if (p->skip_mode || !lv) {
         left = make_int(0);
-      } else {
-        ExprValue right = parse_bitwise_or(p);
-        if (p->has_error) return make_error();
-        int64_t rv;
-        if (!get_int(p, &right, &rv)) {
-          set_syntax_error(p);
-          return make_error();
-        }
-        left = make_int(rv ? 1 : 0);
+        continue;
+      }
+      int64_t rv;
+      if (!get_truth(p, &right, &rv)) {
+        return make_error();
       }
+      left = make_int(rv ? 1 : 0);
     } else {
       break;
     }
@@ -1685,30 +1958,26 @@ static ExprValue parse_logical_or(ExprParser *p) {
 
     if (p->pos + 1 < p->len && CUR_BYTE(p) == '|' && BYTE_AT(p, p->pos + 1) == '|') {
       p->pos += 2;
-      int64_t lv;
-      if (!get_int(p, &left, &lv)) {
-        set_syntax_error(p);
+      int64_t lv = 0;
+      if (!p->skip_mode && !get_truth(p, &left, &lv)) {
         return make_error();
       }
-      // Short-circuit: if left is true, don't evaluate right
-      if (lv) {
-        // Skip parsing the right operand (no side effects)
-        int saved_skip = p->skip_mode;
-        p->skip_mode = 1;
-        parse_logical_and(p);
-        p->skip_mode = saved_skip;
-        if (p->has_error) return make_error();
+      // Short-circuit: if left is true, parse the right operand without
+      // evaluating it
+      int saved_skip = p->skip_mode;
+      p->skip_mode = saved_skip || lv;
+      ExprValue right = parse_logical_and(p);
+      p->skip_mode = saved_skip;
+      if (p->has_error) return make_error();
+      if (p->skip_mode || lv) {
         left = make_int(1);
-      } else {
-        ExprValue right = parse_logical_and(p);
-        if (p->has_error) return make_error();
-        int64_t rv;
-        if (!get_int(p, &right, &rv)) {
-          set_syntax_error(p);
-          return make_error();
-        }
-        left = make_int(rv ? 1 : 0);
+        continue;
+      }
+      int64_t rv;
+      if (!get_truth(p, &right, &rv)) {
+        return make_error();
       }
+      left = make_int(rv ? 1 : 0);
     } else {
       break;
     }
@@ -1717,7 +1986,8 @@ static ExprValue parse_logical_or(ExprParser *p) {
   return left;
 }
 
-// Parse ternary: logical_or ? expr : expr (right-to-left)
+// Parse ternary: logical_or ? expr : expr (right-to-left). Only the branch
+// the condition selects is evaluated; the other is parsed in skip mode.
 static ExprValue parse_ternary(ExprParser *p) {
   ExprValue cond = parse_logical_or(p);
   if (p->has_error) return make_error();
@@ -1725,53 +1995,81 @@ static ExprValue parse_ternary(ExprParser *p) {
   expr_skip_whitespace(p);
   if (p->pos < p->len && CUR_BYTE(p) == '?') {
     p->pos++;
-    int64_t cv;
-    if (!get_int(p, &cond, &cv)) {
-      set_syntax_error(p);
+    int64_t cv = 1;
+    if (!p->skip_mode && !get_truth(p, &cond, &cv)) {
       return make_error();
     }
 
-    ExprValue result;
     int saved_skip = p->skip_mode;
+    p->skip_mode = saved_skip || !cv;
+    ExprValue then_val = parse_ternary(p);
+    p->skip_mode = saved_skip;
+    if (p->has_error) return make_error();
 
-    if (cv) {
-      // Condition is true: evaluate then branch, skip else branch
-      result = parse_ternary(p);
-      if (p->has_error) return make_error();
+    expr_skip_whitespace(p);
+    if (AT_END(p) || CUR_BYTE(p) != ':') {
+      set_syntax_error(p);
+      return make_error();
+    }
+    p->pos++;
 
-      expr_skip_whitespace(p);
-      if (AT_END(p) || CUR_BYTE(p) != ':') {
-        set_syntax_error(p);
-        return make_error();
-      }
-      p->pos++;
+    p->skip_mode = saved_skip || cv;
+    ExprValue else_val = parse_ternary(p);
+    p->skip_mode = saved_skip;
+    if (p->has_error) return make_error();
 
-      p->skip_mode = 1;
-      parse_ternary(p);
-      p->skip_mode = saved_skip;
-      if (p->has_error) return make_error();
-    } else {
-      // Condition is false: skip then branch, evaluate else branch
-      p->skip_mode = 1;
-      parse_ternary(p);
-      p->skip_mode = saved_skip;
-      if (p->has_error) return make_error();
+    return cv ? then_val : else_val;
+  }
 
-      expr_skip_whitespace(p);
-      if (AT_END(p) || CUR_BYTE(p) != ':') {
-        set_syntax_error(p);
-        return make_error();
-      }
-      p->pos++;
+  return cond;
+}
 
-      result = parse_ternary(p);
-      if (p->has_error) return make_error();
+/**
+ * Parses and evaluates expr_obj with parser, storing its value in *out.
+ * On error, leaves the message in the interpreter result.
+ */
+static FeatherResult expr_evaluate(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj expr_obj, ExprParser *parser,
+                                   ExprValue *out) {
+  // Initialize parser with position-based iteration (no string.get needed)
+  FeatherBytes src = feather_bytes(ops, interp, expr_obj);
+  *parser = (ExprParser){
+    .ops = ops,
+    .interp = interp,
+    .src = src,
+    .expr_obj = expr_obj,
+    .len = src.len,
+    .pos = 0,
+    .has_error = 0,
+    .error_msg = 0,
+    .skip_mode = 0
+  };
+
+  // Parse and evaluate
+  *out = parse_ternary(parser);
+
+  // Check for trailing content
+  expr_skip_whitespace(parser);
+  if (!parser->has_error && parser->pos < parser->len) {
+    if (CUR_BYTE(parser) == ')') {
+      set_close_paren_error(parser);
+    } else {
+      set_syntax_error(parser);
     }
+  }
 
-    return result;
+  if (parser->has_error) {
+    ops->interp.set_result(interp, parser->error_msg);
+    return TCL_ERROR;
   }
 
-  return cond;
+  // Check for NaN result - TCL expr errors on NaN (unless checked by isnan)
+  if (out->is_double && ops->dbl.classify(out->dbl_val) == FEATHER_DBL_NAN) {
+    FeatherObj msg = ops->string.intern(interp, "domain error: argument not in valid range", 41);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  return TCL_OK;
 }
 
 FeatherResult feather_builtin_expr(const FeatherHostOps *ops, FeatherInterp interp,
@@ -1797,46 +2095,32 @@ FeatherResult feather_builtin_expr(const FeatherHostOps *ops, FeatherInterp inte
     argc--;
   }
 
-  // Initialize parser with position-based iteration (no string.get needed)
-  ExprParser parser = {
-    .ops = ops,
-    .interp = interp,
-    .expr_obj = expr_obj,
-    .len = ops->string.byte_length(interp, expr_obj),
-    .pos = 0,
-    .has_error = 0,
-    .error_msg = 0,
-    .skip_mode = 0
-  };
-
-  // Parse and evaluate
-  ExprValue result = parse_ternary(&parser);
-
-  // Check for trailing content
-  expr_skip_whitespace(&parser);
-  if (!parser.has_error && parser.pos < parser.len) {
-    if (CUR_BYTE(&parser) == ')') {
-      set_close_paren_error(&parser);
-    } else {
-      set_syntax_error(&parser);
-    }
+  ExprParser parser;
+  ExprValue result;
+  if (expr_evaluate(ops, interp, expr_obj, &parser, &result) != TCL_OK) {
+    return TCL_ERROR;
   }
 
-  if (parser.has_error) {
-    ops->interp.set_result(interp, parser.error_msg);
+  // Return result
+  FeatherObj result_obj = get_obj(&parser, &result);
+  ops->interp.set_result(interp, result_obj);
+  return TCL_OK;
+}
+
+FeatherResult feather_expr_bool(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj expr, int *result) {
+  ExprParser parser;
+  ExprValue value;
+  if (expr_evaluate(ops, interp, expr, &parser, &value) != TCL_OK) {
     return TCL_ERROR;
   }
 
-  // Check for NaN result - TCL expr errors on NaN (unless checked by isnan)
-  if (result.is_double && ops->dbl.classify(result.dbl_val) == FEATHER_DBL_NAN) {
-    FeatherObj msg = ops->string.intern(interp, "domain error: argument not in valid range", 41);
-    ops->interp.set_result(interp, msg);
+  int64_t truth;
+  if (!get_truth(&parser, &value, &truth)) {
+    ops->interp.set_result(interp, parser.error_msg);
     return TCL_ERROR;
   }
-
-  // Return result
-  FeatherObj result_obj = get_obj(&parser, &result);
-  ops->interp.set_result(interp, result_obj);
+  *result = truth != 0;
   return TCL_OK;
 }
 
@@ -1,6 +1,7 @@
 #include "feather.h"
 #include "internal.h"
 #include "charclass.h"
+#include "unicode.h"
 
 // Helper: convert integer to string representation
 // Returns pointer to static buffer, caller should use immediately
@@ -102,11 +103,34 @@ typedef struct {
   SizeModifier size_mod;  // Size modifier (h, l, ll, etc.)
 } FormatSpec;
 
+// Report a field specifier that ends early or has a bad conversion
+// character at pos
+static void format_spec_error(const FeatherHostOps *ops, FeatherInterp interp,
+                              FeatherObj fmtObj, size_t pos, size_t fmtLen) {
+  if (pos >= fmtLen) {
+    FeatherObj msg = ops->string.intern(interp,
+      "format string ended in middle of field specifier", 48);
+    ops->interp.set_result(interp, msg);
+    return;
+  }
+
+  // Quote the whole character, including UTF-8 continuation bytes
+  size_t end = pos + 1;
+  while (end < fmtLen && (ops->string.byte_at(interp, fmtObj, end) & 0xC0) == 0x80) {
+    end++;
+  }
+  FeatherObj msg = ops->string.intern(interp, "bad field specifier \"", 21);
+  msg = ops->string.concat(interp, msg, ops->string.slice(interp, fmtObj, pos, end));
+  msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+  ops->interp.set_result(interp, msg);
+}
+
 // Parse a format specifier starting after the '%'
 // fmtObj: the format string object
 // start: position in fmtObj to start parsing (after '%')
 // fmtLen: total length of format string
-// Returns number of characters consumed, or -1 on error
+// Returns number of characters consumed, or -1 with the interpreter result
+// set on error
 static int parse_format_spec_obj(const FeatherHostOps *ops, FeatherInterp interp,
                                  FeatherObj fmtObj, size_t start, size_t fmtLen,
                                  FormatSpec *spec) {
@@ -127,7 +151,10 @@ static int parse_format_spec_obj(const FeatherHostOps *ops, FeatherInterp interp
   spec->specifier = 0;
   spec->size_mod = SIZE_NONE;
 
-  if (pos >= fmtLen) return -1;
+  if (pos >= fmtLen) {
+    format_spec_error(ops, interp, fmtObj, pos, fmtLen);
+    return -1;
+  }
 
   int ch = ops->string.byte_at(interp, fmtObj, pos);
 
@@ -237,9 +264,7 @@ static int parse_format_spec_obj(const FeatherHostOps *ops, FeatherInterp interp
   }
 
   // Parse specifier
-  if (pos >= fmtLen) return -1;
-
-  ch = ops->string.byte_at(interp, fmtObj, pos);
+  ch = (pos < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos) : -1;
   if (ch == 'd' || ch == 'i' || ch == 'u' || ch == 'o' || ch == 'x' || ch == 'X' ||
       ch == 'b' || ch == 'c' || ch == 's' || ch == 'f' || ch == 'e' || ch == 'E' ||
       ch == 'g' || ch == 'G' || ch == 'a' || ch == 'A' || ch == 'p') {
@@ -248,39 +273,39 @@ static int parse_format_spec_obj(const FeatherHostOps *ops, FeatherInterp interp
     return (int)(pos - start);
   }
 
-  return -1; // Invalid specifier
+  format_spec_error(ops, interp, fmtObj, pos, fmtLen);
+  return -1;
 }
 
-// Apply field width and justification
-// If padchar is '0' and the string starts with a sign, the zeros go after the sign
+// Apply field width and justification, counting width in characters.
+// If padchar is '0' and a numeric string starts with a sign, the zeros go
+// after the sign; other strings are zero-padded in front as they are.
 static FeatherObj apply_width(const FeatherHostOps *ops, FeatherInterp interp,
-                             FeatherObj str, int width, int left_justify, char padchar) {
+                             FeatherObj str, int width, int left_justify, char padchar,
+                             int numeric) {
   if (width <= 0) return str;
 
-  size_t len = ops->string.byte_length(interp, str);
+  size_t len = ops->rune.length(interp, str);
 
   if (len >= (size_t)width) return str;
 
   size_t padlen = (size_t)width - len;
-  char padbuf[256];
-  if (padlen > sizeof(padbuf) - 1) padlen = sizeof(padbuf) - 1;
-
+  FeatherObj builder = ops->string.builder_new(interp, padlen);
   for (size_t i = 0; i < padlen; i++) {
-    padbuf[i] = padchar;
+    ops->string.builder_append_byte(interp, builder, padchar);
   }
-  padbuf[padlen] = '\0';
-
-  FeatherObj pad = ops->string.intern(interp, padbuf, padlen);
+  FeatherObj pad = ops->string.builder_finish(interp, builder);
 
   if (left_justify) {
     return ops->string.concat(interp, str, pad);
   } else {
     // Special case: zero padding with sign - zeros go after sign
     int first_byte = ops->string.byte_at(interp, str, 0);
-    if (padchar == '0' && len > 0 && (first_byte == '-' || first_byte == '+')) {
+    if (numeric && padchar == '0' && len > 0 && (first_byte == '-' || first_byte == '+')) {
+      size_t bytes = ops->string.byte_length(interp, str);
       char signbuf[2] = {(char)first_byte, '\0'};
       FeatherObj sign = ops->string.intern(interp, signbuf, 1);
-      FeatherObj rest = ops->string.slice(interp, str, 1, len);
+      FeatherObj rest = ops->string.slice(interp, str, 1, bytes);
       FeatherObj result = ops->string.concat(interp, sign, pad);
       return ops->string.concat(interp, result, rest);
     }
@@ -288,62 +313,45 @@ static FeatherObj apply_width(const FeatherHostOps *ops, FeatherInterp interp,
   }
 }
 
-// Format an integer value
-static FeatherObj format_integer(const FeatherHostOps *ops, FeatherInterp interp,
-                                int64_t val, FormatSpec *spec) {
-  char buf[128];
-  size_t buflen;
-  int base = 10;
-  int uppercase = 0;
-  int is_unsigned = 0;
-
-  // Apply truncation based on size modifier
-  val = feather_apply_format_truncation(val, spec->size_mod);
-
-  switch (spec->specifier) {
-    case 'd':
-    case 'i':
-      base = 10;
-      break;
+// Get the base and case of an integer conversion, returning 1 if it is unsigned
+static int integer_conversion(char specifier, int *base, int *uppercase) {
+  *base = 10;
+  *uppercase = 0;
+  switch (specifier) {
     case 'u':
-      base = 10;
-      is_unsigned = 1;
-      break;
+      return 1;
     case 'o':
-      base = 8;
-      is_unsigned = 1;
-      break;
+      *base = 8;
+      return 1;
     case 'x':
-      base = 16;
-      is_unsigned = 1;
-      break;
+      *base = 16;
+      return 1;
     case 'X':
-      base = 16;
-      is_unsigned = 1;
-      uppercase = 1;
-      break;
+      *base = 16;
+      *uppercase = 1;
+      return 1;
     case 'b':
-      base = 2;
-      is_unsigned = 1;
-      break;
-    default:
-      base = 10;
-  }
-
-  if (is_unsigned) {
-    buflen = uint_to_str((uint64_t)val, buf, sizeof(buf), base, uppercase);
-  } else {
-    buflen = int_to_str(val, buf, sizeof(buf), base, uppercase);
+      *base = 2;
+      return 1;
   }
+  return 0;
+}
 
+// Apply precision, sign, prefix and width to the digits of an integer.
+// digits may start with '-'.
+static FeatherObj format_digits(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj digits, int is_unsigned, int is_zero,
+                                FormatSpec *spec) {
   // Apply precision (minimum digits)
   int precision = spec->precision;
   if (precision == -2) precision = 1; // Default
   if (precision == -1) precision = 0;
 
   // Handle precision: pad with leading zeros if needed
+  size_t buflen = ops->string.byte_length(interp, digits);
   size_t numDigits = buflen;
-  int hasSign = (buf[0] == '-' || buf[0] == '+');
+  int first = ops->string.byte_at(interp, digits, 0);
+  int hasSign = (first == '-' || first == '+');
   if (hasSign) numDigits--;
 
   FeatherObj result;
@@ -356,23 +364,22 @@ static FeatherObj format_integer(const FeatherHostOps *ops, FeatherInterp interp
     padbuf[padcount] = '\0';
 
     if (hasSign) {
-      char signbuf[2] = {buf[0], '\0'};
+      char signbuf[2] = {(char)first, '\0'};
       FeatherObj sign = ops->string.intern(interp, signbuf, 1);
       FeatherObj zeros = ops->string.intern(interp, padbuf, padcount);
-      FeatherObj digits = ops->string.intern(interp, buf + 1, buflen - 1);
+      FeatherObj rest = ops->string.slice(interp, digits, 1, buflen);
       result = ops->string.concat(interp, sign, zeros);
-      result = ops->string.concat(interp, result, digits);
+      result = ops->string.concat(interp, result, rest);
     } else {
       FeatherObj zeros = ops->string.intern(interp, padbuf, padcount);
-      FeatherObj digits = ops->string.intern(interp, buf, buflen);
       result = ops->string.concat(interp, zeros, digits);
     }
   } else {
-    result = ops->string.intern(interp, buf, buflen);
+    result = digits;
   }
 
   // Add sign if needed
-  if (!hasSign && val >= 0 && !is_unsigned) {
+  if (!hasSign && !is_unsigned) {
     if (spec->show_sign) {
       FeatherObj plus = ops->string.intern(interp, "+", 1);
       result = ops->string.concat(interp, plus, result);
@@ -382,82 +389,91 @@ static FeatherObj format_integer(const FeatherHostOps *ops, FeatherInterp interp
     }
   }
 
-  // Add alternate prefix
-  int used_decimal_alternate = 0;
-  if (spec->alternate && val != 0) {
-    if (spec->specifier == 'x' || spec->specifier == 'X') {
-      FeatherObj prefix = ops->string.intern(interp, "0x", 2);
-      result = ops->string.concat(interp, prefix, result);
-    } else if (spec->specifier == 'o') {
-      FeatherObj prefix = ops->string.intern(interp, "0o", 2);
-      result = ops->string.concat(interp, prefix, result);
-    } else if (spec->specifier == 'b') {
-      FeatherObj prefix = ops->string.intern(interp, "0b", 2);
-      result = ops->string.concat(interp, prefix, result);
-    } else if (spec->specifier == 'd' || spec->specifier == 'i') {
-      used_decimal_alternate = 1;
-      // Handle %#d specially: sign + 0d + zeros + digits
-      // We'll apply width here instead of in apply_width
-      FeatherObj prefix = ops->string.intern(interp, "0d", 2);
-      int first_byte = ops->string.byte_at(interp, result, 0);
-      int result_has_sign = (first_byte == '-' || first_byte == '+');
-      size_t result_len = ops->string.byte_length(interp, result);
-
-      // Calculate total length needed for width
-      size_t total_len = result_len + 2; // result + "0d"
-
-      if (spec->zero_pad && !spec->left_justify && spec->width > 0 &&
-          (size_t)spec->width > total_len) {
-        // Need zero padding: sign + 0d + zeros + digits
-        size_t zeros_needed = (size_t)spec->width - total_len;
-        char zeros[256];
-        if (zeros_needed > sizeof(zeros) - 1) zeros_needed = sizeof(zeros) - 1;
-        for (size_t i = 0; i < zeros_needed; i++) zeros[i] = '0';
-        zeros[zeros_needed] = '\0';
-        FeatherObj zerosObj = ops->string.intern(interp, zeros, zeros_needed);
-
-        if (result_has_sign) {
-          // -42 with width 8 -> -0d00042
-          char signbuf[2] = {(char)first_byte, '\0'};
-          FeatherObj sign = ops->string.intern(interp, signbuf, 1);
-          FeatherObj digits = ops->string.slice(interp, result, 1, result_len);
-          result = ops->string.concat(interp, sign, prefix);
-          result = ops->string.concat(interp, result, zerosObj);
-          result = ops->string.concat(interp, result, digits);
-        } else {
-          // 42 with width 8 -> 0d000042
-          result = ops->string.concat(interp, prefix, zerosObj);
-          FeatherObj digits = ops->string.intern(interp, buf, buflen);
-          result = ops->string.concat(interp, result, digits);
-        }
-      } else {
-        // No zero padding, just add prefix
-        if (result_has_sign) {
-          size_t len = ops->string.byte_length(interp, result);
-          char signbuf[2] = {(char)first_byte, '\0'};
-          FeatherObj sign = ops->string.intern(interp, signbuf, 1);
-          FeatherObj rest = ops->string.slice(interp, result, 1, len);
-          result = ops->string.concat(interp, sign, prefix);
-          result = ops->string.concat(interp, result, rest);
-        } else {
-          result = ops->string.concat(interp, prefix, result);
-        }
-      }
+  // Add alternate prefix after any sign, with zero padding between the
+  // prefix and the digits: -255 in %#08x is -0x000ff
+  int used_prefix = 0;
+  const char *prefix = NULL;
+  if (spec->alternate && !is_zero) {
+    switch (spec->specifier) {
+      case 'x': case 'X': prefix = "0x"; break;
+      case 'o': prefix = "0o"; break;
+      case 'b': prefix = "0b"; break;
+      case 'd': case 'i': prefix = "0d"; break;
+    }
+  }
+  if (prefix) {
+    used_prefix = 1;
+    FeatherObj prefixObj = ops->string.intern(interp, prefix, 2);
+    size_t result_len = ops->string.byte_length(interp, result);
+    int first_byte = ops->string.byte_at(interp, result, 0);
+    int result_has_sign = (first_byte == '-' || first_byte == '+' || first_byte == ' ');
+    FeatherObj head = prefixObj;
+    FeatherObj rest = result;
+    if (result_has_sign) {
+      char signbuf[2] = {(char)first_byte, '\0'};
+      FeatherObj sign = ops->string.intern(interp, signbuf, 1);
+      head = ops->string.concat(interp, sign, prefixObj);
+      rest = ops->string.slice(interp, result, 1, result_len);
     }
+
+    size_t total_len = result_len + 2;
+    if (spec->zero_pad && !spec->left_justify && spec->width > 0 &&
+        (size_t)spec->width > total_len) {
+      size_t zeros_needed = (size_t)spec->width - total_len;
+      char zeros[256];
+      if (zeros_needed > sizeof(zeros) - 1) zeros_needed = sizeof(zeros) - 1;
+      for (size_t i = 0; i < zeros_needed; i++) zeros[i] = '0';
+      zeros[zeros_needed] = '\0';
+      FeatherObj zerosObj = ops->string.intern(interp, zeros, zeros_needed);
+      head = ops->string.concat(interp, head, zerosObj);
+    }
+    result = ops->string.concat(interp, head, rest);
   }
 
-  // Apply width (skip for %#d with non-zero value - already handled)
-  if (!used_decimal_alternate) {
+  // Apply width; a prefixed number has had its zero padding already
+  if (!used_prefix) {
     char padchar = (spec->zero_pad && !spec->left_justify && spec->precision == -2) ? '0' : ' ';
-    result = apply_width(ops, interp, result, spec->width, spec->left_justify, padchar);
-  } else if (spec->width > 0 && !spec->zero_pad) {
-    // %#d needs space padding (not zero), use apply_width
-    result = apply_width(ops, interp, result, spec->width, spec->left_justify, ' ');
+    result = apply_width(ops, interp, result, spec->width, spec->left_justify, padchar, 1);
+  } else {
+    result = apply_width(ops, interp, result, spec->width, spec->left_justify, ' ', 1);
   }
 
   return result;
 }
 
+// Format an integer value
+static FeatherObj format_integer(const FeatherHostOps *ops, FeatherInterp interp,
+                                int64_t val, FormatSpec *spec) {
+  char buf[128];
+  size_t buflen;
+  int base, uppercase;
+
+  // Apply truncation based on size modifier
+  val = feather_apply_format_truncation(val, spec->size_mod);
+
+  int is_unsigned = integer_conversion(spec->specifier, &base, &uppercase);
+  if (is_unsigned) {
+    // %h keeps only the 16 bits it was truncated to
+    uint64_t uval = spec->size_mod == SIZE_H ? (uint64_t)(uint16_t)val : (uint64_t)val;
+    buflen = uint_to_str(uval, buf, sizeof(buf), base, uppercase);
+  } else {
+    buflen = int_to_str(val, buf, sizeof(buf), base, uppercase);
+  }
+
+  FeatherObj digits = ops->string.intern(interp, buf, buflen);
+  return format_digits(ops, interp, digits, is_unsigned, val == 0, spec);
+}
+
+// Format an integer of any size (%lld and friends). As in TCL, these are
+// always signed, so -1 in %llx is -1, and the prefix is kept for zero.
+static FeatherObj format_bignum(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj big, FormatSpec *spec) {
+  int base, uppercase;
+  integer_conversion(spec->specifier, &base, &uppercase);
+  FeatherObj digits = ops->bignum.format(interp, big, base, uppercase);
+  return format_digits(ops, interp, digits, 0, 0, spec);
+}
+
 // Format a string value
 static FeatherObj format_string(const FeatherHostOps *ops, FeatherInterp interp,
                                FeatherObj str, FormatSpec *spec) {
@@ -470,7 +486,8 @@ static FeatherObj format_string(const FeatherHostOps *ops, FeatherInterp interp,
   }
 
   // Apply width
-  str = apply_width(ops, interp, str, spec->width, spec->left_justify, ' ');
+  char padchar = (spec->zero_pad && !spec->left_justify) ? '0' : ' ';
+  str = apply_width(ops, interp, str, spec->width, spec->left_justify, padchar, 0);
 
   return str;
 }
@@ -524,9 +541,6 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
     FormatSpec spec;
     int consumed = parse_format_spec_obj(ops, interp, fmtObj, pos, fmtLen, &spec);
     if (consumed < 0) {
-      FeatherObj msg = ops->string.intern(interp,
-        "format string ended in middle of field specifier", 48);
-      ops->interp.set_result(interp, msg);
       return TCL_ERROR;
     }
     pos += (size_t)consumed;
@@ -547,13 +561,6 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
         return TCL_ERROR;
       }
       usedPositional = 1;
-
-      if (spec.position < 1 || (size_t)spec.position >= argc) {
-        FeatherObj msg = ops->string.intern(interp,
-          "\"%n$\" argument index out of range", 33);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
     } else {
       if (usedPositional == 1) {
         FeatherObj msg = ops->string.intern(interp,
@@ -564,17 +571,25 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
       usedPositional = 0;
     }
 
+    // Width, precision and value are taken in that order, starting at the
+    // positional index if there is one
+    size_t cursor = spec.has_positional ? (size_t)spec.position : argIndex;
+    int needed = 1 + spec.width_from_arg + spec.precision_from_arg;
+    if ((spec.has_positional && spec.position < 1) || cursor + (size_t)needed > argc) {
+      const char *text = spec.has_positional
+        ? "\"%n$\" argument index out of range"
+        : "not enough arguments for all format specifiers";
+      FeatherObj msg = ops->string.intern(interp, text, spec.has_positional ? 33 : 46);
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
+    }
+
     // Get width from argument if needed
     if (spec.width_from_arg) {
-      if (argIndex >= argc) {
-        FeatherObj msg = ops->string.intern(interp,
-          "not enough arguments for all format specifiers", 46);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      FeatherObj widthArg = ops->list.at(interp, args, argIndex++);
+      FeatherObj widthArg = ops->list.at(interp, args, cursor++);
       int64_t w;
       if (ops->integer.get(interp, widthArg, &w) != TCL_OK) {
+        feather_error_expected(ops, interp, "integer", widthArg);
         return TCL_ERROR;
       }
       spec.width = (int)w;
@@ -586,15 +601,10 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
 
     // Get precision from argument if needed
     if (spec.precision_from_arg) {
-      if (argIndex >= argc) {
-        FeatherObj msg = ops->string.intern(interp,
-          "not enough arguments for all format specifiers", 46);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      FeatherObj precArg = ops->list.at(interp, args, argIndex++);
+      FeatherObj precArg = ops->list.at(interp, args, cursor++);
       int64_t p;
       if (ops->integer.get(interp, precArg, &p) != TCL_OK) {
+        feather_error_expected(ops, interp, "integer", precArg);
         return TCL_ERROR;
       }
       spec.precision = (int)p;
@@ -602,17 +612,9 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
     }
 
     // Get the value to format
-    size_t valueIndex;
-    if (spec.has_positional) {
-      valueIndex = (size_t)spec.position;
-    } else {
-      if (argIndex >= argc) {
-        FeatherObj msg = ops->string.intern(interp,
-          "not enough arguments for all format specifiers", 46);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      valueIndex = argIndex++;
+    size_t valueIndex = cursor;
+    if (!spec.has_positional) {
+      argIndex = cursor + 1;
     }
 
     FeatherObj value = ops->list.at(interp, args, valueIndex);
@@ -627,11 +629,24 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
       case 'X':
       case 'b': {
         int64_t intVal;
-        if (ops->integer.get(interp, value, &intVal) != TCL_OK) {
+        FeatherObj bigVal;
+        if (spec.size_mod == SIZE_LL || spec.size_mod == SIZE_BIG_L) {
+          if (spec.specifier == 'u') {
+            FeatherObj msg = ops->string.intern(interp, "unsigned bignum format is invalid", 33);
+            ops->interp.set_result(interp, msg);
+            return TCL_ERROR;
+          }
+          if (ops->bignum.get(interp, value, &bigVal) != TCL_OK) {
+            feather_error_expected(ops, interp, "integer", value);
+            return TCL_ERROR;
+          }
+          formatted = format_bignum(ops, interp, bigVal, &spec);
+        } else if (ops->integer.get(interp, value, &intVal) == TCL_OK) {
+          formatted = format_integer(ops, interp, intVal, &spec);
+        } else {
           feather_error_expected(ops, interp, "integer", value);
           return TCL_ERROR;
         }
-        formatted = format_integer(ops, interp, intVal, &spec);
         break;
       }
 
@@ -641,45 +656,13 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
           feather_error_expected(ops, interp, "integer", value);
           return TCL_ERROR;
         }
-        // Convert integer to Unicode character
-        // For simplicity, handle ASCII range directly
-        if (charVal >= 0 && charVal <= 127) {
-          char c = (char)charVal;
-          formatted = ops->string.intern(interp, &c, 1);
-        } else {
-          // Use host's integer to character conversion via string creation
-          formatted = ops->integer.create(interp, charVal);
-          // Actually need to create a character - use rune.at on a string created from codepoint
-          // For now, handle basic ASCII and let host handle Unicode
-          char buf[8];
-          if (charVal < 128) {
-            buf[0] = (char)charVal;
-            buf[1] = '\0';
-            formatted = ops->string.intern(interp, buf, 1);
-          } else {
-            // UTF-8 encode
-            if (charVal < 0x80) {
-              buf[0] = (char)charVal;
-              formatted = ops->string.intern(interp, buf, 1);
-            } else if (charVal < 0x800) {
-              buf[0] = (char)(0xC0 | (charVal >> 6));
-              buf[1] = (char)(0x80 | (charVal & 0x3F));
-              formatted = ops->string.intern(interp, buf, 2);
-            } else if (charVal < 0x10000) {
-              buf[0] = (char)(0xE0 | (charVal >> 12));
-              buf[1] = (char)(0x80 | ((charVal >> 6) & 0x3F));
-              buf[2] = (char)(0x80 | (charVal & 0x3F));
-              formatted = ops->string.intern(interp, buf, 3);
-            } else {
-              buf[0] = (char)(0xF0 | (charVal >> 18));
-              buf[1] = (char)(0x80 | ((charVal >> 12) & 0x3F));
-              buf[2] = (char)(0x80 | ((charVal >> 6) & 0x3F));
-              buf[3] = (char)(0x80 | (charVal & 0x3F));
-              formatted = ops->string.intern(interp, buf, 4);
-            }
-          }
-        }
-        formatted = apply_width(ops, interp, formatted, spec.width, spec.left_justify, ' ');
+        // Code points outside the Unicode range become U+FFFD
+        char buf[4];
+        uint32_t codepoint = (charVal < 0 || charVal > 0x10FFFF) ? 0xFFFD : (uint32_t)charVal;
+        size_t buflen = feather_utf8_encode(codepoint, buf);
+        formatted = ops->string.intern(interp, buf, buflen);
+        char padchar = (spec.zero_pad && !spec.left_justify) ? '0' : ' ';
+        formatted = apply_width(ops, interp, formatted, spec.width, spec.left_justify, padchar, 0);
         break;
       }
 
@@ -710,7 +693,16 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
         if (precision == -1) precision = 0;
 
         formatted = ops->dbl.format(interp, dblVal, spec.specifier, precision, spec.alternate);
-        formatted = apply_width(ops, interp, formatted, spec.width, spec.left_justify, ' ');
+        int first = ops->string.byte_at(interp, formatted, 0);
+        if (first != '-' && (spec.show_sign || spec.space_sign)) {
+          FeatherObj sign = ops->string.intern(interp, spec.show_sign ? "+" : " ", 1);
+          formatted = ops->string.concat(interp, sign, formatted);
+        }
+        // Infinities are never zero-padded
+        int finite = ops->dbl.classify(dblVal) != FEATHER_DBL_INF &&
+                     ops->dbl.classify(dblVal) != FEATHER_DBL_NEG_INF;
+        char padchar = (spec.zero_pad && !spec.left_justify && finite) ? '0' : ' ';
+        formatted = apply_width(ops, interp, formatted, spec.width, spec.left_justify, padchar, 1);
         break;
       }
 
@@ -725,7 +717,7 @@ FeatherResult feather_builtin_format(const FeatherHostOps *ops, FeatherInterp in
         FeatherObj hex = ops->string.intern(interp, buf, buflen);
         FeatherObj prefix = ops->string.intern(interp, "0x", 2);
         formatted = ops->string.concat(interp, prefix, hex);
-        formatted = apply_width(ops, interp, formatted, spec.width, spec.left_justify, ' ');
+        formatted = apply_width(ops, interp, formatted, spec.width, spec.left_justify, ' ', 1);
         break;
       }
 
@@ -775,7 +767,9 @@ void feather_register_format_usage(const FeatherHostOps *ops, FeatherInterp inte
     "value to convert is taken from the argument indicated by the number, where "
     "1 corresponds to the first arg.\n\n"
     "If there are any positional specifiers in formatString then all specifiers "
-    "must be positional. Cannot mix positional (%n$) and sequential (%) specifiers.");
+    "must be positional. Cannot mix positional (%n$) and sequential (%) specifiers.\n\n"
+    "A * width or precision in a positional specifier is taken from the argument "
+    "the number indicates, and the value from the arguments after it.");
   spec = feather_usage_add(ops, interp, spec, e);
 
   /* Flags section */
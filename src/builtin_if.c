@@ -78,7 +78,9 @@ FeatherResult feather_builtin_if(const FeatherHostOps *ops, FeatherInterp interp
     }
 
     if (condResult) {
-      // Condition is true, execute body as script
+      // Condition is true, execute body as script; an empty body yields an
+      // empty result
+      ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
       return feather_script_eval_obj(ops, interp, body, TCL_EVAL_LOCAL);
     }
 
@@ -24,9 +24,11 @@ FeatherResult feather_builtin_incr(const FeatherHostOps *ops, FeatherInterp inte
 
   // TCL 8.5+ auto-initializes unset variables to 0
   int64_t current = 0;
+  FeatherObj currentBig = 0;
   if (!ops->list.is_nil(interp, currentVal)) {
-    // Variable exists - convert to integer
-    if (ops->integer.get(interp, currentVal, &current) != TCL_OK) {
+    // Variable exists - convert to an integer of any size
+    if (ops->integer.get(interp, currentVal, &current) != TCL_OK &&
+        ops->bignum.get(interp, currentVal, &currentBig) != TCL_OK) {
       feather_error_expected(ops, interp, "integer", currentVal);
       return TCL_ERROR;
     }
@@ -35,17 +37,28 @@ FeatherResult feather_builtin_incr(const FeatherHostOps *ops, FeatherInterp inte
 
   // Get increment (default 1)
   int64_t increment = 1;
+  FeatherObj incrementBig = 0;
   if (argc == 2) {
     FeatherObj incrVal = ops->list.shift(interp, args);
-    if (ops->integer.get(interp, incrVal, &increment) != TCL_OK) {
+    if (ops->integer.get(interp, incrVal, &increment) != TCL_OK &&
+        ops->bignum.get(interp, incrVal, &incrementBig) != TCL_OK) {
       feather_error_expected(ops, interp, "integer", incrVal);
       return TCL_ERROR;
     }
   }
 
-  // Compute new value and store
-  int64_t newVal = current + increment;
-  FeatherObj newObj = ops->integer.create(interp, newVal);
+  // Compute new value, switching to a bignum if it overflows
+  int64_t newVal;
+  FeatherObj newObj;
+  if (!currentBig && !incrementBig && !__builtin_add_overflow(current, increment, &newVal)) {
+    newObj = ops->integer.create(interp, newVal);
+  } else {
+    FeatherObj a = currentBig ? currentBig : ops->integer.create(interp, current);
+    FeatherObj b = incrementBig ? incrementBig : ops->integer.create(interp, increment);
+    if (ops->bignum.arith(interp, FEATHER_BIG_ADD, a, b, &newObj) != TCL_OK) {
+      return TCL_ERROR;
+    }
+  }
 
   // Store back in variable
   // feather_set_var handles qualified names and fires write traces
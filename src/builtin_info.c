@@ -62,7 +62,10 @@ static FeatherResult info_level(const FeatherHostOps *ops, FeatherInterp interp,
   // - negative N means relative: -1 is caller, -2 is caller's caller, etc.
   size_t targetLevel;
   if (levelNum == 0) {
-    // 0 means current level
+    // 0 means current level, which the global level is not
+    if (currentLevel == 0) {
+      goto bad_level;
+    }
     targetLevel = currentLevel;
   } else if (levelNum < 0) {
     // Relative to current level: -1 means caller, -2 means caller's caller, etc.
@@ -87,11 +90,15 @@ static FeatherResult info_level(const FeatherHostOps *ops, FeatherInterp interp,
   }
   (void)frameNs; // Currently unused - info level doesn't include namespace
 
-  // Build result list: {cmd arg1 arg2 ...}
-  // Use display name for the command (strips :: for global namespace)
+  // Build result list: {cmd arg1 arg2 ...}, with the command as the word
+  // it was invoked by if the frame has one, and otherwise its display name
+  // (strips :: for global namespace)
   FeatherObj result = ops->list.create(interp);
-  FeatherObj displayCmd = feather_get_display_name(ops, interp, cmd);
-  result = ops->list.push(interp, result, displayCmd);
+  FeatherObj word = ops->frame.get_word(interp, targetLevel);
+  if (word == 0) {
+    word = feather_get_display_name(ops, interp, cmd);
+  }
+  result = ops->list.push(interp, result, word);
 
   // Append all arguments
   size_t argCount = ops->list.length(interp, frameArgs);
@@ -465,7 +472,8 @@ static FeatherResult info_args(const FeatherHostOps *ops, FeatherInterp interp,
  *   cmd: the command being executed (as a list)
  *   proc: the procedure name (only if type is proc)
  *   level: the stack level
- *   file: the script file path (only if type is source)
+ *   file: the file the command is in (only if it came from a file)
+ *   line: the line of the command in its file, or in the evaluated script
  */
 static FeatherResult info_frame(const FeatherHostOps *ops, FeatherInterp interp,
                             FeatherObj args) {
@@ -524,9 +532,11 @@ static FeatherResult info_frame(const FeatherHostOps *ops, FeatherInterp interp,
   FeatherCommandType cmdType = feather_lookup_command(ops, interp, cmd, NULL, NULL, NULL);
   int isProc = (cmdType == TCL_CMD_PROC);
 
-  // Check if we're in a source context
-  FeatherObj scriptPath = ops->interp.get_script(interp);
-  int hasScriptPath = (ops->string.byte_length(interp, scriptPath) > 0);
+  // The command that created a frame runs in the frame below it, so that
+  // is where its file and line are. The global frame reports its own.
+  size_t siteLevel = targetLevel > 0 ? targetLevel - 1 : 0;
+  FeatherObj scriptPath = ops->frame.get_file(interp, siteLevel);
+  int hasScriptPath = (scriptPath != 0);
 
   // Determine type string
   const char *typeStr;
@@ -569,7 +579,7 @@ static FeatherResult info_frame(const FeatherHostOps *ops, FeatherInterp interp,
   result = ops->list.push(interp, result, ops->string.intern(interp, "level", 5));
   result = ops->list.push(interp, result, ops->integer.create(interp, (int64_t)targetLevel));
 
-  // file (only if type is source)
+  // file (only if the command came from a file)
   if (hasScriptPath) {
     result = ops->list.push(interp, result, ops->string.intern(interp, "file", 4));
     result = ops->list.push(interp, result, scriptPath);
@@ -580,7 +590,7 @@ static FeatherResult info_frame(const FeatherHostOps *ops, FeatherInterp interp,
   result = ops->list.push(interp, result, frameNs);
 
   // line (if available)
-  size_t lineNum = ops->frame.get_line(interp, targetLevel);
+  size_t lineNum = ops->frame.get_line(interp, siteLevel);
   if (lineNum > 0) {
     result = ops->list.push(interp, result, ops->string.intern(interp, "line", 4));
     result = ops->list.push(interp, result, ops->integer.create(interp, (int64_t)lineNum));
@@ -855,6 +865,102 @@ static FeatherResult info_vars(const FeatherHostOps *ops, FeatherInterp interp,
   return TCL_OK;
 }
 
+/**
+ * info coroutine
+ *
+ * Returns the fully qualified name of the running coroutine, or an empty
+ * string outside a coroutine. The host records it in ::tcl::coroutine.
+ */
+static FeatherResult info_coroutine(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj args) {
+  size_t argc = ops->list.length(interp, args);
+  if (argc != 0) {
+    ops->interp.set_result(
+        interp,
+        ops->string.intern(interp, "wrong # args: should be \"info coroutine\"", 40));
+    return TCL_ERROR;
+  }
+
+  FeatherObj ns = ops->string.intern(interp, "::tcl::coroutine", 16);
+  FeatherObj name = ops->ns.get_var(interp, ns, ops->string.intern(interp, "current", 7));
+  if (name == 0) {
+    name = ops->string.intern(interp, "", 0);
+  }
+  ops->interp.set_result(interp, name);
+  return TCL_OK;
+}
+
+/**
+ * info cmdcount
+ *
+ * Returns the number of commands scripts have run in the interpreter.
+ */
+static FeatherResult info_cmdcount(const FeatherHostOps *ops, FeatherInterp interp,
+                               FeatherObj args) {
+  if (ops->list.length(interp, args) != 0) {
+    ops->interp.set_result(
+        interp,
+        ops->string.intern(interp, "wrong # args: should be \"info cmdcount\"", 39));
+    return TCL_ERROR;
+  }
+  FeatherInterpState *state = ops->interp.state(interp);
+  ops->interp.set_result(interp, ops->integer.create(interp, (int64_t)state->cmdcount));
+  return TCL_OK;
+}
+
+/**
+ * info hostname and info nameofexecutable
+ *
+ * Return the name of the machine and the path of the program running the
+ * interpreter, or an empty string if they are unknown. The host records
+ * them in ::tcl::info.
+ */
+static FeatherResult info_host(const FeatherHostOps *ops, FeatherInterp interp,
+                           FeatherObj args, const char *name) {
+  if (ops->list.length(interp, args) != 0) {
+    FeatherObj msg = ops->string.intern(interp, "wrong # args: should be \"info ", 30);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, name, feather_strlen(name)));
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  FeatherObj ns = ops->string.intern(interp, "::tcl::info", 11);
+  FeatherObj value = ops->ns.get_var(interp, ns, ops->string.intern(interp, name, feather_strlen(name)));
+  if (value == 0) {
+    value = ops->string.intern(interp, "", 0);
+  }
+  ops->interp.set_result(interp, value);
+  return TCL_OK;
+}
+
+/**
+ * info tclversion and info patchlevel
+ *
+ * Return the value of the global variable tcl_version or tcl_patchLevel,
+ * which the host sets, as TCL does.
+ */
+static FeatherResult info_version(const FeatherHostOps *ops, FeatherInterp interp,
+                              FeatherObj args, const char *sub, const char *var) {
+  if (ops->list.length(interp, args) != 0) {
+    FeatherObj msg = ops->string.intern(interp, "wrong # args: should be \"info ", 30);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, sub, feather_strlen(sub)));
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  FeatherObj name = ops->string.intern(interp, var, feather_strlen(var));
+  FeatherObj value = ops->ns.get_var(interp, ops->string.intern(interp, "::", 2), name);
+  if (value == 0) {
+    FeatherObj msg = ops->string.intern(interp, "can't read \"", 12);
+    msg = ops->string.concat(interp, msg, name);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\": no such variable", 19));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  ops->interp.set_result(interp, value);
+  return TCL_OK;
+}
+
 /**
  * info script
  *
@@ -978,6 +1084,22 @@ static FeatherResult info_methods(const FeatherHostOps *ops, FeatherInterp inter
   return TCL_OK;
 }
 
+// Subcommand names in the order of InfoSubcommand, for string.get_index.
+static const char *const info_subcommands[] = {
+  "args", "body", "cmdcount", "commands", "coroutine", "default", "exists",
+  "frame", "globals", "hostname", "level", "locals", "methods",
+  "nameofexecutable", "patchlevel", "procs", "script", "tclversion", "type",
+  "vars", NULL
+};
+
+typedef enum {
+  INFO_ARGS, INFO_BODY, INFO_CMDCOUNT, INFO_COMMANDS, INFO_COROUTINE,
+  INFO_DEFAULT, INFO_EXISTS, INFO_FRAME, INFO_GLOBALS, INFO_HOSTNAME,
+  INFO_LEVEL, INFO_LOCALS, INFO_METHODS, INFO_NAMEOFEXECUTABLE,
+  INFO_PATCHLEVEL, INFO_PROCS, INFO_SCRIPT, INFO_TCLVERSION, INFO_TYPE,
+  INFO_VARS
+} InfoSubcommand;
+
 FeatherResult feather_builtin_info(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj cmd, FeatherObj args) {
   size_t argc = ops->list.length(interp, args);
@@ -991,48 +1113,31 @@ FeatherResult feather_builtin_info(const FeatherHostOps *ops, FeatherInterp inte
 
   // Get subcommand
   FeatherObj subcmd = ops->list.shift(interp, args);
-
-  if (feather_obj_eq_literal(ops, interp, subcmd, "exists")) {
-    return info_exists(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "level")) {
-    return info_level(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "commands")) {
-    return info_commands(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "procs")) {
-    return info_procs(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "body")) {
-    return info_body(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "args")) {
-    return info_args(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "frame")) {
-    return info_frame(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "default")) {
-    return info_default(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "locals")) {
-    return info_locals(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "globals")) {
-    return info_globals(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "vars")) {
-    return info_vars(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "script")) {
-    return info_script(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "type")) {
-    return info_type(ops, interp, args);
-  }
-  if (feather_obj_eq_literal(ops, interp, subcmd, "methods")) {
-    return info_methods(ops, interp, args);
+  int index;
+
+  if (ops->string.get_index(interp, subcmd, info_subcommands, NULL, 0, &index) == TCL_OK) {
+    switch ((InfoSubcommand)index) {
+    case INFO_ARGS: return info_args(ops, interp, args);
+    case INFO_BODY: return info_body(ops, interp, args);
+    case INFO_CMDCOUNT: return info_cmdcount(ops, interp, args);
+    case INFO_COMMANDS: return info_commands(ops, interp, args);
+    case INFO_COROUTINE: return info_coroutine(ops, interp, args);
+    case INFO_DEFAULT: return info_default(ops, interp, args);
+    case INFO_EXISTS: return info_exists(ops, interp, args);
+    case INFO_FRAME: return info_frame(ops, interp, args);
+    case INFO_GLOBALS: return info_globals(ops, interp, args);
+    case INFO_HOSTNAME: return info_host(ops, interp, args, "hostname");
+    case INFO_LEVEL: return info_level(ops, interp, args);
+    case INFO_LOCALS: return info_locals(ops, interp, args);
+    case INFO_METHODS: return info_methods(ops, interp, args);
+    case INFO_NAMEOFEXECUTABLE: return info_host(ops, interp, args, "nameofexecutable");
+    case INFO_PATCHLEVEL: return info_version(ops, interp, args, "patchlevel", "tcl_patchLevel");
+    case INFO_PROCS: return info_procs(ops, interp, args);
+    case INFO_SCRIPT: return info_script(ops, interp, args);
+    case INFO_TCLVERSION: return info_version(ops, interp, args, "tclversion", "tcl_version");
+    case INFO_TYPE: return info_type(ops, interp, args);
+    case INFO_VARS: return info_vars(ops, interp, args);
+    }
   }
 
   // Unknown subcommand
@@ -1042,7 +1147,7 @@ FeatherResult feather_builtin_info(const FeatherHostOps *ops, FeatherInterp inte
   msg = ops->string.concat(interp, msg, subcmd);
   msg = ops->string.concat(
       interp, msg,
-      ops->string.intern(interp, "\": must be args, body, commands, default, exists, frame, globals, level, locals, methods, procs, script, type, or vars", 118));
+      ops->string.intern(interp, "\": must be args, body, cmdcount, commands, coroutine, default, exists, frame, globals, hostname, level, locals, methods, nameofexecutable, patchlevel, procs, script, tclversion, type, or vars", 191));
   ops->interp.set_result(interp, msg);
   return TCL_ERROR;
 }
@@ -1084,6 +1189,17 @@ void feather_register_info_usage(const FeatherHostOps *ops, FeatherInterp interp
     "command procedure.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // info cmdcount
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_cmd(ops, interp, "cmdcount", subspec);
+  e = feather_usage_help(ops, interp, e, "Count the commands run");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the number of commands scripts have run in the interpreter.\n\n"
+    "Note: Feather counts the commands of scripts, not the commands builtins "
+    "run internally or the commands the host runs directly, so the count "
+    "differs from TCL's for the same script.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // info commands ?pattern?
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "?pattern?");
@@ -1098,6 +1214,15 @@ void feather_register_info_usage(const FeatherHostOps *ops, FeatherInterp interp
     "documentation.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // info coroutine
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_cmd(ops, interp, "coroutine", subspec);
+  e = feather_usage_help(ops, interp, e, "Get name of the running coroutine");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the fully qualified name of the currently executing coroutine, or "
+    "an empty string if no coroutine is running.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // info default procname arg varname
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "<procname>");
@@ -1161,6 +1286,15 @@ void feather_register_info_usage(const FeatherHostOps *ops, FeatherInterp interp
     "determined using the same rules as for string match.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // info hostname
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_cmd(ops, interp, "hostname", subspec);
+  e = feather_usage_help(ops, interp, e, "Get the name of the machine");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the name of the computer on which this invocation is being "
+    "executed, or the empty string if it cannot be determined.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // info level ?level?
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "?level?");
@@ -1197,6 +1331,24 @@ void feather_register_info_usage(const FeatherHostOps *ops, FeatherInterp interp
     "object. Returns an empty list for non-foreign objects.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // info nameofexecutable
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_cmd(ops, interp, "nameofexecutable", subspec);
+  e = feather_usage_help(ops, interp, e, "Get the path of the program");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the absolute pathname of the program for the current application, "
+    "or the empty string if it cannot be determined.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
+  // info patchlevel
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_cmd(ops, interp, "patchlevel", subspec);
+  e = feather_usage_help(ops, interp, e, "Get the exact TCL version");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the value of the global variable tcl_patchLevel, the exact version "
+    "of TCL feather implements.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // info procs ?pattern?
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "?pattern?");
@@ -1222,6 +1374,15 @@ void feather_register_info_usage(const FeatherHostOps *ops, FeatherInterp interp
     "info script filename.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // info tclversion
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_cmd(ops, interp, "tclversion", subspec);
+  e = feather_usage_help(ops, interp, e, "Get the TCL version");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the value of the global variable tcl_version, the major and minor "
+    "version of TCL feather implements.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // info type value (Feather extension)
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "<value>");
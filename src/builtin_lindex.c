@@ -37,8 +37,9 @@ FeatherResult feather_builtin_lindex(const FeatherHostOps *ops, FeatherInterp in
       ops->interp.set_result(interp, value);
       return TCL_OK;
     } else if (indexListLen == 1) {
-      // Single element - could be "end" or a number, use it directly
-      indices[0] = indexArg;
+      // Single element - the index itself, without surrounding whitespace
+      // or braces
+      indices[0] = ops->list.at(interp, indexList, 0);
       numIndices = 1;
     } else {
       // Multiple elements in list - treat each as an index
@@ -3,14 +3,44 @@
 #include "charclass.h"
 #include "index_parse.h"
 
-// Match mode
+// Option names in the order of LsearchOption, for string.get_index.
+static const char *const lsearch_options[] = {
+  "-all", "-ascii", "-bisect", "-decreasing", "-dictionary", "-exact", "-glob",
+  "-increasing", "-index", "-inline", "-integer", "-nocase", "-not", "-real",
+  "-regexp", "-sorted", "-start", "-stride", "-subindices", NULL
+};
+
+typedef enum {
+  LSEARCH_ALL,
+  LSEARCH_ASCII,
+  LSEARCH_BISECT,
+  LSEARCH_DECREASING,
+  LSEARCH_DICTIONARY,
+  LSEARCH_EXACT,
+  LSEARCH_GLOB,
+  LSEARCH_INCREASING,
+  LSEARCH_INDEX,
+  LSEARCH_INLINE,
+  LSEARCH_INTEGER,
+  LSEARCH_NOCASE,
+  LSEARCH_NOT,
+  LSEARCH_REAL,
+  LSEARCH_REGEXP,
+  LSEARCH_SORTED,
+  LSEARCH_START,
+  LSEARCH_STRIDE,
+  LSEARCH_SUBINDICES
+} LsearchOption;
+
+// Match mode; the last matching style option given wins
 typedef enum {
   MATCH_EXACT,
   MATCH_GLOB,
-  MATCH_REGEXP
+  MATCH_REGEXP,
+  MATCH_SORTED
 } MatchMode;
 
-// Compare mode for sorted searches
+// Compare mode for exact and sorted searches
 typedef enum {
   COMPARE_ASCII,
   COMPARE_INTEGER,
@@ -18,69 +48,24 @@ typedef enum {
   COMPARE_DICTIONARY
 } CompareMode;
 
-static int lsearch_compare_nocase(const FeatherHostOps *ops, FeatherInterp interp,
-                          FeatherObj a, FeatherObj b) {
-  size_t lenA = ops->string.byte_length(interp, a);
-  size_t lenB = ops->string.byte_length(interp, b);
-
-  if (lenA != lenB) return 0;
-  for (size_t i = 0; i < lenA; i++) {
-    int ca = feather_char_tolower((unsigned char)ops->string.byte_at(interp, a, i));
-    int cb = feather_char_tolower((unsigned char)ops->string.byte_at(interp, b, i));
-    if (ca != cb) return 0;
-  }
-  return 1;
-}
-
-// Glob match with nocase support
-static int glob_match_nocase(const char *pattern, size_t plen,
-                             const char *string, size_t slen) {
-  // Simple case-insensitive glob matching
-  // For a complete implementation, we'd need to lowercase both
-  // For now, just use the existing feather_glob_match for case-sensitive
-  // This is a simplified version
-  size_t p = 0, s = 0;
-  size_t starP = (size_t)-1, starS = 0;
-
-  while (s < slen) {
-    if (p < plen && (pattern[p] == '?' ||
-                     feather_char_tolower((unsigned char)pattern[p]) ==
-                     feather_char_tolower((unsigned char)string[s]))) {
-      p++;
-      s++;
-    } else if (p < plen && pattern[p] == '*') {
-      starP = p;
-      starS = s;
-      p++;
-    } else if (starP != (size_t)-1) {
-      p = starP + 1;
-      starS++;
-      s = starS;
-    } else {
-      return 0;
-    }
-  }
-
-  while (p < plen && pattern[p] == '*') p++;
-  return p == plen;
-}
-
-// Case-insensitive comparison that returns -1, 0, or 1 (for sorted comparison)
-static int lsearch_compare_nocase_cmp(const FeatherHostOps *ops, FeatherInterp interp,
-                                       FeatherObj a, FeatherObj b) {
-  size_t lenA = ops->string.byte_length(interp, a);
-  size_t lenB = ops->string.byte_length(interp, b);
-
-  size_t minLen = lenA < lenB ? lenA : lenB;
-  for (size_t i = 0; i < minLen; i++) {
-    int ca = feather_char_tolower((unsigned char)ops->string.byte_at(interp, a, i));
-    int cb = feather_char_tolower((unsigned char)ops->string.byte_at(interp, b, i));
-    if (ca != cb) return ca - cb;
-  }
-  if (lenA < lenB) return -1;
-  if (lenA > lenB) return 1;
-  return 0;
-}
+// Search context
+typedef struct {
+  const FeatherHostOps *ops;
+  FeatherInterp interp;
+  MatchMode mode;
+  CompareMode compareMode;
+  int nocase;
+  int negate;
+  int decreasing;
+  FeatherObj pattern;       // with -nocase and -ascii, already lowercased
+  int64_t patternInt;       // pattern as an integer with -integer
+  double patternReal;       // pattern as a real with -real
+  FeatherObj indexObjs[16]; // -index, resolved against each element
+  size_t numIndices;
+  size_t stride;
+  size_t groupOffset;       // with -stride and -index, the element within each group
+  int64_t path[16];         // indices resolved for the last element selected
+} LsearchContext;
 
 // Dictionary comparison for sorted searches
 static int lsearch_compare_dictionary(const FeatherHostOps *ops, FeatherInterp interp,
@@ -143,81 +128,207 @@ static int lsearch_compare_dictionary(const FeatherHostOps *ops, FeatherInterp i
   return caseDiff;
 }
 
-// Compare two elements for sorted search - returns -1, 0, or 1
-static int sorted_compare(const FeatherHostOps *ops, FeatherInterp interp,
-                          FeatherObj a, FeatherObj b,
-                          CompareMode mode, int nocase, int decreasing) {
-  int result = 0;
+// Select the value of the element at i that is matched against the pattern:
+// the element itself, or with -index the element it selects, or an error if
+// it has no such element.
+static FeatherResult select_value(LsearchContext *ctx, FeatherObj list, size_t i,
+                                  FeatherObj *out) {
+  const FeatherHostOps *ops = ctx->ops;
+  FeatherInterp interp = ctx->interp;
+  size_t k = 0;
+  FeatherObj value;
+  if (ctx->stride > 1 && ctx->numIndices > 0) {
+    // The leading index selects within the group
+    value = ops->list.at(interp, list, i + ctx->groupOffset);
+    ctx->path[0] = (int64_t)ctx->groupOffset;
+    k = 1;
+  } else {
+    value = ops->list.at(interp, list, i);
+  }
+  for (; k < ctx->numIndices; k++) {
+    FeatherObj sublist = ops->list.from(interp, value);
+    size_t sublistLen = ops->list.length(interp, sublist);
+    int64_t idx;
+    if (feather_parse_index(ops, interp, ctx->indexObjs[k], sublistLen, &idx) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (idx < 0 || (size_t)idx >= sublistLen) {
+      FeatherObj msg = ops->string.intern(interp, "element ", 8);
+      msg = ops->string.concat(interp, msg, ops->integer.create(interp, idx));
+      msg = ops->string.concat(interp, msg,
+        ops->string.intern(interp, " missing from sublist \"", 23));
+      msg = ops->string.concat(interp, msg, value);
+      msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
+    }
+    ctx->path[k] = idx;
+    value = ops->list.at(interp, sublist, (size_t)idx);
+  }
+  *out = value;
+  return TCL_OK;
+}
 
-  switch (mode) {
+// Compare the pattern with value in the compare mode, storing a negative
+// number, zero or a positive number in *cmp as the pattern sorts before,
+// with or after value.
+static FeatherResult compare_pattern(LsearchContext *ctx, FeatherObj value, int *cmp) {
+  const FeatherHostOps *ops = ctx->ops;
+  FeatherInterp interp = ctx->interp;
+  switch (ctx->compareMode) {
     case COMPARE_ASCII:
-      if (nocase) {
-        result = lsearch_compare_nocase_cmp(ops, interp, a, b);
-      } else {
-        result = ops->string.compare(interp, a, b);
+      if (ctx->nocase) {
+        value = ops->rune.to_lower(interp, value);
       }
+      *cmp = ops->string.compare(interp, ctx->pattern, value);
       break;
 
     case COMPARE_INTEGER: {
-      int64_t va, vb;
-      ops->integer.get(interp, a, &va);
-      ops->integer.get(interp, b, &vb);
-      if (va < vb) result = -1;
-      else if (va > vb) result = 1;
-      else result = 0;
+      int64_t v;
+      if (ops->integer.get(interp, value, &v) != TCL_OK) {
+        feather_error_expected(ops, interp, "integer", value);
+        return TCL_ERROR;
+      }
+      *cmp = ctx->patternInt < v ? -1 : ctx->patternInt > v;
       break;
     }
 
     case COMPARE_REAL: {
-      double va, vb;
-      ops->dbl.get(interp, a, &va);
-      ops->dbl.get(interp, b, &vb);
-      if (va < vb) result = -1;
-      else if (va > vb) result = 1;
-      else result = 0;
+      double v;
+      if (ops->dbl.get(interp, value, &v) != TCL_OK) {
+        feather_error_expected(ops, interp, "floating-point number", value);
+        return TCL_ERROR;
+      }
+      *cmp = ctx->patternReal < v ? -1 : ctx->patternReal > v;
       break;
     }
 
     case COMPARE_DICTIONARY:
-      result = lsearch_compare_dictionary(ops, interp, a, b);
+      *cmp = lsearch_compare_dictionary(ops, interp, ctx->pattern, value);
       break;
   }
-
-  if (decreasing) result = -result;
-  return result;
+  return TCL_OK;
 }
 
-// Check if element matches pattern
-static int element_matches(const FeatherHostOps *ops, FeatherInterp interp,
-                           FeatherObj element, FeatherObj pattern,
-                           MatchMode mode, int nocase, int negate) {
-  int matches = 0;
-
-  switch (mode) {
+// Check whether value matches the pattern, taking -not into account.
+static FeatherResult value_matches(LsearchContext *ctx, FeatherObj value, int *matches) {
+  const FeatherHostOps *ops = ctx->ops;
+  FeatherInterp interp = ctx->interp;
+  int m = 0;
+  switch (ctx->mode) {
     case MATCH_EXACT:
-      if (nocase) {
-        matches = lsearch_compare_nocase(ops, interp, element, pattern);
-      } else {
-        matches = (ops->string.compare(interp, element, pattern) == 0);
+    case MATCH_SORTED: {
+      // Without binary search, -sorted compares like -exact
+      int cmp;
+      if (compare_pattern(ctx, value, &cmp) != TCL_OK) {
+        return TCL_ERROR;
       }
+      m = cmp == 0;
       break;
+    }
 
-    case MATCH_GLOB: {
-      // Use host's glob match which supports nocase via the third parameter
-      matches = ops->string.match(interp, pattern, element, nocase);
+    case MATCH_GLOB:
+      m = ops->string.match(interp, ctx->pattern, value, ctx->nocase);
       break;
-    }
 
-    case MATCH_REGEXP: {
-      int result;
-      if (ops->string.regex_match(interp, pattern, element, nocase, &result, NULL, NULL) == TCL_OK) {
-        matches = result;
+    case MATCH_REGEXP:
+      if (ops->string.regex_match(interp, ctx->pattern, value, ctx->nocase, &m, NULL, NULL) != TCL_OK) {
+        return TCL_ERROR;
       }
       break;
+  }
+  *matches = ctx->negate ? !m : m;
+  return TCL_OK;
+}
+
+// Build what is returned for the element at i, whose matched value is value.
+static FeatherObj match_result(LsearchContext *ctx, FeatherObj list, size_t i,
+                               FeatherObj value, int inlineResult, int subindices) {
+  const FeatherHostOps *ops = ctx->ops;
+  FeatherInterp interp = ctx->interp;
+  if (inlineResult) {
+    if (ctx->stride > 1) {
+      // The whole group
+      FeatherObj group = ops->list.create(interp);
+      for (size_t j = 0; j < ctx->stride; j++) {
+        group = ops->list.push(interp, group, ops->list.at(interp, list, i + j));
+      }
+      return group;
+    }
+    // With -subindices, the matched value rather than the element holding it
+    return subindices ? value : ops->list.at(interp, list, i);
+  }
+  if (subindices) {
+    FeatherObj path = ops->list.create(interp);
+    path = ops->list.push(interp, path, ops->integer.create(interp, (int64_t)i));
+    for (size_t k = 0; k < ctx->numIndices; k++) {
+      path = ops->list.push(interp, path, ops->integer.create(interp, ctx->path[k]));
     }
+    return path;
   }
+  return ops->integer.create(interp, (int64_t)i);
+}
 
-  return negate ? !matches : matches;
+// Build what is returned when nothing matches.
+static FeatherObj no_match_result(LsearchContext *ctx, int inlineResult, int subindices) {
+  const FeatherHostOps *ops = ctx->ops;
+  FeatherInterp interp = ctx->interp;
+  if (inlineResult) {
+    return ops->string.intern(interp, "", 0);
+  }
+  if (subindices) {
+    FeatherObj path = ops->list.create(interp);
+    path = ops->list.push(interp, path, ops->integer.create(interp, -1));
+    for (size_t k = 0; k < ctx->numIndices; k++) {
+      path = ops->list.push(interp, path, ops->integer.create(interp, ctx->path[k]));
+    }
+    return path;
+  }
+  return ops->integer.create(interp, -1);
+}
+
+// Binary search of the groups of list from start on, which are sorted by
+// their matched values. Stores in *index the first group whose value equals
+// the pattern, or -1; with bisect, the last group whose value is equal to
+// or sorts before the pattern.
+static FeatherResult binary_search(LsearchContext *ctx, FeatherObj list, size_t listLen,
+                                   size_t start, int bisect, int64_t *index) {
+  int64_t stride = (int64_t)ctx->stride;
+  int64_t lower = (int64_t)start - stride;
+  int64_t upper = (int64_t)listLen;
+  int64_t found = -1;
+
+  while (lower + stride < upper) {
+    int64_t i = (lower + upper) / 2;
+    i -= i % stride;
+    if (i <= lower) i = lower + stride;
+    FeatherObj value;
+    int cmp;
+    if (select_value(ctx, list, (size_t)i, &value) != TCL_OK ||
+        compare_pattern(ctx, value, &cmp) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (ctx->decreasing) cmp = -cmp;
+    if (cmp == 0) {
+      // Keep looking for the first equal value, or with bisect the last
+      found = i;
+      if (bisect) {
+        lower = i;
+      } else {
+        upper = i;
+      }
+    } else if (cmp > 0) {
+      lower = i;
+    } else {
+      upper = i;
+    }
+  }
+
+  if (bisect && found < 0) {
+    found = lower >= 0 ? lower : -1;
+  }
+  *index = found;
+  return TCL_OK;
 }
 
 FeatherResult feather_builtin_lsearch(const FeatherHostOps *ops, FeatherInterp interp,
@@ -232,63 +343,67 @@ FeatherResult feather_builtin_lsearch(const FeatherHostOps *ops, FeatherInterp i
     return TCL_ERROR;
   }
 
-  // Parse options
-  MatchMode mode = MATCH_GLOB;  // Default is glob
-  CompareMode compareMode = COMPARE_ASCII;  // Default for sorted
-  int nocase = 0;
+  LsearchContext ctx;
+  ctx.ops = ops;
+  ctx.interp = interp;
+  ctx.mode = MATCH_GLOB;
+  ctx.compareMode = COMPARE_ASCII;
+  ctx.nocase = 0;
+  ctx.negate = 0;
+  ctx.decreasing = 0;
+  ctx.numIndices = 0;
+  ctx.groupOffset = 0;
   int all = 0;
   int inlineResult = 0;
-  int negate = 0;
-  int sorted = 0;
   int bisect = 0;
-  int decreasing = 0;
   int subindices = 0;
-  int64_t startIndex = 0;
-  FeatherObj startIndexObj = 0;  // Raw start index for deferred parsing
   int hasIndex = 0;
-  FeatherObj searchIndexObjs[16];  // Raw index objects for deferred parsing
-  size_t numSearchIndices = 0;
-  int64_t strideLength = 1;  // Default is 1 (no stride)
+  FeatherObj startIndexObj = 0; // parsed once the list length is known
+  int64_t strideLength = 1;     // Default is 1 (no stride)
 
-  // Process options
+  // Every argument but the last two is an option
   while (ops->list.length(interp, args) > 2) {
     FeatherObj arg = ops->list.shift(interp, args);
+    int opt;
+    if (ops->string.get_index(interp, arg, lsearch_options, "option", 0, &opt) != TCL_OK) {
+      return TCL_ERROR;
+    }
 
-    if (feather_obj_eq_literal(ops, interp, arg, "-exact")) {
-      mode = MATCH_EXACT;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-glob")) {
-      mode = MATCH_GLOB;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-regexp")) {
-      mode = MATCH_REGEXP;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-nocase")) {
-      nocase = 1;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-all")) {
-      all = 1;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-inline")) {
-      inlineResult = 1;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-not")) {
-      negate = 1;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-start")) {
-      // -start requires an argument
-      if (ops->list.length(interp, args) < 3) {
-        FeatherObj msg = ops->string.intern(interp, "missing starting index", 22);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      startIndexObj = ops->list.shift(interp, args);
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-index")) {
-      // -index requires an argument
-      if (ops->list.length(interp, args) < 3) {
-        FeatherObj msg = ops->string.intern(interp, "\"-index\" option must be followed by list index", 46);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      FeatherObj indexArg = ops->list.shift(interp, args);
-      // Try as list of indices first
-      FeatherObj indexList = ops->list.from(interp, indexArg);
-      size_t indexListLen = ops->list.length(interp, indexList);
-      if (indexListLen > 1) {
-        // It's a list of indices
+    switch ((LsearchOption)opt) {
+      case LSEARCH_ALL:
+        all = 1;
+        break;
+      case LSEARCH_ASCII:
+        ctx.compareMode = COMPARE_ASCII;
+        break;
+      case LSEARCH_BISECT:
+        ctx.mode = MATCH_SORTED;
+        bisect = 1;
+        break;
+      case LSEARCH_DECREASING:
+        ctx.decreasing = 1;
+        break;
+      case LSEARCH_DICTIONARY:
+        ctx.compareMode = COMPARE_DICTIONARY;
+        break;
+      case LSEARCH_EXACT:
+        ctx.mode = MATCH_EXACT;
+        break;
+      case LSEARCH_GLOB:
+        ctx.mode = MATCH_GLOB;
+        break;
+      case LSEARCH_INCREASING:
+        ctx.decreasing = 0;
+        break;
+      case LSEARCH_INDEX: {
+        if (ops->list.length(interp, args) < 3) {
+          FeatherObj msg = ops->string.intern(interp, "\"-index\" option must be followed by list index", 46);
+          ops->interp.set_result(interp, msg);
+          return TCL_ERROR;
+        }
+        FeatherObj indexArg = ops->list.shift(interp, args);
+        FeatherObj indexList = ops->list.from(interp, indexArg);
+        size_t indexListLen = ops->list.length(interp, indexList);
         if (indexListLen > 16) {
           FeatherObj msg = ops->string.intern(interp, "bad index \"", 11);
           msg = ops->string.concat(interp, msg, indexArg);
@@ -298,81 +413,80 @@ FeatherResult feather_builtin_lsearch(const FeatherHostOps *ops, FeatherInterp i
           return TCL_ERROR;
         }
         for (size_t j = 0; j < indexListLen; j++) {
-          searchIndexObjs[j] = ops->list.at(interp, indexList, j);
+          ctx.indexObjs[j] = ops->list.at(interp, indexList, j);
+          if (feather_check_sublist_index(ops, interp, ctx.indexObjs[j]) != TCL_OK) {
+            return TCL_ERROR;
+          }
         }
-        numSearchIndices = indexListLen;
-      } else {
-        // Single index (store as-is for end-N support)
-        searchIndexObjs[0] = indexArg;
-        numSearchIndices = 1;
-      }
-      hasIndex = 1;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-stride")) {
-      // -stride requires an argument
-      if (ops->list.length(interp, args) < 3) {
-        FeatherObj msg = ops->string.intern(interp,
-          "\"-stride\" option must be followed by stride length", 50);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      FeatherObj strideArg = ops->list.shift(interp, args);
-      if (ops->integer.get(interp, strideArg, &strideLength) != TCL_OK) {
-        FeatherObj msg = ops->string.intern(interp, "bad stride length \"", 19);
-        msg = ops->string.concat(interp, msg, strideArg);
-        FeatherObj suffix = ops->string.intern(interp, "\"", 1);
-        msg = ops->string.concat(interp, msg, suffix);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
+        ctx.numIndices = indexListLen;
+        hasIndex = 1;
+        break;
       }
-      if (strideLength < 1) {
-        FeatherObj msg = ops->string.intern(interp,
-          "stride length must be at least 1", 32);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
+      case LSEARCH_INLINE:
+        inlineResult = 1;
+        break;
+      case LSEARCH_INTEGER:
+        ctx.compareMode = COMPARE_INTEGER;
+        break;
+      case LSEARCH_NOCASE:
+        ctx.nocase = 1;
+        break;
+      case LSEARCH_NOT:
+        ctx.negate = 1;
+        break;
+      case LSEARCH_REAL:
+        ctx.compareMode = COMPARE_REAL;
+        break;
+      case LSEARCH_REGEXP:
+        ctx.mode = MATCH_REGEXP;
+        break;
+      case LSEARCH_SORTED:
+        ctx.mode = MATCH_SORTED;
+        break;
+      case LSEARCH_START:
+        if (ops->list.length(interp, args) < 3) {
+          FeatherObj msg = ops->string.intern(interp, "missing starting index", 22);
+          ops->interp.set_result(interp, msg);
+          return TCL_ERROR;
+        }
+        startIndexObj = ops->list.shift(interp, args);
+        break;
+      case LSEARCH_STRIDE: {
+        if (ops->list.length(interp, args) < 3) {
+          FeatherObj msg = ops->string.intern(interp,
+            "\"-stride\" option must be followed by stride length", 50);
+          ops->interp.set_result(interp, msg);
+          return TCL_ERROR;
+        }
+        FeatherObj strideArg = ops->list.shift(interp, args);
+        if (ops->integer.get(interp, strideArg, &strideLength) != TCL_OK) {
+          FeatherObj msg = ops->string.intern(interp, "bad stride length \"", 19);
+          msg = ops->string.concat(interp, msg, strideArg);
+          FeatherObj suffix = ops->string.intern(interp, "\"", 1);
+          msg = ops->string.concat(interp, msg, suffix);
+          ops->interp.set_result(interp, msg);
+          return TCL_ERROR;
+        }
+        if (strideLength < 1) {
+          FeatherObj msg = ops->string.intern(interp,
+            "stride length must be at least 1", 32);
+          ops->interp.set_result(interp, msg);
+          return TCL_ERROR;
+        }
+        break;
       }
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-sorted")) {
-      sorted = 1;
-      mode = MATCH_EXACT;  // -sorted implies -exact
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-bisect")) {
-      bisect = 1;
-      sorted = 1;  // -bisect implies -sorted
-      mode = MATCH_EXACT;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-dictionary")) {
-      compareMode = COMPARE_DICTIONARY;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-ascii")) {
-      compareMode = COMPARE_ASCII;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-integer")) {
-      compareMode = COMPARE_INTEGER;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-real")) {
-      compareMode = COMPARE_REAL;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-increasing")) {
-      decreasing = 0;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-decreasing")) {
-      decreasing = 1;
-    } else if (feather_obj_eq_literal(ops, interp, arg, "-subindices")) {
-      subindices = 1;
-    } else {
-      FeatherObj msg = ops->string.intern(interp, "bad option \"", 12);
-      msg = ops->string.concat(interp, msg, arg);
-      FeatherObj suffix = ops->string.intern(interp, "\"", 1);
-      msg = ops->string.concat(interp, msg, suffix);
-      ops->interp.set_result(interp, msg);
-      return TCL_ERROR;
+      case LSEARCH_SUBINDICES:
+        subindices = 1;
+        break;
     }
   }
 
   FeatherObj listObj = ops->list.shift(interp, args);
-  FeatherObj pattern = ops->list.shift(interp, args);
+  ctx.pattern = ops->list.shift(interp, args);
 
-  // Convert to list
-  FeatherObj list = ops->list.from(interp, listObj);
-  size_t listLen = ops->list.length(interp, list);
-  size_t stride = (size_t)strideLength;
-
-  // Validate stride constraint
-  if (stride > 1 && listLen % stride != 0) {
+  if (bisect && (all || ctx.negate)) {
     FeatherObj msg = ops->string.intern(interp,
-      "list size must be a multiple of the stride length", 49);
+      "-bisect is not compatible with -all or -not", 43);
     ops->interp.set_result(interp, msg);
     return TCL_ERROR;
   }
@@ -385,347 +499,116 @@ FeatherResult feather_builtin_lsearch(const FeatherHostOps *ops, FeatherInterp i
     return TCL_ERROR;
   }
 
-  // Parse -start index now that we have the list length
-  if (startIndexObj) {
-    if (feather_parse_index(ops, interp, startIndexObj, listLen, &startIndex) != TCL_OK) {
+  // Exact and sorted searches compare the pattern in the compare mode
+  if (ctx.mode == MATCH_EXACT || ctx.mode == MATCH_SORTED) {
+    if (ctx.compareMode == COMPARE_INTEGER &&
+        ops->integer.get(interp, ctx.pattern, &ctx.patternInt) != TCL_OK) {
+      feather_error_expected(ops, interp, "integer", ctx.pattern);
       return TCL_ERROR;
     }
-    // Clamp negative to 0
-    if (startIndex < 0) startIndex = 0;
-  }
-
-  // Clamp startIndex to list length (returns -1 or empty for out of range)
-  size_t start = (size_t)startIndex;
-  if (start > listLen) start = listLen;
-
-  // Resolved indices array for subindices output
-  int64_t resolvedIndices[16];
-
-  // Helper macro to extract element to compare (supports nested indices with end-N)
-  // Resolves searchIndexObjs at match time against actual element lengths
-  #define GET_MATCH_ELEM(i, elem, matchElem) do { \
-    if (stride > 1 && hasIndex) { \
-      /* First index selects within stride group */ \
-      FeatherObj _firstIdxObj = searchIndexObjs[0]; \
-      int64_t _firstIdx; \
-      if (feather_parse_index(ops, interp, _firstIdxObj, stride, &_firstIdx) != TCL_OK) { \
-        matchElem = 0; \
-      } else { \
-        resolvedIndices[0] = _firstIdx; \
-        if (_firstIdx < 0 || (size_t)_firstIdx >= stride) { matchElem = 0; } \
-        else { \
-          matchElem = ops->list.at(interp, list, (i) + (size_t)_firstIdx); \
-          /* Traverse remaining indices for nested access */ \
-          for (size_t _k = 1; _k < numSearchIndices && matchElem; _k++) { \
-            FeatherObj _sub = ops->list.from(interp, matchElem); \
-            size_t _subLen = ops->list.length(interp, _sub); \
-            int64_t _idx; \
-            if (feather_parse_index(ops, interp, searchIndexObjs[_k], _subLen, &_idx) != TCL_OK) { \
-              matchElem = 0; break; \
-            } \
-            resolvedIndices[_k] = _idx; \
-            if (_idx >= 0 && (size_t)_idx < _subLen) { \
-              matchElem = ops->list.at(interp, _sub, (size_t)_idx); \
-            } else { matchElem = 0; } \
-          } \
-        } \
-      } \
-    } else if (stride > 1) { \
-      matchElem = elem; \
-    } else if (hasIndex) { \
-      matchElem = elem; \
-      /* Traverse all indices for nested access */ \
-      for (size_t _k = 0; _k < numSearchIndices && matchElem; _k++) { \
-        FeatherObj _sub = ops->list.from(interp, matchElem); \
-        size_t _subLen = ops->list.length(interp, _sub); \
-        int64_t _idx; \
-        if (feather_parse_index(ops, interp, searchIndexObjs[_k], _subLen, &_idx) != TCL_OK) { \
-          matchElem = 0; break; \
-        } \
-        resolvedIndices[_k] = _idx; \
-        if (_idx >= 0 && (size_t)_idx < _subLen) { \
-          matchElem = ops->list.at(interp, _sub, (size_t)_idx); \
-        } else { matchElem = 0; } \
-      } \
-    } else { \
-      matchElem = elem; \
-    } \
-  } while(0)
-
-  // Use binary search for sorted lists
-  if (sorted && !negate) {
-    // Binary search to find an exact match or insertion point
-    size_t numGroups = listLen / stride;
-    size_t lo = 0, hi = numGroups;
-    size_t foundIdx = (size_t)-1;
-
-    while (lo < hi) {
-      size_t mid = lo + (hi - lo) / 2;
-      size_t realIdx = mid * stride;
-      FeatherObj elem = ops->list.at(interp, list, realIdx);
-      FeatherObj matchElem;
-      GET_MATCH_ELEM(realIdx, elem, matchElem);
-
-      int cmp = sorted_compare(ops, interp, matchElem, pattern, compareMode, nocase, decreasing);
-
-      if (cmp < 0) {
-        lo = mid + 1;
-      } else if (cmp > 0) {
-        hi = mid;
-      } else {
-        // Found a match
-        foundIdx = mid;
-        break;
-      }
+    if (ctx.compareMode == COMPARE_REAL &&
+        ops->dbl.get(interp, ctx.pattern, &ctx.patternReal) != TCL_OK) {
+      feather_error_expected(ops, interp, "floating-point number", ctx.pattern);
+      return TCL_ERROR;
     }
+    if (ctx.compareMode == COMPARE_ASCII && ctx.nocase) {
+      ctx.pattern = ops->rune.to_lower(interp, ctx.pattern);
+    }
+  }
 
-    if (bisect) {
-      // Return insertion point: last element <= pattern (increasing) or >= pattern (decreasing)
-      // For bisect, we want the largest index where element <= pattern
-      if (numGroups == 0) {
-        ops->interp.set_result(interp, ops->integer.create(interp, -1));
-        return TCL_OK;
-      }
+  // Convert to list
+  FeatherObj list = ops->list.from(interp, listObj);
+  size_t listLen = ops->list.length(interp, list);
+  ctx.stride = (size_t)strideLength;
 
-      // Reset and do bisect search
-      lo = 0;
-      hi = numGroups;
-      size_t bisectIdx = (size_t)-1;
-
-      while (lo < hi) {
-        size_t mid = lo + (hi - lo) / 2;
-        size_t realIdx = mid * stride;
-        FeatherObj elem = ops->list.at(interp, list, realIdx);
-        FeatherObj matchElem;
-        GET_MATCH_ELEM(realIdx, elem, matchElem);
-
-        int cmp = sorted_compare(ops, interp, matchElem, pattern, compareMode, nocase, decreasing);
-
-        if (cmp <= 0) {
-          bisectIdx = mid;
-          lo = mid + 1;
-        } else {
-          hi = mid;
-        }
-      }
+  // Validate stride constraint
+  if (ctx.stride > 1 && listLen % ctx.stride != 0) {
+    FeatherObj msg = ops->string.intern(interp,
+      "list size must be a multiple of the stride length", 49);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
 
-      if (bisectIdx == (size_t)-1) {
-        ops->interp.set_result(interp, ops->integer.create(interp, -1));
-      } else {
-        ops->interp.set_result(interp, ops->integer.create(interp, (int64_t)(bisectIdx * stride)));
-      }
-      return TCL_OK;
+  // With -stride, the leading index picks the element within each group
+  if (ctx.stride > 1 && ctx.numIndices > 0) {
+    int64_t offset;
+    if (feather_parse_index(ops, interp, ctx.indexObjs[0], ctx.stride, &offset) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (offset < 0 || (size_t)offset >= ctx.stride) {
+      FeatherObj msg = ops->string.intern(interp,
+        "when used with \"-stride\", the leading \"-index\" value must be within the group", 77);
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
     }
+    ctx.groupOffset = (size_t)offset;
+  }
 
-    if (foundIdx == (size_t)-1) {
-      // Not found
-      if (all) {
-        ops->interp.set_result(interp, ops->list.create(interp));
-      } else if (inlineResult) {
-        ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
-      } else if (subindices) {
-        FeatherObj pair = ops->list.create(interp);
-        pair = ops->list.push(interp, pair, ops->integer.create(interp, -1));
-        for (size_t si = 0; si < numSearchIndices; si++) {
-          pair = ops->list.push(interp, pair, ops->integer.create(interp, resolvedIndices[si]));
-        }
-        ops->interp.set_result(interp, pair);
-      } else {
-        ops->interp.set_result(interp, ops->integer.create(interp, -1));
-      }
-      return TCL_OK;
+  // Parse -start index now that we have the list length
+  size_t start = 0;
+  if (startIndexObj) {
+    int64_t startIndex;
+    if (feather_parse_index(ops, interp, startIndexObj, listLen, &startIndex) != TCL_OK) {
+      return TCL_ERROR;
     }
+    if (startIndex > (int64_t)listLen) startIndex = (int64_t)listLen;
+    start = startIndex < 0 ? 0 : (size_t)startIndex;
+  }
 
-    // Found at foundIdx - handle -all to find all duplicates
-    if (all) {
-      // Find range of matching elements
-      size_t first = foundIdx, last = foundIdx;
-      // Search backward for first match
-      while (first > 0) {
-        size_t prevIdx = (first - 1) * stride;
-        FeatherObj elem = ops->list.at(interp, list, prevIdx);
-        FeatherObj matchElem;
-        GET_MATCH_ELEM(prevIdx, elem, matchElem);
-        if (sorted_compare(ops, interp, matchElem, pattern, compareMode, nocase, decreasing) != 0) break;
-        first--;
-      }
-      // Search forward for last match
-      while (last < numGroups - 1) {
-        size_t nextIdx = (last + 1) * stride;
-        FeatherObj elem = ops->list.at(interp, list, nextIdx);
-        FeatherObj matchElem;
-        GET_MATCH_ELEM(nextIdx, elem, matchElem);
-        if (sorted_compare(ops, interp, matchElem, pattern, compareMode, nocase, decreasing) != 0) break;
-        last++;
-      }
+  for (size_t k = 0; k < ctx.numIndices; k++) {
+    ctx.path[k] = 0;
+  }
 
-      FeatherObj result = ops->list.create(interp);
-      for (size_t i = first; i <= last; i++) {
-        size_t realIdx = i * stride;
-        if (inlineResult) {
-          if (stride > 1) {
-            for (size_t j = 0; j < stride; j++) {
-              result = ops->list.push(interp, result, ops->list.at(interp, list, realIdx + j));
-            }
-          } else if (subindices) {
-            // With -subindices -inline, return the matched element value
-            FeatherObj subElem = ops->list.at(interp, list, realIdx);
-            FeatherObj subMatchElem;
-            GET_MATCH_ELEM(realIdx, subElem, subMatchElem);
-            result = ops->list.push(interp, result, subMatchElem);
-          } else {
-            result = ops->list.push(interp, result, ops->list.at(interp, list, realIdx));
-          }
-        } else if (subindices) {
-          FeatherObj pair = ops->list.create(interp);
-          pair = ops->list.push(interp, pair, ops->integer.create(interp, (int64_t)realIdx));
-          for (size_t si = 0; si < numSearchIndices; si++) {
-            pair = ops->list.push(interp, pair, ops->integer.create(interp, resolvedIndices[si]));
-          }
-          result = ops->list.push(interp, result, pair);
-        } else {
-          result = ops->list.push(interp, result, ops->integer.create(interp, (int64_t)realIdx));
-        }
-      }
-      ops->interp.set_result(interp, result);
-    } else {
-      // Return first match
-      size_t realIdx = foundIdx * stride;
-      if (inlineResult) {
-        if (stride > 1) {
-          FeatherObj group = ops->list.create(interp);
-          for (size_t j = 0; j < stride; j++) {
-            group = ops->list.push(interp, group, ops->list.at(interp, list, realIdx + j));
-          }
-          ops->interp.set_result(interp, group);
-        } else if (subindices) {
-          // With -subindices -inline, return the matched element value
-          FeatherObj singleElem = ops->list.at(interp, list, realIdx);
-          FeatherObj singleMatchElem;
-          GET_MATCH_ELEM(realIdx, singleElem, singleMatchElem);
-          ops->interp.set_result(interp, singleMatchElem);
-        } else {
-          ops->interp.set_result(interp, ops->list.at(interp, list, realIdx));
-        }
-      } else if (subindices) {
-        FeatherObj pair = ops->list.create(interp);
-        pair = ops->list.push(interp, pair, ops->integer.create(interp, (int64_t)realIdx));
-        for (size_t si = 0; si < numSearchIndices; si++) {
-          pair = ops->list.push(interp, pair, ops->integer.create(interp, resolvedIndices[si]));
-        }
-        ops->interp.set_result(interp, pair);
-      } else {
-        ops->interp.set_result(interp, ops->integer.create(interp, (int64_t)realIdx));
-      }
+  // Binary search is only worth it when looking for a single match
+  if (ctx.mode == MATCH_SORTED && !all && !ctx.negate) {
+    int64_t index;
+    if (binary_search(&ctx, list, listLen, start, bisect, &index) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (index < 0) {
+      ops->interp.set_result(interp, no_match_result(&ctx, inlineResult, subindices));
+      return TCL_OK;
+    }
+    FeatherObj value;
+    if (select_value(&ctx, list, (size_t)index, &value) != TCL_OK) {
+      return TCL_ERROR;
     }
+    ops->interp.set_result(interp,
+      match_result(&ctx, list, (size_t)index, value, inlineResult, subindices));
     return TCL_OK;
   }
 
-  // Linear search (used for unsorted lists or when -not is specified with -sorted)
-  if (all) {
-    // Return all matching indices/elements
-    FeatherObj result = ops->list.create(interp);
-    for (size_t i = start; i < listLen; i += stride) {
-      FeatherObj elem = ops->list.at(interp, list, i);
-      FeatherObj matchElem;
-      GET_MATCH_ELEM(i, elem, matchElem);
-      if (!matchElem) continue;  // Index out of range
-
-      int matches;
-      if (sorted) {
-        // -sorted with -not uses linear search but sorted comparison
-        matches = (sorted_compare(ops, interp, matchElem, pattern, compareMode, nocase, decreasing) == 0);
-        if (negate) matches = !matches;
-      } else {
-        matches = element_matches(ops, interp, matchElem, pattern, mode, nocase, negate);
-      }
-
-      if (matches) {
-        if (inlineResult) {
-          if (stride > 1) {
-            // Return all elements in the stride group
-            for (size_t j = 0; j < stride; j++) {
-              result = ops->list.push(interp, result, ops->list.at(interp, list, i + j));
-            }
-          } else if (subindices) {
-            // With -subindices -inline, return the matched element value, not the container
-            result = ops->list.push(interp, result, matchElem);
-          } else {
-            result = ops->list.push(interp, result, elem);
-          }
-        } else if (subindices) {
-          FeatherObj pair = ops->list.create(interp);
-          pair = ops->list.push(interp, pair, ops->integer.create(interp, (int64_t)i));
-          for (size_t si = 0; si < numSearchIndices; si++) {
-            pair = ops->list.push(interp, pair, ops->integer.create(interp, resolvedIndices[si]));
-          }
-          result = ops->list.push(interp, result, pair);
-        } else {
-          result = ops->list.push(interp, result, ops->integer.create(interp, (int64_t)i));
-        }
-      }
+  FeatherObj result = ops->list.create(interp);
+  for (size_t i = start; i < listLen; i += ctx.stride) {
+    FeatherObj value;
+    int matches;
+    if (select_value(&ctx, list, i, &value) != TCL_OK ||
+        value_matches(&ctx, value, &matches) != TCL_OK) {
+      return TCL_ERROR;
     }
-    ops->interp.set_result(interp, result);
-  } else {
-    // Return first match
-    for (size_t i = start; i < listLen; i += stride) {
-      FeatherObj elem = ops->list.at(interp, list, i);
-      FeatherObj matchElem;
-      GET_MATCH_ELEM(i, elem, matchElem);
-      if (!matchElem) continue;  // Index out of range
-
-      int matches;
-      if (sorted) {
-        // -sorted with -not uses linear search but sorted comparison
-        matches = (sorted_compare(ops, interp, matchElem, pattern, compareMode, nocase, decreasing) == 0);
-        if (negate) matches = !matches;
-      } else {
-        matches = element_matches(ops, interp, matchElem, pattern, mode, nocase, negate);
-      }
+    if (!matches) continue;
 
-      if (matches) {
-        if (inlineResult) {
-          if (stride > 1) {
-            // Return all elements in the stride group
-            FeatherObj group = ops->list.create(interp);
-            for (size_t j = 0; j < stride; j++) {
-              group = ops->list.push(interp, group, ops->list.at(interp, list, i + j));
-            }
-            ops->interp.set_result(interp, group);
-          } else if (subindices) {
-            // With -subindices -inline, return the matched element value, not the container
-            ops->interp.set_result(interp, matchElem);
-          } else {
-            ops->interp.set_result(interp, elem);
-          }
-        } else if (subindices) {
-          FeatherObj pair = ops->list.create(interp);
-          pair = ops->list.push(interp, pair, ops->integer.create(interp, (int64_t)i));
-          for (size_t si = 0; si < numSearchIndices; si++) {
-            pair = ops->list.push(interp, pair, ops->integer.create(interp, resolvedIndices[si]));
-          }
-          ops->interp.set_result(interp, pair);
-        } else {
-          ops->interp.set_result(interp, ops->integer.create(interp, (int64_t)i));
-        }
-        return TCL_OK;
-      }
+    FeatherObj found = match_result(&ctx, list, i, value, inlineResult, subindices);
+    if (!all) {
+      ops->interp.set_result(interp, found);
+      return TCL_OK;
     }
-    // Not found
-    if (inlineResult) {
-      ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
-    } else if (subindices) {
-      FeatherObj pair = ops->list.create(interp);
-      pair = ops->list.push(interp, pair, ops->integer.create(interp, -1));
-      for (size_t si = 0; si < numSearchIndices; si++) {
-        pair = ops->list.push(interp, pair, ops->integer.create(interp, resolvedIndices[si]));
+    if (inlineResult && ctx.stride > 1) {
+      // The elements of each group are added to the result one by one
+      size_t n = ops->list.length(interp, found);
+      for (size_t j = 0; j < n; j++) {
+        result = ops->list.push(interp, result, ops->list.at(interp, found, j));
       }
-      ops->interp.set_result(interp, pair);
     } else {
-      ops->interp.set_result(interp, ops->integer.create(interp, -1));
+      result = ops->list.push(interp, result, found);
     }
   }
 
+  if (all) {
+    ops->interp.set_result(interp, result);
+  } else {
+    ops->interp.set_result(interp, no_match_result(&ctx, inlineResult, subindices));
+  }
   return TCL_OK;
 }
 
@@ -11,6 +11,27 @@ typedef enum {
   SORT_DICTIONARY
 } SortMode;
 
+// Option names in the order of LsortOption, for string.get_index.
+static const char *const lsort_options[] = {
+  "-ascii", "-command", "-decreasing", "-dictionary", "-increasing", "-index",
+  "-indices", "-integer", "-nocase", "-real", "-stride", "-unique", NULL
+};
+
+typedef enum {
+  OPT_ASCII,
+  OPT_COMMAND,
+  OPT_DECREASING,
+  OPT_DICTIONARY,
+  OPT_INCREASING,
+  OPT_INDEX,
+  OPT_INDICES,
+  OPT_INTEGER,
+  OPT_NOCASE,
+  OPT_REAL,
+  OPT_STRIDE,
+  OPT_UNIQUE
+} LsortOption;
+
 // Comparison context
 typedef struct {
   const FeatherHostOps *ops;
@@ -121,7 +142,8 @@ static int lsort_compare_dictionary(const FeatherHostOps *ops, FeatherInterp int
 }
 
 // Extract element for comparison - handles -index option and -indices pairs
-// Supports nested indices with end-N resolution at each level
+// Supports nested indices with end-N resolution at each level. Returns 0 if
+// an index is out of range or not a valid index.
 static FeatherObj extract_compare_value(SortContext *ctx, FeatherInterp interp, FeatherObj elem) {
   FeatherObj value = elem;
 
@@ -154,6 +176,63 @@ static FeatherObj extract_compare_value(SortContext *ctx, FeatherInterp interp,
   return value;
 }
 
+// Check that elem can be compared before sorting starts, so that the
+// comparison callback never sees a missing sublist element or a value that
+// does not convert under -integer or -real. Sets an error result on failure.
+static FeatherResult check_compare_value(SortContext *ctx, FeatherInterp interp, FeatherObj elem) {
+  const FeatherHostOps *ops = ctx->ops;
+  FeatherObj value = elem;
+
+  if (ctx->sortingPairs) {
+    value = ops->list.at(interp, ops->list.from(interp, elem), 1);
+  }
+
+  if (ctx->hasIndex) {
+    for (size_t k = 0; k < ctx->numSortIndices; k++) {
+      FeatherObj sublist = ops->list.from(interp, value);
+      size_t sublistLen = ops->list.length(interp, sublist);
+      int64_t idx;
+      if (feather_parse_index(ops, interp, ctx->sortIndexObjs[k], sublistLen, &idx) != TCL_OK) {
+        return TCL_ERROR;
+      }
+      if (idx < 0 || (size_t)idx >= sublistLen) {
+        FeatherObj msg = ops->string.intern(interp, "element ", 8);
+        msg = ops->string.concat(interp, msg, ops->integer.create(interp, idx));
+        msg = ops->string.concat(interp, msg,
+          ops->string.intern(interp, " missing from sublist \"", 23));
+        msg = ops->string.concat(interp, msg, value);
+        msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+        ops->interp.set_result(interp, msg);
+        return TCL_ERROR;
+      }
+      value = ops->list.at(interp, sublist, (size_t)idx);
+    }
+  }
+
+  if (ctx->hasCommand) {
+    return TCL_OK;
+  }
+  if (ctx->mode == SORT_INTEGER) {
+    int64_t v;
+    if (ops->integer.get(interp, value, &v) != TCL_OK) {
+      feather_error_expected(ops, interp, "integer", value);
+      return TCL_ERROR;
+    }
+  } else if (ctx->mode == SORT_REAL) {
+    double v;
+    if (ops->dbl.get(interp, value, &v) != TCL_OK) {
+      feather_error_expected(ops, interp, "floating-point number", value);
+      return TCL_ERROR;
+    }
+    if (v != v) {
+      FeatherObj msg = ops->string.intern(interp, "floating point value is Not a Number", 36);
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
+    }
+  }
+  return TCL_OK;
+}
+
 // Compare two elements - signature matches the host sort callback
 static int compare_elements(FeatherInterp interp, FeatherObj a, FeatherObj b, void *ctx_ptr) {
   SortContext *ctx = (SortContext *)ctx_ptr;
@@ -167,9 +246,13 @@ static int compare_elements(FeatherInterp interp, FeatherObj a, FeatherObj b, vo
   FeatherObj valB = extract_compare_value(ctx, interp, b);
 
   if (ctx->hasCommand) {
-    // Build command: {proc a b}
+    // Build command: the command prefix's words followed by a and b
+    FeatherObj prefix = ctx->ops->list.from(interp, ctx->commandProc);
+    size_t prefixLen = ctx->ops->list.length(interp, prefix);
     FeatherObj cmdList = ctx->ops->list.create(interp);
-    cmdList = ctx->ops->list.push(interp, cmdList, ctx->commandProc);
+    for (size_t k = 0; k < prefixLen; k++) {
+      cmdList = ctx->ops->list.push(interp, cmdList, ctx->ops->list.at(interp, prefix, k));
+    }
     cmdList = ctx->ops->list.push(interp, cmdList, valA);
     cmdList = ctx->ops->list.push(interp, cmdList, valB);
 
@@ -265,32 +348,43 @@ FeatherResult feather_builtin_lsort(const FeatherHostOps *ops, FeatherInterp int
   int returnIndices = 0;
   int64_t strideLength = 1; // Default is 1 (no stride)
 
-  // Process options until we find a non-option (the list)
-  FeatherObj listObj = 0;
-  while (ops->list.length(interp, args) > 0) {
+  // Every argument but the last is an option; the last is the list
+  while (ops->list.length(interp, args) > 1) {
     FeatherObj arg = ops->list.shift(interp, args);
+    int opt;
+    if (ops->string.get_index(interp, arg, lsort_options, "option", 0, &opt) != TCL_OK) {
+      return TCL_ERROR;
+    }
 
-    // Check if this is an option (starts with '-')
-    if (ops->string.byte_at(interp, arg, 0) == '-') {
-      if (feather_obj_eq_literal(ops, interp, arg, "-ascii")) {
+    switch ((LsortOption)opt) {
+      case OPT_ASCII:
         ctx.mode = SORT_ASCII;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-integer")) {
+        break;
+      case OPT_INTEGER:
         ctx.mode = SORT_INTEGER;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-real")) {
+        break;
+      case OPT_REAL:
         ctx.mode = SORT_REAL;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-dictionary")) {
+        break;
+      case OPT_DICTIONARY:
         ctx.mode = SORT_DICTIONARY;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-increasing")) {
+        break;
+      case OPT_INCREASING:
         ctx.decreasing = 0;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-decreasing")) {
+        break;
+      case OPT_DECREASING:
         ctx.decreasing = 1;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-nocase")) {
+        break;
+      case OPT_NOCASE:
         ctx.nocase = 1;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-unique")) {
+        break;
+      case OPT_UNIQUE:
         unique = 1;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-indices")) {
+        break;
+      case OPT_INDICES:
         returnIndices = 1;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-index")) {
+        break;
+      case OPT_INDEX: {
         // -index requires an argument (index value) plus the list
         // If only 1 arg remains, that's the list, so -index is missing its argument
         if (ops->list.length(interp, args) <= 1) {
@@ -319,13 +413,22 @@ FeatherResult feather_builtin_lsort(const FeatherHostOps *ops, FeatherInterp int
           }
           ctx.numSortIndices = indexListLen;
         } else {
-          // Single index (store as-is for end-N support)
-          ctx.sortIndexObjs[0] = indexArg;
-          ctx.numSortIndices = 1;
+          // Single index, without surrounding whitespace or braces
+          if (indexListLen == 1) {
+            ctx.sortIndexObjs[0] = ops->list.at(interp, indexList, 0);
+          }
+          ctx.numSortIndices = indexListLen;
         }
-        ctx.hasIndex = 1;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-command")) {
-        // -command requires an argument (command name) plus the list
+        for (size_t j = 0; j < ctx.numSortIndices; j++) {
+          if (feather_check_sublist_index(ops, interp, ctx.sortIndexObjs[j]) != TCL_OK) {
+            return TCL_ERROR;
+          }
+        }
+        ctx.hasIndex = indexListLen > 0;
+        break;
+      }
+      case OPT_COMMAND: {
+        // -command requires an argument (command prefix) plus the list
         if (ops->list.length(interp, args) <= 1) {
           FeatherObj msg = ops->string.intern(interp,
             "\"-command\" option must be followed by comparison command", 56);
@@ -334,7 +437,9 @@ FeatherResult feather_builtin_lsort(const FeatherHostOps *ops, FeatherInterp int
         }
         ctx.commandProc = ops->list.shift(interp, args);
         ctx.hasCommand = 1;
-      } else if (feather_obj_eq_literal(ops, interp, arg, "-stride")) {
+        break;
+      }
+      case OPT_STRIDE: {
         // -stride requires an argument (stride length) plus the list
         if (ops->list.length(interp, args) <= 1) {
           FeatherObj msg = ops->string.intern(interp,
@@ -357,27 +462,24 @@ FeatherResult feather_builtin_lsort(const FeatherHostOps *ops, FeatherInterp int
           ops->interp.set_result(interp, msg);
           return TCL_ERROR;
         }
-      } else {
-        FeatherObj msg = ops->string.intern(interp, "bad option \"", 12);
-        msg = ops->string.concat(interp, msg, arg);
-        FeatherObj suffix = ops->string.intern(interp,
-          "\": must be -ascii, -command, -decreasing, -dictionary, -increasing, -index, -indices, -integer, -nocase, -real, -stride, or -unique", 131);
-        msg = ops->string.concat(interp, msg, suffix);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
+        break;
       }
-    } else {
-      // This must be the list
-      listObj = arg;
-      break;
     }
   }
+  FeatherObj listObj = ops->list.shift(interp, args);
 
-  if (ops->list.is_nil(interp, listObj)) {
-    FeatherObj msg = ops->string.intern(interp,
-      "wrong # args: should be \"lsort ?options? list\"", 46);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
+  // With -stride, the leading index picks the element within each group
+  if (strideLength > 1 && ctx.hasIndex) {
+    int64_t idx;
+    if (feather_parse_index(ops, interp, ctx.sortIndexObjs[0], (size_t)strideLength, &idx) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (idx < 0 || idx >= strideLength) {
+      FeatherObj msg = ops->string.intern(interp,
+        "when used with \"-stride\", the leading \"-index\" value must be within the group", 77);
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
+    }
   }
 
   // Convert to list
@@ -415,6 +517,13 @@ FeatherResult feather_builtin_lsort(const FeatherHostOps *ops, FeatherInterp int
     }
   }
 
+  // Reject bad elements up front; the sort callback cannot report errors
+  for (size_t i = 0; i < numGroups; i++) {
+    if (check_compare_value(&ctx, interp, ops->list.at(interp, workList, i)) != TCL_OK) {
+      return TCL_ERROR;
+    }
+  }
+
   // Handle empty or single-element list (or single group with stride)
   if (numGroups <= 1) {
     if (returnIndices) {
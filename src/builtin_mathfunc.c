@@ -18,6 +18,34 @@ static FeatherResult get_one_double(const FeatherHostOps *ops, FeatherInterp int
   return ops->dbl.get(interp, arg, out);
 }
 
+/* Helper: Check for a single integer argument outside the int64 range */
+static int get_one_bignum(const FeatherHostOps *ops, FeatherInterp interp,
+                          FeatherObj args, FeatherObj *out) {
+  if (ops->list.length(interp, args) != 1) {
+    return 0;
+  }
+  FeatherObj arg = ops->list.at(interp, args, 0);
+  int64_t ival;
+  return ops->integer.get(interp, arg, &ival) != TCL_OK &&
+         ops->bignum.get(interp, arg, out) == TCL_OK;
+}
+
+/* Helper: Get the low 64 bits of a bignum as a signed integer */
+static int64_t bignum_low_word(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj big) {
+  FeatherObj mask, word;
+  int64_t val;
+  ops->bignum.get(interp, ops->string.intern(interp, "0xffffffffffffffff", 18), &mask);
+  ops->bignum.arith(interp, FEATHER_BIG_AND, big, mask, &word);
+  if (ops->integer.get(interp, word, &val) == TCL_OK) {
+    return val;
+  }
+  /* Bit 63 is set: the word is negative as a signed integer */
+  ops->bignum.arith(interp, FEATHER_BIG_NOT, word, 0, &word);
+  ops->bignum.arith(interp, FEATHER_BIG_AND, word, mask, &word);
+  ops->integer.get(interp, word, &val);
+  return ~val;
+}
+
 /* Helper: Get one double argument with TCL-style error messages for math functions */
 static FeatherResult get_one_double_mathfunc(const FeatherHostOps *ops, FeatherInterp interp,
                                               FeatherObj args, const char *funcname, double *out) {
@@ -224,12 +252,20 @@ FeatherResult feather_builtin_mathfunc_abs(const FeatherHostOps *ops, FeatherInt
   }
   FeatherObj arg = ops->list.at(interp, args, 0);
   int64_t ival;
-  if (ops->integer.get(interp, arg, &ival) == TCL_OK) {
+  FeatherObj big;
+  if (ops->integer.get(interp, arg, &ival) == TCL_OK && ival != INT64_MIN) {
     /* Integer argument - return integer result */
     if (ival < 0) ival = -ival;
     ops->interp.set_result(interp, ops->integer.create(interp, ival));
     return TCL_OK;
   }
+  if (ops->bignum.get(interp, arg, &big) == TCL_OK) {
+    if (ops->bignum.compare(interp, big, ops->integer.create(interp, 0)) < 0) {
+      ops->bignum.arith(interp, FEATHER_BIG_NEG, big, 0, &big);
+    }
+    ops->interp.set_result(interp, big);
+    return TCL_OK;
+  }
   /* Fall back to double */
   double dval, result;
   if (ops->dbl.get(interp, arg, &dval) != TCL_OK) {
@@ -278,6 +314,12 @@ FeatherResult feather_builtin_mathfunc_double(const FeatherHostOps *ops, Feather
 
 FeatherResult feather_builtin_mathfunc_int(const FeatherHostOps *ops, FeatherInterp interp,
                                            FeatherObj cmd, FeatherObj args) {
+  FeatherObj big;
+  if (get_one_bignum(ops, interp, args, &big)) {
+    /* Keep the low order bits of integers beyond the machine word */
+    ops->interp.set_result(interp, ops->integer.create(interp, bignum_low_word(ops, interp, big)));
+    return TCL_OK;
+  }
   double val;
   if (get_one_double(ops, interp, args, "tcl::mathfunc::int", &val) != TCL_OK) {
     return TCL_ERROR;
@@ -288,6 +330,12 @@ FeatherResult feather_builtin_mathfunc_int(const FeatherHostOps *ops, FeatherInt
 
 FeatherResult feather_builtin_mathfunc_wide(const FeatherHostOps *ops, FeatherInterp interp,
                                             FeatherObj cmd, FeatherObj args) {
+  FeatherObj big;
+  if (get_one_bignum(ops, interp, args, &big)) {
+    /* Keep the low order bits of integers beyond the machine word */
+    ops->interp.set_result(interp, ops->integer.create(interp, bignum_low_word(ops, interp, big)));
+    return TCL_OK;
+  }
   double val;
   if (get_one_double(ops, interp, args, "tcl::mathfunc::wide", &val) != TCL_OK) {
     return TCL_ERROR;
@@ -374,19 +422,6 @@ FeatherResult feather_builtin_mathfunc_isunordered(const FeatherHostOps *ops, Fe
   return TCL_OK;
 }
 
-/* Helper: case-insensitive string comparison for boolean parsing */
-static int str_equals_ci(const FeatherHostOps *ops, FeatherInterp interp,
-                         FeatherObj obj, const char *target, size_t target_len) {
-  size_t len = ops->string.byte_length(interp, obj);
-  if (len != target_len) return 0;
-  for (size_t i = 0; i < len; i++) {
-    int c = ops->string.byte_at(interp, obj, i);
-    if (c >= 'A' && c <= 'Z') c = c - 'A' + 'a';
-    if (c != target[i]) return 0;
-  }
-  return 1;
-}
-
 FeatherResult feather_builtin_mathfunc_bool(const FeatherHostOps *ops, FeatherInterp interp,
                                             FeatherObj cmd, FeatherObj args) {
   size_t argc = ops->list.length(interp, args);
@@ -417,17 +452,10 @@ FeatherResult feather_builtin_mathfunc_bool(const FeatherHostOps *ops, FeatherIn
     return TCL_OK;
   }
 
-  /* Try boolean string literals (case-insensitive) */
-  if (str_equals_ci(ops, interp, arg, "true", 4) ||
-      str_equals_ci(ops, interp, arg, "yes", 3) ||
-      str_equals_ci(ops, interp, arg, "on", 2)) {
-    ops->interp.set_result(interp, ops->integer.create(interp, 1));
-    return TCL_OK;
-  }
-  if (str_equals_ci(ops, interp, arg, "false", 5) ||
-      str_equals_ci(ops, interp, arg, "no", 2) ||
-      str_equals_ci(ops, interp, arg, "off", 3)) {
-    ops->interp.set_result(interp, ops->integer.create(interp, 0));
+  /* Try boolean words, such as yes or off */
+  int b = feather_parse_boolean(ops, interp, arg);
+  if (b >= 0) {
+    ops->interp.set_result(interp, ops->integer.create(interp, b));
     return TCL_OK;
   }
 
@@ -438,121 +466,113 @@ FeatherResult feather_builtin_mathfunc_bool(const FeatherHostOps *ops, FeatherIn
 
 FeatherResult feather_builtin_mathfunc_entier(const FeatherHostOps *ops, FeatherInterp interp,
                                               FeatherObj cmd, FeatherObj args) {
+  FeatherObj big;
+  if (get_one_bignum(ops, interp, args, &big)) {
+    /* entier keeps integers of any size */
+    ops->interp.set_result(interp, big);
+    return TCL_OK;
+  }
   double val;
   if (get_one_double(ops, interp, args, "tcl::mathfunc::entier", &val) != TCL_OK) {
     return TCL_ERROR;
   }
-  /* entier truncates toward zero, same as int for our 64-bit implementation */
-  ops->interp.set_result(interp, ops->integer.create(interp, (int64_t)val));
-  return TCL_OK;
-}
-
-FeatherResult feather_builtin_mathfunc_max(const FeatherHostOps *ops, FeatherInterp interp,
-                                           FeatherObj cmd, FeatherObj args) {
-  size_t argc = ops->list.length(interp, args);
-  if (argc < 1) {
-    FeatherObj msg = ops->string.intern(interp, "not enough arguments for math function \"max\"", 44);
+  FeatherDoubleClass cls = ops->dbl.classify(val);
+  if (cls == FEATHER_DBL_NAN) {
+    FeatherObj msg = ops->string.intern(interp, "floating point value is Not a Number", 36);
     ops->interp.set_result(interp, msg);
     return TCL_ERROR;
   }
-
-  /* Track whether we need floating-point result */
-  int use_double = 0;
-  double max_dbl = 0;
-  int64_t max_int = 0;
-  int first = 1;
-
-  for (size_t i = 0; i < argc; i++) {
-    FeatherObj arg = ops->list.at(interp, args, i);
-    int64_t ival;
-    double dval;
-
-    /* Try integer first */
-    if (!use_double && ops->integer.get(interp, arg, &ival) == TCL_OK) {
-      if (first || ival > max_int) {
-        max_int = ival;
-      }
-    } else if (ops->dbl.get(interp, arg, &dval) == TCL_OK) {
-      /* Switch to double mode if needed */
-      if (!use_double) {
-        use_double = 1;
-        if (!first) {
-          max_dbl = (double)max_int;
-        }
-      }
-      if (first || dval > max_dbl) {
-        max_dbl = dval;
-      }
-    } else {
-      /* Conversion failed */
-      FeatherObj msg = ops->string.intern(interp, "expected floating-point number", 30);
-      ops->interp.set_result(interp, msg);
-      return TCL_ERROR;
-    }
-    first = 0;
+  if (cls == FEATHER_DBL_INF || cls == FEATHER_DBL_NEG_INF) {
+    FeatherObj msg = ops->string.intern(interp, "integer value too large to represent", 36);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
   }
-
-  if (use_double) {
-    ops->interp.set_result(interp, ops->dbl.create(interp, max_dbl));
-  } else {
-    ops->interp.set_result(interp, ops->integer.create(interp, max_int));
+  if (val < -9223372036854775808.0 || val >= 9223372036854775808.0) {
+    /* Doubles this large are whole numbers, so their digits are exact */
+    ops->bignum.get(interp, ops->dbl.format(interp, val, 'f', 0, 0), &big);
+    ops->interp.set_result(interp, big);
+    return TCL_OK;
   }
+  /* entier truncates toward zero */
+  ops->interp.set_result(interp, ops->integer.create(interp, (int64_t)val));
   return TCL_OK;
 }
 
-FeatherResult feather_builtin_mathfunc_min(const FeatherHostOps *ops, FeatherInterp interp,
-                                           FeatherObj cmd, FeatherObj args) {
+/* Helper: max (sign 1) and min (sign -1). The result is the extreme
+ * argument as a number; integers of any size are compared exactly. */
+static FeatherResult minmax_mathfunc(const FeatherHostOps *ops, FeatherInterp interp,
+                                     FeatherObj args, const char *name, int sign) {
   size_t argc = ops->list.length(interp, args);
   if (argc < 1) {
-    FeatherObj msg = ops->string.intern(interp, "not enough arguments for math function \"min\"", 44);
+    FeatherObj msg = ops->string.intern(interp, "not enough arguments for math function \"", 40);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, name, feather_strlen(name)));
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
     ops->interp.set_result(interp, msg);
     return TCL_ERROR;
   }
 
-  /* Track whether we need floating-point result */
-  int use_double = 0;
-  double min_dbl = 0;
-  int64_t min_int = 0;
-  int first = 1;
+  FeatherObj best = 0;
+  int best_kind = 0; /* 0 machine integer, 1 bignum, 2 double */
+  int64_t best_int = 0;
+  double best_dbl = 0;
 
   for (size_t i = 0; i < argc; i++) {
     FeatherObj arg = ops->list.at(interp, args, i);
-    int64_t ival;
+    int64_t ival = 0;
     double dval;
-
-    /* Try integer first */
-    if (!use_double && ops->integer.get(interp, arg, &ival) == TCL_OK) {
-      if (first || ival < min_int) {
-        min_int = ival;
-      }
+    FeatherObj val;
+    int kind;
+
+    if (ops->integer.get(interp, arg, &ival) == TCL_OK) {
+      kind = 0;
+      val = ops->integer.create(interp, ival);
+    } else if (ops->bignum.get(interp, arg, &val) == TCL_OK) {
+      kind = 1;
     } else if (ops->dbl.get(interp, arg, &dval) == TCL_OK) {
-      /* Switch to double mode if needed */
-      if (!use_double) {
-        use_double = 1;
-        if (!first) {
-          min_dbl = (double)min_int;
-        }
-      }
-      if (first || dval < min_dbl) {
-        min_dbl = dval;
-      }
+      kind = 2;
+      val = ops->dbl.create(interp, dval);
     } else {
       /* Conversion failed */
       FeatherObj msg = ops->string.intern(interp, "expected floating-point number", 30);
       ops->interp.set_result(interp, msg);
       return TCL_ERROR;
     }
-    first = 0;
-  }
+    if (kind != 2) {
+      ops->dbl.get(interp, val, &dval);
+    }
 
-  if (use_double) {
-    ops->interp.set_result(interp, ops->dbl.create(interp, min_dbl));
-  } else {
-    ops->interp.set_result(interp, ops->integer.create(interp, min_int));
+    int cmp;
+    if (best == 0) {
+      cmp = sign;
+    } else if (kind == 0 && best_kind == 0) {
+      cmp = (ival > best_int) - (ival < best_int);
+    } else if (kind != 2 && best_kind != 2) {
+      cmp = ops->bignum.compare(interp, val, best);
+    } else {
+      cmp = (dval > best_dbl) - (dval < best_dbl);
+    }
+    if (sign * cmp > 0) {
+      best = val;
+      best_kind = kind;
+      best_int = ival;
+      best_dbl = dval;
+    }
   }
+
+  ops->interp.set_result(interp, best);
   return TCL_OK;
 }
 
+FeatherResult feather_builtin_mathfunc_max(const FeatherHostOps *ops, FeatherInterp interp,
+                                           FeatherObj cmd, FeatherObj args) {
+  return minmax_mathfunc(ops, interp, args, "max", 1);
+}
+
+FeatherResult feather_builtin_mathfunc_min(const FeatherHostOps *ops, FeatherInterp interp,
+                                           FeatherObj cmd, FeatherObj args) {
+  return minmax_mathfunc(ops, interp, args, "min", -1);
+}
+
 /* Usage registration for all mathfunc commands - structured as subcommands */
 void feather_register_mathfunc_usage(const FeatherHostOps *ops, FeatherInterp interp) {
   FeatherObj spec = feather_usage_spec(ops, interp);
@@ -569,9 +589,9 @@ void feather_register_mathfunc_usage(const FeatherHostOps *ops, FeatherInterp in
     "All functions work with floating-point numbers unless otherwise noted. "
     "Type conversion functions (int, wide, double, entier) and comparison "
     "functions (max, min) preserve integer types when appropriate.\n\n"
-    "Note: Feather does not implement rand(), srand(), or isqrt() as these "
-    "require features outside Feather's scope (random number generation and "
-    "arbitrary precision integers).");
+    "Note: Feather does not implement rand(), srand(), or isqrt(). Random "
+    "number generation is outside Feather's scope, and bignums are limited to "
+    "the expr operators and entier().");
   spec = feather_usage_add(ops, interp, spec, e);
 
   /* --- abs --- */
@@ -686,9 +706,8 @@ void feather_register_mathfunc_usage(const FeatherHostOps *ops, FeatherInterp in
   e = feather_usage_cmd(ops, interp, "entier", subspec);
   e = feather_usage_long_help(ops, interp, e,
     "The argument may be any numeric value. The integer part of arg is "
-    "determined and returned. In standard TCL, the integer range returned by "
-    "this function is unlimited (arbitrary precision), but Feather uses 64-bit "
-    "integers, so this is equivalent to int().");
+    "determined and returned. The integer range returned by this function is "
+    "unlimited, unlike int() and wide().");
   spec = feather_usage_add(ops, interp, spec, e);
 
   /* --- exp --- */
@@ -744,8 +763,8 @@ void feather_register_mathfunc_usage(const FeatherHostOps *ops, FeatherInterp in
   e = feather_usage_long_help(ops, interp, e,
     "The argument may be any numeric value. The integer part of arg is "
     "determined, and then the low order bits of that integer value up to the "
-    "machine word size are returned as an integer value. In Feather, all "
-    "integers are 64-bit, so this is equivalent to wide().");
+    "machine word size are returned as an integer value. In Feather, the "
+    "machine word is 64 bits, so this is equivalent to wide().");
   spec = feather_usage_add(ops, interp, spec, e);
 
   /* --- isfinite --- */
@@ -1,5 +1,6 @@
 #include "feather.h"
 #include "internal.h"
+#include "namespace_util.h"
 
 // Helper: Get the imports variable name for a namespace
 // Stores imports in ::tcl::imports::<ns> as a dict {localName originPath ...}
@@ -37,13 +38,14 @@ static void set_imports_dict(const FeatherHostOps *ops, FeatherInterp interp, Fe
   ops->ns.set_var(interp, tclNs, localName, dict);
 }
 
-// Helper: Record an import (localName -> srcNs::srcName)
-static void record_import(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj dstNs,
-                          FeatherObj localName, FeatherObj srcNs, FeatherObj srcName) {
-  FeatherObj dict = get_imports_dict(ops, interp, dstNs);
-
-  // Build the origin path: srcNs::srcName
-  FeatherObj origin;
+// Helper: Build the origin path of an imported command: srcNs::srcName,
+// or where srcNs imported it from in turn
+static FeatherObj import_origin(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj srcNs, FeatherObj srcName) {
+  FeatherObj origin = ops->dict.get(interp, get_imports_dict(ops, interp, srcNs), srcName);
+  if (origin != 0) {
+    return origin;
+  }
   if (feather_obj_is_global_ns(ops, interp, srcNs)) {
     origin = ops->string.intern(interp, "::", 2);
     origin = ops->string.concat(interp, origin, srcName);
@@ -51,11 +53,27 @@ static void record_import(const FeatherHostOps *ops, FeatherInterp interp, Feath
     origin = ops->string.concat(interp, srcNs, ops->string.intern(interp, "::", 2));
     origin = ops->string.concat(interp, origin, srcName);
   }
+  return origin;
+}
 
+// Helper: Record an import (localName -> origin)
+static void record_import(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj dstNs,
+                          FeatherObj localName, FeatherObj srcNs, FeatherObj srcName) {
+  FeatherObj dict = get_imports_dict(ops, interp, dstNs);
+  FeatherObj origin = import_origin(ops, interp, srcNs, srcName);
   dict = ops->dict.set(interp, dict, localName, origin);
   set_imports_dict(ops, interp, dstNs, dict);
 }
 
+// Helper: Check whether localName in ns was imported from srcNs, so that
+// importing it from there again is not an error
+static int imported_from(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj ns,
+                         FeatherObj localName, FeatherObj srcNs) {
+  FeatherObj origin = ops->dict.get(interp, get_imports_dict(ops, interp, ns), localName);
+  return origin != 0 &&
+         ops->string.equal(interp, origin, import_origin(ops, interp, srcNs, localName));
+}
+
 // Helper: Remove an import record
 static void remove_import(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj ns, FeatherObj localName) {
   FeatherObj dict = get_imports_dict(ops, interp, ns);
@@ -87,6 +105,55 @@ static FeatherObj resolve_ns_path(const FeatherHostOps *ops, FeatherInterp inter
   return ops->string.concat(interp, result, path);
 }
 
+// Resolve a command name the way command lookup does. Unqualified names
+// are looked for in the current namespace, then the namespaces on its path,
+// then the global namespace; qualified ones relative to the current
+// namespace, then the global one. Returns the namespace it was found in,
+// storing the command's simple name in *simple, or 0 if there is none.
+static FeatherObj resolve_command_ns(const FeatherHostOps *ops, FeatherInterp interp,
+                                     FeatherObj name, FeatherObj *simple) {
+  FeatherObj current = ops->ns.current(interp);
+  FeatherObj global = ops->string.intern(interp, "::", 2);
+  FeatherBuiltinCmd fn;
+  *simple = name;
+  if (feather_obj_is_qualified(ops, interp, name)) {
+    FeatherObj candidates[2];
+    size_t n = 0;
+    size_t len = ops->string.byte_length(interp, name);
+    if (len >= 2 && ops->string.byte_at(interp, name, 0) == ':' &&
+        ops->string.byte_at(interp, name, 1) == ':') {
+      candidates[n++] = name;
+    } else {
+      if (!feather_obj_is_global_ns(ops, interp, current)) {
+        candidates[n++] = resolve_ns_path(ops, interp, name);
+      }
+      candidates[n++] = ops->string.concat(interp, global, name);
+    }
+    for (size_t i = 0; i < n; i++) {
+      FeatherObj ns;
+      feather_obj_split_command(ops, interp, candidates[i], &ns, simple);
+      if (ops->list.is_nil(interp, ns)) {
+        ns = global;
+      }
+      if (ops->ns.get_command(interp, ns, *simple, &fn, NULL, NULL) != TCL_CMD_NONE) {
+        return ns;
+      }
+    }
+    return 0;
+  }
+  if (ops->ns.get_command(interp, current, name, &fn, NULL, NULL) != TCL_CMD_NONE) {
+    return current;
+  }
+  FeatherObj found = 0;
+  if (feather_ns_path_lookup(ops, interp, current, name, &found, &fn, NULL, NULL) != TCL_CMD_NONE) {
+    return found;
+  }
+  if (ops->ns.get_command(interp, global, name, &fn, NULL, NULL) != TCL_CMD_NONE) {
+    return global;
+  }
+  return 0;
+}
+
 // namespace current
 static FeatherResult ns_current(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
   if (ops->list.length(interp, args) != 0) {
@@ -275,6 +342,7 @@ static FeatherResult ns_delete(const FeatherHostOps *ops, FeatherInterp interp,
     if (res != TCL_OK) {
       return res;
     }
+    feather_ns_forget_paths(ops, interp, abs_path);
   }
 
   ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
@@ -499,7 +567,8 @@ static FeatherResult ns_import(const FeatherHostOps *ops, FeatherInterp interp,
       // Check if command already exists in current namespace
       FeatherBuiltinCmd unusedFn = NULL;
       FeatherCommandType existingType = ops->ns.get_command(interp, current, cmdName, &unusedFn, NULL, NULL);
-      if (existingType != TCL_CMD_NONE && !force) {
+      if (existingType != TCL_CMD_NONE && !force &&
+          !imported_from(ops, interp, current, cmdName, srcNs)) {
         FeatherObj msg = ops->string.intern(interp, "can't import command \"", 22);
         msg = ops->string.concat(interp, msg, cmdName);
         msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\": already exists", 17));
@@ -538,48 +607,118 @@ static FeatherResult ns_origin(const FeatherHostOps *ops, FeatherInterp interp,
   }
 
   FeatherObj name = ops->list.at(interp, args, 0);
-  FeatherObj current = ops->ns.current(interp);
 
   // First check if the command exists
-  FeatherBuiltinCmd fn;
-  FeatherCommandType cmdType = ops->ns.get_command(interp, current, name, &fn, NULL, NULL);
-  if (cmdType == TCL_CMD_NONE) {
-    // Try global namespace
-    FeatherObj global = ops->string.intern(interp, "::", 2);
-    cmdType = ops->ns.get_command(interp, global, name, &fn, NULL, NULL);
-    if (cmdType == TCL_CMD_NONE) {
-      FeatherObj msg = ops->string.intern(interp, "invalid command name \"", 22);
-      msg = ops->string.concat(interp, msg, name);
-      msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
-      ops->interp.set_result(interp, msg);
+  FeatherObj simple;
+  FeatherObj ns = resolve_command_ns(ops, interp, name, &simple);
+  if (ns == 0) {
+    FeatherObj msg = ops->string.intern(interp, "invalid command name \"", 22);
+    msg = ops->string.concat(interp, msg, name);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  // If it was imported there, return the origin; otherwise its own
+  // qualified name
+  ops->interp.set_result(interp, import_origin(ops, interp, ns, simple));
+  return TCL_OK;
+}
+
+// Resolve the namespace name to its fully qualified name in *abs, leaving
+// an error in the result if there is no such namespace
+static FeatherResult find_ns(const FeatherHostOps *ops, FeatherInterp interp,
+                             FeatherObj name, FeatherObj *abs) {
+  *abs = resolve_ns_path(ops, interp, name);
+  if (ops->ns.exists(interp, *abs)) {
+    return TCL_OK;
+  }
+  FeatherObj msg = ops->string.intern(interp, "namespace \"", 11);
+  msg = ops->string.concat(interp, msg, name);
+  if (*abs == name) {
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\" not found", 11));
+  } else {
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\" not found in \"", 16));
+    msg = ops->string.concat(interp, msg, ops->ns.current(interp));
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+  }
+  ops->interp.set_result(interp, msg);
+  return TCL_ERROR;
+}
+
+// namespace path ?pathList?
+static FeatherResult ns_path(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  size_t argc = ops->list.length(interp, args);
+  if (argc > 1) {
+    FeatherObj msg = ops->string.intern(interp, "wrong # args: should be \"namespace path ?pathList?\"", 51);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  FeatherObj current = ops->ns.current(interp);
+  if (argc == 0) {
+    ops->interp.set_result(interp, feather_ns_path(ops, interp, current));
+    return TCL_OK;
+  }
+
+  FeatherObj list = ops->list.from(interp, ops->list.at(interp, args, 0));
+  if (ops->list.is_nil(interp, list)) {
+    return TCL_ERROR; // list parse error already set
+  }
+  FeatherObj path = ops->list.create(interp);
+  size_t n = ops->list.length(interp, list);
+  for (size_t i = 0; i < n; i++) {
+    FeatherObj abs;
+    if (find_ns(ops, interp, ops->list.at(interp, list, i), &abs) != TCL_OK) {
       return TCL_ERROR;
     }
-    // Command found in global, return its qualified name
-    FeatherObj result = ops->string.intern(interp, "::", 2);
-    result = ops->string.concat(interp, result, name);
-    ops->interp.set_result(interp, result);
-    return TCL_OK;
+    path = ops->list.push(interp, path, abs);
   }
+  feather_ns_set_path(ops, interp, current, path);
+  ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+  return TCL_OK;
+}
 
-  // Command exists in current namespace - check if it's an import
-  FeatherObj dict = get_imports_dict(ops, interp, current);
-  FeatherObj origin = ops->dict.get(interp, dict, name);
+// namespace upvar ns ?otherVar myVar ...?
+static FeatherResult ns_upvar(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  size_t argc = ops->list.length(interp, args);
+  if (argc == 0 || argc % 2 == 0) {
+    FeatherObj msg = ops->string.intern(interp,
+      "wrong # args: should be \"namespace upvar ns ?otherVar myVar ...?\"", 65);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
 
-  if (origin != 0) {
-    // It's an imported command, return the origin
-    ops->interp.set_result(interp, origin);
-  } else {
-    // Not imported, return the qualified name in current namespace
-    FeatherObj result;
-    if (feather_obj_is_global_ns(ops, interp, current)) {
-      result = ops->string.intern(interp, "::", 2);
-      result = ops->string.concat(interp, result, name);
-    } else {
-      result = ops->string.concat(interp, current, ops->string.intern(interp, "::", 2));
-      result = ops->string.concat(interp, result, name);
+  FeatherObj ns;
+  if (find_ns(ops, interp, ops->list.at(interp, args, 0), &ns) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  FeatherObj global = ops->string.intern(interp, "::", 2);
+
+  for (size_t i = 1; i < argc; i += 2) {
+    FeatherObj other = ops->list.at(interp, args, i);
+    FeatherObj mine = ops->list.at(interp, args, i + 1);
+
+    // otherVar is relative to ns unless it is absolute
+    size_t len = ops->string.byte_length(interp, other);
+    FeatherObj full = other;
+    if (len < 2 || ops->string.byte_at(interp, other, 0) != ':' ||
+        ops->string.byte_at(interp, other, 1) != ':') {
+      full = feather_obj_is_global_ns(ops, interp, ns)
+                 ? global
+                 : ops->string.concat(interp, ns, global);
+      full = ops->string.concat(interp, full, other);
     }
-    ops->interp.set_result(interp, result);
+    long last_sep = feather_obj_find_last_colons(ops, interp, full);
+    size_t full_len = ops->string.byte_length(interp, full);
+    FeatherObj target_ns = (last_sep <= 0) ? global
+                                           : ops->string.slice(interp, full, 0, (size_t)last_sep);
+    FeatherObj target_name = ops->string.slice(interp, full, (size_t)last_sep + 2, full_len);
+
+    ops->var.link_ns(interp, mine, target_ns, target_name);
   }
+
+  ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
   return TCL_OK;
 }
 
@@ -790,19 +929,26 @@ static FeatherResult ns_which(const FeatherHostOps *ops, FeatherInterp interp, F
         ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
       }
     } else {
-      // Relative name - check in current namespace
-      if (ops->ns.var_exists(interp, current, name)) {
-        FeatherObj result;
-        if (feather_obj_is_global_ns(ops, interp, current)) {
-          result = ops->string.intern(interp, "::", 2);
-          result = ops->string.concat(interp, result, name);
-        } else {
-          result = ops->string.concat(interp, current, ops->string.intern(interp, "::", 2));
-          result = ops->string.concat(interp, result, name);
+      // Relative name - check in current namespace, then the global one;
+      // unlike commands, variables are not looked for on the path
+      FeatherObj candidates[2];
+      size_t n = 0;
+      FeatherObj global = ops->string.intern(interp, "::", 2);
+      if (!feather_obj_is_global_ns(ops, interp, current)) {
+        candidates[n++] = resolve_ns_path(ops, interp, name);
+      }
+      candidates[n++] = ops->string.concat(interp, global, name);
+      ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+      for (size_t i = 0; i < n; i++) {
+        long last_sep = feather_obj_find_last_colons(ops, interp, candidates[i]);
+        size_t len = ops->string.byte_length(interp, candidates[i]);
+        FeatherObj ns = (last_sep <= 0) ? global
+                                        : ops->string.slice(interp, candidates[i], 0, (size_t)last_sep);
+        FeatherObj varname = ops->string.slice(interp, candidates[i], (size_t)last_sep + 2, len);
+        if (ops->ns.exists(interp, ns) && ops->ns.var_exists(interp, ns, varname)) {
+          ops->interp.set_result(interp, candidates[i]);
+          break;
         }
-        ops->interp.set_result(interp, result);
-      } else {
-        ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
       }
     }
   } else {
@@ -823,37 +969,570 @@ static FeatherResult ns_which(const FeatherHostOps *ops, FeatherInterp interp, F
         ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
       }
     } else {
-      // Relative name - search current namespace first, then global
-      FeatherBuiltinCmd fn;
-      FeatherCommandType cmdType = ops->ns.get_command(interp, current, name, &fn, NULL, NULL);
-      if (cmdType != TCL_CMD_NONE) {
+      // Relative name - search as command lookup does
+      FeatherObj simple;
+      FeatherObj ns = resolve_command_ns(ops, interp, name, &simple);
+      if (ns != 0) {
         FeatherObj result;
-        if (feather_obj_is_global_ns(ops, interp, current)) {
+        if (feather_obj_is_global_ns(ops, interp, ns)) {
           result = ops->string.intern(interp, "::", 2);
-          result = ops->string.concat(interp, result, name);
+          result = ops->string.concat(interp, result, simple);
         } else {
-          result = ops->string.concat(interp, current, ops->string.intern(interp, "::", 2));
-          result = ops->string.concat(interp, result, name);
+          result = ops->string.concat(interp, ns, ops->string.intern(interp, "::", 2));
+          result = ops->string.concat(interp, result, simple);
         }
         ops->interp.set_result(interp, result);
       } else {
-        // Try global namespace
-        FeatherObj global = ops->string.intern(interp, "::", 2);
-        cmdType = ops->ns.get_command(interp, global, name, &fn, NULL, NULL);
-        if (cmdType != TCL_CMD_NONE) {
-          FeatherObj result = ops->string.intern(interp, "::", 2);
-          result = ops->string.concat(interp, result, name);
-          ops->interp.set_result(interp, result);
-        } else {
-          ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+        ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+      }
+    }
+  }
+
+  return TCL_OK;
+}
+
+// Ensemble configuration lives in ::tcl::ensemble::config, a dict mapping
+// each ensemble's fully qualified command name to a dict of its options:
+// -map, -namespace, -prefixes and -subcommands, in that order.
+static FeatherObj ensemble_config_all(const FeatherHostOps *ops, FeatherInterp interp) {
+  FeatherObj ns = ops->string.intern(interp, "::tcl::ensemble", 15);
+  FeatherObj dict = ops->ns.get_var(interp, ns, ops->string.intern(interp, "config", 6));
+  if (dict == 0) {
+    dict = ops->dict.create(interp);
+  }
+  return dict;
+}
+
+static void ensemble_config_store(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj all) {
+  FeatherObj ns = ops->string.intern(interp, "::tcl::ensemble", 15);
+  ops->ns.create(interp, ns);
+  ops->ns.set_var(interp, ns, ops->string.intern(interp, "config", 6), all);
+}
+
+// Qualify a name relative to ns unless it is already absolute.
+static FeatherObj ensemble_qualify(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj ns, FeatherObj name) {
+  size_t len = ops->string.byte_length(interp, name);
+  if (len >= 2 && ops->string.byte_at(interp, name, 0) == ':' &&
+      ops->string.byte_at(interp, name, 1) == ':') {
+    return name;
+  }
+  FeatherObj sep = ops->string.intern(interp, "::", 2);
+  if (feather_obj_is_global_ns(ops, interp, ns)) {
+    return ops->string.concat(interp, sep, name);
+  }
+  return ops->string.concat(interp, ops->string.concat(interp, ns, sep), name);
+}
+
+// ensemble_target qualifies the command word of a -map target: a command
+// in the ensemble namespace, else a global command, else a name in the
+// ensemble namespace.
+static FeatherObj ensemble_target(const FeatherHostOps *ops, FeatherInterp interp,
+                                  FeatherObj ns, FeatherObj word) {
+  FeatherObj local = ensemble_qualify(ops, interp, ns, word);
+  if (local == word ||
+      feather_lookup_command(ops, interp, local, NULL, NULL, NULL) != TCL_CMD_NONE) {
+    return local;
+  }
+  FeatherObj global = ensemble_qualify(ops, interp, ops->string.intern(interp, "::", 2), word);
+  if (feather_lookup_command(ops, interp, global, NULL, NULL, NULL) != TCL_CMD_NONE) {
+    return global;
+  }
+  return local;
+}
+
+// Resolve a command name the way namespace which does: absolute names as
+// given, otherwise the current namespace and then the global namespace.
+// Returns the fully qualified name, or 0 if there is no such command.
+static FeatherObj ensemble_resolve(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj name, FeatherBuiltinCmd *fn) {
+  FeatherObj global = ops->string.intern(interp, "::", 2);
+  FeatherObj candidates[2];
+  size_t n = 0;
+  candidates[n++] = ensemble_qualify(ops, interp, ops->ns.current(interp), name);
+  if (candidates[0] != name) {
+    candidates[n++] = ensemble_qualify(ops, interp, global, name);
+  }
+  for (size_t i = 0; i < n; i++) {
+    FeatherObj ns, simple;
+    feather_obj_split_command(ops, interp, candidates[i], &ns, &simple);
+    if (ops->list.is_nil(interp, ns)) {
+      ns = global;
+    }
+    *fn = NULL;
+    if (ops->ns.get_command(interp, ns, simple, fn, NULL, NULL) != TCL_CMD_NONE) {
+      return candidates[i];
+    }
+  }
+  return 0;
+}
+
+static int ensemble_name_cmp(FeatherInterp interp, FeatherObj a, FeatherObj b, void *ctx) {
+  const FeatherHostOps *ops = (const FeatherHostOps *)ctx;
+  return ops->string.compare(interp, a, b);
+}
+
+// Option names in the order of EnsembleOption, for string.get_index.
+static const char *const ensemble_create_options[] = {
+  "-command", "-map", "-prefixes", "-subcommands", NULL
+};
+static const char *const ensemble_configure_options[] = {
+  "-map", "-namespace", "-prefixes", "-subcommands", NULL
+};
+
+typedef enum {
+  ENS_COMMAND,
+  ENS_MAP,
+  ENS_NAMESPACE,
+  ENS_PREFIXES,
+  ENS_SUBCOMMANDS
+} EnsembleOption;
+
+static const EnsembleOption ensemble_create_ids[] = {
+  ENS_COMMAND, ENS_MAP, ENS_PREFIXES, ENS_SUBCOMMANDS
+};
+static const EnsembleOption ensemble_configure_ids[] = {
+  ENS_MAP, ENS_NAMESPACE, ENS_PREFIXES, ENS_SUBCOMMANDS
+};
+
+// ensemble_set_option validates value for opt and stores it in *config.
+// Map targets are qualified with ensemble_target.
+static FeatherResult ensemble_set_option(const FeatherHostOps *ops, FeatherInterp interp,
+                                         FeatherObj *config, EnsembleOption opt,
+                                         FeatherObj value) {
+  FeatherObj ns = ops->dict.get(interp, *config, ops->string.intern(interp, "-namespace", 10));
+  switch (opt) {
+    case ENS_MAP: {
+      FeatherObj map = ops->dict.from(interp, value);
+      if (ops->list.is_nil(interp, map)) {
+        if (!ops->list.is_nil(interp, ops->list.from(interp, value))) {
+          ops->interp.set_result(interp,
+            ops->string.intern(interp, "missing value to go with key", 28));
+        }
+        return TCL_ERROR;
+      }
+      FeatherObj keys = ops->dict.keys(interp, map);
+      size_t count = ops->list.length(interp, keys);
+      FeatherObj result = ops->dict.create(interp);
+      for (size_t i = 0; i < count; i++) {
+        FeatherObj key = ops->list.at(interp, keys, i);
+        FeatherObj words = ops->list.from(interp, ops->dict.get(interp, map, key));
+        if (ops->list.is_nil(interp, words)) {
+          return TCL_ERROR;
+        }
+        if (ops->list.length(interp, words) == 0) {
+          ops->interp.set_result(interp, ops->string.intern(interp,
+            "ensemble subcommand implementations must be non-empty lists", 59));
+          return TCL_ERROR;
         }
+        FeatherObj first = ensemble_target(ops, interp, ns, ops->list.shift(interp, words));
+        FeatherObj target = ops->list.create(interp);
+        target = ops->list.push(interp, target, first);
+        size_t nwords = ops->list.length(interp, words);
+        for (size_t j = 0; j < nwords; j++) {
+          target = ops->list.push(interp, target, ops->list.at(interp, words, j));
+        }
+        result = ops->dict.set(interp, result, key, target);
+      }
+      *config = ops->dict.set(interp, *config, ops->string.intern(interp, "-map", 4), result);
+      return TCL_OK;
+    }
+    case ENS_NAMESPACE:
+      ops->interp.set_result(interp,
+        ops->string.intern(interp, "option -namespace is read-only", 30));
+      return TCL_ERROR;
+    case ENS_PREFIXES: {
+      // bool() accepts every boolean form, including on/off
+      FeatherObj boolArgs = ops->list.push(interp, ops->list.create(interp), value);
+      if (feather_builtin_mathfunc_bool(ops, interp, ops->string.intern(interp, "bool", 4),
+                                        boolArgs) != TCL_OK) {
+        return TCL_ERROR;
+      }
+      *config = ops->dict.set(interp, *config, ops->string.intern(interp, "-prefixes", 9),
+                              ops->interp.get_result(interp));
+      return TCL_OK;
+    }
+    case ENS_SUBCOMMANDS: {
+      FeatherObj list = ops->list.from(interp, value);
+      if (ops->list.is_nil(interp, list)) {
+        return TCL_ERROR;
+      }
+      *config = ops->dict.set(interp, *config,
+                              ops->string.intern(interp, "-subcommands", 12), list);
+      return TCL_OK;
+    }
+    case ENS_COMMAND:
+      break;
+  }
+  return TCL_OK;
+}
+
+// ensemble_names returns the sorted subcommand names an ensemble accepts:
+// its -subcommands list, else the keys of its -map, else the commands its
+// namespace exports.
+static FeatherObj ensemble_names(const FeatherHostOps *ops, FeatherInterp interp,
+                                 FeatherObj config) {
+  FeatherObj ns = ops->dict.get(interp, config, ops->string.intern(interp, "-namespace", 10));
+  FeatherObj map = ops->dict.get(interp, config, ops->string.intern(interp, "-map", 4));
+  FeatherObj subs = ops->dict.get(interp, config, ops->string.intern(interp, "-subcommands", 12));
+  FeatherObj source;
+  int exportedOnly = 0;
+  if (ops->list.length(interp, subs) > 0) {
+    source = subs;
+  } else if (ops->dict.size(interp, map) > 0) {
+    source = ops->dict.keys(interp, map);
+  } else {
+    source = ops->ns.list_commands(interp, ns);
+    exportedOnly = 1;
+  }
+
+  FeatherObj names = ops->list.create(interp);
+  size_t count = ops->list.length(interp, source);
+  for (size_t i = 0; i < count; i++) {
+    FeatherObj name = ops->list.at(interp, source, i);
+    if (exportedOnly && !ops->ns.is_exported(interp, ns, name)) {
+      continue;
+    }
+    names = ops->list.push(interp, names, name);
+  }
+  ops->list.sort(interp, names, ensemble_name_cmp, (void *)ops);
+  return names;
+}
+
+// ensemble_unknown reports a subcommand that matches none of names.
+static FeatherResult ensemble_unknown(const FeatherHostOps *ops, FeatherInterp interp,
+                                      FeatherObj config, FeatherObj names,
+                                      FeatherObj sub, int prefixes) {
+  FeatherObj builder = ops->string.builder_new(interp, 64);
+  size_t count = ops->list.length(interp, names);
+  if (prefixes && count > 0) {
+    ops->string.builder_append_obj(interp, builder,
+      ops->string.intern(interp, "unknown or ambiguous subcommand \"", 33));
+  } else {
+    ops->string.builder_append_obj(interp, builder,
+      ops->string.intern(interp, "unknown subcommand \"", 20));
+  }
+  ops->string.builder_append_obj(interp, builder, sub);
+  if (count == 0) {
+    ops->string.builder_append_obj(interp, builder,
+      ops->string.intern(interp, "\": namespace ", 13));
+    ops->string.builder_append_obj(interp, builder,
+      ops->dict.get(interp, config, ops->string.intern(interp, "-namespace", 10)));
+    ops->string.builder_append_obj(interp, builder,
+      ops->string.intern(interp, " does not export any commands", 29));
+  } else {
+    ops->string.builder_append_obj(interp, builder,
+      ops->string.intern(interp, "\": must be ", 11));
+    for (size_t i = 0; i < count; i++) {
+      if (i > 0) {
+        ops->string.builder_append_obj(interp, builder, i == count - 1
+          ? ops->string.intern(interp, ", or ", 5)
+          : ops->string.intern(interp, ", ", 2));
+      }
+      ops->string.builder_append_obj(interp, builder, ops->list.at(interp, names, i));
+    }
+  }
+  ops->interp.set_result(interp, ops->string.builder_finish(interp, builder));
+  return TCL_ERROR;
+}
+
+// ensemble_rewrite_usage makes a wrong # args error from the command an
+// ensemble subcommand maps to name the ensemble and subcommand instead,
+// so "::counter::reset" becomes "counter reset".
+static void ensemble_rewrite_usage(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj display, FeatherObj sub, FeatherObj target) {
+  FeatherObj prefix = ops->string.intern(interp, "wrong # args: should be \"", 25);
+  prefix = ops->string.concat(interp, prefix, feather_get_display_name(ops, interp, target));
+  FeatherObj msg = ops->interp.get_result(interp);
+  size_t prefixLen = ops->string.byte_length(interp, prefix);
+  size_t msgLen = ops->string.byte_length(interp, msg);
+  if (msgLen <= prefixLen ||
+      !ops->string.equal(interp, ops->string.slice(interp, msg, 0, prefixLen), prefix)) {
+    return;
+  }
+  int next = ops->string.byte_at(interp, msg, prefixLen);
+  if (next != ' ' && next != '"') {
+    return;
+  }
+  FeatherObj rewritten = ops->string.intern(interp, "wrong # args: should be \"", 25);
+  rewritten = ops->string.concat(interp, rewritten, display);
+  rewritten = ops->string.concat(interp, rewritten, ops->string.intern(interp, " ", 1));
+  rewritten = ops->string.concat(interp, rewritten, sub);
+  rewritten = ops->string.concat(interp, rewritten,
+                                 ops->string.slice(interp, msg, prefixLen, msgLen));
+  ops->interp.set_result(interp, rewritten);
+}
+
+FeatherResult feather_ensemble_dispatch(const FeatherHostOps *ops, FeatherInterp interp,
+                                        FeatherObj cmd, FeatherObj args) {
+  FeatherObj display = feather_get_display_name(ops, interp, cmd);
+  if (ops->list.length(interp, args) == 0) {
+    FeatherObj msg = ops->string.intern(interp, "wrong # args: should be \"", 25);
+    msg = ops->string.concat(interp, msg, display);
+    msg = ops->string.concat(interp, msg,
+      ops->string.intern(interp, " subcommand ?arg ...?\"", 22));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  FeatherObj config = ops->dict.get(interp, ensemble_config_all(ops, interp), cmd);
+  if (ops->list.is_nil(interp, config)) {
+    FeatherObj msg = ops->string.intern(interp, "\"", 1);
+    msg = ops->string.concat(interp, msg, display);
+    msg = ops->string.concat(interp, msg,
+      ops->string.intern(interp, "\" is not an ensemble command", 28));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  FeatherObj sub = ops->list.shift(interp, args);
+  FeatherObj names = ensemble_names(ops, interp, config);
+  size_t count = ops->list.length(interp, names);
+  int64_t prefixes = 1;
+  ops->integer.get(interp,
+    ops->dict.get(interp, config, ops->string.intern(interp, "-prefixes", 9)), &prefixes);
+
+  FeatherObj match = 0;
+  for (size_t i = 0; i < count && match == 0; i++) {
+    FeatherObj name = ops->list.at(interp, names, i);
+    if (ops->string.equal(interp, name, sub)) {
+      match = name;
+    }
+  }
+  if (match == 0 && prefixes) {
+    size_t subLen = ops->string.byte_length(interp, sub);
+    size_t found = 0;
+    for (size_t i = 0; i < count; i++) {
+      FeatherObj name = ops->list.at(interp, names, i);
+      if (subLen > 0 && ops->string.byte_length(interp, name) >= subLen &&
+          ops->string.equal(interp, ops->string.slice(interp, name, 0, subLen), sub)) {
+        match = name;
+        found++;
       }
     }
+    if (found != 1) {
+      match = 0;
+    }
+  }
+  if (match == 0) {
+    return ensemble_unknown(ops, interp, config, names, sub, (int)prefixes);
+  }
+
+  FeatherObj map = ops->dict.get(interp, config, ops->string.intern(interp, "-map", 4));
+  FeatherObj mapped = ops->dict.get(interp, map, match);
+  FeatherObj command;
+  if (!ops->list.is_nil(interp, mapped)) {
+    command = ops->list.from(interp, mapped);
+  } else {
+    // Unmapped names run the command of that name in the ensemble namespace
+    FeatherObj ns = ops->dict.get(interp, config, ops->string.intern(interp, "-namespace", 10));
+    FeatherObj target = ensemble_qualify(ops, interp, ns, match);
+    if (feather_lookup_command(ops, interp, target, NULL, NULL, NULL) == TCL_CMD_NONE) {
+      target = match;
+    }
+    command = ops->list.create(interp);
+    command = ops->list.push(interp, command, target);
+  }
+  size_t words = ops->list.length(interp, command);
+  FeatherObj target = ops->list.at(interp, command, 0);
+  size_t argc = ops->list.length(interp, args);
+  for (size_t i = 0; i < argc; i++) {
+    command = ops->list.push(interp, command, ops->list.at(interp, args, i));
   }
+  FeatherResult code = feather_command_exec(ops, interp, command, 0);
+  if (code == TCL_ERROR && words == 1) {
+    ensemble_rewrite_usage(ops, interp, display, match, target);
+  }
+  return code;
+}
 
+void feather_ensemble_rename(const FeatherHostOps *ops, FeatherInterp interp,
+                             FeatherObj oldName, FeatherObj newName) {
+  FeatherObj global = ops->string.intern(interp, "::", 2);
+  oldName = ensemble_qualify(ops, interp, global, oldName);
+  FeatherObj all = ensemble_config_all(ops, interp);
+  FeatherObj config = ops->dict.get(interp, all, oldName);
+  if (ops->list.is_nil(interp, config)) {
+    return;
+  }
+  all = ops->dict.remove(interp, all, oldName);
+  if (ops->string.byte_length(interp, newName) > 0) {
+    all = ops->dict.set(interp, all, ensemble_qualify(ops, interp, global, newName), config);
+  }
+  ensemble_config_store(ops, interp, all);
+}
+
+// ensemble_lookup finds the configuration of the ensemble called name,
+// storing its qualified name in *qualified.
+static FeatherResult ensemble_lookup(const FeatherHostOps *ops, FeatherInterp interp,
+                                     FeatherObj name, FeatherObj *qualified,
+                                     FeatherObj *config) {
+  FeatherBuiltinCmd fn = NULL;
+  *qualified = ensemble_resolve(ops, interp, name, &fn);
+  if (*qualified == 0) {
+    FeatherObj msg = ops->string.intern(interp, "unknown command \"", 17);
+    msg = ops->string.concat(interp, msg, name);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  if (fn == feather_ensemble_dispatch) {
+    *config = ops->dict.get(interp, ensemble_config_all(ops, interp), *qualified);
+  } else {
+    *config = 0;
+  }
+  if (*config == 0 || ops->list.is_nil(interp, *config)) {
+    FeatherObj msg = ops->string.intern(interp, "\"", 1);
+    msg = ops->string.concat(interp, msg, name);
+    msg = ops->string.concat(interp, msg,
+      ops->string.intern(interp, "\" is not an ensemble command", 28));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
   return TCL_OK;
 }
 
+// namespace ensemble create ?option value ...?
+static FeatherResult ens_create(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  size_t argc = ops->list.length(interp, args);
+  if (argc % 2 != 0) {
+    FeatherObj msg = ops->string.intern(interp,
+      "wrong # args: should be \"namespace ensemble create ?option value ...?\"", 70);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  FeatherObj current = ops->ns.current(interp);
+  FeatherObj name = current;
+  FeatherObj config = ops->dict.create(interp);
+  config = ops->dict.set(interp, config, ops->string.intern(interp, "-map", 4),
+                         ops->dict.create(interp));
+  config = ops->dict.set(interp, config, ops->string.intern(interp, "-namespace", 10), current);
+  config = ops->dict.set(interp, config, ops->string.intern(interp, "-prefixes", 9),
+                         ops->integer.create(interp, 1));
+  config = ops->dict.set(interp, config, ops->string.intern(interp, "-subcommands", 12),
+                         ops->list.create(interp));
+
+  for (size_t i = 0; i < argc; i += 2) {
+    int idx;
+    if (ops->string.get_index(interp, ops->list.at(interp, args, i),
+                              ensemble_create_options, "option", 0, &idx) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    FeatherObj value = ops->list.at(interp, args, i + 1);
+    if (ensemble_create_ids[idx] == ENS_COMMAND) {
+      name = value;
+    } else if (ensemble_set_option(ops, interp, &config, ensemble_create_ids[idx], value) != TCL_OK) {
+      return TCL_ERROR;
+    }
+  }
+
+  FeatherObj qualified = ensemble_qualify(ops, interp, current, name);
+  feather_register_command(ops, interp, qualified, TCL_CMD_BUILTIN,
+                           feather_ensemble_dispatch, 0, 0);
+  FeatherObj all = ensemble_config_all(ops, interp);
+  ensemble_config_store(ops, interp, ops->dict.set(interp, all, qualified, config));
+  ops->interp.set_result(interp, qualified);
+  return TCL_OK;
+}
+
+// namespace ensemble configure cmdname ?option? ?value option value ...?
+static FeatherResult ens_configure(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  size_t argc = ops->list.length(interp, args);
+  if (argc == 0 || (argc > 2 && argc % 2 == 0)) {
+    FeatherObj msg = ops->string.intern(interp,
+      "wrong # args: should be \"namespace ensemble configure cmdname ?-option value ...?\"", 82);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  FeatherObj qualified, config;
+  if (ensemble_lookup(ops, interp, ops->list.at(interp, args, 0), &qualified, &config) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  if (argc == 1) {
+    ops->interp.set_result(interp, config);
+    return TCL_OK;
+  }
+
+  int idx;
+  if (argc == 2) {
+    FeatherObj opt = ops->list.at(interp, args, 1);
+    if (ops->string.get_index(interp, opt, ensemble_configure_options, "option", 0, &idx) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    FeatherObj key = ops->string.intern(interp, ensemble_configure_options[idx],
+                                        feather_strlen(ensemble_configure_options[idx]));
+    ops->interp.set_result(interp, ops->dict.get(interp, config, key));
+    return TCL_OK;
+  }
+
+  for (size_t i = 1; i < argc; i += 2) {
+    if (ops->string.get_index(interp, ops->list.at(interp, args, i),
+                              ensemble_configure_options, "option", 0, &idx) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (ensemble_set_option(ops, interp, &config, ensemble_configure_ids[idx],
+                            ops->list.at(interp, args, i + 1)) != TCL_OK) {
+      return TCL_ERROR;
+    }
+  }
+  FeatherObj all = ensemble_config_all(ops, interp);
+  ensemble_config_store(ops, interp, ops->dict.set(interp, all, qualified, config));
+  ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+  return TCL_OK;
+}
+
+// namespace ensemble exists cmdname
+static FeatherResult ens_exists(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  if (ops->list.length(interp, args) != 1) {
+    FeatherObj msg = ops->string.intern(interp,
+      "wrong # args: should be \"namespace ensemble exists cmdname\"", 59);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  FeatherBuiltinCmd fn = NULL;
+  FeatherObj qualified = ensemble_resolve(ops, interp, ops->list.at(interp, args, 0), &fn);
+  int exists = qualified != 0 && fn == feather_ensemble_dispatch;
+  ops->interp.set_result(interp, ops->integer.create(interp, exists));
+  return TCL_OK;
+}
+
+// Subcommand names in the order of EnsembleSubcommand, for string.get_index.
+static const char *const ensemble_subcommands[] = {
+  "configure", "create", "exists", NULL
+};
+
+typedef enum {
+  ENS_SUB_CONFIGURE,
+  ENS_SUB_CREATE,
+  ENS_SUB_EXISTS
+} EnsembleSubcommand;
+
+// namespace ensemble subcommand ?arg ...?
+static FeatherResult ns_ensemble(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  if (ops->list.length(interp, args) == 0) {
+    FeatherObj msg = ops->string.intern(interp,
+      "wrong # args: should be \"namespace ensemble subcommand ?arg ...?\"", 65);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  int idx;
+  if (ops->string.get_index(interp, ops->list.shift(interp, args), ensemble_subcommands,
+                            "subcommand", 0, &idx) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  switch ((EnsembleSubcommand)idx) {
+    case ENS_SUB_CONFIGURE:
+      return ens_configure(ops, interp, args);
+    case ENS_SUB_CREATE:
+      return ens_create(ops, interp, args);
+    case ENS_SUB_EXISTS:
+      return ens_exists(ops, interp, args);
+  }
+  return TCL_ERROR;
+}
+
 void feather_register_namespace_usage(const FeatherHostOps *ops, FeatherInterp interp) {
   FeatherObj spec = feather_usage_spec(ops, interp);
   FeatherObj subspec;
@@ -933,6 +1612,30 @@ void feather_register_namespace_usage(const FeatherHostOps *ops, FeatherInterp i
     "given, this command does nothing.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // --- Subcommand: ensemble ---
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_arg(ops, interp, "<subcommand>");
+  subspec = feather_usage_add(ops, interp, subspec, e);
+  e = feather_usage_arg(ops, interp, "?arg?...");
+  subspec = feather_usage_add(ops, interp, subspec, e);
+  e = feather_usage_cmd(ops, interp, "ensemble", subspec);
+  e = feather_usage_help(ops, interp, e, "Create and manipulate ensemble commands");
+  e = feather_usage_long_help(ops, interp, e,
+    "An ensemble is a command whose first argument selects a subcommand, "
+    "like string or dict. namespace ensemble create ?option value ...? "
+    "creates one for the current namespace, named after the namespace "
+    "unless -command is given, and returns its fully-qualified name. "
+    "namespace ensemble configure cmdname ?option? ?value ...? reads or "
+    "changes its options, and namespace ensemble exists cmdname returns 1 "
+    "if cmdname is an ensemble.\n\n"
+    "-map is a dict from subcommand names to the command prefixes they run. "
+    "-subcommands lists the accepted names; names not in the map run the "
+    "command of that name in the ensemble namespace. Without either, the "
+    "namespace's exported commands are the subcommands. -prefixes, on by "
+    "default, allows unique abbreviations of subcommand names. -namespace "
+    "is read-only.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // --- Subcommand: eval ---
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "<namespace>");
@@ -1084,6 +1787,20 @@ void feather_register_namespace_usage(const FeatherHostOps *ops, FeatherInterp i
     "namespace's parent is returned.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // --- Subcommand: path ---
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_arg(ops, interp, "?pathList?");
+  subspec = feather_usage_add(ops, interp, subspec, e);
+  e = feather_usage_cmd(ops, interp, "path", subspec);
+  e = feather_usage_help(ops, interp, e, "Get or set the command resolution path");
+  e = feather_usage_long_help(ops, interp, e,
+    "Returns the command resolution path of the current namespace, or sets "
+    "it to pathList. Unqualified command names are looked up in the current "
+    "namespace, then in each namespace on its path in order, then in the "
+    "global namespace. The path applies to the current namespace only, not "
+    "to its children, and namespaces deleted later drop out of it.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // --- Subcommand: qualifiers ---
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_arg(ops, interp, "<string>");
@@ -1114,6 +1831,20 @@ void feather_register_namespace_usage(const FeatherHostOps *ops, FeatherInterp i
     "the names of currently defined namespaces.");
   spec = feather_usage_add(ops, interp, spec, e);
 
+  // --- Subcommand: upvar ---
+  subspec = feather_usage_spec(ops, interp);
+  e = feather_usage_arg(ops, interp, "<namespace>");
+  subspec = feather_usage_add(ops, interp, subspec, e);
+  e = feather_usage_arg(ops, interp, "?otherVar myVar?...");
+  subspec = feather_usage_add(ops, interp, subspec, e);
+  e = feather_usage_cmd(ops, interp, "upvar", subspec);
+  e = feather_usage_help(ops, interp, e, "Link local variables to namespace variables");
+  e = feather_usage_long_help(ops, interp, e,
+    "For each pair of arguments, makes the local variable myVar refer to "
+    "the variable otherVar in namespace, as upvar does for the variables "
+    "of callers. otherVar is resolved relative to namespace.");
+  spec = feather_usage_add(ops, interp, spec, e);
+
   // --- Subcommand: which ---
   subspec = feather_usage_spec(ops, interp);
   e = feather_usage_flag(ops, interp, "-command", NULL, NULL);
@@ -1180,6 +1911,8 @@ FeatherResult feather_builtin_namespace(const FeatherHostOps *ops, FeatherInterp
 
   if (feather_obj_eq_literal(ops, interp, subcmd, "current")) {
     return ns_current(ops, interp, args);
+  } else if (feather_obj_eq_literal(ops, interp, subcmd, "ensemble")) {
+    return ns_ensemble(ops, interp, args);
   } else if (feather_obj_eq_literal(ops, interp, subcmd, "eval")) {
     return ns_eval(ops, interp, args);
   } else if (feather_obj_eq_literal(ops, interp, subcmd, "exists")) {
@@ -1208,12 +1941,16 @@ FeatherResult feather_builtin_namespace(const FeatherHostOps *ops, FeatherInterp
     return ns_code(ops, interp, args);
   } else if (feather_obj_eq_literal(ops, interp, subcmd, "which")) {
     return ns_which(ops, interp, args);
+  } else if (feather_obj_eq_literal(ops, interp, subcmd, "path")) {
+    return ns_path(ops, interp, args);
+  } else if (feather_obj_eq_literal(ops, interp, subcmd, "upvar")) {
+    return ns_upvar(ops, interp, args);
   } else {
     FeatherObj msg = ops->string.intern(interp,
       "bad option \"", 12);
     msg = ops->string.concat(interp, msg, subcmd);
     FeatherObj suffix = ops->string.intern(interp,
-      "\": must be children, code, current, delete, eval, exists, export, forget, import, inscope, origin, parent, qualifiers, tail, or which", 133);
+      "\": must be children, code, current, delete, ensemble, eval, exists, export, forget, import, inscope, origin, parent, path, qualifiers, tail, upvar, or which", 156);
     msg = ops->string.concat(interp, msg, suffix);
     ops->interp.set_result(interp, msg);
     return TCL_ERROR;
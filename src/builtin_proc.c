@@ -4,6 +4,46 @@
 #include "charclass.h"
 #include "error_trace.h"
 
+FeatherResult feather_check_param_spec(const FeatherHostOps *ops, FeatherInterp interp,
+                                       FeatherObj paramSpec) {
+  size_t specLen = ops->list.length(interp, paramSpec);
+  if (specLen > 2) {
+    // Error: too many fields in argument specifier
+    FeatherObj msg = ops->string.intern(interp, "too many fields in argument specifier \"", 39);
+    msg = ops->string.concat(interp, msg, paramSpec);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+
+  FeatherObj paramName = specLen == 0 ? 0 : ops->list.at(interp, paramSpec, 0);
+  size_t nameLen = paramName == 0 ? 0 : ops->string.byte_length(interp, paramName);
+  if (nameLen == 0) {
+    ops->interp.set_result(interp, ops->string.intern(interp, "argument with no name", 21));
+    return TCL_ERROR;
+  }
+
+  // Parameter names must be simple scalar variable names
+  const char *problem = NULL;
+  for (size_t i = 0; i < nameLen && problem == NULL; i++) {
+    int c = ops->string.byte_at(interp, paramName, i);
+    if (c == '(' && ops->string.byte_at(interp, paramName, nameLen - 1) == ')') {
+      problem = "\" is an array element";
+    } else if (c == ':' && i + 1 < nameLen &&
+               ops->string.byte_at(interp, paramName, i + 1) == ':') {
+      problem = "\" is not a simple name";
+    }
+  }
+  if (problem != NULL) {
+    FeatherObj msg = ops->string.intern(interp, "formal parameter \"", 18);
+    msg = ops->string.concat(interp, msg, paramName);
+    msg = ops->string.concat(interp, msg, ops->string.intern(interp, problem, feather_strlen(problem)));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  return TCL_OK;
+}
+
 FeatherResult feather_builtin_proc(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj cmd, FeatherObj args) {
   size_t argc = ops->list.length(interp, args);
@@ -21,18 +61,12 @@ FeatherResult feather_builtin_proc(const FeatherHostOps *ops, FeatherInterp inte
   FeatherObj params = ops->list.shift(interp, args);
   FeatherObj body = ops->list.shift(interp, args);
 
-  // Validate parameter specs - each must be 1 or 2 elements
+  // Validate parameter specs
   size_t paramc = ops->list.length(interp, params);
   FeatherObj paramsCopy = ops->list.from(interp, params);
   for (size_t i = 0; i < paramc; i++) {
     FeatherObj paramSpec = ops->list.shift(interp, paramsCopy);
-    size_t specLen = ops->list.length(interp, paramSpec);
-    if (specLen > 2) {
-      // Error: too many fields in argument specifier
-      FeatherObj msg = ops->string.intern(interp, "too many fields in argument specifier \"", 39);
-      msg = ops->string.concat(interp, msg, paramSpec);
-      msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
-      ops->interp.set_result(interp, msg);
+    if (feather_check_param_spec(ops, interp, paramSpec) != TCL_OK) {
       return TCL_ERROR;
     }
   }
@@ -103,7 +137,7 @@ static FeatherObj get_default(const FeatherHostOps *ops, FeatherInterp interp, F
 }
 
 FeatherResult feather_invoke_proc(const FeatherHostOps *ops, FeatherInterp interp,
-                          FeatherObj name, FeatherObj args) {
+                          FeatherObj name, FeatherObj word, FeatherObj args) {
   // Get the procedure's parameter list and body
   FeatherObj params = 0;
   FeatherObj body = 0;
@@ -195,9 +229,13 @@ FeatherResult feather_invoke_proc(const FeatherHostOps *ops, FeatherInterp inter
   if (ops->frame.push(interp, name, args) != TCL_OK) {
     return TCL_ERROR;
   }
+  FeatherInterpState *state = ops->interp.state(interp);
+  FeatherTailcall savedTail = feather_tailcall_begin(state);
+  size_t procLevel = ops->frame.level(interp);
 
   // Copy the line number from the parent frame to the new frame
   ops->frame.set_line(interp, parentLine);
+  ops->frame.set_word(interp, word);
 
   // Set the namespace for this frame based on the proc's qualified name
   // For "::counter::incr", the namespace is "::counter"
@@ -278,17 +316,25 @@ FeatherResult feather_invoke_proc(const FeatherHostOps *ops, FeatherInterp inter
     result = feather_script_eval_obj_stepped(ops, interp, body, stepTarget, TCL_EVAL_LOCAL);
   } else {
     // No step tracing needed
-    result = feather_script_eval_obj(ops, interp, body, TCL_EVAL_LOCAL);
+    result = feather_body_eval(ops, interp, body);
   }
 
-  // Append stack frame if error in progress
+  // Append stack frame if error in progress. The frame's line counts from
+  // the start of the file the body is in; report it from the start of the body.
   if (result == TCL_ERROR && feather_error_is_active(ops, interp)) {
     size_t errorLine = ops->frame.get_line(interp, ops->frame.level(interp));
-    feather_error_append_frame(ops, interp, name, args, errorLine);
+    FeatherObj bodyFile;
+    size_t bodyLine = ops->interp.get_source(interp, body, &bodyFile);
+    if (bodyLine > 1 && errorLine >= bodyLine) {
+      errorLine = errorLine - bodyLine + 1;
+    }
+    feather_error_append_frame(ops, interp, name, args, errorLine, parentLine);
   }
 
-  // Pop the call frame
+  // Pop the call frame. A tail call the body requested is left for
+  // feather_command_exec to run in place of the proc.
   ops->frame.pop(interp);
+  result = feather_tailcall_end(state, savedTail, procLevel, result);
 
   // Handle TCL_RETURN specially
   if (result == TCL_RETURN) {
@@ -59,66 +59,100 @@ static int is_binary_digit(int c) {
 typedef struct {
   int suppress;
   int width;
+  int has_width;
   int position;
   int has_position;
   char specifier;
-  char charset[256];
-  int charset_negated;
-  int charset_len;
+  size_t set_start;       // Byte offset of a %[...] set, after any '^'
+  size_t set_end;         // Byte offset of the closing ']'
+  int set_negated;
   SizeModifier size_mod;
 } ScanSpec;
 
-// Parse format specifier using object-based byte access
+static void scan_error(const FeatherHostOps *ops, FeatherInterp interp, const char *msg) {
+  ops->interp.set_result(interp, ops->string.intern(interp, msg, feather_strlen(msg)));
+}
+
+// Read the character at pos, treating a byte that is not valid UTF-8 as a
+// character of its own
+static int64_t scan_char_at(const FeatherHostOps *ops, FeatherInterp interp,
+                            FeatherObj str, size_t pos, size_t len, size_t *bytes_read) {
+  int64_t c = decode_utf8_at_pos(ops, interp, str, pos, len, bytes_read);
+  if (c < 0) {
+    *bytes_read = 1;
+    c = ops->string.byte_at(interp, str, pos);
+  }
+  return c;
+}
+
+// Report a conversion character that scan does not know, quoting the
+// whole character
+static void scan_bad_conversion(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj fmtObj, size_t pos, size_t fmtLen) {
+  FeatherObj msg = ops->string.intern(interp, "bad scan conversion character \"", 31);
+  if (pos < fmtLen) {
+    size_t n;
+    scan_char_at(ops, interp, fmtObj, pos, fmtLen, &n);
+    msg = ops->string.concat(interp, msg, ops->string.slice(interp, fmtObj, pos, pos + n));
+  }
+  msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\"", 1));
+  ops->interp.set_result(interp, msg);
+}
+
+// Parse format specifier using object-based byte access.
+// Returns the number of bytes consumed, or -1 with the interpreter result set
+// if the specifier is malformed.
 static int parse_scan_spec_obj(const FeatherHostOps *ops, FeatherInterp interp,
                                FeatherObj fmtObj, size_t start, size_t fmtLen, ScanSpec *spec) {
   size_t pos = start;
 
   spec->suppress = 0;
   spec->width = 0;
+  spec->has_width = 0;
   spec->position = -1;
   spec->has_position = 0;
   spec->specifier = 0;
-  spec->charset_negated = 0;
-  spec->charset_len = 0;
+  spec->set_start = 0;
+  spec->set_end = 0;
+  spec->set_negated = 0;
   spec->size_mod = SIZE_NONE;
-  for (int i = 0; i < 256; i++) spec->charset[i] = 0;
-
-  if (pos >= fmtLen) return -1;
 
-  int ch = ops->string.byte_at(interp, fmtObj, pos);
+  int ch = (pos < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos) : -1;
   if (ch == '%') {
     spec->specifier = '%';
     return 1;
   }
 
-  size_t posStart = pos;
-  while (pos < fmtLen && (ch = ops->string.byte_at(interp, fmtObj, pos)) >= 0 && feather_is_digit(ch)) {
-    pos++;
-  }
-  if (pos > posStart && pos < fmtLen && ops->string.byte_at(interp, fmtObj, pos) == '$') {
-    int idx = 0;
-    for (size_t i = posStart; i < pos; i++) {
-      idx = idx * 10 + (ops->string.byte_at(interp, fmtObj, i) - '0');
-    }
-    spec->has_position = 1;
-    spec->position = idx;
-    pos++;
-  } else {
-    pos = posStart;
-  }
-
-  ch = (pos < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos) : -1;
+  // A discarded conversion has no position, and a positional one cannot
+  // be discarded
   if (ch == '*') {
     spec->suppress = 1;
     pos++;
+  } else {
+    size_t posStart = pos;
+    while (pos < fmtLen && (ch = ops->string.byte_at(interp, fmtObj, pos)) >= 0 && feather_is_digit(ch)) {
+      pos++;
+    }
+    if (pos > posStart && pos < fmtLen && ops->string.byte_at(interp, fmtObj, pos) == '$') {
+      int idx = 0;
+      for (size_t i = posStart; i < pos; i++) {
+        idx = idx * 10 + (ops->string.byte_at(interp, fmtObj, i) - '0');
+      }
+      spec->has_position = 1;
+      spec->position = idx;
+      pos++;
+    } else {
+      pos = posStart;
+    }
   }
 
   while (pos < fmtLen && (ch = ops->string.byte_at(interp, fmtObj, pos)) >= 0 && feather_is_digit(ch)) {
     spec->width = spec->width * 10 + (ch - '0');
+    spec->has_width = 1;
     pos++;
   }
 
-  // Parse size modifiers (ll, h, l, z, t, L, j, q)
+  // Parse size modifiers (ll, h, l, z, t, L, j)
   ch = (pos < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos) : -1;
   if (ch == 'l') {
     pos++;
@@ -143,68 +177,175 @@ static int parse_scan_spec_obj(const FeatherHostOps *ops, FeatherInterp interp,
   } else if (ch == 't') {
     spec->size_mod = SIZE_T;
     pos++;
-  } else if (ch == 'q') {
-    spec->size_mod = SIZE_Q;
-    pos++;
   }
+  int widened = spec->size_mod != SIZE_NONE && spec->size_mod != SIZE_H;
 
-  if (pos >= fmtLen) return -1;
-
-  ch = ops->string.byte_at(interp, fmtObj, pos);
-  if (ch == 'd' || ch == 'i' || ch == 'u' || ch == 'o' || ch == 'x' || ch == 'X' ||
-      ch == 'b' || ch == 'c' || ch == 's' || ch == 'f' || ch == 'e' || ch == 'E' ||
-      ch == 'g' || ch == 'G' || ch == 'n') {
-    spec->specifier = (char)ch;
-    pos++;
-    return (int)(pos - start);
-  }
+  ch = (pos < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos) : -1;
+  switch (ch) {
+    case 'c':
+      if (spec->has_width) {
+        scan_error(ops, interp, "field width may not be specified in %c conversion");
+        return -1;
+      }
+      // fallthrough
+    case 'n':
+    case 's':
+      if (widened) {
+        FeatherObj msg = ops->string.intern(interp,
+          "field size modifier may not be specified in %", 45);
+        msg = ops->string.concat(interp, msg, ops->string.slice(interp, fmtObj, pos, pos + 1));
+        msg = ops->string.concat(interp, msg, ops->string.intern(interp, " conversion", 11));
+        ops->interp.set_result(interp, msg);
+        return -1;
+      }
+      // fallthrough
+    case 'd': case 'i': case 'u': case 'o': case 'x': case 'X':
+    case 'b': case 'f': case 'e': case 'E': case 'g': case 'G':
+      spec->specifier = (char)ch;
+      pos++;
+      return (int)(pos - start);
 
-  if (ch == '[') {
-    pos++;
-    if (pos >= fmtLen) return -1;
+    case '[': {
+      if (widened) {
+        scan_error(ops, interp, "field size modifier may not be specified in %[ conversion");
+        return -1;
+      }
+      pos++;
+      if (pos < fmtLen && ops->string.byte_at(interp, fmtObj, pos) == '^') {
+        spec->set_negated = 1;
+        pos++;
+      }
+      spec->set_start = pos;
 
-    ch = ops->string.byte_at(interp, fmtObj, pos);
-    if (ch == '^') {
-      spec->charset_negated = 1;
+      // A ']' right after the opening bracket is a member of the set
+      if (pos < fmtLen && ops->string.byte_at(interp, fmtObj, pos) == ']') {
+        pos++;
+      }
+      while (pos < fmtLen && ops->string.byte_at(interp, fmtObj, pos) != ']') {
+        pos++;
+      }
+      if (pos >= fmtLen) {
+        scan_error(ops, interp, "unmatched [ in format string");
+        return -1;
+      }
+      spec->set_end = pos;
       pos++;
+      spec->specifier = '[';
+      return (int)(pos - start);
     }
+  }
 
-    ch = (pos < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos) : -1;
-    if (ch == ']') {
-      spec->charset[(unsigned char)']'] = 1;
-      spec->charset_len++;
-      pos++;
+  scan_bad_conversion(ops, interp, fmtObj, pos, fmtLen);
+  return -1;
+}
+
+// Check whether a character is in the set of a %[...] conversion. A '-'
+// between two characters makes a range, in either order; at either end of
+// the set it stands for itself.
+static int scan_set_contains(const FeatherHostOps *ops, FeatherInterp interp,
+                             FeatherObj fmtObj, const ScanSpec *spec, int64_t c) {
+  size_t pos = spec->set_start;
+  int found = 0;
+  while (pos < spec->set_end && !found) {
+    size_t n;
+    int64_t lo = scan_char_at(ops, interp, fmtObj, pos, spec->set_end, &n);
+    pos += n;
+    int64_t hi = lo;
+    if (pos + 1 < spec->set_end && ops->string.byte_at(interp, fmtObj, pos) == '-') {
+      hi = scan_char_at(ops, interp, fmtObj, pos + 1, spec->set_end, &n);
+      pos += 1 + n;
+      if (hi < lo) {
+        int64_t tmp = lo;
+        lo = hi;
+        hi = tmp;
+      }
     }
+    found = c >= lo && c <= hi;
+  }
+  return spec->set_negated ? !found : found;
+}
 
-    while (pos < fmtLen && (ch = ops->string.byte_at(interp, fmtObj, pos)) >= 0 && ch != ']') {
-      int ch2 = (pos + 1 < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos + 1) : -1;
-      int ch3 = (pos + 2 < fmtLen) ? ops->string.byte_at(interp, fmtObj, pos + 2) : -1;
-      if (ch2 == '-' && ch3 >= 0 && ch3 != ']') {
-        int start_ch = ch;
-        int end_ch = ch3;
-        for (int i = (unsigned char)start_ch; i <= (unsigned char)end_ch; i++) {
-          if (!spec->charset[i]) {
-            spec->charset[i] = 1;
-            spec->charset_len++;
-          }
-        }
-        pos += 3;
-      } else {
-        if (!spec->charset[(unsigned char)ch]) {
-          spec->charset[(unsigned char)ch] = 1;
-          spec->charset_len++;
-        }
-        pos++;
+// Check a whole format before anything is scanned, as TCL does, and count
+// the values it produces: one per non-discarded conversion, or the highest
+// position used.
+static FeatherResult scan_validate_format(const FeatherHostOps *ops, FeatherInterp interp,
+                                          FeatherObj fmtObj, size_t fmtLen,
+                                          size_t numVars, size_t *totalVars) {
+  // How often each value is assigned, grown as positions are seen
+  FeatherObj assigned = ops->list.create(interp);
+  size_t assignedLen = 0;
+  size_t objIndex = 0;
+  size_t maxPosition = 0;
+  int usedPositional = -1;
+
+  size_t pos = 0;
+  while (pos < fmtLen) {
+    if (ops->string.byte_at(interp, fmtObj, pos++) != '%') continue;
+
+    ScanSpec spec;
+    int consumed = parse_scan_spec_obj(ops, interp, fmtObj, pos, fmtLen, &spec);
+    if (consumed < 0) return TCL_ERROR;
+    pos += (size_t)consumed;
+    if (spec.specifier == '%') continue;
+
+    if (spec.has_position) {
+      if (usedPositional == 0) {
+        scan_error(ops, interp, "cannot mix \"%\" and \"%n$\" conversion specifiers");
+        return TCL_ERROR;
+      }
+      usedPositional = 1;
+      if (spec.position < 1 || (numVars > 0 && (size_t)spec.position > numVars)) {
+        scan_error(ops, interp, "\"%n$\" argument index out of range");
+        return TCL_ERROR;
+      }
+      objIndex = (size_t)spec.position - 1;
+      if ((size_t)spec.position > maxPosition) maxPosition = (size_t)spec.position;
+    } else if (!spec.suppress) {
+      if (usedPositional == 1) {
+        scan_error(ops, interp, "cannot mix \"%\" and \"%n$\" conversion specifiers");
+        return TCL_ERROR;
+      }
+      usedPositional = 0;
+      if (numVars > 0 && objIndex >= numVars) {
+        scan_error(ops, interp, "different numbers of variable names and field specifiers");
+        return TCL_ERROR;
       }
     }
 
-    if (pos >= fmtLen) return -1;
-    pos++;
-    spec->specifier = '[';
-    return (int)(pos - start);
+    if (spec.suppress) continue;
+
+    while (assignedLen <= objIndex) {
+      assigned = ops->list.push(interp, assigned, ops->integer.create(interp, 0));
+      assignedLen++;
+    }
+    int64_t count = 0;
+    ops->integer.get(interp, ops->list.at(interp, assigned, objIndex), &count);
+    ops->list.set_at(interp, assigned, objIndex, ops->integer.create(interp, count + 1));
+    objIndex++;
   }
 
-  return -1;
+  size_t total = numVars;
+  if (total == 0) {
+    total = usedPositional == 1 ? maxPosition : objIndex;
+  }
+  for (size_t i = 0; i < total; i++) {
+    int64_t count = 0;
+    if (i < assignedLen) {
+      ops->integer.get(interp, ops->list.at(interp, assigned, i), &count);
+    }
+    if (count > 1) {
+      scan_error(ops, interp, "variable is assigned by multiple \"%n$\" conversion specifiers");
+      return TCL_ERROR;
+    }
+    // Without variables, positions that are never used are left empty
+    if (count == 0 && numVars > 0) {
+      scan_error(ops, interp, "variable is not assigned by any conversion specifiers");
+      return TCL_ERROR;
+    }
+  }
+
+  *totalVars = total;
+  return TCL_OK;
 }
 
 // Skip whitespace using object-based byte access
@@ -217,9 +358,12 @@ static size_t scan_skip_whitespace_obj(const FeatherHostOps *ops, FeatherInterp
   return pos;
 }
 
-// Scan integer using object-based byte access
+// Scan integer using object-based byte access.
+// If big is not NULL, values outside the int64 range are stored in *big as
+// an integer object of any size; otherwise they wrap around.
 static int scan_integer_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                            FeatherObj strObj, size_t len, size_t *pos, int base, int width, int64_t *out) {
+                            FeatherObj strObj, size_t len, size_t *pos, int base, int width,
+                            int64_t *out, FeatherObj *big) {
   size_t start = *pos;
   int negative = 0;
   int max = width > 0 ? width : (int)(len - *pos);
@@ -246,6 +390,7 @@ static int scan_integer_obj(const FeatherHostOps *ops, FeatherInterp interp,
 
   int64_t val = 0;
   int digits = 0;
+  if (big) *big = 0;
 
   while (*pos < len && consumed < max) {
     ch = ops->string.byte_at(interp, strObj, *pos);
@@ -263,7 +408,7 @@ static int scan_integer_obj(const FeatherHostOps *ops, FeatherInterp interp,
       break;
     }
 
-    val = val * base + d;
+    feather_accumulate_digit(ops, interp, &val, big, base, d);
     digits++;
     (*pos)++;
     consumed++;
@@ -274,13 +419,17 @@ static int scan_integer_obj(const FeatherHostOps *ops, FeatherInterp interp,
     return 0;
   }
 
+  if (big && *big && negative) {
+    ops->bignum.arith(interp, FEATHER_BIG_NEG, *big, 0, big);
+  }
   *out = negative ? -val : val;
   return 1;
 }
 
 // Auto-detect integer base using object-based byte access
 static int scan_auto_integer_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                 FeatherObj strObj, size_t len, size_t *pos, int width, int64_t *out) {
+                                 FeatherObj strObj, size_t len, size_t *pos, int width,
+                                 int64_t *out, FeatherObj *big) {
   size_t start = *pos;
   int negative = 0;
   int max = width > 0 ? width : (int)(len - *pos);
@@ -311,6 +460,7 @@ static int scan_auto_integer_obj(const FeatherHostOps *ops, FeatherInterp interp
 
   int64_t val = 0;
   int digits = 0;
+  if (big) *big = 0;
 
   while (*pos < len && consumed < max) {
     ch = ops->string.byte_at(interp, strObj, *pos);
@@ -326,7 +476,7 @@ static int scan_auto_integer_obj(const FeatherHostOps *ops, FeatherInterp interp
       break;
     }
 
-    val = val * base + d;
+    feather_accumulate_digit(ops, interp, &val, big, base, d);
     digits++;
     (*pos)++;
     consumed++;
@@ -337,6 +487,9 @@ static int scan_auto_integer_obj(const FeatherHostOps *ops, FeatherInterp interp
     return 0;
   }
 
+  if (big && *big && negative) {
+    ops->bignum.arith(interp, FEATHER_BIG_NEG, *big, 0, big);
+  }
   *out = negative ? -val : val;
   return 1;
 }
@@ -428,52 +581,46 @@ static int scan_float_obj(const FeatherHostOps *ops, FeatherInterp interp,
   return 1;
 }
 
-// Scan non-whitespace string using object-based byte access
+// Scan non-whitespace string using object-based byte access.
+// The width counts characters.
 static int scan_string_obj(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj strObj, size_t len, size_t *pos, int width,
-                           char *buf, size_t bufsize, size_t *outlen) {
+                           FeatherObj *out) {
   size_t start = *pos;
-  int max = width > 0 ? width : (int)(len - *pos);
   int consumed = 0;
 
-  while (*pos < len && consumed < max) {
+  while (*pos < len && (width <= 0 || consumed < width)) {
     int ch = ops->string.byte_at(interp, strObj, *pos);
     if (feather_is_whitespace_full(ch)) break;
-    if (*outlen < bufsize - 1) {
-      buf[(*outlen)++] = (char)ch;
-    }
-    (*pos)++;
+    size_t n;
+    scan_char_at(ops, interp, strObj, *pos, len, &n);
+    *pos += n;
     consumed++;
   }
 
   if (*pos == start) return 0;
-  buf[*outlen] = '\0';
+  *out = ops->string.slice(interp, strObj, start, *pos);
   return 1;
 }
 
-// Scan charset using object-based byte access
+// Scan characters of a %[...] set using object-based byte access.
+// The width counts characters.
 static int scan_charset_obj(const FeatherHostOps *ops, FeatherInterp interp,
                             FeatherObj strObj, size_t len, size_t *pos, int width,
-                            const char *charset, int negated,
-                            char *buf, size_t bufsize, size_t *outlen) {
+                            FeatherObj fmtObj, const ScanSpec *spec, FeatherObj *out) {
   size_t start = *pos;
-  int max = width > 0 ? width : (int)(len - *pos);
   int consumed = 0;
 
-  while (*pos < len && consumed < max) {
-    int ch = ops->string.byte_at(interp, strObj, *pos);
-    int in_set = charset[(unsigned char)ch];
-    int match = negated ? !in_set : in_set;
-    if (!match) break;
-    if (*outlen < bufsize - 1) {
-      buf[(*outlen)++] = (char)ch;
-    }
-    (*pos)++;
+  while (*pos < len && (width <= 0 || consumed < width)) {
+    size_t n;
+    int64_t c = scan_char_at(ops, interp, strObj, *pos, len, &n);
+    if (!scan_set_contains(ops, interp, fmtObj, spec, c)) break;
+    *pos += n;
     consumed++;
   }
 
   if (*pos == start) return 0;
-  buf[*outlen] = '\0';
+  *out = ops->string.slice(interp, strObj, start, *pos);
   return 1;
 }
 
@@ -498,16 +645,24 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
   int varMode = (argc > 2);
   size_t numVars = argc - 2;
 
-  FeatherObj results[64];
-  size_t resultCount = 0;
-  int positions[64];
-  for (size_t i = 0; i < 64; i++) positions[i] = -1;
+  size_t totalVars;
+  if (scan_validate_format(ops, interp, fmtObj, fmtLen, numVars, &totalVars) != TCL_OK) {
+    return TCL_ERROR;
+  }
+
+  // Inline results start out empty and are filled in by position
+  FeatherObj results = ops->list.create(interp);
+  if (!varMode) {
+    FeatherObj empty = ops->string.intern(interp, "", 0);
+    for (size_t i = 0; i < totalVars; i++) {
+      results = ops->list.push(interp, results, empty);
+    }
+  }
 
   size_t strPos = 0;
   size_t fmtPos = 0;
-  size_t varIndex = 0;
+  size_t objIndex = 0;
   int conversions = 0;
-  int usedPositional = -1;
   int anyConversionAttempted = 0;
 
   while (fmtPos < fmtLen) {
@@ -530,11 +685,10 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
     }
 
     fmtPos++;
-    if (fmtPos >= fmtLen) break;
 
+    // The format was validated, so every specifier parses
     ScanSpec spec;
     int consumed = parse_scan_spec_obj(ops, interp, fmtObj, fmtPos, fmtLen, &spec);
-    if (consumed < 0) break;
     fmtPos += (size_t)consumed;
 
     if (spec.specifier == '%') {
@@ -545,62 +699,30 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
     }
 
     if (spec.has_position) {
-      if (usedPositional == 0) {
-        FeatherObj msg = ops->string.intern(interp,
-          "cannot mix \"%\" and \"%n$\" conversion specifiers", 46);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      usedPositional = 1;
-    } else if (!spec.suppress && spec.specifier != 'n') {
-      if (usedPositional == 1) {
-        FeatherObj msg = ops->string.intern(interp,
-          "cannot mix \"%\" and \"%n$\" conversion specifiers", 46);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
-      usedPositional = 0;
+      objIndex = (size_t)spec.position - 1;
     }
 
+    FeatherObj scannedVal = 0;
+    int success = 0;
+
     if (spec.specifier == 'n') {
-      FeatherObj val = ops->integer.create(interp, (int64_t)strPos);
-      if (spec.suppress) {
-      } else if (spec.has_position) {
-        int idx = spec.position - 1;
-        if (idx >= 0 && idx < 64) {
-          positions[idx] = (int)resultCount;
-          results[resultCount++] = val;
-        }
-      } else {
-        if (varMode) {
-          if (varIndex < numVars) {
-            FeatherObj varName = ops->list.at(interp, args, 2 + varIndex);
-            if (feather_set_var(ops, interp, varName, val) != TCL_OK) {
-              return TCL_ERROR;
-            }
-            varIndex++;
-            conversions++;
-          }
-        } else {
-          results[resultCount++] = val;
-        }
+      scannedVal = ops->integer.create(interp, (int64_t)strPos);
+      success = 1;
+    } else {
+      if (spec.specifier != 'c' && spec.specifier != '[') {
+        strPos = scan_skip_whitespace_obj(ops, interp, strObj, strPos, strLen);
       }
-      continue;
-    }
-
-    if (spec.specifier != 'c' && spec.specifier != '[') {
-      strPos = scan_skip_whitespace_obj(ops, interp, strObj, strPos, strLen);
+      anyConversionAttempted = 1;
     }
 
-    anyConversionAttempted = 1;
-
-    FeatherObj scannedVal = 0;
-    int success = 0;
+    // %ll conversions keep integers of any size
+    FeatherObj big = 0;
+    FeatherObj *bigp = spec.size_mod == SIZE_LL ? &big : NULL;
 
     switch (spec.specifier) {
       case 'd': {
         int64_t val;
-        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 10, spec.width, &val);
+        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 10, spec.width, &val, bigp);
         if (success) {
           val = feather_apply_scan_truncation(val, spec.size_mod);
           scannedVal = ops->integer.create(interp, val);
@@ -609,7 +731,7 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
       }
       case 'u': {
         int64_t val;
-        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 10, spec.width, &val);
+        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 10, spec.width, &val, bigp);
         if (success) {
           val = feather_apply_unsigned_conversion(val, spec.size_mod);
           scannedVal = ops->integer.create(interp, val);
@@ -618,7 +740,7 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
       }
       case 'o': {
         int64_t val;
-        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 8, spec.width, &val);
+        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 8, spec.width, &val, bigp);
         if (success) {
           val = feather_apply_scan_truncation(val, spec.size_mod);
           scannedVal = ops->integer.create(interp, val);
@@ -628,7 +750,7 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
       case 'x':
       case 'X': {
         int64_t val;
-        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 16, spec.width, &val);
+        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 16, spec.width, &val, bigp);
         if (success) {
           val = feather_apply_scan_truncation(val, spec.size_mod);
           scannedVal = ops->integer.create(interp, val);
@@ -637,7 +759,7 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
       }
       case 'b': {
         int64_t val;
-        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 2, spec.width, &val);
+        success = scan_integer_obj(ops, interp, strObj, strLen, &strPos, 2, spec.width, &val, bigp);
         if (success) {
           val = feather_apply_scan_truncation(val, spec.size_mod);
           scannedVal = ops->integer.create(interp, val);
@@ -646,7 +768,7 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
       }
       case 'i': {
         int64_t val;
-        success = scan_auto_integer_obj(ops, interp, strObj, strLen, &strPos, spec.width, &val);
+        success = scan_auto_integer_obj(ops, interp, strObj, strLen, &strPos, spec.width, &val, bigp);
         if (success) {
           val = feather_apply_scan_truncation(val, spec.size_mod);
           scannedVal = ops->integer.create(interp, val);
@@ -676,89 +798,43 @@ FeatherResult feather_builtin_scan(const FeatherHostOps *ops, FeatherInterp inte
         if (success) scannedVal = ops->dbl.create(interp, val);
         break;
       }
-      case 's': {
-        char buf[4096];
-        size_t buflen = 0;
-        success = scan_string_obj(ops, interp, strObj, strLen, &strPos, spec.width, buf, sizeof(buf), &buflen);
-        if (success) scannedVal = ops->string.intern(interp, buf, buflen);
+      case 's':
+        success = scan_string_obj(ops, interp, strObj, strLen, &strPos, spec.width, &scannedVal);
         break;
-      }
-      case '[': {
-        char buf[4096];
-        size_t buflen = 0;
+      case '[':
         success = scan_charset_obj(ops, interp, strObj, strLen, &strPos, spec.width,
-                               spec.charset, spec.charset_negated,
-                               buf, sizeof(buf), &buflen);
-        if (success) scannedVal = ops->string.intern(interp, buf, buflen);
+                                   fmtObj, &spec, &scannedVal);
         break;
-      }
       default:
         break;
     }
 
     if (!success) break;
+    if (big) scannedVal = big;
 
     if (spec.suppress) {
       continue;
     }
 
-    if (spec.has_position) {
-      int idx = spec.position - 1;
-      if (idx >= 0 && idx < 64) {
-        positions[idx] = (int)resultCount;
-        results[resultCount++] = scannedVal;
+    if (varMode) {
+      FeatherObj varName = ops->list.at(interp, args, 2 + objIndex);
+      if (feather_set_var(ops, interp, varName, scannedVal) != TCL_OK) {
+        return TCL_ERROR;
       }
     } else {
-      if (varMode) {
-        if (varIndex < numVars) {
-          FeatherObj varName = ops->list.at(interp, args, 2 + varIndex);
-          if (feather_set_var(ops, interp, varName, scannedVal) != TCL_OK) {
-            return TCL_ERROR;
-          }
-          varIndex++;
-          conversions++;
-        }
-      } else {
-        results[resultCount++] = scannedVal;
-      }
+      ops->list.set_at(interp, results, objIndex, scannedVal);
     }
+    objIndex++;
+    conversions++;
   }
 
+  // Running out of input before any conversion is reported as -1, or as
+  // an empty list inline
+  int underflow = anyConversionAttempted && conversions == 0 && strPos >= strLen;
   if (varMode) {
-    if (usedPositional == 1) {
-      conversions = 0;
-      for (size_t i = 0; i < numVars && i < 64; i++) {
-        if (positions[i] >= 0) {
-          FeatherObj varName = ops->list.at(interp, args, 2 + i);
-          if (feather_set_var(ops, interp, varName, results[positions[i]]) != TCL_OK) {
-            return TCL_ERROR;
-          }
-          conversions++;
-        }
-      }
-    }
-
-    if (anyConversionAttempted && conversions == 0 && strPos >= strLen) {
-      ops->interp.set_result(interp, ops->integer.create(interp, -1));
-    } else {
-      ops->interp.set_result(interp, ops->integer.create(interp, conversions));
-    }
+    ops->interp.set_result(interp, ops->integer.create(interp, underflow ? -1 : conversions));
   } else {
-    if (usedPositional == 1) {
-      FeatherObj list = ops->list.create(interp);
-      for (size_t i = 0; i < 64; i++) {
-        if (positions[i] >= 0) {
-          list = ops->list.push(interp, list, results[positions[i]]);
-        }
-      }
-      ops->interp.set_result(interp, list);
-    } else {
-      FeatherObj list = ops->list.create(interp);
-      for (size_t i = 0; i < resultCount; i++) {
-        list = ops->list.push(interp, list, results[i]);
-      }
-      ops->interp.set_result(interp, list);
-    }
+    ops->interp.set_result(interp, underflow ? ops->list.create(interp) : results);
   }
 
   return TCL_OK;
@@ -835,7 +911,7 @@ void feather_register_scan_usage(const FeatherHostOps *ops, FeatherInterp interp
     "Tcl's integer values. The size modifier field dictates the integer range "
     "acceptable to be stored in a variable.\n\n"
     "h          Truncate to 32-bit signed range (same as no modifier)\n\n"
-    "l, j, q    Truncate to 64-bit range\n\n"
+    "l, j       Truncate to 64-bit range\n\n"
     "ll, L      No truncation (unlimited range)\n\n"
     "z, t       Platform-dependent (32-bit or 64-bit based on pointer size)");
   spec = feather_usage_add(ops, interp, spec, e);
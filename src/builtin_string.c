@@ -127,37 +127,94 @@ static FeatherResult string_match(const FeatherHostOps *ops, FeatherInterp inter
   return TCL_OK;
 }
 
-// string toupper
-static FeatherResult string_toupper(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
-  if (ops->list.length(interp, args) < 1) {
-    FeatherObj msg = ops->string.intern(interp,
-      "wrong # args: should be \"string toupper string ?first? ?last?\"", 62);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
+// parse_case_range reads the optional ?first? ?last? arguments of toupper,
+// tolower and totitle. Without arguments the range is the whole string; with
+// only first it is the single character at first. The range is clamped to
+// the string and is empty when *first > *last.
+static FeatherResult parse_case_range(const FeatherHostOps *ops, FeatherInterp interp,
+                                      FeatherObj args, size_t len,
+                                      int64_t *first, int64_t *last) {
+  *first = 0;
+  *last = (int64_t)len - 1;
+
+  if (ops->list.length(interp, args) >= 1) {
+    FeatherObj firstObj = ops->list.shift(interp, args);
+    if (feather_parse_index(ops, interp, firstObj, len, first) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    *last = *first;
+  }
+  if (ops->list.length(interp, args) >= 1) {
+    FeatherObj lastObj = ops->list.shift(interp, args);
+    if (feather_parse_index(ops, interp, lastObj, len, last) != TCL_OK) {
+      return TCL_ERROR;
+    }
   }
 
-  FeatherObj strObj = ops->list.shift(interp, args);
-  // For now, convert entire string (ignore first/last)
-  FeatherObj result = ops->rune.to_upper(interp, strObj);
-  ops->interp.set_result(interp, result);
+  if (*first < 0) *first = 0;
+  if (*last >= (int64_t)len) *last = (int64_t)len - 1;
   return TCL_OK;
 }
 
-// string tolower
-static FeatherResult string_tolower(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
-  if (ops->list.length(interp, args) < 1) {
-    FeatherObj msg = ops->string.intern(interp,
-      "wrong # args: should be \"string tolower string ?first? ?last?\"", 62);
-    ops->interp.set_result(interp, msg);
+// replace_rune_range returns str with characters first..last replaced by mid.
+static FeatherObj replace_rune_range(const FeatherHostOps *ops, FeatherInterp interp,
+                                     FeatherObj str, size_t len,
+                                     int64_t first, int64_t last, FeatherObj mid) {
+  FeatherObj result = ops->string.intern(interp, "", 0);
+  if (first > 0) {
+    result = ops->string.concat(interp, result, ops->rune.range(interp, str, 0, first - 1));
+  }
+  result = ops->string.concat(interp, result, mid);
+  if (last < (int64_t)len - 1) {
+    result = ops->string.concat(interp, result, ops->rune.range(interp, str, last + 1, len - 1));
+  }
+  return result;
+}
+
+// string toupper/tolower string ?first? ?last?
+static FeatherResult string_convert_case(const FeatherHostOps *ops, FeatherInterp interp,
+                                         FeatherObj args, const char *usage,
+                                         FeatherObj (*convert)(FeatherInterp, FeatherObj)) {
+  size_t argc = ops->list.length(interp, args);
+  if (argc < 1 || argc > 3) {
+    ops->interp.set_result(interp, ops->string.intern(interp, usage, feather_strlen(usage)));
     return TCL_ERROR;
   }
 
   FeatherObj strObj = ops->list.shift(interp, args);
-  FeatherObj result = ops->rune.to_lower(interp, strObj);
-  ops->interp.set_result(interp, result);
+  size_t len = ops->rune.length(interp, strObj);
+
+  if (argc == 1) {
+    ops->interp.set_result(interp, convert(interp, strObj));
+    return TCL_OK;
+  }
+
+  int64_t first, last;
+  if (parse_case_range(ops, interp, args, len, &first, &last) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  if (first > last) {
+    ops->interp.set_result(interp, strObj);
+    return TCL_OK;
+  }
+
+  FeatherObj mid = convert(interp, ops->rune.range(interp, strObj, first, last));
+  ops->interp.set_result(interp, replace_rune_range(ops, interp, strObj, len, first, last, mid));
   return TCL_OK;
 }
 
+// string toupper
+static FeatherResult string_toupper(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  return string_convert_case(ops, interp, args,
+    "wrong # args: should be \"string toupper string ?first? ?last?\"", ops->rune.to_upper);
+}
+
+// string tolower
+static FeatherResult string_tolower(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
+  return string_convert_case(ops, interp, args,
+    "wrong # args: should be \"string tolower string ?first? ?last?\"", ops->rune.to_lower);
+}
+
 // string totitle
 static FeatherResult string_totitle(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj args) {
   size_t argc = ops->list.length(interp, args);
@@ -171,69 +228,26 @@ static FeatherResult string_totitle(const FeatherHostOps *ops, FeatherInterp int
   FeatherObj strObj = ops->list.shift(interp, args);
   size_t len = ops->rune.length(interp, strObj);
 
-  // Default range is entire string
-  int64_t first = 0;
-  int64_t last = (int64_t)len - 1;
-
-  // Parse optional first/last arguments
-  if (argc >= 2) {
-    FeatherObj firstObj = ops->list.shift(interp, args);
-    if (feather_parse_index(ops, interp, firstObj, len, &first) != TCL_OK) {
-      return TCL_ERROR;
-    }
-  }
-  if (argc >= 3) {
-    FeatherObj lastObj = ops->list.shift(interp, args);
-    if (feather_parse_index(ops, interp, lastObj, len, &last) != TCL_OK) {
-      return TCL_ERROR;
-    }
-  }
-
-  // Handle empty string
-  if (len == 0) {
-    ops->interp.set_result(interp, strObj);
-    return TCL_OK;
+  int64_t first, last;
+  if (parse_case_range(ops, interp, args, len, &first, &last) != TCL_OK) {
+    return TCL_ERROR;
   }
 
-  // Clamp indices
-  if (first < 0) first = 0;
-  if (last >= (int64_t)len) last = (int64_t)len - 1;
-
-  // If range is invalid, return original string
-  if (first > last) {
+  // Empty string or empty range: return original string
+  if (len == 0 || first > last) {
     ops->interp.set_result(interp, strObj);
     return TCL_OK;
   }
 
-  // Build result: prefix + title-cased range + suffix
   // Title case = first char upper, rest lower
-  FeatherObj result = ops->string.intern(interp, "", 0);
-
-  // Add prefix (before first) unchanged
-  if (first > 0) {
-    FeatherObj prefix = ops->rune.range(interp, strObj, 0, first - 1);
-    result = ops->string.concat(interp, result, prefix);
-  }
-
-  // Add first character of range uppercased
   FeatherObj firstChar = ops->rune.at(interp, strObj, (size_t)first);
-  FeatherObj upperFirst = ops->rune.to_upper(interp, firstChar);
-  result = ops->string.concat(interp, result, upperFirst);
-
-  // Add rest of range lowercased
+  FeatherObj mid = ops->rune.to_upper(interp, firstChar);
   if (first < last) {
     FeatherObj restOfRange = ops->rune.range(interp, strObj, first + 1, last);
-    FeatherObj lowerRest = ops->rune.to_lower(interp, restOfRange);
-    result = ops->string.concat(interp, result, lowerRest);
-  }
-
-  // Add suffix (after last) unchanged
-  if (last < (int64_t)len - 1) {
-    FeatherObj suffix = ops->rune.range(interp, strObj, last + 1, len - 1);
-    result = ops->string.concat(interp, result, suffix);
+    mid = ops->string.concat(interp, mid, ops->rune.to_lower(interp, restOfRange));
   }
 
-  ops->interp.set_result(interp, result);
+  ops->interp.set_result(interp, replace_rune_range(ops, interp, strObj, len, first, last, mid));
   return TCL_OK;
 }
 
@@ -740,6 +754,13 @@ static FeatherResult string_insert(const FeatherHostOps *ops, FeatherInterp inte
   return TCL_OK;
 }
 
+// Class names for string is, in the order of StringIsClass.
+static const char *const string_is_classes[] = {
+  "alnum", "alpha", "ascii", "boolean", "control", "dict", "digit", "double",
+  "entier", "false", "graph", "integer", "list", "lower", "print", "punct",
+  "space", "true", "upper", "wideinteger", "wordchar", "xdigit", NULL
+};
+
 // Character class type for string is
 typedef enum {
   CLASS_ALNUM,
@@ -750,6 +771,7 @@ typedef enum {
   CLASS_DICT,
   CLASS_DIGIT,
   CLASS_DOUBLE,
+  CLASS_ENTIER,
   CLASS_FALSE,
   CLASS_GRAPH,
   CLASS_INTEGER,
@@ -760,35 +782,12 @@ typedef enum {
   CLASS_SPACE,
   CLASS_TRUE,
   CLASS_UPPER,
+  CLASS_WIDEINTEGER,
   CLASS_WORDCHAR,
-  CLASS_XDIGIT,
-  CLASS_UNKNOWN
+  CLASS_XDIGIT
 } StringIsClass;
 
-// Parse class name to enum
-static StringIsClass parse_class(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj classObj) {
-  if (feather_obj_eq_literal(ops, interp, classObj, "alnum")) return CLASS_ALNUM;
-  if (feather_obj_eq_literal(ops, interp, classObj, "alpha")) return CLASS_ALPHA;
-  if (feather_obj_eq_literal(ops, interp, classObj, "ascii")) return CLASS_ASCII;
-  if (feather_obj_eq_literal(ops, interp, classObj, "boolean")) return CLASS_BOOLEAN;
-  if (feather_obj_eq_literal(ops, interp, classObj, "control")) return CLASS_CONTROL;
-  if (feather_obj_eq_literal(ops, interp, classObj, "dict")) return CLASS_DICT;
-  if (feather_obj_eq_literal(ops, interp, classObj, "digit")) return CLASS_DIGIT;
-  if (feather_obj_eq_literal(ops, interp, classObj, "double")) return CLASS_DOUBLE;
-  if (feather_obj_eq_literal(ops, interp, classObj, "false")) return CLASS_FALSE;
-  if (feather_obj_eq_literal(ops, interp, classObj, "graph")) return CLASS_GRAPH;
-  if (feather_obj_eq_literal(ops, interp, classObj, "integer")) return CLASS_INTEGER;
-  if (feather_obj_eq_literal(ops, interp, classObj, "list")) return CLASS_LIST;
-  if (feather_obj_eq_literal(ops, interp, classObj, "lower")) return CLASS_LOWER;
-  if (feather_obj_eq_literal(ops, interp, classObj, "print")) return CLASS_PRINT;
-  if (feather_obj_eq_literal(ops, interp, classObj, "punct")) return CLASS_PUNCT;
-  if (feather_obj_eq_literal(ops, interp, classObj, "space")) return CLASS_SPACE;
-  if (feather_obj_eq_literal(ops, interp, classObj, "true")) return CLASS_TRUE;
-  if (feather_obj_eq_literal(ops, interp, classObj, "upper")) return CLASS_UPPER;
-  if (feather_obj_eq_literal(ops, interp, classObj, "wordchar")) return CLASS_WORDCHAR;
-  if (feather_obj_eq_literal(ops, interp, classObj, "xdigit")) return CLASS_XDIGIT;
-  return CLASS_UNKNOWN;
-}
+static const char *const string_is_options[] = {"-strict", "-failindex", NULL};
 
 // Map StringIsClass to FeatherCharClass for character testing
 static FeatherCharClass class_to_char_class(StringIsClass cls) {
@@ -819,22 +818,178 @@ static int is_char_class(StringIsClass cls) {
          cls == CLASS_XDIGIT;
 }
 
-// Check if a string is a valid true value
-static int is_true_value(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj str) {
-  if (feather_obj_eq_literal(ops, interp, str, "true")) return 1;
-  if (feather_obj_eq_literal(ops, interp, str, "yes")) return 1;
-  if (feather_obj_eq_literal(ops, interp, str, "on")) return 1;
-  if (feather_obj_eq_literal(ops, interp, str, "1")) return 1;
-  return 0;
+// Whitespace allowed around numbers
+static int is_number_space(int c) {
+  return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f';
 }
 
-// Check if a string is a valid false value
-static int is_false_value(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj str) {
-  if (feather_obj_eq_literal(ops, interp, str, "false")) return 1;
-  if (feather_obj_eq_literal(ops, interp, str, "no")) return 1;
-  if (feather_obj_eq_literal(ops, interp, str, "off")) return 1;
-  if (feather_obj_eq_literal(ops, interp, str, "0")) return 1;
-  return 0;
+// Digit value of c in the given base, or -1
+static int digit_in_base(int c, int base) {
+  int d = -1;
+  if (c >= '0' && c <= '9') d = c - '0';
+  else if (c >= 'a' && c <= 'z') d = c - 'a' + 10;
+  else if (c >= 'A' && c <= 'Z') d = c - 'A' + 10;
+  return d < base ? d : -1;
+}
+
+// Case-insensitive match of the bytes at pos against a lowercase literal
+static int match_word_nocase(const FeatherHostOps *ops, FeatherInterp interp,
+                             FeatherObj str, size_t pos, size_t len, const char *word) {
+  size_t n = feather_strlen(word);
+  if (pos + n > len) return 0;
+  for (size_t i = 0; i < n; i++) {
+    int c = ops->string.byte_at(interp, str, pos + i);
+    if (c >= 'A' && c <= 'Z') c += 'a' - 'A';
+    if (c != word[i]) return 0;
+  }
+  return 1;
+}
+
+// scan_number checks str against TCL's number syntax: optional surrounding
+// whitespace, an optional sign, and decimal digits or digits after a 0x, 0o,
+// 0b or 0d prefix. With allow_double, fractions, exponents, Inf and NaN are
+// accepted too.
+//
+// Returns -1 if the whole string is a number, otherwise the byte offset at
+// which scanning stopped: the end of the longest number prefix plus any
+// whitespace after it, or 0 if the string does not start with a number.
+static int64_t scan_number(const FeatherHostOps *ops, FeatherInterp interp,
+                           FeatherObj str, int allow_double) {
+  size_t len = ops->string.byte_length(interp, str);
+  size_t pos = 0;
+  while (pos < len && is_number_space(ops->string.byte_at(interp, str, pos))) pos++;
+
+  int c = ops->string.byte_at(interp, str, pos);
+  if (c == '+' || c == '-') pos++;
+
+  size_t end = 0; // end of the longest valid number, 0 if none
+  size_t digits = 0;
+  int base = 10;
+
+  c = ops->string.byte_at(interp, str, pos);
+  if (c == '0' && pos + 2 < len) {
+    int p = ops->string.byte_at(interp, str, pos + 1);
+    int prefixBase = 0;
+    if (p == 'x' || p == 'X') prefixBase = 16;
+    else if (p == 'o' || p == 'O') prefixBase = 8;
+    else if (p == 'b' || p == 'B') prefixBase = 2;
+    else if (p == 'd' || p == 'D') prefixBase = 10;
+    if (prefixBase && digit_in_base(ops->string.byte_at(interp, str, pos + 2), prefixBase) >= 0) {
+      base = prefixBase;
+      pos += 2;
+      allow_double = 0;
+    }
+  }
+
+  while (pos < len && digit_in_base(ops->string.byte_at(interp, str, pos), base) >= 0) {
+    pos++;
+    digits++;
+  }
+  if (digits > 0) end = pos;
+
+  if (allow_double) {
+    if (digits == 0 && (match_word_nocase(ops, interp, str, pos, len, "nan") ||
+                        match_word_nocase(ops, interp, str, pos, len, "inf"))) {
+      pos += 3;
+      if (match_word_nocase(ops, interp, str, pos, len, "inity")) pos += 5;
+      end = pos;
+    } else {
+      size_t frac = 0;
+      if (pos < len && ops->string.byte_at(interp, str, pos) == '.') {
+        size_t p = pos + 1;
+        while (p < len && digit_in_base(ops->string.byte_at(interp, str, p), 10) >= 0) {
+          p++;
+          frac++;
+        }
+        if (digits + frac > 0) {
+          pos = p;
+          end = pos;
+        }
+      }
+      c = ops->string.byte_at(interp, str, pos);
+      if (end > 0 && (c == 'e' || c == 'E')) {
+        size_t p = pos + 1;
+        c = ops->string.byte_at(interp, str, p);
+        if (c == '+' || c == '-') p++;
+        size_t exp = 0;
+        while (p < len && digit_in_base(ops->string.byte_at(interp, str, p), 10) >= 0) {
+          p++;
+          exp++;
+        }
+        if (exp > 0) end = p;
+      }
+    }
+  }
+
+  if (end == 0) return 0;
+  pos = end;
+  while (pos < len && is_number_space(ops->string.byte_at(interp, str, pos))) pos++;
+  return pos == len ? -1 : (int64_t)pos;
+}
+
+// Convert a byte offset in str to a character index
+static int64_t byte_to_char_index(const FeatherHostOps *ops, FeatherInterp interp,
+                                  FeatherObj str, int64_t offset) {
+  if (offset <= 0) return offset;
+  return (int64_t)ops->rune.length(interp, ops->string.slice(interp, str, 0, (size_t)offset));
+}
+
+// check_value_class tests str against a value class. Returns 1 if it
+// belongs, otherwise 0 with the character index of the failure in *failat
+// (-1 for integers that are well-formed but out of range).
+static int check_value_class(const FeatherHostOps *ops, FeatherInterp interp,
+                             StringIsClass cls, FeatherObj str, int64_t *failat) {
+  *failat = 0;
+  switch (cls) {
+    case CLASS_BOOLEAN:
+      return feather_parse_boolean(ops, interp, str) >= 0;
+    case CLASS_TRUE:
+      return feather_parse_boolean(ops, interp, str) == 1;
+    case CLASS_FALSE:
+      return feather_parse_boolean(ops, interp, str) == 0;
+    case CLASS_INTEGER:
+    case CLASS_WIDEINTEGER:
+    case CLASS_ENTIER: {
+      int64_t stop = scan_number(ops, interp, str, 0);
+      if (stop >= 0) {
+        *failat = byte_to_char_index(ops, interp, str, stop);
+        return 0;
+      }
+      if (cls == CLASS_ENTIER) return 1;
+      FeatherObj value;
+      int64_t dummy;
+      if (ops->bignum.get(interp, str, &value) != TCL_OK ||
+          ops->integer.get(interp, value, &dummy) != TCL_OK) {
+        *failat = -1;
+        return 0;
+      }
+      return 1;
+    }
+    case CLASS_DOUBLE: {
+      int64_t stop = scan_number(ops, interp, str, 1);
+      if (stop >= 0) {
+        *failat = byte_to_char_index(ops, interp, str, stop);
+        return 0;
+      }
+      return 1;
+    }
+    case CLASS_LIST:
+    case CLASS_DICT: {
+      int64_t stop = feather_list_error_index(ops, interp, str);
+      if (stop >= 0) {
+        *failat = byte_to_char_index(ops, interp, str, stop);
+        return 0;
+      }
+      if (cls == CLASS_DICT && ops->list.length(interp, ops->list.from(interp, str)) % 2 != 0) {
+        // The value of the last key is missing
+        *failat = (int64_t)ops->rune.length(interp, str);
+        return 0;
+      }
+      return 1;
+    }
+    default:
+      return 0;
+  }
 }
 
 // string is class ?-strict? ?-failindex varname? string
@@ -848,29 +1003,25 @@ static FeatherResult string_is(const FeatherHostOps *ops, FeatherInterp interp,
   }
 
   FeatherObj classObj = ops->list.shift(interp, args);
-  StringIsClass cls = parse_class(ops, interp, classObj);
-
-  if (cls == CLASS_UNKNOWN) {
-    FeatherObj msg = ops->string.intern(interp, "bad class \"", 11);
-    msg = ops->string.concat(interp, msg, classObj);
-    FeatherObj suffix = ops->string.intern(interp,
-      "\": must be alnum, alpha, ascii, boolean, control, dict, digit, double, false, graph, integer, list, lower, print, punct, space, true, upper, wordchar, or xdigit", 160);
-    msg = ops->string.concat(interp, msg, suffix);
-    ops->interp.set_result(interp, msg);
+  int classIndex;
+  if (ops->string.get_index(interp, classObj, string_is_classes, "class", 0, &classIndex) != TCL_OK) {
     return TCL_ERROR;
   }
+  StringIsClass cls = (StringIsClass)classIndex;
 
   int strict = 0;
   FeatherObj failindexVar = 0;
 
-  // Parse options
+  // Parse options; the last argument is always the string
   while (ops->list.length(interp, args) > 1) {
-    FeatherObj opt = ops->list.at(interp, args, 0);
-    if (feather_obj_eq_literal(ops, interp, opt, "-strict")) {
+    FeatherObj opt = ops->list.shift(interp, args);
+    int optIndex;
+    if (ops->string.get_index(interp, opt, string_is_options, "option", 0, &optIndex) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    if (optIndex == 0) {
       strict = 1;
-      ops->list.shift(interp, args);
-    } else if (feather_obj_eq_literal(ops, interp, opt, "-failindex")) {
-      ops->list.shift(interp, args);
+    } else {
       if (ops->list.length(interp, args) < 2) {
         FeatherObj msg = ops->string.intern(interp,
           "wrong # args: should be \"string is class ?-strict? ?-failindex var? str\"", 72);
@@ -878,102 +1029,40 @@ static FeatherResult string_is(const FeatherHostOps *ops, FeatherInterp interp,
         return TCL_ERROR;
       }
       failindexVar = ops->list.shift(interp, args);
-    } else {
-      break;
     }
   }
 
-  if (ops->list.length(interp, args) != 1) {
-    FeatherObj msg = ops->string.intern(interp,
-      "wrong # args: should be \"string is class ?-strict? ?-failindex var? str\"", 72);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
-  }
-
   FeatherObj str = ops->list.shift(interp, args);
   size_t len = ops->rune.length(interp, str);
+  int result = 1;
+  int64_t failat = 0;
 
-  // Handle value classes first
-  if (!is_char_class(cls)) {
-    int result = 0;
-
-    switch (cls) {
-      case CLASS_BOOLEAN:
-        result = is_true_value(ops, interp, str) || is_false_value(ops, interp, str);
-        break;
-      case CLASS_TRUE:
-        result = is_true_value(ops, interp, str);
-        break;
-      case CLASS_FALSE:
-        result = is_false_value(ops, interp, str);
-        break;
-      case CLASS_INTEGER: {
-        int64_t dummy;
-        result = (ops->integer.get(interp, str, &dummy) == TCL_OK);
-        break;
-      }
-      case CLASS_DOUBLE: {
-        double dummy;
-        result = (ops->dbl.get(interp, str, &dummy) == TCL_OK);
-        break;
-      }
-      case CLASS_LIST: {
-        FeatherObj listObj = ops->list.from(interp, str);
-        result = (listObj != 0);
-        break;
-      }
-      case CLASS_DICT: {
-        FeatherObj dictObj = ops->dict.from(interp, str);
-        result = (dictObj != 0);
-        break;
-      }
-      default:
-        result = 0;
-    }
-
+  if (len == 0) {
+    // Empty string: true unless -strict; lists and dicts may always be empty
+    result = !strict || cls == CLASS_LIST || cls == CLASS_DICT;
+  } else if (!is_char_class(cls)) {
+    result = check_value_class(ops, interp, cls, str, &failat);
     // Clear any error set during value parsing
     ops->interp.reset_result(interp, ops->string.intern(interp, "", 0));
-
-    if (failindexVar && !result) {
-      // For value classes, failindex is set to the end of string
-      if (feather_set_var(ops, interp, failindexVar, ops->integer.create(interp, 0)) != TCL_OK) {
-        return TCL_ERROR;
-      }
-    }
-
-    ops->interp.set_result(interp, ops->integer.create(interp, result ? 1 : 0));
-    return TCL_OK;
-  }
-
-  // Handle character classes
-  // Empty string: true unless -strict
-  if (len == 0) {
-    if (failindexVar && strict) {
-      if (feather_set_var(ops, interp, failindexVar, ops->integer.create(interp, 0)) != TCL_OK) {
-        return TCL_ERROR;
+  } else {
+    FeatherCharClass charClass = class_to_char_class(cls);
+    for (size_t i = 0; i < len; i++) {
+      FeatherObj ch = ops->rune.at(interp, str, i);
+      if (!ops->rune.is_class(interp, ch, charClass)) {
+        result = 0;
+        failat = (int64_t)i;
+        break;
       }
     }
-    ops->interp.set_result(interp, ops->integer.create(interp, strict ? 0 : 1));
-    return TCL_OK;
   }
 
-  FeatherCharClass charClass = class_to_char_class(cls);
-
-  // Check each character
-  for (size_t i = 0; i < len; i++) {
-    FeatherObj ch = ops->rune.at(interp, str, i);
-    if (!ops->rune.is_class(interp, ch, charClass)) {
-      if (failindexVar) {
-        if (feather_set_var(ops, interp, failindexVar, ops->integer.create(interp, (int64_t)i)) != TCL_OK) {
-          return TCL_ERROR;
-        }
-      }
-      ops->interp.set_result(interp, ops->integer.create(interp, 0));
-      return TCL_OK;
+  if (failindexVar && !result) {
+    if (feather_set_var(ops, interp, failindexVar, ops->integer.create(interp, failat)) != TCL_OK) {
+      return TCL_ERROR;
     }
   }
 
-  ops->interp.set_result(interp, ops->integer.create(interp, 1));
+  ops->interp.set_result(interp, ops->integer.create(interp, result));
   return TCL_OK;
 }
 
@@ -1006,8 +1095,9 @@ static FeatherResult string_replace(const FeatherHostOps *ops, FeatherInterp int
     return TCL_ERROR;
   }
 
-  // Handle out of bounds - if first > last or first >= len, return original
-  if (first > last || first >= (int64_t)len) {
+  // Handle out of bounds - if first > last, first >= len or last < 0,
+  // return original
+  if (first > last || first >= (int64_t)len || last < 0) {
     ops->interp.set_result(interp, str);
     return TCL_OK;
   }
@@ -1188,7 +1278,9 @@ void feather_register_string_usage(const FeatherHostOps *ops, FeatherInterp inte
     "the class was no longer valid will be stored in the variable named varname.\n\n"
     "Character classes: alnum, alpha, ascii, control, digit, graph, lower, print, "
     "punct, space, upper, wordchar, xdigit.\n\n"
-    "Value classes: boolean, true, false, integer, double, list, dict.");
+    "Value classes: boolean, true, false, integer, wideinteger, entier, double, "
+    "list, dict. integer and wideinteger accept 64-bit values; entier accepts "
+    "integers of any size.");
   spec = feather_usage_add(ops, interp, spec, e);
 
   // --- Subcommand: last ---
@@ -1348,9 +1440,11 @@ void feather_register_string_usage(const FeatherHostOps *ops, FeatherInterp inte
   e = feather_usage_long_help(ops, interp, e,
     "Returns a value equal to string except that all upper (or title) case "
     "letters have been converted to lower case.\n\n"
-    "Note: In Feather, the optional first and last arguments are accepted for "
-    "compatibility but are currently ignored. The entire string is always "
-    "converted.");
+    "If first is specified, it refers to the first char index in the string to "
+    "start modifying. If last is specified, it refers to the char index in the "
+    "string to stop at (inclusive). If only first is given, only that character "
+    "is converted. first and last may be specified using the forms described "
+    "in STRING INDICES.");
   spec = feather_usage_add(ops, interp, spec, e);
 
   // --- Subcommand: totitle ---
@@ -1387,9 +1481,11 @@ void feather_register_string_usage(const FeatherHostOps *ops, FeatherInterp inte
   e = feather_usage_long_help(ops, interp, e,
     "Returns a value equal to string except that all lower (or title) case "
     "letters have been converted to upper case.\n\n"
-    "Note: In Feather, the optional first and last arguments are accepted for "
-    "compatibility but are currently ignored. The entire string is always "
-    "converted.");
+    "If first is specified, it refers to the first char index in the string to "
+    "start modifying. If last is specified, it refers to the char index in the "
+    "string to stop at (inclusive). If only first is given, only that character "
+    "is converted. first and last may be specified using the forms described "
+    "in STRING INDICES.");
   spec = feather_usage_add(ops, interp, spec, e);
 
   // --- Subcommand: trim ---
@@ -1453,6 +1549,21 @@ void feather_register_string_usage(const FeatherHostOps *ops, FeatherInterp inte
   feather_usage_register(ops, interp, "string", spec);
 }
 
+// Subcommand names in the order of StringSubcommand, for string.get_index.
+static const char *const string_subcommands[] = {
+  "cat", "compare", "equal", "first", "index", "insert", "is", "last",
+  "length", "map", "match", "range", "repeat", "replace", "reverse", "tolower",
+  "totitle", "toupper", "trim", "trimleft", "trimright", NULL
+};
+
+typedef enum {
+  STRING_CAT, STRING_COMPARE, STRING_EQUAL, STRING_FIRST, STRING_INDEX,
+  STRING_INSERT, STRING_IS, STRING_LAST, STRING_LENGTH, STRING_MAP,
+  STRING_MATCH, STRING_RANGE, STRING_REPEAT, STRING_REPLACE, STRING_REVERSE,
+  STRING_TOLOWER, STRING_TOTITLE, STRING_TOUPPER, STRING_TRIM, STRING_TRIMLEFT,
+  STRING_TRIMRIGHT
+} StringSubcommand;
+
 FeatherResult feather_builtin_string(const FeatherHostOps *ops, FeatherInterp interp,
                               FeatherObj cmd, FeatherObj args) {
   (void)cmd;
@@ -1466,56 +1577,39 @@ FeatherResult feather_builtin_string(const FeatherHostOps *ops, FeatherInterp in
   }
 
   FeatherObj subcmd = ops->list.shift(interp, args);
-
-  if (feather_obj_eq_literal(ops, interp, subcmd, "length")) {
-    return string_length(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "index")) {
-    return string_index(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "range")) {
-    return string_range(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "match")) {
-    return string_match(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "toupper")) {
-    return string_toupper(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "tolower")) {
-    return string_tolower(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "totitle")) {
-    return string_totitle(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "trim")) {
-    return string_trim(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "trimleft")) {
-    return string_trimleft(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "trimright")) {
-    return string_trimright(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "map")) {
-    return string_map(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "cat")) {
-    return string_cat(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "compare")) {
-    return string_compare(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "equal")) {
-    return string_equal(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "first")) {
-    return string_first(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "last")) {
-    return string_last(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "repeat")) {
-    return string_repeat(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "reverse")) {
-    return string_reverse(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "insert")) {
-    return string_insert(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "is")) {
-    return string_is(ops, interp, args);
-  } else if (feather_obj_eq_literal(ops, interp, subcmd, "replace")) {
-    return string_replace(ops, interp, args);
-  } else {
-    FeatherObj msg = ops->string.intern(interp, "unknown or ambiguous subcommand \"", 33);
-    msg = ops->string.concat(interp, msg, subcmd);
-    FeatherObj suffix = ops->string.intern(interp,
-      "\": must be cat, compare, equal, first, index, insert, is, last, length, map, match, range, repeat, replace, reverse, tolower, totitle, toupper, trim, trimleft, or trimright", 172);
-    msg = ops->string.concat(interp, msg, suffix);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
+  int index;
+
+  if (ops->string.get_index(interp, subcmd, string_subcommands, NULL, 0, &index) == TCL_OK) {
+    switch ((StringSubcommand)index) {
+    case STRING_CAT: return string_cat(ops, interp, args);
+    case STRING_COMPARE: return string_compare(ops, interp, args);
+    case STRING_EQUAL: return string_equal(ops, interp, args);
+    case STRING_FIRST: return string_first(ops, interp, args);
+    case STRING_INDEX: return string_index(ops, interp, args);
+    case STRING_INSERT: return string_insert(ops, interp, args);
+    case STRING_IS: return string_is(ops, interp, args);
+    case STRING_LAST: return string_last(ops, interp, args);
+    case STRING_LENGTH: return string_length(ops, interp, args);
+    case STRING_MAP: return string_map(ops, interp, args);
+    case STRING_MATCH: return string_match(ops, interp, args);
+    case STRING_RANGE: return string_range(ops, interp, args);
+    case STRING_REPEAT: return string_repeat(ops, interp, args);
+    case STRING_REPLACE: return string_replace(ops, interp, args);
+    case STRING_REVERSE: return string_reverse(ops, interp, args);
+    case STRING_TOLOWER: return string_tolower(ops, interp, args);
+    case STRING_TOTITLE: return string_totitle(ops, interp, args);
+    case STRING_TOUPPER: return string_toupper(ops, interp, args);
+    case STRING_TRIM: return string_trim(ops, interp, args);
+    case STRING_TRIMLEFT: return string_trimleft(ops, interp, args);
+    case STRING_TRIMRIGHT: return string_trimright(ops, interp, args);
+    }
   }
+
+  FeatherObj msg = ops->string.intern(interp, "unknown or ambiguous subcommand \"", 33);
+  msg = ops->string.concat(interp, msg, subcmd);
+  FeatherObj suffix = ops->string.intern(interp,
+    "\": must be cat, compare, equal, first, index, insert, is, last, length, map, match, range, repeat, replace, reverse, tolower, totitle, toupper, trim, trimleft, or trimright", 172);
+  msg = ops->string.concat(interp, msg, suffix);
+  ops->interp.set_result(interp, msg);
+  return TCL_ERROR;
 }
@@ -4,6 +4,17 @@
 #include "charclass.h"
 #include "unicode.h"
 
+// Option names in the order of SubstOption, for string.get_index.
+static const char *const subst_options[] = {
+  "-nobackslashes", "-nocommands", "-novariables", NULL
+};
+
+typedef enum {
+  SUBST_NOBACKSLASHES,
+  SUBST_NOCOMMANDS,
+  SUBST_NOVARIABLES
+} SubstOption;
+
 static FeatherObj append_literal(const FeatherHostOps *ops, FeatherInterp interp,
                                   FeatherObj result, const char *s, size_t len) {
   if (len == 0) return result;
@@ -35,29 +46,6 @@ static FeatherObj append_obj(const FeatherHostOps *ops, FeatherInterp interp,
   return ops->string.concat(interp, result, obj);
 }
 
-static int option_equals(const FeatherHostOps *ops, FeatherInterp interp,
-                          FeatherObj opt, const char *lit) {
-  size_t len = 0;
-  while (lit[len]) len++;
-  FeatherObj lit_obj = ops->string.intern(interp, lit, len);
-  return ops->string.equal(interp, opt, lit_obj);
-}
-
-static FeatherObj build_bad_option_error(const FeatherHostOps *ops, FeatherInterp interp,
-                                          FeatherObj opt) {
-  FeatherObj builder = ops->string.builder_new(interp, 128);
-  const char *prefix = "bad option \"";
-  for (size_t i = 0; prefix[i]; i++) {
-    ops->string.builder_append_byte(interp, builder, prefix[i]);
-  }
-  ops->string.builder_append_obj(interp, builder, opt);
-  const char *suffix = "\": must be -nobackslashes, -nocommands, or -novariables";
-  for (size_t i = 0; suffix[i]; i++) {
-    ops->string.builder_append_byte(interp, builder, suffix[i]);
-  }
-  return ops->string.builder_finish(interp, builder);
-}
-
 static FeatherObj build_no_such_variable_error(const FeatherHostOps *ops, FeatherInterp interp,
                                                 FeatherObj name) {
   FeatherObj builder = ops->string.builder_new(interp, 128);
@@ -239,38 +227,36 @@ FeatherResult feather_builtin_subst(const FeatherHostOps *ops, FeatherInterp int
     return TCL_ERROR;
   }
 
+  // Every argument but the last is an option
   int flags = TCL_SUBST_ALL;
-  size_t i = 0;
-
-  while (i < argc - 1) {
-    FeatherObj opt = ops->list.at(interp, args, i);
-
-    int first_byte = ops->string.byte_at(interp, opt, 0);
-    if (first_byte == '-') {
-      if (option_equals(ops, interp, opt, "-nobackslashes")) {
+  for (size_t i = 0; i + 1 < argc; i++) {
+    int opt;
+    if (ops->string.get_index(interp, ops->list.at(interp, args, i), subst_options,
+                              "option", 0, &opt) != TCL_OK) {
+      return TCL_ERROR;
+    }
+    switch ((SubstOption)opt) {
+      case SUBST_NOBACKSLASHES:
         flags &= ~TCL_SUBST_BACKSLASHES;
-      } else if (option_equals(ops, interp, opt, "-nocommands")) {
+        break;
+      case SUBST_NOCOMMANDS:
         flags &= ~TCL_SUBST_COMMANDS;
-      } else if (option_equals(ops, interp, opt, "-novariables")) {
+        break;
+      case SUBST_NOVARIABLES:
         flags &= ~TCL_SUBST_VARIABLES;
-      } else {
-        ops->interp.set_result(interp, build_bad_option_error(ops, interp, opt));
-        return TCL_ERROR;
-      }
-      i++;
-    } else {
-      break;
+        break;
     }
   }
 
-  if (i != argc - 1) {
-    FeatherObj msg = ops->string.intern(interp,
-      "wrong # args: should be \"subst ?-nobackslashes? ?-nocommands? ?-novariables? string\"", 84);
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
-  }
+  return feather_subst_string(ops, interp, ops->list.at(interp, args, argc - 1), flags);
+}
 
-  FeatherObj str_obj = ops->list.at(interp, args, i);
+/**
+ * feather_subst_string performs substitutions on str as the subst command does.
+ */
+FeatherResult feather_subst_string(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj str_obj, int flags) {
+  ops = feather_get_ops(ops);
   size_t len = ops->string.byte_length(interp, str_obj);
 
   size_t pos = 0;
@@ -322,7 +308,9 @@ FeatherResult feather_builtin_subst(const FeatherHostOps *ops, FeatherInterp int
         FeatherObj name = ops->string.slice(interp, str_obj, name_start, pos);
         pos++;
         FeatherObj value;
-        feather_get_var(ops, interp, name, &value);
+        if (feather_get_var(ops, interp, name, &value) != TCL_OK) {
+          return TCL_ERROR;
+        }
         if (ops->list.is_nil(interp, value)) {
           ops->interp.set_result(interp, build_no_such_variable_error(ops, interp, name));
           return TCL_ERROR;
@@ -330,7 +318,8 @@ FeatherResult feather_builtin_subst(const FeatherHostOps *ops, FeatherInterp int
         result = append_obj(ops, interp, result, value);
         seg_start = pos;
 
-      } else if (feather_is_varname_char(c)) {
+      } else if (feather_is_varname_char(c) ||
+                 (c == ':' && pos + 1 < len && ops->string.byte_at(interp, str_obj, pos + 1) == ':')) {
         size_t name_start = pos;
         while (pos < len) {
           int ch = ops->string.byte_at(interp, str_obj, pos);
@@ -342,57 +331,52 @@ FeatherResult feather_builtin_subst(const FeatherHostOps *ops, FeatherInterp int
             break;
           }
         }
+        FeatherObj name = ops->string.slice(interp, str_obj, name_start, pos);
 
-        c = (pos < len) ? ops->string.byte_at(interp, str_obj, pos) : -1;
-
-        if (c == '(') {
-          pos++;
-          size_t idx_start = pos;
-          int paren_depth = 1;
-          while (pos < len && paren_depth > 0) {
+        if (pos < len && ops->string.byte_at(interp, str_obj, pos) == '(') {
+          // The index ends at the first ) outside a command substitution.
+          // TCL runs commands in it even with -nocommands; feather honours
+          // the flags here too, so that no command ever runs without them
+          size_t idx_start = ++pos;
+          while (pos < len) {
             int ch = ops->string.byte_at(interp, str_obj, pos);
-            if (ch == '(') paren_depth++;
-            else if (ch == ')') paren_depth--;
-            if (paren_depth > 0) pos++;
+            if (ch == ')') break;
+            if (ch == '[') {
+              pos = find_close_bracket_obj(ops, interp, str_obj, pos + 1, len);
+            } else if (ch == '\\' && pos + 1 < len) {
+              pos++;
+            }
+            if (pos < len) pos++;
           }
-          size_t idx_end = pos;
-          if (pos < len) pos++;
-
-          // Build full array name with substituted index
-          FeatherObj name_part = ops->string.slice(interp, str_obj, name_start, idx_start - 1);
-          FeatherObj idx_part = ops->string.slice(interp, str_obj, idx_start, idx_end);
-
-          if (flags & TCL_SUBST_COMMANDS) {
-            FeatherResult subst_res = feather_subst_obj(ops, interp, idx_part, TCL_SUBST_ALL);
-            if (subst_res != TCL_OK) return TCL_ERROR;
-            idx_part = ops->interp.get_result(interp);
+          if (pos >= len) {
+            ops->interp.set_result(interp, ops->string.intern(interp, "missing )", 9));
+            return TCL_ERROR;
+          }
+          FeatherObj idx_part = ops->string.slice(interp, str_obj, idx_start, pos);
+          pos++;
+          if (feather_subst_obj(ops, interp, idx_part, flags) != TCL_OK) {
+            return TCL_ERROR;
           }
+          idx_part = ops->interp.get_result(interp);
 
           // Build full name: name(idx)
           FeatherObj builder = ops->string.builder_new(interp, 64);
-          ops->string.builder_append_obj(interp, builder, name_part);
+          ops->string.builder_append_obj(interp, builder, name);
           ops->string.builder_append_byte(interp, builder, '(');
           ops->string.builder_append_obj(interp, builder, idx_part);
           ops->string.builder_append_byte(interp, builder, ')');
-          FeatherObj full_name = ops->string.builder_finish(interp, builder);
+          name = ops->string.builder_finish(interp, builder);
+        }
 
-          FeatherObj value;
-          feather_get_var(ops, interp, full_name, &value);
-          if (ops->list.is_nil(interp, value)) {
-            ops->interp.set_result(interp, build_no_such_variable_error(ops, interp, full_name));
-            return TCL_ERROR;
-          }
-          result = append_obj(ops, interp, result, value);
-        } else {
-          FeatherObj name = ops->string.slice(interp, str_obj, name_start, pos);
-          FeatherObj value;
-          feather_get_var(ops, interp, name, &value);
-          if (ops->list.is_nil(interp, value)) {
-            ops->interp.set_result(interp, build_no_such_variable_error(ops, interp, name));
-            return TCL_ERROR;
-          }
-          result = append_obj(ops, interp, result, value);
+        FeatherObj value;
+        if (feather_get_var(ops, interp, name, &value) != TCL_OK) {
+          return TCL_ERROR;
+        }
+        if (ops->list.is_nil(interp, value)) {
+          ops->interp.set_result(interp, build_no_such_variable_error(ops, interp, name));
+          return TCL_ERROR;
         }
+        result = append_obj(ops, interp, result, value);
         seg_start = pos;
       } else {
         result = append_literal(ops, interp, result, "$", 1);
@@ -486,7 +470,9 @@ void feather_register_subst_usage(const FeatherHostOps *ops, FeatherInterp inter
     "the returned value is substituted.\n\n"
     "Variable substitution replaces variable references ($varName, ${varName}, or "
     "$varName(index)) with their values. Note: Feather does not support TCL-style arrays; "
-    "the array-style syntax is parsed but you must define scalar variables with parenthesized names.\n\n"
+    "the array-style syntax is parsed but you must define scalar variables with parenthesized names. "
+    "The index is substituted according to the same switches, so with -nocommands no "
+    "command runs, not even one inside an index.\n\n"
     "The optional switches control which substitutions are performed. Note that the "
     "substitution of one kind can include substitution of other kinds. For example, "
     "even when -novariables is specified, command substitution is performed without "
@@ -62,52 +62,27 @@ FeatherResult feather_builtin_tailcall(const FeatherHostOps *ops, FeatherInterp
     return TCL_ERROR;
   }
 
-  // Check we're inside a proc (level > 0)
+  // Check we're inside a proc (level > 0), and in its own frame rather
+  // than one uplevel made active, whose invocation would not see the call
   size_t level = ops->frame.level(interp);
-  if (level == 0) {
+  if (level == 0 || level + 1 != ops->frame.size(interp)) {
     FeatherObj msg = ops->string.intern(interp,
         "tailcall can only be called from a proc or lambda", 49);
     ops->interp.set_result(interp, msg);
     return TCL_ERROR;
   }
 
-  // Build the command to execute
-  // The args list is already [cmdName, arg1, arg2, ...]
-  FeatherObj tailCmd = args;
-
-  // Save the current namespace BEFORE popping the frame
-  // TCL specifies: "will be looked up in the current namespace context, not in the caller's"
-  FeatherObj savedNs = ops->ns.current(interp);
-
-  // Pop current frame - this makes the caller's frame active
-  ops->frame.pop(interp);
-
-  // Temporarily set namespace to the original proc's namespace for command lookup
-  FeatherObj callerNs = ops->ns.current(interp);
-  ops->frame.set_namespace(interp, savedNs);
-
-  // Execute the command with the original namespace context
-  // The command is already a list: [cmdName, arg1, arg2, ...]
-  FeatherResult rc = feather_command_exec(ops, interp, tailCmd, TCL_EVAL_LOCAL);
-
-  // Restore the caller's namespace
-  ops->frame.set_namespace(interp, callerNs);
-
-  // If command succeeded, return TCL_RETURN to stop body evaluation.
-  // If command failed, propagate the error.
-  // Note: feather_invoke_proc will try to pop the frame again, but since
-  // we already popped it, frame.pop will return TCL_ERROR (no-op).
-  if (rc == TCL_OK) {
-    // Set up return options so feather_invoke_proc processes this correctly
-    // With -code 0 -level 1, when level decrements to 0, code becomes TCL_OK
-    FeatherObj opts = ops->list.create(interp);
-    opts = ops->list.push(interp, opts, ops->string.intern(interp, "-code", 5));
-    opts = ops->list.push(interp, opts, ops->integer.create(interp, TCL_OK));
-    opts = ops->list.push(interp, opts, ops->string.intern(interp, "-level", 6));
-    opts = ops->list.push(interp, opts, ops->integer.create(interp, 1));
-    ops->interp.set_return_options(interp, opts);
-    return TCL_RETURN;
-  }
-
-  return rc;
+  // The command is looked up in the namespace of the proc, not the
+  // caller's. It runs once the proc has returned and its frame is gone,
+  // so that a chain of tail calls needs neither frames nor C stack.
+  feather_tailcall_request(ops, interp, ops->list.from(interp, args), ops->ns.current(interp), level);
+
+  // Return from the proc; feather_tailcall_end turns this into TCL_OK.
+  FeatherObj opts = ops->list.create(interp);
+  opts = ops->list.push(interp, opts, ops->string.intern(interp, "-code", 5));
+  opts = ops->list.push(interp, opts, ops->integer.create(interp, TCL_OK));
+  opts = ops->list.push(interp, opts, ops->string.intern(interp, "-level", 6));
+  opts = ops->list.push(interp, opts, ops->integer.create(interp, 1));
+  ops->interp.set_return_options(interp, opts);
+  return TCL_RETURN;
 }
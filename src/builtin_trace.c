@@ -160,8 +160,15 @@ static FeatherResult trace_add(const FeatherHostOps *ops, FeatherInterp interp,
     opsString = ops->string.concat(interp, opsString, ops->list.at(interp, opsList, i));
   }
 
-  // For command and execution traces, normalize the name to fully qualified form
+  // Variable traces are kept under the name of the variable they resolve to,
+  // following links and qualifying namespace variables, so that they apply
+  // however the variable is named
   FeatherObj traceName = name;
+  if (feather_obj_eq_literal(ops, interp, kind, "variable")) {
+    traceName = ops->var.resolve_link(interp, name);
+  }
+
+  // For command and execution traces, normalize the name to fully qualified form
   if (feather_obj_eq_literal(ops, interp, kind, "command") || feather_obj_eq_literal(ops, interp, kind, "execution")) {
     // If unqualified, prepend ::
     if (!feather_obj_is_qualified(ops, interp, name)) {
@@ -200,6 +207,7 @@ static FeatherResult trace_add(const FeatherHostOps *ops, FeatherInterp interp,
 
   // Store updated dict back to namespace
   feather_trace_set_dict(ops, interp, kindStr, traceDict);
+  feather_traces_count(ops, interp, kindStr, 1);
 
   ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
   return TCL_OK;
@@ -250,8 +258,15 @@ static FeatherResult trace_remove(const FeatherHostOps *ops, FeatherInterp inter
     }
   }
 
-  // For command and execution traces, normalize the name to fully qualified form
+  // Variable traces are kept under the name of the variable they resolve to,
+  // following links and qualifying namespace variables, so that they apply
+  // however the variable is named
   FeatherObj traceName = name;
+  if (feather_obj_eq_literal(ops, interp, kind, "variable")) {
+    traceName = ops->var.resolve_link(interp, name);
+  }
+
+  // For command and execution traces, normalize the name to fully qualified form
   if (feather_obj_eq_literal(ops, interp, kind, "command") || feather_obj_eq_literal(ops, interp, kind, "execution")) {
     if (!feather_obj_is_qualified(ops, interp, name)) {
       traceName = ops->string.intern(interp, "::", 2);
@@ -301,6 +316,7 @@ static FeatherResult trace_remove(const FeatherHostOps *ops, FeatherInterp inter
 
   // Store updated dict back to namespace
   feather_trace_set_dict(ops, interp, kindStr, traceDict);
+  feather_traces_count(ops, interp, kindStr, -(int)(traceCount - ops->list.length(interp, newTraces)));
 
   ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
   return TCL_OK;
@@ -337,8 +353,15 @@ static FeatherResult trace_info(const FeatherHostOps *ops, FeatherInterp interp,
     return TCL_ERROR;
   }
 
-  // For command and execution traces, normalize the name to fully qualified form
+  // Variable traces are kept under the name of the variable they resolve to,
+  // following links and qualifying namespace variables, so that they apply
+  // however the variable is named
   FeatherObj traceName = name;
+  if (feather_obj_eq_literal(ops, interp, kind, "variable")) {
+    traceName = ops->var.resolve_link(interp, name);
+  }
+
+  // For command and execution traces, normalize the name to fully qualified form
   if (feather_obj_eq_literal(ops, interp, kind, "command") || feather_obj_eq_literal(ops, interp, kind, "execution")) {
     if (!feather_obj_is_qualified(ops, interp, name)) {
       traceName = ops->string.intern(interp, "::", 2);
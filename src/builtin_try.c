@@ -5,6 +5,15 @@
 // Helper macro
 #define S(lit) (lit), feather_strlen(lit)
 
+// Handler types in the order of TryHandler, for string.get_index.
+static const char *const try_handlers[] = {"finally", "on", "trap", NULL};
+
+typedef enum {
+  TRY_FINALLY,
+  TRY_ON,
+  TRY_TRAP
+} TryHandler;
+
 // Parse return code from string: ok=0, error=1, return=2, break=3, continue=4
 // Returns -1 if invalid
 static int try_parse_code(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj codeObj) {
@@ -88,7 +97,7 @@ static void add_during_to_options(const FeatherHostOps *ops, FeatherInterp inter
   FeatherObj newOpts = ops->list.create(interp);
 
   // Copy existing options if any
-  if (!ops->list.is_nil(interp, currentOpts)) {
+  if (ops->list.length(interp, currentOpts) > 0) {
     FeatherObj optsCopy = ops->list.from(interp, currentOpts);
     size_t optsLen = ops->list.length(interp, optsCopy);
     for (size_t i = 0; i < optsLen; i++) {
@@ -116,357 +125,193 @@ static void add_during_to_options(const FeatherHostOps *ops, FeatherInterp inter
   // Set the modified options
   ops->interp.set_return_options(interp, newOpts);
 }
+// Set the error message for a malformed clause and return TCL_ERROR
+static FeatherResult try_clause_error(const FeatherHostOps *ops, FeatherInterp interp,
+                                      const char *msg) {
+  ops->interp.set_result(interp, ops->string.intern(interp, msg, feather_strlen(msg)));
+  return TCL_ERROR;
+}
 
-FeatherResult feather_builtin_try(const FeatherHostOps *ops, FeatherInterp interp,
-                           FeatherObj cmd, FeatherObj args) {
-  (void)cmd;
-
+// Check every handler and the finally clause before the body runs, as TCL
+// does, so that a malformed try never runs its body. Stores the finally
+// script, or 0, in *finallyScript, and where the handlers end in *handlerEnd.
+static FeatherResult try_check_clauses(const FeatherHostOps *ops, FeatherInterp interp,
+                                       FeatherObj args, FeatherObj *finallyScript,
+                                       size_t *handlerEnd) {
   size_t argc = ops->list.length(interp, args);
+  FeatherObj lastScript = 0;
+  *finallyScript = 0;
+  *handlerEnd = argc;
 
-  // try body ?handler...? ?finally script?
-  if (argc < 1) {
-    FeatherObj msg = ops->string.intern(
-        interp, S("wrong # args: should be \"try body ?handler ...? ?finally script?\""));
-    ops->interp.set_result(interp, msg);
-    return TCL_ERROR;
-  }
-
-  // Get the body
-  FeatherObj body = ops->list.at(interp, args, 0);
+  size_t i = 1;
+  while (i < argc) {
+    int type;
+    if (ops->string.get_index(interp, ops->list.at(interp, args, i), try_handlers,
+                              "handler type", 0, &type) != TCL_OK) {
+      return TCL_ERROR;
+    }
 
-  // Parse handlers and finally clause
-  // First, find the finally clause if present
-  FeatherObj finallyScript = 0; // nil
-  size_t handlerEnd = argc;
-
-  // Check for finally at the end
-  if (argc >= 2) {
-    FeatherObj lastArg = ops->list.at(interp, args, argc - 1);
-
-    // Check if second-to-last is "finally"
-    if (argc >= 3) {
-      FeatherObj secondLast = ops->list.at(interp, args, argc - 2);
-      if (feather_obj_eq_literal(ops, interp, secondLast, "finally")) {
-        finallyScript = lastArg;
-        handlerEnd = argc - 2;
+    if ((TryHandler)type == TRY_FINALLY) {
+      if (i < argc - 2) {
+        return try_clause_error(ops, interp, "finally clause must be last");
+      }
+      if (i == argc - 1) {
+        return try_clause_error(ops, interp,
+          "wrong # args to finally clause: must be \"... finally script\"");
       }
+      *finallyScript = ops->list.at(interp, args, i + 1);
+      *handlerEnd = i;
+      break;
     }
 
-    // Check if last arg itself is "finally" (missing script)
-    if (feather_obj_eq_literal(ops, interp, lastArg, "finally") && finallyScript == 0) {
-      FeatherObj msg = ops->string.intern(
-          interp, S("wrong # args to finally clause: must be \"finally script\""));
-      ops->interp.set_result(interp, msg);
-      return TCL_ERROR;
+    if (i + 4 > argc) {
+      return try_clause_error(ops, interp, (TryHandler)type == TRY_ON
+        ? "wrong # args to on clause: must be \"... on code variableList script\""
+        : "wrong # args to trap clause: must be \"... trap pattern variableList script\"");
     }
-  }
 
-  // Evaluate the body
-  FeatherResult bodyCode = feather_script_eval_obj(ops, interp, body, TCL_EVAL_LOCAL);
-  FeatherObj bodyResult = ops->interp.get_result(interp);
-  FeatherObj bodyOptions = ops->interp.get_return_options(interp, bodyCode);
-
-  // Handle TCL_RETURN specially
-  FeatherResult effectiveCode = bodyCode;
-  if (bodyCode == TCL_RETURN) {
-    // Parse -code and -level from options
-    int returnCode = TCL_OK;
-    int level = 1;
-
-    if (!ops->list.is_nil(interp, bodyOptions)) {
-      FeatherObj optsCopy = ops->list.from(interp, bodyOptions);
-      size_t optsLen = ops->list.length(interp, optsCopy);
-
-      for (size_t i = 0; i + 1 < optsLen; i += 2) {
-        FeatherObj key = ops->list.at(interp, optsCopy, i);
-        FeatherObj val = ops->list.at(interp, optsCopy, i + 1);
-
-        if (feather_obj_eq_literal(ops, interp, key, "-code")) {
-          int64_t intVal;
-          if (ops->integer.get(interp, val, &intVal) == TCL_OK) {
-            returnCode = (int)intVal;
-          }
-        } else if (feather_obj_eq_literal(ops, interp, key, "-level")) {
-          int64_t intVal;
-          if (ops->integer.get(interp, val, &intVal) == TCL_OK) {
-            level = (int)intVal;
-          }
-        }
+    FeatherObj selector = ops->list.at(interp, args, i + 1);
+    if ((TryHandler)type == TRY_ON) {
+      if (try_parse_code(ops, interp, selector) < 0) {
+        FeatherObj msg = ops->string.intern(interp, S("bad completion code \""));
+        msg = ops->string.concat(interp, msg, selector);
+        msg = ops->string.concat(interp, msg, ops->string.intern(interp,
+          S("\": must be ok, error, return, break, continue, or an integer")));
+        ops->interp.set_result(interp, msg);
+        return TCL_ERROR;
       }
+    } else if (ops->list.is_nil(interp, ops->list.from(interp, selector))) {
+      FeatherObj msg = ops->string.intern(interp, S("bad prefix '"));
+      msg = ops->string.concat(interp, msg, selector);
+      msg = ops->string.concat(interp, msg, ops->string.intern(interp, S("': must be a list")));
+      ops->interp.set_result(interp, msg);
+      return TCL_ERROR;
     }
 
-    // Decrement level
-    level--;
-    if (level <= 0) {
-      effectiveCode = (FeatherResult)returnCode;
+    // The variable list must be a list; only its first two names are used
+    if (ops->list.is_nil(interp, ops->list.from(interp, ops->list.at(interp, args, i + 2)))) {
+      return TCL_ERROR; // list parse error already set
     }
-  }
 
-  // Finalize error state before matching handlers (transfers accumulated trace to opts)
-  if (effectiveCode == TCL_ERROR && feather_error_is_active(ops, interp)) {
-    feather_error_finalize(ops, interp);
-    // Update bodyOptions to include the newly added error info
-    bodyOptions = ops->interp.get_return_options(interp, effectiveCode);
+    lastScript = ops->list.at(interp, args, i + 3);
+    i += 4;
   }
 
-  // Try to find a matching handler
-  FeatherResult handlerResult = effectiveCode;
-  FeatherObj handlerResultObj = bodyResult;
-  int handlerMatched = 0;
-
-  // For fallthrough: save the varList from the first matching handler
-  FeatherObj savedVarList = 0;
-  int pendingFallthrough = 0;
-
-  size_t i = 1;
-  while (i < handlerEnd) {
-    FeatherObj handlerType = ops->list.at(interp, args, i);
-
-    if (feather_obj_eq_literal(ops, interp, handlerType, "on")) {
-      // on code variableList script
-      if (i + 3 > handlerEnd) {
-        FeatherObj msg = ops->string.intern(
-            interp, S("wrong # args to on clause: must be \"on code variableList script\""));
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
+  if (lastScript != 0 && is_fallthrough(ops, interp, lastScript)) {
+    return try_clause_error(ops, interp,
+      "last non-finally clause must not have a body of \"-\"");
+  }
+  return TCL_OK;
+}
 
-      FeatherObj codeObj = ops->list.at(interp, args, i + 1);
-      FeatherObj varList = ops->list.at(interp, args, i + 2);
-      FeatherObj script = ops->list.at(interp, args, i + 3);
-
-      // Parse the code
-      int code = try_parse_code(ops, interp, codeObj);
-      if (code < 0) {
-        // Build error message
-        FeatherObj prefix = ops->string.intern(interp, S("bad completion code \""));
-        FeatherObj suffix = ops->string.intern(interp, S("\": must be ok, error, return, break, continue, or an integer"));
-        FeatherObj msg = ops->string.concat(interp, prefix, codeObj);
-        msg = ops->string.concat(interp, msg, suffix);
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
+// Check whether the handler at i matches a body that completed with code
+// and the given options.
+static int try_handler_matches(const FeatherHostOps *ops, FeatherInterp interp,
+                               FeatherObj args, size_t i, FeatherResult code,
+                               FeatherObj options) {
+  int type;
+  ops->string.get_index(interp, ops->list.at(interp, args, i), try_handlers,
+                        "handler type", 0, &type);
+  FeatherObj selector = ops->list.at(interp, args, i + 1);
+  if ((TryHandler)type == TRY_ON) {
+    return (int)code == try_parse_code(ops, interp, selector);
+  }
+  // trap only matches errors
+  return code == TCL_ERROR &&
+         match_errorcode(ops, interp, selector, get_errorcode(ops, interp, options));
+}
 
-      // Check varList length
-      FeatherObj varListParsed = ops->list.from(interp, varList);
-      size_t varListLen = ops->list.length(interp, varListParsed);
-      if (varListLen > 2) {
-        FeatherObj msg = ops->string.intern(
-            interp, S("wrong # args: should be \"on code ?resultVar ?optionsVar?? script\""));
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
+FeatherResult feather_builtin_try(const FeatherHostOps *ops, FeatherInterp interp,
+                           FeatherObj cmd, FeatherObj args) {
+  (void)cmd;
 
-      // Check if we have a pending fallthrough or this handler matches
-      int thisMatches = (int)effectiveCode == code;
-
-      if (pendingFallthrough || thisMatches) {
-        // If this is a new match (not fallthrough), save the varList
-        if (!pendingFallthrough && thisMatches) {
-          savedVarList = varList;
-        }
-
-        // Check for fallthrough ("-")
-        if (is_fallthrough(ops, interp, script)) {
-          pendingFallthrough = 1;
-          i += 4;
-          continue;
-        }
-
-        // Use saved varList if we had a fallthrough, otherwise use this handler's varList
-        FeatherObj useVarList = pendingFallthrough ? savedVarList : varList;
-        FeatherObj useVarListParsed = ops->list.from(interp, useVarList);
-        size_t useVarListLen = ops->list.length(interp, useVarListParsed);
-
-        handlerMatched = 1;
-        pendingFallthrough = 0;
-
-        // Bind variables
-        if (useVarListLen >= 1) {
-          FeatherObj resultVar = ops->list.at(interp, useVarListParsed, 0);
-          if (feather_set_var(ops, interp, resultVar, bodyResult) != TCL_OK) {
-            return TCL_ERROR;
-          }
-        }
-        if (useVarListLen >= 2) {
-          FeatherObj optsVar = ops->list.at(interp, useVarListParsed, 1);
-          if (ops->list.is_nil(interp, bodyOptions)) {
-            FeatherObj defaultOpts = ops->list.create(interp);
-            defaultOpts = ops->list.push(interp, defaultOpts, ops->string.intern(interp, S("-code")));
-            defaultOpts = ops->list.push(interp, defaultOpts, ops->integer.create(interp, (int64_t)effectiveCode));
-            if (feather_set_var(ops, interp, optsVar, defaultOpts) != TCL_OK) {
-              return TCL_ERROR;
-            }
-          } else {
-            if (feather_set_var(ops, interp, optsVar, bodyOptions) != TCL_OK) {
-              return TCL_ERROR;
-            }
-          }
-        }
-
-        // Execute handler script
-        handlerResult = feather_script_eval_obj(ops, interp, script, TCL_EVAL_LOCAL);
-        handlerResultObj = ops->interp.get_result(interp);
-
-        // If handler raised an exception, add -during key with body's options
-        if (handlerResult != TCL_OK) {
-          add_during_to_options(ops, interp, handlerResult, bodyOptions);
-        } else {
-          // Handler succeeded - clear return options to avoid stale error state
-          FeatherObj emptyOpts = ops->list.create(interp);
-          ops->interp.set_return_options(interp, emptyOpts);
-        }
-
-        break; // Handler executed, stop searching
-      }
+  size_t argc = ops->list.length(interp, args);
 
-      i += 4;
-    } else if (feather_obj_eq_literal(ops, interp, handlerType, "trap")) {
-      // trap pattern variableList script
-      if (i + 3 > handlerEnd) {
-        FeatherObj msg = ops->string.intern(
-            interp, S("wrong # args to trap clause: must be \"trap pattern variableList script\""));
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
+  // try body ?handler...? ?finally script?
+  if (argc < 1) {
+    FeatherObj msg = ops->string.intern(
+        interp, S("wrong # args: should be \"try body ?handler ...? ?finally script?\""));
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
 
-      FeatherObj pattern = ops->list.at(interp, args, i + 1);
-      FeatherObj varList = ops->list.at(interp, args, i + 2);
-      FeatherObj script = ops->list.at(interp, args, i + 3);
+  FeatherObj finallyScript;
+  size_t handlerEnd;
+  if (try_check_clauses(ops, interp, args, &finallyScript, &handlerEnd) != TCL_OK) {
+    return TCL_ERROR;
+  }
 
-      // Check varList length
-      FeatherObj varListParsed = ops->list.from(interp, varList);
-      size_t varListLen = ops->list.length(interp, varListParsed);
-      if (varListLen > 2) {
-        FeatherObj msg = ops->string.intern(
-            interp, S("wrong # args: should be \"trap pattern ?resultVar ?optionsVar?? script\""));
-        ops->interp.set_result(interp, msg);
-        return TCL_ERROR;
-      }
+  // Evaluate the body. Handlers see its completion code as it is: a return
+  // in the body is matched by "on return", whatever its -code
+  FeatherObj body = ops->list.at(interp, args, 0);
+  FeatherResult code = feather_script_eval_obj(ops, interp, body, TCL_EVAL_LOCAL);
+  FeatherObj bodyResult = ops->interp.get_result(interp);
 
-      // trap only matches errors (code 1)
-      int thisMatches = 0;
-      if ((int)effectiveCode == TCL_ERROR) {
-        // Get errorcode from options
-        FeatherObj errorcode = get_errorcode(ops, interp, bodyOptions);
-        thisMatches = match_errorcode(ops, interp, pattern, errorcode);
-      }
+  // Finalize error state before matching handlers (transfers accumulated trace to opts)
+  if (code == TCL_ERROR && feather_error_is_active(ops, interp)) {
+    feather_error_finalize(ops, interp, body);
+  }
+  FeatherObj bodyOptions = feather_return_options(ops, interp, code);
+  ops->interp.set_return_options(interp, ops->list.create(interp));
+
+  // The outcome of try so far: the body's, until a handler runs
+  FeatherResult result = code;
+  FeatherObj resultObj = bodyResult;
+  FeatherObj resultOptions = bodyOptions;
+
+  // Run the first handler that matches
+  for (size_t i = 1; i < handlerEnd; i += 4) {
+    if (!try_handler_matches(ops, interp, args, i, code, bodyOptions)) {
+      continue;
+    }
 
-      // Check if we have a pending fallthrough or this handler matches
-      if (pendingFallthrough || thisMatches) {
-        // If this is a new match (not fallthrough), save the varList
-        if (!pendingFallthrough && thisMatches) {
-          savedVarList = varList;
-        }
-
-        // Check for fallthrough ("-")
-        if (is_fallthrough(ops, interp, script)) {
-          pendingFallthrough = 1;
-          i += 4;
-          continue;
-        }
-
-        // Use saved varList if we had a fallthrough, otherwise use this handler's varList
-        FeatherObj useVarList = pendingFallthrough ? savedVarList : varList;
-        FeatherObj useVarListParsed = ops->list.from(interp, useVarList);
-        size_t useVarListLen = ops->list.length(interp, useVarListParsed);
-
-        handlerMatched = 1;
-        pendingFallthrough = 0;
-
-        // Bind variables
-        if (useVarListLen >= 1) {
-          FeatherObj resultVar = ops->list.at(interp, useVarListParsed, 0);
-          if (feather_set_var(ops, interp, resultVar, bodyResult) != TCL_OK) {
-            return TCL_ERROR;
-          }
-        }
-        if (useVarListLen >= 2) {
-          FeatherObj optsVar = ops->list.at(interp, useVarListParsed, 1);
-          if (ops->list.is_nil(interp, bodyOptions)) {
-            FeatherObj defaultOpts = ops->list.create(interp);
-            defaultOpts = ops->list.push(interp, defaultOpts, ops->string.intern(interp, S("-code")));
-            defaultOpts = ops->list.push(interp, defaultOpts, ops->integer.create(interp, 1));
-            if (feather_set_var(ops, interp, optsVar, defaultOpts) != TCL_OK) {
-              return TCL_ERROR;
-            }
-          } else {
-            if (feather_set_var(ops, interp, optsVar, bodyOptions) != TCL_OK) {
-              return TCL_ERROR;
-            }
-          }
-        }
-
-        // Execute handler script
-        handlerResult = feather_script_eval_obj(ops, interp, script, TCL_EVAL_LOCAL);
-        handlerResultObj = ops->interp.get_result(interp);
-
-        // If handler raised an exception, add -during key with body's options
-        if (handlerResult != TCL_OK) {
-          add_during_to_options(ops, interp, handlerResult, bodyOptions);
-        } else {
-          // Handler succeeded - clear return options to avoid stale error state
-          FeatherObj emptyOpts = ops->list.create(interp);
-          ops->interp.set_return_options(interp, emptyOpts);
-        }
-
-        break; // Handler executed, stop searching
-      }
+    // A script of "-" falls through to the next handler, whose variables
+    // and script are used; the last handler never has one
+    size_t handlerAt = i;
+    while (is_fallthrough(ops, interp, ops->list.at(interp, args, handlerAt + 3))) {
+      handlerAt += 4;
+    }
+    FeatherObj script = ops->list.at(interp, args, handlerAt + 3);
 
-      i += 4;
-    } else if (feather_obj_eq_literal(ops, interp, handlerType, "finally")) {
-      // finally must be at the end - if we got here, it's malformed
-      FeatherObj msg = ops->string.intern(
-          interp, S("finally clause must be at the end"));
-      ops->interp.set_result(interp, msg);
+    // Bind the result and options to the handler's variables
+    FeatherObj varList = ops->list.from(interp, ops->list.at(interp, args, handlerAt + 2));
+    size_t numVars = ops->list.length(interp, varList);
+    if (numVars >= 1 &&
+        feather_set_var(ops, interp, ops->list.at(interp, varList, 0), bodyResult) != TCL_OK) {
       return TCL_ERROR;
-    } else {
-      // Unknown handler type
-      FeatherObj prefix = ops->string.intern(interp, S("bad handler type \""));
-      FeatherObj suffix = ops->string.intern(interp, S("\": must be on, trap, or finally"));
-      FeatherObj msg = ops->string.concat(interp, prefix, handlerType);
-      msg = ops->string.concat(interp, msg, suffix);
-      ops->interp.set_result(interp, msg);
+    }
+    if (numVars >= 2 &&
+        feather_set_var(ops, interp, ops->list.at(interp, varList, 1), bodyOptions) != TCL_OK) {
       return TCL_ERROR;
     }
-  }
 
-  // Execute finally clause if present
-  FeatherResult finalResult = handlerResult;
-  FeatherObj finalResultObj = handlerResultObj;
-
-  if (!ops->list.is_nil(interp, finallyScript) && finallyScript != 0) {
-    // Save the previous result's options before executing finally
-    // This will be either the body's options (if no handler) or handler's result options
-    FeatherObj previousOptions;
-    if (handlerMatched) {
-      // Handler was executed, get its result options
-      previousOptions = ops->interp.get_return_options(interp, handlerResult);
+    result = feather_script_eval_obj(ops, interp, script, TCL_EVAL_LOCAL);
+    resultObj = ops->interp.get_result(interp);
+    if (result != TCL_OK) {
+      // If handler raised an exception, add -during key with body's options
+      add_during_to_options(ops, interp, result, bodyOptions);
+      resultOptions = ops->interp.get_return_options(interp, result);
     } else {
-      // No handler, use body's options
-      previousOptions = bodyOptions;
+      resultOptions = feather_return_options(ops, interp, TCL_OK);
     }
+    break;
+  }
 
+  // Execute finally clause if present
+  if (finallyScript != 0) {
     FeatherResult finallyCode = feather_script_eval_obj(ops, interp, finallyScript, TCL_EVAL_LOCAL);
-
-    // If finally returns a non-ok result, add -during key
     if (finallyCode != TCL_OK) {
-      add_during_to_options(ops, interp, finallyCode, previousOptions);
+      // The outcome so far goes under -during of the finally clause's
+      add_during_to_options(ops, interp, finallyCode, resultOptions);
       return finallyCode;
     }
-    // Otherwise, restore the previous result
-    ops->interp.set_result(interp, finalResultObj);
-  }
-
-  // If no handler matched and body returned non-ok, propagate it
-  if (!handlerMatched) {
-    ops->interp.set_result(interp, bodyResult);
-    return effectiveCode;
   }
 
-  // Return handler's result
-  ops->interp.set_result(interp, handlerResultObj);
-  return handlerResult;
+  // The outcome stands, with the options of an exception
+  ops->interp.set_return_options(interp,
+    result == TCL_OK ? ops->list.create(interp) : resultOptions);
+  ops->interp.set_result(interp, resultObj);
+  return result;
 }
 
 void feather_register_try_usage(const FeatherHostOps *ops, FeatherInterp interp) {
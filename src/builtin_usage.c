@@ -2837,8 +2837,9 @@ static CompletionStateInfo determine_completion_state(
         return state;
     }
 
-    /* Get command name and look up spec */
+    /* Get command name and look up spec, registering builtin specs on demand */
     FeatherObj cmdName = ops->list.at(interp, ctx->complete_tokens, 0);
+    feather_ensure_usage_registered(ops, interp, cmdName);
     FeatherObj specs = usage_get_specs(ops, interp);
     FeatherObj specEntry = ops->dict.get(interp, specs, cmdName);
 
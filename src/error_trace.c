@@ -72,7 +72,8 @@ void feather_error_init(const FeatherHostOps *ops, FeatherInterp interp,
 }
 
 void feather_error_append_frame(const FeatherHostOps *ops, FeatherInterp interp,
-                                FeatherObj procName, FeatherObj args, size_t line) {
+                                FeatherObj procName, FeatherObj args, size_t line,
+                                size_t callLine) {
     ops = feather_get_ops(ops);
 
     // Get display name (strip :: prefix for global namespace commands)
@@ -138,9 +139,13 @@ void feather_error_append_frame(const FeatherHostOps *ops, FeatherInterp interp,
     }
     stack = ops->list.push(interp, stack, callEntry);
     set_error_var(ops, interp, "stack", stack);
+
+    // The error now points at the call
+    set_error_var(ops, interp, "line", ops->integer.create(interp, (int64_t)callLine));
 }
 
-void feather_error_finalize(const FeatherHostOps *ops, FeatherInterp interp) {
+void feather_error_finalize(const FeatherHostOps *ops, FeatherInterp interp,
+                            FeatherObj script) {
     ops = feather_get_ops(ops);
 
     // Get accumulated state
@@ -148,6 +153,16 @@ void feather_error_finalize(const FeatherHostOps *ops, FeatherInterp interp) {
     FeatherObj stack = get_error_var(ops, interp, "stack");
     FeatherObj line = get_error_var(ops, interp, "line");
 
+    // The error line counts from the start of the enclosing file; make it
+    // relative to script if the parser recorded where script starts
+    FeatherObj file;
+    size_t first = ops->interp.get_source(interp, script, &file);
+    int64_t lineNum;
+    if (first > 1 && ops->integer.get(interp, line, &lineNum) == TCL_OK &&
+        lineNum >= (int64_t)first) {
+        line = ops->integer.create(interp, lineNum - (int64_t)first + 1);
+    }
+
     // Get current return options and add error fields
     FeatherObj opts = ops->interp.get_return_options(interp, TCL_ERROR);
     if (ops->list.is_nil(interp, opts)) {
@@ -29,16 +29,19 @@ void feather_error_init(const FeatherHostOps *ops, FeatherInterp interp,
  * feather_error_append_frame appends a stack frame during error propagation.
  *
  * Called when exiting a proc frame with TCL_ERROR. Adds information about
- * the procedure call to both -errorinfo and -errorstack.
+ * the procedure call to both -errorinfo and -errorstack, and moves the
+ * error line to the line of the call in the calling frame.
  *
  * @param ops The host operations
  * @param interp The interpreter
  * @param procName The name of the procedure
  * @param args The arguments passed to the procedure (as a list)
  * @param line The line number in the procedure where the error occurred
+ * @param callLine The line of the procedure call in the calling frame
  */
 void feather_error_append_frame(const FeatherHostOps *ops, FeatherInterp interp,
-                                FeatherObj procName, FeatherObj args, size_t line);
+                                FeatherObj procName, FeatherObj args, size_t line,
+                                size_t callLine);
 
 /**
  * feather_error_finalize copies accumulated error state to return options.
@@ -46,13 +49,16 @@ void feather_error_append_frame(const FeatherHostOps *ops, FeatherInterp interp,
  * Called when catch/try catches the error. Transfers the accumulated
  * -errorinfo, -errorstack, and -errorline from ::tcl::errors:: variables
  * to the interpreter's return options. Also sets the global ::errorInfo
- * and ::errorCode variables.
+ * and ::errorCode variables. -errorline counts lines from the start of
+ * script.
  *
  * Resets the error state (sets active to "0").
  *
  * @param ops The host operations
  * @param interp The interpreter
+ * @param script The script that raised the error
  */
-void feather_error_finalize(const FeatherHostOps *ops, FeatherInterp interp);
+void feather_error_finalize(const FeatherHostOps *ops, FeatherInterp interp,
+                            FeatherObj script);
 
 #endif
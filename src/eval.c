@@ -1,6 +1,9 @@
 #include "feather.h"
 #include "host.h"
 #include "internal.h"
+#include "namespace_util.h"
+
+#define S(lit) (lit), feather_strlen(lit)
 
 // Global to track the current step trace target for propagation through nested calls
 static FeatherObj current_step_target = 0;
@@ -13,9 +16,109 @@ void feather_set_step_target(FeatherObj target) {
   current_step_target = target;
 }
 
+/**
+ * Fires "leave" execution traces for a command that has completed with code,
+ * if there were traces when it was entered.
+ */
+static FeatherResult fire_leave_traces(const FeatherHostOps *ops, FeatherInterp interp,
+                                       int traced, FeatherObj cmdName,
+                                       FeatherObj cmdList, FeatherResult code) {
+  if (!traced) {
+    return TCL_OK;
+  }
+  return feather_fire_exec_traces(ops, interp, cmdName, cmdList, "leave", code,
+                                  ops->interp.get_result(interp));
+}
+
+// run_host_command runs cmd with the host through bind.unknown, which
+// runs the commands registered by the host and handles those that do not
+// exist, and fires the "leave" execution traces.
+static FeatherResult run_host_command(const FeatherHostOps *ops, FeatherInterp interp,
+                                      int traced, FeatherObj cmd, FeatherObj args,
+                                      FeatherObj lookupName, FeatherObj originalCmd) {
+  FeatherObj result;
+  FeatherResult code = ops->bind.unknown(interp, cmd, args, &result);
+
+  if (code == TCL_OK) {
+    ops->interp.set_result(interp, result);
+  }
+
+  FeatherResult leaveResult = fire_leave_traces(ops, interp, traced, lookupName, originalCmd, code);
+  return (leaveResult != TCL_OK) ? leaveResult : code;
+}
+
+// A tail call requested with tailcall. The pending one waits for the
+// proc or lambda at its level to pop its frame, which makes it ready for
+// feather_command_exec to run. Both are kept in the interpreter's state.
+
+void feather_tailcall_request(const FeatherHostOps *ops, FeatherInterp interp,
+                              FeatherObj cmd, FeatherObj ns, size_t level) {
+  FeatherInterpState *state = ops->interp.state(interp);
+  state->tailcall_pending.cmd = cmd;
+  state->tailcall_pending.ns = ns;
+  state->tailcall_pending.level = level;
+}
+
+FeatherTailcall feather_tailcall_begin(FeatherInterpState *state) {
+  FeatherTailcall saved = state->tailcall_pending;
+  state->tailcall_pending.cmd = 0;
+  return saved;
+}
+
+FeatherResult feather_tailcall_end(FeatherInterpState *state, FeatherTailcall saved,
+                                   size_t level, FeatherResult code) {
+  FeatherTailcall *pending = &state->tailcall_pending;
+  if (pending->cmd != 0 && pending->level == level && code != TCL_ERROR) {
+    state->tailcall_ready = *pending;
+    code = TCL_OK;
+  }
+  *pending = saved;
+  return code;
+}
+
+static FeatherResult exec_command(const FeatherHostOps *ops, FeatherInterp interp,
+                                  FeatherObj command, FeatherEvalFlags flags);
+
+/**
+ * Runs the tail call that the proc or lambda just invoked left ready, and
+ * the tail calls that one leaves in turn, each in the namespace it was
+ * requested in. They run here, after the invocation returned, rather than
+ * within it, so that a chain of tail calls takes no C stack.
+ */
+static FeatherResult run_tailcalls(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherInterpState *state, FeatherResult code,
+                                   FeatherEvalFlags flags) {
+  if (state->tailcall_ready.cmd == 0) {
+    return code;
+  }
+  FeatherObj callerNs = ops->ns.current(interp);
+  while (state->tailcall_ready.cmd != 0) {
+    FeatherTailcall tc = state->tailcall_ready;
+    state->tailcall_ready.cmd = 0;
+    ops->frame.set_namespace(interp, tc.ns);
+    code = exec_command(ops, interp, tc.cmd, flags);
+  }
+  ops->frame.set_namespace(interp, callerNs);
+  return code;
+}
+
+// Runs command and the tail calls it leaves, for the evaluation loops,
+// which have the interpreter's state at hand.
+static FeatherResult command_exec(const FeatherHostOps *ops, FeatherInterp interp,
+                                  FeatherInterpState *state, FeatherObj command,
+                                  FeatherEvalFlags flags) {
+  FeatherResult code = exec_command(ops, interp, command, flags);
+  return run_tailcalls(ops, interp, state, code, flags);
+}
+
 FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj command, FeatherEvalFlags flags) {
   ops = feather_get_ops(ops);
+  return command_exec(ops, interp, ops->interp.state(interp), command, flags);
+}
+
+static FeatherResult exec_command(const FeatherHostOps *ops, FeatherInterp interp,
+                                  FeatherObj command, FeatherEvalFlags flags) {
   // command is a parsed command list [name, arg1, arg2, ...]
   // First element is the command name, rest are arguments (unevaluated)
 
@@ -23,10 +126,16 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
   if (ops->list.length(interp, command) == 0) {
     return TCL_OK;
   }
+  if (feather_stack_check(ops, interp) != TCL_OK) {
+    return TCL_ERROR;
+  }
 
   // Save the original command list for execution traces
   // Use list.from to create a copy (it creates a new list from an existing one)
-  FeatherObj originalCmd = ops->list.from(interp, command);
+  // Without traces there is nothing to save it for, and none are fired on
+  // leaving the command even if it adds some
+  int traced = feather_traces_active(ops, interp, "execution");
+  FeatherObj originalCmd = traced ? ops->list.from(interp, command) : 0;
 
   // Extract the command name (first element)
   FeatherObj cmd = ops->list.shift(interp, command);
@@ -43,7 +152,8 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
   //    - Split into namespace and simple name, look up in that namespace
   // 2. For unqualified names:
   //    a. Try current namespace first
-  //    b. Fall back to global namespace
+  //    b. Then the namespaces on its path, set by namespace path
+  //    c. Fall back to global namespace
   //
   FeatherBuiltinCmd builtin = NULL;
   FeatherCommandType cmdType = TCL_CMD_NONE;
@@ -104,6 +214,12 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
       }
     }
 
+    // Then the namespaces on the current namespace's path
+    if (cmdType == TCL_CMD_NONE) {
+      cmdType = feather_ns_path_lookup(ops, interp, currentNs, cmd, &lookupNs,
+                                       &builtin, NULL, NULL);
+    }
+
     // If not found in current namespace, try global namespace
     if (cmdType == TCL_CMD_NONE) {
       cmdType = ops->ns.get_command(interp, globalNs, cmd, &builtin, NULL, NULL);
@@ -119,7 +235,7 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
   if (ops->list.is_nil(interp, lookupNs) || lookupNs == 0) {
     lookupName = cmd;
   } else {
-    if (feather_obj_is_global_ns(ops, interp, lookupNs)) {
+    if (lookupNs == globalNs || feather_obj_is_global_ns(ops, interp, lookupNs)) {
       // Global namespace: "::simpleName"
       lookupName = ops->string.concat(interp, globalNs, simpleName);
     } else {
@@ -131,9 +247,11 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
   }
 
   // Fire "enter" execution traces before command executes
-  FeatherResult enterResult = feather_fire_exec_traces(ops, interp, lookupName, originalCmd, "enter", 0, 0);
-  if (enterResult != TCL_OK) {
-    return enterResult;  // Enter trace error propagates directly
+  if (traced) {
+    FeatherResult enterResult = feather_fire_exec_traces(ops, interp, lookupName, originalCmd, "enter", 0, 0);
+    if (enterResult != TCL_OK) {
+      return enterResult;  // Enter trace error propagates directly
+    }
   }
 
   FeatherResult code;
@@ -143,29 +261,55 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
     if (builtin != NULL) {
       // Call the builtin function directly
       code = builtin(ops, interp, lookupName, args);
+      // Leave traces see the result of a tail call apply left
+      if (traced) {
+        code = run_tailcalls(ops, interp, ops->interp.state(interp), code, flags);
+      }
       // Fire "leave" execution traces after command completes
-      leaveResult = feather_fire_exec_traces(ops, interp, lookupName, originalCmd, "leave", code, ops->interp.get_result(interp));
+      leaveResult = fire_leave_traces(ops, interp, traced, lookupName, originalCmd, code);
       return (leaveResult != TCL_OK) ? leaveResult : code;
     }
-    // NULL builtin means host-registered command - fall through to unknown
-    break;
+    // NULL builtin means host-registered command, which the host runs
+    // through bind.unknown without consulting a user-defined 'unknown'
+    return run_host_command(ops, interp, traced, cmd, args, lookupName, originalCmd);
   case TCL_CMD_PROC:
     // For procs, use the fully qualified name for lookup
-    code = feather_invoke_proc(ops, interp, lookupName, args);
+    code = feather_invoke_proc(ops, interp, lookupName, cmd, args);
+    if (traced) {
+      code = run_tailcalls(ops, interp, ops->interp.state(interp), code, flags);
+    }
     // Fire "leave" execution traces after command completes
-    leaveResult = feather_fire_exec_traces(ops, interp, lookupName, originalCmd, "leave", code, ops->interp.get_result(interp));
+    leaveResult = fire_leave_traces(ops, interp, traced, lookupName, originalCmd, code);
     return (leaveResult != TCL_OK) ? leaveResult : code;
   case TCL_CMD_NONE:
+    // A foreign object that is not also a command has its methods called
+    // by the host, so that [$obj method ?arg ...?] works for any object
+    if (ops->foreign.is_foreign(interp, cmd)) {
+      if (ops->list.length(interp, args) == 0) {
+        FeatherObj msg = ops->string.intern(interp, "wrong # args: should be \"", 25);
+        msg = ops->string.concat(interp, msg, cmd);
+        msg = ops->string.concat(interp, msg,
+                                 ops->string.intern(interp, " method ?arg ...?\"", 18));
+        ops->interp.set_result(interp, msg);
+        code = TCL_ERROR;
+      } else {
+        FeatherObj method = ops->list.shift(interp, args);
+        code = ops->foreign.invoke(interp, cmd, method, args);
+      }
+      leaveResult = fire_leave_traces(ops, interp, traced, lookupName, originalCmd, code);
+      return (leaveResult != TCL_OK) ? leaveResult : code;
+    }
     // Fall through to unknown handling
     break;
   }
 
-  // Check for user-defined 'unknown' procedure in global namespace
+  // Check for a user-defined 'unknown' command in the global namespace,
+  // which may be a proc or a command registered by the host
   FeatherObj unknownSimple = ops->string.intern(interp, "unknown", 7);
   FeatherBuiltinCmd unusedFn = NULL;
   FeatherCommandType unknownType = ops->ns.get_command(interp, globalNs, unknownSimple, &unusedFn, NULL, NULL);
 
-  if (unknownType == TCL_CMD_PROC) {
+  if (unknownType != TCL_CMD_NONE) {
     // Build args list: [originalCmd, arg1, arg2, ...]
     FeatherObj unknownArgs = ops->list.create(interp);
     unknownArgs = ops->list.push(interp, unknownArgs, cmd);
@@ -175,30 +319,120 @@ FeatherResult feather_command_exec(const FeatherHostOps *ops, FeatherInterp inte
       unknownArgs = ops->list.push(interp, unknownArgs, arg);
     }
     FeatherObj unknownName = ops->string.intern(interp, "::unknown", 9);
-    code = feather_invoke_proc(ops, interp, unknownName, unknownArgs);
+    if (unknownType == TCL_CMD_PROC) {
+      code = feather_invoke_proc(ops, interp, unknownName, unknownName, unknownArgs);
+      if (traced) {
+        code = run_tailcalls(ops, interp, ops->interp.state(interp), code, flags);
+      }
+    } else {
+      // Other commands are run as the command [::unknown originalCmd arg ...]
+      FeatherObj unknownCmd = ops->list.create(interp);
+      unknownCmd = ops->list.push(interp, unknownCmd, unknownName);
+      for (size_t i = 0; i <= argc; i++) {
+        unknownCmd = ops->list.push(interp, unknownCmd, ops->list.at(interp, unknownArgs, i));
+      }
+      code = feather_command_exec(ops, interp, unknownCmd, flags);
+    }
     // Fire "leave" execution traces after command completes
-    leaveResult = feather_fire_exec_traces(ops, interp, lookupName, originalCmd, "leave", code, ops->interp.get_result(interp));
+    leaveResult = fire_leave_traces(ops, interp, traced, lookupName, originalCmd, code);
     return (leaveResult != TCL_OK) ? leaveResult : code;
   }
 
   // Fall back to host command lookup via bind.unknown
-  FeatherObj result;
-  code = ops->bind.unknown(interp, cmd, args, &result);
+  return run_host_command(ops, interp, traced, cmd, args, lookupName, originalCmd);
+}
 
-  if (code == TCL_OK) {
-    ops->interp.set_result(interp, result);
+void feather_eval_hooks_enable(const FeatherHostOps *ops, FeatherInterp interp, int delta) {
+  ops = feather_get_ops(ops);
+  ops->interp.state(interp)->eval_hooks += delta;
+}
+
+// The highest address of the stack seen on this thread, taken as its top.
+// Each coroutine runs on a thread of its own, so this is kept per thread.
+#ifdef FEATHER_WASM_BUILD
+static uintptr_t stack_top = 0;
+#else
+static _Thread_local uintptr_t stack_top = 0;
+#endif
+
+// Bytes of C stack evaluation in interp may use on a thread (0 = no limit)
+static size_t stack_limit(const FeatherHostOps *ops, FeatherInterp interp) {
+  size_t limit = ops->interp.state(interp)->stack_limit;
+  if (limit == 0) {
+    return FEATHER_DEFAULT_STACK_LIMIT;
   }
+  return limit == SIZE_MAX ? 0 : limit;
+}
 
-  // Fire "leave" execution traces after command completes
-  leaveResult = feather_fire_exec_traces(ops, interp, lookupName, originalCmd, "leave", code, ops->interp.get_result(interp));
+size_t feather_set_stack_limit(const FeatherHostOps *ops, FeatherInterp interp, size_t bytes) {
+  ops = feather_get_ops(ops);
+  size_t previous = stack_limit(ops, interp);
+  ops->interp.state(interp)->stack_limit = bytes == 0 ? SIZE_MAX : bytes;
+  return previous;
+}
 
-  return (leaveResult != TCL_OK) ? leaveResult : code;
+FeatherResult feather_stack_check(const FeatherHostOps *ops, FeatherInterp interp) {
+  // Stacks grow down on the platforms feather runs on
+  char marker;
+  uintptr_t here = (uintptr_t)&marker;
+  if (here > stack_top) {
+    stack_top = here;
+    return TCL_OK;
+  }
+  ops = feather_get_ops(ops);
+  size_t limit = stack_limit(ops, interp);
+  if (limit == 0 || stack_top - here <= limit) {
+    return TCL_OK;
+  }
+  FeatherObj code = ops->list.create(interp);
+  code = ops->list.push(interp, code, ops->string.intern(interp, S("TCL")));
+  code = ops->list.push(interp, code, ops->string.intern(interp, S("LIMIT")));
+  code = ops->list.push(interp, code, ops->string.intern(interp, S("STACK")));
+  FeatherObj options = ops->list.create(interp);
+  options = ops->list.push(interp, options, ops->string.intern(interp, S("-code")));
+  options = ops->list.push(interp, options, ops->integer.create(interp, 1));
+  options = ops->list.push(interp, options, ops->string.intern(interp, S("-errorcode")));
+  options = ops->list.push(interp, options, code);
+  ops->interp.set_return_options(interp, options);
+  ops->interp.set_result(interp, ops->string.intern(interp, S("out of stack space (infinite loop?)")));
+  return TCL_ERROR;
+}
+
+FeatherResult feather_body_eval(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj body) {
+  char marker;
+  uintptr_t here = (uintptr_t)&marker;
+  size_t limit = stack_limit(ops, interp);
+  if (limit != 0 && here < stack_top && stack_top - here > limit / 2) {
+    return ops->interp.eval_on_new_stack(interp, body, TCL_EVAL_LOCAL);
+  }
+  return feather_script_eval_obj(ops, interp, body, TCL_EVAL_LOCAL);
+}
+
+void feather_eval_limits_enable(const FeatherHostOps *ops, FeatherInterp interp, int delta) {
+  ops = feather_get_ops(ops);
+  ops->interp.state(interp)->eval_limits += delta;
+}
+
+/**
+ * Asks the host whether evaluation may go on. Called where stopping is safe:
+ * before and after each command and at the start of each script, so that
+ * loops with empty bodies are checked too. An error stops the script as a failing
+ * command would, and unwinds the frames on the way out as usual.
+ */
+static inline FeatherResult check_eval_limit(const FeatherHostOps *ops, FeatherInterp interp,
+                                            FeatherInterpState *state) {
+  return state->eval_limits > 0 ? ops->interp.limit_check(interp) : TCL_OK;
 }
 
 FeatherResult feather_script_eval(const FeatherHostOps *ops, FeatherInterp interp,
                           const char *source, size_t len, FeatherEvalFlags flags) {
   ops = feather_get_ops(ops);
-  FeatherResult result = TCL_OK;
+  FeatherResult result = feather_stack_check(ops, interp);
+  if (result != TCL_OK) {
+    return result;
+  }
+  FeatherInterpState *state = ops->interp.state(interp);
   FeatherParseContext ctx;
   feather_parse_init(&ctx, source, len);
 
@@ -208,7 +442,25 @@ FeatherResult feather_script_eval(const FeatherHostOps *ops, FeatherInterp inter
 
     // Only execute non-empty commands
     if (ops->list.length(interp, parsed) > 0) {
-      result = feather_command_exec(ops, interp, parsed, flags);
+      if ((result = check_eval_limit(ops, interp, state)) != TCL_OK) {
+        return result;
+      }
+      // Read eval_hooks once, so that enabling or disabling a hook while the
+      // command runs cannot unbalance the calls
+      int hooked = state->eval_hooks > 0;
+      if (hooked) {
+        ops->interp.eval_hook(interp, parsed, ctx.cmd_line);
+      }
+      state->cmdcount++;
+      result = command_exec(ops, interp, state, parsed, flags);
+      if (hooked) {
+        ops->interp.eval_done_hook(interp, parsed, result);
+      }
+      if (result == TCL_OK) {
+        // A limit the command ran into, such as on the size of the value
+        // it built, fails the command itself, so that catch sees it
+        result = check_eval_limit(ops, interp, state);
+      }
       if (result != TCL_OK) {
         // Let break/continue propagate - the while loop will catch them
         // If they reach the top level, the host converts to error
@@ -220,33 +472,108 @@ FeatherResult feather_script_eval(const FeatherHostOps *ops, FeatherInterp inter
   return (status == TCL_PARSE_DONE) ? result : TCL_ERROR;
 }
 
+/**
+ * Starts numbering the lines of the script in ctx from where the parser
+ * found it, if it was a braced word or command substitution, and makes its
+ * file the current frame's. Returns the frame's previous file, to be
+ * restored with end_script_source.
+ */
+static FeatherObj begin_script_source(const FeatherHostOps *ops, FeatherInterp interp,
+                                      FeatherParseContextObj *ctx) {
+  FeatherObj file = 0;
+  size_t line = ops->interp.get_source(interp, ctx->script, &file);
+  if (line > 0) {
+    ctx->line = line;
+    ctx->cmd_line = line;
+    ctx->file = file;
+  }
+  FeatherObj saved = ops->frame.get_file(interp, ops->frame.level(interp));
+  if (saved != 0 || file != 0) {
+    ops->frame.set_file(interp, file);
+  }
+  return saved;
+}
+
+/**
+ * Restores the frame's file after evaluating a script. The frame's line is
+ * left alone, so that after an error it is the line of the failing command.
+ */
+static void end_script_source(const FeatherHostOps *ops, FeatherInterp interp,
+                              FeatherParseContextObj *ctx, FeatherObj saved) {
+  if (saved != 0 || ctx->file != 0) {
+    ops->frame.set_file(interp, saved);
+  }
+}
+
 FeatherResult feather_script_eval_obj(const FeatherHostOps *ops, FeatherInterp interp,
                               FeatherObj script, FeatherEvalFlags flags) {
   ops = feather_get_ops(ops);
+  FeatherResult result = feather_stack_check(ops, interp);
+  FeatherInterpState *state = ops->interp.state(interp);
+  if (result == TCL_OK) {
+    result = check_eval_limit(ops, interp, state);
+  }
+  if (result != TCL_OK) {
+    return result;
+  }
   size_t len = ops->string.byte_length(interp, script);
-  FeatherResult result = TCL_OK;
+  if (len == 0) {
+    // Nothing to run, as for the empty body of a loop; the result of an
+    // empty script is the empty string
+    ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+    return TCL_OK;
+  }
   FeatherParseContextObj ctx;
   feather_parse_init_obj(&ctx, script, len);
+  FeatherObj savedFile = begin_script_source(ops, interp, &ctx);
 
   FeatherParseStatus status;
+  int ran = 0;
   while ((status = feather_parse_command_obj(ops, interp, &ctx)) == TCL_PARSE_OK) {
     FeatherObj parsed = ops->interp.get_result(interp);
 
     // Only execute non-empty commands
     if (ops->list.length(interp, parsed) > 0) {
-      // Note: The line is set by feather_parse_command_obj before parsing,
-      // so command substitutions during parsing don't overwrite it.
-      // The parser also handles the check to prevent nested evals from
-      // overwriting the outer command's line.
+      ran = 1;
+      // The parser set the frame's line before substituting the words, but
+      // command substitutions may have changed it since
+      ops->frame.set_line(interp, ctx.cmd_line);
 
-      result = feather_command_exec(ops, interp, parsed, flags);
+      if ((result = check_eval_limit(ops, interp, state)) != TCL_OK) {
+        end_script_source(ops, interp, &ctx, savedFile);
+        return result;
+      }
+
+      // Read eval_hooks once, so that enabling or disabling a hook while the
+      // command runs cannot unbalance the calls
+      int hooked = state->eval_hooks > 0;
+      if (hooked) {
+        ops->interp.eval_hook(interp, parsed, ctx.cmd_line);
+      }
+      state->cmdcount++;
+      result = command_exec(ops, interp, state, parsed, flags);
+      if (hooked) {
+        ops->interp.eval_done_hook(interp, parsed, result);
+      }
+      if (result == TCL_OK) {
+        // A limit the command ran into, such as on the size of the value
+        // it built, fails the command itself, so that catch sees it
+        result = check_eval_limit(ops, interp, state);
+      }
       if (result != TCL_OK) {
+        end_script_source(ops, interp, &ctx, savedFile);
         return result;
       }
     }
   }
 
-  return (status == TCL_PARSE_DONE) ? result : TCL_ERROR;
+  result = (status == TCL_PARSE_DONE) ? result : TCL_ERROR;
+  if (result == TCL_OK && !ran) {
+    // Only whitespace and comments, whose result is the empty string
+    ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+  }
+  end_script_source(ops, interp, &ctx, savedFile);
+  return result;
 }
 
 FeatherResult feather_command_exec_stepped(const FeatherHostOps *ops, FeatherInterp interp,
@@ -287,21 +614,41 @@ FeatherResult feather_script_eval_obj_stepped(const FeatherHostOps *ops, Feather
   ops = feather_get_ops(ops);
   size_t len = ops->string.byte_length(interp, script);
   FeatherResult result = TCL_OK;
+  FeatherInterpState *state = ops->interp.state(interp);
   FeatherParseContextObj ctx;
   feather_parse_init_obj(&ctx, script, len);
+  FeatherObj savedFile = begin_script_source(ops, interp, &ctx);
 
   FeatherParseStatus status;
+  int ran = 0;
   while ((status = feather_parse_command_obj(ops, interp, &ctx)) == TCL_PARSE_OK) {
     FeatherObj parsed = ops->interp.get_result(interp);
 
     // Only execute non-empty commands
     if (ops->list.length(interp, parsed) > 0) {
+      ran = 1;
+      ops->frame.set_line(interp, ctx.cmd_line);
+      if ((result = check_eval_limit(ops, interp, state)) != TCL_OK) {
+        end_script_source(ops, interp, &ctx, savedFile);
+        return result;
+      }
+      state->cmdcount++;
       result = feather_command_exec_stepped(ops, interp, parsed, stepTarget, flags);
+      if (result == TCL_OK) {
+        result = check_eval_limit(ops, interp, state);
+      }
       if (result != TCL_OK) {
+        end_script_source(ops, interp, &ctx, savedFile);
         return result;
       }
     }
   }
 
-  return (status == TCL_PARSE_DONE) ? result : TCL_ERROR;
+  result = (status == TCL_PARSE_DONE) ? result : TCL_ERROR;
+  if (result == TCL_OK && !ran) {
+    // Only whitespace and comments, whose result is the empty string
+    ops->interp.set_result(interp, ops->string.intern(interp, "", 0));
+  }
+  end_script_source(ops, interp, &ctx, savedFile);
+  return result;
 }
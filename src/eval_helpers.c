@@ -8,34 +8,67 @@ FeatherResult feather_eval_bool_condition(const FeatherHostOps *ops,
                                            FeatherInterp interp,
                                            FeatherObj condition,
                                            int *result) {
-  // Build args list with the condition for expr
-  FeatherObj exprArgs = ops->list.create(interp);
-  exprArgs = ops->list.push(interp, exprArgs, condition);
-
-  // Call expr builtin
-  FeatherObj exprCmd = ops->string.intern(interp, "expr", 4);
-  FeatherResult rc = feather_builtin_expr(ops, interp, exprCmd, exprArgs);
-  if (rc != TCL_OK) {
-    return rc;
-  }
-
-  FeatherObj resultObj = ops->interp.get_result(interp);
+  return feather_expr_bool(ops, interp, condition, result);
+}
 
-  // Check for boolean literals using helper
-  if (feather_obj_to_bool_literal(ops, interp, resultObj, result)) {
-    return TCL_OK;
+/**
+ * feather_parse_boolean recognizes the boolean words.
+ */
+int feather_parse_boolean(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj str) {
+  static const char *const words[] = {"true", "false", "yes", "no", "on", "off"};
+  static const int values[] = {1, 0, 1, 0, 1, 0};
+  size_t len = ops->string.byte_length(interp, str);
+  if (len == 1) {
+    int c = ops->string.byte_at(interp, str, 0);
+    if (c == '0' || c == '1') return c - '0';
   }
-
-  // Try as integer
-  int64_t intVal;
-  if (ops->integer.get(interp, resultObj, &intVal) == TCL_OK) {
-    *result = (intVal != 0) ? 1 : 0;
-    return TCL_OK;
+  int found = -1;
+  for (int w = 0; w < 6; w++) {
+    size_t n = feather_strlen(words[w]);
+    if (len == 0 || len > n) continue;
+    int ok = 1;
+    for (size_t i = 0; i < len && ok; i++) {
+      int c = ops->string.byte_at(interp, str, i);
+      if (c >= 'A' && c <= 'Z') c += 'a' - 'A';
+      ok = c == words[w][i];
+    }
+    if (!ok) continue;
+    if (len == n) return values[w];
+    if (found >= 0) return -1; // ambiguous prefix such as "o"
+    found = w;
   }
+  return found >= 0 ? values[found] : -1;
+}
 
-  // Invalid boolean expression
-  feather_error_expected(ops, interp, "boolean value", resultObj);
-  return TCL_ERROR;
+/**
+ * feather_return_options returns the return options for a completion code.
+ */
+FeatherObj feather_return_options(const FeatherHostOps *ops,
+                                  FeatherInterp interp,
+                                  FeatherResult code) {
+  if (code != TCL_OK) {
+    // The stored options belong to this exception if they were stored for
+    // its code; a return stores the code it returns instead
+    FeatherObj options = ops->interp.get_return_options(interp, code);
+    size_t n = ops->list.length(interp, options);
+    for (size_t i = 0; i + 1 < n; i += 2) {
+      if (feather_obj_eq_literal(ops, interp, ops->list.at(interp, options, i), "-code")) {
+        int64_t stored;
+        if (code == TCL_RETURN ||
+            (ops->integer.get(interp, ops->list.at(interp, options, i + 1), &stored) == TCL_OK &&
+             stored == (int64_t)code)) {
+          return options;
+        }
+        break;
+      }
+    }
+  }
+  FeatherObj options = ops->list.create(interp);
+  options = ops->list.push(interp, options, ops->string.intern(interp, "-code", 5));
+  options = ops->list.push(interp, options, ops->integer.create(interp, (int64_t)code));
+  options = ops->list.push(interp, options, ops->string.intern(interp, "-level", 6));
+  options = ops->list.push(interp, options, ops->integer.create(interp, 0));
+  return options;
 }
 
 /**
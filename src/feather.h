@@ -200,6 +200,8 @@ typedef struct {
   size_t pos;          // Current position
   size_t line;         // Current line number (1-based)
   size_t cmd_line;     // Line number where current command started
+  FeatherObj file;     // File the script was read from (0 = unknown)
+  int check_only;      // Check syntax only: don't substitute variables or commands
 } FeatherParseContextObj;
 
 /**
@@ -316,6 +318,68 @@ FeatherResult feather_script_eval(const FeatherHostOps *ops, FeatherInterp inter
 FeatherResult feather_script_eval_obj(const FeatherHostOps *ops, FeatherInterp interp,
                               FeatherObj script, FeatherEvalFlags flags);
 
+/**
+ * feather_eval_hooks_enable adjusts the number of hooks installed on interp
+ * that want ops->interp.eval_hook to be called, by delta (+1 or -1).
+ *
+ * The count is kept in the interpreter's FeatherInterpState, so each
+ * interpreter only pays for the hooks installed on it.
+ */
+void feather_eval_hooks_enable(const FeatherHostOps *ops, FeatherInterp interp, int delta);
+
+/**
+ * feather_eval_limits_enable adjusts the number of limits set on interp
+ * that want ops->interp.limit_check to be called, by delta (+1 or -1).
+ *
+ * The count is kept in the interpreter's FeatherInterpState, so each
+ * interpreter only pays for the limits set on it.
+ */
+void feather_eval_limits_enable(const FeatherHostOps *ops, FeatherInterp interp, int delta);
+
+/**
+ * FEATHER_DEFAULT_STACK_LIMIT is the number of bytes of C stack evaluation
+ * in an interpreter may use on a thread unless feather_set_stack_limit
+ * changes it.
+ */
+#define FEATHER_DEFAULT_STACK_LIMIT (4 * 1024 * 1024)
+
+/**
+ * feather_set_stack_limit sets the number of bytes of C stack evaluation
+ * in interp may use on a thread before commands fail with "out of stack
+ * space", and returns the previous limit. 0 turns the check off.
+ *
+ * The limit must leave room below the size of the threads' stacks for the
+ * host's callbacks and for unwinding the error. It is kept in the
+ * interpreter's FeatherInterpState, so each interpreter has its own.
+ */
+size_t feather_set_stack_limit(const FeatherHostOps *ops, FeatherInterp interp, size_t bytes);
+
+/**
+ * feather_stack_check measures the C stack in use on the calling thread,
+ * from the outermost call into the core seen on it, and fails with the
+ * error "out of stack space (infinite loop?)", code TCL LIMIT STACK, when
+ * it is over the limit.
+ *
+ * The evaluator calls it wherever it recurses: for each script and command
+ * and for each level of nesting in expressions, so that deeply nested
+ * substitutions and expressions stop with an error instead of overflowing
+ * the stack. Builtins that recurse by other means should call it too.
+ */
+FeatherResult feather_stack_check(const FeatherHostOps *ops, FeatherInterp interp);
+
+/**
+ * feather_traces_count records that delta traces of the given kind were
+ * added to interp (or removed, if negative).
+ *
+ * The trace command keeps the count itself; hosts only call this when they
+ * discard an interpreter's traces without it, such as when resetting the
+ * interpreter.
+ *
+ * kind must be "variable", "command", or "execution".
+ */
+void feather_traces_count(const FeatherHostOps *ops, FeatherInterp interp,
+                          const char *kind, int delta);
+
 /**
  * Flags for feather_subst controlling which substitutions to perform.
  */
@@ -335,8 +399,8 @@ typedef enum {
  * interpreter's result slot.
  *
  * This is the core substitution engine used by quoted strings in both
- * the main parser and expression evaluator, and implements the `subst`
- * command.
+ * the main parser and expression evaluator. The `subst` command uses
+ * feather_subst_string.
  *
  * Returns TCL_OK on success, TCL_ERROR on failure.
  */
@@ -350,6 +414,22 @@ FeatherResult feather_subst(const FeatherHostOps *ops, FeatherInterp interp,
 FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
                                  FeatherObj str, int flags);
 
+/**
+ * feather_subst_string performs substitutions on str as the subst command
+ * does, placing the result in the interpreter's result slot.
+ *
+ * Unlike feather_subst_obj, a command substitution may end with any code:
+ * break stops substituting and keeps what was substituted so far,
+ * continue substitutes an empty string, and return or any other code
+ * substitutes the command's result. Without TCL_SUBST_COMMANDS no command
+ * is run at all, not even in the index of a name like $a([cmd]), where
+ * TCL would run it.
+ *
+ * Returns TCL_OK on success, TCL_ERROR on failure.
+ */
+FeatherResult feather_subst_string(const FeatherHostOps *ops, FeatherInterp interp,
+                                   FeatherObj str, int flags);
+
 /**
  * The heart of the implementation.  An embedder needs to provide all of the
  * following operations.
@@ -436,7 +516,9 @@ typedef struct FeatherFrameOps {
   FeatherResult (*pop_locals)(FeatherInterp interp);
 
   /**
-   * set_line sets the line number for the current frame.
+   * set_line sets the line of the command running in the current frame.
+   * Lines count from the start of the frame's file (see set_file), or from
+   * the start of the script passed to eval when it has none.
    * Used to track source location for debugging and error reporting.
    */
   FeatherResult (*set_line)(FeatherInterp interp, size_t line);
@@ -447,6 +529,18 @@ typedef struct FeatherFrameOps {
    */
   size_t (*get_line)(FeatherInterp interp, size_t level);
 
+  /**
+   * set_file sets the file of the script running in the current frame.
+   * Pass 0 when the script did not come from a file.
+   */
+  FeatherResult (*set_file)(FeatherInterp interp, FeatherObj file);
+
+  /**
+   * get_file returns the file of the script running in the frame at the
+   * given level, or 0 if it did not come from a file.
+   */
+  FeatherObj (*get_file)(FeatherInterp interp, size_t level);
+
   /**
    * set_lambda stores the lambda expression for the current frame.
    * Used by apply to record the lambda for info frame.
@@ -458,8 +552,28 @@ typedef struct FeatherFrameOps {
    * Returns 0 if no lambda info is available (not an apply frame).
    */
   FeatherObj (*get_lambda)(FeatherInterp interp, size_t level);
+
+  /**
+   * set_word stores the word the command of the current frame was invoked
+   * by, such as "pp" for the proc ::ns::pp called from ::ns. Used by procs
+   * for info level, which reports the words of the command as written.
+   */
+  FeatherResult (*set_word)(FeatherInterp interp, FeatherObj word);
+
+  /**
+   * get_word returns the word stored by set_word for the frame at the given
+   * level. Returns 0 if none was stored.
+   */
+  FeatherObj (*get_word)(FeatherInterp interp, size_t level);
 } FeatherFrameOps;
 
+/**
+ * FeatherIndexFlags controls how string.get_index matches a word.
+ */
+typedef enum FeatherIndexFlags {
+  FEATHER_INDEX_EXACT = 1, /* Only accept the full name, not a unique prefix */
+} FeatherIndexFlags;
+
 /**
  * FeatherStringOps describes the string operations the host needs to support.
  *
@@ -482,6 +596,17 @@ typedef struct FeatherStringOps {
    */
   size_t (*byte_length)(FeatherInterp interp, FeatherObj str);
 
+  /**
+   * bytes returns a pointer to the bytes of the string and stores their
+   * number in *len, so that C code can scan the string without a call per
+   * byte. The bytes are not NUL-terminated and must not be modified; they
+   * stay valid for as long as handles to temporary objects do.
+   *
+   * Returns NULL if the host does not expose its storage, in which case
+   * callers read the string with byte_at. May be left NULL by hosts.
+   */
+  const char *(*bytes)(FeatherInterp interp, FeatherObj str, size_t *len);
+
   /**
    * slice returns a new string object containing bytes [start, end).
    * Returns empty string if start >= end or start >= length.
@@ -532,6 +657,24 @@ typedef struct FeatherStringOps {
                                int nocase, int *result,
                                FeatherObj *matches, FeatherObj *indices);
 
+  /**
+   * get_index looks up the string value of obj in table and stores the
+   * position of the matching entry in *index.
+   *
+   * table is a NULL-terminated array of names with static storage. A unique
+   * prefix of a name matches unless flags includes FEATHER_INDEX_EXACT; a
+   * full match always wins. The host may cache the result on obj, keyed by
+   * the table address, so a word that is looked up repeatedly (such as the
+   * subcommand of `dict get` in a loop body) is only resolved once.
+   *
+   * Returns TCL_ERROR if nothing matches. When what is non-NULL the result
+   * is set to `bad <what> "word": must be a, b, or c` (or `ambiguous ...`);
+   * when it is NULL the result is left alone so the caller can report its
+   * own message.
+   */
+  FeatherResult (*get_index)(FeatherInterp interp, FeatherObj obj, const char *const *table,
+                             const char *what, int flags, int *index);
+
   /**
    * builder_new creates a new string builder with optional initial capacity.
    */
@@ -753,6 +896,115 @@ typedef struct FeatherDoubleOps {
                         double b, double *out);
 } FeatherDoubleOps;
 
+/**
+ * FeatherBignumOp identifies an arbitrary-precision integer operation.
+ * Unary operations use parameter 'a' only; binary operations use both 'a' and 'b'.
+ */
+typedef enum FeatherBignumOp {
+  /* Binary operations */
+  FEATHER_BIG_ADD,
+  FEATHER_BIG_SUB,
+  FEATHER_BIG_MUL,
+  FEATHER_BIG_DIV, /* Rounds toward negative infinity */
+  FEATHER_BIG_MOD, /* Result has the sign of the divisor */
+  FEATHER_BIG_POW,
+  FEATHER_BIG_AND,
+  FEATHER_BIG_OR,
+  FEATHER_BIG_XOR,
+  FEATHER_BIG_SHL,
+  FEATHER_BIG_SHR,
+
+  /* Unary operations */
+  FEATHER_BIG_NEG,
+  FEATHER_BIG_NOT,
+} FeatherBignumOp;
+
+/**
+ * FeatherBignumOps gives access to integers of any size from the host.
+ *
+ * expr promotes to these operations when an integer no longer fits in
+ * 64 bits. Every result that does fit is returned as a plain integer, so
+ * ops->integer.get succeeds on it; the host must make ops->integer.get
+ * fail for values outside the int64 range.
+ */
+typedef struct FeatherBignumOps {
+  /**
+   * get parses obj as an integer of any size, accepting the same syntax
+   * as integer literals (sign, 0x/0o/0b/0d prefixes).
+   *
+   * Returns TCL_ERROR without touching the interpreter result if obj is
+   * not an integer.
+   */
+  FeatherResult (*get)(FeatherInterp interp, FeatherObj obj, FeatherObj *out);
+
+  /**
+   * arith computes an operation on integers of any size.
+   * @param op The operation to perform
+   * @param a First operand (used by all operations)
+   * @param b Second operand (used only by binary operations)
+   * @param out Result is written here on success
+   * @return TCL_OK on success, TCL_ERROR with the interpreter result set on
+   *         division by zero, negative shifts and results too large to
+   *         represent
+   */
+  FeatherResult (*arith)(FeatherInterp interp, FeatherBignumOp op,
+                         FeatherObj a, FeatherObj b, FeatherObj *out);
+
+  /**
+   * compare returns <0, 0 or >0 as a is less than, equal to or greater
+   * than b.
+   */
+  int (*compare)(FeatherInterp interp, FeatherObj a, FeatherObj b);
+
+  /**
+   * format converts an integer to its digits in the given base (2, 8, 10
+   * or 16), preceded by '-' if it is negative.
+   */
+  FeatherObj (*format)(FeatherInterp interp, FeatherObj obj, int base,
+                       int uppercase);
+} FeatherBignumOps;
+
+/**
+ * FeatherTailcall is a command requested with tailcall, to run in place of
+ * the proc or lambda whose frame is at level once that frame is popped.
+ */
+typedef struct FeatherTailcall {
+  FeatherObj cmd;  // the command list, 0 if none
+  FeatherObj ns;   // the namespace it is resolved in
+  size_t level;    // the frame level of the proc or lambda
+} FeatherTailcall;
+
+/**
+ * FeatherInterpState is the state the core keeps for each interpreter
+ * across calls. The host allocates it, zeroed, when it creates the
+ * interpreter, frees it when it deletes the interpreter, and returns it
+ * from ops->interp.state. Only the core changes it.
+ */
+typedef struct FeatherInterpState {
+  /** The number of commands scripts have run, for info cmdcount. */
+  uint64_t cmdcount;
+  /** The number of namespaces with a command path, so that command
+   * lookups only look for one once there are some. */
+  size_t paths;
+  /** The number of limits enabled with feather_eval_limits_enable. */
+  int eval_limits;
+  /** The number of hooks enabled with feather_eval_hooks_enable. */
+  int eval_hooks;
+  /** The number of traces of each kind, variable, command and execution,
+   * so that commands and variable accesses need not look up traces of a
+   * kind the interpreter has none of. */
+  int traces[3];
+  /** The limit set with feather_set_stack_limit: 0 until it is called,
+   * for FEATHER_DEFAULT_STACK_LIMIT, and SIZE_MAX once the check is off. */
+  size_t stack_limit;
+  /** The tail call waiting for the proc or lambda at its level to pop its
+   * frame, and the one that did, ready for feather_command_exec to run.
+   * They belong to the call stack running, so hosts with coroutines swap
+   * them along with it. */
+  FeatherTailcall tailcall_pending;
+  FeatherTailcall tailcall_ready;
+} FeatherInterpState;
+
 /**
  * FeatherInterpOps holds the operations on the state of the
  * interpreter instance.
@@ -819,6 +1071,86 @@ typedef struct FeatherInterpOps {
    * Pass nil or empty string to clear.
    */
   void (*set_script)(FeatherInterp interp, FeatherObj path);
+
+  /**
+   * eval_hook reports a command the evaluation loop is about to execute.
+   *
+   * command is the command and its arguments as a list, after substitution.
+   * line is the line where the command starts, counted as for frame.set_line.
+   *
+   * Only called while at least one hook is enabled on the interpreter
+   * with feather_eval_hooks_enable, so interpreters without a hook pay
+   * nothing for it.
+   */
+  void (*eval_hook)(FeatherInterp interp, FeatherObj command, size_t line);
+
+  /**
+   * eval_done_hook reports that a command reported by eval_hook finished
+   * with the given result code.
+   *
+   * Called under the same conditions as eval_hook, once for each call of
+   * it, in reverse order for nested commands.
+   */
+  void (*eval_done_hook)(FeatherInterp interp, FeatherObj command, FeatherResult code);
+
+  /**
+   * limit_check reports whether evaluation may go on, for hosts that stop
+   * scripts that run too long or build values that are too large.
+   *
+   * The evaluation loop calls it before and after each command and at the
+   * start of each script. Returning TCL_ERROR after a command fails that
+   * command. Returning TCL_ERROR, with the result and return options
+   * set to describe the error, stops the script as a failing command would,
+   * so that the frames of the commands running unwind as usual. A host that
+   * stops a script for good must keep returning TCL_ERROR until it is back
+   * at the top level, so that scripts cannot catch the error and carry on.
+   *
+   * Only called while at least one limit is enabled on the interpreter
+   * with feather_eval_limits_enable.
+   */
+  FeatherResult (*limit_check)(FeatherInterp interp);
+
+  /**
+   * trace_hook reports a trace about to run, for hosts that log them.
+   *
+   * kind is "variable", "command" or "execution", and command is the
+   * callback the trace runs, with the arguments it is given, as a list.
+   */
+  void (*trace_hook)(FeatherInterp interp, const char *kind, FeatherObj command);
+
+  /**
+   * set_source records where the text of obj appears in the source:
+   * the file it was read from (0 if none) and the line it starts on.
+   *
+   * The parser calls it for braced words and command substitutions, so that
+   * scripts passed to proc, if, catch and similar commands report the lines
+   * they occupy in the enclosing script rather than counting from 1.
+   * Hosts may ignore it; copies of obj need not keep the location.
+   */
+  void (*set_source)(FeatherInterp interp, FeatherObj obj, FeatherObj file, size_t line);
+
+  /**
+   * get_source returns the line recorded for obj by set_source and stores
+   * its file in *file. Returns 0 if obj has no recorded location.
+   */
+  size_t (*get_source)(FeatherInterp interp, FeatherObj obj, FeatherObj *file);
+
+  /**
+   * state returns the interpreter's FeatherInterpState. The evaluation
+   * loop asks for it once for each script it runs.
+   */
+  FeatherInterpState *(*state)(FeatherInterp interp);
+
+  /**
+   * eval_on_new_stack evaluates script as feather_script_eval_obj does,
+   * but on a C stack of its own. Procs and lambdas run their bodies with it
+   * once they have used half the stack limit, so that recursion is limited
+   * by the recursion limit rather than by the stack of the thread.
+   *
+   * Hosts that cannot switch stacks evaluate script on the current one.
+   */
+  FeatherResult (*eval_on_new_stack)(FeatherInterp interp, FeatherObj script,
+                                     FeatherEvalFlags flags);
 } FeatherInterpOps;
 
 /**
@@ -913,7 +1245,9 @@ typedef struct FeatherVarOps {
    *
    * If the variable is a link (via upvar, global, or variable commands),
    * returns the name of the target variable that traces should be looked up on.
-   * If the variable is not a link, returns the original name.
+   * If the variable is not a link, returns the original name. Namespace
+   * variables, including globals, are returned fully qualified ("::x",
+   * "::ns::x"), so that every name of a variable resolves to the same one.
    *
    * This is needed for trace support: traces are registered on the actual
    * variable, but when accessed via a link, the trace should be called with
@@ -1412,6 +1746,7 @@ typedef struct FeatherHostOps {
   FeatherDictOps dict;
   FeatherIntOps integer;
   FeatherDoubleOps dbl;
+  FeatherBignumOps bignum;
   FeatherInterpOps interp;
   FeatherBindOpts bind;
   FeatherForeignOps foreign;
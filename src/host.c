@@ -15,8 +15,12 @@ static FeatherHostOps default_ops = {
         .pop_locals = feather_host_frame_pop_locals,
         .set_line = feather_host_frame_set_line,
         .get_line = feather_host_frame_get_line,
+        .set_file = feather_host_frame_set_file,
+        .get_file = feather_host_frame_get_file,
         .set_lambda = feather_host_frame_set_lambda,
         .get_lambda = feather_host_frame_get_lambda,
+        .set_word = feather_host_frame_set_word,
+        .get_word = feather_host_frame_get_word,
     },
     .var = {
         .get = feather_host_var_get,
@@ -52,12 +56,14 @@ static FeatherHostOps default_ops = {
     .string = {
         .byte_at = feather_host_string_byte_at,
         .byte_length = feather_host_string_byte_length,
+        .bytes = feather_host_string_bytes,
         .slice = feather_host_string_slice,
         .concat = feather_host_string_concat,
         .compare = feather_host_string_compare,
         .equal = feather_host_string_equal,
         .match = feather_host_string_match,
         .regex_match = feather_host_string_regex_match,
+        .get_index = feather_host_string_get_index,
         .builder_new = feather_host_string_builder_new,
         .builder_append_byte = feather_host_string_builder_append_byte,
         .builder_append_obj = feather_host_string_builder_append_obj,
@@ -111,6 +117,12 @@ static FeatherHostOps default_ops = {
         .format = feather_host_dbl_format,
         .math = feather_host_dbl_math,
     },
+    .bignum = {
+        .get = feather_host_bignum_get,
+        .arith = feather_host_bignum_arith,
+        .compare = feather_host_bignum_compare,
+        .format = feather_host_bignum_format,
+    },
     .interp = {
         .set_result = feather_host_interp_set_result,
         .get_result = feather_host_interp_get_result,
@@ -119,6 +131,14 @@ static FeatherHostOps default_ops = {
         .get_return_options = feather_host_interp_get_return_options,
         .get_script = feather_host_interp_get_script,
         .set_script = feather_host_interp_set_script,
+        .eval_hook = feather_host_interp_eval_hook,
+        .eval_done_hook = feather_host_interp_eval_done_hook,
+        .limit_check = feather_host_interp_limit_check,
+        .trace_hook = feather_host_interp_trace_hook,
+        .set_source = feather_host_interp_set_source,
+        .get_source = feather_host_interp_get_source,
+        .state = feather_host_interp_state,
+        .eval_on_new_stack = feather_host_interp_eval_on_new_stack,
     },
     .bind = {
         .unknown = feather_host_bind_unknown,
@@ -36,8 +36,12 @@ extern FeatherResult feather_host_frame_push_locals(FeatherInterp interp, Feathe
 extern FeatherResult feather_host_frame_pop_locals(FeatherInterp interp);
 extern FeatherResult feather_host_frame_set_line(FeatherInterp interp, size_t line);
 extern size_t feather_host_frame_get_line(FeatherInterp interp, size_t level);
+extern FeatherResult feather_host_frame_set_file(FeatherInterp interp, FeatherObj file);
+extern FeatherObj feather_host_frame_get_file(FeatherInterp interp, size_t level);
 extern FeatherResult feather_host_frame_set_lambda(FeatherInterp interp, FeatherObj lambda);
 extern FeatherObj feather_host_frame_get_lambda(FeatherInterp interp, size_t level);
+extern FeatherResult feather_host_frame_set_word(FeatherInterp interp, FeatherObj word);
+extern FeatherObj feather_host_frame_get_word(FeatherInterp interp, size_t level);
 
 /* ============================================================================
  * Variable Operations (7 functions)
@@ -89,11 +93,12 @@ extern FeatherResult feather_host_ns_copy_command(FeatherInterp interp, FeatherO
                                                   FeatherObj dstName);
 
 /* ============================================================================
- * String Operations (13 functions)
+ * String Operations (15 functions)
  * ============================================================================ */
 
 extern int feather_host_string_byte_at(FeatherInterp interp, FeatherObj str, size_t index);
 extern size_t feather_host_string_byte_length(FeatherInterp interp, FeatherObj str);
+extern const char *feather_host_string_bytes(FeatherInterp interp, FeatherObj str, size_t *len);
 extern FeatherObj feather_host_string_slice(FeatherInterp interp, FeatherObj str, size_t start,
                                             size_t end);
 extern FeatherObj feather_host_string_concat(FeatherInterp interp, FeatherObj a, FeatherObj b);
@@ -104,6 +109,9 @@ extern int feather_host_string_match(FeatherInterp interp, FeatherObj pattern, F
 extern FeatherResult feather_host_string_regex_match(FeatherInterp interp, FeatherObj pattern,
                                                      FeatherObj string, int nocase, int *result,
                                                      FeatherObj *matches, FeatherObj *indices);
+extern FeatherResult feather_host_string_get_index(FeatherInterp interp, FeatherObj obj,
+                                                  const char *const *table, const char *what,
+                                                  int flags, int *index);
 extern FeatherObj feather_host_string_builder_new(FeatherInterp interp, size_t capacity);
 extern void feather_host_string_builder_append_byte(FeatherInterp interp, FeatherObj builder,
                                                     int byte);
@@ -186,7 +194,18 @@ extern FeatherResult feather_host_dbl_math(FeatherInterp interp, FeatherMathOp o
                                            double b, double *out);
 
 /* ============================================================================
- * Interp Operations (7 functions)
+ * Bignum Operations (4 functions)
+ * ============================================================================ */
+
+extern FeatherResult feather_host_bignum_get(FeatherInterp interp, FeatherObj obj, FeatherObj *out);
+extern FeatherResult feather_host_bignum_arith(FeatherInterp interp, FeatherBignumOp op,
+                                               FeatherObj a, FeatherObj b, FeatherObj *out);
+extern int feather_host_bignum_compare(FeatherInterp interp, FeatherObj a, FeatherObj b);
+extern FeatherObj feather_host_bignum_format(FeatherInterp interp, FeatherObj obj, int base,
+                                             int uppercase);
+
+/* ============================================================================
+ * Interp Operations (13 functions)
  * ============================================================================ */
 
 extern FeatherResult feather_host_interp_set_result(FeatherInterp interp, FeatherObj result);
@@ -197,6 +216,18 @@ extern FeatherResult feather_host_interp_set_return_options(FeatherInterp interp
 extern FeatherObj feather_host_interp_get_return_options(FeatherInterp interp, FeatherResult code);
 extern FeatherObj feather_host_interp_get_script(FeatherInterp interp);
 extern void feather_host_interp_set_script(FeatherInterp interp, FeatherObj path);
+extern void feather_host_interp_eval_hook(FeatherInterp interp, FeatherObj command, size_t line);
+extern void feather_host_interp_eval_done_hook(FeatherInterp interp, FeatherObj command,
+                                               FeatherResult code);
+extern FeatherResult feather_host_interp_limit_check(FeatherInterp interp);
+extern void feather_host_interp_trace_hook(FeatherInterp interp, const char *kind,
+                                           FeatherObj command);
+extern void feather_host_interp_set_source(FeatherInterp interp, FeatherObj obj, FeatherObj file,
+                                           size_t line);
+extern size_t feather_host_interp_get_source(FeatherInterp interp, FeatherObj obj, FeatherObj *file);
+extern FeatherInterpState *feather_host_interp_state(FeatherInterp interp);
+extern FeatherResult feather_host_interp_eval_on_new_stack(FeatherInterp interp, FeatherObj script,
+                                                           FeatherEvalFlags flags);
 
 /* ============================================================================
  * Bind Operations (1 function)
@@ -6,73 +6,133 @@ static int get_byte(const FeatherHostOps *ops, FeatherInterp interp,
   return ops->string.byte_at(interp, obj, pos);
 }
 
-static int parse_int64_obj(const FeatherHostOps *ops, FeatherInterp interp,
+static int is_index_space(int c) {
+  return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f';
+}
+
+// Digit value of c in the given base, or -1
+static int index_digit(int c, int base) {
+  int d = -1;
+  if (c >= '0' && c <= '9') d = c - '0';
+  else if (c >= 'a' && c <= 'z') d = c - 'a' + 10;
+  else if (c >= 'A' && c <= 'Z') d = c - 'A' + 10;
+  return d < base ? d : -1;
+}
+
+// parse_index_int parses an integer as TCL does in indices: an optional
+// sign, then decimal digits or digits after a 0x, 0o, 0b or 0d prefix. A
+// leading 0 does not make the integer octal. Values too large for 64 bits
+// are clamped, since they are out of range for any list. Returns 1 and
+// advances *pos past the integer, or 0 if there is none at *pos.
+static int parse_index_int(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj obj, size_t len, size_t *pos, int64_t *out) {
-  if (*pos >= len) return 0;
-  int ch = get_byte(ops, interp, obj, *pos);
+  size_t p = *pos;
   int negative = 0;
-  if (ch == '-') {
-    negative = 1;
-    (*pos)++;
-    ch = get_byte(ops, interp, obj, *pos);
-  } else if (ch == '+') {
-    (*pos)++;
-    ch = get_byte(ops, interp, obj, *pos);
+  int ch = get_byte(ops, interp, obj, p);
+  if (ch == '-' || ch == '+') {
+    negative = ch == '-';
+    p++;
   }
-  if (*pos >= len || ch < '0' || ch > '9') return 0;
-  int64_t val = 0;
-  while (*pos < len) {
-    ch = get_byte(ops, interp, obj, *pos);
-    if (ch < '0' || ch > '9') break;
-    val = val * 10 + (ch - '0');
-    (*pos)++;
+  if (p >= len || index_digit(get_byte(ops, interp, obj, p), 10) < 0) {
+    return 0;
   }
-  *out = negative ? -val : val;
+
+  int base = 10;
+  if (get_byte(ops, interp, obj, p) == '0' && p + 1 < len) {
+    int prefix = get_byte(ops, interp, obj, p + 1);
+    int prefixBase = 0;
+    if (prefix == 'x' || prefix == 'X') prefixBase = 16;
+    else if (prefix == 'o' || prefix == 'O') prefixBase = 8;
+    else if (prefix == 'b' || prefix == 'B') prefixBase = 2;
+    else if (prefix == 'd' || prefix == 'D') prefixBase = 10;
+    if (prefixBase) {
+      base = prefixBase;
+      p += 2;
+      if (p >= len || index_digit(get_byte(ops, interp, obj, p), base) < 0) {
+        return 0;
+      }
+    }
+  }
+
+  uint64_t val = 0;
+  uint64_t limit = (uint64_t)INT64_MAX + (negative ? 1 : 0);
+  while (p < len) {
+    int d = index_digit(get_byte(ops, interp, obj, p), base);
+    if (d < 0) break;
+    if (val > (limit - (uint64_t)d) / (uint64_t)base) {
+      val = limit;
+    } else {
+      val = val * (uint64_t)base + (uint64_t)d;
+    }
+    p++;
+  }
+
+  *out = negative ? (int64_t)(0 - val) : (int64_t)val;
+  *pos = p;
   return 1;
 }
 
+// add_offset returns base+offset, or base-offset if op is '-', clamped to
+// the range of int64_t so that huge offsets stay out of range.
+static int64_t add_offset(int64_t base, int op, int64_t offset) {
+  if (op == '-') {
+    if (offset == INT64_MIN) return base >= 0 ? INT64_MAX : base - INT64_MIN;
+    offset = -offset;
+  }
+  if (offset > 0 && base > INT64_MAX - offset) return INT64_MAX;
+  if (offset < 0 && base < INT64_MIN - offset) return INT64_MIN;
+  return base + offset;
+}
+
+// feather_parse_index parses an index into a list or string of listLen
+// elements, using TCL's index syntax:
+//
+//   integer              e.g. 3, 0x1f, 0b101 or 0o17
+//   integer[+-]integer   e.g. 2+1 or 5-2
+//   end                  the last element
+//   end[+-]integer       e.g. end-1 or end+1
+//
+// Each integer may have its own sign, as in end--1 or 1-+1. Integer forms
+// may be surrounded by whitespace, while end forms may only be followed by
+// it. The index is stored in *out and may be out of range; callers decide
+// what that means.
 FeatherResult feather_parse_index(const FeatherHostOps *ops, FeatherInterp interp,
                                   FeatherObj indexObj, size_t listLen, int64_t *out) {
   size_t len = ops->string.byte_length(interp, indexObj);
   size_t pos = 0;
   int64_t base;
-  int is_end = 0;
 
-  // Check for "end"
-  int c0 = get_byte(ops, interp, indexObj, 0);
-  int c1 = get_byte(ops, interp, indexObj, 1);
-  int c2 = get_byte(ops, interp, indexObj, 2);
-  if (len >= 3 && c0 == 'e' && c1 == 'n' && c2 == 'd') {
-    base = (int64_t)listLen - 1;
-    pos = 3;
-    is_end = 1;
-  } else {
-    if (!parse_int64_obj(ops, interp, indexObj, len, &pos, &base)) {
-      goto bad_index;
+  if (len >= 3 && get_byte(ops, interp, indexObj, 0) == 'e' &&
+      get_byte(ops, interp, indexObj, 1) == 'n' &&
+      get_byte(ops, interp, indexObj, 2) == 'd') {
+    if (len == 3) {
+      *out = (int64_t)listLen - 1;
+      return TCL_OK;
     }
+    int op = get_byte(ops, interp, indexObj, 3);
+    if (op != '+' && op != '-') goto bad_index;
+    pos = 4;
+    int64_t offset;
+    if (!parse_index_int(ops, interp, indexObj, len, &pos, &offset)) goto bad_index;
+    while (pos < len && is_index_space(get_byte(ops, interp, indexObj, pos))) pos++;
+    if (pos < len) goto bad_index;
+    *out = add_offset((int64_t)listLen - 1, op, offset);
+    return TCL_OK;
   }
 
-  while (pos < len) {
-    int ch = get_byte(ops, interp, indexObj, pos);
-    if (ch == '+' || ch == '-') {
-      int op = ch;
-      pos++;
-      int64_t offset;
-      if (!parse_int64_obj(ops, interp, indexObj, len, &pos, &offset)) {
-        goto bad_index;
-      }
-      if (op == '+') {
-        base += offset;
-      } else {
-        base -= offset;
-      }
-    } else {
-      goto bad_index;
-    }
+  while (pos < len && is_index_space(get_byte(ops, interp, indexObj, pos))) pos++;
+  if (!parse_index_int(ops, interp, indexObj, len, &pos, &base)) goto bad_index;
+  int op = pos < len ? get_byte(ops, interp, indexObj, pos) : 0;
+  if (op == '+' || op == '-') {
+    pos++;
+    int64_t offset;
+    if (!parse_index_int(ops, interp, indexObj, len, &pos, &offset)) goto bad_index;
+    base = add_offset(base, op, offset);
   }
+  while (pos < len && is_index_space(get_byte(ops, interp, indexObj, pos))) pos++;
+  if (pos < len) goto bad_index;
 
   *out = base;
-  (void)is_end;
   return TCL_OK;
 
 bad_index:;
@@ -84,3 +144,28 @@ bad_index:;
   ops->interp.set_result(interp, msg);
   return TCL_ERROR;
 }
+
+// feather_check_sublist_index checks an index given to -index, as lsearch
+// and lsort do before looking at any sublist: besides being well formed, it
+// must be able to select an element from some list, which rules out
+// negative integers and end+N for positive N.
+FeatherResult feather_check_sublist_index(const FeatherHostOps *ops, FeatherInterp interp,
+                                          FeatherObj indexObj) {
+  int64_t empty, single;
+  if (feather_parse_index(ops, interp, indexObj, 0, &empty) != TCL_OK ||
+      feather_parse_index(ops, interp, indexObj, 1, &single) != TCL_OK) {
+    return TCL_ERROR;
+  }
+  // Only end-relative indices depend on the length of the list
+  int endRelative = empty != single;
+  if ((!endRelative && empty < 0) || (endRelative && empty >= 0)) {
+    FeatherObj msg = ops->string.intern(interp, "index \"", 7);
+    msg = ops->string.concat(interp, msg, indexObj);
+    FeatherObj suffix = ops->string.intern(interp,
+      "\" cannot select an element from any list", 40);
+    msg = ops->string.concat(interp, msg, suffix);
+    ops->interp.set_result(interp, msg);
+    return TCL_ERROR;
+  }
+  return TCL_OK;
+}
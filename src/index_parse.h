@@ -6,4 +6,7 @@
 FeatherResult feather_parse_index(const FeatherHostOps *ops, FeatherInterp interp,
                                   FeatherObj indexObj, size_t listLen, int64_t *out);
 
+FeatherResult feather_check_sublist_index(const FeatherHostOps *ops, FeatherInterp interp,
+                                          FeatherObj indexObj);
+
 #endif
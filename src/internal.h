@@ -2,6 +2,7 @@
 #define INCLUDE_FEATHER_INTERNAL
 
 #include "feather.h"
+#include "parse_helpers.h"
 
 // Internal forward declarations go here
 
@@ -28,19 +29,16 @@ static inline int feather_obj_eq_literal(const FeatherHostOps *ops, FeatherInter
 /**
  * feather_obj_is_qualified checks if an object's string value contains "::".
  *
- * Uses byte-at-a-time access to avoid ops->string.get().
+ * Reads the bytes with feather_bytes to avoid ops->string.get().
  * Returns 1 if qualified (contains "::"), 0 otherwise.
  */
 static inline int feather_obj_is_qualified(const FeatherHostOps *ops, FeatherInterp interp,
                                            FeatherObj obj) {
-    size_t len = ops->string.byte_length(interp, obj);
-    for (size_t i = 0; i + 1 < len; i++) {
-        int c1 = ops->string.byte_at(interp, obj, i);
-        if (c1 == ':') {
-            int c2 = ops->string.byte_at(interp, obj, i + 1);
-            if (c2 == ':') {
-                return 1;
-            }
+    FeatherBytes b = feather_bytes(ops, interp, obj);
+    for (size_t i = 0; i + 1 < b.len; i++) {
+        if (feather_bytes_at(ops, interp, &b, i) == ':' &&
+            feather_bytes_at(ops, interp, &b, i + 1) == ':') {
+            return 1;
         }
     }
     return 0;
@@ -118,11 +116,44 @@ static inline int feather_obj_to_bool_literal(const FeatherHostOps *ops, Feather
     return 0;
 }
 
+/**
+ * feather_parse_boolean recognizes the boolean forms accepted by string is
+ * boolean and by conditions: 0, 1, and unique case-insensitive prefixes of
+ * true, false, yes, no, on and off.
+ *
+ * Returns 1 or 0 for the value, or -1 if str is not a boolean.
+ */
+int feather_parse_boolean(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj str);
+
+/**
+ * feather_list_error_index finds the first malformed element of a list.
+ *
+ * Returns the byte offset at which that element starts, or -1 if s is a
+ * well-formed list. The interpreter result is left with the parse error.
+ */
+int64_t feather_list_error_index(const FeatherHostOps *ops, FeatherInterp interp,
+                                 FeatherObj s);
+
+/**
+ * feather_expr_bool evaluates an expression as expr does and converts its
+ * value to a boolean.
+ *
+ * Numbers are true when non-zero; other values must be boolean words, as
+ * for feather_parse_boolean. The interpreter result is left unspecified
+ * on success.
+ *
+ * On success, stores 0 or 1 in *result and returns TCL_OK.
+ * On error (invalid expression or boolean), sets error message and returns
+ * TCL_ERROR.
+ */
+FeatherResult feather_expr_bool(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj expr, int *result);
+
 /**
  * feather_eval_bool_condition evaluates an expression and converts to boolean.
  *
- * Calls expr builtin, then checks for boolean literals (true/false/yes/no)
- * or converts integer result to boolean (0 = false, non-zero = true).
+ * Evaluates the expression with feather_expr_bool, so the interpreter
+ * result is left unspecified on success.
  *
  * On success, stores 0 or 1 in *result and returns TCL_OK.
  * On error (invalid boolean), sets error message and returns TCL_ERROR.
@@ -132,6 +163,21 @@ FeatherResult feather_eval_bool_condition(const FeatherHostOps *ops,
                                            FeatherObj condition,
                                            int *result);
 
+/**
+ * feather_return_options returns the return options dictionary for a
+ * script that completed with code, as catch and try report it.
+ *
+ * An exception uses the options stored for it, such as by error or return;
+ * stored options whose -code is not the exception's are left over from an
+ * earlier command and ignored. Otherwise, and for TCL_OK, the options are
+ * {-code code -level 0}. Commands that
+ * consume an error's options, like catch and try, clear the stored options
+ * so that a later error raised without any does not pick them up.
+ */
+FeatherObj feather_return_options(const FeatherHostOps *ops,
+                                  FeatherInterp interp,
+                                  FeatherResult code);
+
 /**
  * feather_error_expected constructs an error message of the form:
  * "expected <type> but got \"<value>\""
@@ -275,13 +321,58 @@ FeatherBuiltinCmd feather_lookup_builtin(const char *name, size_t len);
 FeatherResult feather_builtin_proc(const FeatherHostOps *ops, FeatherInterp interp,
                            FeatherObj cmd, FeatherObj args);
 
+/**
+ * feather_check_param_spec validates a formal parameter specifier of
+ * proc or apply: a name with an optional default value. The name must be
+ * non-empty and a simple scalar variable name. On failure, leaves a TCL
+ * compatible error message in the interpreter result.
+ */
+FeatherResult feather_check_param_spec(const FeatherHostOps *ops, FeatherInterp interp,
+                                       FeatherObj paramSpec);
+
 /**
  * feather_invoke_proc invokes a user-defined procedure.
  *
- * Handles frame push/pop, parameter binding, and body evaluation.
+ * name is the fully qualified name of the procedure and word the word it
+ * was invoked by, which info level reports. Handles frame push/pop,
+ * parameter binding, and body evaluation.
  */
 FeatherResult feather_invoke_proc(const FeatherHostOps *ops, FeatherInterp interp,
-                          FeatherObj name, FeatherObj args);
+                          FeatherObj name, FeatherObj word, FeatherObj args);
+
+/**
+ * feather_tailcall_request records cmd, resolved in ns, as the tail call of
+ * the proc or lambda at level.
+ */
+void feather_tailcall_request(const FeatherHostOps *ops, FeatherInterp interp,
+                              FeatherObj cmd, FeatherObj ns, size_t level);
+
+/**
+ * feather_tailcall_begin is called by an invocation of a proc or lambda
+ * once its frame is pushed. It returns the tail call pending for an
+ * enclosing invocation, to be given back to feather_tailcall_end.
+ */
+FeatherTailcall feather_tailcall_begin(FeatherInterpState *state);
+
+/**
+ * feather_tailcall_end is called by an invocation of a proc or lambda at
+ * level once its frame is popped, with the code it would return. If the
+ * body requested a tail call and did not fail, the call is left for
+ * feather_command_exec to run in place of the invocation, and TCL_OK is
+ * returned; a failing body drops it. The tail call saved by
+ * feather_tailcall_begin is pending again afterwards.
+ */
+FeatherResult feather_tailcall_end(FeatherInterpState *state, FeatherTailcall saved,
+                                   size_t level, FeatherResult code);
+
+/**
+ * feather_body_eval evaluates the body of a proc or lambda, whose frame
+ * is pushed. Once half the stack limit is used, it is evaluated on a new
+ * stack with ops->interp.eval_on_new_stack, so that recursion through
+ * procs takes no more of the stack of the thread.
+ */
+FeatherResult feather_body_eval(const FeatherHostOps *ops, FeatherInterp interp,
+                                FeatherObj body);
 
 /**
  * feather_builtin_if implements the TCL 'if' command.
@@ -624,10 +715,30 @@ FeatherResult feather_builtin_rename(const FeatherHostOps *ops, FeatherInterp in
  *   children ?ns?       - list child namespaces
  *   parent ?ns?         - get parent namespace
  *   delete ns ?ns ...?  - delete namespaces
+ *   ensemble subcmd ... - create and configure ensemble commands
  */
 FeatherResult feather_builtin_namespace(const FeatherHostOps *ops, FeatherInterp interp,
                                  FeatherObj cmd, FeatherObj args);
 
+/**
+ * feather_ensemble_dispatch implements commands created by
+ * 'namespace ensemble create'.
+ *
+ * Looks up the first argument among the ensemble's subcommands (allowing
+ * unique prefixes unless -prefixes is off) and invokes the command it maps
+ * to with the remaining arguments.
+ */
+FeatherResult feather_ensemble_dispatch(const FeatherHostOps *ops, FeatherInterp interp,
+                                        FeatherObj cmd, FeatherObj args);
+
+/**
+ * feather_ensemble_rename moves the configuration of an ensemble command
+ * from oldName to newName, or drops it if newName is empty.
+ * Both names are fully qualified. Does nothing for other commands.
+ */
+void feather_ensemble_rename(const FeatherHostOps *ops, FeatherInterp interp,
+                             FeatherObj oldName, FeatherObj newName);
+
 /**
  * feather_builtin_variable implements the TCL 'variable' command.
  *
@@ -976,6 +1087,15 @@ FeatherObj feather_trace_get_dict(const FeatherHostOps *ops, FeatherInterp inter
 void feather_trace_set_dict(const FeatherHostOps *ops, FeatherInterp interp,
                             const char *kind, FeatherObj dict);
 
+/**
+ * feather_traces_active reports whether the interpreter may have traces of
+ * the given kind, so that callers can skip looking them up when it has none.
+ *
+ * kind must be "variable", "command", or "execution".
+ * Returns 1 if traces of the kind may exist, 0 if none do.
+ */
+int feather_traces_active(const FeatherHostOps *ops, FeatherInterp interp, const char *kind);
+
 /**
  * feather_fire_var_traces fires variable traces for the given operation.
  *
@@ -1118,6 +1238,33 @@ void feather_unset_var(const FeatherHostOps *ops, FeatherInterp interp,
 int feather_var_exists(const FeatherHostOps *ops, FeatherInterp interp,
                        FeatherObj name);
 
+// ============================================================================
+// Integer literals of any size
+// ============================================================================
+
+// Append a digit to an integer being read in the given base.
+// *val holds the value while it fits in an int64. When it overflows and big
+// is not NULL, *big takes over as an integer object of any size; *big must
+// start out as 0. Without big the value wraps around.
+static inline void feather_accumulate_digit(const FeatherHostOps *ops, FeatherInterp interp,
+                                            int64_t *val, FeatherObj *big, int base, int digit) {
+  if (big == NULL) {
+    *val = (int64_t)((uint64_t)*val * (uint64_t)base + (uint64_t)digit);
+    return;
+  }
+  if (*big == 0) {
+    int64_t next;
+    if (!__builtin_mul_overflow(*val, (int64_t)base, &next) &&
+        !__builtin_add_overflow(next, (int64_t)digit, &next)) {
+      *val = next;
+      return;
+    }
+    *big = ops->integer.create(interp, *val);
+  }
+  ops->bignum.arith(interp, FEATHER_BIG_MUL, *big, ops->integer.create(interp, base), big);
+  ops->bignum.arith(interp, FEATHER_BIG_ADD, *big, ops->integer.create(interp, digit), big);
+}
+
 // ============================================================================
 // Size modifiers for format and scan
 // ============================================================================
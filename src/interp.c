@@ -150,14 +150,15 @@ void feather_interp_init(const FeatherHostOps *ops, FeatherInterp interp) {
   FeatherObj traceNs = ops->string.intern(interp, "::tcl::trace", 12);
   ops->ns.create(interp, traceNs);
 
-  FeatherObj emptyDict = ops->dict.create(interp);
+  // Each kind gets its own dict: one shared between them would give every
+  // kind the traces added for any of them
   FeatherObj varName = ops->string.intern(interp, "variable", 8);
   FeatherObj cmdName = ops->string.intern(interp, "command", 7);
   FeatherObj execName = ops->string.intern(interp, "execution", 9);
 
-  ops->ns.set_var(interp, traceNs, varName, emptyDict);
-  ops->ns.set_var(interp, traceNs, cmdName, emptyDict);
-  ops->ns.set_var(interp, traceNs, execName, emptyDict);
+  ops->ns.set_var(interp, traceNs, varName, ops->dict.create(interp));
+  ops->ns.set_var(interp, traceNs, cmdName, ops->dict.create(interp));
+  ops->ns.set_var(interp, traceNs, execName, ops->dict.create(interp));
 
   // Create ::tcl::errors namespace and initialize error state variables
   FeatherObj errorsNs = ops->string.intern(interp, "::tcl::errors", 13);
@@ -193,3 +194,27 @@ void feather_trace_set_dict(const FeatherHostOps *ops, FeatherInterp interp,
   FeatherObj kindName = ops->string.intern(interp, kind, feather_strlen(kind));
   ops->ns.set_var(interp, traceNs, kindName, dict);
 }
+
+// Traces of each kind, variable, command and execution, are counted in the
+// interpreter's state. While a count is zero, commands and variable accesses
+// need not look up traces of that kind at all.
+static int trace_kind_index(const char *kind) {
+  switch (kind[0]) {
+  case 'v':
+    return 0;
+  case 'c':
+    return 1;
+  default:
+    return 2;
+  }
+}
+
+void feather_traces_count(const FeatherHostOps *ops, FeatherInterp interp,
+                          const char *kind, int delta) {
+  ops = feather_get_ops(ops);
+  ops->interp.state(interp)->traces[trace_kind_index(kind)] += delta;
+}
+
+int feather_traces_active(const FeatherHostOps *ops, FeatherInterp interp, const char *kind) {
+  return ops->interp.state(interp)->traces[trace_kind_index(kind)] > 0;
+}
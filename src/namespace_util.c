@@ -91,10 +91,13 @@ FeatherCommandType feather_lookup_command(const FeatherHostOps *ops, FeatherInte
       }
     }
   } else {
-    // Unqualified name - try current namespace first, then global
+    // Unqualified name - try current namespace first, then its path
     if (!inGlobalNs) {
       cmdType = ops->ns.get_command(interp, currentNs, name, fn, params, body);
     }
+    if (cmdType == TCL_CMD_NONE) {
+      cmdType = feather_ns_path_lookup(ops, interp, currentNs, name, NULL, fn, params, body);
+    }
 
     // If not found in current namespace, try global namespace
     if (cmdType == TCL_CMD_NONE) {
@@ -105,6 +108,106 @@ FeatherCommandType feather_lookup_command(const FeatherHostOps *ops, FeatherInte
   return cmdType;
 }
 
+// Command paths live in ::tcl::namespace::path, a dict mapping each
+// namespace that has one to the list of namespaces it searches.
+static FeatherObj ns_paths_all(const FeatherHostOps *ops, FeatherInterp interp) {
+  FeatherObj ns = ops->string.intern(interp, "::tcl::namespace", 16);
+  FeatherObj dict = ops->ns.get_var(interp, ns, ops->string.intern(interp, "path", 4));
+  if (dict == 0) {
+    dict = ops->dict.create(interp);
+  }
+  return dict;
+}
+
+static void ns_paths_store(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj all) {
+  FeatherObj ns = ops->string.intern(interp, "::tcl::namespace", 16);
+  ops->ns.create(interp, ns);
+  ops->ns.set_var(interp, ns, ops->string.intern(interp, "path", 4), all);
+  ops->interp.state(interp)->paths = ops->dict.size(interp, all);
+}
+
+FeatherObj feather_ns_path(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj ns) {
+  FeatherObj result = ops->list.create(interp);
+  if (ops->interp.state(interp)->paths == 0) {
+    return result;
+  }
+  FeatherObj path = ops->dict.get(interp, ns_paths_all(ops, interp), ns);
+  if (path == 0) {
+    return result;
+  }
+  // Namespaces deleted since the path was set drop out of it
+  size_t n = ops->list.length(interp, path);
+  for (size_t i = 0; i < n; i++) {
+    FeatherObj entry = ops->list.at(interp, path, i);
+    if (ops->ns.exists(interp, entry)) {
+      result = ops->list.push(interp, result, entry);
+    }
+  }
+  return result;
+}
+
+void feather_ns_set_path(const FeatherHostOps *ops, FeatherInterp interp,
+                         FeatherObj ns, FeatherObj path) {
+  FeatherObj all = ns_paths_all(ops, interp);
+  if (ops->list.length(interp, path) == 0) {
+    all = ops->dict.remove(interp, all, ns);
+  } else {
+    all = ops->dict.set(interp, all, ns, path);
+  }
+  ns_paths_store(ops, interp, all);
+}
+
+void feather_ns_forget_paths(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj ns) {
+  if (ops->interp.state(interp)->paths == 0) {
+    return;
+  }
+  FeatherObj all = ns_paths_all(ops, interp);
+  FeatherObj keys = ops->dict.keys(interp, all);
+  size_t ns_len = ops->string.byte_length(interp, ns);
+  size_t n = ops->list.length(interp, keys);
+  for (size_t i = 0; i < n; i++) {
+    FeatherObj key = ops->list.at(interp, keys, i);
+    size_t key_len = ops->string.byte_length(interp, key);
+    // The namespace itself and its children, ns::...
+    if (key_len == ns_len ||
+        (key_len > ns_len + 2 && ops->string.byte_at(interp, key, ns_len) == ':' &&
+         ops->string.byte_at(interp, key, ns_len + 1) == ':')) {
+      if (ops->string.equal(interp, ops->string.slice(interp, key, 0, ns_len), ns)) {
+        all = ops->dict.remove(interp, all, key);
+      }
+    }
+  }
+  ns_paths_store(ops, interp, all);
+}
+
+FeatherCommandType feather_ns_path_lookup(const FeatherHostOps *ops, FeatherInterp interp,
+                                          FeatherObj ns, FeatherObj name, FeatherObj *foundNs,
+                                          FeatherBuiltinCmd *fn, FeatherObj *params,
+                                          FeatherObj *body) {
+  if (ops->interp.state(interp)->paths == 0) {
+    return TCL_CMD_NONE;
+  }
+  FeatherObj path = ops->dict.get(interp, ns_paths_all(ops, interp), ns);
+  if (path == 0) {
+    return TCL_CMD_NONE;
+  }
+  size_t n = ops->list.length(interp, path);
+  for (size_t i = 0; i < n; i++) {
+    FeatherObj entry = ops->list.at(interp, path, i);
+    if (!ops->ns.exists(interp, entry)) {
+      continue;
+    }
+    FeatherCommandType cmdType = ops->ns.get_command(interp, entry, name, fn, params, body);
+    if (cmdType != TCL_CMD_NONE) {
+      if (foundNs != NULL) {
+        *foundNs = entry;
+      }
+      return cmdType;
+    }
+  }
+  return TCL_CMD_NONE;
+}
+
 int feather_proc_exists(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj name) {
   FeatherCommandType cmdType = feather_lookup_command(ops, interp, name, NULL, NULL, NULL);
   return cmdType == TCL_CMD_PROC;
@@ -154,6 +257,9 @@ FeatherResult feather_rename_command(const FeatherHostOps *ops, FeatherInterp in
   size_t newLen = ops->string.byte_length(interp, newName);
   if (newLen == 0) {
     // Just delete the old command
+    if (fn == feather_ensemble_dispatch) {
+      feather_ensemble_rename(ops, interp, oldName, newName);
+    }
     return ops->ns.delete_command(interp, oldNs, oldSimple);
   }
 
@@ -167,11 +273,20 @@ FeatherResult feather_rename_command(const FeatherHostOps *ops, FeatherInterp in
   // Create namespace if needed
   ops->ns.create(interp, newNs);
 
-  // Register command with new name
-  ops->ns.set_command(interp, newNs, newSimple, cmdType, fn, params, body);
+  // Register command with new name. Host commands have no builtin function
+  // and keep their implementation in the host's entry, so copy the entry.
+  if (cmdType == TCL_CMD_BUILTIN && fn == NULL) {
+    ops->ns.copy_command(interp, oldNs, oldSimple, newNs, newSimple);
+  } else {
+    ops->ns.set_command(interp, newNs, newSimple, cmdType, fn, params, body);
+  }
 
   // Delete old command
   ops->ns.delete_command(interp, oldNs, oldSimple);
 
+  if (fn == feather_ensemble_dispatch) {
+    feather_ensemble_rename(ops, interp, oldName, newName);
+  }
+
   return TCL_OK;
 }
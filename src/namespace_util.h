@@ -29,7 +29,8 @@ void feather_register_command(const FeatherHostOps *ops, FeatherInterp interp,
  *
  * Search order for unqualified names:
  *   1. Current namespace
- *   2. Global namespace
+ *   2. The namespaces on the current namespace's path, in order
+ *   3. Global namespace
  *
  * Qualified names are looked up directly in the specified namespace.
  */
@@ -37,6 +38,39 @@ FeatherCommandType feather_lookup_command(const FeatherHostOps *ops, FeatherInte
                                           FeatherObj name, FeatherBuiltinCmd *fn,
                                           FeatherObj *params, FeatherObj *body);
 
+/**
+ * feather_ns_path returns the command path of namespace ns, as set by
+ * namespace path, leaving out namespaces deleted since. A namespace
+ * without a path has an empty one.
+ */
+FeatherObj feather_ns_path(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj ns);
+
+/**
+ * feather_ns_set_path sets the command path of namespace ns to path,
+ * a list of fully qualified namespace names. An empty list removes it.
+ */
+void feather_ns_set_path(const FeatherHostOps *ops, FeatherInterp interp,
+                         FeatherObj ns, FeatherObj path);
+
+/**
+ * feather_ns_forget_paths removes the command paths of namespace ns and
+ * its children, for when they are deleted.
+ */
+void feather_ns_forget_paths(const FeatherHostOps *ops, FeatherInterp interp, FeatherObj ns);
+
+/**
+ * feather_ns_path_lookup looks up the unqualified command name in the
+ * namespaces on the path of ns, in order.
+ *
+ * Returns the command type, TCL_CMD_NONE if no namespace on the path has
+ * it. If foundNs is non-NULL, stores the namespace it was found in there.
+ * fn, params and body are filled in as for feather_lookup_command.
+ */
+FeatherCommandType feather_ns_path_lookup(const FeatherHostOps *ops, FeatherInterp interp,
+                                          FeatherObj ns, FeatherObj name, FeatherObj *foundNs,
+                                          FeatherBuiltinCmd *fn, FeatherObj *params,
+                                          FeatherObj *body);
+
 /**
  * feather_proc_exists checks if a proc exists with the given name.
  *
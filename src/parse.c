@@ -11,10 +11,10 @@ static int parse_is_whitespace(int c) {
 
 // Check if we're at a namespace separator (::) using object-based access
 static int is_namespace_sep_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                FeatherObj script, size_t pos, size_t len) {
+                                const FeatherBytes *src, size_t pos, size_t len) {
   if (pos + 1 >= len) return 0;
-  int c1 = ops->string.byte_at(interp, script, pos);
-  int c2 = ops->string.byte_at(interp, script, pos + 1);
+  int c1 = feather_bytes_at(ops, interp, src, pos);
+  int c2 = feather_bytes_at(ops, interp, src, pos + 1);
   return c1 == ':' && c2 == ':';
 }
 
@@ -26,14 +26,14 @@ static int is_namespace_sep_obj(const FeatherHostOps *ops, FeatherInterp interp,
  * Returns the number of bytes written via out_len.
  */
 static size_t process_backslash_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                     FeatherObj script, size_t pos, size_t len,
+                                     const FeatherBytes *src, size_t pos, size_t len,
                                      char *out_buf, size_t *out_len) {
   if (pos >= len) {
     *out_len = 0;
     return 0;
   }
 
-  int c = ops->string.byte_at(interp, script, pos);
+  int c = feather_bytes_at(ops, interp, src, pos);
 
   switch (c) {
     case 'a': *out_buf = '\a'; *out_len = 1; return 1;
@@ -48,7 +48,7 @@ static size_t process_backslash_obj(const FeatherHostOps *ops, FeatherInterp int
       // Backslash-newline: consume newline and following whitespace, produce space
       size_t consumed = 1;
       size_t p = pos + 1;
-      while (p < len && parse_is_whitespace(ops->string.byte_at(interp, script, p))) {
+      while (p < len && parse_is_whitespace(feather_bytes_at(ops, interp, src, p))) {
         p++;
         consumed++;
       }
@@ -63,7 +63,7 @@ static size_t process_backslash_obj(const FeatherHostOps *ops, FeatherInterp int
       size_t p = pos + 1;
       int digits = 0;
       while (p < len && digits < 2) {
-        int ch = ops->string.byte_at(interp, script, p);
+        int ch = feather_bytes_at(ops, interp, src, p);
         if (!feather_is_hex_digit(ch)) break;
         value = value * 16 + feather_hex_value(ch);
         p++;
@@ -87,7 +87,7 @@ static size_t process_backslash_obj(const FeatherHostOps *ops, FeatherInterp int
       size_t p = pos + 1;
       int digits = 0;
       while (p < len && digits < 4) {
-        int ch = ops->string.byte_at(interp, script, p);
+        int ch = feather_bytes_at(ops, interp, src, p);
         if (!feather_is_hex_digit(ch)) break;
         value = value * 16 + feather_hex_value(ch);
         p++;
@@ -122,7 +122,7 @@ static size_t process_backslash_obj(const FeatherHostOps *ops, FeatherInterp int
       size_t p = pos + 1;
       int digits = 0;
       while (p < len && digits < 8) {
-        int ch = ops->string.byte_at(interp, script, p);
+        int ch = feather_bytes_at(ops, interp, src, p);
         if (!feather_is_hex_digit(ch)) break;
         unsigned int new_val = value * 16 + feather_hex_value(ch);
         if (new_val > 0x10FFFF) break;
@@ -166,7 +166,7 @@ static size_t process_backslash_obj(const FeatherHostOps *ops, FeatherInterp int
         size_t p = pos + 1;
         int digits = 1;
         while (p < len && digits < 3) {
-          int ch = ops->string.byte_at(interp, script, p);
+          int ch = feather_bytes_at(ops, interp, src, p);
           if (!feather_is_octal_digit(ch)) break;
           int new_val = value * 8 + (ch - '0');
           if (new_val > 0377) break;
@@ -217,20 +217,30 @@ static FeatherObj append_literal_to_word(const FeatherHostOps *ops, FeatherInter
  * Find the matching close bracket for command substitution.
  * pos points to the character after the opening '['.
  * Returns the position of the matching ']', or len if not found.
+ * If newlines is not NULL, it receives the number of newlines skipped.
  */
 static size_t find_matching_bracket_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                         FeatherObj script, size_t pos, size_t len) {
+                                         const FeatherBytes *src, size_t pos, size_t len,
+                                         size_t *newlines) {
   int depth = 1;
+  size_t lines = 0;
 
   while (pos < len && depth > 0) {
-    int c = ops->string.byte_at(interp, script, pos);
+    int c = feather_bytes_at(ops, interp, src, pos);
 
     if (c == '\\' && pos + 1 < len) {
       // Skip escaped character
+      if (feather_bytes_at(ops, interp, src, pos + 1) == '\n') lines++;
       pos += 2;
       continue;
     }
 
+    if (c == '\n') {
+      lines++;
+      pos++;
+      continue;
+    }
+
     if (c == '[') {
       depth++;
       pos++;
@@ -240,6 +250,7 @@ static size_t find_matching_bracket_obj(const FeatherHostOps *ops, FeatherInterp
     if (c == ']') {
       depth--;
       if (depth == 0) {
+        if (newlines != NULL) *newlines = lines;
         return pos;
       }
       pos++;
@@ -251,11 +262,13 @@ static size_t find_matching_bracket_obj(const FeatherHostOps *ops, FeatherInterp
       int brace_depth = 1;
       pos++;
       while (pos < len && brace_depth > 0) {
-        int ch = ops->string.byte_at(interp, script, pos);
+        int ch = feather_bytes_at(ops, interp, src, pos);
         if (ch == '\\' && pos + 1 < len) {
+          if (feather_bytes_at(ops, interp, src, pos + 1) == '\n') lines++;
           pos += 2;
           continue;
         }
+        if (ch == '\n') lines++;
         if (ch == '{') brace_depth++;
         else if (ch == '}') brace_depth--;
         pos++;
@@ -267,12 +280,14 @@ static size_t find_matching_bracket_obj(const FeatherHostOps *ops, FeatherInterp
       // Skip quoted content
       pos++;
       while (pos < len) {
-        int ch = ops->string.byte_at(interp, script, pos);
+        int ch = feather_bytes_at(ops, interp, src, pos);
         if (ch == '"') break;
         if (ch == '\\' && pos + 1 < len) {
+          if (feather_bytes_at(ops, interp, src, pos + 1) == '\n') lines++;
           pos += 2;
           continue;
         }
+        if (ch == '\n') lines++;
         pos++;
       }
       if (pos < len) pos++; // skip closing quote
@@ -282,16 +297,18 @@ static size_t find_matching_bracket_obj(const FeatherHostOps *ops, FeatherInterp
     pos++;
   }
 
+  if (newlines != NULL) *newlines = lines;
   return (depth == 0) ? pos : len;
 }
 
 /**
  * Parse and substitute a variable starting at pos (after the $).
  * Uses object-based byte access.
+ * If check_only is set, the variable is not read and its text is kept as is.
  */
 static FeatherResult substitute_variable_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                              FeatherObj script, size_t len,
-                                              size_t pos, FeatherObj word,
+                                              const FeatherBytes *src, size_t len,
+                                              size_t pos, FeatherObj word, int check_only,
                                               FeatherObj *word_out, size_t *consumed_out) {
   if (pos >= len) {
     // Just a $ at end - treat as literal
@@ -300,13 +317,13 @@ static FeatherResult substitute_variable_obj(const FeatherHostOps *ops, FeatherI
     return TCL_OK;
   }
 
-  int c = ops->string.byte_at(interp, script, pos);
+  int c = feather_bytes_at(ops, interp, src, pos);
 
   if (c == '{') {
     // ${name} form - scan until closing brace
     size_t name_start = pos + 1;
     size_t p = name_start;
-    while (p < len && ops->string.byte_at(interp, script, p) != '}') {
+    while (p < len && feather_bytes_at(ops, interp, src, p) != '}') {
       p++;
     }
     if (p >= len) {
@@ -316,7 +333,12 @@ static FeatherResult substitute_variable_obj(const FeatherHostOps *ops, FeatherI
       return TCL_OK;
     }
     // Found closing brace
-    FeatherObj varName = ops->string.slice(interp, script, name_start, p);
+    if (check_only) {
+      *word_out = append_slice_to_word(ops, interp, word, src->obj, pos - 1, p + 1);
+      *consumed_out = (p - pos) + 1;
+      return TCL_OK;
+    }
+    FeatherObj varName = ops->string.slice(interp, src->obj, name_start, p);
 
     // feather_get_var handles qualified names and fires traces
     FeatherObj value;
@@ -341,21 +363,26 @@ static FeatherResult substitute_variable_obj(const FeatherHostOps *ops, FeatherI
     }
     *consumed_out = (p - pos) + 1; // +1 for closing brace
     return TCL_OK;
-  } else if (feather_is_varname_char(c) || is_namespace_sep_obj(ops, interp, script, pos, len)) {
+  } else if (feather_is_varname_char(c) || is_namespace_sep_obj(ops, interp, src, pos, len)) {
     // $name form - scan valid variable name characters
     size_t name_start = pos;
     size_t p = pos;
     while (p < len) {
-      int ch = ops->string.byte_at(interp, script, p);
+      int ch = feather_bytes_at(ops, interp, src, p);
       if (feather_is_varname_char(ch)) {
         p++;
-      } else if (is_namespace_sep_obj(ops, interp, script, p, len)) {
+      } else if (is_namespace_sep_obj(ops, interp, src, p, len)) {
         p += 2; // Skip both colons
       } else {
         break;
       }
     }
-    FeatherObj varName = ops->string.slice(interp, script, name_start, p);
+    if (check_only) {
+      *word_out = append_slice_to_word(ops, interp, word, src->obj, pos - 1, p);
+      *consumed_out = p - name_start;
+      return TCL_OK;
+    }
+    FeatherObj varName = ops->string.slice(interp, src->obj, name_start, p);
 
     // feather_get_var handles qualified names and fires traces
     FeatherObj value;
@@ -391,13 +418,18 @@ static FeatherResult substitute_variable_obj(const FeatherHostOps *ops, FeatherI
  * Parse and substitute a command starting at pos (after the [).
  * Returns the number of characters consumed (including the closing ]).
  * Returns (size_t)-1 on error.
+ * Advances ctx->line past the newlines inside the brackets.
+ * If ctx->check_only is set, the command is not evaluated and its text is
+ * kept as is.
  */
 static size_t substitute_command_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                      FeatherObj script, size_t scriptLen,
+                                      const FeatherBytes *src, size_t scriptLen,
+                                      FeatherParseContextObj *ctx,
                                       size_t pos, FeatherObj word,
                                       FeatherObj *word_out, FeatherParseStatus *status) {
   size_t bracket_start = pos - 1; // points to '['
-  size_t close = find_matching_bracket_obj(ops, interp, script, pos, scriptLen);
+  size_t newlines;
+  size_t close = find_matching_bracket_obj(ops, interp, src, pos, scriptLen, &newlines);
 
   if (close >= scriptLen) {
     // Unclosed bracket
@@ -413,9 +445,18 @@ static size_t substitute_command_obj(const FeatherHostOps *ops, FeatherInterp in
     return (size_t)-1;
   }
 
-  // Extract and evaluate the script between brackets
-  FeatherObj cmdScript = ops->string.slice(interp, script, pos, close);
+  if (ctx->check_only) {
+    ctx->line += newlines;
+    *word_out = append_slice_to_word(ops, interp, word, src->obj, bracket_start, close + 1);
+    return (close - pos) + 1;
+  }
+
+  // Extract and evaluate the script between brackets, numbering its lines
+  // from the line the bracket is on
+  FeatherObj cmdScript = ops->string.slice(interp, src->obj, pos, close);
+  ops->interp.set_source(interp, cmdScript, ctx->file, ctx->line);
   FeatherResult eval_result = feather_script_eval_obj(ops, interp, cmdScript, TCL_EVAL_LOCAL);
+  ctx->line += newlines;
 
   if (eval_result != TCL_OK) {
     *status = TCL_PARSE_ERROR;
@@ -447,36 +488,62 @@ void feather_parse_init_obj(FeatherParseContextObj *ctx, FeatherObj script, size
   ctx->pos = 0;
   ctx->line = 1;
   ctx->cmd_line = 1;
+  ctx->file = 0;
+  ctx->check_only = 0;
 }
 
 /**
  * Skip whitespace in list context (spaces, tabs, newlines).
  */
 static size_t skip_list_whitespace_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                        FeatherObj s, size_t len, size_t pos) {
+                                        const FeatherBytes *src, size_t len, size_t pos) {
   while (pos < len) {
-    int c = ops->string.byte_at(interp, s, pos);
+    int c = feather_bytes_at(ops, interp, src, pos);
     if (c != ' ' && c != '\t' && c != '\n') break;
     pos++;
   }
   return pos;
 }
 
+/**
+ * Check that a braced or quoted list element is followed by whitespace or
+ * the end of the list, as in "{a}b". On error sets a TCL-style message that
+ * quotes the offending characters.
+ */
+static FeatherResult check_list_element_end(const FeatherHostOps *ops, FeatherInterp interp,
+                                            const FeatherBytes *src, size_t len, size_t pos,
+                                            const char *kind) {
+  if (pos >= len || skip_list_whitespace_obj(ops, interp, src, len, pos) > pos) {
+    return TCL_OK;
+  }
+  size_t end = pos;
+  while (end < len && skip_list_whitespace_obj(ops, interp, src, len, end) == end) {
+    end++;
+  }
+  FeatherObj msg = ops->string.intern(interp, "list element in ", 16);
+  msg = ops->string.concat(interp, msg, ops->string.intern(interp, kind, feather_strlen(kind)));
+  msg = ops->string.concat(interp, msg, ops->string.intern(interp, " followed by \"", 14));
+  msg = ops->string.concat(interp, msg, ops->string.slice(interp, src->obj, pos, end));
+  msg = ops->string.concat(interp, msg, ops->string.intern(interp, "\" instead of space", 18));
+  ops->interp.set_result(interp, msg);
+  return TCL_ERROR;
+}
+
 /**
  * Parse a single element from a list string using object-based access.
  */
 static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                             FeatherObj s, size_t len, size_t *pos,
+                                             const FeatherBytes *src, size_t len, size_t *pos,
                                              FeatherObj *elem_out) {
   // Skip leading whitespace
-  *pos = skip_list_whitespace_obj(ops, interp, s, len, *pos);
+  *pos = skip_list_whitespace_obj(ops, interp, src, len, *pos);
 
   if (*pos >= len) {
     *elem_out = 0; // nil - no more elements
     return TCL_OK;
   }
 
-  int c = ops->string.byte_at(interp, s, *pos);
+  int c = feather_bytes_at(ops, interp, src, *pos);
   FeatherObj word = 0;
 
   if (c == '{') {
@@ -485,7 +552,7 @@ static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherIn
     size_t content_start = *pos + 1;
     (*pos)++;
     while (*pos < len && depth > 0) {
-      int ch = ops->string.byte_at(interp, s, *pos);
+      int ch = feather_bytes_at(ops, interp, src, *pos);
       if (ch == '\\' && *pos + 1 < len) {
         (*pos) += 2;
         continue;
@@ -501,25 +568,29 @@ static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherIn
       return TCL_ERROR;
     }
 
+    if (check_list_element_end(ops, interp, src, len, *pos, "braces") != TCL_OK) {
+      return TCL_ERROR;
+    }
+
     // Content is from content_start to pos-1 (before closing brace)
-    *elem_out = ops->string.slice(interp, s, content_start, *pos - 1);
+    *elem_out = ops->string.slice(interp, src->obj, content_start, *pos - 1);
     return TCL_OK;
   } else if (c == '"') {
     // Quoted element - process backslash escapes
     size_t seg_start = *pos + 1;
     (*pos)++;
     while (*pos < len) {
-      int ch = ops->string.byte_at(interp, s, *pos);
+      int ch = feather_bytes_at(ops, interp, src, *pos);
       if (ch == '"') break;
       if (ch == '\\' && *pos + 1 < len) {
         // Flush segment before backslash
         if (*pos > seg_start) {
-          word = append_slice_to_word(ops, interp, word, s, seg_start, *pos);
+          word = append_slice_to_word(ops, interp, word, src->obj, seg_start, *pos);
         }
         (*pos)++;
         char escape_buf[4];
         size_t escape_len;
-        size_t consumed = process_backslash_obj(ops, interp, s, *pos, len, escape_buf, &escape_len);
+        size_t consumed = process_backslash_obj(ops, interp, src, *pos, len, escape_buf, &escape_len);
         word = append_literal_to_word(ops, interp, word, escape_buf, escape_len);
         *pos += consumed;
         seg_start = *pos;
@@ -536,10 +607,14 @@ static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherIn
 
     // Flush remaining segment
     if (*pos > seg_start) {
-      word = append_slice_to_word(ops, interp, word, s, seg_start, *pos);
+      word = append_slice_to_word(ops, interp, word, src->obj, seg_start, *pos);
     }
     (*pos)++; // skip closing quote
 
+    if (check_list_element_end(ops, interp, src, len, *pos, "quotes") != TCL_OK) {
+      return TCL_ERROR;
+    }
+
     if (ops->list.is_nil(interp, word)) {
       word = ops->string.intern(interp, "", 0);
     }
@@ -549,17 +624,17 @@ static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherIn
     // Bare word - scan until whitespace, process backslashes
     size_t seg_start = *pos;
     while (*pos < len) {
-      int ch = ops->string.byte_at(interp, s, *pos);
+      int ch = feather_bytes_at(ops, interp, src, *pos);
       if (ch == ' ' || ch == '\t' || ch == '\n') break;
       if (ch == '\\' && *pos + 1 < len) {
         // Flush segment before backslash
         if (*pos > seg_start) {
-          word = append_slice_to_word(ops, interp, word, s, seg_start, *pos);
+          word = append_slice_to_word(ops, interp, word, src->obj, seg_start, *pos);
         }
         (*pos)++;
         char escape_buf[4];
         size_t escape_len;
-        size_t consumed = process_backslash_obj(ops, interp, s, *pos, len, escape_buf, &escape_len);
+        size_t consumed = process_backslash_obj(ops, interp, src, *pos, len, escape_buf, &escape_len);
         word = append_literal_to_word(ops, interp, word, escape_buf, escape_len);
         *pos += consumed;
         seg_start = *pos;
@@ -570,7 +645,7 @@ static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherIn
 
     // Flush remaining segment
     if (*pos > seg_start) {
-      word = append_slice_to_word(ops, interp, word, s, seg_start, *pos);
+      word = append_slice_to_word(ops, interp, word, src->obj, seg_start, *pos);
     }
 
     if (ops->list.is_nil(interp, word)) {
@@ -587,13 +662,15 @@ static FeatherResult parse_list_element_obj(const FeatherHostOps *ops, FeatherIn
 FeatherObj feather_list_parse_obj(const FeatherHostOps *ops, FeatherInterp interp,
                                    FeatherObj s) {
   ops = feather_get_ops(ops);
-  size_t len = ops->string.byte_length(interp, s);
+  FeatherBytes bytes = feather_bytes(ops, interp, s);
+  const FeatherBytes *src = &bytes;
+  size_t len = bytes.len;
   FeatherObj result = ops->list.create(interp);
   size_t pos = 0;
 
   while (pos < len) {
     FeatherObj elem;
-    FeatherResult status = parse_list_element_obj(ops, interp, s, len, &pos, &elem);
+    FeatherResult status = parse_list_element_obj(ops, interp, src, len, &pos, &elem);
     if (status != TCL_OK) {
       return 0;  // error already set in interp result
     }
@@ -606,17 +683,39 @@ FeatherObj feather_list_parse_obj(const FeatherHostOps *ops, FeatherInterp inter
   return result;
 }
 
+int64_t feather_list_error_index(const FeatherHostOps *ops, FeatherInterp interp,
+                                 FeatherObj s) {
+  ops = feather_get_ops(ops);
+  FeatherBytes bytes = feather_bytes(ops, interp, s);
+  const FeatherBytes *src = &bytes;
+  size_t len = bytes.len;
+  size_t pos = 0;
+
+  while (pos < len) {
+    size_t start = skip_list_whitespace_obj(ops, interp, src, len, pos);
+    FeatherObj elem;
+    if (parse_list_element_obj(ops, interp, src, len, &pos, &elem) != TCL_OK) {
+      return (int64_t)start;
+    }
+    if (ops->list.is_nil(interp, elem)) {
+      break;
+    }
+  }
+
+  return -1;
+}
+
 /**
  * Skip whitespace, backslash-newline continuations, and comments.
  * Updates ctx->line when encountering newlines.
  */
 static void skip_whitespace_and_comments_ctx(const FeatherHostOps *ops, FeatherInterp interp,
+                                              const FeatherBytes *src,
                                               FeatherParseContextObj *ctx) {
-  FeatherObj script = ctx->script;
   size_t len = ctx->len;
 
   while (ctx->pos < len) {
-    int c = ops->string.byte_at(interp, script, ctx->pos);
+    int c = feather_bytes_at(ops, interp, src, ctx->pos);
 
     // Skip whitespace
     if (parse_is_whitespace(c)) {
@@ -633,11 +732,11 @@ static void skip_whitespace_and_comments_ctx(const FeatherHostOps *ops, FeatherI
 
     // Skip backslash-newline continuation
     if (c == '\\' && ctx->pos + 1 < len) {
-      int c2 = ops->string.byte_at(interp, script, ctx->pos + 1);
+      int c2 = feather_bytes_at(ops, interp, src, ctx->pos + 1);
       if (c2 == '\n') {
         ctx->pos += 2;
         ctx->line++;
-        while (ctx->pos < len && parse_is_whitespace(ops->string.byte_at(interp, script, ctx->pos))) {
+        while (ctx->pos < len && parse_is_whitespace(feather_bytes_at(ops, interp, src, ctx->pos))) {
           ctx->pos++;
         }
         continue;
@@ -646,7 +745,7 @@ static void skip_whitespace_and_comments_ctx(const FeatherHostOps *ops, FeatherI
 
     // Skip comments (# at start of command)
     if (c == '#') {
-      while (ctx->pos < len && ops->string.byte_at(interp, script, ctx->pos) != '\n') {
+      while (ctx->pos < len && feather_bytes_at(ops, interp, src, ctx->pos) != '\n') {
         ctx->pos++;
       }
       if (ctx->pos < len) {
@@ -664,28 +763,31 @@ static void skip_whitespace_and_comments_ctx(const FeatherHostOps *ops, FeatherI
  * Parse a single word using object-based access.
  */
 static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp,
-                                  FeatherObj script, size_t len,
+                                  const FeatherBytes *src, size_t len,
                                   FeatherParseContextObj *ctx,
                                   FeatherParseStatus *status) {
   size_t p = ctx->pos;
   FeatherObj word = 0;
   size_t word_start = p;
 
-  while (p < len && !feather_is_word_terminator(ops->string.byte_at(interp, script, p))) {
-    int c = ops->string.byte_at(interp, script, p);
+  while (p < len && !feather_is_word_terminator(feather_bytes_at(ops, interp, src, p))) {
+    int c = feather_bytes_at(ops, interp, src, p);
 
-    if (c == '{') {
+    // Braces and quotes are only special at the start of a word, so that
+    // a{b}c and a"b are plain words
+    if (c == '{' && p == word_start) {
       // Braced string - no substitutions, content is literal
       int depth = 1;
       size_t brace_start = p;
+      size_t brace_line = ctx->line;
       size_t content_start = p + 1;
       p++;
       while (p < len && depth > 0) {
-        int ch = ops->string.byte_at(interp, script, p);
+        int ch = feather_bytes_at(ops, interp, src, p);
         if (ch == '\\' && p + 1 < len) {
           p++; // skip backslash
           // Check if the escaped character is a newline for line tracking
-          ch = ops->string.byte_at(interp, script, p);
+          ch = feather_bytes_at(ops, interp, src, p);
           if (ch == '\n') {
             ctx->line++;
           }
@@ -715,7 +817,7 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
       }
 
       // Check for extra characters after close brace
-      if (p < len && !feather_is_word_terminator(ops->string.byte_at(interp, script, p))) {
+      if (p < len && !feather_is_word_terminator(feather_bytes_at(ops, interp, src, p))) {
         FeatherObj result = ops->list.create(interp);
         FeatherObj error_tag = ops->string.intern(interp, "ERROR", 5);
         FeatherObj start_pos = ops->integer.create(interp, (int64_t)brace_start);
@@ -730,42 +832,48 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
         return 0;
       }
 
-      // Append braced content (literal, no substitution)
-      word = append_slice_to_word(ops, interp, word, script, content_start, p - 1);
+      // Append braced content (literal, no substitution). A word that is
+      // all braced content may be a script, so record where it starts.
+      if (ops->list.is_nil(interp, word) && content_start < p - 1) {
+        word = ops->string.slice(interp, src->obj, content_start, p - 1);
+        ops->interp.set_source(interp, word, ctx->file, brace_line);
+      } else {
+        word = append_slice_to_word(ops, interp, word, src->obj, content_start, p - 1);
+      }
 
-    } else if (c == '"') {
+    } else if (c == '"' && p == word_start) {
       // Double-quoted string
       size_t quote_start = p;
       p++; // skip opening quote
 
       size_t seg_start = p;
-      while (p < len && ops->string.byte_at(interp, script, p) != '"') {
-        int ch = ops->string.byte_at(interp, script, p);
+      while (p < len && feather_bytes_at(ops, interp, src, p) != '"') {
+        int ch = feather_bytes_at(ops, interp, src, p);
         if (ch == '\\' && p + 1 < len) {
           // Flush segment before backslash
           if (p > seg_start) {
-            word = append_slice_to_word(ops, interp, word, script, seg_start, p);
+            word = append_slice_to_word(ops, interp, word, src->obj, seg_start, p);
           }
           p++; // skip backslash
           // Check if backslash-newline for line tracking
-          int escaped = ops->string.byte_at(interp, script, p);
+          int escaped = feather_bytes_at(ops, interp, src, p);
           if (escaped == '\n') {
             ctx->line++;
           }
           char escape_buf[4];
           size_t escape_len;
-          size_t consumed = process_backslash_obj(ops, interp, script, p, len, escape_buf, &escape_len);
+          size_t consumed = process_backslash_obj(ops, interp, src, p, len, escape_buf, &escape_len);
           word = append_literal_to_word(ops, interp, word, escape_buf, escape_len);
           p += consumed;
           seg_start = p;
         } else if (ch == '$') {
           // Flush segment before $
           if (p > seg_start) {
-            word = append_slice_to_word(ops, interp, word, script, seg_start, p);
+            word = append_slice_to_word(ops, interp, word, src->obj, seg_start, p);
           }
           p++; // skip $
           size_t consumed;
-          if (substitute_variable_obj(ops, interp, script, len, p, word, &word, &consumed) != TCL_OK) {
+          if (substitute_variable_obj(ops, interp, src, len, p, word, ctx->check_only, &word, &consumed) != TCL_OK) {
             *status = TCL_PARSE_ERROR;
             return 0;
           }
@@ -774,10 +882,10 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
         } else if (ch == '[') {
           // Flush segment before [
           if (p > seg_start) {
-            word = append_slice_to_word(ops, interp, word, script, seg_start, p);
+            word = append_slice_to_word(ops, interp, word, src->obj, seg_start, p);
           }
           p++; // skip [
-          size_t consumed = substitute_command_obj(ops, interp, script, len, p, word, &word, status);
+          size_t consumed = substitute_command_obj(ops, interp, src, len, ctx, p, word, &word, status);
           if (consumed == (size_t)-1) {
             return 0;
           }
@@ -807,12 +915,12 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
 
       // Flush remaining segment
       if (p > seg_start) {
-        word = append_slice_to_word(ops, interp, word, script, seg_start, p);
+        word = append_slice_to_word(ops, interp, word, src->obj, seg_start, p);
       }
       p++; // skip closing quote
 
       // Check for extra characters after close quote
-      if (p < len && !feather_is_word_terminator(ops->string.byte_at(interp, script, p))) {
+      if (p < len && !feather_is_word_terminator(feather_bytes_at(ops, interp, src, p))) {
         FeatherObj result = ops->list.create(interp);
         FeatherObj error_tag = ops->string.intern(interp, "ERROR", 5);
         FeatherObj start_pos = ops->integer.create(interp, (int64_t)quote_start);
@@ -831,19 +939,19 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
       // Backslash in bare word
       p++; // skip backslash
       if (p < len) {
-        int ch = ops->string.byte_at(interp, script, p);
+        int ch = feather_bytes_at(ops, interp, src, p);
         if (ch == '\n') {
           // Backslash-newline in bare word acts as word terminator
           ctx->line++;
           p++;
-          while (p < len && parse_is_whitespace(ops->string.byte_at(interp, script, p))) {
+          while (p < len && parse_is_whitespace(feather_bytes_at(ops, interp, src, p))) {
             p++;
           }
           break;
         }
         char escape_buf[4];
         size_t escape_len;
-        size_t consumed = process_backslash_obj(ops, interp, script, p, len, escape_buf, &escape_len);
+        size_t consumed = process_backslash_obj(ops, interp, src, p, len, escape_buf, &escape_len);
         word = append_literal_to_word(ops, interp, word, escape_buf, escape_len);
         p += consumed;
       }
@@ -852,7 +960,7 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
       // Variable substitution in bare word
       p++; // skip $
       size_t consumed;
-      if (substitute_variable_obj(ops, interp, script, len, p, word, &word, &consumed) != TCL_OK) {
+      if (substitute_variable_obj(ops, interp, src, len, p, word, ctx->check_only, &word, &consumed) != TCL_OK) {
         *status = TCL_PARSE_ERROR;
         return 0;
       }
@@ -861,7 +969,7 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
     } else if (c == '[') {
       // Command substitution in bare word
       p++; // skip [
-      size_t consumed = substitute_command_obj(ops, interp, script, len, p, word, &word, status);
+      size_t consumed = substitute_command_obj(ops, interp, src, len, ctx, p, word, &word, status);
       if (consumed == (size_t)-1) {
         return 0;
       }
@@ -871,14 +979,14 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
       // Regular character in bare word - collect a run of them
       size_t seg_start = p;
       while (p < len) {
-        int ch = ops->string.byte_at(interp, script, p);
-        if (feather_is_word_terminator(ch) || ch == '{' || ch == '"' || ch == '\\' || ch == '$' || ch == '[') {
+        int ch = feather_bytes_at(ops, interp, src, p);
+        if (feather_is_word_terminator(ch) || ch == '\\' || ch == '$' || ch == '[') {
           break;
         }
         p++;
       }
       if (p > seg_start) {
-        word = append_slice_to_word(ops, interp, word, script, seg_start, p);
+        word = append_slice_to_word(ops, interp, word, src->obj, seg_start, p);
       }
     }
   }
@@ -900,24 +1008,26 @@ static FeatherObj parse_word_obj(const FeatherHostOps *ops, FeatherInterp interp
 FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
                                  FeatherObj str, int flags) {
   ops = feather_get_ops(ops);
-  size_t len = ops->string.byte_length(interp, str);
+  FeatherBytes bytes = feather_bytes(ops, interp, str);
+  const FeatherBytes *src = &bytes;
+  size_t len = bytes.len;
   size_t p = 0;
   FeatherObj result = 0;
   size_t seg_start = 0;
 
   while (p < len) {
-    int c = ops->string.byte_at(interp, str, p);
+    int c = feather_bytes_at(ops, interp, src, p);
 
     if (c == '\\' && (flags & TCL_SUBST_BACKSLASHES)) {
       // Flush segment before backslash
       if (p > seg_start) {
-        result = append_slice_to_word(ops, interp, result, str, seg_start, p);
+        result = append_slice_to_word(ops, interp, result, src->obj, seg_start, p);
       }
       p++;  // skip backslash
       if (p < len) {
         char escape_buf[4];
         size_t escape_len;
-        size_t consumed = process_backslash_obj(ops, interp, str, p, len, escape_buf, &escape_len);
+        size_t consumed = process_backslash_obj(ops, interp, src, p, len, escape_buf, &escape_len);
         result = append_literal_to_word(ops, interp, result, escape_buf, escape_len);
         p += consumed;
       }
@@ -926,11 +1036,11 @@ FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
     } else if (c == '$' && (flags & TCL_SUBST_VARIABLES)) {
       // Flush segment before $
       if (p > seg_start) {
-        result = append_slice_to_word(ops, interp, result, str, seg_start, p);
+        result = append_slice_to_word(ops, interp, result, src->obj, seg_start, p);
       }
       p++;  // skip $
       size_t consumed;
-      if (substitute_variable_obj(ops, interp, str, len, p, result, &result, &consumed) != TCL_OK) {
+      if (substitute_variable_obj(ops, interp, src, len, p, result, 0, &result, &consumed) != TCL_OK) {
         return TCL_ERROR;
       }
       p += consumed;
@@ -939,12 +1049,12 @@ FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
     } else if (c == '[' && (flags & TCL_SUBST_COMMANDS)) {
       // Flush segment before [
       if (p > seg_start) {
-        result = append_slice_to_word(ops, interp, result, str, seg_start, p);
+        result = append_slice_to_word(ops, interp, result, src->obj, seg_start, p);
       }
       p++;  // skip [
 
       // Find matching close bracket
-      size_t close = find_matching_bracket_obj(ops, interp, str, p, len);
+      size_t close = find_matching_bracket_obj(ops, interp, src, p, len, NULL);
       if (close >= len) {
         // Unclosed bracket - error
         FeatherObj msg = ops->string.intern(interp, "missing close-bracket", 21);
@@ -953,7 +1063,7 @@ FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
       }
 
       // Evaluate the command between brackets
-      FeatherObj cmdScript = ops->string.slice(interp, str, p, close);
+      FeatherObj cmdScript = ops->string.slice(interp, src->obj, p, close);
       FeatherResult eval_result = feather_script_eval_obj(ops, interp, cmdScript, TCL_EVAL_LOCAL);
       if (eval_result != TCL_OK) {
         return TCL_ERROR;
@@ -979,7 +1089,7 @@ FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
 
   // Flush remaining segment
   if (p > seg_start) {
-    result = append_slice_to_word(ops, interp, result, str, seg_start, p);
+    result = append_slice_to_word(ops, interp, result, src->obj, seg_start, p);
   }
 
   // Handle empty result
@@ -997,23 +1107,24 @@ FeatherResult feather_subst_obj(const FeatherHostOps *ops, FeatherInterp interp,
 FeatherParseStatus feather_parse_command_obj(const FeatherHostOps *ops, FeatherInterp interp,
                                               FeatherParseContextObj *ctx) {
   ops = feather_get_ops(ops);
-  FeatherObj script = ctx->script;
+  FeatherBytes bytes = feather_bytes(ops, interp, ctx->script);
+  const FeatherBytes *src = &bytes;
   size_t len = ctx->len;
 
   // Skip whitespace and comments between commands
-  skip_whitespace_and_comments_ctx(ops, interp, ctx);
+  skip_whitespace_and_comments_ctx(ops, interp, src, ctx);
 
   // Check if we've reached the end
   if (ctx->pos >= len) {
     return TCL_PARSE_DONE;
   }
 
-  int c = ops->string.byte_at(interp, script, ctx->pos);
+  int c = feather_bytes_at(ops, interp, src, ctx->pos);
 
   // Skip command terminator if we're at one (semicolon - newlines handled by skip_whitespace)
   if (c == ';') {
     ctx->pos++;
-    skip_whitespace_and_comments_ctx(ops, interp, ctx);
+    skip_whitespace_and_comments_ctx(ops, interp, src, ctx);
     if (ctx->pos >= len) {
       return TCL_PARSE_DONE;
     }
@@ -1022,19 +1133,9 @@ FeatherParseStatus feather_parse_command_obj(const FeatherHostOps *ops, FeatherI
   // Record the line where this command starts
   ctx->cmd_line = ctx->line;
 
-  // Set the line on frame 0 immediately, before any command substitutions
-  // happen during word parsing. Only set on the global frame, and only if
-  // this is the outermost eval (not a nested eval from command substitution).
-  // We detect nested eval by checking: if we're at line 1 but the frame
-  // already has a higher line number, we're in a nested eval context.
-  size_t currentLevel = ops->frame.level(interp);
-  if (currentLevel == 0) {
-    size_t existingLine = ops->frame.get_line(interp, 0);
-    // Only update if this is not a nested eval that would reset to line 1
-    if (ctx->cmd_line >= existingLine || existingLine == 0) {
-      ops->frame.set_line(interp, ctx->cmd_line);
-    }
-  }
+  // Set the frame's line before parsing the words, so that errors raised
+  // by substitutions point at this command
+  ops->frame.set_line(interp, ctx->cmd_line);
 
   // Create a list to hold the words
   FeatherObj words = ops->list.create(interp);
@@ -1042,20 +1143,20 @@ FeatherParseStatus feather_parse_command_obj(const FeatherHostOps *ops, FeatherI
   // Parse words until command terminator
   while (ctx->pos < len) {
     // Skip whitespace between words (but not command terminators)
-    while (ctx->pos < len && parse_is_whitespace(ops->string.byte_at(interp, script, ctx->pos))) {
+    while (ctx->pos < len && parse_is_whitespace(feather_bytes_at(ops, interp, src, ctx->pos))) {
       ctx->pos++;
     }
 
     // Also skip backslash-newline in whitespace context
     while (ctx->pos < len) {
-      int ch = ops->string.byte_at(interp, script, ctx->pos);
+      int ch = feather_bytes_at(ops, interp, src, ctx->pos);
       if (ch != '\\') break;
       if (ctx->pos + 1 >= len) break;
-      int ch2 = ops->string.byte_at(interp, script, ctx->pos + 1);
+      int ch2 = feather_bytes_at(ops, interp, src, ctx->pos + 1);
       if (ch2 != '\n') break;
       ctx->pos += 2;
       ctx->line++;  // Track newline in backslash continuation
-      while (ctx->pos < len && parse_is_whitespace(ops->string.byte_at(interp, script, ctx->pos))) {
+      while (ctx->pos < len && parse_is_whitespace(feather_bytes_at(ops, interp, src, ctx->pos))) {
         ctx->pos++;
       }
     }
@@ -1064,7 +1165,7 @@ FeatherParseStatus feather_parse_command_obj(const FeatherHostOps *ops, FeatherI
       break;
     }
 
-    c = ops->string.byte_at(interp, script, ctx->pos);
+    c = feather_bytes_at(ops, interp, src, ctx->pos);
 
     // Check for command terminator
     if (feather_is_command_terminator(c)) {
@@ -1082,11 +1183,11 @@ FeatherParseStatus feather_parse_command_obj(const FeatherHostOps *ops, FeatherI
     // Check for argument expansion {*}
     int is_expansion = 0;
     if (ctx->pos + 3 <= len) {
-      int c1 = ops->string.byte_at(interp, script, ctx->pos);
-      int c2 = ops->string.byte_at(interp, script, ctx->pos + 1);
-      int c3 = ops->string.byte_at(interp, script, ctx->pos + 2);
+      int c1 = feather_bytes_at(ops, interp, src, ctx->pos);
+      int c2 = feather_bytes_at(ops, interp, src, ctx->pos + 1);
+      int c3 = feather_bytes_at(ops, interp, src, ctx->pos + 2);
       if (c1 == '{' && c2 == '*' && c3 == '}' && ctx->pos + 3 < len) {
-        int c4 = ops->string.byte_at(interp, script, ctx->pos + 3);
+        int c4 = feather_bytes_at(ops, interp, src, ctx->pos + 3);
         if (!feather_is_word_terminator(c4)) {
           is_expansion = 1;
           ctx->pos += 3; // skip {*}
@@ -1096,7 +1197,7 @@ FeatherParseStatus feather_parse_command_obj(const FeatherHostOps *ops, FeatherI
 
     // Parse a word
     FeatherParseStatus status;
-    FeatherObj word = parse_word_obj(ops, interp, script, len, ctx, &status);
+    FeatherObj word = parse_word_obj(ops, interp, src, len, ctx, &status);
     if (status != TCL_PARSE_OK) {
       return status;
     }
@@ -25,6 +25,45 @@
 #define FEATHER_AT_END(ops, interp, obj, pos) \
     ((ops)->string.byte_at((interp), (obj), (pos)) < 0)
 
+/**
+ * FeatherBytes reads the bytes of a string object. When the host exposes
+ * its storage through ops->string.bytes, reads index that memory directly;
+ * otherwise each read goes through ops->string.byte_at.
+ *
+ * Use it for loops that scan a string byte by byte:
+ *   FeatherBytes src = feather_bytes(ops, interp, str);
+ *   for (size_t i = 0; i < src.len; i++) {
+ *     int ch = feather_bytes_at(ops, interp, &src, i);
+ *   }
+ */
+typedef struct {
+  FeatherObj obj;  /* the string object */
+  const char *buf; /* its bytes, NULL when reading through byte_at */
+  size_t len;      /* its length in bytes */
+} FeatherBytes;
+
+/* Start reading the bytes of obj */
+static inline FeatherBytes feather_bytes(const FeatherHostOps *ops,
+                                         FeatherInterp interp, FeatherObj obj) {
+  FeatherBytes b = {obj, NULL, 0};
+  if (ops->string.bytes != NULL) {
+    b.buf = ops->string.bytes(interp, obj, &b.len);
+  }
+  if (b.buf == NULL) {
+    b.len = ops->string.byte_length(interp, obj);
+  }
+  return b;
+}
+
+/* Get byte at position, -1 if past end */
+static inline int feather_bytes_at(const FeatherHostOps *ops, FeatherInterp interp,
+                                   const FeatherBytes *b, size_t pos) {
+  if (b->buf != NULL) {
+    return pos < b->len ? (unsigned char)b->buf[pos] : -1;
+  }
+  return ops->string.byte_at(interp, b->obj, pos);
+}
+
 /* Skip whitespace, return new position */
 static inline size_t feather_skip_whitespace(const FeatherHostOps *ops,
                                               FeatherInterp interp,
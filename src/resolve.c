@@ -4,8 +4,6 @@
 FeatherResult feather_obj_resolve_variable(const FeatherHostOps *ops, FeatherInterp interp,
                                            FeatherObj name,
                                            FeatherObj *ns_out, FeatherObj *local_out) {
-  size_t len = ops->string.byte_length(interp, name);
-
   // Check if qualified (contains "::")
   if (!feather_obj_is_qualified(ops, interp, name)) {
     // Case 1: Unqualified - just a local variable
@@ -13,6 +11,7 @@ FeatherResult feather_obj_resolve_variable(const FeatherHostOps *ops, FeatherInt
     *local_out = name;
     return TCL_OK;
   }
+  size_t len = ops->string.byte_length(interp, name);
 
   // Find the last "::" to split namespace and local name
   long last_sep = feather_obj_find_last_colons(ops, interp, name);
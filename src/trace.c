@@ -22,6 +22,15 @@ static int ops_contains(const FeatherHostOps *ops, FeatherInterp interp,
   return 0;
 }
 
+/**
+ * Reports the trace callback cmd, of the given kind, to the host and runs it.
+ */
+static FeatherResult run_trace(const FeatherHostOps *ops, FeatherInterp interp,
+                               const char *kind, FeatherObj cmd) {
+  ops->interp.trace_hook(interp, kind, cmd);
+  return feather_script_eval_obj(ops, interp, cmd, 0);
+}
+
 /**
  * feather_fire_var_traces fires variable traces for the given operation.
  *
@@ -40,7 +49,7 @@ static int ops_contains(const FeatherHostOps *ops, FeatherInterp interp,
  */
 FeatherResult feather_fire_var_traces(const FeatherHostOps *ops, FeatherInterp interp,
                                       FeatherObj varName, const char *op) {
-  if (trace_firing) return TCL_OK;
+  if (trace_firing || !feather_traces_active(ops, interp, "variable")) return TCL_OK;
   trace_firing = 1;
 
   FeatherResult result = TCL_OK;
@@ -76,7 +85,7 @@ FeatherResult feather_fire_var_traces(const FeatherHostOps *ops, FeatherInterp i
       cmd = ops->list.push(interp, cmd, opObj);
 
       // Execute the trace command
-      FeatherResult traceResult = feather_script_eval_obj(ops, interp, cmd, 0);
+      FeatherResult traceResult = run_trace(ops, interp, "variable", cmd);
 
       // For read/write traces, propagate errors (unset errors are ignored)
       if (traceResult == TCL_ERROR && !is_unset) {
@@ -125,7 +134,7 @@ FeatherResult feather_fire_var_traces(const FeatherHostOps *ops, FeatherInterp i
  */
 void feather_fire_cmd_traces(const FeatherHostOps *ops, FeatherInterp interp,
                              FeatherObj oldName, FeatherObj newName, const char *op) {
-  if (trace_firing) return;
+  if (trace_firing || !feather_traces_active(ops, interp, "command")) return;
   trace_firing = 1;
 
   // Save the current result so we can restore it if traces error
@@ -157,7 +166,7 @@ void feather_fire_cmd_traces(const FeatherHostOps *ops, FeatherInterp interp,
       cmd = ops->list.push(interp, cmd, opObj);
 
       // Execute the trace command (errors are ignored for command traces)
-      feather_script_eval_obj(ops, interp, cmd, 0);
+      run_trace(ops, interp, "command", cmd);
     }
   }
 
@@ -186,7 +195,7 @@ void feather_fire_cmd_traces(const FeatherHostOps *ops, FeatherInterp interp,
 FeatherResult feather_fire_exec_traces(const FeatherHostOps *ops, FeatherInterp interp,
                                        FeatherObj cmdName, FeatherObj cmdList,
                                        const char *op, int code, FeatherObj result) {
-  if (trace_firing) return TCL_OK;
+  if (trace_firing || !feather_traces_active(ops, interp, "execution")) return TCL_OK;
   trace_firing = 1;
 
   FeatherResult returnResult = TCL_OK;
@@ -245,7 +254,7 @@ FeatherResult feather_fire_exec_traces(const FeatherHostOps *ops, FeatherInterp
       cmd = ops->list.push(interp, cmd, opObj);
 
       // Execute the trace command
-      FeatherResult traceResult = feather_script_eval_obj(ops, interp, cmd, 0);
+      FeatherResult traceResult = run_trace(ops, interp, "execution", cmd);
 
       // Propagate errors directly
       if (traceResult == TCL_ERROR) {
@@ -268,6 +277,9 @@ FeatherResult feather_fire_exec_traces(const FeatherHostOps *ops, FeatherInterp
  */
 int feather_has_step_traces(const FeatherHostOps *ops, FeatherInterp interp,
                             FeatherObj cmdName) {
+  if (!feather_traces_active(ops, interp, "execution")) {
+    return 0;
+  }
   FeatherObj traceDict = feather_trace_get_dict(ops, interp, "execution");
   FeatherObj traces = ops->dict.get(interp, traceDict, cmdName);
 
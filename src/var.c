@@ -87,6 +87,8 @@ void feather_unset_var(const FeatherHostOps *ops, FeatherInterp interp,
   // Fire traces BEFORE unset (standard TCL behavior)
   // Note: unset trace errors are ignored
   feather_fire_var_traces(ops, interp, name, "unset");
+  int traced = feather_traces_active(ops, interp, "variable");
+  FeatherObj traceName = traced ? ops->var.resolve_link(interp, name) : 0;
 
   // Resolve qualified name
   FeatherObj ns, localName;
@@ -101,9 +103,17 @@ void feather_unset_var(const FeatherHostOps *ops, FeatherInterp interp,
   }
 
   // Remove all traces on this variable (TCL behavior)
+  if (!traced) {
+    return;
+  }
   FeatherObj traceDict = feather_trace_get_dict(ops, interp, "variable");
-  traceDict = ops->dict.remove(interp, traceDict, name);
+  FeatherObj traces = ops->dict.get(interp, traceDict, traceName);
+  if (ops->list.is_nil(interp, traces)) {
+    return;
+  }
+  traceDict = ops->dict.remove(interp, traceDict, traceName);
   feather_trace_set_dict(ops, interp, "variable", traceDict);
+  feather_traces_count(ops, interp, "variable", -(int)ops->list.length(interp, traces));
 }
 
 /**